@@ -1,37 +1,155 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/base64"
+	"expvar"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
+	kitexpvar "github.com/go-kit/kit/metrics/expvar"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/go-redis/redis/v8"
+	"github.com/nats-io/nats.go"
 
 	_ "github.com/go-sql-driver/mysql"
 
 	"google.golang.org/grpc"
 
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/attributes"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/avatar"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/cache"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/consent"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/encryption"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/group"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/idempotency"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/impersonation"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/invitation"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/organization"
 	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/preference"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/privacy"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/user"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/webhook"
 )
 
 func main() {
 
-	var db *sql.DB
+	var (
+		dbDSN             = flag.String("db.dsn", "root:PewDiePie8!!@tcp(127.0.0.1:3306)/test?parseTime=true", "MySQL data source name")
+		dbMaxOpenConns    = flag.Int("db.max-open-conns", 25, "maximum number of open connections to the database")
+		dbMaxIdleConns    = flag.Int("db.max-idle-conns", 25, "maximum number of idle connections kept in the pool")
+		dbConnMaxLifetime = flag.Duration("db.conn-max-lifetime", 5*time.Minute, "maximum amount of time a connection may be reused")
+		dbStartupDeadline = flag.Duration("db.startup-deadline", 30*time.Second, "how long to keep retrying the database at startup")
+		dbHealthInterval  = flag.Duration("db.health-interval", 10*time.Second, "interval between database health checks")
+		dbReplicaDSNs     = flag.String("db.replica-dsns", "", "comma separated MySQL data source names of the read replicas")
+	)
+	var (
+		cacheSize        = flag.Int("cache.size", 10000, "maximum number of users kept in the in-process cache, 0 disables it")
+		cacheTTL         = flag.Duration("cache.ttl", time.Minute, "how long a user stays cached")
+		cacheNegativeTTL = flag.Duration("cache.negative-ttl", 10*time.Second, "how long a missing user stays cached")
+		cacheRedisAddr   = flag.String("cache.redis-addr", "", "address of a Redis server to use instead of the in-process cache")
+	)
+	var (
+		keyfile             = flag.String("encryption.keyfile", "keys.json", "path of the keyfile holding the master and blind index keys")
+		keyRotationInterval = flag.Duration("encryption.rotation-interval", time.Hour, "interval between runs of the key rotation job")
+		keyRotationBatch    = flag.Int("encryption.rotation-batch", 500, "number of users rewrapped per key rotation batch")
+	)
+	var (
+		receiptKeyfile = flag.String("privacy.receipt-keyfile", "", "path of the file holding the base64 Ed25519 seed erasure receipts are signed with, a key is generated when empty")
+	)
+	var (
+		invitationKeyfile      = flag.String("invitation.token-keyfile", "", "path of the file holding the base64 HMAC key invitation links are signed with, a key is generated when empty")
+		invitationTTL          = flag.Duration("invitation.ttl", 7*24*time.Hour, "how long an invitation link can be accepted")
+		invitationLinkURL      = flag.String("invitation.link-url", "http://localhost:3000/accept-invitation", "page invitees accept their invitation on, the token is added as the token query parameter")
+		invitationNotifier     = flag.String("invitation.notifier", "log", "how invitation links are delivered: log or smtp")
+		invitationSMTPAddr     = flag.String("invitation.smtp-addr", "localhost:25", "SMTP server invitation links are mailed through")
+		invitationSMTPFrom     = flag.String("invitation.smtp-from", "no-reply@localhost", "sender of the invitation mails")
+		invitationSMTPUser     = flag.String("invitation.smtp-username", "", "username of the SMTP server, no authentication when empty")
+		invitationSMTPPassFile = flag.String("invitation.smtp-passwordfile", "", "path of the file holding the password of the SMTP server")
+	)
+	var (
+		impersonationKeyfile = flag.String("impersonation.token-keyfile", "", "path of the file holding the base64 HMAC key impersonation tokens are signed with, a key is generated when empty")
+		impersonationTTL     = flag.Duration("impersonation.ttl", 15*time.Minute, "how long an impersonation session lasts when the request doesn't say")
+		impersonationMaxTTL  = flag.Duration("impersonation.max-ttl", time.Hour, "longest impersonation session a request can ask for")
+	)
+	var (
+		apikeyTouchInterval = flag.Duration("apikeys.touch-interval", time.Minute, "how often the last use of an API key is written to the database, at most")
+	)
+	var (
+		avatarStore       = flag.String("avatar.store", "local", "where avatar thumbnails are kept: none, local or s3")
+		avatarDir         = flag.String("avatar.dir", "avatars", "directory avatar thumbnails are kept in with the local store")
+		avatarMaxBytes    = flag.Int64("avatar.max-bytes", 5<<20, "largest avatar picture accepted, in bytes")
+		avatarS3Endpoint  = flag.String("avatar.s3-endpoint", "https://s3.amazonaws.com", "endpoint of the S3 compatible server with the s3 store")
+		avatarS3Region    = flag.String("avatar.s3-region", "us-east-1", "region requests to the S3 server are signed for")
+		avatarS3Bucket    = flag.String("avatar.s3-bucket", "", "bucket avatar thumbnails are kept in with the s3 store")
+		avatarS3AccessKey = flag.String("avatar.s3-access-key", "", "access key id of the S3 server")
+		avatarS3SecretKey = flag.String("avatar.s3-secret-keyfile", "", "path of the file holding the secret access key of the S3 server")
+		avatarS3PathStyle = flag.Bool("avatar.s3-path-style", false, "address the bucket in the path instead of the host, as most self hosted S3 servers expect")
+	)
+	var (
+		tenantJWTSecretFile = flag.String("tenant.jwt-secretfile", "", "path of the file holding the HS256 secret of the tokens whose claim authenticates the tenant of a caller, tokens are ignored when empty")
+		tenantJWTClaim      = flag.String("tenant.jwt-claim", "tenant_id", "claim of the token holding the tenant")
+	)
+	var (
+		eventsPublisher     = flag.String("events.publisher", "none", "where outbox events are published besides webhooks: none, file or nats")
+		eventsFile          = flag.String("events.file", "events.ndjson", "file events are appended to with the file publisher")
+		eventsNATSURL       = flag.String("events.nats-url", nats.DefaultURL, "NATS server events are published to with the nats publisher")
+		eventsNATSPrefix    = flag.String("events.nats-prefix", "users", "subject prefix of the published events")
+		eventsRelayInterval = flag.Duration("events.relay-interval", time.Second, "interval between outbox relay runs")
+		eventsRelayBatch    = flag.Int("events.relay-batch", 100, "number of events relayed per batch")
+	)
+	var (
+		watchPollInterval      = flag.Duration("watch.poll-interval", 500*time.Millisecond, "interval between event log reads of a WatchUsers stream")
+		watchHeartbeatInterval = flag.Duration("watch.heartbeat-interval", 15*time.Second, "interval between heartbeats of an idle WatchUsers stream")
+	)
+	var (
+		idStrategy = flag.String("ids.strategy", ids.UUIDv7, "how the ids of new users are made: uuidv4, uuidv7, ulid or snowflake")
+		idNode     = flag.Int64("ids.node", 0, "node id of this instance in snowflake ids, unique among the instances sharing a database")
+	)
+	var (
+		importChunkSize = flag.Int("import.chunk-size", 500, "number of rows of an import committed together")
+		exportPageSize  = flag.Int("export.page-size", 1000, "number of users an export reads and sends at once")
+	)
+	var (
+		idempotencyTTL           = flag.Duration("idempotency.ttl", 24*time.Hour, "how long the response to a request sent with an idempotency key is replayed")
+		idempotencyLease         = flag.Duration("idempotency.lease", time.Minute, "how long a key stays claimed by a request that never finished")
+		idempotencyWait          = flag.Duration("idempotency.wait", 5*time.Second, "how long a retry waits for the request holding its key before failing")
+		idempotencyPurgeInterval = flag.Duration("idempotency.purge-interval", time.Hour, "interval between runs deleting expired idempotency keys")
+	)
 
-	db, err := sql.Open("mysql", "root:PewDiePie8!!@tcp(127.0.0.1:3306)/test?parseTime=true")
+	var (
+		webhookDispatchInterval = flag.Duration("webhooks.dispatch-interval", time.Second, "interval between webhook dispatch runs")
+		webhookTimeout          = flag.Duration("webhooks.timeout", 10*time.Second, "timeout of a single webhook delivery")
+		webhookMaxAttempts      = flag.Uint("webhooks.max-attempts", 8, "number of attempts made to deliver an event to a webhook")
+		webhookDisableAfter     = flag.Uint("webhooks.disable-after", 20, "consecutive failed attempts after which a webhook is disabled")
+	)
+	var (
+		debugAddr = flag.String("debug.addr", ":8081", "address serving expvar metrics under /debug/vars")
+	)
 
-	if err != nil {
-		fmt.Println("sd")
-	}
-
-	defer db.Close()
+	flag.Parse()
 
 	var logger log.Logger
 	{
@@ -44,12 +162,300 @@ func main() {
 		)
 	}
 
-	repo := user.NewSQL(db, logger)
+	keys, err := encryption.LoadKeyring(*keyfile)
+	if err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+
+	var receipts *privacy.Signer
+	if *receiptKeyfile != "" {
+		receipts, err = privacy.LoadSigner(*receiptKeyfile)
+	} else {
+		receipts, err = privacy.GenerateSigner()
+		level.Warn(logger).Log("msg", "no receipt keyfile, erasure receipts are signed with a key that is lost on restart")
+	}
+	if err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+	level.Info(logger).Log("msg", "erasure receipts signed", "key_id", receipts.KeyId(), "public_key", base64.StdEncoding.EncodeToString(receipts.PublicKey()))
+
+	var invitationTokens *invitation.Signer
+	if *invitationKeyfile != "" {
+		invitationTokens, err = invitation.LoadSigner(*invitationKeyfile)
+	} else {
+		invitationTokens, err = invitation.GenerateSigner()
+		level.Warn(logger).Log("msg", "no invitation token keyfile, invitation links stop working on restart")
+	}
+	if err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+
+	var impersonationTokens *impersonation.Signer
+	if *impersonationKeyfile != "" {
+		impersonationTokens, err = impersonation.LoadSigner(*impersonationKeyfile)
+	} else {
+		impersonationTokens, err = impersonation.GenerateSigner()
+		level.Warn(logger).Log("msg", "no impersonation token keyfile, impersonation sessions end on restart")
+	}
+	if err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+
+	var notifier invitation.Notifier
+	switch *invitationNotifier {
+	case "log":
+		notifier = invitation.NewLogNotifier(logger)
+	case "smtp":
+		var password []byte
+		if *invitationSMTPPassFile != "" {
+			content, err := ioutil.ReadFile(*invitationSMTPPassFile)
+			if err != nil {
+				level.Error(logger).Log("exit", err)
+				os.Exit(-1)
+			}
+			password = bytes.TrimSpace(content)
+		}
+		notifier = invitation.NewSMTPNotifier(*invitationSMTPAddr, *invitationSMTPFrom, *invitationSMTPUser, string(password))
+	default:
+		level.Error(logger).Log("exit", "unknown invitation notifier "+*invitationNotifier)
+		os.Exit(-1)
+	}
+
+	var avatars avatar.BlobStore
+	switch *avatarStore {
+	case "none":
+	case "local":
+		avatars, err = avatar.NewLocalStore(*avatarDir)
+	case "s3":
+		var secret []byte
+		if *avatarS3SecretKey != "" {
+			content, err := ioutil.ReadFile(*avatarS3SecretKey)
+			if err != nil {
+				level.Error(logger).Log("exit", err)
+				os.Exit(-1)
+			}
+			secret = bytes.TrimSpace(content)
+		}
+		avatars, err = avatar.NewS3Store(avatar.S3Config{
+			Endpoint:  *avatarS3Endpoint,
+			Region:    *avatarS3Region,
+			Bucket:    *avatarS3Bucket,
+			AccessKey: *avatarS3AccessKey,
+			SecretKey: string(secret),
+			PathStyle: *avatarS3PathStyle,
+		}, &http.Client{Timeout: 30 * time.Second})
+	default:
+		err = fmt.Errorf("unknown avatar store %s", *avatarStore)
+	}
+	if err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+
+	idGenerator, err := ids.New(*idStrategy, *idNode)
+	if err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+
+	var tenantSecret []byte
+	if *tenantJWTSecretFile != "" {
+		content, err := ioutil.ReadFile(*tenantJWTSecretFile)
+		if err != nil {
+			level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+		tenantSecret = bytes.TrimSpace(content)
+	}
+	tenants := tenant.NewResolver(tenantSecret, *tenantJWTClaim)
+
+	dbConfig := database.DefaultConfig()
+	dbConfig.DSN = *dbDSN
+	dbConfig.MaxOpenConns = *dbMaxOpenConns
+	dbConfig.MaxIdleConns = *dbMaxIdleConns
+	dbConfig.ConnMaxLifetime = *dbConnMaxLifetime
+	dbConfig.StartupDeadline = *dbStartupDeadline
+
+	db, err := database.Open(context.Background(), dbConfig, logger)
+	if err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+
+	defer db.Close()
+
+	healthSv := health.NewServer()
+	monitor := database.NewMonitor(db, *dbHealthInterval, logger)
+	monitor.OnChange = func(healthy bool) {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !healthy {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		healthSv.SetServingStatus("", status)
+	}
+
+	var replicaDBs []*sql.DB
+	for _, dsn := range strings.Split(*dbReplicaDSNs, ",") {
+		if dsn == "" {
+			continue
+		}
+		// Replicas that are down at startup are left to the health checks
+		// instead of blocking the service.
+		replicaDB, err := sql.Open("mysql", dsn)
+		if err != nil {
+			level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+		database.Configure(replicaDB, dbConfig)
+		replicaDBs = append(replicaDBs, replicaDB)
+	}
+
+	replicas := database.NewReplicaSet(replicaDBs, *dbHealthInterval, logger)
+	defer replicas.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go monitor.Run(ctx)
+	go replicas.Run(ctx)
+
+	expvar.Publish("db_stats", expvar.Func(func() interface{} { return db.Stats() }))
+
+	sqlRepo := user.NewReplicatedSQL(db, replicas, keys, logger)
+	// Users from before encryption can only be found by email once
+	// encrypted, which is done before taking any request.
+	if err := sqlRepo.EncryptLegacyUsers(ctx, *keyRotationBatch); err != nil {
+		level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+	go sqlRepo.RunKeyRotation(ctx, *keyRotationInterval, *keyRotationBatch)
+
+	var repo user.Repository = sqlRepo
+	{
+		var store cache.Store
+		switch {
+		case *cacheRedisAddr != "":
+			store = cache.NewRedis(redis.NewClient(&redis.Options{Addr: *cacheRedisAddr}), "grpcUserService:")
+		case *cacheSize > 0:
+			store = cache.NewLRU(*cacheSize)
+		}
+
+		if store != nil {
+			cacheConfig := user.CacheConfig{TTL: *cacheTTL, NegativeTTL: *cacheNegativeTTL}
+			hits := kitexpvar.NewCounter("user_cache_hits")
+			misses := kitexpvar.NewCounter("user_cache_misses")
+			repo = user.NewCachingRepository(repo, store, keys, cacheConfig, hits, misses, logger)
+		}
+	}
+
+	webhookRepo := webhook.NewSQL(db, keys, logger)
+	dispatcher := webhook.NewDispatcher(webhookRepo, webhook.NewClient(*webhookTimeout), logger)
+	dispatcher.Retry.MaxAttempts = uint32(*webhookMaxAttempts)
+	dispatcher.DisableAfter = uint32(*webhookDisableAfter)
+	go dispatcher.Run(ctx, *webhookDispatchInterval)
+
+	publisher := events.MultiPublisher{dispatcher}
+	switch *eventsPublisher {
+	case "file":
+		filePublisher, err := events.NewFilePublisher(*eventsFile)
+		if err != nil {
+			level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+		defer filePublisher.Close()
+		publisher = append(publisher, filePublisher)
+	case "nats":
+		conn, err := nats.Connect(*eventsNATSURL, nats.MaxReconnects(-1))
+		if err != nil {
+			level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+		defer conn.Close()
+		publisher = append(publisher, events.NewNATSPublisher(conn, *eventsNATSPrefix))
+	}
+
+	relay := events.NewRelay(db, publisher, *eventsRelayBatch, logger)
+	go relay.Run(ctx, *eventsRelayInterval)
+
 	srv := user.NewService(logger, repo)
+	srv.Watch.PollInterval = *watchPollInterval
+	srv.Watch.HeartbeatInterval = *watchHeartbeatInterval
+	srv.Bulk.ChunkSize = *importChunkSize
+	srv.Bulk.ExportPageSize = *exportPageSize
+	srv.Avatars.Blobs = avatars
+	srv.Avatars.MaxBytes = *avatarMaxBytes
+	srv.Ids = idGenerator
 
-	end := user.MakeEndpoint(srv)
+	guard := idempotency.NewGuard(idempotency.NewSQL(db, logger), keys, logger)
+	guard.TTL = *idempotencyTTL
+	guard.Lease = *idempotencyLease
+	guard.Wait = *idempotencyWait
+	go guard.Run(ctx, *idempotencyPurgeInterval, 1000)
+	srv.Idempotency = guard
+
+	auditRepo := audit.NewSQL(db, logger)
+	srv.Audit = auditRepo
+	srv.Receipts = receipts
+
+	attributeRepo := attributes.NewSQL(db, logger)
+	srv.Attributes = attributeRepo
+
+	consentRepo := consent.NewSQL(db, logger)
+	srv.Consents = consentRepo
+
+	invitationRepo := invitation.NewSQL(db, keys, logger)
+	srv.Invitations = invitationRepo
+
+	// Users, their groups and audit log are only reached through an
+	// organization, and organizations are managed by admins of an existing
+	// one.
+	orgRepo := organization.NewSQL(db, logger)
+	tenantScope := tenant.Middleware(orgRepo)
+
+	end := user.MakeEndpoint(srv).Wrap(tenantScope)
 	grpcSv := user.NewGrpcServer(end)
 
+	webhookSrv := webhook.NewService(logger, webhookRepo)
+	webhookSv := webhook.NewGrpcServer(webhook.MakeEndpoint(webhookSrv).Wrap(tenantScope))
+
+	auditSv := audit.NewGrpcServer(audit.MakeEndpoint(audit.NewService(logger, auditRepo)).Wrap(tenantScope))
+
+	orgSv := organization.NewGrpcServer(organization.MakeEndpoint(organization.NewService(logger, orgRepo)).Wrap(tenantScope))
+
+	groupRepo := group.NewSQL(db, logger)
+	groupSv := group.NewGrpcServer(group.MakeEndpoint(group.NewService(logger, groupRepo)).Wrap(tenantScope))
+
+	// Accepted invitations create their user through the user service.
+	invitationSrv := invitation.NewService(logger, invitationRepo, srv, groupRepo, invitationTokens, notifier, invitation.Config{
+		TTL:     *invitationTTL,
+		LinkURL: *invitationLinkURL,
+	})
+	invitationSv := invitation.NewGrpcServer(invitation.MakeEndpoint(invitationSrv).Wrap(tenantScope))
+
+	attributeSv := attributes.NewGrpcServer(attributes.MakeEndpoint(attributes.NewService(logger, attributeRepo)).Wrap(tenantScope))
+
+	consentSv := consent.NewGrpcServer(consent.MakeEndpoint(consent.NewService(logger, consentRepo)).Wrap(tenantScope))
+
+	preferenceSv := preference.NewGrpcServer(preference.MakeEndpoint(preference.NewService(logger, preference.NewSQL(db, logger))).Wrap(tenantScope))
+
+	// Requests carrying an impersonation token go through the guard, which
+	// audits each of them.
+	impersonationRepo := impersonation.NewSQL(db, logger)
+	impersonationGuard := impersonation.NewGuard(logger, impersonationRepo, auditRepo, impersonationTokens)
+	impersonationSv := impersonation.NewGrpcServer(impersonation.MakeEndpoint(impersonation.NewService(logger, impersonationRepo, auditRepo, impersonationTokens, impersonation.Config{
+		TTL:    *impersonationTTL,
+		MaxTTL: *impersonationMaxTTL,
+	})).Wrap(tenantScope))
+
+	// Requests carrying an API key are authenticated as the key, in its
+	// tenant, and only reach the methods its scopes allow.
+	apikeyRepo := apikey.NewSQL(db, logger)
+	apikeyAuthenticator := apikey.NewAuthenticator(logger, apikeyRepo, *apikeyTouchInterval)
+	apikeySv := apikey.NewGrpcServer(apikey.MakeEndpoint(apikey.NewService(logger, apikeyRepo)).Wrap(tenantScope))
+
 	errs := make(chan error)
 	go func() {
 		c := make(chan os.Signal, 1)
@@ -67,9 +473,28 @@ func main() {
 	defer grpcListener.Close()
 
 	go func() {
-		baseServer := grpc.NewServer()
+		level.Info(logger).Log("msg", "debug server started", "addr", *debugAddr)
+		errs <- http.ListenAndServe(*debugAddr, expvar.Handler())
+	}()
+
+	go func() {
+		baseServer := grpc.NewServer(
+			grpc.ChainUnaryInterceptor(audit.UnaryServerInterceptor, tenants.UnaryServerInterceptor, apikeyAuthenticator.UnaryServerInterceptor, impersonationGuard.UnaryServerInterceptor),
+			grpc.ChainStreamInterceptor(audit.StreamServerInterceptor, tenants.StreamServerInterceptor, apikeyAuthenticator.StreamServerInterceptor, impersonationGuard.StreamServerInterceptor),
+		)
 		reflection.Register(baseServer)
+		healthpb.RegisterHealthServer(baseServer, healthSv)
 		pb.RegisterUserServiceServer(baseServer, grpcSv)
+		pb.RegisterWebhookServiceServer(baseServer, webhookSv)
+		pb.RegisterAuditServiceServer(baseServer, auditSv)
+		pb.RegisterOrganizationServiceServer(baseServer, orgSv)
+		pb.RegisterGroupServiceServer(baseServer, groupSv)
+		pb.RegisterInvitationServiceServer(baseServer, invitationSv)
+		pb.RegisterAttributeSchemaServiceServer(baseServer, attributeSv)
+		pb.RegisterConsentServiceServer(baseServer, consentSv)
+		pb.RegisterPreferenceServiceServer(baseServer, preferenceSv)
+		pb.RegisterImpersonationServiceServer(baseServer, impersonationSv)
+		pb.RegisterApiKeyServiceServer(baseServer, apikeySv)
 		level.Info(logger).Log("msg", "Server started")
 		baseServer.Serve(grpcListener)
 	}()
@@ -0,0 +1,11 @@
+-- first_name and email hold envelope encrypted values, email_index the
+-- blind index used for lookups and uniqueness. Existing rows keep
+-- key_version 0 until the service encrypts them, which it does on start
+-- before serving.
+ALTER TABLE USER
+    MODIFY first_name VARCHAR(512) NOT NULL,
+    MODIFY email VARCHAR(512) NOT NULL,
+    ADD COLUMN email_index CHAR(64) NULL,
+    ADD COLUMN key_version INT UNSIGNED NOT NULL DEFAULT 0,
+    ADD UNIQUE INDEX user_email_index (email_index),
+    ADD INDEX user_key_version (key_version);
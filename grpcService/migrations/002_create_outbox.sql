@@ -0,0 +1,13 @@
+-- Events written in the same transaction as the user rows they describe,
+-- waiting to be published by the outbox relay.
+CREATE TABLE outbox (
+    seq BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    id CHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload BLOB NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    published_at TIMESTAMP(6) NULL,
+    UNIQUE INDEX outbox_id (id),
+    INDEX outbox_pending (published_at, seq)
+);
@@ -0,0 +1,31 @@
+-- Endpoints that receive user events. The secret is encrypted with the
+-- same keyring as user PII.
+CREATE TABLE webhooks (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(512) NOT NULL,
+    event_types VARCHAR(512) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+-- One row per delivery attempt, kept as the delivery log.
+CREATE TABLE webhook_deliveries (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    webhook_id CHAR(36) NOT NULL,
+    event_id CHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload BLOB NOT NULL,
+    attempt INT UNSIGNED NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    response_code INT NULL,
+    error VARCHAR(1024) NULL,
+    next_attempt_at TIMESTAMP(6) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    completed_at TIMESTAMP(6) NULL,
+    UNIQUE INDEX webhook_deliveries_attempt (webhook_id, event_id, attempt),
+    INDEX webhook_deliveries_due (status, next_attempt_at),
+    INDEX webhook_deliveries_log (webhook_id, created_at),
+    FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
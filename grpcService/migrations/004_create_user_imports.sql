@@ -0,0 +1,11 @@
+-- Progress of user imports. rows_committed counts every row of the file up
+-- to the last chunk committed, an upload sent again skips that many.
+CREATE TABLE user_imports (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    format VARCHAR(16) NOT NULL,
+    rows_committed BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    imported BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    failed BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6)
+);
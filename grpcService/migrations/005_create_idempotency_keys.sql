@@ -0,0 +1,16 @@
+-- Requests sent with an idempotency key. A row is in_flight while the first
+-- request holding the key runs, expires_at being its lease, and completed
+-- with the outcome replayed to retries until expires_at.
+CREATE TABLE idempotency_keys (
+    scope VARCHAR(64) NOT NULL,
+    idem_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(128) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    response BLOB NULL,
+    code INT UNSIGNED NOT NULL DEFAULT 0,
+    message VARCHAR(1024) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP(6) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (scope, idem_key),
+    INDEX idempotency_keys_expires_at (expires_at)
+);
@@ -0,0 +1,5 @@
+-- version goes up by one on every write to a user and backs the etags of
+-- the API, updated_at is set along with it by the repository.
+ALTER TABLE USER
+    ADD COLUMN version BIGINT UNSIGNED NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6);
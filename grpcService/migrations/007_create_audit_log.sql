@@ -0,0 +1,34 @@
+-- Append-only log of the writes to users. hash covers an entry and the
+-- hash of the one before it, audit_chain_head holds the hash of the last
+-- entry and is locked while appending so entries chain in seq order.
+CREATE TABLE audit_log (
+    seq BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    id CHAR(36) NOT NULL,
+    occurred_at TIMESTAMP(6) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    source_ip VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    operation VARCHAR(64) NOT NULL,
+    target_user_id VARCHAR(64) NOT NULL DEFAULT '',
+    diff TEXT NOT NULL,
+    outcome VARCHAR(32) NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    UNIQUE INDEX audit_log_id (id),
+    INDEX audit_log_occurred_at (occurred_at),
+    INDEX audit_log_actor (actor, occurred_at),
+    INDEX audit_log_target_user_id (target_user_id, occurred_at)
+);
+
+CREATE TABLE audit_chain_head (
+    id TINYINT UNSIGNED NOT NULL PRIMARY KEY,
+    hash CHAR(64) NOT NULL
+);
+
+INSERT INTO audit_chain_head (id, hash) VALUES (1, REPEAT('0', 64));
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
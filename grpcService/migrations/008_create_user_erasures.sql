@@ -0,0 +1,12 @@
+-- Erased users leave a tombstone holding their signed erasure receipt, so
+-- erasing them again returns the same receipt.
+CREATE TABLE user_erasures (
+    user_id VARCHAR(64) NOT NULL PRIMARY KEY,
+    receipt_id CHAR(36) NOT NULL,
+    erased_at TIMESTAMP(6) NOT NULL,
+    receipt TEXT NOT NULL,
+    UNIQUE INDEX user_erasures_receipt_id (receipt_id)
+);
+
+-- Data exports read the events of a single user.
+ALTER TABLE outbox ADD INDEX outbox_aggregate_id (aggregate_id, seq);
@@ -0,0 +1,39 @@
+-- Customer organizations. The id of an organization is the tenant its
+-- users, imports, erasures, events and audit entries are scoped to. Rows
+-- written before tenants existed belong to the default organization.
+CREATE TABLE organizations (
+    id VARCHAR(63) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+INSERT INTO organizations (id, name) VALUES ('default', 'Default');
+
+-- Emails are unique per tenant, the same person can be a user of several
+-- organizations. An organization can't be deleted while it has users.
+ALTER TABLE USER
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default',
+    DROP INDEX user_email_index,
+    ADD UNIQUE INDEX user_tenant_email_index (tenant_id, email_index),
+    ADD INDEX user_tenant_id (tenant_id, id),
+    ADD FOREIGN KEY (tenant_id) REFERENCES organizations (id);
+
+ALTER TABLE user_imports
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default',
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (tenant_id, id);
+
+ALTER TABLE user_erasures
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+
+-- Watch streams only read the events of their own tenant.
+ALTER TABLE outbox
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default',
+    ADD INDEX outbox_tenant_id (tenant_id, seq);
+
+ALTER TABLE audit_log
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default',
+    ADD INDEX audit_log_tenant_id (tenant_id, seq);
+
+-- Scopes are prefixed with the tenant.
+ALTER TABLE idempotency_keys MODIFY scope VARCHAR(128) NOT NULL;
@@ -0,0 +1,24 @@
+-- Teams users are organized into, within a tenant. Group names are unique
+-- per tenant.
+CREATE TABLE user_groups (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE INDEX user_groups_tenant_name (tenant_id, name),
+    INDEX user_groups_tenant_id (tenant_id, id),
+    FOREIGN KEY (tenant_id) REFERENCES organizations (id)
+);
+
+-- Members go with their group. Deleting a user deletes its memberships in
+-- the same transaction.
+CREATE TABLE group_members (
+    tenant_id VARCHAR(63) NOT NULL,
+    group_id CHAR(36) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    joined_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (group_id, user_id),
+    INDEX group_members_user_id (tenant_id, user_id),
+    FOREIGN KEY (group_id) REFERENCES user_groups (id) ON DELETE CASCADE
+);
@@ -0,0 +1,22 @@
+-- Invitations to join a tenant, accepted through a signed link. The email
+-- is encrypted with the same keyring as user PII. A pending invitation
+-- holds the blind index of its email, so an email has one pending
+-- invitation at a time; accepting or revoking it clears the index.
+CREATE TABLE invitations (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    email VARCHAR(512) NOT NULL,
+    pending_email_index CHAR(64) NULL,
+    roles VARCHAR(1024) NOT NULL DEFAULT '',
+    group_id VARCHAR(36) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL,
+    invited_by VARCHAR(255) NOT NULL DEFAULT '',
+    token_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    expires_at TIMESTAMP(6) NOT NULL,
+    accepted_at TIMESTAMP(6) NULL,
+    user_id VARCHAR(64) NOT NULL DEFAULT '',
+    UNIQUE INDEX invitations_pending_email (tenant_id, pending_email_index),
+    INDEX invitations_tenant_id (tenant_id, id),
+    FOREIGN KEY (tenant_id) REFERENCES organizations (id)
+);
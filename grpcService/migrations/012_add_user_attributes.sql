@@ -0,0 +1,24 @@
+-- Custom attributes of users, a JSON object checked against the attribute
+-- schema of their tenant. NULL stands for no attributes.
+ALTER TABLE USER
+    ADD COLUMN attributes JSON NULL;
+
+CREATE TABLE attribute_schemas (
+    tenant_id VARCHAR(63) NOT NULL PRIMARY KEY,
+    schema_json JSON NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (tenant_id) REFERENCES organizations (id)
+);
+
+-- The attributes a schema marks as indexed, one row per user and
+-- attribute, for ListUsers to filter on. The repository rewrites the rows
+-- of a user along with its attributes, and every row of a tenant when its
+-- schema changes. Deleting a user deletes its rows in the same transaction.
+CREATE TABLE user_attribute_index (
+    tenant_id VARCHAR(63) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    PRIMARY KEY (tenant_id, user_id, name),
+    INDEX user_attribute_index_value (tenant_id, name, value, user_id)
+);
@@ -0,0 +1,14 @@
+-- The avatar of a user. Its thumbnails are kept in a blob store under keys
+-- made of the tenant, the user, the hash of the uploaded picture and the
+-- size, sizes lists them comma separated. Deleting a user deletes its row
+-- in the same transaction, the service removes the thumbnails once it has
+-- committed.
+CREATE TABLE user_avatars (
+    tenant_id VARCHAR(63) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    hash CHAR(32) NOT NULL,
+    content_type VARCHAR(32) NOT NULL,
+    sizes VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL,
+    PRIMARY KEY (tenant_id, user_id)
+);
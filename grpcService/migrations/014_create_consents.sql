@@ -0,0 +1,30 @@
+-- Versions of the policy documents of a tenant. The current version of a
+-- kind is the last one published.
+CREATE TABLE policies (
+    tenant_id VARCHAR(63) NOT NULL,
+    kind VARCHAR(64) NOT NULL,
+    version VARCHAR(64) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    mandatory BOOLEAN NOT NULL,
+    published_at TIMESTAMP(6) NOT NULL,
+    PRIMARY KEY (tenant_id, kind, version),
+    INDEX policies_published_at (tenant_id, kind, published_at),
+    FOREIGN KEY (tenant_id) REFERENCES organizations (id)
+);
+
+-- Every policy a user accepted, and when and where it did. Rows are only
+-- ever added, except to record a withdrawal, and outlive the user: they
+-- prove the consent it gave.
+CREATE TABLE consents (
+    seq BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    kind VARCHAR(64) NOT NULL,
+    version VARCHAR(64) NOT NULL,
+    source VARCHAR(64) NOT NULL,
+    accepted_at TIMESTAMP(6) NOT NULL,
+    withdrawn_at TIMESTAMP(6) NULL,
+    withdrawn_from VARCHAR(64) NOT NULL DEFAULT '',
+    INDEX consents_user_id (tenant_id, user_id, kind, seq),
+    FOREIGN KEY (tenant_id) REFERENCES organizations (id)
+);
@@ -0,0 +1,10 @@
+-- The settings a user changed from their default, a JSON object. Settings
+-- it never set, or set back to their default with null, are not stored.
+-- Deleting a user deletes its row in the same transaction.
+CREATE TABLE user_preferences (
+    tenant_id VARCHAR(63) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    preferences JSON NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL,
+    PRIMARY KEY (tenant_id, user_id)
+);
@@ -0,0 +1,19 @@
+-- Sessions admins act as a user through. The token of a session is not
+-- kept, it is signed and checked against the row on every request.
+CREATE TABLE impersonation_sessions (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    reason VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    expires_at TIMESTAMP(6) NOT NULL,
+    ended_at TIMESTAMP(6) NULL,
+    INDEX impersonation_sessions_user_id (tenant_id, user_id, created_at),
+    FOREIGN KEY (tenant_id) REFERENCES organizations (id)
+);
+
+-- Entries written while an admin impersonates a user name the session.
+-- Empty for the others, which keeps the hashes of earlier entries valid.
+ALTER TABLE audit_log
+    ADD COLUMN impersonation_id CHAR(36) NOT NULL DEFAULT '';
@@ -0,0 +1,20 @@
+-- Keys services call the gRPC service with. Only the SHA-256 of a key is
+-- kept, its prefix finds it. A rotated key keeps working until expires_at,
+-- along with the key that replaced it.
+CREATE TABLE api_keys (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix CHAR(12) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    scopes VARCHAR(1024) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    expires_at TIMESTAMP(6) NULL,
+    revoked_at TIMESTAMP(6) NULL,
+    last_used_at TIMESTAMP(6) NULL,
+    replaced_by CHAR(36) NOT NULL DEFAULT '',
+    UNIQUE INDEX api_keys_prefix (prefix),
+    INDEX api_keys_tenant_id (tenant_id, created_at),
+    FOREIGN KEY (tenant_id) REFERENCES organizations (id)
+);
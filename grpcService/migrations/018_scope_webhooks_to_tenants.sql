@@ -0,0 +1,11 @@
+-- Webhooks receive the events of their own tenant only. Webhooks created
+-- before belong to the default organization, like the users they got the
+-- events of.
+ALTER TABLE webhooks
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default',
+    ADD INDEX webhooks_tenant_id (tenant_id, active, created_at),
+    ADD FOREIGN KEY (tenant_id) REFERENCES organizations (id);
+
+ALTER TABLE webhook_deliveries
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default',
+    ADD INDEX webhook_deliveries_tenant_id (tenant_id, webhook_id, created_at);
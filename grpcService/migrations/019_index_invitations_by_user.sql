@@ -0,0 +1,3 @@
+-- Erasing a user deletes the invitations it accepted, and its data export
+-- lists them.
+ALTER TABLE invitations ADD INDEX invitations_user_id (tenant_id, user_id);
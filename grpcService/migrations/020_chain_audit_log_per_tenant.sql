@@ -0,0 +1,14 @@
+-- Each tenant chains its audit entries on its own, so writers of different
+-- tenants don't wait on one another for the head. chain_id is the tenant
+-- whose chain holds an entry. Entries written before stay on the chain
+-- spanning every tenant, chain_id '', whose head is kept under that id.
+-- That chain is walked across tenants, so it isn't indexed by tenant.
+ALTER TABLE audit_log
+    ADD COLUMN chain_id VARCHAR(63) NOT NULL DEFAULT '',
+    ADD INDEX audit_log_chain (chain_id, seq);
+
+ALTER TABLE audit_chain_head
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT '' FIRST,
+    DROP PRIMARY KEY,
+    DROP COLUMN id,
+    ADD PRIMARY KEY (tenant_id);
@@ -0,0 +1,53 @@
+package apikey
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+// Header is the metadata key a key is sent in.
+const Header = "x-api-key"
+
+type secretKey struct{}
+
+type keyKey struct{}
+
+// WithSecret keeps the key a request was sent with, for a gateway to
+// forward.
+func WithSecret(ctx context.Context, secret string) context.Context {
+	return context.WithValue(ctx, secretKey{}, secret)
+}
+
+// ToOutgoingContext forwards the key carried by ctx to the gRPC server it
+// is used to call.
+func ToOutgoingContext(ctx context.Context) context.Context {
+	secret, ok := ctx.Value(secretKey{}).(string)
+	if !ok || secret == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, Header, secret)
+}
+
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(ToOutgoingContext(ctx), method, req, reply, cc, opts...)
+}
+
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(ToOutgoingContext(ctx), desc, cc, method, opts...)
+}
+
+// WithKey records the key the caller was authenticated with.
+func WithKey(ctx context.Context, key entities.ApiKey) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// FromContext returns the key the caller of ctx was authenticated with, if
+// it was.
+func FromContext(ctx context.Context) (entities.ApiKey, bool) {
+	key, ok := ctx.Value(keyKey{}).(entities.ApiKey)
+	return key, ok
+}
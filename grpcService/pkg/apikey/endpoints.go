@@ -0,0 +1,89 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	CreateApiKey(ctx context.Context, rq entities.CreateApiKeyRequest) (entities.CreateApiKeyResponse, error)
+	ListApiKeys(ctx context.Context, rq entities.ListApiKeysRequest) (entities.ListApiKeysResponse, error)
+	RevokeApiKey(ctx context.Context, rq entities.RevokeApiKeyRequest) (entities.RevokeApiKeyResponse, error)
+	RotateApiKey(ctx context.Context, rq entities.RotateApiKeyRequest) (entities.RotateApiKeyResponse, error)
+}
+
+type Endpoints struct {
+	CreateApiKey endpoint.Endpoint
+	ListApiKeys  endpoint.Endpoint
+	RevokeApiKey endpoint.Endpoint
+	RotateApiKey endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		CreateApiKey: MakeCreateApiKeyEndpoint(s),
+		ListApiKeys:  MakeListApiKeysEndpoint(s),
+		RevokeApiKey: MakeRevokeApiKeyEndpoint(s),
+		RotateApiKey: MakeRotateApiKeyEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		CreateApiKey: mw(e.CreateApiKey),
+		ListApiKeys:  mw(e.ListApiKeys),
+		RevokeApiKey: mw(e.RevokeApiKey),
+		RotateApiKey: mw(e.RotateApiKey),
+	}
+}
+
+func MakeCreateApiKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.CreateApiKeyRequest)
+		c, err := s.CreateApiKey(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListApiKeysEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListApiKeysRequest)
+		c, err := s.ListApiKeys(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeRevokeApiKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.RevokeApiKeyRequest)
+		c, err := s.RevokeApiKey(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeRotateApiKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.RotateApiKeyRequest)
+		c, err := s.RotateApiKey(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
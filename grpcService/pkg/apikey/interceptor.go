@@ -0,0 +1,144 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+// Authenticator checks the requests carrying a key: the key is known, not
+// revoked nor expired, and holds the scope the method requires. The caller
+// of those requests is the key, in the tenant of the key.
+type Authenticator struct {
+	Keys Repository
+	// TouchInterval is how often the last use of a key is recorded, at
+	// most.
+	TouchInterval time.Duration
+	Logger        log.Logger
+}
+
+func NewAuthenticator(l log.Logger, keys Repository, touchInterval time.Duration) *Authenticator {
+	return &Authenticator{keys, touchInterval, l}
+}
+
+// UnaryServerInterceptor goes after the interceptors reading the caller and
+// the tenant.
+func (a *Authenticator) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *Authenticator) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &keyStream{ss, ctx})
+}
+
+// authenticate returns ctx carrying the key of the request and its caller,
+// or ctx as it is when the request carries no key.
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	secret := first(md.Get(Header))
+	if secret == "" {
+		return ctx, nil
+	}
+
+	prefix, ok := Parse(secret)
+	if !ok {
+		return nil, errors.NewUnauthenticated("api key is malformed")
+	}
+
+	key, err := a.Keys.GetKeyByPrefix(ctx, prefix)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, errors.NewUnauthenticated("api key is invalid")
+	case err != nil && database.IsUnavailable(err):
+		return nil, errors.NewDataBaseUnavailable()
+	case err != nil:
+		return nil, errors.NewDataBaseError()
+	}
+	if !Matches(key.Hash, secret) {
+		return nil, errors.NewUnauthenticated("api key is invalid")
+	}
+
+	now := time.Now()
+	if !key.RevokedAt.IsZero() {
+		return nil, errors.NewUnauthenticated("api key was revoked")
+	}
+	if !key.ExpiresAt.IsZero() && !now.Before(key.ExpiresAt) {
+		return nil, errors.NewUnauthenticated("api key has expired")
+	}
+
+	scope, ok := Required[method]
+	if !ok {
+		return nil, errors.NewForbidden(path.Base(method) + " can't be called with an api key")
+	}
+	if !HasScope(key.Scopes, scope) {
+		return nil, errors.NewForbidden("api key lacks the " + scope + " scope")
+	}
+
+	// A key is only good for its tenant. The tenant middleware denies the
+	// requests sent for another.
+	if claim, ok := tenant.ClaimFromContext(ctx); ok && claim != key.TenantId {
+		return nil, errors.NewCrossTenantAccess()
+	}
+	ctx = tenant.WithClaim(ctx, key.TenantId)
+	if first(md.Get(tenant.Header)) == "" {
+		ctx = tenant.WithTenant(ctx, key.TenantId)
+	}
+
+	caller := audit.CallerFromContext(ctx)
+	caller.Actor = "apikey:" + key.Id
+	caller.Roles = ""
+	ctx = audit.WithCaller(ctx, caller)
+
+	a.touch(ctx, key, now)
+
+	return WithKey(ctx, key), nil
+}
+
+// touch records the use of key, unless one was recorded less than
+// TouchInterval ago. The request goes on anyway, an error recording it is
+// only logged.
+func (a *Authenticator) touch(ctx context.Context, key entities.ApiKey, now time.Time) {
+	since := now.Add(-a.TouchInterval)
+	if !key.LastUsedAt.IsZero() && key.LastUsedAt.After(since) {
+		return
+	}
+	if err := a.Keys.TouchKey(ctx, key.Id, now.UTC().Truncate(time.Microsecond), since.UTC()); err != nil {
+		level.Error(a.Logger).Log("msg", "recording the use of an api key failed", "error", err)
+	}
+}
+
+type keyStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *keyStream) Context() context.Context {
+	return s.ctx
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
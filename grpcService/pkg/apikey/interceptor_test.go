@@ -0,0 +1,217 @@
+package apikey_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+func TestAuthenticatorUnary(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	secret, prefix, hash, _ := apikey.Generate()
+	// The secret with its last character changed, whichever it was.
+	wrong := secret[:len(secret)-1] + "A"
+	if strings.HasSuffix(secret, "A") {
+		wrong = secret[:len(secret)-1] + "B"
+	}
+	now := time.Now().UTC()
+	active := entities.ApiKey{Id: keyId, TenantId: "acme", Prefix: prefix, Hash: hash, Scopes: []string{"users:read"}, CreatedAt: now.Add(-time.Hour)}
+
+	testCases := []struct {
+		Name           string
+		Metadata       metadata.MD
+		Claim          string
+		Method         string
+		buildMock      func(repo *utils.ApiKeyRepositoryMock)
+		assertResponse func(t *testing.T, ctx context.Context, called bool, err error)
+	}{
+		{
+			Name:   "Without Key",
+			Method: "/proto.UserService/DeleteUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, called)
+				_, ok := apikey.FromContext(ctx)
+				assert.False(t, ok)
+			},
+		},
+		{
+			Name:     "Scope Held",
+			Metadata: metadata.Pairs(apikey.Header, secret),
+			Method:   "/proto.UserService/GetUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("GetKeyByPrefix", mock.Anything, prefix).Return(active, nil)
+				repo.On("TouchKey", mock.Anything, keyId, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(nil)
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, called)
+				key, ok := apikey.FromContext(ctx)
+				assert.True(t, ok)
+				assert.Equal(t, keyId, key.Id)
+				assert.Equal(t, "apikey:"+keyId, audit.CallerFromContext(ctx).Actor)
+				assert.Empty(t, audit.CallerFromContext(ctx).Roles)
+				assert.Equal(t, "acme", tenant.FromContext(ctx))
+			},
+		},
+		{
+			Name:     "Used Recently",
+			Metadata: metadata.Pairs(apikey.Header, secret),
+			Method:   "/proto.UserService/GetUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				used := active
+				used.LastUsedAt = now
+				repo.On("GetKeyByPrefix", mock.Anything, prefix).Return(used, nil)
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, called)
+			},
+		},
+		{
+			Name:     "Scope Missing",
+			Metadata: metadata.Pairs(apikey.Header, secret),
+			Method:   "/proto.UserService/DeleteUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("GetKeyByPrefix", mock.Anything, prefix).Return(active, nil)
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.Equal(t, codes.PermissionDenied, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:     "Method Not Allowed",
+			Metadata: metadata.Pairs(apikey.Header, secret),
+			Method:   "/proto.ImpersonationService/Impersonate",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("GetKeyByPrefix", mock.Anything, prefix).Return(active, nil)
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.Equal(t, codes.PermissionDenied, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:     "Malformed Key",
+			Metadata: metadata.Pairs(apikey.Header, "not-a-key"),
+			Method:   "/proto.UserService/GetUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:     "Unknown Key",
+			Metadata: metadata.Pairs(apikey.Header, secret),
+			Method:   "/proto.UserService/GetUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("GetKeyByPrefix", mock.Anything, prefix).Return(entities.ApiKey{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:     "Wrong Secret",
+			Metadata: metadata.Pairs(apikey.Header, wrong),
+			Method:   "/proto.UserService/GetUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("GetKeyByPrefix", mock.Anything, prefix).Return(active, nil)
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:     "Revoked Key",
+			Metadata: metadata.Pairs(apikey.Header, secret),
+			Method:   "/proto.UserService/GetUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				revoked := active
+				revoked.RevokedAt = now
+				repo.On("GetKeyByPrefix", mock.Anything, prefix).Return(revoked, nil)
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:     "Expired Key",
+			Metadata: metadata.Pairs(apikey.Header, secret),
+			Method:   "/proto.UserService/GetUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				expired := active
+				expired.ExpiresAt = now.Add(-time.Second)
+				repo.On("GetKeyByPrefix", mock.Anything, prefix).Return(expired, nil)
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:     "Claim Of Another Tenant",
+			Metadata: metadata.Pairs(apikey.Header, secret),
+			Claim:    "globex",
+			Method:   "/proto.UserService/GetUser",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("GetKeyByPrefix", mock.Anything, prefix).Return(active, nil)
+			},
+			assertResponse: func(t *testing.T, ctx context.Context, called bool, err error) {
+				assert.Equal(t, codes.PermissionDenied, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ApiKeyRepositoryMock)
+			tc.buildMock(repo)
+
+			ctx := metadata.NewIncomingContext(context.Background(), tc.Metadata)
+			ctx = audit.WithCaller(ctx, audit.Caller{Actor: "someone", Roles: "admin"})
+			if tc.Claim != "" {
+				ctx = tenant.WithClaim(ctx, tc.Claim)
+			}
+
+			called := false
+			var handled context.Context
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				handled = ctx
+				return nil, nil
+			}
+
+			authenticator := apikey.NewAuthenticator(logger, repo, time.Minute)
+			_, err := authenticator.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.Method}, handler)
+			tc.assertResponse(t, handled, called, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package apikey lets services call the gRPC service with keys rather
+// than the credentials of a user. A key is limited to the methods its
+// scopes allow and only its hash is kept.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// keyPrefix starts every key, so leaked keys are easy to spot.
+const keyPrefix = "uk"
+
+// A key is keyPrefix, its lookup prefix and its secret joined by
+// underscores, like uk_1a2b3c4d5e6f_<43 base64 characters>.
+var keyFormat = regexp.MustCompile(`^` + keyPrefix + `_([0-9a-f]{12})_([A-Za-z0-9_-]{43})$`)
+
+// Generate makes a new key and returns it along with its prefix and the
+// hash to keep.
+func Generate() (key string, prefix string, hash string, err error) {
+	lookup := make([]byte, 6)
+	if _, err := rand.Read(lookup); err != nil {
+		return "", "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(lookup)
+	key = strings.Join([]string{keyPrefix, prefix, base64.RawURLEncoding.EncodeToString(secret)}, "_")
+	return key, prefix, Hash(key), nil
+}
+
+// Parse returns the prefix key is found by, if key is well formed.
+func Parse(key string) (string, bool) {
+	match := keyFormat.FindStringSubmatch(key)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// Hash is what is kept of key. Keys are random, a plain hash of them can't
+// be reversed.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches tells in constant time whether key is the one hash was made of.
+func Matches(hash string, key string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(Hash(key))) == 1
+}
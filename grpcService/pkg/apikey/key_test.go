@@ -0,0 +1,56 @@
+package apikey_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+)
+
+func TestKey(t *testing.T) {
+	key, prefix, hash, err := apikey.Generate()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "uk_"+prefix+"_"))
+	assert.NotContains(t, hash, prefix)
+
+	t.Run("Parse", func(t *testing.T) {
+		parsed, ok := apikey.Parse(key)
+		assert.True(t, ok)
+		assert.Equal(t, prefix, parsed)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, malformed := range []string{"", "uk_" + prefix, "xx" + key[2:], key + "a", strings.ToUpper(key)} {
+			_, ok := apikey.Parse(malformed)
+			assert.False(t, ok, malformed)
+		}
+	})
+
+	t.Run("Matches", func(t *testing.T) {
+		assert.True(t, apikey.Matches(hash, key))
+
+		other, _, _, _ := apikey.Generate()
+		assert.False(t, apikey.Matches(hash, other))
+	})
+
+	t.Run("Unique", func(t *testing.T) {
+		other, otherPrefix, _, _ := apikey.Generate()
+		assert.NotEqual(t, key, other)
+		assert.NotEqual(t, prefix, otherPrefix)
+	})
+}
+
+func TestScopes(t *testing.T) {
+	scopes := apikey.Scopes()
+	assert.Contains(t, scopes, "users:read")
+	assert.Contains(t, scopes, apikey.ManageScope)
+	assert.IsIncreasing(t, scopes)
+
+	assert.True(t, apikey.Known("groups:write"))
+	assert.False(t, apikey.Known("users:admin"))
+
+	_, ok := apikey.Required["/proto.UserService/Authenticate"]
+	assert.False(t, ok)
+}
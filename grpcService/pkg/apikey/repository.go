@@ -0,0 +1,159 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// Repository keeps the keys of the tenant carried by the context of each
+// call. Keys are found by prefix across tenants, a key names its tenant.
+type Repository interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateKey(ctx context.Context, key entities.ApiKey) error
+	// GetKeyByPrefix returns sql.ErrNoRows when no key has prefix.
+	GetKeyByPrefix(ctx context.Context, prefix string) (entities.ApiKey, error)
+	// LockKey locks a key until the transaction carried by ctx ends. It
+	// returns sql.ErrNoRows when there is no such key.
+	LockKey(ctx context.Context, id string) (entities.ApiKey, error)
+	ListKeys(ctx context.Context) ([]entities.ApiKey, error)
+	RevokeKey(ctx context.Context, id string, at time.Time) error
+	// RotateKey sets a key to expire, replaced by another.
+	RotateKey(ctx context.Context, id string, expiresAt time.Time, replacedBy string) error
+	// TouchKey records a use of a key, unless one after since was.
+	TouchKey(ctx context.Context, id string, at time.Time, since time.Time) error
+}
+
+type sqlRepo struct {
+	DB       *sql.DB
+	Logger   log.Logger
+	TxConfig database.TxConfig
+}
+
+func NewSQL(db *sql.DB, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, log, database.DefaultTxConfig()}
+}
+
+func (repo *sqlRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, repo.DB, repo.TxConfig, fn)
+}
+
+// conn returns the transaction in ctx, or the database when there is none.
+func (repo *sqlRepo) conn(ctx context.Context) database.Querier {
+	return database.Conn(ctx, repo.DB)
+}
+
+func (repo *sqlRepo) CreateKey(ctx context.Context, key entities.ApiKey) error {
+	var expiresAt sql.NullTime
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: key.ExpiresAt, Valid: true}
+	}
+
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.CreateApiKeyQuery,
+		key.Id, tenant.FromContext(ctx), key.Name, key.Prefix, key.Hash, strings.Join(key.Scopes, ","), key.CreatedBy, key.CreatedAt, expiresAt)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) GetKeyByPrefix(ctx context.Context, prefix string) (entities.ApiKey, error) {
+	key, err := scanKey(repo.conn(ctx).QueryRowContext(ctx, utils.GetApiKeyByPrefixQuery, prefix))
+	if err != nil && err != sql.ErrNoRows {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return key, err
+}
+
+func (repo *sqlRepo) LockKey(ctx context.Context, id string) (entities.ApiKey, error) {
+	key, err := scanKey(repo.conn(ctx).QueryRowContext(ctx, utils.LockApiKeyQuery, tenant.FromContext(ctx), id))
+	if err != nil && err != sql.ErrNoRows {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return key, err
+}
+
+func (repo *sqlRepo) ListKeys(ctx context.Context) ([]entities.ApiKey, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, utils.ListApiKeysQuery, tenant.FromContext(ctx))
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []entities.ApiKey{}
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (repo *sqlRepo) RevokeKey(ctx context.Context, id string, at time.Time) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.RevokeApiKeyQuery, at, tenant.FromContext(ctx), id)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) RotateKey(ctx context.Context, id string, expiresAt time.Time, replacedBy string) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.RotateApiKeyQuery, expiresAt, replacedBy, tenant.FromContext(ctx), id)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) TouchKey(ctx context.Context, id string, at time.Time, since time.Time) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.TouchApiKeyQuery, at, id, since)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanKey(row scanner) (entities.ApiKey, error) {
+	var (
+		key                              entities.ApiKey
+		scopes                           string
+		expiresAt, revokedAt, lastUsedAt sql.NullTime
+	)
+	err := row.Scan(&key.Id, &key.TenantId, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedBy, &key.CreatedAt,
+		&expiresAt, &revokedAt, &lastUsedAt, &key.ReplacedBy)
+	if err != nil {
+		return entities.ApiKey{}, err
+	}
+
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, ",")
+	}
+	key.ExpiresAt = expiresAt.Time
+	key.RevokedAt = revokedAt.Time
+	key.LastUsedAt = lastUsedAt.Time
+	return key, nil
+}
@@ -0,0 +1,108 @@
+package apikey
+
+import "sort"
+
+// ManageScope lets a key manage API keys, granting no more than the scopes
+// it holds.
+const ManageScope = "apikeys:manage"
+
+// Required is the scope a key needs to call each method. Methods missing,
+// like authenticating users or impersonating them, can't be called with a
+// key.
+var Required = map[string]string{
+	"/proto.UserService/CreateUser":           "users:write",
+	"/proto.UserService/GetUser":              "users:read",
+	"/proto.UserService/DeleteUser":           "users:write",
+	"/proto.UserService/BatchCreateUsers":     "users:write",
+	"/proto.UserService/BatchGetUsers":        "users:read",
+	"/proto.UserService/BatchDeleteUsers":     "users:write",
+	"/proto.UserService/WatchUsers":           "users:read",
+	"/proto.UserService/ImportUsers":          "users:write",
+	"/proto.UserService/ExportUsers":          "users:read",
+	"/proto.UserService/ExportUserData":       "users:read",
+	"/proto.UserService/EraseUser":            "users:write",
+	"/proto.UserService/UpdateUserAttributes": "users:write",
+	"/proto.UserService/ListUsers":            "users:read",
+	"/proto.UserService/UploadAvatar":         "users:write",
+	"/proto.UserService/GetAvatar":            "users:read",
+
+	"/proto.OrganizationService/CreateOrganization": "organizations:write",
+	"/proto.OrganizationService/GetOrganization":    "organizations:read",
+	"/proto.OrganizationService/ListOrganizations":  "organizations:read",
+	"/proto.OrganizationService/UpdateOrganization": "organizations:write",
+	"/proto.OrganizationService/DeleteOrganization": "organizations:write",
+
+	"/proto.GroupService/CreateGroup":    "groups:write",
+	"/proto.GroupService/RenameGroup":    "groups:write",
+	"/proto.GroupService/DeleteGroup":    "groups:write",
+	"/proto.GroupService/ListGroups":     "groups:read",
+	"/proto.GroupService/AddMember":      "groups:write",
+	"/proto.GroupService/RemoveMember":   "groups:write",
+	"/proto.GroupService/ListMembers":    "groups:read",
+	"/proto.GroupService/ListUserGroups": "groups:read",
+
+	"/proto.InvitationService/InviteUser":       "invitations:write",
+	"/proto.InvitationService/ListInvitations":  "invitations:read",
+	"/proto.InvitationService/RevokeInvitation": "invitations:write",
+	"/proto.InvitationService/ResendInvitation": "invitations:write",
+
+	"/proto.AttributeSchemaService/GetAttributeSchema": "schema:read",
+	"/proto.AttributeSchemaService/SetAttributeSchema": "schema:write",
+
+	"/proto.ConsentService/PublishPolicy":   "consents:write",
+	"/proto.ConsentService/ListPolicies":    "consents:read",
+	"/proto.ConsentService/AcceptPolicy":    "consents:write",
+	"/proto.ConsentService/ListConsents":    "consents:read",
+	"/proto.ConsentService/WithdrawConsent": "consents:write",
+
+	"/proto.PreferenceService/GetPreferences":    "preferences:read",
+	"/proto.PreferenceService/UpdatePreferences": "preferences:write",
+
+	"/proto.AuditService/ListAuditEvents": "audit:read",
+	"/proto.AuditService/VerifyAuditLog":  "audit:read",
+
+	"/proto.WebhookService/CreateWebhook":         "webhooks:write",
+	"/proto.WebhookService/ListWebhooks":          "webhooks:read",
+	"/proto.WebhookService/DeleteWebhook":         "webhooks:write",
+	"/proto.WebhookService/ListWebhookDeliveries": "webhooks:read",
+	"/proto.WebhookService/RedeliverWebhook":      "webhooks:write",
+
+	"/proto.ApiKeyService/CreateApiKey": ManageScope,
+	"/proto.ApiKeyService/ListApiKeys":  ManageScope,
+	"/proto.ApiKeyService/RevokeApiKey": ManageScope,
+	"/proto.ApiKeyService/RotateApiKey": ManageScope,
+}
+
+// Scopes returns every scope a key can be granted, in order.
+func Scopes() []string {
+	seen := map[string]bool{}
+	var scopes []string
+	for _, scope := range Required {
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// Known tells whether scope can be granted.
+func Known(scope string) bool {
+	for _, s := range Required {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope tells whether scopes hold scope.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
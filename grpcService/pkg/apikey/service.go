@@ -0,0 +1,281 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+const (
+	maxNameLength = 100
+	// defaultOverlap is how long a rotated key keeps working when the
+	// request doesn't say.
+	defaultOverlap = 24 * time.Hour
+	maxOverlap     = 7 * 24 * time.Hour
+)
+
+type service struct {
+	Repo   Repository
+	Logger log.Logger
+}
+
+func NewService(l log.Logger, r Repository) *service {
+	return &service{r, l}
+}
+
+// CreateApiKey returns the new key along with its secret, which is not
+// kept and can't be read back.
+func (s *service) CreateApiKey(ctx context.Context, rq entities.CreateApiKeyRequest) (entities.CreateApiKeyResponse, error) {
+	s.Logger.Log("request", "create api key", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.CreateApiKeyResponse{}, err
+	}
+
+	name := strings.TrimSpace(rq.Name)
+	if name == "" {
+		return entities.CreateApiKeyResponse{}, errors.NewInvalidField("name", "is required")
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return entities.CreateApiKeyResponse{}, errors.NewInvalidField("name", "at most 100 characters are allowed")
+	}
+
+	scopes, err := grantable(ctx, rq.Scopes)
+	if err != nil {
+		return entities.CreateApiKeyResponse{}, err
+	}
+
+	// The database keeps microseconds.
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	key := entities.ApiKey{
+		Name:      name,
+		Scopes:    scopes,
+		CreatedBy: audit.CallerFromContext(ctx).Actor,
+		CreatedAt: now,
+	}
+	if rq.TtlSeconds != 0 {
+		key.ExpiresAt = now.Add(time.Duration(rq.TtlSeconds) * time.Second)
+	}
+
+	key, secret, err := s.create(ctx, key)
+	if err != nil {
+		return entities.CreateApiKeyResponse{}, s.mapError(err)
+	}
+
+	return entities.CreateApiKeyResponse{Key: key, Secret: secret}, nil
+}
+
+func (s *service) ListApiKeys(ctx context.Context, rq entities.ListApiKeysRequest) (entities.ListApiKeysResponse, error) {
+	s.Logger.Log("request", "list api keys", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.ListApiKeysResponse{}, err
+	}
+
+	keys, err := s.Repo.ListKeys(ctx)
+	if err != nil {
+		return entities.ListApiKeysResponse{}, s.mapError(err)
+	}
+
+	return entities.ListApiKeysResponse{Keys: keys}, nil
+}
+
+// RevokeApiKey stops a key from working at once. Revoking a key already
+// revoked changes nothing.
+func (s *service) RevokeApiKey(ctx context.Context, rq entities.RevokeApiKeyRequest) (entities.RevokeApiKeyResponse, error) {
+	s.Logger.Log("request", "revoke api key", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.RevokeApiKeyResponse{}, err
+	}
+
+	var key entities.ApiKey
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		key, err = s.lock(ctx, rq.KeyId)
+		if err != nil {
+			return err
+		}
+		if !key.RevokedAt.IsZero() {
+			return nil
+		}
+
+		key.RevokedAt = time.Now().UTC().Truncate(time.Microsecond)
+		return s.Repo.RevokeKey(ctx, key.Id, key.RevokedAt)
+	})
+	if err != nil {
+		return entities.RevokeApiKeyResponse{}, s.mapError(err)
+	}
+
+	return entities.RevokeApiKeyResponse{Key: key}, nil
+}
+
+// RotateApiKey replaces a key with a new one with the same name and
+// scopes, and lasting as long. The rotated key keeps working until the end
+// of the overlap window, or until it expires if that comes first.
+func (s *service) RotateApiKey(ctx context.Context, rq entities.RotateApiKeyRequest) (entities.RotateApiKeyResponse, error) {
+	s.Logger.Log("request", "rotate api key", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.RotateApiKeyResponse{}, err
+	}
+
+	overlap := defaultOverlap
+	if rq.OverlapSeconds != 0 {
+		overlap = time.Duration(rq.OverlapSeconds) * time.Second
+	}
+	if overlap > maxOverlap {
+		return entities.RotateApiKeyResponse{}, errors.NewInvalidField("overlap_seconds", "must be at most 7 days")
+	}
+
+	var res entities.RotateApiKeyResponse
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		previous, err := s.lock(ctx, rq.KeyId)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		switch {
+		case !previous.RevokedAt.IsZero():
+			return errors.NewPreconditionFailed("revoked keys can't be rotated")
+		case previous.ReplacedBy != "":
+			return errors.NewPreconditionFailed("the key was rotated already")
+		case !previous.ExpiresAt.IsZero() && !now.Before(previous.ExpiresAt):
+			return errors.NewPreconditionFailed("expired keys can't be rotated")
+		}
+		// A key holding the ManageScope can't keep the scopes it couldn't
+		// grant alive.
+		if _, err := grantable(ctx, previous.Scopes); err != nil {
+			return err
+		}
+
+		key := entities.ApiKey{
+			Name:      previous.Name,
+			Scopes:    previous.Scopes,
+			CreatedBy: audit.CallerFromContext(ctx).Actor,
+			CreatedAt: now,
+		}
+		if !previous.ExpiresAt.IsZero() {
+			key.ExpiresAt = now.Add(previous.ExpiresAt.Sub(previous.CreatedAt))
+		}
+
+		key, secret, err := s.create(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if previous.ExpiresAt.IsZero() || now.Add(overlap).Before(previous.ExpiresAt) {
+			previous.ExpiresAt = now.Add(overlap)
+		}
+		previous.ReplacedBy = key.Id
+		if err := s.Repo.RotateKey(ctx, previous.Id, previous.ExpiresAt, key.Id); err != nil {
+			return err
+		}
+
+		res = entities.RotateApiKeyResponse{Key: key, Secret: secret, Previous: previous}
+		return nil
+	})
+	if err != nil {
+		return entities.RotateApiKeyResponse{}, s.mapError(err)
+	}
+
+	return res, nil
+}
+
+// create saves key with a new secret, drawing another when its prefix is
+// taken already.
+func (s *service) create(ctx context.Context, key entities.ApiKey) (entities.ApiKey, string, error) {
+	key.Id = uuid.NewString()
+	for attempt := 0; ; attempt++ {
+		secret, prefix, hash, err := Generate()
+		if err != nil {
+			return entities.ApiKey{}, "", err
+		}
+		key.Prefix, key.Hash = prefix, hash
+
+		err = s.Repo.CreateKey(ctx, key)
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 && attempt < 2 {
+			continue
+		}
+		if err != nil {
+			return entities.ApiKey{}, "", err
+		}
+		return key, secret, nil
+	}
+}
+
+func (s *service) lock(ctx context.Context, id string) (entities.ApiKey, error) {
+	key, err := s.Repo.LockKey(ctx, id)
+	if err == sql.ErrNoRows {
+		return entities.ApiKey{}, errors.NewResourceNotFound("api key")
+	}
+	return key, err
+}
+
+// authorize lets admins through, and callers authenticated with a key,
+// which the interceptor checked holds the ManageScope.
+func authorize(ctx context.Context) error {
+	if _, ok := FromContext(ctx); ok {
+		return nil
+	}
+	if audit.CallerFromContext(ctx).IsAdmin() {
+		return nil
+	}
+	return errors.NewForbidden("only admins can manage api keys")
+}
+
+// grantable checks scopes can be granted by the caller of ctx and returns
+// them sorted, without duplicates. A caller authenticated with a key only
+// grants the scopes it holds.
+func grantable(ctx context.Context, scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, errors.NewInvalidField("scopes", "at least one is required")
+	}
+
+	caller, byKey := FromContext(ctx)
+	seen := map[string]bool{}
+	var res []string
+	for _, scope := range scopes {
+		if !Known(scope) {
+			return nil, errors.NewInvalidField("scopes", "unknown scope "+scope+", known ones are "+strings.Join(Scopes(), ", "))
+		}
+		if byKey && !HasScope(caller.Scopes, scope) {
+			return nil, errors.NewForbidden("a key can't grant the " + scope + " scope it doesn't hold")
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			res = append(res, scope)
+		}
+	}
+	sort.Strings(res)
+
+	return res, nil
+}
+
+// mapError maps the errors of the repository, passing through the ones
+// already made for the client.
+func (s *service) mapError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	level.Error(s.Logger).Log("error", err)
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
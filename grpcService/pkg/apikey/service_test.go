@@ -0,0 +1,321 @@
+package apikey_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const (
+	adminId = "0190f3b2-7c1e-7d3a-9b4f-000000000001"
+	keyId   = "7d1f2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"
+)
+
+var admin = audit.Caller{Actor: adminId, Roles: "admin"}
+
+func TestServiceCreateApiKey(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	manager := entities.ApiKey{Id: keyId, Scopes: []string{apikey.ManageScope, "users:read"}}
+
+	testCases := []struct {
+		Name           string
+		Caller         audit.Caller
+		Key            *entities.ApiKey
+		Request        entities.CreateApiKeyRequest
+		buildMock      func(repo *utils.ApiKeyRepositoryMock)
+		assertResponse func(t *testing.T, res entities.CreateApiKeyResponse, err error)
+	}{
+		{
+			Name:    "Admin",
+			Caller:  admin,
+			Request: entities.CreateApiKeyRequest{Name: " nightly export ", Scopes: []string{"users:read", "groups:read", "users:read"}, TtlSeconds: 3600},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("CreateKey", mock.Anything, mock.MatchedBy(func(k entities.ApiKey) bool {
+					return k.Name == "nightly export" && assert.ObjectsAreEqual([]string{"groups:read", "users:read"}, k.Scopes) &&
+						k.CreatedBy == adminId && k.ExpiresAt.Sub(k.CreatedAt) == time.Hour && len(k.Prefix) == 12 && len(k.Hash) == 64
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateApiKeyResponse, err error) {
+				assert.NoError(t, err)
+				prefix, ok := apikey.Parse(res.Secret)
+				assert.True(t, ok)
+				assert.Equal(t, res.Key.Prefix, prefix)
+				assert.True(t, apikey.Matches(res.Key.Hash, res.Secret))
+			},
+		},
+		{
+			Name:    "Without Expiry",
+			Caller:  admin,
+			Request: entities.CreateApiKeyRequest{Name: "sync", Scopes: []string{"users:read"}},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("CreateKey", mock.Anything, mock.MatchedBy(func(k entities.ApiKey) bool {
+					return k.ExpiresAt.IsZero()
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateApiKeyResponse, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name:    "Prefix Taken",
+			Caller:  admin,
+			Request: entities.CreateApiKeyRequest{Name: "sync", Scopes: []string{"users:read"}},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("CreateKey", mock.Anything, mock.Anything).Return(&mysql.MySQLError{Number: 1062}).Once()
+				repo.On("CreateKey", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			assertResponse: func(t *testing.T, res entities.CreateApiKeyResponse, err error) {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, res.Secret)
+			},
+		},
+		{
+			Name:    "Not An Admin",
+			Caller:  audit.Caller{Actor: adminId, Roles: "support"},
+			Request: entities.CreateApiKeyRequest{Name: "sync", Scopes: []string{"users:read"}},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, res entities.CreateApiKeyResponse, err error) {
+				assert.IsType(t, myErr.Forbidden{}, err)
+			},
+		},
+		{
+			Name:    "Missing Name",
+			Caller:  admin,
+			Request: entities.CreateApiKeyRequest{Name: "  ", Scopes: []string{"users:read"}},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, res entities.CreateApiKeyResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:    "Missing Scopes",
+			Caller:  admin,
+			Request: entities.CreateApiKeyRequest{Name: "sync"},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, res entities.CreateApiKeyResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:    "Unknown Scope",
+			Caller:  admin,
+			Request: entities.CreateApiKeyRequest{Name: "sync", Scopes: []string{"users:admin"}},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, res entities.CreateApiKeyResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:    "Key Granting Its Scopes",
+			Key:     &manager,
+			Request: entities.CreateApiKeyRequest{Name: "sync", Scopes: []string{"users:read"}},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("CreateKey", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateApiKeyResponse, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name:    "Key Granting Other Scopes",
+			Key:     &manager,
+			Request: entities.CreateApiKeyRequest{Name: "sync", Scopes: []string{"users:write"}},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, res entities.CreateApiKeyResponse, err error) {
+				assert.IsType(t, myErr.Forbidden{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ApiKeyRepositoryMock)
+			tc.buildMock(repo)
+
+			ctx := audit.WithCaller(context.Background(), tc.Caller)
+			if tc.Key != nil {
+				ctx = apikey.WithKey(ctx, *tc.Key)
+			}
+			res, err := apikey.NewService(logger, repo).CreateApiKey(ctx, tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceRevokeApiKey(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	now := time.Now().UTC()
+	active := entities.ApiKey{Id: keyId, Name: "sync", Scopes: []string{"users:read"}, CreatedAt: now}
+
+	testCases := []struct {
+		Name           string
+		buildMock      func(repo *utils.ApiKeyRepositoryMock)
+		assertResponse func(t *testing.T, res entities.RevokeApiKeyResponse, err error)
+	}{
+		{
+			Name: "Active Key",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("LockKey", mock.Anything, keyId).Return(active, nil)
+				repo.On("RevokeKey", mock.Anything, keyId, mock.AnythingOfType("time.Time")).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.RevokeApiKeyResponse, err error) {
+				assert.NoError(t, err)
+				assert.False(t, res.Key.RevokedAt.IsZero())
+			},
+		},
+		{
+			Name: "Already Revoked",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				revoked := active
+				revoked.RevokedAt = now
+				repo.On("LockKey", mock.Anything, keyId).Return(revoked, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.RevokeApiKeyResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, now, res.Key.RevokedAt)
+			},
+		},
+		{
+			Name: "Unknown Key",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("LockKey", mock.Anything, keyId).Return(entities.ApiKey{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.RevokeApiKeyResponse, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+		{
+			Name: "Database Error",
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("LockKey", mock.Anything, keyId).Return(entities.ApiKey{}, sql.ErrConnDone)
+			},
+			assertResponse: func(t *testing.T, res entities.RevokeApiKeyResponse, err error) {
+				assert.Error(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ApiKeyRepositoryMock)
+			tc.buildMock(repo)
+
+			ctx := audit.WithCaller(context.Background(), admin)
+			res, err := apikey.NewService(logger, repo).RevokeApiKey(ctx, entities.RevokeApiKeyRequest{KeyId: keyId})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceRotateApiKey(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	now := time.Now().UTC()
+	active := entities.ApiKey{Id: keyId, Name: "sync", Scopes: []string{"users:read"}, CreatedAt: now.Add(-time.Hour)}
+
+	testCases := []struct {
+		Name           string
+		Request        entities.RotateApiKeyRequest
+		buildMock      func(repo *utils.ApiKeyRepositoryMock)
+		assertResponse func(t *testing.T, res entities.RotateApiKeyResponse, err error)
+	}{
+		{
+			Name:    "Default Overlap",
+			Request: entities.RotateApiKeyRequest{KeyId: keyId},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				repo.On("LockKey", mock.Anything, keyId).Return(active, nil)
+				repo.On("CreateKey", mock.Anything, mock.MatchedBy(func(k entities.ApiKey) bool {
+					return k.Name == "sync" && assert.ObjectsAreEqual(active.Scopes, k.Scopes) && k.ExpiresAt.IsZero()
+				})).Return(nil)
+				repo.On("RotateKey", mock.Anything, keyId, mock.MatchedBy(func(expiresAt time.Time) bool {
+					return expiresAt.Sub(now) > 23*time.Hour && expiresAt.Sub(now) <= 25*time.Hour
+				}), mock.AnythingOfType("string")).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.RotateApiKeyResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, res.Key.Id, res.Previous.ReplacedBy)
+				assert.True(t, apikey.Matches(res.Key.Hash, res.Secret))
+			},
+		},
+		{
+			Name:    "Expiring Before Overlap Ends",
+			Request: entities.RotateApiKeyRequest{KeyId: keyId, OverlapSeconds: 7200},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				expiring := active
+				expiring.ExpiresAt = now.Add(time.Minute)
+				repo.On("LockKey", mock.Anything, keyId).Return(expiring, nil)
+				repo.On("CreateKey", mock.Anything, mock.MatchedBy(func(k entities.ApiKey) bool {
+					return k.ExpiresAt.Sub(k.CreatedAt) == time.Hour+time.Minute
+				})).Return(nil)
+				repo.On("RotateKey", mock.Anything, keyId, expiring.ExpiresAt, mock.AnythingOfType("string")).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.RotateApiKeyResponse, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name:    "Revoked Key",
+			Request: entities.RotateApiKeyRequest{KeyId: keyId},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				revoked := active
+				revoked.RevokedAt = now
+				repo.On("LockKey", mock.Anything, keyId).Return(revoked, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.RotateApiKeyResponse, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name:    "Rotated Already",
+			Request: entities.RotateApiKeyRequest{KeyId: keyId},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+				rotated := active
+				rotated.ReplacedBy = "0190f3b2-7c1e-7d3a-9b4f-000000000002"
+				repo.On("LockKey", mock.Anything, keyId).Return(rotated, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.RotateApiKeyResponse, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name:    "Overlap Too Long",
+			Request: entities.RotateApiKeyRequest{KeyId: keyId, OverlapSeconds: 8 * 24 * 3600},
+			buildMock: func(repo *utils.ApiKeyRepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, res entities.RotateApiKeyResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ApiKeyRepositoryMock)
+			tc.buildMock(repo)
+
+			ctx := audit.WithCaller(context.Background(), admin)
+			res, err := apikey.NewService(logger, repo).RotateApiKey(ctx, tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	create gr.Handler
+	list   gr.Handler
+	revoke gr.Handler
+	rotate gr.Handler
+	proto.UnimplementedApiKeyServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.ApiKeyServiceServer {
+	return &gRPCSv{
+		create: gr.NewServer(
+			end.CreateApiKey,
+			decodeCreateApiKeyRequest,
+			encodeCreateApiKeyResponse,
+		),
+
+		list: gr.NewServer(
+			end.ListApiKeys,
+			decodeListApiKeysRequest,
+			encodeListApiKeysResponse,
+		),
+
+		revoke: gr.NewServer(
+			end.RevokeApiKey,
+			decodeRevokeApiKeyRequest,
+			encodeRevokeApiKeyResponse,
+		),
+
+		rotate: gr.NewServer(
+			end.RotateApiKey,
+			decodeRotateApiKeyRequest,
+			encodeRotateApiKeyResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) CreateApiKey(ctx context.Context, rq *proto.CreateApiKeyRequest) (*proto.CreateApiKeyResponse, error) {
+	_, resp, err := g.create.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.CreateApiKeyResponse), nil
+}
+
+func (g *gRPCSv) ListApiKeys(ctx context.Context, rq *proto.ListApiKeysRequest) (*proto.ListApiKeysResponse, error) {
+	_, resp, err := g.list.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListApiKeysResponse), nil
+}
+
+func (g *gRPCSv) RevokeApiKey(ctx context.Context, rq *proto.RevokeApiKeyRequest) (*proto.RevokeApiKeyResponse, error) {
+	_, resp, err := g.revoke.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.RevokeApiKeyResponse), nil
+}
+
+func (g *gRPCSv) RotateApiKey(ctx context.Context, rq *proto.RotateApiKeyRequest) (*proto.RotateApiKeyResponse, error) {
+	_, resp, err := g.rotate.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.RotateApiKeyResponse), nil
+}
+
+func decodeCreateApiKeyRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.CreateApiKeyRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.CreateApiKeyRequest{Name: res.Name, Scopes: res.Scopes, TtlSeconds: res.Ttl_Seconds}, nil
+}
+
+func encodeCreateApiKeyResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.CreateApiKeyResponse)
+	return &proto.CreateApiKeyResponse{Key: keyToProto(res.Key), Secret: res.Secret}, nil
+}
+
+func decodeListApiKeysRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	if _, valid := request.(*proto.ListApiKeysRequest); !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListApiKeysRequest{}, nil
+}
+
+func encodeListApiKeysResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListApiKeysResponse)
+
+	keys := make([]*proto.ApiKey, 0, len(res.Keys))
+	for _, key := range res.Keys {
+		keys = append(keys, keyToProto(key))
+	}
+	return &proto.ListApiKeysResponse{Keys: keys}, nil
+}
+
+func decodeRevokeApiKeyRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.RevokeApiKeyRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := parseKeyId(res.Key_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.RevokeApiKeyRequest{KeyId: id}, nil
+}
+
+func encodeRevokeApiKeyResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.RevokeApiKeyResponse)
+	return &proto.RevokeApiKeyResponse{Key: keyToProto(res.Key)}, nil
+}
+
+func decodeRotateApiKeyRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.RotateApiKeyRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := parseKeyId(res.Key_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.RotateApiKeyRequest{KeyId: id, OverlapSeconds: res.Overlap_Seconds}, nil
+}
+
+func encodeRotateApiKeyResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.RotateApiKeyResponse)
+	return &proto.RotateApiKeyResponse{Key: keyToProto(res.Key), Secret: res.Secret, Previous: keyToProto(res.Previous)}, nil
+}
+
+func parseKeyId(id string) (string, error) {
+	if id == "" {
+		return "", customErr.NewInvalidField("key_id", "is required")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", customErr.NewInvalidField("key_id", "must be a UUID")
+	}
+	return id, nil
+}
+
+// keyToProto leaves the hash of the key out.
+func keyToProto(key entities.ApiKey) *proto.ApiKey {
+	return &proto.ApiKey{
+		Id:           key.Id,
+		Name:         key.Name,
+		Prefix:       key.Prefix,
+		Scopes:       key.Scopes,
+		Created_By:   key.CreatedBy,
+		Created_At:   timeToProto(key.CreatedAt),
+		Expires_At:   timeToProto(key.ExpiresAt),
+		Revoked_At:   timeToProto(key.RevokedAt),
+		Last_Used_At: timeToProto(key.LastUsedAt),
+		Replaced_By:  key.ReplacedBy,
+	}
+}
+
+// timeToProto leaves unset times out of the response.
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
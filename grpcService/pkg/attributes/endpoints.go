@@ -0,0 +1,57 @@
+package attributes
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	GetAttributeSchema(ctx context.Context, rq entities.GetAttributeSchemaRequest) (entities.GetAttributeSchemaResponse, error)
+	SetAttributeSchema(ctx context.Context, rq entities.SetAttributeSchemaRequest) (entities.SetAttributeSchemaResponse, error)
+}
+
+type Endpoints struct {
+	GetAttributeSchema endpoint.Endpoint
+	SetAttributeSchema endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		GetAttributeSchema: MakeGetAttributeSchemaEndpoint(s),
+		SetAttributeSchema: MakeSetAttributeSchemaEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		GetAttributeSchema: mw(e.GetAttributeSchema),
+		SetAttributeSchema: mw(e.SetAttributeSchema),
+	}
+}
+
+func MakeGetAttributeSchemaEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.GetAttributeSchemaRequest)
+		c, err := s.GetAttributeSchema(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeSetAttributeSchemaEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.SetAttributeSchemaRequest)
+		c, err := s.SetAttributeSchema(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
@@ -0,0 +1,197 @@
+package attributes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// reindexPageSize is how many users a reindex reads at a time.
+const reindexPageSize = 500
+
+type Repository interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// GetSchema returns sql.ErrNoRows when the tenant has no schema.
+	GetSchema(ctx context.Context) (entities.AttributeSchema, error)
+	SaveSchema(ctx context.Context, schema entities.AttributeSchema) error
+	// Reindex writes the index of every user of the tenant again for
+	// schema and returns how many users have indexed attributes.
+	Reindex(ctx context.Context, schema *Schema) (uint64, error)
+}
+
+// sqlRepo keeps the schema of the tenant of the context it is called with.
+type sqlRepo struct {
+	DB       *sql.DB
+	Logger   log.Logger
+	TxConfig database.TxConfig
+}
+
+func NewSQL(db *sql.DB, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, log, database.DefaultTxConfig()}
+}
+
+func (repo *sqlRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, repo.DB, repo.TxConfig, fn)
+}
+
+func (repo *sqlRepo) GetSchema(ctx context.Context) (entities.AttributeSchema, error) {
+	var schema entities.AttributeSchema
+	err := database.Conn(ctx, repo.DB).QueryRowContext(ctx, utils.GetAttributeSchemaQuery, tenant.FromContext(ctx)).
+		Scan(&schema.Schema, &schema.UpdatedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			level.Error(repo.Logger).Log(err)
+		}
+		return entities.AttributeSchema{}, err
+	}
+
+	return schema, nil
+}
+
+// Schema returns the parsed schema of the tenant, nil when it has none. It
+// is how the user service finds the schema to check attributes against.
+func (repo *sqlRepo) Schema(ctx context.Context) (*Schema, error) {
+	stored, err := repo.GetSchema(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return Parse([]byte(stored.Schema))
+}
+
+func (repo *sqlRepo) SaveSchema(ctx context.Context, schema entities.AttributeSchema) error {
+	if _, err := database.Conn(ctx, repo.DB).ExecContext(ctx, utils.SaveAttributeSchemaQuery, tenant.FromContext(ctx), schema.Schema, schema.UpdatedAt); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+// Reindex runs in the transaction carried by ctx, callers save the schema
+// in the same one.
+func (repo *sqlRepo) Reindex(ctx context.Context, schema *Schema) (uint64, error) {
+	db := database.Conn(ctx, repo.DB)
+	tenantId := tenant.FromContext(ctx)
+
+	if _, err := db.ExecContext(ctx, utils.DeleteTenantAttributeIndexQuery, tenantId); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return 0, err
+	}
+
+	var indexed uint64
+	afterId := ""
+	for {
+		users, err := listUserAttributes(ctx, db, tenantId, afterId)
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return 0, err
+		}
+
+		for _, user := range users {
+			entries := schema.Index(user.Attributes)
+			if len(entries) == 0 {
+				continue
+			}
+			if err := WriteIndex(ctx, db, user.Id, entries); err != nil {
+				level.Error(repo.Logger).Log(err)
+				return 0, err
+			}
+			indexed++
+		}
+
+		if len(users) < reindexPageSize {
+			return indexed, nil
+		}
+		afterId = users[len(users)-1].Id
+	}
+}
+
+// listUserAttributes reads a page of the users holding attributes. The rows
+// are closed before it returns, the connection is free for the writes that
+// follow.
+func listUserAttributes(ctx context.Context, db database.Querier, tenantId string, afterId string) ([]entities.User, error) {
+	rows, err := db.QueryContext(ctx, utils.ListUserAttributesQuery, tenantId, afterId, reindexPageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []entities.User
+	for rows.Next() {
+		var (
+			user    entities.User
+			content sql.NullString
+		)
+		if err := rows.Scan(&user.Id, &content); err != nil {
+			return nil, err
+		}
+		if user.Attributes, err = Decode(content); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// WriteIndex stores the indexed attributes of a new user, or of one whose
+// rows were deleted first, in the tenant of ctx.
+func WriteIndex(ctx context.Context, db database.Querier, userId string, entries map[string]string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tenantId := tenant.FromContext(ctx)
+	args := make([]interface{}, 0, len(names)*4)
+	for _, name := range names {
+		args = append(args, tenantId, userId, name, entries[name])
+	}
+
+	_, err := db.ExecContext(ctx, utils.Placeholders(utils.InsertAttributeIndexQuery, len(names), 4), args...)
+	return err
+}
+
+// Encode writes attrs for the attributes column, NULL when there are none.
+func Encode(attrs map[string]interface{}) (interface{}, error) {
+	if len(attrs) == 0 {
+		return nil, nil
+	}
+
+	content, err := json.Marshal(attrs)
+	if err != nil {
+		return nil, err
+	}
+	return string(content), nil
+}
+
+// Decode reads the attributes column.
+func Decode(content sql.NullString) (map[string]interface{}, error) {
+	if !content.Valid || content.String == "" {
+		return nil, nil
+	}
+
+	var attrs map[string]interface{}
+	if err := json.Unmarshal([]byte(content.String), &attrs); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
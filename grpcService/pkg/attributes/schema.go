@@ -0,0 +1,432 @@
+// Package attributes keeps the custom attributes of users, the JSON object
+// every user carries next to its fixed fields, and the schema each tenant
+// defines for them.
+//
+// Schemas are written in a subset of JSON Schema: a top level object with
+// properties, required and additionalProperties, whose properties are
+// strings, integers, numbers, booleans or arrays of those. Keywords outside
+// the subset are refused rather than ignored, so a schema never promises a
+// check that isn't made. Properties marked "x-indexed" hold a scalar that
+// users can be listed by.
+package attributes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+const (
+	// MaxBytes caps the attributes of a user, as JSON.
+	MaxBytes = 16 << 10
+	// MaxSchemaBytes caps a schema.
+	MaxSchemaBytes = 64 << 10
+	// MaxIndexed caps the indexed properties of a schema.
+	MaxIndexed = 10
+	// MaxIndexedLength is the longest value an indexed property holds, in
+	// characters.
+	MaxIndexedLength = 255
+)
+
+// Property types.
+const (
+	String  = "string"
+	Integer = "integer"
+	Number  = "number"
+	Boolean = "boolean"
+	Array   = "array"
+)
+
+var propertyName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Schema checks the attributes of the users of a tenant.
+type Schema struct {
+	Properties map[string]*Property
+	Required   []string
+	// AdditionalProperties lets attributes the schema doesn't name through,
+	// unchecked and unindexed.
+	AdditionalProperties bool
+}
+
+// Property checks a single attribute. Bounds left nil are not checked.
+type Property struct {
+	Type      string
+	Enum      []interface{}
+	MinLength *int
+	MaxLength *int
+	Pattern   *regexp.Regexp
+	Minimum   *float64
+	Maximum   *float64
+	MaxItems  *int
+	// Items checks the elements of an array.
+	Items   *Property
+	Indexed bool
+}
+
+type rawSchema struct {
+	Schema               string                 `json:"$schema"`
+	Title                string                 `json:"title"`
+	Description          string                 `json:"description"`
+	Type                 string                 `json:"type"`
+	Properties           map[string]rawProperty `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+}
+
+type rawProperty struct {
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Type        string        `json:"type"`
+	Enum        []interface{} `json:"enum"`
+	MinLength   *int          `json:"minLength"`
+	MaxLength   *int          `json:"maxLength"`
+	Pattern     string        `json:"pattern"`
+	Minimum     *float64      `json:"minimum"`
+	Maximum     *float64      `json:"maximum"`
+	MaxItems    *int          `json:"maxItems"`
+	Items       *rawProperty  `json:"items"`
+	Indexed     bool          `json:"x-indexed"`
+}
+
+// Parse reads a schema, failing with an InvalidField error on the schema
+// field that names what is wrong with it.
+func Parse(content []byte) (*Schema, error) {
+	if len(content) > MaxSchemaBytes {
+		return nil, invalidSchema("is larger than 64 KiB")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var raw rawSchema
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, invalidSchema(err.Error())
+	}
+	if raw.Type != "object" {
+		return nil, invalidSchema(`must have type "object"`)
+	}
+
+	schema := &Schema{
+		Properties:           make(map[string]*Property, len(raw.Properties)),
+		Required:             raw.Required,
+		AdditionalProperties: raw.AdditionalProperties == nil || *raw.AdditionalProperties,
+	}
+
+	indexed := 0
+	for name, rawProp := range raw.Properties {
+		if !propertyName.MatchString(name) {
+			return nil, invalidSchema(fmt.Sprintf("property %q must be lower case letters, digits and underscores", name))
+		}
+
+		prop, err := parseProperty(name, rawProp, false)
+		if err != nil {
+			return nil, err
+		}
+		if prop.Indexed {
+			indexed++
+		}
+		schema.Properties[name] = prop
+	}
+	if indexed > MaxIndexed {
+		return nil, invalidSchema(fmt.Sprintf("indexes more than %d properties", MaxIndexed))
+	}
+
+	for _, name := range raw.Required {
+		if _, ok := schema.Properties[name]; !ok {
+			return nil, invalidSchema(fmt.Sprintf("requires %q, which is not a property", name))
+		}
+	}
+
+	return schema, nil
+}
+
+func parseProperty(name string, raw rawProperty, item bool) (*Property, error) {
+	prop := &Property{
+		Type:      raw.Type,
+		Enum:      raw.Enum,
+		MinLength: raw.MinLength,
+		MaxLength: raw.MaxLength,
+		Minimum:   raw.Minimum,
+		Maximum:   raw.Maximum,
+		MaxItems:  raw.MaxItems,
+		Indexed:   raw.Indexed,
+	}
+
+	switch raw.Type {
+	case String, Integer, Number, Boolean:
+	case Array:
+		if item {
+			return nil, invalidSchema(fmt.Sprintf("property %q can't hold arrays of arrays", name))
+		}
+		if raw.Items == nil {
+			return nil, invalidSchema(fmt.Sprintf("property %q must set items", name))
+		}
+		if raw.Indexed {
+			return nil, invalidSchema(fmt.Sprintf("property %q can't be indexed, only scalars are", name))
+		}
+
+		items, err := parseProperty(name, *raw.Items, true)
+		if err != nil {
+			return nil, err
+		}
+		prop.Items = items
+	default:
+		return nil, invalidSchema(fmt.Sprintf("property %q must be a string, integer, number, boolean or array", name))
+	}
+
+	if raw.Type == Array && len(raw.Enum) > 0 {
+		return nil, invalidSchema(fmt.Sprintf("property %q can't list an enum, its items can", name))
+	}
+	if item && raw.Indexed {
+		return nil, invalidSchema(fmt.Sprintf("items of property %q can't be indexed", name))
+	}
+
+	if raw.Pattern != "" {
+		if raw.Type != String {
+			return nil, invalidSchema(fmt.Sprintf("property %q sets a pattern but is not a string", name))
+		}
+		pattern, err := regexp.Compile(raw.Pattern)
+		if err != nil {
+			return nil, invalidSchema(fmt.Sprintf("property %q has an invalid pattern", name))
+		}
+		prop.Pattern = pattern
+	}
+
+	for _, value := range raw.Enum {
+		if err := prop.checkType(value); err != nil {
+			return nil, invalidSchema(fmt.Sprintf("property %q lists an enum value that is not a %s", name, raw.Type))
+		}
+	}
+
+	return prop, nil
+}
+
+// Validate checks attrs against the schema. The error names the first
+// attribute that fails, as "attributes.<name>". A tenant without a schema,
+// a nil one, takes no attributes.
+func (s *Schema) Validate(attrs map[string]interface{}) error {
+	if s == nil {
+		if len(attrs) > 0 {
+			return errors.NewInvalidField("attributes", "no attribute schema is set for the tenant")
+		}
+		return nil
+	}
+
+	if err := checkSize(attrs); err != nil {
+		return err
+	}
+
+	for _, name := range s.Required {
+		if _, ok := attrs[name]; !ok {
+			return errors.NewInvalidField("attributes."+name, "is required")
+		}
+	}
+
+	for _, name := range sortedNames(attrs) {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if !s.AdditionalProperties {
+				return errors.NewInvalidField("attributes."+name, "is not in the attribute schema")
+			}
+			continue
+		}
+
+		if err := prop.check(attrs[name]); err != nil {
+			return errors.NewInvalidField("attributes."+name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Index returns the values of the indexed attributes of attrs, as they are
+// stored for users to be listed by.
+func (s *Schema) Index(attrs map[string]interface{}) map[string]string {
+	entries := make(map[string]string)
+	if s == nil {
+		return entries
+	}
+	for name, prop := range s.Properties {
+		if value, ok := attrs[name]; ok && prop.Indexed {
+			entries[name] = indexValue(value)
+		}
+	}
+	return entries
+}
+
+// FilterValue reads value, as given in a filter on the attribute name, into
+// the form it is indexed in. Only indexed attributes can be filtered on.
+func (s *Schema) FilterValue(name string, value string) (string, error) {
+	field := "filter." + name
+
+	var prop *Property
+	if s != nil {
+		prop = s.Properties[name]
+	}
+	if prop == nil || !prop.Indexed {
+		return "", errors.NewInvalidField(field, "is not an indexed attribute")
+	}
+
+	switch prop.Type {
+	case Integer, Number:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", errors.NewInvalidField(field, "must be a number")
+		}
+		return indexValue(n), nil
+	case Boolean:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", errors.NewInvalidField(field, "must be true or false")
+		}
+		return indexValue(b), nil
+	}
+	return value, nil
+}
+
+func (p *Property) check(value interface{}) error {
+	if err := p.checkType(value); err != nil {
+		return err
+	}
+
+	if len(p.Enum) > 0 && !contains(p.Enum, value) {
+		return fmt.Errorf("is not one of the allowed values")
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if p.MinLength != nil && length < *p.MinLength {
+			return fmt.Errorf("is shorter than %d characters", *p.MinLength)
+		}
+		if p.MaxLength != nil && length > *p.MaxLength {
+			return fmt.Errorf("is longer than %d characters", *p.MaxLength)
+		}
+		if p.Indexed && length > MaxIndexedLength {
+			return fmt.Errorf("is longer than %d characters, the most an indexed attribute holds", MaxIndexedLength)
+		}
+		if p.Pattern != nil && !p.Pattern.MatchString(v) {
+			return fmt.Errorf("does not match %s", p.Pattern)
+		}
+	case float64:
+		if p.Minimum != nil && v < *p.Minimum {
+			return fmt.Errorf("is less than %v", *p.Minimum)
+		}
+		if p.Maximum != nil && v > *p.Maximum {
+			return fmt.Errorf("is greater than %v", *p.Maximum)
+		}
+	case []interface{}:
+		if p.MaxItems != nil && len(v) > *p.MaxItems {
+			return fmt.Errorf("has more than %d items", *p.MaxItems)
+		}
+		for i, item := range v {
+			if err := p.Items.check(item); err != nil {
+				return fmt.Errorf("item %d %v", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Property) checkType(value interface{}) error {
+	ok := false
+	switch v := value.(type) {
+	case string:
+		ok = p.Type == String
+	case float64:
+		ok = p.Type == Number || p.Type == Integer && v == math.Trunc(v)
+	case bool:
+		ok = p.Type == Boolean
+	case []interface{}:
+		ok = p.Type == Array
+	}
+
+	if !ok {
+		return fmt.Errorf("must be %s %s", article(p.Type), p.Type)
+	}
+	return nil
+}
+
+// Merge applies patch to attrs as a JSON merge patch: attributes set to
+// null are removed and the others replace the ones with their name. attrs
+// is left as it is.
+func Merge(attrs map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(attrs)+len(patch))
+	for name, value := range attrs {
+		merged[name] = value
+	}
+	for name, value := range patch {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = value
+	}
+	return merged
+}
+
+// Names returns the names of attrs, sorted.
+func Names(attrs map[string]interface{}) []string {
+	return sortedNames(attrs)
+}
+
+func checkSize(attrs map[string]interface{}) error {
+	content, err := json.Marshal(attrs)
+	if err != nil {
+		return errors.NewInvalidField("attributes", "must be JSON")
+	}
+	if len(content) > MaxBytes {
+		return errors.NewInvalidField("attributes", "are larger than 16 KiB")
+	}
+	return nil
+}
+
+// indexValue writes scalars the way filters are compared with them.
+func indexValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(attrs map[string]interface{}) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func article(typ string) string {
+	if typ == Integer || typ == Array {
+		return "an"
+	}
+	return "a"
+}
+
+func invalidSchema(reason string) error {
+	return errors.NewInvalidField("schema", reason)
+}
@@ -0,0 +1,132 @@
+package attributes_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/attributes"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+const testSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"properties": {
+		"team": {"type": "string", "enum": ["core", "growth"], "x-indexed": true},
+		"level": {"type": "integer", "minimum": 1, "maximum": 5, "x-indexed": true},
+		"remote": {"type": "boolean", "x-indexed": true},
+		"nickname": {"type": "string", "maxLength": 8, "pattern": "^[a-z]+$"},
+		"skills": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+	},
+	"required": ["team"],
+	"additionalProperties": false
+}`
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Schema string
+		Reason string
+	}{
+		{Name: "Valid", Schema: testSchema},
+		{Name: "Not An Object", Schema: `{"type": "string"}`, Reason: `must have type "object"`},
+		{Name: "Unknown Keyword", Schema: `{"type": "object", "oneOf": []}`, Reason: "unknown field"},
+		{Name: "Bad Property Name", Schema: `{"type": "object", "properties": {"Team": {"type": "string"}}}`, Reason: "Team"},
+		{Name: "Indexed Array", Schema: `{"type": "object", "properties": {"skills": {"type": "array", "items": {"type": "string"}, "x-indexed": true}}}`, Reason: "skills"},
+		{Name: "Pattern On A Number", Schema: `{"type": "object", "properties": {"level": {"type": "integer", "pattern": "^1$"}}}`, Reason: "pattern"},
+		{Name: "Enum Of Another Type", Schema: `{"type": "object", "properties": {"level": {"type": "integer", "enum": ["one"]}}}`, Reason: "enum"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			schema, err := attributes.Parse([]byte(tc.Schema))
+			if tc.Reason == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, schema)
+				return
+			}
+
+			assert.IsType(t, myErr.InvalidField{}, err)
+			assert.Contains(t, err.Error(), tc.Reason)
+		})
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := attributes.Parse([]byte(testSchema))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		Name       string
+		Schema     *attributes.Schema
+		Attributes map[string]interface{}
+		Field      string
+	}{
+		{Name: "Valid", Schema: schema, Attributes: map[string]interface{}{"team": "core", "level": float64(3), "skills": []interface{}{"go"}}},
+		{Name: "Missing Required", Schema: schema, Attributes: map[string]interface{}{"level": float64(3)}, Field: "attributes.team"},
+		{Name: "Not In The Enum", Schema: schema, Attributes: map[string]interface{}{"team": "sales"}, Field: "attributes.team"},
+		{Name: "Not An Integer", Schema: schema, Attributes: map[string]interface{}{"team": "core", "level": 2.5}, Field: "attributes.level"},
+		{Name: "Above Maximum", Schema: schema, Attributes: map[string]interface{}{"team": "core", "level": float64(6)}, Field: "attributes.level"},
+		{Name: "Pattern Mismatch", Schema: schema, Attributes: map[string]interface{}{"team": "core", "nickname": "Timo"}, Field: "attributes.nickname"},
+		{Name: "Too Many Items", Schema: schema, Attributes: map[string]interface{}{"team": "core", "skills": []interface{}{"go", "sql", "k8s"}}, Field: "attributes.skills"},
+		{Name: "Item Of Another Type", Schema: schema, Attributes: map[string]interface{}{"team": "core", "skills": []interface{}{float64(1)}}, Field: "attributes.skills"},
+		{Name: "Not In The Schema", Schema: schema, Attributes: map[string]interface{}{"team": "core", "shoe_size": float64(42)}, Field: "attributes.shoe_size"},
+		{Name: "Too Large", Schema: schema, Attributes: map[string]interface{}{"team": "core", "nickname": strings.Repeat("a", attributes.MaxBytes)}, Field: "attributes"},
+		{Name: "No Schema And No Attributes", Attributes: nil},
+		{Name: "No Schema", Attributes: map[string]interface{}{"team": "core"}, Field: "attributes"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Schema.Validate(tc.Attributes)
+			if tc.Field == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.IsType(t, myErr.InvalidField{}, err)
+			assert.Contains(t, err.Error(), tc.Field)
+		})
+	}
+}
+
+func TestSchemaIndex(t *testing.T) {
+	schema, err := attributes.Parse([]byte(testSchema))
+	assert.NoError(t, err)
+
+	entries := schema.Index(map[string]interface{}{"team": "core", "level": float64(3), "remote": true, "nickname": "timo"})
+	assert.Equal(t, map[string]string{"team": "core", "level": "3", "remote": "true"}, entries)
+
+	var none *attributes.Schema
+	assert.Empty(t, none.Index(map[string]interface{}{"team": "core"}))
+}
+
+func TestSchemaFilterValue(t *testing.T) {
+	schema, err := attributes.Parse([]byte(testSchema))
+	assert.NoError(t, err)
+
+	value, err := schema.FilterValue("level", "3.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", value)
+
+	value, err = schema.FilterValue("remote", "TRUE")
+	assert.NoError(t, err)
+	assert.Equal(t, "true", value)
+
+	_, err = schema.FilterValue("level", "three")
+	assert.IsType(t, myErr.InvalidField{}, err)
+
+	_, err = schema.FilterValue("nickname", "timo")
+	assert.IsType(t, myErr.InvalidField{}, err)
+	assert.Contains(t, err.Error(), "filter.nickname")
+}
+
+func TestMerge(t *testing.T) {
+	attrs := map[string]interface{}{"team": "core", "level": float64(3)}
+
+	merged := attributes.Merge(attrs, map[string]interface{}{"level": nil, "remote": true})
+	assert.Equal(t, map[string]interface{}{"team": "core", "remote": true}, merged)
+	assert.Equal(t, map[string]interface{}{"team": "core", "level": float64(3)}, attrs)
+}
@@ -0,0 +1,90 @@
+package attributes
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+type service struct {
+	Repo   Repository
+	Logger log.Logger
+}
+
+func NewService(l log.Logger, r Repository) *service {
+	return &service{r, l}
+}
+
+func (s *service) GetAttributeSchema(ctx context.Context, rq entities.GetAttributeSchemaRequest) (entities.GetAttributeSchemaResponse, error) {
+	s.Logger.Log("request", "get attribute schema", "received")
+
+	schema, err := s.Repo.GetSchema(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.GetAttributeSchemaResponse{}, errors.NewResourceNotFound("attribute schema")
+		}
+		level.Error(s.Logger).Log("error", err)
+		return entities.GetAttributeSchemaResponse{}, dataBaseError(err)
+	}
+
+	return entities.GetAttributeSchemaResponse{Schema: schema.Schema, UpdatedAt: schema.UpdatedAt}, nil
+}
+
+// SetAttributeSchema replaces the schema of the tenant and indexes the
+// attributes of its users for it, in one transaction. Users are not checked
+// against the new schema, their next write to their attributes is.
+func (s *service) SetAttributeSchema(ctx context.Context, rq entities.SetAttributeSchemaRequest) (entities.SetAttributeSchemaResponse, error) {
+	s.Logger.Log("request", "set attribute schema", "received")
+
+	parsed, err := Parse([]byte(rq.Schema))
+	if err != nil {
+		return entities.SetAttributeSchemaResponse{}, err
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(rq.Schema)); err != nil {
+		return entities.SetAttributeSchemaResponse{}, invalidSchema(err.Error())
+	}
+
+	schema := entities.AttributeSchema{
+		Schema: compact.String(),
+		// The database keeps microseconds.
+		UpdatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	var reindexed uint64
+	err = s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.Repo.SaveSchema(ctx, schema); err != nil {
+			return err
+		}
+
+		reindexed, err = s.Repo.Reindex(ctx, parsed)
+		return err
+	})
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.SetAttributeSchemaResponse{}, dataBaseError(err)
+	}
+
+	return entities.SetAttributeSchemaResponse{
+		Schema:    schema.Schema,
+		UpdatedAt: schema.UpdatedAt,
+		Reindexed: reindexed,
+	}, nil
+}
+
+func dataBaseError(err error) error {
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
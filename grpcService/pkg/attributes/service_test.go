@@ -0,0 +1,115 @@
+package attributes_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/attributes"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+// repositoryMock lives here rather than in utils, which the attributes
+// package imports.
+type repositoryMock struct {
+	mock.Mock
+}
+
+func (repo *repositoryMock) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (repo *repositoryMock) GetSchema(ctx context.Context) (entities.AttributeSchema, error) {
+	args := repo.Called(ctx)
+
+	return args.Get(0).(entities.AttributeSchema), args.Error(1)
+}
+
+func (repo *repositoryMock) SaveSchema(ctx context.Context, schema entities.AttributeSchema) error {
+	args := repo.Called(ctx, schema)
+
+	return args.Error(0)
+}
+
+func (repo *repositoryMock) Reindex(ctx context.Context, schema *attributes.Schema) (uint64, error) {
+	args := repo.Called(ctx, schema)
+
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func TestServiceGetAttributeSchema(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	repo := new(repositoryMock)
+	repo.On("GetSchema", mock.Anything).Return(entities.AttributeSchema{}, sql.ErrNoRows)
+
+	_, err := attributes.NewService(logger, repo).GetAttributeSchema(context.Background(), entities.GetAttributeSchemaRequest{})
+	assert.IsType(t, myErr.ResourceNotFound{}, err)
+	repo.AssertExpectations(t)
+}
+
+func TestServiceSetAttributeSchema(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Schema         string
+		buildMock      func(repo *repositoryMock)
+		assertResponse func(t *testing.T, res entities.SetAttributeSchemaResponse, err error)
+	}{
+		{
+			Name:   "Saved And Reindexed",
+			Schema: testSchema,
+			buildMock: func(repo *repositoryMock) {
+				repo.On("SaveSchema", mock.Anything, mock.MatchedBy(func(schema entities.AttributeSchema) bool {
+					return strings.HasPrefix(schema.Schema, `{"$schema":`) && !strings.Contains(schema.Schema, "\n")
+				})).Return(nil)
+				repo.On("Reindex", mock.Anything, mock.Anything).Return(uint64(7), nil)
+			},
+			assertResponse: func(t *testing.T, res entities.SetAttributeSchemaResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint64(7), res.Reindexed)
+				assert.NotContains(t, res.Schema, "\n")
+				assert.False(t, res.UpdatedAt.IsZero())
+			},
+		},
+		{
+			Name:      "Invalid Schema",
+			Schema:    `{"type": "object", "properties": {"level": {"type": "float"}}}`,
+			buildMock: func(repo *repositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.SetAttributeSchemaResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:   "Reindex Fails",
+			Schema: testSchema,
+			buildMock: func(repo *repositoryMock) {
+				repo.On("SaveSchema", mock.Anything, mock.Anything).Return(nil)
+				repo.On("Reindex", mock.Anything, mock.Anything).Return(uint64(0), sql.ErrConnDone)
+			},
+			assertResponse: func(t *testing.T, res entities.SetAttributeSchemaResponse, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, res.Schema)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(repositoryMock)
+			tc.buildMock(repo)
+
+			res, err := attributes.NewService(logger, repo).SetAttributeSchema(context.Background(), entities.SetAttributeSchemaRequest{Schema: tc.Schema})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package attributes
+
+import (
+	"context"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	getSchema gr.Handler
+	setSchema gr.Handler
+	proto.UnimplementedAttributeSchemaServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.AttributeSchemaServiceServer {
+	return &gRPCSv{
+		getSchema: gr.NewServer(
+			end.GetAttributeSchema,
+			decodeGetAttributeSchemaRequest,
+			encodeGetAttributeSchemaResponse,
+		),
+
+		setSchema: gr.NewServer(
+			end.SetAttributeSchema,
+			decodeSetAttributeSchemaRequest,
+			encodeSetAttributeSchemaResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) GetAttributeSchema(ctx context.Context, rq *proto.GetAttributeSchemaRequest) (*proto.GetAttributeSchemaResponse, error) {
+	_, resp, err := g.getSchema.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.GetAttributeSchemaResponse), nil
+}
+
+func (g *gRPCSv) SetAttributeSchema(ctx context.Context, rq *proto.SetAttributeSchemaRequest) (*proto.SetAttributeSchemaResponse, error) {
+	_, resp, err := g.setSchema.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.SetAttributeSchemaResponse), nil
+}
+
+func decodeGetAttributeSchemaRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	if _, valid := request.(*proto.GetAttributeSchemaRequest); !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.GetAttributeSchemaRequest{}, nil
+}
+
+func encodeGetAttributeSchemaResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.GetAttributeSchemaResponse)
+	return &proto.GetAttributeSchemaResponse{Schema: res.Schema, Updated_At: timestamppb.New(res.UpdatedAt)}, nil
+}
+
+func decodeSetAttributeSchemaRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.SetAttributeSchemaRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.SetAttributeSchemaRequest{Schema: res.Schema}, nil
+}
+
+func encodeSetAttributeSchemaResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.SetAttributeSchemaResponse)
+	return &proto.SetAttributeSchemaResponse{
+		Schema:     res.Schema,
+		Updated_At: timestamppb.New(res.UpdatedAt),
+		Reindexed:  res.Reindexed,
+	}, nil
+}
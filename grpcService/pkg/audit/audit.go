@@ -0,0 +1,342 @@
+// Package audit keeps an append-only, hash chained record of who changed
+// which user, from where and with what outcome.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+// Metadata keys a gateway forwards the caller of a request with.
+const (
+	ActorHeader     = "x-actor"
+	SourceIpHeader  = "x-source-ip"
+	RequestIdHeader = "x-request-id"
+	// RolesHeader holds the roles of the actor, comma separated.
+	RolesHeader = "x-actor-roles"
+	// ImpersonationTokenHeader holds the token of the impersonation
+	// session the actor acts through, if any.
+	ImpersonationTokenHeader = "x-impersonation-token"
+)
+
+// Anonymous is the actor of requests nobody was authenticated for.
+const Anonymous = "anonymous"
+
+// GenesisHash is the previous hash of the first entry.
+var GenesisHash = strings.Repeat("0", 64)
+
+const redacted = "[redacted]"
+
+// Caller is who sent a request.
+type Caller struct {
+	Actor     string
+	SourceIp  string
+	RequestId string
+	// Roles are the roles the gateway authenticated the actor with, comma
+	// separated.
+	Roles string
+	// ImpersonationToken is what the actor acts as another user with, as
+	// forwarded. Impersonation is the id of its session once the token was
+	// checked, entries about the caller record it.
+	ImpersonationToken string
+	Impersonation      string
+}
+
+// HasRole tells whether the actor was authenticated with role.
+func (c Caller) HasRole(role string) bool {
+	for _, r := range strings.Split(c.Roles, ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminRole is the role of callers allowed to manage the tenant, like its
+// invitations and API keys, or to impersonate its users.
+const AdminRole = "admin"
+
+// IsAdmin tells whether the actor was authenticated with the AdminRole.
+func (c Caller) IsAdmin() bool {
+	return c.HasRole(AdminRole)
+}
+
+type callerKey struct{}
+
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller carried by ctx, the zero Caller if
+// there is none.
+func CallerFromContext(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// FromIncomingContext reads the caller forwarded in the metadata of a gRPC
+// request into its context. Without a forwarded address the source is the
+// address of the peer.
+func FromIncomingContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	caller := Caller{
+		Actor:     first(md.Get(ActorHeader)),
+		SourceIp:  first(md.Get(SourceIpHeader)),
+		RequestId: first(md.Get(RequestIdHeader)),
+		Roles:     first(md.Get(RolesHeader)),
+		// Only the impersonation interceptor, once it checked the token,
+		// says which session the caller acts through.
+		ImpersonationToken: first(md.Get(ImpersonationTokenHeader)),
+	}
+	if caller.SourceIp == "" {
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			caller.SourceIp = hostOf(p.Addr.String())
+		}
+	}
+	return WithCaller(ctx, caller)
+}
+
+// ToOutgoingContext forwards the caller carried by ctx to the gRPC server
+// it is used to call.
+func ToOutgoingContext(ctx context.Context) context.Context {
+	caller, ok := ctx.Value(callerKey{}).(Caller)
+	if !ok {
+		return ctx
+	}
+
+	var kv []string
+	for _, pair := range [][2]string{
+		{ActorHeader, caller.Actor},
+		{SourceIpHeader, caller.SourceIp},
+		{RequestIdHeader, caller.RequestId},
+		{RolesHeader, caller.Roles},
+		{ImpersonationTokenHeader, caller.ImpersonationToken},
+	} {
+		if pair[1] != "" {
+			kv = append(kv, pair[0], pair[1])
+		}
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+func UnaryServerInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(FromIncomingContext(ctx), req)
+}
+
+func StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &callerStream{ss, FromIncomingContext(ss.Context())})
+}
+
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(ToOutgoingContext(ctx), method, req, reply, cc, opts...)
+}
+
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(ToOutgoingContext(ctx), desc, cc, method, opts...)
+}
+
+type callerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *callerStream) Context() context.Context {
+	return s.ctx
+}
+
+// NewEntry describes operation on a user by the caller of ctx. before and
+// after are the user around it, nil where it didn't exist, and err what the
+// operation failed with.
+func NewEntry(ctx context.Context, operation string, targetUserId string, before, after *entities.User, err error) entities.AuditEntry {
+	caller := CallerFromContext(ctx)
+	if caller.Actor == "" {
+		caller.Actor = Anonymous
+	}
+
+	return entities.AuditEntry{
+		Id:       uuid.NewString(),
+		TenantId: tenant.FromContext(ctx),
+		// The database keeps microseconds, the hash has to survive the trip.
+		Time:         time.Now().UTC().Truncate(time.Microsecond),
+		Actor:        caller.Actor,
+		SourceIp:     caller.SourceIp,
+		RequestId:    caller.RequestId,
+		Operation:    operation,
+		TargetUserId: targetUserId,
+		Diff:         Diff(before, after),
+		Outcome:      status.Code(err).String(),
+		// The actor is the real one, the session tells who it acted as.
+		ImpersonationId: caller.Impersonation,
+	}
+}
+
+type change struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Diff returns the fields that differ between before and after as a JSON
+// object. Names and emails are masked and passwords only show as changed.
+func Diff(before, after *entities.User) string {
+	type field struct {
+		name  string
+		value func(user *entities.User) (raw interface{}, shown interface{})
+	}
+	fields := []field{
+		{"name", func(u *entities.User) (interface{}, interface{}) { return u.Name, maskName(u.Name) }},
+		{"age", func(u *entities.User) (interface{}, interface{}) { return u.Age, u.Age }},
+		{"email", func(u *entities.User) (interface{}, interface{}) { return u.Email, maskEmail(u.Email) }},
+		{"password", func(u *entities.User) (interface{}, interface{}) {
+			if u.Pass == "" {
+				return nil, nil
+			}
+			return u.Pass, redacted
+		}},
+		// Attribute values may hold anything, only their names are shown.
+		{"attributes", func(u *entities.User) (interface{}, interface{}) {
+			if len(u.Attributes) == 0 {
+				return nil, nil
+			}
+			raw, _ := json.Marshal(u.Attributes)
+			return string(raw), attributeNames(u.Attributes)
+		}},
+	}
+
+	changes := make(map[string]change)
+	for _, f := range fields {
+		var c change
+		var rawBefore, rawAfter interface{}
+		if before != nil {
+			rawBefore, c.Before = f.value(before)
+		}
+		if after != nil {
+			rawAfter, c.After = f.value(after)
+		}
+		if rawBefore != rawAfter {
+			changes[f.name] = c
+		}
+	}
+
+	diff, _ := json.Marshal(changes)
+	return string(diff)
+}
+
+// Hash chains entry to the entry before it, whose hash is prevHash. The
+// tenant is left out for the default one, entries written before tenants
+// existed keep their hash.
+func Hash(prevHash string, entry entities.AuditEntry) string {
+	fields := []string{
+		entry.Id,
+		entry.Time.UTC().Format(time.RFC3339Nano),
+		entry.Actor,
+		entry.SourceIp,
+		entry.RequestId,
+		entry.Operation,
+		entry.TargetUserId,
+		entry.Diff,
+		entry.Outcome,
+	}
+	if entry.TenantId != "" && entry.TenantId != tenant.Default {
+		fields = append(fields, entry.TenantId)
+	}
+	// Labelled, so it can't be mistaken for a tenant, and left out when
+	// empty so the hashes of earlier entries still match.
+	if entry.ImpersonationId != "" {
+		fields = append(fields, "impersonation:"+entry.ImpersonationId)
+	}
+	payload, _ := json.Marshal(fields)
+
+	sum := sha256.Sum256(append([]byte(prevHash+"\n"), payload...))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChainError is the first entry whose hash doesn't match its content or
+// the entry before it.
+type ChainError struct {
+	Seq int64
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit chain broken at entry %d", e.Seq)
+}
+
+// Verify checks that consecutive entries, in seq order, chain to each
+// other and that none of them was altered.
+func Verify(entries []entities.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return VerifyFrom(entries[0].PrevHash, entries)
+}
+
+// VerifyFrom is Verify for entries following the entry whose hash is
+// prevHash, it lets a chain be checked a page at a time.
+func VerifyFrom(prevHash string, entries []entities.AuditEntry) error {
+	for _, entry := range entries {
+		if entry.PrevHash != prevHash || Hash(entry.PrevHash, entry) != entry.Hash {
+			return &ChainError{Seq: entry.Seq}
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+func maskName(name string) string {
+	if name == "" {
+		return ""
+	}
+	r, _ := utf8.DecodeRuneInString(name)
+	return string(r) + "***"
+}
+
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return maskName(email)
+	}
+	return maskName(email[:at]) + email[at:]
+}
+
+func attributeNames(attrs map[string]interface{}) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
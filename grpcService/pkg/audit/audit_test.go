@@ -0,0 +1,200 @@
+package audit_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+func TestDiff(t *testing.T) {
+	before := &entities.User{Name: "Timoteo", Age: 19, Email: "timoteo@globant.com", Pass: "$2a$10$old"}
+	after := &entities.User{Name: "Timoteo", Age: 20, Email: "timo@globant.com", Pass: "$2a$10$new"}
+
+	var diff map[string]map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(audit.Diff(before, after)), &diff))
+	assert.Equal(t, map[string]map[string]interface{}{
+		"age":      {"before": float64(19), "after": float64(20)},
+		"email":    {"before": "t***@globant.com", "after": "t***@globant.com"},
+		"password": {"before": "[redacted]", "after": "[redacted]"},
+	}, diff)
+
+	assert.NoError(t, json.Unmarshal([]byte(audit.Diff(nil, before)), &diff))
+	assert.Equal(t, "T***", diff["name"]["after"])
+	assert.NotContains(t, audit.Diff(before, nil), "$2a$10$old")
+}
+
+func TestNewEntry(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		audit.ActorHeader, "admin@globant.com",
+		audit.SourceIpHeader, "10.0.0.1",
+		audit.RequestIdHeader, "request-1",
+	))
+	ctx = audit.FromIncomingContext(ctx)
+
+	entry := audit.NewEntry(ctx, "DeleteUser", "user-1", &entities.User{Name: "Timo"}, nil, myErr.NewUserNotFound())
+	assert.Equal(t, "admin@globant.com", entry.Actor)
+	assert.Equal(t, "10.0.0.1", entry.SourceIp)
+	assert.Equal(t, "request-1", entry.RequestId)
+	assert.Equal(t, "user-1", entry.TargetUserId)
+	assert.Equal(t, "NotFound", entry.Outcome)
+
+	entry = audit.NewEntry(context.Background(), "CreateUser", "user-1", nil, &entities.User{Name: "Timo"}, nil)
+	assert.Equal(t, audit.Anonymous, entry.Actor)
+	assert.Equal(t, "OK", entry.Outcome)
+}
+
+func TestImpersonatingCaller(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		audit.ActorHeader, "admin@globant.com",
+		audit.RolesHeader, "support, admin",
+		audit.ImpersonationTokenHeader, "token",
+	))
+	caller := audit.CallerFromContext(audit.FromIncomingContext(ctx))
+	assert.True(t, caller.HasRole("admin"))
+	assert.True(t, caller.IsAdmin())
+	assert.False(t, caller.HasRole("owner"))
+	assert.Equal(t, "token", caller.ImpersonationToken)
+	// Only a checked token says which session the caller acts through.
+	assert.Empty(t, caller.Impersonation)
+
+	caller.Impersonation = "session-1"
+	entry := audit.NewEntry(audit.WithCaller(ctx, caller), "GetUser", "user-1", nil, nil, nil)
+	assert.Equal(t, "admin@globant.com", entry.Actor)
+	assert.Equal(t, "session-1", entry.ImpersonationId)
+
+	// The session is part of the hash, entries without one hash as before.
+	plain := entry
+	plain.ImpersonationId = ""
+	assert.NotEqual(t, audit.Hash(audit.GenesisHash, plain), audit.Hash(audit.GenesisHash, entry))
+}
+
+func chain(entries ...entities.AuditEntry) []entities.AuditEntry {
+	prevHash := audit.GenesisHash
+	for i := range entries {
+		entries[i].Seq = int64(i + 1)
+		entries[i].PrevHash = prevHash
+		entries[i].Hash = audit.Hash(prevHash, entries[i])
+		prevHash = entries[i].Hash
+	}
+	return entries
+}
+
+func TestVerify(t *testing.T) {
+	at := time.Date(2022, 1, 2, 3, 4, 5, 6000, time.UTC)
+	entries := func() []entities.AuditEntry {
+		return chain(
+			entities.AuditEntry{Id: "a", Time: at, Actor: "admin", Operation: "CreateUser", TargetUserId: "user-1", Diff: "{}", Outcome: "OK"},
+			entities.AuditEntry{Id: "b", Time: at, Actor: "admin", Operation: "DeleteUser", TargetUserId: "user-1", Diff: "{}", Outcome: "OK"},
+			entities.AuditEntry{Id: "c", Time: at, Actor: "admin", Operation: "DeleteUser", TargetUserId: "user-2", Diff: "{}", Outcome: "NotFound"},
+		)
+	}
+	assert.NoError(t, audit.Verify(entries()))
+
+	edited := entries()
+	edited[1].Actor = "someone else"
+	var chainErr *audit.ChainError
+	if assert.True(t, errors.As(audit.Verify(edited), &chainErr)) {
+		assert.Equal(t, int64(2), chainErr.Seq)
+	}
+
+	removed := entries()
+	removed = append(removed[:1], removed[2:]...)
+	if assert.True(t, errors.As(audit.Verify(removed), &chainErr)) {
+		assert.Equal(t, int64(3), chainErr.Seq)
+	}
+
+	// A chain checked a page at a time, the first entries gone.
+	assert.NoError(t, audit.VerifyFrom(entries()[0].Hash, entries()[1:]))
+	if assert.True(t, errors.As(audit.VerifyFrom(audit.GenesisHash, entries()[1:]), &chainErr)) {
+		assert.Equal(t, int64(2), chainErr.Seq)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+
+	at := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []entities.AuditEntry{
+		{Id: "a", Time: at, Actor: "admin", Operation: "BatchDeleteUsers", TargetUserId: "user-1", Diff: "{}", Outcome: "OK"},
+		{Id: "b", Time: at, Actor: "admin", Operation: "BatchDeleteUsers", TargetUserId: "user-2", Diff: "{}", Outcome: "OK"},
+		{Id: "c", TenantId: "acme", Time: at, Actor: "admin", Operation: "DeleteUser", TargetUserId: "user-3", Diff: "{}", Outcome: "OK"},
+	}
+	chained := chain(append([]entities.AuditEntry(nil), entries[:2]...)...)
+	acme := chain(entries[2])
+
+	argsOf := func(tenantId string, chained []entities.AuditEntry) []driver.Value {
+		args := make([]driver.Value, 0, len(chained)*14)
+		for _, e := range chained {
+			args = append(args, e.Id, e.Time, e.Actor, e.SourceIp, e.RequestId, e.Operation, e.TargetUserId, e.Diff, e.Outcome, e.PrevHash, e.Hash, tenantId, e.ImpersonationId, tenantId)
+		}
+		return args
+	}
+
+	// Each tenant appends to its own chain, the chain of acme starts with
+	// its first entry.
+	mock.ExpectBegin()
+	mock.ExpectQuery(utils.LockAuditChainQuery).WithArgs("acme").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(utils.StartAuditChainQuery).WithArgs("acme", audit.GenesisHash).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(utils.LockAuditChainQuery).WithArgs("acme").WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(audit.GenesisHash))
+	mock.ExpectExec(utils.Placeholders(utils.InsertAuditEntriesQuery, 1, 14)).
+		WithArgs(argsOf("acme", acme)...).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec(utils.AdvanceAuditChainQuery).WithArgs(acme[0].Hash, "acme").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(utils.LockAuditChainQuery).WithArgs(tenant.Default).WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(audit.GenesisHash))
+	mock.ExpectExec(utils.Placeholders(utils.InsertAuditEntriesQuery, 2, 14)).
+		WithArgs(argsOf(tenant.Default, chained)...).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	mock.ExpectExec(utils.AdvanceAuditChainQuery).WithArgs(chained[1].Hash, tenant.Default).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, audit.NewSQL(db, logger).Record(context.Background(), entries...))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListChain(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+
+	at := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	// The legacy chain holds the entries of every tenant, interleaved.
+	legacy := chain(
+		entities.AuditEntry{Id: "a", TenantId: "acme", Time: at, Actor: "admin", Operation: "CreateUser", TargetUserId: "user-1", Diff: "{}", Outcome: "OK"},
+		entities.AuditEntry{Id: "b", TenantId: "globex", Time: at, Actor: "admin", Operation: "CreateUser", TargetUserId: "user-2", Diff: "{}", Outcome: "OK"},
+		entities.AuditEntry{Id: "c", TenantId: "acme", Time: at, Actor: "admin", Operation: "DeleteUser", TargetUserId: "user-1", Diff: "{}", Outcome: "OK"},
+	)
+	rows := sqlmock.NewRows([]string{"seq", "id", "occurred_at", "actor", "source_ip", "request_id", "operation", "target_user_id", "diff", "outcome", "prev_hash", "hash", "impersonation_id", "tenant_id"})
+	for _, e := range legacy {
+		rows.AddRow(e.Seq, e.Id, e.Time, e.Actor, e.SourceIp, e.RequestId, e.Operation, e.TargetUserId, e.Diff, e.Outcome, e.PrevHash, e.Hash, e.ImpersonationId, e.TenantId)
+	}
+	mock.ExpectQuery(utils.ListAuditChainQuery).WithArgs("", int64(0), 10).WillReturnRows(rows)
+	mock.ExpectQuery(utils.GetAuditChainHeadQuery).WithArgs("").WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(legacy[2].Hash))
+
+	repo := audit.NewSQL(db, logger)
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	entries, err := repo.ListChain(ctx, true, 0, 10)
+	assert.NoError(t, err)
+	assert.NoError(t, audit.Verify(entries))
+	head, err := repo.ChainHead(ctx, true)
+	assert.NoError(t, err)
+	assert.Equal(t, entries[2].Hash, head)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
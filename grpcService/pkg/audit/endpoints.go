@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	ListAuditEvents(ctx context.Context, rq entities.ListAuditEventsRequest) (entities.ListAuditEventsResponse, error)
+	VerifyAuditLog(ctx context.Context, rq entities.VerifyAuditLogRequest) (entities.VerifyAuditLogResponse, error)
+}
+
+type Endpoints struct {
+	ListAuditEvents endpoint.Endpoint
+	VerifyAuditLog  endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		ListAuditEvents: MakeListAuditEventsEndpoint(s),
+		VerifyAuditLog:  MakeVerifyAuditLogEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		ListAuditEvents: mw(e.ListAuditEvents),
+		VerifyAuditLog:  mw(e.VerifyAuditLog),
+	}
+}
+
+func MakeListAuditEventsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListAuditEventsRequest)
+		c, err := s.ListAuditEvents(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeVerifyAuditLogEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.VerifyAuditLogRequest)
+		c, err := s.VerifyAuditLog(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
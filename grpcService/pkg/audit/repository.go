@@ -0,0 +1,242 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const insertChunkSize = 500
+
+// Recorder appends entries to the audit log.
+type Recorder interface {
+	// Record joins the transaction carried by ctx, entries of a write are
+	// kept if and only if the write is.
+	Record(ctx context.Context, entries ...entities.AuditEntry) error
+}
+
+type Repository interface {
+	Recorder
+	// ListEntries returns up to limit entries matching filter with a seq
+	// below beforeSeq, newest first. A zero beforeSeq starts at the newest.
+	ListEntries(ctx context.Context, filter entities.AuditFilter, beforeSeq int64, limit int) ([]entities.AuditEntry, error)
+	// ListChain returns up to limit entries of the chain of the tenant of
+	// ctx with a seq above afterSeq, oldest first. With legacy it returns
+	// the entries of the chain written before tenants had chains of their
+	// own instead, of every tenant.
+	ListChain(ctx context.Context, legacy bool, afterSeq int64, limit int) ([]entities.AuditEntry, error)
+	// ChainHead returns the hash of the last entry of the chain ListChain
+	// walks, GenesisHash while it has none.
+	ChainHead(ctx context.Context, legacy bool) (string, error)
+}
+
+type sqlRepo struct {
+	DB       *sql.DB
+	TxConfig database.TxConfig
+	Logger   log.Logger
+}
+
+func NewSQL(db *sql.DB, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, database.DefaultTxConfig(), log}
+}
+
+// Record appends entries to the chain of their tenant. It locks the head of
+// the chain, so concurrent writers of a tenant append one after the other,
+// until the transaction ends.
+func (repo *sqlRepo) Record(ctx context.Context, entries ...entities.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Chains are locked in tenant order, two writers can't wait on each
+	// other.
+	byTenant := make(map[string][]entities.AuditEntry)
+	var tenants []string
+	for _, entry := range entries {
+		id := tenantOf(entry)
+		if _, ok := byTenant[id]; !ok {
+			tenants = append(tenants, id)
+		}
+		byTenant[id] = append(byTenant[id], entry)
+	}
+	sort.Strings(tenants)
+
+	err := database.RunInTx(ctx, repo.DB, repo.TxConfig, func(ctx context.Context) error {
+		for _, id := range tenants {
+			if err := repo.append(ctx, id, byTenant[id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *sqlRepo) append(ctx context.Context, tenantId string, entries []entities.AuditEntry) error {
+	q := database.Conn(ctx, repo.DB)
+
+	prevHash, err := repo.lockChain(ctx, tenantId)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(entries); start += insertChunkSize {
+		end := start + insertChunkSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		args := make([]interface{}, 0, (end-start)*14)
+		for _, entry := range entries[start:end] {
+			entry.PrevHash = prevHash
+			entry.Hash = Hash(prevHash, entry)
+			prevHash = entry.Hash
+
+			args = append(args, entry.Id, entry.Time, entry.Actor, entry.SourceIp, entry.RequestId,
+				entry.Operation, entry.TargetUserId, entry.Diff, entry.Outcome, entry.PrevHash, entry.Hash, tenantId, entry.ImpersonationId, tenantId)
+		}
+
+		if _, err := q.ExecContext(ctx, utils.Placeholders(utils.InsertAuditEntriesQuery, end-start, 14), args...); err != nil {
+			return err
+		}
+	}
+
+	_, err = q.ExecContext(ctx, utils.AdvanceAuditChainQuery, prevHash, tenantId)
+	return err
+}
+
+// lockChain returns the head of the chain of tenantId, starting the chain
+// on its first entry.
+func (repo *sqlRepo) lockChain(ctx context.Context, tenantId string) (string, error) {
+	q := database.Conn(ctx, repo.DB)
+
+	var head string
+	err := q.QueryRowContext(ctx, utils.LockAuditChainQuery, tenantId).Scan(&head)
+	if err != sql.ErrNoRows {
+		return head, err
+	}
+
+	if _, err := q.ExecContext(ctx, utils.StartAuditChainQuery, tenantId, GenesisHash); err != nil {
+		return "", err
+	}
+	err = q.QueryRowContext(ctx, utils.LockAuditChainQuery, tenantId).Scan(&head)
+	return head, err
+}
+
+// ListEntries only lists the entries of the tenant of ctx.
+func (repo *sqlRepo) ListEntries(ctx context.Context, filter entities.AuditFilter, beforeSeq int64, limit int) ([]entities.AuditEntry, error) {
+	if beforeSeq == 0 {
+		beforeSeq = math.MaxInt64
+	}
+	start, end := filter.Start, filter.End
+	if end.IsZero() {
+		end = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	tenantId := tenant.FromContext(ctx)
+	args := []interface{}{tenantId, beforeSeq, start, end}
+	conditions := ""
+	if filter.Actor != "" {
+		conditions += " AND actor = ?"
+		args = append(args, filter.Actor)
+	}
+	if filter.TargetUserId != "" {
+		conditions += " AND target_user_id = ?"
+		args = append(args, filter.TargetUserId)
+	}
+	args = append(args, limit)
+
+	rows, err := repo.DB.QueryContext(ctx, fmt.Sprintf(utils.ListAuditEntriesQuery, conditions), args...)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []entities.AuditEntry
+	for rows.Next() {
+		entry := entities.AuditEntry{TenantId: tenantId}
+		if err := rows.Scan(&entry.Seq, &entry.Id, &entry.Time, &entry.Actor, &entry.SourceIp, &entry.RequestId,
+			&entry.Operation, &entry.TargetUserId, &entry.Diff, &entry.Outcome, &entry.PrevHash, &entry.Hash, &entry.ImpersonationId); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (repo *sqlRepo) ListChain(ctx context.Context, legacy bool, afterSeq int64, limit int) ([]entities.AuditEntry, error) {
+	rows, err := repo.DB.QueryContext(ctx, utils.ListAuditChainQuery, chainOf(ctx, legacy), afterSeq, limit)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []entities.AuditEntry
+	for rows.Next() {
+		var entry entities.AuditEntry
+		if err := rows.Scan(&entry.Seq, &entry.Id, &entry.Time, &entry.Actor, &entry.SourceIp, &entry.RequestId,
+			&entry.Operation, &entry.TargetUserId, &entry.Diff, &entry.Outcome, &entry.PrevHash, &entry.Hash, &entry.ImpersonationId, &entry.TenantId); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (repo *sqlRepo) ChainHead(ctx context.Context, legacy bool) (string, error) {
+	var head string
+	err := repo.DB.QueryRowContext(ctx, utils.GetAuditChainHeadQuery, chainOf(ctx, legacy)).Scan(&head)
+	if err == sql.ErrNoRows {
+		return GenesisHash, nil
+	}
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return head, err
+}
+
+// chainOf is the id of the chain of the tenant of ctx, or of the legacy one.
+func chainOf(ctx context.Context, legacy bool) string {
+	if legacy {
+		return ""
+	}
+	return tenant.FromContext(ctx)
+}
+
+func tenantOf(entry entities.AuditEntry) string {
+	if entry.TenantId == "" {
+		return tenant.Default
+	}
+	return entry.TenantId
+}
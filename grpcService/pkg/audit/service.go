@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	// verifyPageSize is how many entries VerifyAuditLog reads at a time.
+	verifyPageSize = 1000
+)
+
+type service struct {
+	Repo   Repository
+	Logger log.Logger
+}
+
+func NewService(l log.Logger, r Repository) *service {
+	return &service{r, l}
+}
+
+// ListAuditEvents pages through the audit log newest first. The page token
+// is the seq of the last entry of the page before.
+func (s *service) ListAuditEvents(ctx context.Context, rq entities.ListAuditEventsRequest) (entities.ListAuditEventsResponse, error) {
+	s.Logger.Log("request", "list audit events", "received")
+
+	if !rq.Start.IsZero() && !rq.End.IsZero() && !rq.End.After(rq.Start) {
+		return entities.ListAuditEventsResponse{}, errors.NewInvalidField("end", "must be after start")
+	}
+
+	var beforeSeq int64
+	if rq.PageToken != "" {
+		seq, err := strconv.ParseInt(rq.PageToken, 10, 64)
+		if err != nil || seq <= 0 {
+			return entities.ListAuditEventsResponse{}, errors.NewInvalidField("page_token", "malformed")
+		}
+		beforeSeq = seq
+	}
+
+	pageSize := int(rq.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	// One entry more than asked tells whether there is a next page.
+	entries, err := s.Repo.ListEntries(ctx, rq.AuditFilter, beforeSeq, pageSize+1)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListAuditEventsResponse{}, dataBaseError(err)
+	}
+
+	response := entities.ListAuditEventsResponse{Events: entries}
+	if len(entries) > pageSize {
+		response.Events = entries[:pageSize]
+		response.NextPageToken = strconv.FormatInt(entries[pageSize-1].Seq, 10)
+	}
+
+	return response, nil
+}
+
+// VerifyAuditLog walks the audit chain of the tenant, oldest entry first,
+// and stops at the first entry altered or out of the chain. Entries written
+// before tenants had chains of their own are on a chain spanning every
+// tenant, verified with Legacy by admins of the default organization only.
+func (s *service) VerifyAuditLog(ctx context.Context, rq entities.VerifyAuditLogRequest) (entities.VerifyAuditLogResponse, error) {
+	s.Logger.Log("request", "verify audit log", "received")
+
+	if rq.Legacy && (!CallerFromContext(ctx).IsAdmin() || tenant.FromContext(ctx) != tenant.Default) {
+		return entities.VerifyAuditLogResponse{}, errors.NewForbidden("only admins of the default organization can verify the legacy audit chain")
+	}
+
+	// Entries appended while walking come after the head, it is seen on
+	// the way as long as nothing was cut off the end.
+	head, err := s.Repo.ChainHead(ctx, rq.Legacy)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.VerifyAuditLogResponse{}, dataBaseError(err)
+	}
+
+	var res entities.VerifyAuditLogResponse
+	prevHash, headSeen := GenesisHash, head == GenesisHash
+	broken, err := s.walk(ctx, rq.Legacy, &res, func(entry entities.AuditEntry) bool {
+		if VerifyFrom(prevHash, []entities.AuditEntry{entry}) != nil {
+			return false
+		}
+		prevHash = entry.Hash
+		headSeen = headSeen || entry.Hash == head
+		return true
+	})
+	if err != nil || broken {
+		return res, err
+	}
+
+	res.Intact = headSeen
+	return res, nil
+}
+
+// walk pages through a chain and passes its entries to check until one
+// fails it, which is then reported in res.
+func (s *service) walk(ctx context.Context, legacy bool, res *entities.VerifyAuditLogResponse, check func(entry entities.AuditEntry) bool) (bool, error) {
+	var afterSeq int64
+	for {
+		entries, err := s.Repo.ListChain(ctx, legacy, afterSeq, verifyPageSize)
+		if err != nil {
+			level.Error(s.Logger).Log("error", err)
+			return false, dataBaseError(err)
+		}
+
+		for _, entry := range entries {
+			res.Entries++
+			if !check(entry) {
+				level.Warn(s.Logger).Log("msg", "audit chain broken", "seq", entry.Seq)
+				res.BrokenAtSeq = entry.Seq
+				return true, nil
+			}
+		}
+
+		if len(entries) < verifyPageSize {
+			return false, nil
+		}
+		afterSeq = entries[len(entries)-1].Seq
+	}
+}
+
+func dataBaseError(err error) error {
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
@@ -0,0 +1,229 @@
+package audit_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+func TestServiceListAuditEvents(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	filter := entities.AuditFilter{Start: start, End: end, Actor: "admin"}
+
+	testCases := []struct {
+		Name           string
+		Request        entities.ListAuditEventsRequest
+		buildMock      func(repo *utils.AuditRepositoryMock)
+		assertResponse func(t *testing.T, res entities.ListAuditEventsResponse, err error)
+	}{
+		{
+			Name:    "Full Page Has Next Token",
+			Request: entities.ListAuditEventsRequest{AuditFilter: filter, PageSize: 2},
+			buildMock: func(repo *utils.AuditRepositoryMock) {
+				repo.On("ListEntries", mock.Anything, filter, int64(0), 3).Return([]entities.AuditEntry{{Seq: 9}, {Seq: 7}, {Seq: 4}}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ListAuditEventsResponse, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, res.Events, 2)
+				assert.Equal(t, "7", res.NextPageToken)
+			},
+		},
+		{
+			Name:    "Last Page",
+			Request: entities.ListAuditEventsRequest{AuditFilter: filter, PageSize: 2, PageToken: "7"},
+			buildMock: func(repo *utils.AuditRepositoryMock) {
+				repo.On("ListEntries", mock.Anything, filter, int64(7), 3).Return([]entities.AuditEntry{{Seq: 4}}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ListAuditEventsResponse, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, res.Events, 1)
+				assert.Empty(t, res.NextPageToken)
+			},
+		},
+		{
+			Name:      "Malformed Page Token",
+			Request:   entities.ListAuditEventsRequest{PageToken: "next"},
+			buildMock: func(repo *utils.AuditRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.ListAuditEventsResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:      "End Before Start",
+			Request:   entities.ListAuditEventsRequest{AuditFilter: entities.AuditFilter{Start: end, End: start}},
+			buildMock: func(repo *utils.AuditRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.ListAuditEventsResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.AuditRepositoryMock)
+			tc.buildMock(repo)
+
+			srvc := audit.NewService(logger, repo)
+			res, err := srvc.ListAuditEvents(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceVerifyAuditLog(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	at := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := func() []entities.AuditEntry {
+		return chain(
+			entities.AuditEntry{Id: "b", Time: at, Actor: "admin", Operation: "DeleteUser", TargetUserId: "user-1", Diff: "{}", Outcome: "OK"},
+			entities.AuditEntry{Id: "c", Time: at, Actor: "admin", Operation: "DeleteUser", TargetUserId: "user-2", Diff: "{}", Outcome: "NotFound"},
+		)
+	}
+	head := entries()[1].Hash
+	// Entries written before tenants had chains of their own, those of
+	// acme and globex interleaved on one chain.
+	legacy := func() []entities.AuditEntry {
+		return chain(
+			entities.AuditEntry{Id: "l1", TenantId: "acme", Time: at, Actor: "admin", Operation: "CreateUser", TargetUserId: "user-1", Diff: "{}", Outcome: "OK"},
+			entities.AuditEntry{Id: "l2", TenantId: "globex", Time: at, Actor: "admin", Operation: "CreateUser", TargetUserId: "user-2", Diff: "{}", Outcome: "OK"},
+			entities.AuditEntry{Id: "l3", TenantId: "acme", Time: at, Actor: "admin", Operation: "DeleteUser", TargetUserId: "user-1", Diff: "{}", Outcome: "OK"},
+			entities.AuditEntry{Id: "l4", TenantId: "globex", Time: at, Actor: "admin", Operation: "DeleteUser", TargetUserId: "user-2", Diff: "{}", Outcome: "OK"},
+		)
+	}
+	legacyHead := legacy()[3].Hash
+
+	operator := audit.WithCaller(tenant.WithTenant(context.Background(), tenant.Default), audit.Caller{Actor: "root", Roles: "admin"})
+
+	testCases := []struct {
+		Name           string
+		Ctx            context.Context
+		Request        entities.VerifyAuditLogRequest
+		buildMock      func(repo *utils.AuditRepositoryMock)
+		assertResponse func(t *testing.T, res entities.VerifyAuditLogResponse, err error)
+	}{
+		{
+			Name: "Intact Log",
+			buildMock: func(repo *utils.AuditRepositoryMock) {
+				repo.On("ChainHead", mock.Anything, false).Return(head, nil)
+				repo.On("ListChain", mock.Anything, false, int64(0), 1000).Return(entries(), nil)
+			},
+			assertResponse: func(t *testing.T, res entities.VerifyAuditLogResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entities.VerifyAuditLogResponse{Intact: true, Entries: 2}, res)
+			},
+		},
+		{
+			Name: "Altered Entry",
+			buildMock: func(repo *utils.AuditRepositoryMock) {
+				altered := entries()
+				altered[1].Actor = "someone else"
+				repo.On("ChainHead", mock.Anything, false).Return(head, nil)
+				repo.On("ListChain", mock.Anything, false, int64(0), 1000).Return(altered, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.VerifyAuditLogResponse, err error) {
+				assert.NoError(t, err)
+				assert.False(t, res.Intact)
+				assert.Equal(t, int64(2), res.BrokenAtSeq)
+			},
+		},
+		{
+			Name: "Last Entries Missing",
+			buildMock: func(repo *utils.AuditRepositoryMock) {
+				repo.On("ChainHead", mock.Anything, false).Return(head, nil)
+				repo.On("ListChain", mock.Anything, false, int64(0), 1000).Return(entries()[:1], nil)
+			},
+			assertResponse: func(t *testing.T, res entities.VerifyAuditLogResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entities.VerifyAuditLogResponse{Entries: 1}, res)
+			},
+		},
+		{
+			Name:    "Interleaved Legacy Entries Of Two Tenants",
+			Ctx:     operator,
+			Request: entities.VerifyAuditLogRequest{Legacy: true},
+			buildMock: func(repo *utils.AuditRepositoryMock) {
+				repo.On("ChainHead", mock.Anything, true).Return(legacyHead, nil)
+				repo.On("ListChain", mock.Anything, true, int64(0), 1000).Return(legacy(), nil)
+			},
+			assertResponse: func(t *testing.T, res entities.VerifyAuditLogResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entities.VerifyAuditLogResponse{Intact: true, Entries: 4}, res)
+			},
+		},
+		{
+			Name:    "Legacy Entry Removed",
+			Ctx:     operator,
+			Request: entities.VerifyAuditLogRequest{Legacy: true},
+			buildMock: func(repo *utils.AuditRepositoryMock) {
+				removed := legacy()
+				removed = append(removed[:1], removed[2:]...)
+				repo.On("ChainHead", mock.Anything, true).Return(legacyHead, nil)
+				repo.On("ListChain", mock.Anything, true, int64(0), 1000).Return(removed, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.VerifyAuditLogResponse, err error) {
+				assert.NoError(t, err)
+				assert.False(t, res.Intact)
+				assert.Equal(t, int64(3), res.BrokenAtSeq)
+			},
+		},
+		{
+			Name:      "Legacy Chain By A Tenant Admin",
+			Ctx:       audit.WithCaller(tenant.WithTenant(context.Background(), "acme"), audit.Caller{Actor: "admin", Roles: "admin"}),
+			Request:   entities.VerifyAuditLogRequest{Legacy: true},
+			buildMock: func(repo *utils.AuditRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.VerifyAuditLogResponse, err error) {
+				assert.IsType(t, myErr.Forbidden{}, err)
+			},
+		},
+		{
+			Name:      "Legacy Chain By A Non-Admin",
+			Request:   entities.VerifyAuditLogRequest{Legacy: true},
+			buildMock: func(repo *utils.AuditRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.VerifyAuditLogResponse, err error) {
+				assert.IsType(t, myErr.Forbidden{}, err)
+			},
+		},
+		{
+			Name: "Database Down",
+			buildMock: func(repo *utils.AuditRepositoryMock) {
+				repo.On("ChainHead", mock.Anything, false).Return("", driver.ErrBadConn)
+			},
+			assertResponse: func(t *testing.T, res entities.VerifyAuditLogResponse, err error) {
+				assert.IsType(t, myErr.DataBaseUnavailable{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.AuditRepositoryMock)
+			tc.buildMock(repo)
+
+			ctx := tc.Ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			srvc := audit.NewService(logger, repo)
+			res, err := srvc.VerifyAuditLog(ctx, tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
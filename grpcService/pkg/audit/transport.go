@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	listEv gr.Handler
+	verify gr.Handler
+	proto.UnimplementedAuditServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.AuditServiceServer {
+	return &gRPCSv{
+		listEv: gr.NewServer(
+			end.ListAuditEvents,
+			decodeListAuditEventsRequest,
+			encodeListAuditEventsResponse,
+		),
+		verify: gr.NewServer(
+			end.VerifyAuditLog,
+			decodeVerifyAuditLogRequest,
+			encodeVerifyAuditLogResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) ListAuditEvents(ctx context.Context, rq *proto.ListAuditEventsRequest) (*proto.ListAuditEventsResponse, error) {
+	_, resp, err := g.listEv.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListAuditEventsResponse), nil
+}
+
+func (g *gRPCSv) VerifyAuditLog(ctx context.Context, rq *proto.VerifyAuditLogRequest) (*proto.VerifyAuditLogResponse, error) {
+	_, resp, err := g.verify.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.VerifyAuditLogResponse), nil
+}
+
+func decodeListAuditEventsRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListAuditEventsRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListAuditEventsRequest{
+		AuditFilter: entities.AuditFilter{
+			Start:        timeFromProto(res.Start),
+			End:          timeFromProto(res.End),
+			Actor:        res.Actor,
+			TargetUserId: res.Target_User_Id,
+		},
+		PageSize:  res.Page_Size,
+		PageToken: res.Page_Token,
+	}, nil
+}
+
+func encodeListAuditEventsResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListAuditEventsResponse)
+	protoResp := &proto.ListAuditEventsResponse{Next_Page_Token: res.NextPageToken}
+	for _, entry := range res.Events {
+		protoResp.Events = append(protoResp.Events, &proto.AuditEvent{
+			Seq:              entry.Seq,
+			Id:               entry.Id,
+			Time:             timestamppb.New(entry.Time),
+			Actor:            entry.Actor,
+			Source_Ip:        entry.SourceIp,
+			Request_Id:       entry.RequestId,
+			Operation:        entry.Operation,
+			Target_User_Id:   entry.TargetUserId,
+			Diff:             entry.Diff,
+			Outcome:          entry.Outcome,
+			Prev_Hash:        entry.PrevHash,
+			Hash:             entry.Hash,
+			Impersonation_Id: entry.ImpersonationId,
+		})
+	}
+	return protoResp, nil
+}
+
+func decodeVerifyAuditLogRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.VerifyAuditLogRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.VerifyAuditLogRequest{Legacy: res.Legacy}, nil
+}
+
+func encodeVerifyAuditLogResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.VerifyAuditLogResponse)
+	return &proto.VerifyAuditLogResponse{Intact: res.Intact, Entries: res.Entries, Broken_At_Seq: res.BrokenAtSeq}, nil
+}
+
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
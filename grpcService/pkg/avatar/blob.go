@@ -0,0 +1,105 @@
+package avatar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned by a BlobStore for a key nothing is kept under.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore keeps the thumbnails of avatars under keys made of segments
+// separated by slashes.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns ErrNotFound when nothing is kept under key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete succeeds when nothing is kept under key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Key is where the thumbnail of size of an avatar is kept. Every upload of
+// another picture gets keys of its own, a thumbnail under a key never
+// changes.
+func Key(tenantId string, userId string, hash string, size int) string {
+	return fmt.Sprintf("avatars/%s/%s/%s/%d", tenantId, userId, hash, size)
+}
+
+// localStore keeps blobs as files under a directory.
+type localStore struct {
+	dir string
+}
+
+func NewLocalStore(dir string) (*localStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &localStore{dir}, nil
+}
+
+func (s *localStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Readers never see a file half written.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *localStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path maps key to a file under the directory of the store, refusing keys
+// that would lead out of it.
+func (s *localStore) path(key string) (string, error) {
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsRune(segment, filepath.Separator) {
+			return "", fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
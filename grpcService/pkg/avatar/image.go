@@ -0,0 +1,155 @@
+// Package avatar turns uploaded pictures into the square thumbnails users
+// are shown with, and keeps them in a BlobStore.
+package avatar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+const (
+	// MaxSide and MaxPixels bound the pictures that get decoded, a small
+	// file can claim to hold a huge image.
+	MaxSide   = 8192
+	MaxPixels = 40 << 20
+
+	jpegQuality = 85
+)
+
+// DefaultSizes are the sides, in pixels, of the thumbnails made of every
+// avatar.
+var DefaultSizes = []int{32, 64, 128, 256, 512}
+
+// decoders are the types of picture an avatar can be made of, by sniffed
+// content type.
+var decoders = map[string]func(data []byte) (image.Image, error){
+	"image/jpeg": func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) },
+	"image/png":  func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) },
+	"image/gif":  func(data []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(data)) },
+}
+
+type Thumbnail struct {
+	Size int
+	Data []byte
+}
+
+// Image holds the thumbnails of an avatar. Hash names the uploaded picture,
+// the same picture uploaded twice gets the same one.
+type Image struct {
+	Hash        string
+	ContentType string
+	Thumbnails  []Thumbnail
+}
+
+// Process makes a square thumbnail of every size out of data, which has to
+// be a JPEG, PNG or GIF picture. The picture is cropped around its center,
+// turned upright as its EXIF orientation says and encoded again, which
+// leaves EXIF and any other metadata behind. JPEG pictures make JPEG
+// thumbnails, the others PNG ones so they keep their transparency. Only the
+// first frame of an animated GIF is kept.
+func Process(data []byte, sizes []int) (Image, error) {
+	contentType := http.DetectContentType(data)
+	decode, ok := decoders[contentType]
+	if !ok {
+		return Image{}, errors.NewUnsupportedMediaType("avatar", contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, errors.NewInvalidField("avatar", "is not a valid image")
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return Image{}, errors.NewInvalidField("avatar", "is empty")
+	}
+	if config.Width > MaxSide || config.Height > MaxSide || config.Width*config.Height > MaxPixels {
+		return Image{}, errors.NewInvalidField("avatar", fmt.Sprintf("is larger than %dx%d pixels or %d pixels in all", MaxSide, MaxSide, MaxPixels))
+	}
+
+	decoded, err := decode(data)
+	if err != nil {
+		return Image{}, errors.NewInvalidField("avatar", "is not a valid image")
+	}
+
+	square := orient(cropSquare(decoded), orientation(data))
+
+	sum := sha256.Sum256(data)
+	img := Image{Hash: hex.EncodeToString(sum[:16]), ContentType: "image/png"}
+	if contentType == "image/jpeg" {
+		img.ContentType = "image/jpeg"
+	}
+
+	for _, size := range sizes {
+		var buf bytes.Buffer
+		thumbnail := resize(square, size)
+		if img.ContentType == "image/jpeg" {
+			err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			err = png.Encode(&buf, thumbnail)
+		}
+		if err != nil {
+			return Image{}, err
+		}
+		img.Thumbnails = append(img.Thumbnails, Thumbnail{Size: size, Data: buf.Bytes()})
+	}
+
+	return img, nil
+}
+
+// cropSquare copies the largest square around the center of img.
+func cropSquare(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+
+	from := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), img, from, draw.Src)
+	return square
+}
+
+// orient turns a square picture stored with the EXIF orientation o
+// upright.
+func orient(src *image.RGBA, o int) *image.RGBA {
+	n := src.Bounds().Dx() - 1
+
+	var from func(x, y int) (int, int)
+	switch o {
+	case 2:
+		from = func(x, y int) (int, int) { return n - x, y }
+	case 3:
+		from = func(x, y int) (int, int) { return n - x, n - y }
+	case 4:
+		from = func(x, y int) (int, int) { return x, n - y }
+	case 5:
+		from = func(x, y int) (int, int) { return y, x }
+	case 6:
+		from = func(x, y int) (int, int) { return y, n - x }
+	case 7:
+		from = func(x, y int) (int, int) { return n - y, n - x }
+	case 8:
+		from = func(x, y int) (int, int) { return n - y, x }
+	default:
+		return src
+	}
+
+	dst := image.NewRGBA(src.Bounds())
+	for y := 0; y <= n; y++ {
+		for x := 0; x <= n; x++ {
+			sx, sy := from(x, y)
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,155 @@
+package avatar_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/avatar"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+var (
+	red  = color.RGBA{255, 0, 0, 255}
+	blue = color.RGBA{0, 0, 255, 255}
+)
+
+// halves draws a picture whose left half is red and right half blue.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}))
+	return buf.Bytes()
+}
+
+// withOrientation inserts an EXIF segment holding orientation o right
+// after the start of a JPEG file.
+func withOrientation(data []byte, o uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = append(tiff, 0, 1)
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], o)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	header := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, header...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r>>8 > 200 && g>>8 < 60 && b>>8 < 60
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r>>8 < 60 && g>>8 < 60 && b>>8 > 200
+}
+
+func TestProcess(t *testing.T) {
+	var pngData bytes.Buffer
+	assert.NoError(t, png.Encode(&pngData, halves(300, 200)))
+
+	img, err := avatar.Process(pngData.Bytes(), []int{32, 128, 512})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", img.ContentType)
+	assert.Len(t, img.Hash, 32)
+	assert.Len(t, img.Thumbnails, 3)
+
+	for _, thumbnail := range img.Thumbnails {
+		decoded, err := png.Decode(bytes.NewReader(thumbnail.Data))
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, thumbnail.Size, thumbnail.Size), decoded.Bounds())
+		// The square is cut around the center, half red and half blue.
+		assert.True(t, isRed(decoded.At(thumbnail.Size/8, thumbnail.Size/2)))
+		assert.True(t, isBlue(decoded.At(thumbnail.Size*7/8, thumbnail.Size/2)))
+	}
+
+	again, err := avatar.Process(pngData.Bytes(), []int{32})
+	assert.NoError(t, err)
+	assert.Equal(t, img.Hash, again.Hash)
+}
+
+func TestProcessJPEG(t *testing.T) {
+	data := withOrientation(encodeJPEG(t, halves(128, 64)), 6)
+
+	img, err := avatar.Process(data, []int{64})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", img.ContentType)
+
+	thumbnail := img.Thumbnails[0].Data
+	// Encoded again, the thumbnail keeps no EXIF segment.
+	assert.False(t, bytes.Contains(thumbnail, []byte("Exif\x00\x00")))
+
+	decoded, err := jpeg.Decode(bytes.NewReader(thumbnail))
+	assert.NoError(t, err)
+	// Orientation 6 is turned a quarter clockwise, the left half on top.
+	assert.True(t, isRed(decoded.At(32, 8)))
+	assert.True(t, isBlue(decoded.At(32, 56)))
+}
+
+func TestProcessGIF(t *testing.T) {
+	var data bytes.Buffer
+	palette := image.NewPaletted(image.Rect(0, 0, 40, 40), color.Palette{red, blue})
+	assert.NoError(t, gif.Encode(&data, palette, nil))
+
+	img, err := avatar.Process(data.Bytes(), []int{64})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", img.ContentType)
+}
+
+func TestProcessRejects(t *testing.T) {
+	// A PNG header claiming a picture far larger than its file.
+	var huge bytes.Buffer
+	assert.NoError(t, png.Encode(&huge, image.NewGray(image.Rect(0, 0, 1, 1))))
+	bomb := huge.Bytes()
+	binary.BigEndian.PutUint32(bomb[16:], 100000)
+	binary.BigEndian.PutUint32(bomb[20:], 100000)
+
+	testCases := []struct {
+		Name string
+		Data []byte
+		Err  interface{}
+	}{
+		{Name: "Not An Image", Data: []byte("name,age\ntimo,19\n"), Err: myErr.UnsupportedMediaType{}},
+		{Name: "Unsupported Image", Data: []byte("BM" + string(make([]byte, 64))), Err: myErr.UnsupportedMediaType{}},
+		{Name: "Truncated", Data: encodeJPEG(t, halves(64, 64))[:200], Err: myErr.InvalidField{}},
+		{Name: "Too Many Pixels", Data: bomb, Err: myErr.InvalidField{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := avatar.Process(tc.Data, avatar.DefaultSizes)
+			assert.IsType(t, tc.Err, err)
+		})
+	}
+}
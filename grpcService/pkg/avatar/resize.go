@@ -0,0 +1,89 @@
+package avatar
+
+import (
+	"image"
+	"math"
+)
+
+// contribution holds the weights of the source pixels, starting at start,
+// that make up one pixel of a resized row.
+type contribution struct {
+	start   int
+	weights []float64
+}
+
+// resize scales a square picture to size pixels a side with a triangle
+// filter, widened when shrinking so that every source pixel counts.
+func resize(src *image.RGBA, size int) *image.RGBA {
+	if src.Bounds().Dx() == size {
+		return src
+	}
+	// Each pass scales the rows and writes them out as columns, so two
+	// passes scale both ways and leave the picture as it was turned.
+	return scaleRows(scaleRows(src, size), size)
+}
+
+// scaleRows scales every row of src to width pixels and writes it as a
+// column of the picture returned.
+func scaleRows(src *image.RGBA, width int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), width))
+	contributions := weights(b.Dx(), width)
+
+	for y := 0; y < b.Dy(); y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, c := range contributions {
+			var r, g, bl, a float64
+			for k, w := range c.weights {
+				p := row[(c.start+k)*4:]
+				r += w * float64(p[0])
+				g += w * float64(p[1])
+				bl += w * float64(p[2])
+				a += w * float64(p[3])
+			}
+
+			p := dst.Pix[x*dst.Stride+y*4:]
+			p[0], p[1], p[2], p[3] = clamp(r), clamp(g), clamp(bl), clamp(a)
+		}
+	}
+	return dst
+}
+
+func weights(in, out int) []contribution {
+	scale := float64(in) / float64(out)
+	support := math.Max(scale, 1)
+
+	contributions := make([]contribution, out)
+	for i := range contributions {
+		center := (float64(i)+0.5)*scale - 0.5
+		lo := int(math.Max(math.Ceil(center-support), 0))
+		hi := int(math.Min(math.Floor(center+support), float64(in-1)))
+
+		c := contribution{start: lo, weights: make([]float64, hi-lo+1)}
+		var sum float64
+		for j := lo; j <= hi; j++ {
+			w := 1 - math.Abs(float64(j)-center)/support
+			if w < 0 {
+				w = 0
+			}
+			c.weights[j-lo] = w
+			sum += w
+		}
+		for k := range c.weights {
+			c.weights[k] /= sum
+		}
+		contributions[i] = c
+	}
+	return contributions
+}
+
+func clamp(v float64) uint8 {
+	v = math.Round(v)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
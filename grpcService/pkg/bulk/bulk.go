@@ -0,0 +1,300 @@
+// Package bulk reads and writes users as CSV or newline delimited JSON for
+// imports and exports.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	CSV    = "csv"
+	NDJSON = "ndjson"
+)
+
+// MaxLineBytes caps a single NDJSON line.
+const MaxLineBytes = 1 << 20
+
+// ErrUnsupportedFormat is returned for formats other than CSV and NDJSON.
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+var columns = []string{"id", "name", "age", "email", "password", "password_hash"}
+
+// Row is a user as it appears in a file. Id is optional on import, a new
+// one is generated when it is empty. Password and PasswordHash are never
+// exported.
+type Row struct {
+	Id           string `json:"id,omitempty"`
+	Name         string `json:"name"`
+	Age          uint32 `json:"age"`
+	Email        string `json:"email"`
+	Password     string `json:"password,omitempty"`
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+// RowError is a row that could not be read. The rows after it still can.
+type RowError struct {
+	Row   uint64
+	Field string
+	Err   error
+}
+
+func (e *RowError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d: %s: %v", e.Row, e.Field, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// SyntaxError is a file that can't be read past Row.
+type SyntaxError struct {
+	Row uint64
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Row == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("row %d: %s", e.Row, e.Msg)
+}
+
+// Supported tells whether format can be read and written.
+func Supported(format string) bool {
+	return format == CSV || format == NDJSON
+}
+
+func ContentType(format string) string {
+	if format == CSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+// Decoder reads rows one at a time. Next returns io.EOF after the last row,
+// a *RowError for a row that is broken and a *SyntaxError, or the error of
+// the underlying reader, when reading can't go on.
+type Decoder interface {
+	Next() (Row, error)
+}
+
+// NewDecoder reads rows in format from r. CSV files start with a header
+// naming their columns, in any order.
+func NewDecoder(format string, r io.Reader) (Decoder, error) {
+	switch format {
+	case CSV:
+		return newCSVDecoder(r)
+	case NDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), MaxLineBytes)
+		return &ndjsonDecoder{scanner: scanner}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns []string
+	row     uint64
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, &SyntaxError{Msg: "missing header"}
+	}
+	if err != nil {
+		return nil, csvError(err, 0)
+	}
+
+	decoder := &csvDecoder{reader: reader, columns: make([]string, len(header))}
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		// Spreadsheets like to start their exports with a byte order mark.
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !known(column) {
+			return nil, &SyntaxError{Msg: fmt.Sprintf("unknown column %q", column)}
+		}
+		if seen[column] {
+			return nil, &SyntaxError{Msg: fmt.Sprintf("column %q appears twice", column)}
+		}
+		seen[column] = true
+		decoder.columns[i] = column
+	}
+
+	for _, column := range []string{"name", "age", "email"} {
+		if !seen[column] {
+			return nil, &SyntaxError{Msg: fmt.Sprintf("missing column %q", column)}
+		}
+	}
+	if !seen["password"] && !seen["password_hash"] {
+		return nil, &SyntaxError{Msg: `missing column "password" or "password_hash"`}
+	}
+
+	return decoder, nil
+}
+
+func (d *csvDecoder) Next() (Row, error) {
+	record, err := d.reader.Read()
+	if err == io.EOF {
+		return Row{}, io.EOF
+	}
+	d.row++
+
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+			return Row{}, &RowError{Row: d.row, Err: fmt.Errorf("%d fields, the header has %d", len(record), len(d.columns))}
+		}
+		return Row{}, csvError(err, d.row)
+	}
+
+	var row Row
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		switch d.columns[i] {
+		case "id":
+			row.Id = value
+		case "name":
+			row.Name = value
+		case "email":
+			row.Email = value
+		case "password":
+			row.Password = value
+		case "password_hash":
+			row.PasswordHash = value
+		case "age":
+			age, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return Row{}, &RowError{Row: d.row, Field: "age", Err: errors.New("not a whole number")}
+			}
+			row.Age = uint32(age)
+		}
+	}
+
+	return row, nil
+}
+
+// csvError turns the quoting errors of the CSV reader, after which the rest
+// of the file can't be trusted, into a SyntaxError.
+func csvError(err error, row uint64) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &SyntaxError{Row: row, Msg: parseErr.Err.Error()}
+	}
+	return err
+}
+
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	row     uint64
+}
+
+func (d *ndjsonDecoder) Next() (Row, error) {
+	for d.scanner.Scan() {
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		d.row++
+
+		var row Row
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return Row{}, &RowError{Row: d.row, Field: typeErr.Field, Err: fmt.Errorf("expected a %s", typeErr.Type)}
+			}
+			return Row{}, &RowError{Row: d.row, Err: errors.New(strings.TrimPrefix(err.Error(), "json: "))}
+		}
+		if decoder.More() {
+			return Row{}, &RowError{Row: d.row, Err: errors.New("more than one object on the line")}
+		}
+
+		return row, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return Row{}, &SyntaxError{Row: d.row + 1, Msg: fmt.Sprintf("line longer than %d bytes", MaxLineBytes)}
+		}
+		return Row{}, err
+	}
+	return Row{}, io.EOF
+}
+
+// Encoder writes rows in a format, the id, name, age and email of each.
+type Encoder interface {
+	Encode(row Row) error
+	Flush() error
+}
+
+// NewEncoder writes rows in format to w. A CSV header is only written with
+// header set, so resumed exports can be appended to what came before.
+func NewEncoder(format string, w io.Writer, header bool) (Encoder, error) {
+	switch format {
+	case CSV:
+		writer := csv.NewWriter(w)
+		if header {
+			if err := writer.Write(columns[:4]); err != nil {
+				return nil, err
+			}
+		}
+		return &csvEncoder{writer: writer}, nil
+	case NDJSON:
+		return &ndjsonEncoder{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvEncoder) Encode(row Row) error {
+	return e.writer.Write([]string{row.Id, row.Name, strconv.FormatUint(uint64(row.Age), 10), row.Email})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(row Row) error {
+	row.Password, row.PasswordHash = "", ""
+	return e.encoder.Encode(row)
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return nil
+}
+
+func known(column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
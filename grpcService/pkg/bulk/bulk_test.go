@@ -0,0 +1,131 @@
+package bulk_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/bulk"
+)
+
+func TestDecodeCSV(t *testing.T) {
+	file := "\ufeffName, EMAIL ,age,password,id\n" +
+		"Timo,timoteo@globant.com,19,123,user-1\n" +
+		"Ana,ana@globant.com,old,123,\n" +
+		"Luz,luz@globant.com,30\n" +
+		"\"Sol, Maria\",sol@globant.com,25,123,\n"
+
+	decoder, err := bulk.NewDecoder(bulk.CSV, strings.NewReader(file))
+	assert.NoError(t, err)
+
+	row, err := decoder.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, bulk.Row{Id: "user-1", Name: "Timo", Age: 19, Email: "timoteo@globant.com", Password: "123"}, row)
+
+	_, err = decoder.Next()
+	var rowErr *bulk.RowError
+	assert.True(t, errors.As(err, &rowErr))
+	assert.Equal(t, uint64(2), rowErr.Row)
+	assert.Equal(t, "age", rowErr.Field)
+
+	_, err = decoder.Next()
+	assert.True(t, errors.As(err, &rowErr))
+	assert.Equal(t, uint64(3), rowErr.Row)
+
+	row, err = decoder.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "Sol, Maria", row.Name)
+
+	_, err = decoder.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecodeCSVHeader(t *testing.T) {
+	for header, msg := range map[string]string{
+		"":                                "missing header",
+		"name,email,age\n":                `missing column "password" or "password_hash"`,
+		"name,email,password\n":           `missing column "age"`,
+		"name,email,age,password,name\n":  `column "name" appears twice`,
+		"name,email,age,password,phone\n": `unknown column "phone"`,
+	} {
+		_, err := bulk.NewDecoder(bulk.CSV, strings.NewReader(header))
+		var syntaxErr *bulk.SyntaxError
+		if assert.True(t, errors.As(err, &syntaxErr), header) {
+			assert.Equal(t, msg, syntaxErr.Msg)
+		}
+	}
+}
+
+func TestDecodeNDJSON(t *testing.T) {
+	file := `{"name":"Timo","email":"timoteo@globant.com","age":19,"password_hash":"$2a$10$abc"}` + "\n" +
+		"\n" +
+		`{"name":"Ana","email":"ana@globant.com","age":"21"}` + "\n" +
+		`{"name":"Luz","phone":"123"}` + "\n" +
+		`{"name":"Sol"` + "\n"
+
+	decoder, err := bulk.NewDecoder(bulk.NDJSON, strings.NewReader(file))
+	assert.NoError(t, err)
+
+	row, err := decoder.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, bulk.Row{Name: "Timo", Age: 19, Email: "timoteo@globant.com", PasswordHash: "$2a$10$abc"}, row)
+
+	var rowErr *bulk.RowError
+	for _, want := range []bulk.RowError{{Row: 2, Field: "age"}, {Row: 3}, {Row: 4}} {
+		_, err = decoder.Next()
+		if assert.True(t, errors.As(err, &rowErr)) {
+			assert.Equal(t, want.Row, rowErr.Row)
+			assert.Equal(t, want.Field, rowErr.Field)
+		}
+	}
+
+	_, err = decoder.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecodeNDJSONLineTooLong(t *testing.T) {
+	file := `{"name":"` + strings.Repeat("a", bulk.MaxLineBytes) + `"}`
+
+	decoder, err := bulk.NewDecoder(bulk.NDJSON, strings.NewReader(file))
+	assert.NoError(t, err)
+
+	_, err = decoder.Next()
+	var syntaxErr *bulk.SyntaxError
+	assert.True(t, errors.As(err, &syntaxErr))
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	rows := []bulk.Row{
+		{Id: "user-1", Name: "Timo", Age: 19, Email: "timoteo@globant.com"},
+		{Id: "user-2", Name: "Ana \"Anita\", Maria", Age: 21, Email: "ana@globant.com"},
+	}
+
+	for _, format := range []string{bulk.CSV, bulk.NDJSON} {
+		var buf bytes.Buffer
+		encoder, err := bulk.NewEncoder(format, &buf, true)
+		assert.NoError(t, err)
+		for _, row := range rows {
+			assert.NoError(t, encoder.Encode(row))
+		}
+		assert.NoError(t, encoder.Flush())
+
+		// Exports carry no passwords, add the column imports need.
+		file := buf.String()
+		if format == bulk.CSV {
+			file = strings.Replace(file, "email\n", "email,password_hash\n", 1)
+			file = strings.ReplaceAll(file, ".com\n", ".com,\n")
+		}
+
+		decoder, err := bulk.NewDecoder(format, strings.NewReader(file))
+		assert.NoError(t, err)
+		for _, want := range rows {
+			row, err := decoder.Next()
+			assert.NoError(t, err, format)
+			assert.Equal(t, want, row, format)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/cache"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Get Stored Value", func(t *testing.T) {
+		store := cache.NewLRU(2)
+		store.Set(ctx, "a", []byte("1"), time.Minute)
+
+		value, ok, err := store.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), value)
+	})
+
+	t.Run("Evict Least Recently Used", func(t *testing.T) {
+		store := cache.NewLRU(2)
+		store.Set(ctx, "a", []byte("1"), time.Minute)
+		store.Set(ctx, "b", []byte("2"), time.Minute)
+		store.Get(ctx, "a")
+		store.Set(ctx, "c", []byte("3"), time.Minute)
+
+		_, ok, _ := store.Get(ctx, "b")
+		assert.False(t, ok)
+		_, ok, _ = store.Get(ctx, "a")
+		assert.True(t, ok)
+		assert.Equal(t, 2, store.Len())
+	})
+
+	t.Run("Expired Entry Is Missing", func(t *testing.T) {
+		store := cache.NewLRU(2)
+		store.Set(ctx, "a", []byte("1"), -time.Second)
+
+		_, ok, _ := store.Get(ctx, "a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, store.Len())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := cache.NewLRU(2)
+		store.Set(ctx, "a", []byte("1"), time.Minute)
+		store.Delete(ctx, "a")
+
+		_, ok, _ := store.Get(ctx, "a")
+		assert.False(t, ok)
+	})
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	store := cache.NewRedis(client, "test:")
+
+	_, ok, err := store.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Set(ctx, "a", []byte("1"), time.Minute))
+	assert.True(t, server.Exists("test:a"))
+
+	value, ok, err := store.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	server.FastForward(2 * time.Minute)
+	_, ok, _ = store.Get(ctx, "a")
+	assert.False(t, ok)
+
+	store.Set(ctx, "b", []byte("2"), time.Minute)
+	assert.NoError(t, store.Delete(ctx, "b"))
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+}
@@ -0,0 +1,105 @@
+package consent
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	PublishPolicy(ctx context.Context, rq entities.PublishPolicyRequest) (entities.PublishPolicyResponse, error)
+	ListPolicies(ctx context.Context, rq entities.ListPoliciesRequest) (entities.ListPoliciesResponse, error)
+	AcceptPolicy(ctx context.Context, rq entities.AcceptPolicyRequest) (entities.AcceptPolicyResponse, error)
+	ListConsents(ctx context.Context, rq entities.ListConsentsRequest) (entities.ListConsentsResponse, error)
+	WithdrawConsent(ctx context.Context, rq entities.WithdrawConsentRequest) (entities.WithdrawConsentResponse, error)
+}
+
+type Endpoints struct {
+	PublishPolicy   endpoint.Endpoint
+	ListPolicies    endpoint.Endpoint
+	AcceptPolicy    endpoint.Endpoint
+	ListConsents    endpoint.Endpoint
+	WithdrawConsent endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		PublishPolicy:   MakePublishPolicyEndpoint(s),
+		ListPolicies:    MakeListPoliciesEndpoint(s),
+		AcceptPolicy:    MakeAcceptPolicyEndpoint(s),
+		ListConsents:    MakeListConsentsEndpoint(s),
+		WithdrawConsent: MakeWithdrawConsentEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		PublishPolicy:   mw(e.PublishPolicy),
+		ListPolicies:    mw(e.ListPolicies),
+		AcceptPolicy:    mw(e.AcceptPolicy),
+		ListConsents:    mw(e.ListConsents),
+		WithdrawConsent: mw(e.WithdrawConsent),
+	}
+}
+
+func MakePublishPolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.PublishPolicyRequest)
+		c, err := s.PublishPolicy(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListPoliciesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListPoliciesRequest)
+		c, err := s.ListPolicies(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeAcceptPolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.AcceptPolicyRequest)
+		c, err := s.AcceptPolicy(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListConsentsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListConsentsRequest)
+		c, err := s.ListConsents(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeWithdrawConsentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.WithdrawConsentRequest)
+		c, err := s.WithdrawConsent(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
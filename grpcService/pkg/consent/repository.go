@@ -0,0 +1,184 @@
+package consent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+var ErrVersionExists = errors.New("consent: policy version already published")
+
+// Repository keeps the policies and consents of the tenant carried by the
+// context of each call.
+type Repository interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// CreatePolicy returns ErrVersionExists when the version of the kind
+	// was already published.
+	CreatePolicy(ctx context.Context, policy entities.Policy) error
+	// CurrentPolicies returns the current version of every kind, in kind
+	// order.
+	CurrentPolicies(ctx context.Context) ([]entities.Policy, error)
+	// ListPolicyVersions returns every version of kind, newest first.
+	ListPolicyVersions(ctx context.Context, kind string) ([]entities.Policy, error)
+	CreateConsent(ctx context.Context, consent entities.Consent) error
+	// ListConsents returns the consents of a user, newest first.
+	ListConsents(ctx context.Context, userId string) ([]entities.Consent, error)
+	// LockConsent returns the consent in force of a kind, sql.ErrNoRows
+	// when the user never gave one.
+	LockConsent(ctx context.Context, userId string, kind string) (entities.Consent, error)
+	// WithdrawConsent records the withdrawal of the consent in force of a
+	// kind.
+	WithdrawConsent(ctx context.Context, consent entities.Consent) error
+	UserExists(ctx context.Context, userId string) (bool, error)
+}
+
+type sqlRepo struct {
+	DB       *sql.DB
+	Logger   log.Logger
+	TxConfig database.TxConfig
+}
+
+func NewSQL(db *sql.DB, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, log, database.DefaultTxConfig()}
+}
+
+func (repo *sqlRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, repo.DB, repo.TxConfig, fn)
+}
+
+// conn returns the transaction in ctx, or the database when there is none.
+func (repo *sqlRepo) conn(ctx context.Context) database.Querier {
+	return database.Conn(ctx, repo.DB)
+}
+
+func (repo *sqlRepo) CreatePolicy(ctx context.Context, policy entities.Policy) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.CreatePolicyQuery,
+		tenant.FromContext(ctx), policy.Kind, policy.Version, policy.Url, policy.Mandatory, policy.PublishedAt)
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+		return ErrVersionExists
+	}
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) CurrentPolicies(ctx context.Context) ([]entities.Policy, error) {
+	return repo.listPolicies(ctx, utils.CurrentPoliciesQuery, tenant.FromContext(ctx))
+}
+
+func (repo *sqlRepo) ListPolicyVersions(ctx context.Context, kind string) ([]entities.Policy, error) {
+	return repo.listPolicies(ctx, utils.ListPolicyVersionsQuery, tenant.FromContext(ctx), kind)
+}
+
+func (repo *sqlRepo) listPolicies(ctx context.Context, query string, args ...interface{}) ([]entities.Policy, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []entities.Policy
+	for rows.Next() {
+		var policy entities.Policy
+		if err := rows.Scan(&policy.Kind, &policy.Version, &policy.Url, &policy.Mandatory, &policy.PublishedAt); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+func (repo *sqlRepo) CreateConsent(ctx context.Context, consent entities.Consent) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.CreateConsentQuery,
+		tenant.FromContext(ctx), consent.UserId, consent.Kind, consent.Version, consent.Source, consent.AcceptedAt)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) ListConsents(ctx context.Context, userId string) ([]entities.Consent, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, utils.ListConsentsQuery, tenant.FromContext(ctx), userId)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consents []entities.Consent
+	for rows.Next() {
+		consent, err := scan(rows)
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		consents = append(consents, consent)
+	}
+
+	return consents, rows.Err()
+}
+
+// LockConsent locks the row of the consent until the transaction carried
+// by ctx ends.
+func (repo *sqlRepo) LockConsent(ctx context.Context, userId string, kind string) (entities.Consent, error) {
+	consent, err := scan(repo.conn(ctx).QueryRowContext(ctx, utils.LockConsentQuery, tenant.FromContext(ctx), userId, kind))
+	if err != nil && err != sql.ErrNoRows {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return consent, err
+}
+
+func (repo *sqlRepo) WithdrawConsent(ctx context.Context, consent entities.Consent) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.WithdrawConsentQuery,
+		consent.WithdrawnAt, consent.WithdrawnFrom, tenant.FromContext(ctx), consent.UserId, consent.Kind)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) UserExists(ctx context.Context, userId string) (bool, error) {
+	var exists bool
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.UserExistsQuery, tenant.FromContext(ctx), userId).Scan(&exists)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return exists, err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(row scanner) (entities.Consent, error) {
+	var (
+		consent     entities.Consent
+		withdrawnAt sql.NullTime
+	)
+	err := row.Scan(&consent.UserId, &consent.Kind, &consent.Version, &consent.Source,
+		&consent.AcceptedAt, &withdrawnAt, &consent.WithdrawnFrom)
+	if err != nil {
+		return entities.Consent{}, err
+	}
+	consent.WithdrawnAt = withdrawnAt.Time
+
+	return consent, nil
+}
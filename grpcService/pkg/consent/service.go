@@ -0,0 +1,256 @@
+package consent
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+const maxUrlLength = 2048
+
+// Kinds name documents, like "terms" or "marketing_email", and sources
+// name where a consent was given or withdrawn, like "signup".
+var (
+	validKind    = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+	validVersion = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.+-]{0,63}$`)
+	validSource  = regexp.MustCompile(`^[a-z0-9_.:-]{1,64}$`)
+)
+
+type service struct {
+	Repo   Repository
+	Logger log.Logger
+}
+
+func NewService(l log.Logger, r Repository) *service {
+	return &service{r, l}
+}
+
+// PublishPolicy makes a new version the current one of its kind.
+func (s *service) PublishPolicy(ctx context.Context, rq entities.PublishPolicyRequest) (entities.PublishPolicyResponse, error) {
+	s.Logger.Log("request", "publish policy", "received")
+
+	if err := validKindOf(rq.Kind); err != nil {
+		return entities.PublishPolicyResponse{}, err
+	}
+	if !validVersion.MatchString(rq.Version) {
+		return entities.PublishPolicyResponse{}, errors.NewInvalidField("version", "must be 1 to 64 letters, digits, '_', '.', '+' or '-'")
+	}
+	link, err := url.Parse(rq.Url)
+	if err != nil || (link.Scheme != "https" && link.Scheme != "http") || link.Host == "" || len(rq.Url) > maxUrlLength {
+		return entities.PublishPolicyResponse{}, errors.NewInvalidField("url", "must be an http or https URL of at most 2048 bytes")
+	}
+
+	// The database keeps microseconds.
+	policy := entities.Policy{
+		Kind:        rq.Kind,
+		Version:     rq.Version,
+		Url:         rq.Url,
+		Mandatory:   rq.Mandatory,
+		PublishedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := s.Repo.CreatePolicy(ctx, policy); err != nil {
+		return entities.PublishPolicyResponse{}, s.mapError(err)
+	}
+
+	return entities.PublishPolicyResponse{
+		Policy: policy,
+		Status: entities.Status{Message: "policy published"},
+	}, nil
+}
+
+func (s *service) ListPolicies(ctx context.Context, rq entities.ListPoliciesRequest) (entities.ListPoliciesResponse, error) {
+	s.Logger.Log("request", "list policies", "received")
+
+	var (
+		policies []entities.Policy
+		err      error
+	)
+	if rq.Kind == "" {
+		policies, err = s.Repo.CurrentPolicies(ctx)
+	} else {
+		if err := validKindOf(rq.Kind); err != nil {
+			return entities.ListPoliciesResponse{}, err
+		}
+		policies, err = s.Repo.ListPolicyVersions(ctx, rq.Kind)
+	}
+	if err != nil {
+		return entities.ListPoliciesResponse{}, s.mapError(err)
+	}
+
+	return entities.ListPoliciesResponse{Policies: policies}, nil
+}
+
+// AcceptPolicy records that the user accepted the current version of a
+// policy. Accepting the version in force again changes nothing.
+func (s *service) AcceptPolicy(ctx context.Context, rq entities.AcceptPolicyRequest) (entities.AcceptPolicyResponse, error) {
+	s.Logger.Log("request", "accept policy", "received")
+
+	if err := validKindOf(rq.Kind); err != nil {
+		return entities.AcceptPolicyResponse{}, err
+	}
+	if err := validSourceOf(rq.Source); err != nil {
+		return entities.AcceptPolicyResponse{}, err
+	}
+
+	var consent entities.Consent
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		exists, err := s.Repo.UserExists(ctx, rq.UserId)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.NewUserNotFound()
+		}
+
+		versions, err := s.Repo.ListPolicyVersions(ctx, rq.Kind)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return errors.NewResourceNotFound("policy")
+		}
+		current := versions[0]
+		if rq.Version != "" && rq.Version != current.Version {
+			return errors.NewPreconditionFailed("version " + rq.Version + " of " + rq.Kind + " is not the current one")
+		}
+
+		consent, err = s.Repo.LockConsent(ctx, rq.UserId, rq.Kind)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && consent.Version == current.Version && consent.WithdrawnAt.IsZero() {
+			return nil
+		}
+
+		consent = entities.Consent{
+			UserId:     rq.UserId,
+			Kind:       rq.Kind,
+			Version:    current.Version,
+			Source:     rq.Source,
+			AcceptedAt: time.Now().UTC().Truncate(time.Microsecond),
+		}
+		return s.Repo.CreateConsent(ctx, consent)
+	})
+	if err != nil {
+		return entities.AcceptPolicyResponse{}, s.mapError(err)
+	}
+
+	return entities.AcceptPolicyResponse{Consent: consent}, nil
+}
+
+// ListConsents lists the consents of a user, withdrawn ones included, and
+// the mandatory policies it has yet to accept. The consents of a deleted
+// user are still listed.
+func (s *service) ListConsents(ctx context.Context, rq entities.ListConsentsRequest) (entities.ListConsentsResponse, error) {
+	s.Logger.Log("request", "list consents", "received")
+
+	consents, err := s.Repo.ListConsents(ctx, rq.UserId)
+	if err != nil {
+		return entities.ListConsentsResponse{}, s.mapError(err)
+	}
+
+	policies, err := s.Repo.CurrentPolicies(ctx)
+	if err != nil {
+		return entities.ListConsentsResponse{}, s.mapError(err)
+	}
+
+	return entities.ListConsentsResponse{Consents: consents, Pending: Pending(policies, consents)}, nil
+}
+
+// WithdrawConsent withdraws the consent in force of a kind. Withdrawing it
+// again changes nothing.
+func (s *service) WithdrawConsent(ctx context.Context, rq entities.WithdrawConsentRequest) (entities.WithdrawConsentResponse, error) {
+	s.Logger.Log("request", "withdraw consent", "received")
+
+	if err := validKindOf(rq.Kind); err != nil {
+		return entities.WithdrawConsentResponse{}, err
+	}
+	if err := validSourceOf(rq.Source); err != nil {
+		return entities.WithdrawConsentResponse{}, err
+	}
+
+	var consent entities.Consent
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		consent, err = s.Repo.LockConsent(ctx, rq.UserId, rq.Kind)
+		if err != nil {
+			return err
+		}
+		if !consent.WithdrawnAt.IsZero() {
+			return nil
+		}
+
+		consent.WithdrawnAt = time.Now().UTC().Truncate(time.Microsecond)
+		consent.WithdrawnFrom = rq.Source
+		return s.Repo.WithdrawConsent(ctx, consent)
+	})
+	if err != nil {
+		return entities.WithdrawConsentResponse{}, s.mapError(err)
+	}
+
+	return entities.WithdrawConsentResponse{Consent: consent}, nil
+}
+
+// Pending returns the mandatory policies among the current ones whose
+// version isn't the one of the consent in force of their kind. Consents
+// are newest first.
+func Pending(policies []entities.Policy, consents []entities.Consent) []entities.Policy {
+	inForce := map[string]entities.Consent{}
+	for _, consent := range consents {
+		if _, ok := inForce[consent.Kind]; !ok {
+			inForce[consent.Kind] = consent
+		}
+	}
+
+	var pending []entities.Policy
+	for _, policy := range policies {
+		consent, ok := inForce[policy.Kind]
+		if policy.Mandatory && (!ok || consent.Version != policy.Version || !consent.WithdrawnAt.IsZero()) {
+			pending = append(pending, policy)
+		}
+	}
+	return pending
+}
+
+func validKindOf(kind string) error {
+	if !validKind.MatchString(kind) {
+		return errors.NewInvalidField("kind", "must be 1 to 64 lowercase letters, digits or '_', starting with a letter")
+	}
+	return nil
+}
+
+func validSourceOf(source string) error {
+	if !validSource.MatchString(source) {
+		return errors.NewInvalidField("source", "must be 1 to 64 lowercase letters, digits, '_', '.', ':' or '-'")
+	}
+	return nil
+}
+
+// mapError maps the errors of the repository, passing through the ones
+// already made for the client.
+func (s *service) mapError(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return errors.NewResourceNotFound("consent")
+	case ErrVersionExists:
+		return errors.NewResourceAlreadyExists("policy version")
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	level.Error(s.Logger).Log("error", err)
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
@@ -0,0 +1,316 @@
+package consent_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/consent"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const userId = "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"
+
+var (
+	terms     = entities.Policy{Kind: "terms", Version: "2026-10", Url: "https://example.com/terms/2026-10", Mandatory: true}
+	privacy   = entities.Policy{Kind: "privacy", Version: "3", Url: "https://example.com/privacy/3", Mandatory: true}
+	marketing = entities.Policy{Kind: "marketing_email", Version: "1", Url: "https://example.com/marketing/1"}
+)
+
+func TestServicePublishPolicy(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Request        entities.PublishPolicyRequest
+		buildMock      func(repo *utils.ConsentRepositoryMock)
+		assertResponse func(t *testing.T, res entities.PublishPolicyResponse, err error)
+	}{
+		{
+			Name:    "Publish Policy",
+			Request: entities.PublishPolicyRequest{Kind: "terms", Version: "2026-10", Url: "https://example.com/terms/2026-10", Mandatory: true},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("CreatePolicy", mock.Anything, mock.MatchedBy(func(p entities.Policy) bool {
+					return p.Kind == "terms" && p.Version == "2026-10" && p.Mandatory && !p.PublishedAt.IsZero()
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.PublishPolicyResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "2026-10", res.Policy.Version)
+			},
+		},
+		{
+			Name:    "Version Already Published",
+			Request: entities.PublishPolicyRequest{Kind: "terms", Version: "2026-10", Url: "https://example.com/terms/2026-10"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("CreatePolicy", mock.Anything, mock.Anything).Return(consent.ErrVersionExists)
+			},
+			assertResponse: func(t *testing.T, res entities.PublishPolicyResponse, err error) {
+				assert.IsType(t, myErr.ResourceAlreadyExists{}, err)
+			},
+		},
+		{
+			Name:      "Invalid Kind",
+			Request:   entities.PublishPolicyRequest{Kind: "Terms of Service", Version: "1", Url: "https://example.com/terms"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.PublishPolicyResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:      "Url Is Not A Web Page",
+			Request:   entities.PublishPolicyRequest{Kind: "terms", Version: "1", Url: "javascript:alert(1)"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.PublishPolicyResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ConsentRepositoryMock)
+			tc.buildMock(repo)
+
+			res, err := consent.NewService(logger, repo).PublishPolicy(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceAcceptPolicy(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	accepted := entities.Consent{UserId: userId, Kind: "terms", Version: "2026-10", Source: "signup", AcceptedAt: time.Now()}
+
+	testCases := []struct {
+		Name           string
+		Request        entities.AcceptPolicyRequest
+		buildMock      func(repo *utils.ConsentRepositoryMock)
+		assertResponse func(t *testing.T, res entities.AcceptPolicyResponse, err error)
+	}{
+		{
+			Name:    "Current Version Is Accepted",
+			Request: entities.AcceptPolicyRequest{UserId: userId, Kind: "terms", Source: "settings"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(true, nil)
+				repo.On("ListPolicyVersions", mock.Anything, "terms").Return([]entities.Policy{terms, {Kind: "terms", Version: "2025-01"}}, nil)
+				repo.On("LockConsent", mock.Anything, userId, "terms").Return(entities.Consent{Kind: "terms", Version: "2025-01"}, nil)
+				repo.On("CreateConsent", mock.Anything, mock.MatchedBy(func(c entities.Consent) bool {
+					return c.Version == "2026-10" && c.Source == "settings" && !c.AcceptedAt.IsZero()
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AcceptPolicyResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "2026-10", res.Consent.Version)
+			},
+		},
+		{
+			Name:    "Accepting Again Changes Nothing",
+			Request: entities.AcceptPolicyRequest{UserId: userId, Kind: "terms", Version: "2026-10", Source: "settings"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(true, nil)
+				repo.On("ListPolicyVersions", mock.Anything, "terms").Return([]entities.Policy{terms}, nil)
+				repo.On("LockConsent", mock.Anything, userId, "terms").Return(accepted, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AcceptPolicyResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "signup", res.Consent.Source)
+			},
+		},
+		{
+			Name:    "Withdrawn Consent Is Given Again",
+			Request: entities.AcceptPolicyRequest{UserId: userId, Kind: "marketing_email", Source: "settings"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(true, nil)
+				repo.On("ListPolicyVersions", mock.Anything, "marketing_email").Return([]entities.Policy{marketing}, nil)
+				repo.On("LockConsent", mock.Anything, userId, "marketing_email").Return(entities.Consent{Kind: "marketing_email", Version: "1", WithdrawnAt: time.Now()}, nil)
+				repo.On("CreateConsent", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AcceptPolicyResponse, err error) {
+				assert.NoError(t, err)
+				assert.True(t, res.Consent.WithdrawnAt.IsZero())
+			},
+		},
+		{
+			Name:    "Version Is No Longer Current",
+			Request: entities.AcceptPolicyRequest{UserId: userId, Kind: "terms", Version: "2025-01", Source: "signup"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(true, nil)
+				repo.On("ListPolicyVersions", mock.Anything, "terms").Return([]entities.Policy{terms}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AcceptPolicyResponse, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name:    "Unpublished Kind",
+			Request: entities.AcceptPolicyRequest{UserId: userId, Kind: "cookies", Source: "signup"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(true, nil)
+				repo.On("ListPolicyVersions", mock.Anything, "cookies").Return([]entities.Policy(nil), nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AcceptPolicyResponse, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+		{
+			Name:    "Unknown User",
+			Request: entities.AcceptPolicyRequest{UserId: userId, Kind: "terms", Source: "signup"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(false, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AcceptPolicyResponse, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+		{
+			Name:      "Source Is Required",
+			Request:   entities.AcceptPolicyRequest{UserId: userId, Kind: "terms"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.AcceptPolicyResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ConsentRepositoryMock)
+			tc.buildMock(repo)
+
+			res, err := consent.NewService(logger, repo).AcceptPolicy(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceListConsents(t *testing.T) {
+	repo := new(utils.ConsentRepositoryMock)
+	consents := []entities.Consent{
+		{UserId: userId, Kind: "marketing_email", Version: "1", Source: "settings"},
+		{UserId: userId, Kind: "terms", Version: "2025-01", Source: "signup"},
+		{UserId: userId, Kind: "privacy", Version: "3", Source: "signup"},
+	}
+	repo.On("ListConsents", mock.Anything, userId).Return(consents, nil)
+	repo.On("CurrentPolicies", mock.Anything).Return([]entities.Policy{marketing, privacy, terms}, nil)
+
+	res, err := consent.NewService(log.NewNopLogger(), repo).ListConsents(context.Background(), entities.ListConsentsRequest{UserId: userId})
+	assert.NoError(t, err)
+	assert.Equal(t, consents, res.Consents)
+	assert.Equal(t, []entities.Policy{terms}, res.Pending)
+	repo.AssertExpectations(t)
+}
+
+func TestServiceWithdrawConsent(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	given := entities.Consent{UserId: userId, Kind: "marketing_email", Version: "1", Source: "signup", AcceptedAt: time.Now()}
+
+	testCases := []struct {
+		Name           string
+		Request        entities.WithdrawConsentRequest
+		buildMock      func(repo *utils.ConsentRepositoryMock)
+		assertResponse func(t *testing.T, res entities.WithdrawConsentResponse, err error)
+	}{
+		{
+			Name:    "Withdraw Consent",
+			Request: entities.WithdrawConsentRequest{UserId: userId, Kind: "marketing_email", Source: "unsubscribe_link"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("LockConsent", mock.Anything, userId, "marketing_email").Return(given, nil)
+				repo.On("WithdrawConsent", mock.Anything, mock.MatchedBy(func(c entities.Consent) bool {
+					return !c.WithdrawnAt.IsZero() && c.WithdrawnFrom == "unsubscribe_link" && c.Source == "signup"
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.WithdrawConsentResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "unsubscribe_link", res.Consent.WithdrawnFrom)
+			},
+		},
+		{
+			Name:    "Withdrawing Again Changes Nothing",
+			Request: entities.WithdrawConsentRequest{UserId: userId, Kind: "marketing_email", Source: "settings"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				withdrawn := given
+				withdrawn.WithdrawnAt, withdrawn.WithdrawnFrom = time.Now(), "unsubscribe_link"
+				repo.On("LockConsent", mock.Anything, userId, "marketing_email").Return(withdrawn, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.WithdrawConsentResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "unsubscribe_link", res.Consent.WithdrawnFrom)
+			},
+		},
+		{
+			Name:    "Never Given",
+			Request: entities.WithdrawConsentRequest{UserId: userId, Kind: "marketing_sms", Source: "settings"},
+			buildMock: func(repo *utils.ConsentRepositoryMock) {
+				repo.On("LockConsent", mock.Anything, userId, "marketing_sms").Return(entities.Consent{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.WithdrawConsentResponse, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ConsentRepositoryMock)
+			tc.buildMock(repo)
+
+			res, err := consent.NewService(logger, repo).WithdrawConsent(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPending(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Consents []entities.Consent
+		Pending  []entities.Policy
+	}{
+		{
+			Name:    "Nothing Accepted",
+			Pending: []entities.Policy{privacy, terms},
+		},
+		{
+			Name: "Current Versions Accepted",
+			Consents: []entities.Consent{
+				{Kind: "terms", Version: "2026-10"},
+				{Kind: "privacy", Version: "3"},
+			},
+		},
+		{
+			Name: "Older Version Accepted Last",
+			Consents: []entities.Consent{
+				{Kind: "terms", Version: "2025-01"},
+				{Kind: "terms", Version: "2026-10"},
+				{Kind: "privacy", Version: "3"},
+			},
+			Pending: []entities.Policy{terms},
+		},
+		{
+			Name: "Mandatory Policy Withdrawn",
+			Consents: []entities.Consent{
+				{Kind: "terms", Version: "2026-10"},
+				{Kind: "privacy", Version: "3", WithdrawnAt: time.Now()},
+			},
+			Pending: []entities.Policy{privacy},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Pending, consent.Pending([]entities.Policy{marketing, privacy, terms}, tc.Consents))
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package consent
+
+import (
+	"context"
+	"time"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	publish  gr.Handler
+	policies gr.Handler
+	accept   gr.Handler
+	list     gr.Handler
+	withdraw gr.Handler
+	proto.UnimplementedConsentServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.ConsentServiceServer {
+	return &gRPCSv{
+		publish: gr.NewServer(
+			end.PublishPolicy,
+			decodePublishPolicyRequest,
+			encodePublishPolicyResponse,
+		),
+
+		policies: gr.NewServer(
+			end.ListPolicies,
+			decodeListPoliciesRequest,
+			encodeListPoliciesResponse,
+		),
+
+		accept: gr.NewServer(
+			end.AcceptPolicy,
+			decodeAcceptPolicyRequest,
+			encodeAcceptPolicyResponse,
+		),
+
+		list: gr.NewServer(
+			end.ListConsents,
+			decodeListConsentsRequest,
+			encodeListConsentsResponse,
+		),
+
+		withdraw: gr.NewServer(
+			end.WithdrawConsent,
+			decodeWithdrawConsentRequest,
+			encodeWithdrawConsentResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) PublishPolicy(ctx context.Context, rq *proto.PublishPolicyRequest) (*proto.PublishPolicyResponse, error) {
+	_, resp, err := g.publish.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.PublishPolicyResponse), nil
+}
+
+func (g *gRPCSv) ListPolicies(ctx context.Context, rq *proto.ListPoliciesRequest) (*proto.ListPoliciesResponse, error) {
+	_, resp, err := g.policies.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListPoliciesResponse), nil
+}
+
+func (g *gRPCSv) AcceptPolicy(ctx context.Context, rq *proto.AcceptPolicyRequest) (*proto.AcceptPolicyResponse, error) {
+	_, resp, err := g.accept.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.AcceptPolicyResponse), nil
+}
+
+func (g *gRPCSv) ListConsents(ctx context.Context, rq *proto.ListConsentsRequest) (*proto.ListConsentsResponse, error) {
+	_, resp, err := g.list.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListConsentsResponse), nil
+}
+
+func (g *gRPCSv) WithdrawConsent(ctx context.Context, rq *proto.WithdrawConsentRequest) (*proto.WithdrawConsentResponse, error) {
+	_, resp, err := g.withdraw.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.WithdrawConsentResponse), nil
+}
+
+func decodePublishPolicyRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.PublishPolicyRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.PublishPolicyRequest{Kind: res.Kind, Version: res.Version, Url: res.Url, Mandatory: res.Mandatory}, nil
+}
+
+func encodePublishPolicyResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.PublishPolicyResponse)
+	return &proto.PublishPolicyResponse{
+		Policy: policyToProto(res.Policy),
+		Status: &proto.Status{Message: res.Status.Message, Code: res.Status.Code},
+	}, nil
+}
+
+func decodeListPoliciesRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListPoliciesRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListPoliciesRequest{Kind: res.Kind}, nil
+}
+
+func encodeListPoliciesResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListPoliciesResponse)
+	return &proto.ListPoliciesResponse{Policies: policiesToProto(res.Policies)}, nil
+}
+
+func decodeAcceptPolicyRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.AcceptPolicyRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.AcceptPolicyRequest{UserId: id.String(), Kind: res.Kind, Version: res.Version, Source: res.Source}, nil
+}
+
+func encodeAcceptPolicyResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.AcceptPolicyResponse)
+	return &proto.AcceptPolicyResponse{Consent: consentToProto(res.Consent)}, nil
+}
+
+func decodeListConsentsRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListConsentsRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ListConsentsRequest{UserId: id.String()}, nil
+}
+
+func encodeListConsentsResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListConsentsResponse)
+	protoResp := &proto.ListConsentsResponse{Pending: policiesToProto(res.Pending)}
+	for _, consent := range res.Consents {
+		protoResp.Consents = append(protoResp.Consents, consentToProto(consent))
+	}
+	return protoResp, nil
+}
+
+func decodeWithdrawConsentRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.WithdrawConsentRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.WithdrawConsentRequest{UserId: id.String(), Kind: res.Kind, Source: res.Source}, nil
+}
+
+func encodeWithdrawConsentResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.WithdrawConsentResponse)
+	return &proto.WithdrawConsentResponse{Consent: consentToProto(res.Consent)}, nil
+}
+
+func policyToProto(policy entities.Policy) *proto.Policy {
+	return &proto.Policy{
+		Kind:         policy.Kind,
+		Version:      policy.Version,
+		Url:          policy.Url,
+		Mandatory:    policy.Mandatory,
+		Published_At: timestamppb.New(policy.PublishedAt),
+	}
+}
+
+func policiesToProto(policies []entities.Policy) []*proto.Policy {
+	var res []*proto.Policy
+	for _, policy := range policies {
+		res = append(res, policyToProto(policy))
+	}
+	return res
+}
+
+func consentToProto(consent entities.Consent) *proto.Consent {
+	return &proto.Consent{
+		User_Id:        consent.UserId,
+		Kind:           consent.Kind,
+		Version:        consent.Version,
+		Source:         consent.Source,
+		Accepted_At:    timestamppb.New(consent.AcceptedAt),
+		Withdrawn_At:   timeToProto(consent.WithdrawnAt),
+		Withdrawn_From: consent.WithdrawnFrom,
+	}
+}
+
+// timeToProto leaves unset times out of the response.
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
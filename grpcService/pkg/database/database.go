@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-sql-driver/mysql"
+)
+
+// Config holds the connection pool settings and the startup retry policy.
+type Config struct {
+	DSN             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// StartupDeadline bounds how long Connect keeps retrying the initial ping.
+	StartupDeadline time.Duration
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxOpenConns:    25,
+		MaxIdleConns:    25,
+		ConnMaxLifetime: 5 * time.Minute,
+		StartupDeadline: 30 * time.Second,
+		InitialBackoff:  250 * time.Millisecond,
+		MaxBackoff:      5 * time.Second,
+	}
+}
+
+// Open opens a MySQL pool for cfg.DSN and waits until it answers a ping.
+func Open(ctx context.Context, cfg Config, logger log.Logger) (*sql.DB, error) {
+	db, err := sql.Open("mysql", cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Connect(ctx, db, cfg, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Configure applies the pool settings in cfg to db.
+func Configure(db *sql.DB, cfg Config) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+}
+
+// Connect applies the pool settings to db and pings it with exponential
+// backoff until it succeeds or cfg.StartupDeadline expires.
+func Connect(ctx context.Context, db *sql.DB, cfg Config, logger log.Logger) error {
+	Configure(db, cfg)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.StartupDeadline)
+	defer cancel()
+
+	backoff := cfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			level.Info(logger).Log("msg", "database is reachable", "attempts", attempt)
+			return nil
+		}
+
+		level.Warn(logger).Log("msg", "database ping failed", "attempt", attempt, "retry_in", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+	}
+}
+
+// IsUnavailable reports whether err is a connectivity failure that is worth
+// retrying, as opposed to an error produced by the query itself.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		// too many connections, server shutdown in progress, server shutting down
+		case 1040, 1053, 1077:
+			return true
+		}
+	}
+
+	return false
+}
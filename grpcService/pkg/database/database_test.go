@@ -0,0 +1,137 @@
+package database_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+)
+
+var errRefused = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+func testConfig() database.Config {
+	cfg := database.DefaultConfig()
+	cfg.StartupDeadline = time.Second
+	cfg.InitialBackoff = time.Millisecond
+	cfg.MaxBackoff = 5 * time.Millisecond
+	return cfg
+}
+
+func TestConnect(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		buildMock      func(mock sqlmock.Sqlmock)
+		assertResponse func(t *testing.T, err error)
+	}{
+		{
+			Name: "Database Reachable At First Attempt",
+			buildMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name: "Database Reachable After Retries",
+			buildMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing().WillReturnError(errRefused)
+				mock.ExpectPing().WillReturnError(errRefused)
+				mock.ExpectPing()
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			defer db.Close()
+
+			tc.buildMock(mock)
+
+			err := database.Connect(context.Background(), db, testConfig(), logger)
+			tc.assertResponse(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestConnectDeadline(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	db, mock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	defer db.Close()
+
+	for i := 0; i < 100; i++ {
+		mock.ExpectPing().WillReturnError(errRefused)
+	}
+
+	cfg := testConfig()
+	cfg.StartupDeadline = 20 * time.Millisecond
+
+	err := database.Connect(context.Background(), db, cfg, logger)
+	assert.Error(t, err)
+}
+
+func TestMonitor(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	db, mock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	defer db.Close()
+
+	var changes []bool
+	monitor := database.NewMonitor(db, time.Second, logger)
+	monitor.OnChange = func(healthy bool) {
+		changes = append(changes, healthy)
+	}
+
+	ctx := context.Background()
+
+	mock.ExpectPing()
+	assert.True(t, monitor.Check(ctx))
+
+	mock.ExpectPing().WillReturnError(errRefused)
+	assert.False(t, monitor.Check(ctx))
+	assert.False(t, monitor.Healthy())
+
+	mock.ExpectPing()
+	assert.True(t, monitor.Check(ctx))
+
+	assert.Equal(t, []bool{false, true}, changes)
+}
+
+func TestIsUnavailable(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Err      error
+		Expected bool
+	}{
+		{Name: "Nil Error", Err: nil, Expected: false},
+		{Name: "Bad Connection", Err: driver.ErrBadConn, Expected: true},
+		{Name: "Invalid Connection", Err: mysql.ErrInvalidConn, Expected: true},
+		{Name: "Network Error", Err: errRefused, Expected: true},
+		{Name: "Too Many Connections", Err: &mysql.MySQLError{Number: 1040}, Expected: true},
+		{Name: "Duplicate Entry", Err: &mysql.MySQLError{Number: 1062}, Expected: false},
+		{Name: "Syntax Error", Err: &mysql.MySQLError{Number: 1064}, Expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, database.IsUnavailable(tc.Err))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Pinger is the subset of *sql.DB the health monitor needs.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+	Stats() sql.DBStats
+}
+
+// Monitor periodically pings the database and keeps track of whether it is
+// reachable. OnChange, when set, is called every time the state flips.
+type Monitor struct {
+	db       Pinger
+	interval time.Duration
+	timeout  time.Duration
+	logger   log.Logger
+
+	OnChange func(healthy bool)
+
+	mu      sync.RWMutex
+	healthy bool
+}
+
+func NewMonitor(db Pinger, interval time.Duration, logger log.Logger) *Monitor {
+	return &Monitor{
+		db:       db,
+		interval: interval,
+		timeout:  interval / 2,
+		logger:   logger,
+		healthy:  true,
+	}
+}
+
+// Run blocks until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Check pings the database once and records the outcome.
+func (m *Monitor) Check(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	err := m.db.PingContext(ctx)
+	healthy := err == nil
+
+	stats := m.db.Stats()
+	level.Debug(m.logger).Log(
+		"msg", "database health check",
+		"healthy", healthy,
+		"open", stats.OpenConnections,
+		"in_use", stats.InUse,
+		"idle", stats.Idle,
+		"wait_count", stats.WaitCount,
+		"wait_duration", stats.WaitDuration,
+	)
+
+	m.set(healthy, err)
+
+	return healthy
+}
+
+// MarkDown flags the database as unhealthy right away, without waiting for
+// the next check. A later successful check brings it back.
+func (m *Monitor) MarkDown(err error) {
+	m.set(false, err)
+}
+
+func (m *Monitor) set(healthy bool, err error) {
+	m.mu.Lock()
+	changed := m.healthy != healthy
+	m.healthy = healthy
+	m.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if healthy {
+		level.Info(m.logger).Log("msg", "database connection recovered")
+	} else {
+		level.Error(m.logger).Log("msg", "database connection lost", "error", err)
+	}
+	if m.OnChange != nil {
+		m.OnChange(healthy)
+	}
+}
+
+func (m *Monitor) Healthy() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.healthy
+}
+
+func (m *Monitor) Stats() sql.DBStats {
+	return m.db.Stats()
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+type readYourWritesKey struct{}
+
+// WithReadYourWrites marks ctx so reads made with it go to the primary and
+// observe writes that may not have reached the replicas yet.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKey{}, true)
+}
+
+func ReadYourWrites(ctx context.Context) bool {
+	v, _ := ctx.Value(readYourWritesKey{}).(bool)
+	return v
+}
+
+type replica struct {
+	db      *sql.DB
+	monitor *Monitor
+}
+
+// ReplicaSet load balances reads over a group of read replicas, skipping the
+// ones whose last health check failed.
+type ReplicaSet struct {
+	replicas []replica
+	next     uint32
+}
+
+func NewReplicaSet(dbs []*sql.DB, interval time.Duration, logger log.Logger) *ReplicaSet {
+	set := &ReplicaSet{}
+	for i, db := range dbs {
+		set.replicas = append(set.replicas, replica{
+			db:      db,
+			monitor: NewMonitor(db, interval, log.With(logger, "replica", i)),
+		})
+	}
+	return set
+}
+
+// Run health checks every replica until ctx is cancelled.
+func (s *ReplicaSet) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range s.replicas {
+		wg.Add(1)
+		go func(m *Monitor) {
+			defer wg.Done()
+			m.Run(ctx)
+		}(r.monitor)
+	}
+	wg.Wait()
+}
+
+// Pick returns the next healthy replica in round-robin order. It returns
+// false when the set is empty or every replica is down.
+func (s *ReplicaSet) Pick() (*sql.DB, bool) {
+	n := len(s.replicas)
+	if n == 0 {
+		return nil, false
+	}
+
+	start := int(atomic.AddUint32(&s.next, 1) - 1)
+	for i := 0; i < n; i++ {
+		r := s.replicas[(start+i)%n]
+		if r.monitor.Healthy() {
+			return r.db, true
+		}
+	}
+
+	return nil, false
+}
+
+// Eject takes db out of rotation until its next successful health check.
+func (s *ReplicaSet) Eject(db *sql.DB, err error) {
+	for _, r := range s.replicas {
+		if r.db == db {
+			r.monitor.MarkDown(err)
+			return
+		}
+	}
+}
+
+func (s *ReplicaSet) Close() error {
+	var firstErr error
+	for _, r := range s.replicas {
+		if err := r.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,93 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+)
+
+func TestReplicaSetPick(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	first, _, _ := sqlmock.New()
+	defer first.Close()
+	second, _, _ := sqlmock.New()
+	defer second.Close()
+
+	testCases := []struct {
+		Name           string
+		Replicas       []*sql.DB
+		buildSet       func(set *database.ReplicaSet)
+		assertResponse func(t *testing.T, set *database.ReplicaSet)
+	}{
+		{
+			Name:     "Empty Set",
+			Replicas: nil,
+			buildSet: func(set *database.ReplicaSet) {},
+			assertResponse: func(t *testing.T, set *database.ReplicaSet) {
+				_, ok := set.Pick()
+				assert.False(t, ok)
+			},
+		},
+		{
+			Name:     "Round Robin",
+			Replicas: []*sql.DB{first, second},
+			buildSet: func(set *database.ReplicaSet) {},
+			assertResponse: func(t *testing.T, set *database.ReplicaSet) {
+				a, _ := set.Pick()
+				b, _ := set.Pick()
+				c, _ := set.Pick()
+				assert.Equal(t, first, a)
+				assert.Equal(t, second, b)
+				assert.Equal(t, first, c)
+			},
+		},
+		{
+			Name:     "Ejected Replica Is Skipped",
+			Replicas: []*sql.DB{first, second},
+			buildSet: func(set *database.ReplicaSet) {
+				set.Eject(first, errRefused)
+			},
+			assertResponse: func(t *testing.T, set *database.ReplicaSet) {
+				for i := 0; i < 3; i++ {
+					db, ok := set.Pick()
+					assert.True(t, ok)
+					assert.Equal(t, second, db)
+				}
+			},
+		},
+		{
+			Name:     "All Replicas Down",
+			Replicas: []*sql.DB{first, second},
+			buildSet: func(set *database.ReplicaSet) {
+				set.Eject(first, errRefused)
+				set.Eject(second, errRefused)
+			},
+			assertResponse: func(t *testing.T, set *database.ReplicaSet) {
+				_, ok := set.Pick()
+				assert.False(t, ok)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			set := database.NewReplicaSet(tc.Replicas, time.Second, logger)
+			tc.buildSet(set)
+			tc.assertResponse(t, set)
+		})
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, database.ReadYourWrites(ctx))
+	assert.True(t, database.ReadYourWrites(database.WithReadYourWrites(ctx)))
+}
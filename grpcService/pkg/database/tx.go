@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Querier is implemented by both *sql.DB and *sql.Tx so repositories can run
+// the same statements inside and outside a transaction.
+type Querier interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// TxConfig controls how RunInTx starts and retries transactions.
+type TxConfig struct {
+	Isolation  sql.IsolationLevel
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+func DefaultTxConfig() TxConfig {
+	return TxConfig{
+		Isolation:  sql.LevelRepeatableRead,
+		MaxRetries: 3,
+		Backoff:    10 * time.Millisecond,
+	}
+}
+
+type txKey struct{}
+
+type isolationKey struct{}
+
+// ContextWithTx returns a copy of ctx carrying tx, so every repository call
+// made with it joins the transaction.
+func ContextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// WithIsolationLevel overrides the configured isolation level for the
+// transactions started with ctx.
+func WithIsolationLevel(ctx context.Context, level sql.IsolationLevel) context.Context {
+	return context.WithValue(ctx, isolationKey{}, level)
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none.
+func Conn(ctx context.Context, db *sql.DB) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// RunInTx runs fn inside a transaction on db and commits it when fn returns
+// nil. If ctx already carries a transaction fn joins it and the outermost
+// caller stays in charge of committing. Deadlocks and lock wait timeouts
+// roll back and run fn again, up to cfg.MaxRetries times.
+func RunInTx(ctx context.Context, db *sql.DB, cfg TxConfig, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	opts := &sql.TxOptions{Isolation: cfg.Isolation}
+	if level, ok := ctx.Value(isolationKey{}).(sql.IsolationLevel); ok {
+		opts.Isolation = level
+	}
+
+	backoff := cfg.Backoff
+	for attempt := 0; ; attempt++ {
+		err := runOnce(ctx, db, opts, fn)
+		if err == nil || !IsRetryable(err) || attempt >= cfg.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func runOnce(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(ContextWithTx(ctx, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsRetryable reports whether err means the transaction lost a lock race
+// and can be safely run again from the start.
+func IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// lock wait timeout, deadlock found when trying to get lock
+		return mysqlErr.Number == 1205 || mysqlErr.Number == 1213
+	}
+	return false
+}
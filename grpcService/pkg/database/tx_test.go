@@ -0,0 +1,152 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+)
+
+func TestRunInTx(t *testing.T) {
+	cfg := database.DefaultTxConfig()
+	cfg.Backoff = time.Millisecond
+
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	failure := errors.New("query failed")
+
+	testCases := []struct {
+		Name           string
+		buildMock      func(mock sqlmock.Sqlmock)
+		buildFn        func(calls *int) func(ctx context.Context) error
+		assertResponse func(t *testing.T, calls int, err error)
+	}{
+		{
+			Name: "Commit On Success",
+			buildMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			buildFn: func(calls *int) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					tx, ok := database.TxFromContext(ctx)
+					assert.True(t, ok)
+					_, err := tx.ExecContext(ctx, "UPDATE")
+					return err
+				}
+			},
+			assertResponse: func(t *testing.T, calls int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 1, calls)
+			},
+		},
+		{
+			Name: "Rollback On Error",
+			buildMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			buildFn: func(calls *int) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					return failure
+				}
+			},
+			assertResponse: func(t *testing.T, calls int, err error) {
+				assert.ErrorIs(t, err, failure)
+				assert.Equal(t, 1, calls)
+			},
+		},
+		{
+			Name: "Retry On Deadlock",
+			buildMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+			buildFn: func(calls *int) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					if *calls == 1 {
+						return deadlock
+					}
+					return nil
+				}
+			},
+			assertResponse: func(t *testing.T, calls int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, calls)
+			},
+		},
+		{
+			Name: "Give Up After Max Retries",
+			buildMock: func(mock sqlmock.Sqlmock) {
+				for i := 0; i <= cfg.MaxRetries; i++ {
+					mock.ExpectBegin()
+					mock.ExpectRollback()
+				}
+			},
+			buildFn: func(calls *int) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					return deadlock
+				}
+			},
+			assertResponse: func(t *testing.T, calls int, err error) {
+				assert.ErrorIs(t, err, deadlock)
+				assert.Equal(t, cfg.MaxRetries+1, calls)
+			},
+		},
+		{
+			Name: "Nested Calls Join The Outer Transaction",
+			buildMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+			buildFn: func(calls *int) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					*calls++
+					outer, _ := database.TxFromContext(ctx)
+					return database.RunInTx(ctx, nil, cfg, func(ctx context.Context) error {
+						*calls++
+						inner, _ := database.TxFromContext(ctx)
+						assert.Same(t, outer, inner)
+						return nil
+					})
+				}
+			},
+			assertResponse: func(t *testing.T, calls int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, calls)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			tc.buildMock(mock)
+
+			calls := 0
+			err := database.RunInTx(context.Background(), db, cfg, tc.buildFn(&calls))
+			tc.assertResponse(t, calls, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, database.IsRetryable(&mysql.MySQLError{Number: 1213}))
+	assert.True(t, database.IsRetryable(&mysql.MySQLError{Number: 1205}))
+	assert.False(t, database.IsRetryable(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, database.IsRetryable(errors.New("query failed")))
+}
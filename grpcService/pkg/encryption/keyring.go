@@ -0,0 +1,247 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const keySize = 32
+
+var (
+	ErrMalformedCiphertext = errors.New("malformed ciphertext")
+	ErrUnknownKeyVersion   = errors.New("unknown master key version")
+)
+
+// Keyring encrypts fields with envelope encryption: every value gets its own
+// random AES-256-GCM data key, which is stored next to the value wrapped by
+// one of the versioned master keys. Rotating the master key only requires
+// rewrapping the data keys, the encrypted values themselves stay untouched.
+type Keyring struct {
+	active   uint32
+	masters  map[uint32]cipher.AEAD
+	indexKey []byte
+}
+
+type keyfile struct {
+	ActiveVersion uint32            `json:"active_version"`
+	MasterKeys    map[string]string `json:"master_keys"`
+	IndexKey      string            `json:"index_key"`
+}
+
+// LoadKeyring reads a JSON keyfile of the form
+//
+//	{
+//	  "active_version": 2,
+//	  "master_keys": {"1": "<base64 32 bytes>", "2": "<base64 32 bytes>"},
+//	  "index_key": "<base64 32 bytes>"
+//	}
+func LoadKeyring(path string) (*Keyring, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyfile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parsing keyfile: %w", err)
+	}
+
+	masters := make(map[uint32][]byte, len(file.MasterKeys))
+	for version, encoded := range file.MasterKeys {
+		v, err := strconv.ParseUint(version, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key version %q", version)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding master key %d: %w", v, err)
+		}
+		masters[uint32(v)] = key
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(file.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding index key: %w", err)
+	}
+
+	return NewKeyring(file.ActiveVersion, masters, indexKey)
+}
+
+func NewKeyring(active uint32, masters map[uint32][]byte, indexKey []byte) (*Keyring, error) {
+	if _, ok := masters[active]; !ok {
+		return nil, fmt.Errorf("active master key %d is missing", active)
+	}
+	if len(indexKey) != keySize {
+		return nil, fmt.Errorf("index key must be %d bytes", keySize)
+	}
+
+	k := &Keyring{active: active, masters: make(map[uint32]cipher.AEAD), indexKey: indexKey}
+	for version, key := range masters {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %d: %w", version, err)
+		}
+		k.masters[version] = aead
+	}
+
+	return k, nil
+}
+
+func (k *Keyring) ActiveVersion() uint32 {
+	return k.active
+}
+
+// Encrypt returns "v<version>.<wrapped data key>.<ciphertext>".
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(k.masters[k.active], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return format(k.active, wrapped, ciphertext), nil
+}
+
+func (k *Keyring) Decrypt(value string) (string, error) {
+	_, dataKey, ciphertext, err := k.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(aead, ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// Rewrap wraps the data key of value with the active master key. It reports
+// false when value already uses it.
+func (k *Keyring) Rewrap(value string) (string, bool, error) {
+	version, dataKey, ciphertext, err := k.unwrap(value)
+	if err != nil {
+		return "", false, err
+	}
+	if version == k.active {
+		return value, false, nil
+	}
+
+	wrapped, err := seal(k.masters[k.active], dataKey)
+	if err != nil {
+		return "", false, err
+	}
+
+	return format(k.active, wrapped, ciphertext), true, nil
+}
+
+// BlindIndex returns a keyed hash of value that allows equality lookups and
+// unique constraints on an encrypted column without revealing its content.
+func (k *Keyring) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (k *Keyring) unwrap(value string) (uint32, []byte, []byte, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 || !strings.HasPrefix(parts[0], "v") {
+		return 0, nil, nil, ErrMalformedCiphertext
+	}
+
+	version, err := strconv.ParseUint(parts[0][1:], 10, 32)
+	if err != nil {
+		return 0, nil, nil, ErrMalformedCiphertext
+	}
+
+	master, ok := k.masters[uint32(version)]
+	if !ok {
+		return 0, nil, nil, ErrUnknownKeyVersion
+	}
+
+	wrapped, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, nil, ErrMalformedCiphertext
+	}
+
+	ciphertext, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return 0, nil, nil, ErrMalformedCiphertext
+	}
+
+	dataKey, err := open(master, wrapped)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	return uint32(version), dataKey, ciphertext, nil
+}
+
+func format(version uint32, wrapped, ciphertext []byte) string {
+	return fmt.Sprintf("v%d.%s.%s",
+		version,
+		base64.RawURLEncoding.EncodeToString(wrapped),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+	)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes", keySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal prepends a random nonce to the sealed data.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedCiphertext
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
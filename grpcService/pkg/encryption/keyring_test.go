@@ -0,0 +1,102 @@
+package encryption_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/encryption"
+)
+
+var (
+	oldMaster = bytes.Repeat([]byte{1}, 32)
+	newMaster = bytes.Repeat([]byte{2}, 32)
+	indexKey  = bytes.Repeat([]byte{3}, 32)
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	keys, err := encryption.NewKeyring(1, map[uint32][]byte{1: oldMaster}, indexKey)
+	assert.NoError(t, err)
+
+	first, err := keys.Encrypt("timoteo@globant.com")
+	assert.NoError(t, err)
+	second, err := keys.Encrypt("timoteo@globant.com")
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "v1."))
+	assert.NotContains(t, first, "timoteo")
+	assert.NotEqual(t, first, second)
+
+	plaintext, err := keys.Decrypt(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "timoteo@globant.com", plaintext)
+}
+
+func TestDecryptTampered(t *testing.T) {
+	keys, _ := encryption.NewKeyring(1, map[uint32][]byte{1: oldMaster}, indexKey)
+
+	value, _ := keys.Encrypt("Timo")
+	parts := strings.Split(value, ".")
+	ciphertext, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	ciphertext[len(ciphertext)-1] ^= 1
+	parts[2] = base64.RawURLEncoding.EncodeToString(ciphertext)
+
+	_, err := keys.Decrypt(strings.Join(parts, "."))
+	assert.Error(t, err)
+
+	_, err = keys.Decrypt("Timo")
+	assert.ErrorIs(t, err, encryption.ErrMalformedCiphertext)
+}
+
+func TestRewrap(t *testing.T) {
+	oldKeys, _ := encryption.NewKeyring(1, map[uint32][]byte{1: oldMaster}, indexKey)
+	newKeys, _ := encryption.NewKeyring(2, map[uint32][]byte{1: oldMaster, 2: newMaster}, indexKey)
+
+	value, _ := oldKeys.Encrypt("Timo")
+
+	rewrapped, changed, err := newKeys.Rewrap(value)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, strings.HasPrefix(rewrapped, "v2."))
+	assert.Equal(t, strings.Split(value, ".")[2], strings.Split(rewrapped, ".")[2])
+
+	plaintext, err := newKeys.Decrypt(rewrapped)
+	assert.NoError(t, err)
+	assert.Equal(t, "Timo", plaintext)
+
+	_, changed, err = newKeys.Rewrap(rewrapped)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	_, err = oldKeys.Decrypt(rewrapped)
+	assert.ErrorIs(t, err, encryption.ErrUnknownKeyVersion)
+}
+
+func TestBlindIndex(t *testing.T) {
+	keys, _ := encryption.NewKeyring(1, map[uint32][]byte{1: oldMaster}, indexKey)
+
+	assert.Equal(t, keys.BlindIndex("timoteo@globant.com"), keys.BlindIndex(" Timoteo@Globant.com"))
+	assert.NotEqual(t, keys.BlindIndex("timoteo@globant.com"), keys.BlindIndex("other@globant.com"))
+	assert.Len(t, keys.BlindIndex("timoteo@globant.com"), 64)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	content := `{
+		"active_version": 2,
+		"master_keys": {"1": "` + base64.StdEncoding.EncodeToString(oldMaster) + `", "2": "` + base64.StdEncoding.EncodeToString(newMaster) + `"},
+		"index_key": "` + base64.StdEncoding.EncodeToString(indexKey) + `"
+	}`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	keys, err := encryption.LoadKeyring(path)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), keys.ActiveVersion())
+
+	_, err = encryption.NewKeyring(3, map[uint32][]byte{1: oldMaster}, indexKey)
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package entities
+
+import "time"
+
+// ApiKey lets a service, rather than a user, call the gRPC service for a
+// tenant, limited to the methods its scopes allow. Only the hash of the
+// key is kept, its prefix tells keys apart.
+type ApiKey struct {
+	Id       string
+	TenantId string
+	Name     string
+	Prefix   string
+	Hash     string `json:"-"`
+	Scopes   []string
+	// CreatedBy is the actor who created the key.
+	CreatedBy string
+	CreatedAt time.Time
+	// ExpiresAt is zero for keys that don't expire. A rotated key expires
+	// at the end of its overlap window.
+	ExpiresAt  time.Time
+	RevokedAt  time.Time
+	LastUsedAt time.Time
+	// ReplacedBy is the id of the key this one was rotated to.
+	ReplacedBy string
+}
+
+type CreateApiKeyRequest struct {
+	Name   string
+	Scopes []string
+	// TtlSeconds is how long the key lasts, it doesn't expire when 0.
+	TtlSeconds uint32
+}
+
+type CreateApiKeyResponse struct {
+	Key ApiKey
+	// Secret is the key itself, it is only returned here.
+	Secret string
+}
+
+type ListApiKeysRequest struct{}
+
+type ListApiKeysResponse struct {
+	Keys []ApiKey
+}
+
+type RevokeApiKeyRequest struct {
+	KeyId string
+}
+
+type RevokeApiKeyResponse struct {
+	Key ApiKey
+}
+
+type RotateApiKeyRequest struct {
+	KeyId string
+	// OverlapSeconds is how long the rotated key keeps working, the
+	// default overlap when 0.
+	OverlapSeconds uint32
+}
+
+type RotateApiKeyResponse struct {
+	Key    ApiKey
+	Secret string
+	// Previous is the rotated key, set to expire.
+	Previous ApiKey
+}
@@ -0,0 +1,55 @@
+package entities
+
+import "time"
+
+// AttributeSchema is the JSON Schema the attributes of the users of a
+// tenant are checked against.
+type AttributeSchema struct {
+	Schema    string
+	UpdatedAt time.Time
+}
+
+type GetAttributeSchemaRequest struct{}
+
+type GetAttributeSchemaResponse struct {
+	Schema    string
+	UpdatedAt time.Time
+}
+
+type SetAttributeSchemaRequest struct {
+	Schema string
+}
+
+// SetAttributeSchemaResponse counts the users whose indexed attributes were
+// written again for the new schema.
+type SetAttributeSchemaResponse struct {
+	Schema    string
+	UpdatedAt time.Time
+	Reindexed uint64
+}
+
+// UpdateUserAttributesRequest holds a JSON merge patch of the attributes,
+// attributes set to nil are removed.
+type UpdateUserAttributesRequest struct {
+	UserId     string
+	Attributes map[string]interface{}
+	IfMatch    string
+}
+
+type UpdateUserAttributesResponse struct {
+	Attributes map[string]interface{}
+	Etag       string
+}
+
+// ListUsersRequest keeps the users whose indexed attributes hold the values
+// of Filters, keyed by attribute name.
+type ListUsersRequest struct {
+	PageSize  uint32
+	PageToken string
+	Filters   map[string]string
+}
+
+type ListUsersResponse struct {
+	Users         []GetUserResponse
+	NextPageToken string
+}
@@ -0,0 +1,56 @@
+package entities
+
+import "time"
+
+type AuditEntry struct {
+	Seq          int64
+	Id           string
+	TenantId     string
+	Time         time.Time
+	Actor        string
+	SourceIp     string
+	RequestId    string
+	Operation    string
+	TargetUserId string
+	Diff         string
+	Outcome      string
+	// ImpersonationId is the impersonation session the actor acted
+	// through, empty when it acted as itself.
+	ImpersonationId string
+	PrevHash        string
+	Hash            string
+}
+
+type AuditFilter struct {
+	Start        time.Time
+	End          time.Time
+	Actor        string
+	TargetUserId string
+}
+
+type ListAuditEventsRequest struct {
+	AuditFilter
+	PageSize  uint32
+	PageToken string
+}
+
+type ListAuditEventsResponse struct {
+	Events        []AuditEntry
+	NextPageToken string
+}
+
+type VerifyAuditLogRequest struct {
+	// Legacy verifies the chain of the entries written before tenants had
+	// chains of their own, which spans every tenant, instead of the chain
+	// of the tenant.
+	Legacy bool
+}
+
+type VerifyAuditLogResponse struct {
+	Intact bool
+	// Entries counts the entries checked.
+	Entries int64
+	// BrokenAtSeq is the first entry altered or out of the chain. It is 0
+	// when the log isn't intact because entries are missing at its end.
+	BrokenAtSeq int64
+}
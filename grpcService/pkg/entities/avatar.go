@@ -0,0 +1,42 @@
+package entities
+
+import "time"
+
+// Avatar tells which thumbnails of the avatar of a user are kept. Hash
+// names the picture they were made of, Sizes are their sides in pixels.
+type Avatar struct {
+	UserId      string
+	Hash        string
+	ContentType string
+	Sizes       []int
+	UpdatedAt   time.Time
+}
+
+// UploadAvatarRequest names the user whose avatar the streamed picture
+// becomes.
+type UploadAvatarRequest struct {
+	UserId string
+}
+
+type UploadAvatarResponse struct {
+	ContentType string
+	Sizes       []uint32
+	Etag        string
+	UpdatedAt   time.Time
+}
+
+type GetAvatarRequest struct {
+	UserId      string
+	Size        uint32
+	IfNoneMatch string
+}
+
+// GetAvatarResponse leaves Image empty when NotModified.
+type GetAvatarResponse struct {
+	Image       []byte
+	ContentType string
+	Size        uint32
+	Etag        string
+	UpdatedAt   time.Time
+	NotModified bool
+}
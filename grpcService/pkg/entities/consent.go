@@ -0,0 +1,86 @@
+package entities
+
+import "time"
+
+// Policy is a published version of a policy document, such as the terms
+// of service or the privacy policy. Users must accept the current version
+// of every mandatory policy to authenticate. Optional policies, like the
+// marketing ones of each channel, are what users opt in to.
+type Policy struct {
+	Kind        string
+	Version     string
+	Url         string
+	Mandatory   bool
+	PublishedAt time.Time
+}
+
+// Consent records that a user accepted a version of a policy, and where.
+// A withdrawn consent keeps when and where it was withdrawn. Accepting a
+// policy again adds a consent, the last one of a kind is the one in force.
+type Consent struct {
+	UserId      string
+	Kind        string
+	Version     string
+	Source      string
+	AcceptedAt  time.Time
+	WithdrawnAt time.Time
+	// WithdrawnFrom is the source of the withdrawal.
+	WithdrawnFrom string
+}
+
+type PublishPolicyRequest struct {
+	Kind      string
+	Version   string
+	Url       string
+	Mandatory bool
+}
+
+type PublishPolicyResponse struct {
+	Policy Policy
+	Status Status
+}
+
+// ListPoliciesRequest lists the current version of every kind of policy,
+// or every version of Kind, newest first, when it is set.
+type ListPoliciesRequest struct {
+	Kind string
+}
+
+type ListPoliciesResponse struct {
+	Policies []Policy
+}
+
+// AcceptPolicyRequest accepts the current version of Kind. Version, when
+// set, must be the current one, so users can't accept a version they were
+// not shown.
+type AcceptPolicyRequest struct {
+	UserId  string
+	Kind    string
+	Version string
+	Source  string
+}
+
+type AcceptPolicyResponse struct {
+	Consent Consent
+}
+
+type ListConsentsRequest struct {
+	UserId string
+}
+
+// ListConsentsResponse holds every consent of the user, newest first, and
+// the mandatory policies it has yet to accept.
+type ListConsentsResponse struct {
+	Consents []Consent
+	Pending  []Policy
+}
+
+type WithdrawConsentRequest struct {
+	UserId string
+	Kind   string
+	Source string
+}
+
+type WithdrawConsentResponse struct {
+	Consent Consent
+}
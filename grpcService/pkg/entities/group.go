@@ -0,0 +1,108 @@
+package entities
+
+import "time"
+
+// Roles of a group member. Owners manage the group, the last one can't be
+// removed or made a plain member.
+const (
+	GroupRoleOwner  = "owner"
+	GroupRoleMember = "member"
+)
+
+// Group is a team of users of a tenant.
+type Group struct {
+	Id        string
+	Name      string
+	CreatedAt time.Time
+}
+
+type GroupMember struct {
+	GroupId  string
+	UserId   string
+	Role     string
+	JoinedAt time.Time
+}
+
+// UserGroup is a group a user is a member of, with the role it holds.
+type UserGroup struct {
+	Group Group
+	Role  string
+}
+
+type CreateGroupRequest struct {
+	Name string
+	// OwnerId optionally names a user added to the group as its owner.
+	OwnerId string
+}
+
+type CreateGroupResponse struct {
+	Group  Group
+	Status Status
+}
+
+type RenameGroupRequest struct {
+	GroupId string
+	Name    string
+}
+
+type RenameGroupResponse struct {
+	Group Group
+}
+
+type DeleteGroupRequest struct {
+	GroupId string
+}
+
+type DeleteGroupResponse struct {
+	Status Status
+}
+
+type ListGroupsRequest struct {
+	PageSize  uint32
+	PageToken string
+}
+
+type ListGroupsResponse struct {
+	Groups        []Group
+	NextPageToken string
+}
+
+type AddMemberRequest struct {
+	GroupId string
+	UserId  string
+	// Role defaults to GroupRoleMember. Adding a member again changes its
+	// role.
+	Role string
+}
+
+type AddMemberResponse struct {
+	Member GroupMember
+}
+
+type RemoveMemberRequest struct {
+	GroupId string
+	UserId  string
+}
+
+type RemoveMemberResponse struct {
+	Status Status
+}
+
+type ListMembersRequest struct {
+	GroupId   string
+	PageSize  uint32
+	PageToken string
+}
+
+type ListMembersResponse struct {
+	Members       []GroupMember
+	NextPageToken string
+}
+
+type ListUserGroupsRequest struct {
+	UserId string
+}
+
+type ListUserGroupsResponse struct {
+	Groups []UserGroup
+}
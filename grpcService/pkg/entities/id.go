@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
+)
+
+// ID is the id of a user as taken from a request. ParseID makes sure it
+// has the form of the ids the service generates, so malformed ones are
+// turned down before they reach the database.
+type ID string
+
+// ParseID checks s is a UUID, ULID or Snowflake id. The error names field,
+// the request field s was read from.
+func ParseID(field, s string) (ID, error) {
+	if s == "" {
+		return "", errors.NewInvalidField(field, "is required")
+	}
+	if !ids.Valid(s) {
+		return "", errors.NewInvalidField(field, "is not a UUID, ULID or Snowflake id")
+	}
+	return ID(s), nil
+}
+
+func (id ID) String() string {
+	return string(id)
+}
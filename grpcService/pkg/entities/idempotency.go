@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+// IdempotencyRecord is what is kept of a request sent with an idempotency
+// key: a keyed hash of its body and, once it completed, its outcome.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Status      string
+	Response    []byte
+	Code        uint32
+	Message     string
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,37 @@
+package entities
+
+import "time"
+
+// ImpersonationSession lets an admin, the actor, act as a user for a short
+// while, to see what the user sees. The reason is kept for the audit trail.
+type ImpersonationSession struct {
+	Id        string
+	Actor     string
+	UserId    string
+	Reason    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// EndedAt is set when the session was ended before it expired.
+	EndedAt time.Time
+}
+
+type ImpersonateRequest struct {
+	UserId string
+	Reason string
+	// TtlSeconds is how long the session lasts, the default one when 0.
+	TtlSeconds uint32
+}
+
+type ImpersonateResponse struct {
+	// Token is sent along every request made as the user.
+	Token   string
+	Session ImpersonationSession
+}
+
+type EndImpersonationRequest struct {
+	SessionId string
+}
+
+type EndImpersonationResponse struct {
+	Session ImpersonationSession
+}
@@ -0,0 +1,82 @@
+package entities
+
+import "time"
+
+// Statuses of an invitation. A pending invitation past its expiry is
+// reported as expired.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation asks someone to join a tenant as a user. Roles are kept with
+// the invitation for the application to grant, the invitee joins GroupId,
+// when set, as a member.
+type Invitation struct {
+	Id        string
+	Email     string
+	Roles     []string
+	GroupId   string
+	Status    string
+	InvitedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// AcceptedAt and UserId are set once the invitation is accepted.
+	AcceptedAt time.Time
+	UserId     string
+}
+
+type InviteUserRequest struct {
+	Email   string
+	Roles   []string
+	GroupId string
+}
+
+type InviteUserResponse struct {
+	Invitation Invitation
+	Status     Status
+}
+
+// AcceptInvitationRequest carries the hashed password, as a
+// CreateUserRequest does.
+type AcceptInvitationRequest struct {
+	Token string
+	Name  string
+	Pass  string
+}
+
+type AcceptInvitationResponse struct {
+	UserId string
+	// Roles are the roles the invitation grants the user, for the gateway
+	// to authenticate it with.
+	Roles      []string
+	Invitation Invitation
+}
+
+type ListInvitationsRequest struct {
+	PageSize  uint32
+	PageToken string
+}
+
+type ListInvitationsResponse struct {
+	Invitations   []Invitation
+	NextPageToken string
+}
+
+type RevokeInvitationRequest struct {
+	InvitationId string
+}
+
+type RevokeInvitationResponse struct {
+	Invitation Invitation
+}
+
+type ResendInvitationRequest struct {
+	InvitationId string
+}
+
+type ResendInvitationResponse struct {
+	Invitation Invitation
+}
@@ -0,0 +1,56 @@
+package entities
+
+import "time"
+
+// Organization is a customer hosted by the service. Its id is the tenant
+// its users belong to.
+type Organization struct {
+	Id        string
+	Name      string
+	CreatedAt time.Time
+}
+
+type CreateOrganizationRequest struct {
+	Id   string
+	Name string
+}
+
+type CreateOrganizationResponse struct {
+	Organization Organization
+	Status       Status
+}
+
+type GetOrganizationRequest struct {
+	OrganizationId string
+}
+
+type GetOrganizationResponse struct {
+	Organization Organization
+}
+
+type ListOrganizationsRequest struct {
+	PageSize  uint32
+	PageToken string
+}
+
+type ListOrganizationsResponse struct {
+	Organizations []Organization
+	NextPageToken string
+}
+
+type UpdateOrganizationRequest struct {
+	OrganizationId string
+	Name           string
+}
+
+type UpdateOrganizationResponse struct {
+	Organization Organization
+}
+
+type DeleteOrganizationRequest struct {
+	OrganizationId string
+}
+
+type DeleteOrganizationResponse struct {
+	Status Status
+}
@@ -0,0 +1,39 @@
+package entities
+
+import "time"
+
+// Preferences are the settings a user changed from their default, like its
+// locale or timezone. Values are JSON values, numbers are float64.
+type Preferences struct {
+	UserId    string
+	Values    map[string]interface{}
+	UpdatedAt time.Time
+}
+
+type GetPreferencesRequest struct {
+	UserId string
+}
+
+type GetPreferencesResponse struct {
+	// Preferences holds every setting, the ones the user never set at
+	// their default.
+	Preferences map[string]interface{}
+	// Customized names the settings the user set, in order.
+	Customized []string
+	// UpdatedAt is zero while the user never set any.
+	UpdatedAt time.Time
+}
+
+// UpdatePreferencesRequest changes the preferences of a user with a JSON
+// merge patch: settings set to null go back to their default, the others
+// are set.
+type UpdatePreferencesRequest struct {
+	UserId      string
+	Preferences map[string]interface{}
+}
+
+type UpdatePreferencesResponse struct {
+	Preferences map[string]interface{}
+	Customized  []string
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,32 @@
+package entities
+
+import "time"
+
+type ExportUserDataRequest struct {
+	UserId string
+}
+
+type ExportUserDataResponse struct {
+	Archive  []byte
+	FileName string
+}
+
+type EraseUserRequest struct {
+	UserId string
+}
+
+type EraseUserResponse struct {
+	Receipt ErasureReceipt
+}
+
+// ErasureReceipt lists what was erased of a user and what was kept, and
+// why. It is signed, the signature covers every other field.
+type ErasureReceipt struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"user_id"`
+	ErasedAt  time.Time `json:"erased_at"`
+	Erased    []string  `json:"erased"`
+	Retained  []string  `json:"retained"`
+	KeyId     string    `json:"key_id"`
+	Signature string    `json:"signature,omitempty"`
+}
@@ -1,15 +1,18 @@
 package entities
 
+import pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+
 type Status struct {
 	Code    int32
 	Message string
 }
 
 type CreateUserRequest struct {
-	Name  string
-	Pass  string
-	Age   uint32
-	Email string
+	Name       string
+	Pass       string
+	Age        uint32
+	Email      string
+	Attributes map[string]interface{}
 }
 
 type CreateUserResponse struct {
@@ -22,10 +25,12 @@ type GetUserRequest struct {
 }
 
 type GetUserResponse struct {
-	Name  string
-	Id    string
-	Age   uint32
-	Email string
+	Name       string
+	Id         string
+	Age        uint32
+	Email      string
+	Etag       string
+	Attributes map[string]interface{}
 }
 
 type AuthenticateRequest struct {
@@ -34,13 +39,124 @@ type AuthenticateRequest struct {
 }
 
 type AuthenticateResponse struct {
+	UserId string
 	Status Status
 }
 
 type DeleteUserRequest struct {
-	UserId string
+	UserId  string
+	IfMatch string
 }
 
 type DeleteUserResponse struct {
 	Status Status
 }
+
+type WatchFilter struct {
+	EventTypes []string
+	UserIds    []string
+}
+
+type WatchRequest struct {
+	ResumeToken string
+	Filter      WatchFilter
+}
+
+type WatchEvent struct {
+	Event       *pb.Event
+	ResumeToken string
+	Heartbeat   bool
+}
+
+// EventLogEntry is a user event together with its position in the event log.
+type EventLogEntry struct {
+	Seq   int64
+	Event *pb.Event
+}
+
+// Batch results keep the order of the request items. Error is nil for the
+// items that succeeded and carries a gRPC status code otherwise.
+
+type BatchCreateUsersRequest struct {
+	Users        []CreateUserRequest
+	AllOrNothing bool
+}
+
+type BatchCreateUserResult struct {
+	UserId string
+	Error  *Status
+}
+
+type BatchCreateUsersResponse struct {
+	Results []BatchCreateUserResult
+}
+
+type BatchGetUsersRequest struct {
+	UserIds []string
+}
+
+type BatchGetUserResult struct {
+	User  GetUserResponse
+	Error *Status
+}
+
+type BatchGetUsersResponse struct {
+	Results []BatchGetUserResult
+}
+
+type BatchDeleteUsersRequest struct {
+	UserIds      []string
+	AllOrNothing bool
+}
+
+type BatchDeleteUserResult struct {
+	UserId string
+	Error  *Status
+}
+
+type BatchDeleteUsersResponse struct {
+	Results []BatchDeleteUserResult
+}
+
+type ImportOptions struct {
+	Format   string
+	DryRun   bool
+	ImportId string
+}
+
+type ImportRowError struct {
+	Row   uint64
+	Field string
+	Error Status
+}
+
+type ImportUsersResponse struct {
+	ImportId        string
+	Rows            uint64
+	Imported        uint64
+	Skipped         uint64
+	Failed          uint64
+	Errors          []ImportRowError
+	ErrorsTruncated bool
+	DryRun          bool
+}
+
+// ImportCheckpoint is how far an import got, Rows counts every row up to
+// the last one committed whether it was imported or failed.
+type ImportCheckpoint struct {
+	ImportId string
+	Format   string
+	Rows     uint64
+	Imported uint64
+	Failed   uint64
+}
+
+type ExportUsersRequest struct {
+	Format  string
+	AfterId string
+}
+
+type ExportChunk struct {
+	Data   []byte
+	LastId string
+}
@@ -1,8 +1,14 @@
 package entities
 
 type User struct {
+	Id    string
 	Name  string
 	Pass  string
 	Age   uint32
 	Email string
+	// Version goes up by one on every write to the user.
+	Version uint64
+	// Attributes are the custom attributes of the user, checked against
+	// the attribute schema of its tenant.
+	Attributes map[string]interface{}
 }
@@ -0,0 +1,73 @@
+package entities
+
+import "time"
+
+type Webhook struct {
+	Id                  string
+	Url                 string
+	Secret              string
+	EventTypes          []string
+	Active              bool
+	ConsecutiveFailures uint32
+	CreatedAt           time.Time
+}
+
+type WebhookDelivery struct {
+	Id string
+	// TenantId is the tenant of the webhook, deliveries are sent outside
+	// of any request.
+	TenantId     string
+	WebhookId    string
+	EventId      string
+	EventType    string
+	Payload      []byte
+	Attempt      uint32
+	Status       string
+	ResponseCode int32
+	Error        string
+	CreatedAt    time.Time
+	CompletedAt  *time.Time
+}
+
+type CreateWebhookRequest struct {
+	Url        string
+	EventTypes []string
+	Secret     string
+}
+
+type CreateWebhookResponse struct {
+	Webhook Webhook
+	Secret  string
+	Status  Status
+}
+
+type ListWebhooksRequest struct{}
+
+type ListWebhooksResponse struct {
+	Webhooks []Webhook
+}
+
+type DeleteWebhookRequest struct {
+	WebhookId string
+}
+
+type DeleteWebhookResponse struct {
+	Status Status
+}
+
+type ListWebhookDeliveriesRequest struct {
+	WebhookId string
+	Limit     uint32
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery
+}
+
+type RedeliverWebhookRequest struct {
+	DeliveryId string
+}
+
+type RedeliverWebhookResponse struct {
+	Delivery WebhookDelivery
+}
@@ -4,11 +4,30 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// Reasons carried by an ErrorInfo detail on the gRPC statuses whose code
+// alone doesn't tell which HTTP status they stand for.
+const (
+	ReasonIdempotencyKeyReused = "IDEMPOTENCY_KEY_REUSED"
+	ReasonRequestInProgress    = "REQUEST_IN_PROGRESS"
+	ReasonCrossTenantAccess    = "CROSS_TENANT_ACCESS"
+	ReasonPayloadTooLarge      = "PAYLOAD_TOO_LARGE"
+	ReasonUnsupportedMedia     = "UNSUPPORTED_MEDIA_TYPE"
+	ReasonPolicyNotAccepted    = "POLICY_NOT_ACCEPTED"
+	ReasonForbidden            = "FORBIDDEN"
+)
+
+// violationPolicy is the type of the precondition violations naming a
+// policy a user has yet to accept.
+const violationPolicy = "POLICY"
+
 type UserNotFoundErr struct {
 	err error
 }
@@ -33,6 +52,59 @@ type UserAlreadyExists struct {
 	err error
 }
 
+type DataBaseUnavailable struct {
+	err error
+}
+
+type ResourceNotFound struct {
+	err error
+}
+
+type InvalidField struct {
+	err error
+}
+
+type PreconditionFailed struct {
+	err error
+}
+
+type IdempotencyKeyReused struct {
+	err error
+}
+
+type Unauthenticated struct {
+	err error
+}
+
+type RequestInProgress struct {
+	err error
+}
+
+type CrossTenantAccess struct {
+	err error
+}
+
+type ResourceAlreadyExists struct {
+	err error
+}
+
+type PayloadTooLarge struct {
+	err error
+}
+
+type UnsupportedMediaType struct {
+	err error
+}
+
+type Forbidden struct {
+	err error
+}
+
+type PolicyNotAccepted struct {
+	err     error
+	pending map[string]string
+}
+
 func (err FieldsMissingErr) Error() string {
 	return fmt.Sprint(err.err)
 }
@@ -57,6 +129,30 @@ func (err UserAlreadyExists) Error() string {
 	return fmt.Sprint(err.err)
 }
 
+func (err DataBaseUnavailable) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err ResourceNotFound) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err InvalidField) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err PreconditionFailed) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err IdempotencyKeyReused) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err RequestInProgress) Error() string {
+	return fmt.Sprint(err.err)
+}
+
 func NewFieldsMissing() FieldsMissingErr {
 	return FieldsMissingErr{err: errors.New("all fields are required")}
 }
@@ -81,6 +177,34 @@ func NewUserAlreadyExists() UserAlreadyExists {
 	return UserAlreadyExists{err: errors.New("user already exists in database")}
 }
 
+func NewDataBaseUnavailable() DataBaseUnavailable {
+	return DataBaseUnavailable{err: errors.New("database is unavailable, try again later")}
+}
+
+func NewResourceNotFound(resource string) ResourceNotFound {
+	return ResourceNotFound{err: fmt.Errorf("%s not found", resource)}
+}
+
+func NewInvalidField(field string, reason string) InvalidField {
+	return InvalidField{err: fmt.Errorf("invalid %s: %s", field, reason)}
+}
+
+func NewPreconditionFailed(reason string) PreconditionFailed {
+	return PreconditionFailed{err: errors.New(reason)}
+}
+
+func NewIdempotencyKeyReused() IdempotencyKeyReused {
+	return IdempotencyKeyReused{err: errors.New("idempotency key was already used with a different request")}
+}
+
+func NewUnauthenticated(reason string) Unauthenticated {
+	return Unauthenticated{err: errors.New(reason)}
+}
+
+func NewRequestInProgress() RequestInProgress {
+	return RequestInProgress{err: errors.New("a request with the same idempotency key is still in progress")}
+}
+
 func (err UserNotFoundErr) StatusCode() int {
 	return http.StatusNotFound
 }
@@ -129,6 +253,38 @@ func (err UserAlreadyExists) GRPCStatus() *status.Status {
 	return status.New(codes.AlreadyExists, err.Error())
 }
 
+func (err DataBaseUnavailable) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
+
+func (err DataBaseUnavailable) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, err.Error())
+}
+
+func (err ResourceNotFound) StatusCode() int {
+	return http.StatusNotFound
+}
+
+func (err ResourceNotFound) GRPCStatus() *status.Status {
+	return status.New(codes.NotFound, err.Error())
+}
+
+func (err InvalidField) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (err InvalidField) GRPCStatus() *status.Status {
+	return status.New(codes.InvalidArgument, err.Error())
+}
+
+func (err PreconditionFailed) StatusCode() int {
+	return http.StatusPreconditionFailed
+}
+
+func (err PreconditionFailed) GRPCStatus() *status.Status {
+	return status.New(codes.FailedPrecondition, err.Error())
+}
+
 func CustomToHttp(err error) int {
 	switch err.(type) {
 	case UserNotFoundErr:
@@ -141,8 +297,277 @@ func CustomToHttp(err error) int {
 		return http.StatusConflict
 	case DataBaseErr:
 		return http.StatusServiceUnavailable
+	case DataBaseUnavailable:
+		return http.StatusServiceUnavailable
+	case ResourceNotFound:
+		return http.StatusNotFound
+	case InvalidField:
+		return http.StatusBadRequest
+	case PreconditionFailed:
+		return http.StatusPreconditionFailed
+	case IdempotencyKeyReused:
+		return http.StatusUnprocessableEntity
+	case RequestInProgress:
+		return http.StatusConflict
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	case CrossTenantAccess:
+		return http.StatusForbidden
+	case ResourceAlreadyExists:
+		return http.StatusConflict
+	case PayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case UnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case PolicyNotAccepted:
+		return http.StatusForbidden
+	case Forbidden:
+		return http.StatusForbidden
 	default:
+		if st, ok := status.FromError(err); ok && err != nil {
+			return grpcToHttp(st)
+		}
 		return http.StatusInternalServerError
 	}
 
 }
+
+// grpcToHttp maps the statuses returned by the gRPC service, which reach
+// the HTTP gateway as plain status errors, to the HTTP status of the custom
+// error they were made from.
+func grpcToHttp(st *status.Status) int {
+	switch st.Code() {
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.InvalidArgument:
+		switch reason(st) {
+		case ReasonIdempotencyKeyReused:
+			return http.StatusUnprocessableEntity
+		case ReasonPayloadTooLarge:
+			return http.StatusRequestEntityTooLarge
+		case ReasonUnsupportedMedia:
+			return http.StatusUnsupportedMediaType
+		}
+		return http.StatusBadRequest
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.Aborted:
+		if reason(st) == ReasonRequestInProgress {
+			return http.StatusConflict
+		}
+		return http.StatusServiceUnavailable
+	case codes.FailedPrecondition:
+		if reason(st) == ReasonPolicyNotAccepted {
+			return http.StatusForbidden
+		}
+		return http.StatusPreconditionFailed
+	case codes.PermissionDenied, codes.Unauthenticated:
+		switch reason(st) {
+		case ReasonCrossTenantAccess, ReasonForbidden:
+			return http.StatusForbidden
+		}
+		return http.StatusUnauthorized
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func reason(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+// withReason attaches an ErrorInfo detail to a status, falling back to the
+// bare status if the detail can't be marshalled.
+func withReason(st *status.Status, reason string) *status.Status {
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: "users"})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
+
+func (err IdempotencyKeyReused) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+func (err IdempotencyKeyReused) GRPCStatus() *status.Status {
+	return withReason(status.New(codes.InvalidArgument, err.Error()), ReasonIdempotencyKeyReused)
+}
+
+func (err RequestInProgress) StatusCode() int {
+	return http.StatusConflict
+}
+
+func (err RequestInProgress) GRPCStatus() *status.Status {
+	return withReason(status.New(codes.Aborted, err.Error()), ReasonRequestInProgress)
+}
+
+func (err Unauthenticated) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err Unauthenticated) StatusCode() int {
+	return http.StatusUnauthorized
+}
+
+func (err Unauthenticated) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, err.Error())
+}
+
+// NewCrossTenantAccess is returned when a request is made for a tenant
+// other than the one its caller was authenticated for.
+func NewCrossTenantAccess() CrossTenantAccess {
+	return CrossTenantAccess{err: errors.New("access to another tenant is not allowed")}
+}
+
+func (err CrossTenantAccess) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err CrossTenantAccess) StatusCode() int {
+	return http.StatusForbidden
+}
+
+func (err CrossTenantAccess) GRPCStatus() *status.Status {
+	return withReason(status.New(codes.PermissionDenied, err.Error()), ReasonCrossTenantAccess)
+}
+
+// NewForbidden is returned to authenticated callers who aren't allowed what
+// they asked for.
+func NewForbidden(reason string) Forbidden {
+	return Forbidden{err: errors.New(reason)}
+}
+
+func (err Forbidden) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err Forbidden) StatusCode() int {
+	return http.StatusForbidden
+}
+
+func (err Forbidden) GRPCStatus() *status.Status {
+	return withReason(status.New(codes.PermissionDenied, err.Error()), ReasonForbidden)
+}
+
+func NewResourceAlreadyExists(resource string) ResourceAlreadyExists {
+	return ResourceAlreadyExists{err: fmt.Errorf("%s already exists", resource)}
+}
+
+func (err ResourceAlreadyExists) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err ResourceAlreadyExists) StatusCode() int {
+	return http.StatusConflict
+}
+
+func (err ResourceAlreadyExists) GRPCStatus() *status.Status {
+	return status.New(codes.AlreadyExists, err.Error())
+}
+
+func NewPayloadTooLarge(field string, limit int64) PayloadTooLarge {
+	return PayloadTooLarge{err: fmt.Errorf("%s is larger than %d bytes", field, limit)}
+}
+
+func (err PayloadTooLarge) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err PayloadTooLarge) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+func (err PayloadTooLarge) GRPCStatus() *status.Status {
+	return withReason(status.New(codes.InvalidArgument, err.Error()), ReasonPayloadTooLarge)
+}
+
+// NewUnsupportedMediaType is returned for content, sniffed or declared,
+// of a type the request doesn't take.
+func NewUnsupportedMediaType(field string, contentType string) UnsupportedMediaType {
+	return UnsupportedMediaType{err: fmt.Errorf("%s of type %s is not supported", field, contentType)}
+}
+
+func (err UnsupportedMediaType) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err UnsupportedMediaType) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+func (err UnsupportedMediaType) GRPCStatus() *status.Status {
+	return withReason(status.New(codes.InvalidArgument, err.Error()), ReasonUnsupportedMedia)
+}
+
+// NewPolicyNotAccepted is returned to users who have yet to accept the
+// current version of mandatory policies, given as versions by kind.
+func NewPolicyNotAccepted(pending map[string]string) PolicyNotAccepted {
+	kinds := make([]string, 0, len(pending))
+	for kind := range pending {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return PolicyNotAccepted{err: fmt.Errorf("the current version of %s must be accepted", strings.Join(kinds, ", ")), pending: pending}
+}
+
+func (err PolicyNotAccepted) Error() string {
+	return fmt.Sprint(err.err)
+}
+
+func (err PolicyNotAccepted) StatusCode() int {
+	return http.StatusForbidden
+}
+
+// GRPCStatus lists the pending policies as precondition violations, their
+// kind as subject and their version as description.
+func (err PolicyNotAccepted) GRPCStatus() *status.Status {
+	failure := &errdetails.PreconditionFailure{}
+	for kind, version := range err.pending {
+		failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{Type: violationPolicy, Subject: kind, Description: version})
+	}
+	sort.Slice(failure.Violations, func(i, j int) bool { return failure.Violations[i].Subject < failure.Violations[j].Subject })
+
+	st := withReason(status.New(codes.FailedPrecondition, err.Error()), ReasonPolicyNotAccepted)
+	if detailed, err := st.WithDetails(failure); err == nil {
+		st = detailed
+	}
+	return st
+}
+
+// PendingPolicies returns the versions by kind of the policies err says
+// are yet to be accepted, nil for any other error.
+func PendingPolicies(err error) map[string]string {
+	if policyErr, ok := err.(PolicyNotAccepted); ok {
+		return policyErr.pending
+	}
+	st, ok := status.FromError(err)
+	if !ok || err == nil || reason(st) != ReasonPolicyNotAccepted {
+		return nil
+	}
+
+	pending := map[string]string{}
+	for _, detail := range st.Details() {
+		if failure, ok := detail.(*errdetails.PreconditionFailure); ok {
+			for _, violation := range failure.Violations {
+				if violation.Type == violationPolicy {
+					pending[violation.Subject] = violation.Description
+				}
+			}
+		}
+	}
+	return pending
+}
@@ -0,0 +1,82 @@
+// Package etag turns row versions into entity tags and evaluates the
+// If-Match and If-None-Match preconditions of RFC 9110 against them.
+package etag
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Any is the If-Match and If-None-Match value matching every etag.
+const Any = "*"
+
+// Format returns the strong etag of a row version, quotes included.
+func Format(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// Valid tells whether header is "*" or a comma separated list of etags.
+func Valid(header string) bool {
+	if strings.TrimSpace(header) == Any {
+		return true
+	}
+	tags := split(header)
+	if len(tags) == 0 {
+		return false
+	}
+	for _, tag := range tags {
+		if _, ok := opaque(tag); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Match evaluates If-Match: header matches current if it is "*" or lists
+// current. Weak etags never match, If-Match uses strong comparison.
+func Match(header string, current string) bool {
+	if strings.TrimSpace(header) == Any {
+		return true
+	}
+	for _, tag := range split(header) {
+		if !strings.HasPrefix(tag, "W/") && tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// NoneMatch evaluates If-None-Match: it is false, and a GET can be
+// answered with 304 Not Modified, when header is "*" or lists current
+// either weak or strong.
+func NoneMatch(header string, current string) bool {
+	if strings.TrimSpace(header) == Any {
+		return false
+	}
+	current, _ = opaque(current)
+	for _, tag := range split(header) {
+		if value, ok := opaque(tag); ok && value == current {
+			return false
+		}
+	}
+	return true
+}
+
+func split(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// opaque strips the weak prefix and quotes off tag.
+func opaque(tag string) (string, bool) {
+	tag = strings.TrimPrefix(tag, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' || strings.Contains(tag[1:len(tag)-1], `"`) {
+		return "", false
+	}
+	return tag[1 : len(tag)-1], true
+}
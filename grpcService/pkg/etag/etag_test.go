@@ -0,0 +1,34 @@
+package etag_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/etag"
+)
+
+func TestMatch(t *testing.T) {
+	current := etag.Format(3)
+
+	testCases := []struct {
+		Header    string
+		Valid     bool
+		Match     bool
+		NoneMatch bool
+	}{
+		{Header: `"3"`, Valid: true, Match: true, NoneMatch: false},
+		{Header: `"2"`, Valid: true, Match: false, NoneMatch: true},
+		{Header: `"1", "3"`, Valid: true, Match: true, NoneMatch: false},
+		{Header: `W/"3"`, Valid: true, Match: false, NoneMatch: false},
+		{Header: `*`, Valid: true, Match: true, NoneMatch: false},
+		{Header: `3`, Valid: false, Match: false, NoneMatch: true},
+		{Header: ` , `, Valid: false, Match: false, NoneMatch: true},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.Valid, etag.Valid(tc.Header), tc.Header)
+		assert.Equal(t, tc.Match, etag.Match(tc.Header, current), tc.Header)
+		assert.Equal(t, tc.NoneMatch, etag.NoneMatch(tc.Header, current), tc.Header)
+	}
+}
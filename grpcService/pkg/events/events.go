@@ -0,0 +1,54 @@
+package events
+
+import (
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+const (
+	UserCreatedType = "user.created"
+	UserUpdatedType = "user.updated"
+	UserDeletedType = "user.deleted"
+)
+
+func NewUserCreated(userId string) *pb.Event {
+	event := newEvent(UserCreatedType)
+	event.Payload = &pb.Event_User_Created{User_Created: &pb.UserCreated{User_Id: userId}}
+	return event
+}
+
+func NewUserUpdated(userId string) *pb.Event {
+	event := newEvent(UserUpdatedType)
+	event.Payload = &pb.Event_User_Updated{User_Updated: &pb.UserUpdated{User_Id: userId}}
+	return event
+}
+
+func NewUserDeleted(userId string) *pb.Event {
+	event := newEvent(UserDeletedType)
+	event.Payload = &pb.Event_User_Deleted{User_Deleted: &pb.UserDeleted{User_Id: userId}}
+	return event
+}
+
+// UserId returns the id of the user event is about.
+func UserId(event *pb.Event) string {
+	switch payload := event.Payload.(type) {
+	case *pb.Event_User_Created:
+		return payload.User_Created.User_Id
+	case *pb.Event_User_Updated:
+		return payload.User_Updated.User_Id
+	case *pb.Event_User_Deleted:
+		return payload.User_Deleted.User_Id
+	default:
+		return ""
+	}
+}
+
+func newEvent(eventType string) *pb.Event {
+	return &pb.Event{
+		Id:          uuid.NewString(),
+		Type:        eventType,
+		Occurred_At: timestamppb.Now(),
+	}
+}
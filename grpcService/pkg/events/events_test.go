@@ -0,0 +1,219 @@
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/log"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+type failingPublisher struct {
+	failAfter int
+	published []*pb.Event
+	tenants   []string
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, event *pb.Event) error {
+	if len(p.published) == p.failAfter {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event)
+	p.tenants = append(p.tenants, tenant.FromContext(ctx))
+	return nil
+}
+
+func TestAppend(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+
+	event := events.NewUserCreated("user-1")
+	payload, _ := proto.Marshal(event)
+
+	mock.ExpectExec(utils.InsertOutboxEventQuery).
+		WithArgs(event.Id, events.UserCreatedType, "user-1", payload, tenant.Default).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(t, events.Append(context.Background(), db, event))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelayBatch(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	created := events.NewUserCreated("user-1")
+	deleted := events.NewUserDeleted("user-1")
+	createdPayload, _ := proto.Marshal(created)
+	deletedPayload, _ := proto.Marshal(deleted)
+
+	testCases := []struct {
+		Name           string
+		Publisher      *failingPublisher
+		Tenants        []string
+		buildMock      func(mock sqlmock.Sqlmock)
+		assertResponse func(t *testing.T, published []*pb.Event, n int, err error)
+	}{
+		{
+			Name:      "Publish Pending Events In Order",
+			Publisher: &failingPublisher{failAfter: -1},
+			Tenants:   []string{"acme", "globex"},
+			buildMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"seq", "tenant_id", "payload"}).AddRow(1, "acme", createdPayload).AddRow(2, "globex", deletedPayload)
+				mock.ExpectBegin()
+				mock.ExpectQuery(utils.ListPendingOutboxEventsQuery).WithArgs(10).WillReturnRows(rows)
+				mock.ExpectExec(utils.MarkOutboxEventPublishedQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(utils.MarkOutboxEventPublishedQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			assertResponse: func(t *testing.T, published []*pb.Event, n int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, n)
+				assert.Equal(t, created.Id, published[0].Id)
+				assert.Equal(t, deleted.Id, published[1].Id)
+			},
+		},
+		{
+			Name:      "Stop At First Publish Failure",
+			Publisher: &failingPublisher{failAfter: 1},
+			buildMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"seq", "tenant_id", "payload"}).AddRow(1, "acme", createdPayload).AddRow(2, "globex", deletedPayload)
+				mock.ExpectBegin()
+				mock.ExpectQuery(utils.ListPendingOutboxEventsQuery).WithArgs(10).WillReturnRows(rows)
+				mock.ExpectExec(utils.MarkOutboxEventPublishedQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			assertResponse: func(t *testing.T, published []*pb.Event, n int, err error) {
+				assert.Error(t, err)
+				assert.Equal(t, 1, n)
+				assert.Len(t, published, 1)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, mock := utils.NewMock(logger)
+			defer db.Close()
+
+			tc.buildMock(mock)
+
+			relay := events.NewRelay(db, tc.Publisher, 10, logger)
+			n, err := relay.RelayBatch(context.Background())
+			tc.assertResponse(t, tc.Publisher.published, n, err)
+			if tc.Tenants != nil {
+				assert.Equal(t, tc.Tenants, tc.Publisher.tenants)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestChannelPublisher(t *testing.T) {
+	publisher := events.NewChannelPublisher(1)
+	event := events.NewUserDeleted("user-1")
+
+	assert.NoError(t, publisher.Publish(context.Background(), event))
+	assert.Equal(t, event, <-publisher.C)
+
+	publisher.Publish(context.Background(), event)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, publisher.Publish(ctx, event), context.DeadlineExceeded)
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	publisher, err := events.NewFilePublisher(path)
+	assert.NoError(t, err)
+
+	first := events.NewUserCreated("user-1")
+	second := events.NewUserDeleted("user-1")
+	assert.NoError(t, publisher.Publish(context.Background(), first))
+	assert.NoError(t, publisher.Publish(context.Background(), second))
+	assert.NoError(t, publisher.Close())
+
+	file, _ := os.Open(path)
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event := &pb.Event{}
+		assert.NoError(t, protojson.Unmarshal(scanner.Bytes(), event))
+		ids = append(ids, event.Id)
+	}
+	assert.Equal(t, []string{first.Id, second.Id}, ids)
+}
+
+func TestNATSPublisher(t *testing.T) {
+	server := natsserver.RunRandClientPortServer()
+	defer server.Shutdown()
+
+	conn, err := nats.Connect(server.ClientURL())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	sub, err := conn.SubscribeSync("users.>")
+	assert.NoError(t, err)
+
+	event := events.NewUserCreated("user-1")
+	publisher := events.NewNATSPublisher(conn, "users")
+	assert.NoError(t, publisher.Publish(context.Background(), event))
+
+	msg, err := sub.NextMsg(time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "users."+events.UserCreatedType, msg.Subject)
+	assert.Equal(t, event.Id, msg.Header.Get(nats.MsgIdHdr))
+
+	received := &pb.Event{}
+	assert.NoError(t, proto.Unmarshal(msg.Data, received))
+	assert.Equal(t, "user-1", events.UserId(received))
+}
+
+func TestReadLog(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+
+	event := events.NewUserCreated("user-1")
+	payload, _ := proto.Marshal(event)
+
+	mock.ExpectQuery(utils.ListEventsAfterQuery).
+		WithArgs(tenant.Default, 4, int64(1000000), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "payload"}).AddRow(5, payload))
+
+	entries, err := events.ReadLog(context.Background(), db, 4, 10, time.Second)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, int64(5), entries[0].Seq)
+	assert.Equal(t, event.Id, entries[0].Event.Id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResumeToken(t *testing.T) {
+	seq, err := events.DecodeResumeToken(events.EncodeResumeToken(42))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), seq)
+
+	for _, token := range []string{"42", "!!", events.EncodeResumeToken(-1)} {
+		_, err := events.DecodeResumeToken(token)
+		assert.ErrorIs(t, err, events.ErrInvalidResumeToken)
+	}
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const resumeTokenPrefix = "seq:"
+
+var ErrInvalidResumeToken = errors.New("events: invalid resume token")
+
+// ReadLog returns up to limit events of the tenant of ctx written after
+// seq, in order. The outbox sequence is assigned on insert, not on commit,
+// so rows younger than settle are left for the next read: a transaction
+// holding a lower sequence could still commit and would otherwise be
+// skipped.
+func ReadLog(ctx context.Context, q database.Querier, after int64, limit int, settle time.Duration) ([]entities.EventLogEntry, error) {
+	rows, err := q.QueryContext(ctx, utils.ListEventsAfterQuery, tenant.FromContext(ctx), after, settle.Microseconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLog(rows)
+}
+
+// ReadAggregate returns every event about aggregateId in the tenant of
+// ctx, in order.
+func ReadAggregate(ctx context.Context, q database.Querier, aggregateId string) ([]entities.EventLogEntry, error) {
+	rows, err := q.QueryContext(ctx, utils.ListAggregateEventsQuery, tenant.FromContext(ctx), aggregateId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLog(rows)
+}
+
+func scanLog(rows *sql.Rows) ([]entities.EventLogEntry, error) {
+	var entries []entities.EventLogEntry
+	for rows.Next() {
+		var (
+			entry   entities.EventLogEntry
+			payload []byte
+		)
+		if err := rows.Scan(&entry.Seq, &payload); err != nil {
+			return nil, err
+		}
+
+		entry.Event = &pb.Event{}
+		if err := proto.Unmarshal(payload, entry.Event); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// LastSeq returns the position of the latest event of the tenant of ctx in
+// the outbox.
+func LastSeq(ctx context.Context, q database.Querier) (int64, error) {
+	var seq int64
+	err := q.QueryRowContext(ctx, utils.LastEventSeqQuery, tenant.FromContext(ctx)).Scan(&seq)
+	return seq, err
+}
+
+// EncodeResumeToken turns an outbox position into the opaque token handed
+// to clients of the change feed.
+func EncodeResumeToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(resumeTokenPrefix + strconv.FormatInt(seq, 10)))
+}
+
+func DecodeResumeToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), resumeTokenPrefix) {
+		return 0, ErrInvalidResumeToken
+	}
+
+	seq, err := strconv.ParseInt(strings.TrimPrefix(string(raw), resumeTokenPrefix), 10, 64)
+	if err != nil || seq < 0 {
+		return 0, ErrInvalidResumeToken
+	}
+
+	return seq, nil
+}
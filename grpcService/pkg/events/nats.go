@@ -0,0 +1,45 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+// NATSPublisher publishes every event on "<prefix>.<event type>" with its
+// protobuf encoding as payload and its id in the Nats-Msg-Id header, which
+// JetStream streams use to drop duplicates.
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNATSPublisher(conn *nats.Conn, prefix string) *NATSPublisher {
+	return &NATSPublisher{conn: conn, prefix: prefix}
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event *pb.Event) error {
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.prefix + "." + event.Type)
+	msg.Header.Set(nats.MsgIdHdr, event.Id)
+	msg.Data = data
+
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+
+	// Wait for the server to acknowledge it got the message.
+	timeout := 5 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	return p.conn.FlushTimeout(timeout)
+}
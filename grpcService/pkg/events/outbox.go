@@ -0,0 +1,146 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// Append stores event in the outbox table through q, under the tenant of
+// ctx. Passing the transaction that writes the user row makes both commit
+// or fail together.
+func Append(ctx context.Context, q database.Querier, event *pb.Event) error {
+	payload, err := proto.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, utils.InsertOutboxEventQuery, event.Id, event.Type, UserId(event), payload, tenant.FromContext(ctx))
+	return err
+}
+
+// Relay publishes the events stored in the outbox in the order they were
+// written. An event is only marked as published after the publisher accepts
+// it, so a crash in between delivers it again: delivery is at least once
+// and consumers dedupe by event id.
+type Relay struct {
+	DB        *sql.DB
+	Publisher Publisher
+	BatchSize int
+	TxConfig  database.TxConfig
+	Logger    log.Logger
+}
+
+func NewRelay(db *sql.DB, publisher Publisher, batchSize int, logger log.Logger) *Relay {
+	return &Relay{
+		DB:        db,
+		Publisher: publisher,
+		BatchSize: batchSize,
+		TxConfig:  database.DefaultTxConfig(),
+		Logger:    logger,
+	}
+}
+
+// Run relays pending events every interval until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.RelayBatch(ctx)
+			if err != nil {
+				level.Error(r.Logger).Log("msg", "outbox relay failed", "error", err)
+				break
+			}
+			if n < r.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayBatch publishes up to BatchSize pending events and returns how many
+// were published. The rows stay locked while they are published so other
+// relays running against the same database skip them.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	var (
+		published  int
+		publishErr error
+	)
+
+	err := database.RunInTx(ctx, r.DB, r.TxConfig, func(ctx context.Context) error {
+		tx := database.Conn(ctx, r.DB)
+
+		rows, err := tx.QueryContext(ctx, utils.ListPendingOutboxEventsQuery, r.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		var (
+			seqs     []int64
+			tenants  []string
+			payloads [][]byte
+		)
+		for rows.Next() {
+			var (
+				seq      int64
+				tenantId string
+				payload  []byte
+			)
+			if err := rows.Scan(&seq, &tenantId, &payload); err != nil {
+				rows.Close()
+				return err
+			}
+			seqs = append(seqs, seq)
+			tenants = append(tenants, tenantId)
+			payloads = append(payloads, payload)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i, payload := range payloads {
+			event := &pb.Event{}
+			if err := proto.Unmarshal(payload, event); err != nil {
+				return err
+			}
+
+			// Stop at the first failure so events keep their order, the
+			// ones already published are still marked. Publishers read the
+			// tenant of the event from the context.
+			if err := r.Publisher.Publish(tenant.WithTenant(ctx, tenants[i]), event); err != nil {
+				publishErr = err
+				break
+			}
+
+			if _, err := tx.ExecContext(ctx, utils.MarkOutboxEventPublishedQuery, seqs[i]); err != nil {
+				return err
+			}
+			published++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, publishErr
+}
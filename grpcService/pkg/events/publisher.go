@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+// Publisher delivers events to their consumers. Publish returns nil only
+// once the event has been handed over.
+type Publisher interface {
+	Publish(ctx context.Context, event *pb.Event) error
+}
+
+// ChannelPublisher hands events to an in-process consumer.
+type ChannelPublisher struct {
+	C chan *pb.Event
+}
+
+func NewChannelPublisher(size int) *ChannelPublisher {
+	return &ChannelPublisher{C: make(chan *pb.Event, size)}
+}
+
+func (p *ChannelPublisher) Publish(ctx context.Context, event *pb.Event) error {
+	select {
+	case p.C <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FilePublisher appends events to a file as newline delimited JSON.
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FilePublisher{file: file}, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, event *pb.Event) error {
+	line, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+// MultiPublisher hands every event to all of its publishers and fails when
+// any of them does, so the outbox relays the event again. Publishers fed
+// this way have to tolerate duplicates.
+type MultiPublisher []Publisher
+
+func (p MultiPublisher) Publish(ctx context.Context, event *pb.Event) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package group
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	CreateGroup(ctx context.Context, rq entities.CreateGroupRequest) (entities.CreateGroupResponse, error)
+	RenameGroup(ctx context.Context, rq entities.RenameGroupRequest) (entities.RenameGroupResponse, error)
+	DeleteGroup(ctx context.Context, rq entities.DeleteGroupRequest) (entities.DeleteGroupResponse, error)
+	ListGroups(ctx context.Context, rq entities.ListGroupsRequest) (entities.ListGroupsResponse, error)
+	AddMember(ctx context.Context, rq entities.AddMemberRequest) (entities.AddMemberResponse, error)
+	RemoveMember(ctx context.Context, rq entities.RemoveMemberRequest) (entities.RemoveMemberResponse, error)
+	ListMembers(ctx context.Context, rq entities.ListMembersRequest) (entities.ListMembersResponse, error)
+	ListUserGroups(ctx context.Context, rq entities.ListUserGroupsRequest) (entities.ListUserGroupsResponse, error)
+}
+
+type Endpoints struct {
+	CreateGroup    endpoint.Endpoint
+	RenameGroup    endpoint.Endpoint
+	DeleteGroup    endpoint.Endpoint
+	ListGroups     endpoint.Endpoint
+	AddMember      endpoint.Endpoint
+	RemoveMember   endpoint.Endpoint
+	ListMembers    endpoint.Endpoint
+	ListUserGroups endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		CreateGroup:    MakeCreateGroupEndpoint(s),
+		RenameGroup:    MakeRenameGroupEndpoint(s),
+		DeleteGroup:    MakeDeleteGroupEndpoint(s),
+		ListGroups:     MakeListGroupsEndpoint(s),
+		AddMember:      MakeAddMemberEndpoint(s),
+		RemoveMember:   MakeRemoveMemberEndpoint(s),
+		ListMembers:    MakeListMembersEndpoint(s),
+		ListUserGroups: MakeListUserGroupsEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		CreateGroup:    mw(e.CreateGroup),
+		RenameGroup:    mw(e.RenameGroup),
+		DeleteGroup:    mw(e.DeleteGroup),
+		ListGroups:     mw(e.ListGroups),
+		AddMember:      mw(e.AddMember),
+		RemoveMember:   mw(e.RemoveMember),
+		ListMembers:    mw(e.ListMembers),
+		ListUserGroups: mw(e.ListUserGroups),
+	}
+}
+
+func MakeCreateGroupEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.CreateGroupRequest)
+		c, err := s.CreateGroup(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeRenameGroupEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.RenameGroupRequest)
+		c, err := s.RenameGroup(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeDeleteGroupEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.DeleteGroupRequest)
+		c, err := s.DeleteGroup(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListGroupsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListGroupsRequest)
+		c, err := s.ListGroups(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeAddMemberEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.AddMemberRequest)
+		c, err := s.AddMember(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeRemoveMemberEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.RemoveMemberRequest)
+		c, err := s.RemoveMember(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListMembersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListMembersRequest)
+		c, err := s.ListMembers(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListUserGroupsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListUserGroupsRequest)
+		c, err := s.ListUserGroups(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
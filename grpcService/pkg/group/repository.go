@@ -0,0 +1,288 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+var (
+	ErrExists     = errors.New("group: name already taken")
+	ErrNoSuchUser = errors.New("group: no such user")
+)
+
+// Repository keeps the groups of the tenant carried by the context of each
+// call.
+type Repository interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateGroup(ctx context.Context, group entities.Group) error
+	// GetGroup and LockGroup return sql.ErrNoRows when there is no group
+	// id.
+	GetGroup(ctx context.Context, id string) (entities.Group, error)
+	LockGroup(ctx context.Context, id string) error
+	ListGroups(ctx context.Context, afterId string, limit int) ([]entities.Group, error)
+	RenameGroup(ctx context.Context, id string, name string) error
+	DeleteGroup(ctx context.Context, id string) error
+	AddMember(ctx context.Context, member entities.GroupMember) error
+	// GetMember returns sql.ErrNoRows when the user isn't a member.
+	GetMember(ctx context.Context, groupId string, userId string) (entities.GroupMember, error)
+	UpdateMember(ctx context.Context, member entities.GroupMember) error
+	RemoveMember(ctx context.Context, groupId string, userId string) error
+	CountOwners(ctx context.Context, groupId string) (int, error)
+	ListMembers(ctx context.Context, groupId string, afterUserId string, limit int) ([]entities.GroupMember, error)
+	ListUserGroups(ctx context.Context, userId string) ([]entities.UserGroup, error)
+	UserExists(ctx context.Context, userId string) (bool, error)
+}
+
+type sqlRepo struct {
+	DB       *sql.DB
+	Logger   log.Logger
+	TxConfig database.TxConfig
+}
+
+func NewSQL(db *sql.DB, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, log, database.DefaultTxConfig()}
+}
+
+func (repo *sqlRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, repo.DB, repo.TxConfig, fn)
+}
+
+// conn returns the transaction in ctx, or the database when there is none.
+func (repo *sqlRepo) conn(ctx context.Context) database.Querier {
+	return database.Conn(ctx, repo.DB)
+}
+
+// CreateGroup returns ErrExists when the tenant has a group of that name.
+func (repo *sqlRepo) CreateGroup(ctx context.Context, group entities.Group) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.CreateGroupQuery, group.Id, tenant.FromContext(ctx), group.Name, group.CreatedAt)
+	if err != nil {
+		if isMySQLError(err, 1062) {
+			return ErrExists
+		}
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *sqlRepo) GetGroup(ctx context.Context, id string) (entities.Group, error) {
+	var group entities.Group
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.GetGroupQuery, tenant.FromContext(ctx), id).Scan(&group.Id, &group.Name, &group.CreatedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			level.Error(repo.Logger).Log(err)
+		}
+		return entities.Group{}, err
+	}
+
+	return group, nil
+}
+
+// LockGroup locks the row of a group until the transaction carried by ctx
+// ends, so changes to its members are made one at a time.
+func (repo *sqlRepo) LockGroup(ctx context.Context, id string) error {
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.LockGroupQuery, tenant.FromContext(ctx), id).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+// ListGroups returns up to limit groups with an id greater than afterId, in
+// id order.
+func (repo *sqlRepo) ListGroups(ctx context.Context, afterId string, limit int) ([]entities.Group, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, utils.ListGroupsAfterQuery, tenant.FromContext(ctx), afterId, limit)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []entities.Group
+	for rows.Next() {
+		var group entities.Group
+		if err := rows.Scan(&group.Id, &group.Name, &group.CreatedAt); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+// RenameGroup returns sql.ErrNoRows when there is no group id, and
+// ErrExists when the tenant has another group of that name.
+func (repo *sqlRepo) RenameGroup(ctx context.Context, id string, name string) error {
+	res, err := repo.conn(ctx).ExecContext(ctx, utils.RenameGroupQuery, name, tenant.FromContext(ctx), id)
+	if err != nil {
+		if isMySQLError(err, 1062) {
+			return ErrExists
+		}
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	// MySQL counts the rows changed, not the ones matched, a rename to the
+	// same name affects none.
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := repo.GetGroup(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteGroup returns sql.ErrNoRows when there was no group id. Its
+// memberships are deleted with it.
+func (repo *sqlRepo) DeleteGroup(ctx context.Context, id string) error {
+	res, err := repo.conn(ctx).ExecContext(ctx, utils.DeleteGroupQuery, tenant.FromContext(ctx), id)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AddMember returns ErrNoSuchUser when the user isn't one of the tenant.
+func (repo *sqlRepo) AddMember(ctx context.Context, member entities.GroupMember) error {
+	id := tenant.FromContext(ctx)
+	res, err := repo.conn(ctx).ExecContext(ctx, utils.AddGroupMemberQuery, member.GroupId, member.Role, member.JoinedAt, id, member.UserId)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNoSuchUser
+	}
+
+	return nil
+}
+
+func (repo *sqlRepo) GetMember(ctx context.Context, groupId string, userId string) (entities.GroupMember, error) {
+	var member entities.GroupMember
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.GetGroupMemberQuery, tenant.FromContext(ctx), groupId, userId).
+		Scan(&member.GroupId, &member.UserId, &member.Role, &member.JoinedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			level.Error(repo.Logger).Log(err)
+		}
+		return entities.GroupMember{}, err
+	}
+
+	return member, nil
+}
+
+func (repo *sqlRepo) UpdateMember(ctx context.Context, member entities.GroupMember) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.UpdateGroupMemberQuery, member.Role, tenant.FromContext(ctx), member.GroupId, member.UserId)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+// RemoveMember returns sql.ErrNoRows when the user wasn't a member.
+func (repo *sqlRepo) RemoveMember(ctx context.Context, groupId string, userId string) error {
+	res, err := repo.conn(ctx).ExecContext(ctx, utils.RemoveGroupMemberQuery, tenant.FromContext(ctx), groupId, userId)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (repo *sqlRepo) CountOwners(ctx context.Context, groupId string) (int, error) {
+	var owners int
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.CountGroupOwnersQuery, tenant.FromContext(ctx), groupId).Scan(&owners)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return owners, err
+}
+
+// ListMembers returns up to limit members of a group with a user id greater
+// than afterUserId, in user id order, joined with their users so only
+// existing users are listed.
+func (repo *sqlRepo) ListMembers(ctx context.Context, groupId string, afterUserId string, limit int) ([]entities.GroupMember, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, utils.ListGroupMembersQuery, tenant.FromContext(ctx), groupId, afterUserId, limit)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []entities.GroupMember
+	for rows.Next() {
+		var member entities.GroupMember
+		if err := rows.Scan(&member.GroupId, &member.UserId, &member.Role, &member.JoinedAt); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// ListUserGroups returns the groups a user is a member of, in name order.
+func (repo *sqlRepo) ListUserGroups(ctx context.Context, userId string) ([]entities.UserGroup, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, utils.ListUserGroupsQuery, tenant.FromContext(ctx), userId)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []entities.UserGroup
+	for rows.Next() {
+		var group entities.UserGroup
+		if err := rows.Scan(&group.Group.Id, &group.Group.Name, &group.Group.CreatedAt, &group.Role); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+func (repo *sqlRepo) UserExists(ctx context.Context, userId string) (bool, error) {
+	var exists bool
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.UserExistsQuery, tenant.FromContext(ctx), userId).Scan(&exists)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return exists, err
+}
+
+func isMySQLError(err error, number uint16) bool {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	return ok && mysqlErr.Number == number
+}
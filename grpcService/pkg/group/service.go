@@ -0,0 +1,310 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/uuid"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	maxNameLength   = 255
+)
+
+type service struct {
+	Repo   Repository
+	Logger log.Logger
+}
+
+func NewService(l log.Logger, r Repository) *service {
+	return &service{r, l}
+}
+
+// CreateGroup creates a group, with its owner when the request names one.
+func (s *service) CreateGroup(ctx context.Context, rq entities.CreateGroupRequest) (entities.CreateGroupResponse, error) {
+	s.Logger.Log("request", "create group", "received")
+
+	name, err := validName(rq.Name)
+	if err != nil {
+		return entities.CreateGroupResponse{}, err
+	}
+
+	// The database keeps microseconds.
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	group := entities.Group{Id: uuid.NewString(), Name: name, CreatedAt: now}
+
+	err = s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.Repo.CreateGroup(ctx, group); err != nil {
+			return err
+		}
+		if rq.OwnerId == "" {
+			return nil
+		}
+		return s.Repo.AddMember(ctx, entities.GroupMember{GroupId: group.Id, UserId: rq.OwnerId, Role: entities.GroupRoleOwner, JoinedAt: now})
+	})
+	if err != nil {
+		return entities.CreateGroupResponse{}, s.notFoundOr(err)
+	}
+
+	return entities.CreateGroupResponse{
+		Group:  group,
+		Status: entities.Status{Message: "created successfully"},
+	}, nil
+}
+
+func (s *service) RenameGroup(ctx context.Context, rq entities.RenameGroupRequest) (entities.RenameGroupResponse, error) {
+	s.Logger.Log("request", "rename group", "received")
+
+	name, err := validName(rq.Name)
+	if err != nil {
+		return entities.RenameGroupResponse{}, err
+	}
+
+	if err := s.Repo.RenameGroup(ctx, rq.GroupId, name); err != nil {
+		return entities.RenameGroupResponse{}, s.notFoundOr(err)
+	}
+
+	group, err := s.Repo.GetGroup(ctx, rq.GroupId)
+	if err != nil {
+		return entities.RenameGroupResponse{}, s.notFoundOr(err)
+	}
+
+	return entities.RenameGroupResponse{Group: group}, nil
+}
+
+func (s *service) DeleteGroup(ctx context.Context, rq entities.DeleteGroupRequest) (entities.DeleteGroupResponse, error) {
+	s.Logger.Log("request", "delete group", "received")
+
+	if err := s.Repo.DeleteGroup(ctx, rq.GroupId); err != nil {
+		return entities.DeleteGroupResponse{}, s.notFoundOr(err)
+	}
+
+	return entities.DeleteGroupResponse{
+		Status: entities.Status{Message: "group deleted successfully"},
+	}, nil
+}
+
+// ListGroups pages through the groups of the tenant in id order. The page
+// token is the id of the last group of the page before.
+func (s *service) ListGroups(ctx context.Context, rq entities.ListGroupsRequest) (entities.ListGroupsResponse, error) {
+	s.Logger.Log("request", "list groups", "received")
+
+	pageSize := validPageSize(rq.PageSize)
+
+	// One group more than asked tells whether there is a next page.
+	groups, err := s.Repo.ListGroups(ctx, rq.PageToken, pageSize+1)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListGroupsResponse{}, dataBaseError(err)
+	}
+
+	response := entities.ListGroupsResponse{Groups: groups}
+	if len(groups) > pageSize {
+		response.Groups = groups[:pageSize]
+		response.NextPageToken = groups[pageSize-1].Id
+	}
+
+	return response, nil
+}
+
+// AddMember adds a user to a group, or changes the role of a member. A
+// member keeps the time it joined at.
+func (s *service) AddMember(ctx context.Context, rq entities.AddMemberRequest) (entities.AddMemberResponse, error) {
+	s.Logger.Log("request", "add member", "received")
+
+	if rq.UserId == "" {
+		return entities.AddMemberResponse{}, errors.NewInvalidField("user_id", "is required")
+	}
+	role := rq.Role
+	if role == "" {
+		role = entities.GroupRoleMember
+	}
+	if role != entities.GroupRoleOwner && role != entities.GroupRoleMember {
+		return entities.AddMemberResponse{}, errors.NewInvalidField("role", "must be \"owner\" or \"member\"")
+	}
+
+	var member entities.GroupMember
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.Repo.LockGroup(ctx, rq.GroupId); err != nil {
+			return err
+		}
+
+		existing, err := s.Repo.GetMember(ctx, rq.GroupId, rq.UserId)
+		switch {
+		case err == sql.ErrNoRows:
+			member = entities.GroupMember{
+				GroupId:  rq.GroupId,
+				UserId:   rq.UserId,
+				Role:     role,
+				JoinedAt: time.Now().UTC().Truncate(time.Microsecond),
+			}
+			return s.Repo.AddMember(ctx, member)
+		case err != nil:
+			return err
+		}
+
+		member = existing
+		if existing.Role == role {
+			return nil
+		}
+		if existing.Role == entities.GroupRoleOwner {
+			if err := s.keepAnOwner(ctx, rq.GroupId); err != nil {
+				return err
+			}
+		}
+		member.Role = role
+		return s.Repo.UpdateMember(ctx, member)
+	})
+	if err != nil {
+		return entities.AddMemberResponse{}, s.notFoundOr(err)
+	}
+
+	return entities.AddMemberResponse{Member: member}, nil
+}
+
+func (s *service) RemoveMember(ctx context.Context, rq entities.RemoveMemberRequest) (entities.RemoveMemberResponse, error) {
+	s.Logger.Log("request", "remove member", "received")
+
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.Repo.LockGroup(ctx, rq.GroupId); err != nil {
+			return err
+		}
+
+		member, err := s.Repo.GetMember(ctx, rq.GroupId, rq.UserId)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.NewResourceNotFound("member")
+			}
+			return err
+		}
+		if member.Role == entities.GroupRoleOwner {
+			if err := s.keepAnOwner(ctx, rq.GroupId); err != nil {
+				return err
+			}
+		}
+
+		return s.Repo.RemoveMember(ctx, rq.GroupId, rq.UserId)
+	})
+	if err != nil {
+		return entities.RemoveMemberResponse{}, s.notFoundOr(err)
+	}
+
+	return entities.RemoveMemberResponse{
+		Status: entities.Status{Message: "member removed successfully"},
+	}, nil
+}
+
+// ListMembers pages through the members of a group in user id order. The
+// page token is the id of the last user of the page before.
+func (s *service) ListMembers(ctx context.Context, rq entities.ListMembersRequest) (entities.ListMembersResponse, error) {
+	s.Logger.Log("request", "list members", "received")
+
+	if _, err := s.Repo.GetGroup(ctx, rq.GroupId); err != nil {
+		return entities.ListMembersResponse{}, s.notFoundOr(err)
+	}
+
+	pageSize := validPageSize(rq.PageSize)
+	members, err := s.Repo.ListMembers(ctx, rq.GroupId, rq.PageToken, pageSize+1)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListMembersResponse{}, dataBaseError(err)
+	}
+
+	response := entities.ListMembersResponse{Members: members}
+	if len(members) > pageSize {
+		response.Members = members[:pageSize]
+		response.NextPageToken = members[pageSize-1].UserId
+	}
+
+	return response, nil
+}
+
+func (s *service) ListUserGroups(ctx context.Context, rq entities.ListUserGroupsRequest) (entities.ListUserGroupsResponse, error) {
+	s.Logger.Log("request", "list user groups", "received")
+
+	exists, err := s.Repo.UserExists(ctx, rq.UserId)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListUserGroupsResponse{}, dataBaseError(err)
+	}
+	if !exists {
+		return entities.ListUserGroupsResponse{}, errors.NewUserNotFound()
+	}
+
+	groups, err := s.Repo.ListUserGroups(ctx, rq.UserId)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListUserGroupsResponse{}, dataBaseError(err)
+	}
+
+	return entities.ListUserGroupsResponse{Groups: groups}, nil
+}
+
+// keepAnOwner fails when an owner of the locked group is its last one.
+func (s *service) keepAnOwner(ctx context.Context, groupId string) error {
+	owners, err := s.Repo.CountOwners(ctx, groupId)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errors.NewPreconditionFailed("a group keeps at least one owner")
+	}
+	return nil
+}
+
+// notFoundOr maps the errors of the repository, passing through the ones
+// the service already made.
+func (s *service) notFoundOr(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return errors.NewResourceNotFound("group")
+	case ErrExists:
+		return errors.NewResourceAlreadyExists("group")
+	case ErrNoSuchUser:
+		return errors.NewUserNotFound()
+	}
+	switch err.(type) {
+	case errors.PreconditionFailed, errors.ResourceNotFound:
+		return err
+	}
+	level.Error(s.Logger).Log("error", err)
+	return dataBaseError(err)
+}
+
+func validName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.NewInvalidField("name", "is required")
+	}
+	if len(name) > maxNameLength {
+		return "", errors.NewInvalidField("name", "must be at most 255 bytes")
+	}
+	return name, nil
+}
+
+func validPageSize(size uint32) int {
+	if size == 0 {
+		return defaultPageSize
+	}
+	if size > maxPageSize {
+		return maxPageSize
+	}
+	return int(size)
+}
+
+func dataBaseError(err error) error {
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
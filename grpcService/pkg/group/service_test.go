@@ -0,0 +1,262 @@
+package group_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/group"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+func TestServiceCreateGroup(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Request        entities.CreateGroupRequest
+		buildMock      func(repo *utils.GroupRepositoryMock)
+		assertResponse func(t *testing.T, res entities.CreateGroupResponse, err error)
+	}{
+		{
+			Name:    "Create Group",
+			Request: entities.CreateGroupRequest{Name: " Platform "},
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("CreateGroup", mock.Anything, mock.MatchedBy(func(g entities.Group) bool {
+					return g.Id != "" && g.Name == "Platform" && !g.CreatedAt.IsZero()
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateGroupResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "Platform", res.Group.Name)
+			},
+		},
+		{
+			Name:    "Create Group With Owner",
+			Request: entities.CreateGroupRequest{Name: "Platform", OwnerId: "user-1"},
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("CreateGroup", mock.Anything, mock.Anything).Return(nil)
+				repo.On("AddMember", mock.Anything, mock.MatchedBy(func(m entities.GroupMember) bool {
+					return m.UserId == "user-1" && m.Role == entities.GroupRoleOwner
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateGroupResponse, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name:    "Unknown Owner",
+			Request: entities.CreateGroupRequest{Name: "Platform", OwnerId: "user-1"},
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("CreateGroup", mock.Anything, mock.Anything).Return(nil)
+				repo.On("AddMember", mock.Anything, mock.Anything).Return(group.ErrNoSuchUser)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateGroupResponse, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+		{
+			Name:    "Name Taken",
+			Request: entities.CreateGroupRequest{Name: "Platform"},
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("CreateGroup", mock.Anything, mock.Anything).Return(group.ErrExists)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateGroupResponse, err error) {
+				assert.IsType(t, myErr.ResourceAlreadyExists{}, err)
+			},
+		},
+		{
+			Name:      "Missing Name",
+			Request:   entities.CreateGroupRequest{Name: "  "},
+			buildMock: func(repo *utils.GroupRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.CreateGroupResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.GroupRepositoryMock)
+			tc.buildMock(repo)
+
+			srvc := group.NewService(logger, repo)
+			res, err := srvc.CreateGroup(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceAddMember(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	owner := entities.GroupMember{GroupId: "group-1", UserId: "user-1", Role: entities.GroupRoleOwner}
+
+	testCases := []struct {
+		Name           string
+		Request        entities.AddMemberRequest
+		buildMock      func(repo *utils.GroupRepositoryMock)
+		assertResponse func(t *testing.T, res entities.AddMemberResponse, err error)
+	}{
+		{
+			Name:    "Add Member",
+			Request: entities.AddMemberRequest{GroupId: "group-1", UserId: "user-2"},
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("LockGroup", mock.Anything, "group-1").Return(nil)
+				repo.On("GetMember", mock.Anything, "group-1", "user-2").Return(entities.GroupMember{}, sql.ErrNoRows)
+				repo.On("AddMember", mock.Anything, mock.MatchedBy(func(m entities.GroupMember) bool {
+					return m.UserId == "user-2" && m.Role == entities.GroupRoleMember && !m.JoinedAt.IsZero()
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AddMemberResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entities.GroupRoleMember, res.Member.Role)
+			},
+		},
+		{
+			Name:    "Demote Last Owner",
+			Request: entities.AddMemberRequest{GroupId: "group-1", UserId: "user-1", Role: entities.GroupRoleMember},
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("LockGroup", mock.Anything, "group-1").Return(nil)
+				repo.On("GetMember", mock.Anything, "group-1", "user-1").Return(owner, nil)
+				repo.On("CountOwners", mock.Anything, "group-1").Return(1, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AddMemberResponse, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name:    "Demote One Of Two Owners",
+			Request: entities.AddMemberRequest{GroupId: "group-1", UserId: "user-1", Role: entities.GroupRoleMember},
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("LockGroup", mock.Anything, "group-1").Return(nil)
+				repo.On("GetMember", mock.Anything, "group-1", "user-1").Return(owner, nil)
+				repo.On("CountOwners", mock.Anything, "group-1").Return(2, nil)
+				repo.On("UpdateMember", mock.Anything, mock.MatchedBy(func(m entities.GroupMember) bool {
+					return m.UserId == "user-1" && m.Role == entities.GroupRoleMember
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AddMemberResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entities.GroupRoleMember, res.Member.Role)
+			},
+		},
+		{
+			Name:    "Unknown Group",
+			Request: entities.AddMemberRequest{GroupId: "group-1", UserId: "user-2"},
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("LockGroup", mock.Anything, "group-1").Return(sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.AddMemberResponse, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+		{
+			Name:      "Invalid Role",
+			Request:   entities.AddMemberRequest{GroupId: "group-1", UserId: "user-2", Role: "admin"},
+			buildMock: func(repo *utils.GroupRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.AddMemberResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.GroupRepositoryMock)
+			tc.buildMock(repo)
+
+			srvc := group.NewService(logger, repo)
+			res, err := srvc.AddMember(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceRemoveMember(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		buildMock      func(repo *utils.GroupRepositoryMock)
+		assertResponse func(t *testing.T, err error)
+	}{
+		{
+			Name: "Remove Member",
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("LockGroup", mock.Anything, "group-1").Return(nil)
+				repo.On("GetMember", mock.Anything, "group-1", "user-1").Return(entities.GroupMember{Role: entities.GroupRoleMember}, nil)
+				repo.On("RemoveMember", mock.Anything, "group-1", "user-1").Return(nil)
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name: "Remove Last Owner",
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("LockGroup", mock.Anything, "group-1").Return(nil)
+				repo.On("GetMember", mock.Anything, "group-1", "user-1").Return(entities.GroupMember{Role: entities.GroupRoleOwner}, nil)
+				repo.On("CountOwners", mock.Anything, "group-1").Return(1, nil)
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name: "Not A Member",
+			buildMock: func(repo *utils.GroupRepositoryMock) {
+				repo.On("LockGroup", mock.Anything, "group-1").Return(nil)
+				repo.On("GetMember", mock.Anything, "group-1", "user-1").Return(entities.GroupMember{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "member not found")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.GroupRepositoryMock)
+			tc.buildMock(repo)
+
+			srvc := group.NewService(logger, repo)
+			_, err := srvc.RemoveMember(context.Background(), entities.RemoveMemberRequest{GroupId: "group-1", UserId: "user-1"})
+			tc.assertResponse(t, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceListUserGroups(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	t.Run("Groups Of A User", func(t *testing.T) {
+		repo := new(utils.GroupRepositoryMock)
+		repo.On("UserExists", mock.Anything, "user-1").Return(true, nil)
+		repo.On("ListUserGroups", mock.Anything, "user-1").
+			Return([]entities.UserGroup{{Group: entities.Group{Id: "group-1", Name: "Platform"}, Role: entities.GroupRoleOwner}}, nil)
+
+		res, err := group.NewService(logger, repo).ListUserGroups(context.Background(), entities.ListUserGroupsRequest{UserId: "user-1"})
+		assert.NoError(t, err)
+		assert.Len(t, res.Groups, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown User", func(t *testing.T) {
+		repo := new(utils.GroupRepositoryMock)
+		repo.On("UserExists", mock.Anything, "user-1").Return(false, nil)
+
+		_, err := group.NewService(logger, repo).ListUserGroups(context.Background(), entities.ListUserGroupsRequest{UserId: "user-1"})
+		assert.IsType(t, myErr.UserNotFoundErr{}, err)
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,296 @@
+package group
+
+import (
+	"context"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	create         gr.Handler
+	rename         gr.Handler
+	delete         gr.Handler
+	list           gr.Handler
+	addMember      gr.Handler
+	removeMember   gr.Handler
+	listMembers    gr.Handler
+	listUserGroups gr.Handler
+	proto.UnimplementedGroupServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.GroupServiceServer {
+	return &gRPCSv{
+		create: gr.NewServer(
+			end.CreateGroup,
+			decodeCreateGroupRequest,
+			encodeCreateGroupResponse,
+		),
+
+		rename: gr.NewServer(
+			end.RenameGroup,
+			decodeRenameGroupRequest,
+			encodeRenameGroupResponse,
+		),
+
+		delete: gr.NewServer(
+			end.DeleteGroup,
+			decodeDeleteGroupRequest,
+			encodeDeleteGroupResponse,
+		),
+
+		list: gr.NewServer(
+			end.ListGroups,
+			decodeListGroupsRequest,
+			encodeListGroupsResponse,
+		),
+
+		addMember: gr.NewServer(
+			end.AddMember,
+			decodeAddMemberRequest,
+			encodeAddMemberResponse,
+		),
+
+		removeMember: gr.NewServer(
+			end.RemoveMember,
+			decodeRemoveMemberRequest,
+			encodeRemoveMemberResponse,
+		),
+
+		listMembers: gr.NewServer(
+			end.ListMembers,
+			decodeListMembersRequest,
+			encodeListMembersResponse,
+		),
+
+		listUserGroups: gr.NewServer(
+			end.ListUserGroups,
+			decodeListUserGroupsRequest,
+			encodeListUserGroupsResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) CreateGroup(ctx context.Context, rq *proto.CreateGroupRequest) (*proto.CreateGroupResponse, error) {
+	_, resp, err := g.create.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.CreateGroupResponse), nil
+}
+
+func (g *gRPCSv) RenameGroup(ctx context.Context, rq *proto.RenameGroupRequest) (*proto.RenameGroupResponse, error) {
+	_, resp, err := g.rename.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.RenameGroupResponse), nil
+}
+
+func (g *gRPCSv) DeleteGroup(ctx context.Context, rq *proto.DeleteGroupRequest) (*proto.DeleteGroupResponse, error) {
+	_, resp, err := g.delete.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.DeleteGroupResponse), nil
+}
+
+func (g *gRPCSv) ListGroups(ctx context.Context, rq *proto.ListGroupsRequest) (*proto.ListGroupsResponse, error) {
+	_, resp, err := g.list.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListGroupsResponse), nil
+}
+
+func (g *gRPCSv) AddMember(ctx context.Context, rq *proto.AddMemberRequest) (*proto.AddMemberResponse, error) {
+	_, resp, err := g.addMember.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.AddMemberResponse), nil
+}
+
+func (g *gRPCSv) RemoveMember(ctx context.Context, rq *proto.RemoveMemberRequest) (*proto.RemoveMemberResponse, error) {
+	_, resp, err := g.removeMember.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.RemoveMemberResponse), nil
+}
+
+func (g *gRPCSv) ListMembers(ctx context.Context, rq *proto.ListMembersRequest) (*proto.ListMembersResponse, error) {
+	_, resp, err := g.listMembers.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListMembersResponse), nil
+}
+
+func (g *gRPCSv) ListUserGroups(ctx context.Context, rq *proto.ListUserGroupsRequest) (*proto.ListUserGroupsResponse, error) {
+	_, resp, err := g.listUserGroups.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListUserGroupsResponse), nil
+}
+
+func decodeCreateGroupRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.CreateGroupRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.CreateGroupRequest{Name: res.Name, OwnerId: res.Owner_Id}, nil
+}
+
+func encodeCreateGroupResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.CreateGroupResponse)
+	return &proto.CreateGroupResponse{
+		Group:  groupToProto(res.Group),
+		Status: &proto.Status{Message: res.Status.Message, Code: res.Status.Code},
+	}, nil
+}
+
+func decodeRenameGroupRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.RenameGroupRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.RenameGroupRequest{GroupId: res.Group_Id, Name: res.Name}, nil
+}
+
+func encodeRenameGroupResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.RenameGroupResponse)
+	return &proto.RenameGroupResponse{Group: groupToProto(res.Group)}, nil
+}
+
+func decodeDeleteGroupRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.DeleteGroupRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.DeleteGroupRequest{GroupId: res.Group_Id}, nil
+}
+
+func encodeDeleteGroupResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.DeleteGroupResponse)
+	return &proto.DeleteGroupResponse{
+		Status: &proto.Status{Message: res.Status.Message, Code: res.Status.Code},
+	}, nil
+}
+
+func decodeListGroupsRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListGroupsRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListGroupsRequest{PageSize: res.Page_Size, PageToken: res.Page_Token}, nil
+}
+
+func encodeListGroupsResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListGroupsResponse)
+	protoResp := &proto.ListGroupsResponse{Next_Page_Token: res.NextPageToken}
+	for _, group := range res.Groups {
+		protoResp.Groups = append(protoResp.Groups, groupToProto(group))
+	}
+	return protoResp, nil
+}
+
+func decodeAddMemberRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.AddMemberRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.AddMemberRequest{GroupId: res.Group_Id, UserId: res.User_Id, Role: res.Role}, nil
+}
+
+func encodeAddMemberResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.AddMemberResponse)
+	return &proto.AddMemberResponse{Member: memberToProto(res.Member)}, nil
+}
+
+func decodeRemoveMemberRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.RemoveMemberRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.RemoveMemberRequest{GroupId: res.Group_Id, UserId: res.User_Id}, nil
+}
+
+func encodeRemoveMemberResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.RemoveMemberResponse)
+	return &proto.RemoveMemberResponse{
+		Status: &proto.Status{Message: res.Status.Message, Code: res.Status.Code},
+	}, nil
+}
+
+func decodeListMembersRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListMembersRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListMembersRequest{GroupId: res.Group_Id, PageSize: res.Page_Size, PageToken: res.Page_Token}, nil
+}
+
+func encodeListMembersResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListMembersResponse)
+	protoResp := &proto.ListMembersResponse{Next_Page_Token: res.NextPageToken}
+	for _, member := range res.Members {
+		protoResp.Members = append(protoResp.Members, memberToProto(member))
+	}
+	return protoResp, nil
+}
+
+func decodeListUserGroupsRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListUserGroupsRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListUserGroupsRequest{UserId: res.User_Id}, nil
+}
+
+func encodeListUserGroupsResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListUserGroupsResponse)
+	protoResp := &proto.ListUserGroupsResponse{}
+	for _, group := range res.Groups {
+		protoResp.Groups = append(protoResp.Groups, &proto.UserGroup{Group: groupToProto(group.Group), Role: group.Role})
+	}
+	return protoResp, nil
+}
+
+func groupToProto(group entities.Group) *proto.Group {
+	return &proto.Group{
+		Id:         group.Id,
+		Name:       group.Name,
+		Created_At: timestamppb.New(group.CreatedAt),
+	}
+}
+
+func memberToProto(member entities.GroupMember) *proto.GroupMember {
+	return &proto.GroupMember{
+		Group_Id:  member.GroupId,
+		User_Id:   member.UserId,
+		Role:      member.Role,
+		Joined_At: timestamppb.New(member.JoinedAt),
+	}
+}
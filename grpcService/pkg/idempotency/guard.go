@@ -0,0 +1,212 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/encryption"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+// ErrClaimLost is returned by Complete when the record was taken over by
+// another request after its lease expired. The transaction completing it
+// must roll back, the other request owns the key now.
+var ErrClaimLost = errors.New("idempotency key was claimed by another request")
+
+type Store interface {
+	// Claim stores record as in flight unless an unexpired record is kept
+	// under its key, which is returned instead with claimed false.
+	Claim(ctx context.Context, record entities.IdempotencyRecord, now time.Time) (existing entities.IdempotencyRecord, claimed bool, err error)
+	// Complete stores the outcome of a claimed record, joining the
+	// transaction carried by ctx.
+	Complete(ctx context.Context, record entities.IdempotencyRecord) error
+	// Release deletes a claimed record so the key can be used again.
+	Release(ctx context.Context, record entities.IdempotencyRecord) error
+	Purge(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// Commit stores res as the response of a request. It is meant to be called
+// in the transaction making the changes of the request, so they are only
+// kept along with their response.
+type Commit func(ctx context.Context, res interface{}) error
+
+// Guard runs requests at most once per idempotency key.
+type Guard struct {
+	Store Store
+	Keys  *encryption.Keyring
+	// TTL is how long responses are kept and replayed.
+	TTL time.Duration
+	// Lease is how long a key stays claimed by a request that neither
+	// completed nor released it, because its process died.
+	Lease time.Duration
+	// Wait is how long a retry waits for the request holding its key to
+	// finish before giving up with a RequestInProgress error.
+	Wait time.Duration
+	Poll time.Duration
+
+	Logger log.Logger
+}
+
+func NewGuard(store Store, keys *encryption.Keyring, logger log.Logger) *Guard {
+	return &Guard{
+		Store:  store,
+		Keys:   keys,
+		TTL:    24 * time.Hour,
+		Lease:  time.Minute,
+		Wait:   5 * time.Second,
+		Poll:   100 * time.Millisecond,
+		Logger: logger,
+	}
+}
+
+// Do runs fn for the first request sent with key in scope and decodes the
+// response stored by fn, or the error it returned, into res for the ones
+// that follow. A request reusing key with another fingerprint fails with
+// IdempotencyKeyReused. One sent while the first is still running waits
+// for it up to Wait.
+//
+// Errors caused by the request, like a user that already exists, are
+// stored and replayed. Any other error releases the key so the request can
+// be retried.
+func (g *Guard) Do(ctx context.Context, scope string, key string, fingerprint string, res interface{}, fn func(ctx context.Context, commit Commit) error) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	// The fingerprint is a plain hash of the request, passwords included,
+	// it is stored keyed like the other indexes on PII.
+	record := entities.IdempotencyRecord{Scope: scope, Key: key, Fingerprint: g.Keys.BlindIndex(fingerprint)}
+
+	deadline := time.Now().Add(g.Wait)
+	for {
+		now := time.Now()
+		record.ExpiresAt = now.Add(g.Lease)
+		existing, claimed, err := g.Store.Claim(ctx, record, now)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			break
+		}
+
+		if existing.Fingerprint != record.Fingerprint {
+			return customErr.NewIdempotencyKeyReused()
+		}
+		if existing.Status == StatusCompleted {
+			return replay(existing, res)
+		}
+		if now.After(deadline) {
+			return customErr.NewRequestInProgress()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(g.Poll):
+		}
+	}
+
+	committed := false
+	err := fn(ctx, func(ctx context.Context, res interface{}) error {
+		response, err := json.Marshal(res)
+		if err != nil {
+			return err
+		}
+
+		completed := record
+		completed.Status = StatusCompleted
+		completed.Response = response
+		completed.ExpiresAt = time.Now().Add(g.TTL)
+		if err := g.Store.Complete(ctx, completed); err != nil {
+			return err
+		}
+		committed = true
+		return nil
+	})
+
+	switch {
+	case err == nil && committed:
+		return nil
+	case err != nil && final(err):
+		st, _ := status.FromError(err)
+		completed := record
+		completed.Status = StatusCompleted
+		completed.Code = uint32(st.Code())
+		completed.Message = st.Message()
+		completed.ExpiresAt = time.Now().Add(g.TTL)
+		if completeErr := g.Store.Complete(ctx, completed); completeErr == nil {
+			return err
+		}
+	}
+
+	g.release(record)
+	return err
+}
+
+// release runs even when the request was cancelled, the key would stay
+// claimed for the whole lease otherwise.
+func (g *Guard) release(record entities.IdempotencyRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := g.Store.Release(ctx, record); err != nil {
+		level.Error(g.Logger).Log("msg", "releasing idempotency key failed", "scope", record.Scope, "error", err)
+	}
+}
+
+// Run deletes expired records every interval until ctx is cancelled.
+func (g *Guard) Run(ctx context.Context, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := g.Store.Purge(ctx, time.Now(), batchSize)
+			if err != nil {
+				level.Error(g.Logger).Log("msg", "purging idempotency keys failed", "error", err)
+				break
+			}
+			if n < int64(batchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func replay(record entities.IdempotencyRecord, res interface{}) error {
+	if record.Code != uint32(codes.OK) {
+		return status.Error(codes.Code(record.Code), record.Message)
+	}
+	return json.Unmarshal(record.Response, res)
+}
+
+// final tells whether err comes from the request itself, so sending it
+// again would fail the same way.
+func final(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.FailedPrecondition, codes.PermissionDenied, codes.Unauthenticated:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,192 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/idempotency"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+type response struct {
+	UserId string
+}
+
+func TestGuardDo(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	keys := utils.NewKeyringMock()
+	fingerprint := keys.BlindIndex("fingerprint")
+
+	completed := func(res string, code codes.Code) entities.IdempotencyRecord {
+		return entities.IdempotencyRecord{Fingerprint: fingerprint, Status: idempotency.StatusCompleted, Response: []byte(res), Code: uint32(code), Message: "stored"}
+	}
+
+	testCases := []struct {
+		Name           string
+		Key            string
+		Fn             func(ctx context.Context, commit idempotency.Commit) error
+		buildMock      func(store *utils.IdempotencyStoreMock)
+		assertResponse func(t *testing.T, res response, ran bool, err error)
+	}{
+		{
+			Name: "First Request Stores Its Response",
+			Key:  "key-1",
+			Fn: func(ctx context.Context, commit idempotency.Commit) error {
+				return commit(ctx, response{UserId: "user-1"})
+			},
+			buildMock: func(store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.MatchedBy(func(record entities.IdempotencyRecord) bool {
+					return record.Scope == "CreateUser" && record.Key == "key-1" && record.Fingerprint == fingerprint
+				}), mock.Anything).Return(entities.IdempotencyRecord{}, true, nil)
+				store.On("Complete", mock.Anything, mock.MatchedBy(func(record entities.IdempotencyRecord) bool {
+					return record.Status == idempotency.StatusCompleted && string(record.Response) == `{"UserId":"user-1"}`
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res response, ran bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, ran)
+			},
+		},
+		{
+			Name: "Completed Request Is Replayed",
+			Key:  "key-1",
+			buildMock: func(store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(completed(`{"UserId":"user-1"}`, codes.OK), false, nil)
+			},
+			assertResponse: func(t *testing.T, res response, ran bool, err error) {
+				assert.NoError(t, err)
+				assert.False(t, ran)
+				assert.Equal(t, response{UserId: "user-1"}, res)
+			},
+		},
+		{
+			Name: "Stored Error Is Replayed",
+			Key:  "key-1",
+			buildMock: func(store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(completed("", codes.AlreadyExists), false, nil)
+			},
+			assertResponse: func(t *testing.T, res response, ran bool, err error) {
+				assert.False(t, ran)
+				assert.Equal(t, codes.AlreadyExists, status.Code(err))
+				assert.Equal(t, "stored", status.Convert(err).Message())
+			},
+		},
+		{
+			Name: "Key Reused With Another Request",
+			Key:  "key-1",
+			buildMock: func(store *utils.IdempotencyStoreMock) {
+				record := completed(`{"UserId":"user-1"}`, codes.OK)
+				record.Fingerprint = keys.BlindIndex("other")
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(record, false, nil)
+			},
+			assertResponse: func(t *testing.T, res response, ran bool, err error) {
+				assert.False(t, ran)
+				assert.IsType(t, myErr.IdempotencyKeyReused{}, err)
+			},
+		},
+		{
+			Name: "Retry Waits For The Request In Flight",
+			Key:  "key-1",
+			buildMock: func(store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).
+					Return(entities.IdempotencyRecord{Fingerprint: fingerprint, Status: idempotency.StatusInFlight}, false, nil).Once()
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(completed(`{"UserId":"user-1"}`, codes.OK), false, nil).Once()
+			},
+			assertResponse: func(t *testing.T, res response, ran bool, err error) {
+				assert.NoError(t, err)
+				assert.False(t, ran)
+				assert.Equal(t, "user-1", res.UserId)
+			},
+		},
+		{
+			Name: "Failed Request Releases The Key",
+			Key:  "key-1",
+			Fn: func(ctx context.Context, commit idempotency.Commit) error {
+				return errors.New("connection reset")
+			},
+			buildMock: func(store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(entities.IdempotencyRecord{}, true, nil)
+				store.On("Release", mock.Anything, mock.MatchedBy(func(record entities.IdempotencyRecord) bool {
+					return record.Key == "key-1"
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res response, ran bool, err error) {
+				assert.EqualError(t, err, "connection reset")
+			},
+		},
+		{
+			Name: "Rejected Request Is Stored",
+			Key:  "key-1",
+			Fn: func(ctx context.Context, commit idempotency.Commit) error {
+				return myErr.NewUserAlreadyExists()
+			},
+			buildMock: func(store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(entities.IdempotencyRecord{}, true, nil)
+				store.On("Complete", mock.Anything, mock.MatchedBy(func(record entities.IdempotencyRecord) bool {
+					return record.Code == uint32(codes.AlreadyExists) && record.Message == myErr.NewUserAlreadyExists().Error()
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res response, ran bool, err error) {
+				assert.IsType(t, myErr.UserAlreadyExists{}, err)
+			},
+		},
+		{
+			Name:      "Key Too Long",
+			Key:       strings.Repeat("k", idempotency.MaxKeyLength+1),
+			buildMock: func(store *utils.IdempotencyStoreMock) {},
+			assertResponse: func(t *testing.T, res response, ran bool, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			store := new(utils.IdempotencyStoreMock)
+			tc.buildMock(store)
+
+			guard := idempotency.NewGuard(store, keys, logger)
+			guard.Poll = time.Millisecond
+
+			var res response
+			ran := false
+			err := guard.Do(context.Background(), "CreateUser", tc.Key, "fingerprint", &res, func(ctx context.Context, commit idempotency.Commit) error {
+				ran = true
+				return tc.Fn(ctx, commit)
+			})
+			tc.assertResponse(t, res, ran, err)
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGuardGivesUpOnRequestInFlight(t *testing.T) {
+	keys := utils.NewKeyringMock()
+	store := new(utils.IdempotencyStoreMock)
+	store.On("Claim", mock.Anything, mock.Anything, mock.Anything).
+		Return(entities.IdempotencyRecord{Fingerprint: keys.BlindIndex("fingerprint"), Status: idempotency.StatusInFlight}, false, nil)
+
+	guard := idempotency.NewGuard(store, keys, log.NewNopLogger())
+	guard.Wait, guard.Poll = 5*time.Millisecond, time.Millisecond
+
+	err := guard.Do(context.Background(), "CreateUser", "key-1", "fingerprint", &response{}, nil)
+	assert.IsType(t, myErr.RequestInProgress{}, err)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, idempotency.Fingerprint("a", "b"), idempotency.Fingerprint("a", "b"))
+	assert.NotEqual(t, idempotency.Fingerprint("ab", ""), idempotency.Fingerprint("a", "b"))
+}
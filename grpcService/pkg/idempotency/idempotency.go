@@ -0,0 +1,132 @@
+// Package idempotency lets clients retry a request safely: the first
+// request sent with a key runs and its outcome is stored, retries with the
+// same key and body get that outcome back instead of running again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+const (
+	// KeyHeader is the gRPC metadata key holding the idempotency key.
+	KeyHeader = "idempotency-key"
+	// FingerprintHeader lets a gateway that rewrites the request, like the
+	// HTTP service hashing passwords, send the fingerprint of what its own
+	// client sent.
+	FingerprintHeader = "idempotency-fingerprint"
+
+	MaxKeyLength = 255
+)
+
+const (
+	StatusInFlight  = "in_flight"
+	StatusCompleted = "completed"
+)
+
+type contextKey struct{}
+
+type request struct {
+	key         string
+	fingerprint string
+}
+
+// WithKey returns a copy of ctx carrying an idempotency key.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, contextKey{}, request{key: key})
+}
+
+// WithFingerprint sets the fingerprint of the request whose key ctx
+// carries. It does nothing to a context without a key.
+func WithFingerprint(ctx context.Context, fingerprint string) context.Context {
+	req, ok := ctx.Value(contextKey{}).(request)
+	if !ok {
+		return ctx
+	}
+	req.fingerprint = fingerprint
+	return context.WithValue(ctx, contextKey{}, req)
+}
+
+// FromContext returns the idempotency key carried by ctx and the
+// fingerprint set along with it, if any.
+func FromContext(ctx context.Context) (key string, fingerprint string, ok bool) {
+	req, ok := ctx.Value(contextKey{}).(request)
+	return req.key, req.fingerprint, ok
+}
+
+// FromMetadata reads the idempotency key and fingerprint sent by a gRPC
+// client into ctx.
+func FromMetadata(ctx context.Context, md metadata.MD) context.Context {
+	keys := md.Get(KeyHeader)
+	if len(keys) == 0 {
+		return ctx
+	}
+
+	ctx = WithKey(ctx, keys[0])
+	if fingerprints := md.Get(FingerprintHeader); len(fingerprints) > 0 {
+		ctx = WithFingerprint(ctx, fingerprints[0])
+	}
+	return ctx
+}
+
+// ToOutgoingContext forwards the idempotency key and fingerprint carried by
+// ctx to the gRPC server it is used to call.
+func ToOutgoingContext(ctx context.Context) context.Context {
+	key, fingerprint, ok := FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, KeyHeader, key)
+	if fingerprint != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, FingerprintHeader, fingerprint)
+	}
+	return ctx
+}
+
+// ValidateKey accepts keys of 1 to MaxKeyLength visible ASCII characters.
+func ValidateKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return customErr.NewInvalidField("idempotency key", "must be 1 to 255 characters long")
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '!' || key[i] > '~' {
+			return customErr.NewInvalidField("idempotency key", "must only contain visible ASCII characters")
+		}
+	}
+	return nil
+}
+
+// Fingerprint hashes the fields of a request. Each one is prefixed with its
+// length so fields can't bleed into one another.
+func Fingerprint(fields ...string) string {
+	hash := sha256.New()
+	var size [8]byte
+	for _, field := range fields {
+		binary.BigEndian.PutUint64(size[:], uint64(len(field)))
+		hash.Write(size[:])
+		hash.Write([]byte(field))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// CreateUserFingerprint fingerprints a CreateUser request as its client sent
+// it. Attributes only count when set, so keys recorded before users had
+// attributes still match their retries.
+func CreateUserFingerprint(rq entities.CreateUserRequest) string {
+	fields := []string{rq.Name, strconv.FormatUint(uint64(rq.Age), 10), rq.Email, rq.Pass}
+	if len(rq.Attributes) > 0 {
+		attrs, _ := json.Marshal(rq.Attributes)
+		fields = append(fields, string(attrs))
+	}
+	return Fingerprint(fields...)
+}
@@ -0,0 +1,104 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+type sqlStore struct {
+	DB     *sql.DB
+	Logger log.Logger
+}
+
+// NewSQL builds a Store keeping records in the idempotency_keys table.
+func NewSQL(db *sql.DB, log log.Logger) *sqlStore {
+	return &sqlStore{db, log}
+}
+
+// Claim writes outside of any transaction carried by ctx, the claim has to
+// be visible to concurrent retries right away.
+func (s *sqlStore) Claim(ctx context.Context, record entities.IdempotencyRecord, now time.Time) (entities.IdempotencyRecord, bool, error) {
+	// The record may expire and be purged between the statements, try again
+	// when that happens.
+	for attempt := 0; attempt < 3; attempt++ {
+		claimed, err := s.exec(ctx, utils.ClaimIdempotencyKeyQuery, record.Scope, record.Key, record.Fingerprint, record.ExpiresAt)
+		if err != nil || claimed {
+			return entities.IdempotencyRecord{}, claimed, err
+		}
+
+		claimed, err = s.exec(ctx, utils.TakeOverIdempotencyKeyQuery,
+			record.Fingerprint, record.ExpiresAt, record.Scope, record.Key, now)
+		if err != nil || claimed {
+			return entities.IdempotencyRecord{}, claimed, err
+		}
+
+		existing := entities.IdempotencyRecord{Scope: record.Scope, Key: record.Key}
+		err = s.DB.QueryRowContext(ctx, utils.GetIdempotencyKeyQuery, record.Scope, record.Key).Scan(
+			&existing.Fingerprint, &existing.Status, &existing.Response, &existing.Code, &existing.Message, &existing.ExpiresAt)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			level.Error(s.Logger).Log(err)
+			return entities.IdempotencyRecord{}, false, err
+		}
+
+		return existing, false, nil
+	}
+
+	return entities.IdempotencyRecord{}, false, ErrClaimLost
+}
+
+func (s *sqlStore) Complete(ctx context.Context, record entities.IdempotencyRecord) error {
+	res, err := database.Conn(ctx, s.DB).ExecContext(ctx, utils.CompleteIdempotencyKeyQuery,
+		record.Response, record.Code, record.Message, record.ExpiresAt, record.Scope, record.Key, record.Fingerprint)
+	if err != nil {
+		level.Error(s.Logger).Log(err)
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+func (s *sqlStore) Release(ctx context.Context, record entities.IdempotencyRecord) error {
+	_, err := s.DB.ExecContext(ctx, utils.ReleaseIdempotencyKeyQuery, record.Scope, record.Key, record.Fingerprint)
+	if err != nil {
+		level.Error(s.Logger).Log(err)
+	}
+	return err
+}
+
+func (s *sqlStore) Purge(ctx context.Context, before time.Time, limit int) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, utils.PurgeIdempotencyKeysQuery, before, limit)
+	if err != nil {
+		level.Error(s.Logger).Log(err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *sqlStore) exec(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	res, err := s.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		level.Error(s.Logger).Log(err)
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
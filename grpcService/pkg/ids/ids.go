@@ -0,0 +1,123 @@
+package ids
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Strategies ids can be generated with. Random UUIDs scatter inserts over
+// the whole primary key index, the others start with the time they were
+// made at so new rows land at its end.
+const (
+	UUIDv4    = "uuidv4"
+	UUIDv7    = "uuidv7"
+	ULID      = "ulid"
+	Snowflake = "snowflake"
+)
+
+// IDGenerator makes the ids of new users.
+type IDGenerator interface {
+	NewId() string
+}
+
+// New returns the generator of strategy, node only matters to Snowflake
+// ids and has to be unique among the instances of the service.
+func New(strategy string, node int64) (IDGenerator, error) {
+	switch strategy {
+	case UUIDv4:
+		return UUIDv4Generator{}, nil
+	case UUIDv7:
+		return NewUUIDv7Generator(), nil
+	case ULID:
+		return NewULIDGenerator(), nil
+	case Snowflake:
+		return NewSnowflakeGenerator(node)
+	}
+	return nil, fmt.Errorf("unknown id strategy %s", strategy)
+}
+
+// Pattern is a regular expression taking the ids Valid takes, bar the
+// Snowflake ids too large for 63 bits. It is meant for routes, whose
+// matches still go through Valid.
+const Pattern = `[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-7][0-9A-HJKMNP-TV-Z]{25}|[1-9][0-9]{0,18}`
+
+// Valid tells whether id has the form of an id made by any of the
+// strategies, so ids stay valid when the strategy is changed.
+func Valid(id string) bool {
+	return validUUID(id) || validULID(id) || validSnowflake(id)
+}
+
+// validUUID takes the lowercase hyphenated form generators make.
+func validUUID(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if id[i] != '-' {
+				return false
+			}
+		case '0' <= id[i] && id[i] <= '9', 'a' <= id[i] && id[i] <= 'f':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func validSnowflake(id string) bool {
+	if id == "" || len(id) > 19 || id[0] == '0' {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '0' || id[i] > '9' {
+			return false
+		}
+	}
+	_, err := strconv.ParseInt(id, 10, 64)
+	return err == nil
+}
+
+// UUIDv4Generator makes random UUIDs.
+type UUIDv4Generator struct{}
+
+func (UUIDv4Generator) NewId() string {
+	return uuid.NewString()
+}
+
+// clock hands out the milliseconds ids are made at. It never goes back:
+// when the system clock is set back ids keep counting from the last
+// millisecond handed out until the clock catches up, which keeps them
+// unique and ordered.
+type clock struct {
+	last int64
+}
+
+// tick returns the millisecond of now and whether it is the one handed out
+// last.
+func (c *clock) tick(now time.Time) (int64, bool) {
+	ms := now.UnixNano() / int64(time.Millisecond)
+	if ms <= c.last {
+		return c.last, true
+	}
+	c.last = ms
+	return ms, false
+}
+
+// skip moves past the current millisecond once every id of it is used.
+func (c *clock) skip() int64 {
+	c.last++
+	return c.last
+}
+
+func random(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails when the system has no entropy source.
+		panic(err)
+	}
+}
@@ -0,0 +1,187 @@
+package ids_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
+)
+
+// stoppedClock returns a clock that only moves when told to.
+func stoppedClock(at time.Time) (func() time.Time, func(time.Duration)) {
+	return func() time.Time { return at }, func(d time.Duration) { at = at.Add(d) }
+}
+
+// ordered turns an id into a value that sorts like the rows it keys.
+func ordered(strategy, id string) string {
+	if strategy == ids.Snowflake {
+		n, _ := strconv.ParseInt(id, 10, 64)
+		return fmt.Sprintf("%019d", n)
+	}
+	return id
+}
+
+func TestGenerators(t *testing.T) {
+	for _, strategy := range []string{ids.UUIDv7, ids.ULID, ids.Snowflake} {
+		t.Run(strategy, func(t *testing.T) {
+			now, move := stoppedClock(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+			generator, err := ids.New(strategy, 7)
+			assert.NoError(t, err)
+			switch g := generator.(type) {
+			case *ids.UUIDv7Generator:
+				g.Now = now
+			case *ids.ULIDGenerator:
+				g.Now = now
+			case *ids.SnowflakeGenerator:
+				g.Now = now
+			}
+
+			seen := map[string]bool{}
+			last := ""
+			// More ids than a millisecond holds, then the clock set back.
+			for i := 0; i < 10000; i++ {
+				if i == 9000 {
+					move(-time.Second)
+				}
+				id := generator.NewId()
+				assert.True(t, ids.Valid(id), id)
+				assert.False(t, seen[id], "%s made twice", id)
+				assert.Greater(t, ordered(strategy, id), last)
+				seen[id], last = true, ordered(strategy, id)
+			}
+
+			move(time.Hour)
+			assert.Greater(t, ordered(strategy, generator.NewId()), last)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	generator, err := ids.New(ids.UUIDv4, 0)
+	assert.NoError(t, err)
+	assert.True(t, ids.Valid(generator.NewId()))
+
+	_, err = ids.New("serial", 0)
+	assert.Error(t, err)
+	_, err = ids.New(ids.Snowflake, ids.MaxSnowflakeNode+1)
+	assert.Error(t, err)
+}
+
+func TestSnowflakeLayout(t *testing.T) {
+	generator, err := ids.NewSnowflakeGenerator(5)
+	assert.NoError(t, err)
+	generator.Now = func() time.Time { return ids.SnowflakeEpoch.Add(time.Second) }
+
+	id, err := strconv.ParseInt(generator.NewId(), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), id>>22)
+	assert.Equal(t, int64(5), id>>12&1023)
+	assert.Equal(t, int64(0), id&4095)
+}
+
+func TestUUIDv7Layout(t *testing.T) {
+	generator := ids.NewUUIDv7Generator()
+	generator.Now = func() time.Time { return time.Unix(0, 0x0190f3b27c1e*int64(time.Millisecond)) }
+
+	id := generator.NewId()
+	assert.True(t, strings.HasPrefix(id, "0190f3b2-7c1e-7"), id)
+	assert.Contains(t, "89ab", id[19:20])
+}
+
+func TestValid(t *testing.T) {
+	testCases := []struct {
+		Id    string
+		Valid bool
+	}{
+		{"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b", true},
+		{"9b2c7c6e-3f0a-4d5e-8a1b-2c3d4e5f6a7b", true},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", true},
+		{"7ZZZZZZZZZZZZZZZZZZZZZZZZZ", true},
+		{"1234567890123456789", true},
+		{"", false},
+		{"user-1", false},
+		{"0190F3B2-7C1E-7D3A-9B4F-1C2D3E4F5A6B", false},
+		{"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6", false},
+		{"0190f3b27c1e7d3a9b4f1c2d3e4f5a6b", false},
+		{"8ZZZZZZZZZZZZZZZZZZZZZZZZZ", false},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAU", false},
+		{"01arz3ndektsv4rrffq69g5fav", false},
+		{"0123", false},
+		{"9223372036854775808", false},
+		{"1' OR '1'='1", false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.Valid, ids.Valid(tc.Id), tc.Id)
+	}
+}
+
+func BenchmarkNewId(b *testing.B) {
+	for _, strategy := range []string{ids.UUIDv4, ids.UUIDv7, ids.ULID, ids.Snowflake} {
+		b.Run(strategy, func(b *testing.B) {
+			generator, _ := ids.New(strategy, 1)
+			for i := 0; i < b.N; i++ {
+				generator.NewId()
+			}
+		})
+	}
+}
+
+// BenchmarkInsert measures how fast rows keyed by each kind of id go into
+// a MySQL table, like users, whose primary key is the id. It needs a
+// database to write to, given by IDS_BENCH_DSN. The gap grows with the
+// table, run it with -benchtime=2000000x to see random UUIDs fall behind
+// once the index outgrows the buffer pool.
+func BenchmarkInsert(b *testing.B) {
+	dsn := os.Getenv("IDS_BENCH_DSN")
+	if dsn == "" {
+		b.Skip("IDS_BENCH_DSN is not set")
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	const batch = 100
+	query := "INSERT INTO ids_bench (id, payload) VALUES " + strings.TrimSuffix(strings.Repeat("(?, REPEAT('x', 200)),", batch), ",")
+
+	for _, strategy := range []string{ids.UUIDv4, ids.UUIDv7, ids.ULID, ids.Snowflake} {
+		b.Run(strategy, func(b *testing.B) {
+			for _, stmt := range []string{
+				"DROP TABLE IF EXISTS ids_bench",
+				"CREATE TABLE ids_bench (id VARCHAR(36) NOT NULL PRIMARY KEY, payload VARCHAR(255) NOT NULL)",
+			} {
+				if _, err := db.Exec(stmt); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			generator, _ := ids.New(strategy, 1)
+			args := make([]interface{}, batch)
+			start := time.Now()
+			b.ResetTimer()
+			for i := 0; i < b.N; i += batch {
+				for j := range args {
+					args[j] = generator.NewId()
+				}
+				if _, err := db.Exec(query, args...); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "rows/s")
+		})
+	}
+
+	db.Exec("DROP TABLE IF EXISTS ids_bench")
+}
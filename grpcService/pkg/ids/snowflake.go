@@ -0,0 +1,64 @@
+package ids
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Snowflake ids are 63 bit numbers: 41 bits of milliseconds since
+// SnowflakeEpoch, enough for 69 years, 10 bits naming the node that made
+// them and a 12 bit counter ordering the ids a node made in a millisecond.
+const (
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+
+	MaxSnowflakeNode = 1<<snowflakeNodeBits - 1
+	snowflakeSeqMax  = 1<<snowflakeSeqBits - 1
+)
+
+// SnowflakeEpoch is the time Snowflake ids count from.
+var SnowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SnowflakeGenerator makes Snowflake ids, written in decimal. Two
+// generators with the same node make the same ids, every instance of the
+// service needs its own.
+type SnowflakeGenerator struct {
+	// Now is the clock ids are made with.
+	Now func() time.Time
+
+	node  int64
+	mu    sync.Mutex
+	clock clock
+	seq   int64
+}
+
+func NewSnowflakeGenerator(node int64) (*SnowflakeGenerator, error) {
+	if node < 0 || node > MaxSnowflakeNode {
+		return nil, fmt.Errorf("snowflake node %d is not between 0 and %d", node, MaxSnowflakeNode)
+	}
+	return &SnowflakeGenerator{Now: time.Now, node: node}, nil
+}
+
+// NewId never blocks: past 4096 ids in a millisecond it borrows the next
+// one, which the clock then doesn't hand out again.
+func (g *SnowflakeGenerator) NewId() string {
+	g.mu.Lock()
+	ms, same := g.clock.tick(g.Now())
+	switch {
+	case !same:
+		g.seq = 0
+	case g.seq == snowflakeSeqMax:
+		ms = g.clock.skip()
+		g.seq = 0
+	default:
+		g.seq++
+	}
+	seq := g.seq
+	g.mu.Unlock()
+
+	elapsed := ms - SnowflakeEpoch.UnixNano()/int64(time.Millisecond)
+	id := elapsed<<(snowflakeNodeBits+snowflakeSeqBits) | g.node<<snowflakeSeqBits | seq
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,86 @@
+package ids
+
+import (
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+)
+
+// crockford is the base32 alphabet of ULIDs, without I, L, O and U.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator makes ULIDs: 48 bits of Unix milliseconds and 80 random
+// bits, written as 26 characters of Crockford's base32. The ids of a
+// millisecond are ordered by incrementing the random bits of the first.
+type ULIDGenerator struct {
+	// Now is the clock ids are made with.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	clock clock
+	last  [16]byte
+}
+
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{Now: time.Now}
+}
+
+func (g *ULIDGenerator) NewId() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms, same := g.clock.tick(g.Now())
+	if !same || !increment(g.last[6:]) {
+		if same {
+			ms = g.clock.skip()
+		}
+		random(g.last[6:])
+	}
+	g.last[0] = byte(ms >> 40)
+	g.last[1] = byte(ms >> 32)
+	g.last[2] = byte(ms >> 24)
+	g.last[3] = byte(ms >> 16)
+	g.last[4] = byte(ms >> 8)
+	g.last[5] = byte(ms)
+
+	return encodeULID(g.last)
+}
+
+// increment adds one to the big endian number b, false when it overflows.
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// validULID takes the uppercase form generators make. The first character
+// only holds 3 bits.
+func validULID(id string) bool {
+	if len(id) != 26 || id[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(crockford, id[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
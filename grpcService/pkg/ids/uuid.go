@@ -0,0 +1,61 @@
+package ids
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UUIDv7Generator makes time ordered UUIDs: 48 bits of Unix milliseconds,
+// a 12 bit counter ordering the ids of a millisecond and 62 random bits.
+type UUIDv7Generator struct {
+	// Now is the clock ids are made with.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	clock clock
+	seq   uint16
+}
+
+func NewUUIDv7Generator() *UUIDv7Generator {
+	return &UUIDv7Generator{Now: time.Now}
+}
+
+// The counter starts from a random value below uuidSeqStart every
+// millisecond, which leaves at least 2048 ids to the millisecond without
+// making ids made at once guessable from each other.
+const (
+	uuidSeqMax   = 0xfff
+	uuidSeqStart = 0x800
+)
+
+func (g *UUIDv7Generator) NewId() string {
+	var id uuid.UUID
+	random(id[6:])
+
+	g.mu.Lock()
+	ms, same := g.clock.tick(g.Now())
+	switch {
+	case !same:
+		g.seq = (uint16(id[6])<<8 | uint16(id[7])) & (uuidSeqStart - 1)
+	case g.seq == uuidSeqMax:
+		ms = g.clock.skip()
+		g.seq = (uint16(id[6])<<8 | uint16(id[7])) & (uuidSeqStart - 1)
+	default:
+		g.seq++
+	}
+	seq := g.seq
+	g.mu.Unlock()
+
+	id[0] = byte(ms >> 40)
+	id[1] = byte(ms >> 32)
+	id[2] = byte(ms >> 24)
+	id[3] = byte(ms >> 16)
+	id[4] = byte(ms >> 8)
+	id[5] = byte(ms)
+	id[6] = 0x70 | byte(seq>>8)
+	id[7] = byte(seq)
+	id[8] = 0x80 | id[8]&0x3f
+	return id.String()
+}
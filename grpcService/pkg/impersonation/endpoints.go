@@ -0,0 +1,57 @@
+package impersonation
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	Impersonate(ctx context.Context, rq entities.ImpersonateRequest) (entities.ImpersonateResponse, error)
+	EndImpersonation(ctx context.Context, rq entities.EndImpersonationRequest) (entities.EndImpersonationResponse, error)
+}
+
+type Endpoints struct {
+	Impersonate      endpoint.Endpoint
+	EndImpersonation endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		Impersonate:      MakeImpersonateEndpoint(s),
+		EndImpersonation: MakeEndImpersonationEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		Impersonate:      mw(e.Impersonate),
+		EndImpersonation: mw(e.EndImpersonation),
+	}
+}
+
+func MakeImpersonateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ImpersonateRequest)
+		c, err := s.Impersonate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeEndImpersonationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.EndImpersonationRequest)
+		c, err := s.EndImpersonation(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
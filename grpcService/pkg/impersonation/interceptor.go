@@ -0,0 +1,161 @@
+package impersonation
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+// Allowed are the methods requests carrying an impersonation token may
+// call. They read the user or change its settings, anything else, like
+// changing its password or MFA, or deleting its account, is denied.
+var Allowed = map[string]bool{
+	"/proto.UserService/GetUser":                   true,
+	"/proto.UserService/UpdateUserAttributes":      true,
+	"/proto.UserService/GetAvatar":                 true,
+	"/proto.GroupService/ListUserGroups":           true,
+	"/proto.ConsentService/ListPolicies":           true,
+	"/proto.ConsentService/ListConsents":           true,
+	"/proto.PreferenceService/GetPreferences":      true,
+	"/proto.PreferenceService/UpdatePreferences":   true,
+	"/proto.ImpersonationService/EndImpersonation": true,
+}
+
+// Guard checks the requests carrying an impersonation token: the token is
+// valid for the caller and the tenant, its session wasn't ended, the method
+// is allowed and the request is about the impersonated user. Each of them
+// is recorded in the audit log, with the real actor and the method as the
+// operation, whether it was let through or not.
+type Guard struct {
+	Sessions Repository
+	Audit    audit.Recorder
+	Tokens   *Signer
+	Logger   log.Logger
+}
+
+func NewGuard(l log.Logger, sessions Repository, auditRepo audit.Recorder, tokens *Signer) *Guard {
+	return &Guard{sessions, auditRepo, tokens, l}
+}
+
+// UnaryServerInterceptor goes after the interceptors reading the caller and
+// the tenant.
+func (g *Guard) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	caller := audit.CallerFromContext(ctx)
+	if caller.ImpersonationToken == "" {
+		return handler(ctx, req)
+	}
+
+	claims, err := g.check(ctx, caller, info.FullMethod, req)
+	if claims.SessionId != "" {
+		caller.Impersonation = claims.SessionId
+		ctx = audit.WithCaller(ctx, caller)
+	}
+
+	var res interface{}
+	if err == nil {
+		res, err = handler(ctx, req)
+	}
+	g.record(ctx, info.FullMethod, claims.Subject, err)
+
+	return res, err
+}
+
+// StreamServerInterceptor denies every stream carrying an impersonation
+// token, none of them is only about the impersonated user.
+func (g *Guard) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := ss.Context()
+	caller := audit.CallerFromContext(ctx)
+	if caller.ImpersonationToken == "" {
+		return handler(srv, ss)
+	}
+
+	var subject string
+	if claims, err := g.Tokens.Parse(caller.ImpersonationToken); err == nil {
+		caller.Impersonation = claims.SessionId
+		ctx = audit.WithCaller(ctx, caller)
+		subject = claims.Subject
+	}
+
+	err := errors.NewForbidden("streams are not allowed while impersonating a user")
+	g.record(ctx, info.FullMethod, subject, err)
+	return err
+}
+
+// check returns the claims of the token of caller, as soon as they could be
+// trusted, and why req can't go through, if it can't.
+func (g *Guard) check(ctx context.Context, caller audit.Caller, method string, req interface{}) (Claims, error) {
+	claims, err := g.Tokens.Parse(caller.ImpersonationToken)
+	if err != nil {
+		return Claims{}, errors.NewUnauthenticated("impersonation token is invalid or expired")
+	}
+	if claims.Actor != caller.Actor {
+		return Claims{}, errors.NewUnauthenticated("impersonation token was issued to another actor")
+	}
+	if claims.Tenant != tenant.FromContext(ctx) {
+		return claims, errors.NewCrossTenantAccess()
+	}
+
+	if !Allowed[method] {
+		return claims, errors.NewForbidden(path.Base(method) + " is not allowed while impersonating a user")
+	}
+	if userId, ok := field(req, "User_Id"); ok && userId != claims.Subject {
+		return claims, errors.NewForbidden("only the impersonated user can be reached while impersonating")
+	}
+	if sessionId, ok := field(req, "Session_Id"); ok && sessionId != claims.SessionId {
+		return claims, errors.NewForbidden("only the current session can be ended while impersonating")
+	}
+
+	session, err := g.Sessions.GetSession(ctx, claims.SessionId)
+	switch {
+	case err == sql.ErrNoRows:
+		return claims, errors.NewUnauthenticated("impersonation session not found")
+	case err != nil && database.IsUnavailable(err):
+		return claims, errors.NewDataBaseUnavailable()
+	case err != nil:
+		return claims, errors.NewDataBaseError()
+	}
+	// Ending the session again is let through, it changes nothing.
+	if !session.EndedAt.IsZero() && method != "/proto.ImpersonationService/EndImpersonation" {
+		return claims, errors.NewUnauthenticated("impersonation session has ended")
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		return claims, errors.NewUnauthenticated("impersonation session has expired")
+	}
+
+	return claims, nil
+}
+
+// record audits an impersonated call. The request is answered already, an
+// error recording it is only logged.
+func (g *Guard) record(ctx context.Context, method string, subject string, err error) {
+	if g.Audit == nil {
+		return
+	}
+	if err := g.Audit.Record(ctx, audit.NewEntry(ctx, method, subject, nil, nil, err)); err != nil {
+		level.Error(g.Logger).Log("msg", "recording audit entries failed", "error", err)
+	}
+}
+
+// field returns the string field name of req, if req has one.
+func field(req interface{}, name protoreflect.Name) (string, bool) {
+	msg, ok := req.(protoreflect.ProtoMessage)
+	if !ok {
+		return "", false
+	}
+	fd := msg.ProtoReflect().Descriptor().Fields().ByName(name)
+	if fd == nil || fd.Kind() != protoreflect.StringKind {
+		return "", false
+	}
+	return msg.ProtoReflect().Get(fd).String(), true
+}
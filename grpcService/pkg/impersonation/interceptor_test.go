@@ -0,0 +1,154 @@
+package impersonation_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/impersonation"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+func TestGuardUnary(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	signer, _ := impersonation.GenerateSigner()
+	expiresAt := time.Now().Add(time.Minute)
+	token, _ := signer.Sign(impersonation.Claims{SessionId: sessionId, Actor: adminId, Subject: userId, Tenant: "acme", ExpiresAt: expiresAt.Unix()})
+	active := entities.ImpersonationSession{Id: sessionId, Actor: adminId, UserId: userId, ExpiresAt: expiresAt}
+
+	recorded := func(operation string, code codes.Code) interface{} {
+		return mock.MatchedBy(func(entries []entities.AuditEntry) bool {
+			return len(entries) == 1 && entries[0].Operation == operation && entries[0].Actor == adminId &&
+				entries[0].ImpersonationId == sessionId && entries[0].TargetUserId == userId && entries[0].Outcome == code.String()
+		})
+	}
+
+	testCases := []struct {
+		Name           string
+		Caller         audit.Caller
+		Method         string
+		Request        interface{}
+		buildMock      func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock)
+		assertResponse func(t *testing.T, called bool, err error)
+	}{
+		{
+			Name:    "Without Token",
+			Caller:  audit.Caller{Actor: adminId},
+			Method:  "/proto.UserService/DeleteUser",
+			Request: &proto.DeleteUserRequest{User_Id: userId},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, called bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, called)
+			},
+		},
+		{
+			Name:    "Allowed Method",
+			Caller:  audit.Caller{Actor: adminId, ImpersonationToken: token},
+			Method:  "/proto.UserService/GetUser",
+			Request: &proto.GetUserRequest{User_Id: userId},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				repo.On("GetSession", mock.Anything, sessionId).Return(active, nil)
+				auditRepo.On("Record", mock.Anything, recorded("/proto.UserService/GetUser", codes.OK)).Return(nil)
+			},
+			assertResponse: func(t *testing.T, called bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, called)
+			},
+		},
+		{
+			Name:    "Deleting The Account",
+			Caller:  audit.Caller{Actor: adminId, ImpersonationToken: token},
+			Method:  "/proto.UserService/DeleteUser",
+			Request: &proto.DeleteUserRequest{User_Id: userId},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				auditRepo.On("Record", mock.Anything, recorded("/proto.UserService/DeleteUser", codes.PermissionDenied)).Return(nil)
+			},
+			assertResponse: func(t *testing.T, called bool, err error) {
+				assert.Equal(t, codes.PermissionDenied, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:    "Another User",
+			Caller:  audit.Caller{Actor: adminId, ImpersonationToken: token},
+			Method:  "/proto.PreferenceService/UpdatePreferences",
+			Request: &proto.UpdatePreferencesRequest{User_Id: adminId},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				auditRepo.On("Record", mock.Anything, recorded("/proto.PreferenceService/UpdatePreferences", codes.PermissionDenied)).Return(nil)
+			},
+			assertResponse: func(t *testing.T, called bool, err error) {
+				assert.Equal(t, codes.PermissionDenied, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:    "Ended Session",
+			Caller:  audit.Caller{Actor: adminId, ImpersonationToken: token},
+			Method:  "/proto.UserService/GetUser",
+			Request: &proto.GetUserRequest{User_Id: userId},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				ended := active
+				ended.EndedAt = time.Now()
+				repo.On("GetSession", mock.Anything, sessionId).Return(ended, nil)
+				auditRepo.On("Record", mock.Anything, recorded("/proto.UserService/GetUser", codes.Unauthenticated)).Return(nil)
+			},
+			assertResponse: func(t *testing.T, called bool, err error) {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+		{
+			Name:    "Token Of Another Actor",
+			Caller:  audit.Caller{Actor: "someone-else", ImpersonationToken: token},
+			Method:  "/proto.UserService/GetUser",
+			Request: &proto.GetUserRequest{User_Id: userId},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				auditRepo.On("Record", mock.Anything, mock.MatchedBy(func(entries []entities.AuditEntry) bool {
+					return entries[0].Actor == "someone-else" && entries[0].ImpersonationId == "" && entries[0].Outcome == codes.Unauthenticated.String()
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, called bool, err error) {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				assert.False(t, called)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ImpersonationRepositoryMock)
+			auditRepo := new(utils.AuditRepositoryMock)
+			tc.buildMock(repo, auditRepo)
+
+			ctx := audit.WithCaller(tenant.WithTenant(context.Background(), "acme"), tc.Caller)
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				if tc.Caller.ImpersonationToken != "" {
+					assert.Equal(t, sessionId, audit.CallerFromContext(ctx).Impersonation)
+				}
+				return nil, nil
+			}
+
+			guard := impersonation.NewGuard(logger, repo, auditRepo, signer)
+			_, err := guard.UnaryServerInterceptor(ctx, tc.Request, &grpc.UnaryServerInfo{FullMethod: tc.Method}, handler)
+			tc.assertResponse(t, called, err)
+			repo.AssertExpectations(t)
+			auditRepo.AssertExpectations(t)
+		})
+	}
+}
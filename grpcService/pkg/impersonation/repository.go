@@ -0,0 +1,92 @@
+package impersonation
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// Repository keeps the impersonation sessions of the tenant carried by the
+// context of each call.
+type Repository interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateSession(ctx context.Context, session entities.ImpersonationSession) error
+	// GetSession returns sql.ErrNoRows when there is no such session.
+	GetSession(ctx context.Context, id string) (entities.ImpersonationSession, error)
+	// EndSession leaves a session already ended as it was.
+	EndSession(ctx context.Context, session entities.ImpersonationSession) error
+	UserExists(ctx context.Context, userId string) (bool, error)
+}
+
+type sqlRepo struct {
+	DB       *sql.DB
+	Logger   log.Logger
+	TxConfig database.TxConfig
+}
+
+func NewSQL(db *sql.DB, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, log, database.DefaultTxConfig()}
+}
+
+func (repo *sqlRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, repo.DB, repo.TxConfig, fn)
+}
+
+// conn returns the transaction in ctx, or the database when there is none.
+func (repo *sqlRepo) conn(ctx context.Context) database.Querier {
+	return database.Conn(ctx, repo.DB)
+}
+
+func (repo *sqlRepo) CreateSession(ctx context.Context, session entities.ImpersonationSession) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.CreateImpersonationQuery,
+		session.Id, tenant.FromContext(ctx), session.Actor, session.UserId, session.Reason, session.CreatedAt, session.ExpiresAt)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) GetSession(ctx context.Context, id string) (entities.ImpersonationSession, error) {
+	var (
+		session entities.ImpersonationSession
+		endedAt sql.NullTime
+	)
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.GetImpersonationQuery, tenant.FromContext(ctx), id).
+		Scan(&session.Id, &session.Actor, &session.UserId, &session.Reason, &session.CreatedAt, &session.ExpiresAt, &endedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			level.Error(repo.Logger).Log(err)
+		}
+		return entities.ImpersonationSession{}, err
+	}
+	session.EndedAt = endedAt.Time
+
+	return session, nil
+}
+
+func (repo *sqlRepo) EndSession(ctx context.Context, session entities.ImpersonationSession) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.EndImpersonationQuery, session.EndedAt, tenant.FromContext(ctx), session.Id)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) UserExists(ctx context.Context, userId string) (bool, error) {
+	var exists bool
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.UserExistsQuery, tenant.FromContext(ctx), userId).Scan(&exists)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return exists, err
+}
@@ -0,0 +1,215 @@
+// Package impersonation lets admins act as a user for a short while, to see
+// what the user sees. Everything done that way is recorded in the audit log
+// under the admin, and what could hurt the user is not allowed.
+package impersonation
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+const maxReasonLength = 500
+
+// Config holds how long impersonation sessions last.
+type Config struct {
+	// TTL is how long a session lasts when the request doesn't say.
+	TTL time.Duration
+	// MaxTTL is the longest session a request can ask for.
+	MaxTTL time.Duration
+}
+
+type service struct {
+	Repo   Repository
+	Audit  audit.Recorder
+	Tokens *Signer
+	Config Config
+	Logger log.Logger
+}
+
+func NewService(l log.Logger, r Repository, auditRepo audit.Recorder, tokens *Signer, config Config) *service {
+	return &service{r, auditRepo, tokens, config, l}
+}
+
+// Impersonate starts a session of the calling admin as a user and returns
+// the token requests made as the user carry. Attempts that fail are
+// recorded too.
+func (s *service) Impersonate(ctx context.Context, rq entities.ImpersonateRequest) (entities.ImpersonateResponse, error) {
+	s.Logger.Log("request", "impersonate", "received")
+
+	res, err := s.impersonate(ctx, rq)
+	if err != nil {
+		s.recordFailure(ctx, audit.NewEntry(ctx, "Impersonate", rq.UserId, nil, nil, err))
+		return entities.ImpersonateResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) impersonate(ctx context.Context, rq entities.ImpersonateRequest) (entities.ImpersonateResponse, error) {
+	caller := audit.CallerFromContext(ctx)
+	if caller.Actor == "" {
+		return entities.ImpersonateResponse{}, errors.NewUnauthenticated("impersonating a user requires an authenticated caller")
+	}
+	if caller.ImpersonationToken != "" {
+		return entities.ImpersonateResponse{}, errors.NewForbidden("users can't be impersonated while impersonating another")
+	}
+	if !caller.IsAdmin() {
+		return entities.ImpersonateResponse{}, errors.NewForbidden("only admins can impersonate users")
+	}
+	if rq.UserId == caller.Actor {
+		return entities.ImpersonateResponse{}, errors.NewInvalidField("user_id", "must be another user than the caller")
+	}
+
+	reason := strings.TrimSpace(rq.Reason)
+	if reason == "" {
+		return entities.ImpersonateResponse{}, errors.NewInvalidField("reason", "is required")
+	}
+	if utf8.RuneCountInString(reason) > maxReasonLength {
+		return entities.ImpersonateResponse{}, errors.NewInvalidField("reason", "at most 500 characters are allowed")
+	}
+
+	ttl := s.Config.TTL
+	if rq.TtlSeconds != 0 {
+		ttl = time.Duration(rq.TtlSeconds) * time.Second
+	}
+	// A session ending before it is issued would be of no use.
+	if ttl <= 0 {
+		return entities.ImpersonateResponse{}, errors.NewInvalidField("ttl_seconds", "must be positive")
+	}
+	if ttl > s.Config.MaxTTL {
+		return entities.ImpersonateResponse{}, errors.NewInvalidField("ttl_seconds", "must be at most "+s.Config.MaxTTL.String())
+	}
+
+	exists, err := s.Repo.UserExists(ctx, rq.UserId)
+	if err != nil {
+		return entities.ImpersonateResponse{}, s.mapError(err)
+	}
+	if !exists {
+		return entities.ImpersonateResponse{}, errors.NewUserNotFound()
+	}
+
+	// The database keeps microseconds.
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	session := entities.ImpersonationSession{
+		Id:        uuid.NewString(),
+		Actor:     caller.Actor,
+		UserId:    rq.UserId,
+		Reason:    reason,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	token, err := s.Tokens.Sign(Claims{
+		SessionId: session.Id,
+		Actor:     session.Actor,
+		Subject:   session.UserId,
+		Tenant:    tenant.FromContext(ctx),
+		ExpiresAt: session.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ImpersonateResponse{}, errors.NewGrpcError()
+	}
+
+	err = s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.Repo.CreateSession(ctx, session); err != nil {
+			return err
+		}
+		return s.record(ctx, session.Id, "Impersonate", session.UserId)
+	})
+	if err != nil {
+		return entities.ImpersonateResponse{}, s.mapError(err)
+	}
+
+	return entities.ImpersonateResponse{Token: token, Session: session}, nil
+}
+
+// EndImpersonation ends a session before it expires. Sessions are ended by
+// the admin who started them, through their token or not, or by another
+// admin. Ending a session already over changes nothing.
+func (s *service) EndImpersonation(ctx context.Context, rq entities.EndImpersonationRequest) (entities.EndImpersonationResponse, error) {
+	s.Logger.Log("request", "end impersonation", "received")
+
+	caller := audit.CallerFromContext(ctx)
+	if caller.Actor == "" {
+		return entities.EndImpersonationResponse{}, errors.NewUnauthenticated("ending an impersonation requires an authenticated caller")
+	}
+
+	session, err := s.Repo.GetSession(ctx, rq.SessionId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.EndImpersonationResponse{}, errors.NewResourceNotFound("impersonation session")
+		}
+		return entities.EndImpersonationResponse{}, s.mapError(err)
+	}
+	if session.Actor != caller.Actor && !caller.IsAdmin() {
+		return entities.EndImpersonationResponse{}, errors.NewForbidden("only admins can end the impersonations of others")
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !session.EndedAt.IsZero() || !now.Before(session.ExpiresAt) {
+		return entities.EndImpersonationResponse{Session: session}, nil
+	}
+
+	session.EndedAt = now
+	err = s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.Repo.EndSession(ctx, session); err != nil {
+			return err
+		}
+		return s.record(ctx, session.Id, "EndImpersonation", session.UserId)
+	})
+	if err != nil {
+		return entities.EndImpersonationResponse{}, s.mapError(err)
+	}
+
+	return entities.EndImpersonationResponse{Session: session}, nil
+}
+
+// record appends the entry of operation on session to the audit log in the
+// transaction carried by ctx.
+func (s *service) record(ctx context.Context, sessionId string, operation string, userId string) error {
+	if s.Audit == nil {
+		return nil
+	}
+	entry := audit.NewEntry(ctx, operation, userId, nil, nil, nil)
+	entry.ImpersonationId = sessionId
+	return s.Audit.Record(ctx, entry)
+}
+
+// recordFailure audits attempts that didn't go through. The request failed
+// already, an error recording them is only logged.
+func (s *service) recordFailure(ctx context.Context, entry entities.AuditEntry) {
+	if s.Audit == nil {
+		return
+	}
+	if err := s.Audit.Record(ctx, entry); err != nil {
+		level.Error(s.Logger).Log("msg", "recording audit entries failed", "error", err)
+	}
+}
+
+// mapError maps the errors of the repository, passing through the ones
+// already made for the client.
+func (s *service) mapError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	level.Error(s.Logger).Log("error", err)
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
@@ -0,0 +1,243 @@
+package impersonation_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/impersonation"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const (
+	adminId   = "0190f3b2-7c1e-7d3a-9b4f-000000000001"
+	userId    = "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"
+	sessionId = "7d1f2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"
+)
+
+var config = impersonation.Config{TTL: 15 * time.Minute, MaxTTL: time.Hour}
+
+func TestServiceImpersonate(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	signer, _ := impersonation.GenerateSigner()
+	admin := audit.Caller{Actor: adminId, Roles: "support, admin"}
+
+	testCases := []struct {
+		Name           string
+		Caller         audit.Caller
+		Config         impersonation.Config
+		Request        entities.ImpersonateRequest
+		buildMock      func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock)
+		assertResponse func(t *testing.T, res entities.ImpersonateResponse, err error)
+	}{
+		{
+			Name:    "Admin",
+			Caller:  admin,
+			Request: entities.ImpersonateRequest{UserId: userId, Reason: " ticket 4521 "},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(true, nil)
+				repo.On("CreateSession", mock.Anything, mock.MatchedBy(func(s entities.ImpersonationSession) bool {
+					return s.Actor == adminId && s.UserId == userId && s.Reason == "ticket 4521" && s.ExpiresAt.Sub(s.CreatedAt) == 15*time.Minute
+				})).Return(nil)
+				auditRepo.On("Record", mock.Anything, mock.MatchedBy(func(entries []entities.AuditEntry) bool {
+					return len(entries) == 1 && entries[0].Operation == "Impersonate" && entries[0].Actor == adminId &&
+						entries[0].TargetUserId == userId && entries[0].ImpersonationId != "" && entries[0].Outcome == "OK"
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ImpersonateResponse, err error) {
+				assert.NoError(t, err)
+				claims, err := signer.Parse(res.Token)
+				assert.NoError(t, err)
+				assert.Equal(t, res.Session.Id, claims.SessionId)
+				assert.Equal(t, adminId, claims.Actor)
+				assert.Equal(t, userId, claims.Subject)
+			},
+		},
+		{
+			Name:    "Not An Admin",
+			Caller:  audit.Caller{Actor: adminId, Roles: "support"},
+			Request: entities.ImpersonateRequest{UserId: userId, Reason: "ticket 4521"},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				auditRepo.On("Record", mock.Anything, mock.MatchedBy(func(entries []entities.AuditEntry) bool {
+					return entries[0].Operation == "Impersonate" && entries[0].Outcome == "PermissionDenied"
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ImpersonateResponse, err error) {
+				assert.IsType(t, myErr.Forbidden{}, err)
+			},
+		},
+		{
+			Name:    "Already Impersonating",
+			Caller:  audit.Caller{Actor: adminId, Roles: "admin", ImpersonationToken: "token"},
+			Request: entities.ImpersonateRequest{UserId: userId, Reason: "ticket 4521"},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				auditRepo.On("Record", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ImpersonateResponse, err error) {
+				assert.IsType(t, myErr.Forbidden{}, err)
+			},
+		},
+		{
+			Name:    "Anonymous",
+			Request: entities.ImpersonateRequest{UserId: userId, Reason: "ticket 4521"},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				auditRepo.On("Record", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ImpersonateResponse, err error) {
+				assert.IsType(t, myErr.Unauthenticated{}, err)
+			},
+		},
+		{
+			Name:    "Missing Reason",
+			Caller:  admin,
+			Request: entities.ImpersonateRequest{UserId: userId, Reason: "  "},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				auditRepo.On("Record", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ImpersonateResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:    "TTL Too Long",
+			Caller:  admin,
+			Request: entities.ImpersonateRequest{UserId: userId, Reason: "ticket 4521", TtlSeconds: 7200},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				auditRepo.On("Record", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ImpersonateResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:    "Default TTL Not Positive",
+			Caller:  admin,
+			Config:  impersonation.Config{TTL: -time.Minute, MaxTTL: time.Hour},
+			Request: entities.ImpersonateRequest{UserId: userId, Reason: "ticket 4521"},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				auditRepo.On("Record", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ImpersonateResponse, err error) {
+				assert.Equal(t, myErr.NewInvalidField("ttl_seconds", "must be positive"), err)
+			},
+		},
+		{
+			Name:    "Unknown User",
+			Caller:  admin,
+			Request: entities.ImpersonateRequest{UserId: userId, Reason: "ticket 4521"},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(false, nil)
+				auditRepo.On("Record", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ImpersonateResponse, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ImpersonationRepositoryMock)
+			auditRepo := new(utils.AuditRepositoryMock)
+			tc.buildMock(repo, auditRepo)
+
+			if tc.Config == (impersonation.Config{}) {
+				tc.Config = config
+			}
+
+			ctx := audit.WithCaller(context.Background(), tc.Caller)
+			res, err := impersonation.NewService(logger, repo, auditRepo, signer, tc.Config).Impersonate(ctx, tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+			auditRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceEndImpersonation(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	signer, _ := impersonation.GenerateSigner()
+	now := time.Now().UTC()
+	active := entities.ImpersonationSession{Id: sessionId, Actor: adminId, UserId: userId, CreatedAt: now, ExpiresAt: now.Add(time.Minute)}
+
+	testCases := []struct {
+		Name           string
+		Caller         audit.Caller
+		buildMock      func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock)
+		assertResponse func(t *testing.T, res entities.EndImpersonationResponse, err error)
+	}{
+		{
+			Name:   "Own Session",
+			Caller: audit.Caller{Actor: adminId},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				repo.On("GetSession", mock.Anything, sessionId).Return(active, nil)
+				repo.On("EndSession", mock.Anything, mock.MatchedBy(func(s entities.ImpersonationSession) bool {
+					return s.Id == sessionId && !s.EndedAt.IsZero()
+				})).Return(nil)
+				auditRepo.On("Record", mock.Anything, mock.MatchedBy(func(entries []entities.AuditEntry) bool {
+					return entries[0].Operation == "EndImpersonation" && entries[0].ImpersonationId == sessionId
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.EndImpersonationResponse, err error) {
+				assert.NoError(t, err)
+				assert.False(t, res.Session.EndedAt.IsZero())
+			},
+		},
+		{
+			Name:   "Already Ended",
+			Caller: audit.Caller{Actor: adminId},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				ended := active
+				ended.EndedAt = now
+				repo.On("GetSession", mock.Anything, sessionId).Return(ended, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.EndImpersonationResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, now, res.Session.EndedAt)
+			},
+		},
+		{
+			Name:   "Session Of Another Actor",
+			Caller: audit.Caller{Actor: "someone-else"},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				repo.On("GetSession", mock.Anything, sessionId).Return(active, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.EndImpersonationResponse, err error) {
+				assert.IsType(t, myErr.Forbidden{}, err)
+			},
+		},
+		{
+			Name:   "Unknown Session",
+			Caller: audit.Caller{Actor: adminId},
+			buildMock: func(repo *utils.ImpersonationRepositoryMock, auditRepo *utils.AuditRepositoryMock) {
+				repo.On("GetSession", mock.Anything, sessionId).Return(entities.ImpersonationSession{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.EndImpersonationResponse, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.ImpersonationRepositoryMock)
+			auditRepo := new(utils.AuditRepositoryMock)
+			tc.buildMock(repo, auditRepo)
+
+			ctx := audit.WithCaller(context.Background(), tc.Caller)
+			res, err := impersonation.NewService(logger, repo, auditRepo, signer, config).EndImpersonation(ctx, entities.EndImpersonationRequest{SessionId: sessionId})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+			auditRepo.AssertExpectations(t)
+		})
+	}
+}
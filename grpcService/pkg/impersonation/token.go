@@ -0,0 +1,123 @@
+package impersonation
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("impersonation: invalid token")
+	ErrExpiredToken = errors.New("impersonation: token expired")
+)
+
+// Claims are what an impersonation token vouches for: the actor may act
+// as the subject, in the tenant, until it expires.
+type Claims struct {
+	SessionId string `json:"sid"`
+	Actor     string `json:"act"`
+	Subject   string `json:"sub"`
+	Tenant    string `json:"tenant"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Signer signs impersonation tokens with an HMAC-SHA256 key. A token is
+// its base64 claims and signature joined by a dot.
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(key []byte) (*Signer, error) {
+	if len(key) < 32 {
+		return nil, errors.New("impersonation: token key must be at least 32 bytes")
+	}
+	return &Signer{key}, nil
+}
+
+// LoadSigner reads a file holding the base64 key.
+func LoadSigner(path string) (*Signer, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, errors.New("impersonation: token key is not base64")
+	}
+	return NewSigner(key)
+}
+
+// GenerateSigner makes a signer with a new random key.
+func GenerateSigner() (*Signer, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &Signer{key}, nil
+}
+
+func (s *Signer) Sign(claims Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded)), nil
+}
+
+// Parse checks the signature and expiry of token and returns its claims.
+func (s *Signer) Parse(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, s.mac(parts[0])) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	claims, err := Subject(token)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+	return claims, nil
+}
+
+// Subject reads the claims of token without checking it. It is for a
+// gateway to route the requests of an impersonating actor to the user's
+// own resources, the gRPC service checks the token on every call.
+func Subject(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.SessionId == "" || claims.Subject == "" {
+		return Claims{}, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (s *Signer) mac(payload string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,62 @@
+package impersonation_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/impersonation"
+)
+
+func TestSigner(t *testing.T) {
+	signer, err := impersonation.GenerateSigner()
+	assert.NoError(t, err)
+
+	claims := impersonation.Claims{SessionId: "session-1", Actor: "admin-1", Subject: "user-1", Tenant: "acme", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	token, err := signer.Sign(claims)
+	assert.NoError(t, err)
+
+	t.Run("Valid Token", func(t *testing.T) {
+		parsed, err := signer.Parse(token)
+		assert.NoError(t, err)
+		assert.Equal(t, claims, parsed)
+	})
+
+	t.Run("Tampered Subject", func(t *testing.T) {
+		other, _ := signer.Sign(impersonation.Claims{SessionId: "session-1", Actor: "admin-1", Subject: "user-2", Tenant: "acme", ExpiresAt: claims.ExpiresAt})
+		forged := strings.Split(other, ".")[0] + "." + strings.Split(token, ".")[1]
+
+		_, err := signer.Parse(forged)
+		assert.ErrorIs(t, err, impersonation.ErrInvalidToken)
+	})
+
+	t.Run("Other Key", func(t *testing.T) {
+		other, _ := impersonation.GenerateSigner()
+
+		_, err := other.Parse(token)
+		assert.ErrorIs(t, err, impersonation.ErrInvalidToken)
+	})
+
+	t.Run("Expired Token", func(t *testing.T) {
+		expired, _ := signer.Sign(impersonation.Claims{SessionId: "session-1", Actor: "admin-1", Subject: "user-1", Tenant: "acme", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+
+		_, err := signer.Parse(expired)
+		assert.ErrorIs(t, err, impersonation.ErrExpiredToken)
+	})
+
+	t.Run("Unchecked Subject", func(t *testing.T) {
+		parsed, err := impersonation.Subject(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "user-1", parsed.Subject)
+
+		_, err = impersonation.Subject("not-a-token")
+		assert.ErrorIs(t, err, impersonation.ErrInvalidToken)
+	})
+
+	t.Run("Short Key", func(t *testing.T) {
+		_, err := impersonation.NewSigner([]byte("short"))
+		assert.Error(t, err)
+	})
+}
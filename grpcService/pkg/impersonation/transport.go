@@ -0,0 +1,114 @@
+package impersonation
+
+import (
+	"context"
+	"time"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	impersonate gr.Handler
+	end         gr.Handler
+	proto.UnimplementedImpersonationServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.ImpersonationServiceServer {
+	return &gRPCSv{
+		impersonate: gr.NewServer(
+			end.Impersonate,
+			decodeImpersonateRequest,
+			encodeImpersonateResponse,
+		),
+
+		end: gr.NewServer(
+			end.EndImpersonation,
+			decodeEndImpersonationRequest,
+			encodeEndImpersonationResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) Impersonate(ctx context.Context, rq *proto.ImpersonateRequest) (*proto.ImpersonateResponse, error) {
+	_, resp, err := g.impersonate.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ImpersonateResponse), nil
+}
+
+func (g *gRPCSv) EndImpersonation(ctx context.Context, rq *proto.EndImpersonationRequest) (*proto.EndImpersonationResponse, error) {
+	_, resp, err := g.end.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.EndImpersonationResponse), nil
+}
+
+func decodeImpersonateRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ImpersonateRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("target_user_id", res.Target_User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ImpersonateRequest{UserId: id.String(), Reason: res.Reason, TtlSeconds: res.Ttl_Seconds}, nil
+}
+
+func encodeImpersonateResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ImpersonateResponse)
+	return &proto.ImpersonateResponse{Token: res.Token, Session: sessionToProto(res.Session)}, nil
+}
+
+func decodeEndImpersonationRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.EndImpersonationRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	if res.Session_Id == "" {
+		return nil, customErr.NewInvalidField("session_id", "is required")
+	}
+	if _, err := uuid.Parse(res.Session_Id); err != nil {
+		return nil, customErr.NewInvalidField("session_id", "must be a UUID")
+	}
+
+	return entities.EndImpersonationRequest{SessionId: res.Session_Id}, nil
+}
+
+func encodeEndImpersonationResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.EndImpersonationResponse)
+	return &proto.EndImpersonationResponse{Session: sessionToProto(res.Session)}, nil
+}
+
+func sessionToProto(session entities.ImpersonationSession) *proto.ImpersonationSession {
+	return &proto.ImpersonationSession{
+		Id:         session.Id,
+		Actor:      session.Actor,
+		User_Id:    session.UserId,
+		Reason:     session.Reason,
+		Created_At: timeToProto(session.CreatedAt),
+		Expires_At: timeToProto(session.ExpiresAt),
+		Ended_At:   timeToProto(session.EndedAt),
+	}
+}
+
+// timeToProto leaves unset times out of the response.
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,105 @@
+package invitation
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	InviteUser(ctx context.Context, rq entities.InviteUserRequest) (entities.InviteUserResponse, error)
+	AcceptInvitation(ctx context.Context, rq entities.AcceptInvitationRequest) (entities.AcceptInvitationResponse, error)
+	ListInvitations(ctx context.Context, rq entities.ListInvitationsRequest) (entities.ListInvitationsResponse, error)
+	RevokeInvitation(ctx context.Context, rq entities.RevokeInvitationRequest) (entities.RevokeInvitationResponse, error)
+	ResendInvitation(ctx context.Context, rq entities.ResendInvitationRequest) (entities.ResendInvitationResponse, error)
+}
+
+type Endpoints struct {
+	InviteUser       endpoint.Endpoint
+	AcceptInvitation endpoint.Endpoint
+	ListInvitations  endpoint.Endpoint
+	RevokeInvitation endpoint.Endpoint
+	ResendInvitation endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		InviteUser:       MakeInviteUserEndpoint(s),
+		AcceptInvitation: MakeAcceptInvitationEndpoint(s),
+		ListInvitations:  MakeListInvitationsEndpoint(s),
+		RevokeInvitation: MakeRevokeInvitationEndpoint(s),
+		ResendInvitation: MakeResendInvitationEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		InviteUser:       mw(e.InviteUser),
+		AcceptInvitation: mw(e.AcceptInvitation),
+		ListInvitations:  mw(e.ListInvitations),
+		RevokeInvitation: mw(e.RevokeInvitation),
+		ResendInvitation: mw(e.ResendInvitation),
+	}
+}
+
+func MakeInviteUserEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.InviteUserRequest)
+		c, err := s.InviteUser(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeAcceptInvitationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.AcceptInvitationRequest)
+		c, err := s.AcceptInvitation(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListInvitationsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListInvitationsRequest)
+		c, err := s.ListInvitations(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeRevokeInvitationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.RevokeInvitationRequest)
+		c, err := s.RevokeInvitation(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeResendInvitationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ResendInvitationRequest)
+		c, err := s.ResendInvitation(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
@@ -0,0 +1,75 @@
+package invitation
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+// Notifier delivers the link of an invitation to the invitee.
+type Notifier interface {
+	Notify(ctx context.Context, invitation entities.Invitation, link string) error
+}
+
+// LogNotifier writes the links to the log instead of sending them, for
+// development.
+type LogNotifier struct {
+	Logger log.Logger
+}
+
+func NewLogNotifier(logger log.Logger) *LogNotifier {
+	return &LogNotifier{logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, invitation entities.Invitation, link string) error {
+	level.Info(n.Logger).Log("msg", "invitation", "id", invitation.Id, "email", invitation.Email, "link", link)
+	return nil
+}
+
+// SMTPNotifier mails the links through an SMTP server.
+type SMTPNotifier struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+// NewSMTPNotifier sends through the server at addr, authenticating with
+// PLAIN auth when a username is given.
+func NewSMTPNotifier(addr, from, username, password string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{Addr: addr, From: from, Auth: auth}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, invitation entities.Invitation, link string) error {
+	// The email comes from a validated address, it holds no line breaks.
+	message := strings.Join([]string{
+		"From: " + n.From,
+		"To: " + invitation.Email,
+		"Subject: You have been invited",
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		"You have been invited to create an account. Follow the link below to accept:",
+		"",
+		link,
+		"",
+		fmt.Sprintf("The link expires on %s.", invitation.ExpiresAt.UTC().Format(time.RFC1123)),
+		"",
+	}, "\r\n")
+
+	return smtp.SendMail(n.Addr, n.Auth, n.From, []string{invitation.Email}, []byte(message))
+}
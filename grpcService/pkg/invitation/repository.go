@@ -0,0 +1,252 @@
+package invitation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/encryption"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+var ErrPending = errors.New("invitation: email already has a pending invitation")
+
+// Repository keeps the invitations of the tenant carried by the context of
+// each call, with the hash of the last token sent for each.
+type Repository interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// CreateInvitation returns ErrPending while another invitation to the
+	// same email is pending. Expired ones don't count.
+	CreateInvitation(ctx context.Context, invitation entities.Invitation, tokenHash string) error
+	// GetInvitation and LockInvitation return sql.ErrNoRows when there is
+	// no invitation id.
+	GetInvitation(ctx context.Context, id string) (entities.Invitation, string, error)
+	LockInvitation(ctx context.Context, id string) (entities.Invitation, string, error)
+	ListInvitations(ctx context.Context, afterId string, limit int) ([]entities.Invitation, error)
+	// RenewInvitation sets the token and expiry of an invitation sent again.
+	// Like CreateInvitation it returns ErrPending while another invitation
+	// to the same email is pending.
+	RenewInvitation(ctx context.Context, invitation entities.Invitation, tokenHash string) error
+	// CloseInvitation records that an invitation was accepted or revoked.
+	CloseInvitation(ctx context.Context, invitation entities.Invitation) error
+	// ListUserInvitations returns the invitations accepted by a user, and
+	// DeleteUserInvitations deletes them when the user is erased.
+	ListUserInvitations(ctx context.Context, userId string) ([]entities.Invitation, error)
+	DeleteUserInvitations(ctx context.Context, userId string) error
+	EmailTaken(ctx context.Context, email string) (bool, error)
+}
+
+type sqlRepo struct {
+	DB       *sql.DB
+	Keys     *encryption.Keyring
+	Logger   log.Logger
+	TxConfig database.TxConfig
+}
+
+// NewSQL builds a repository encrypting emails with keys.
+func NewSQL(db *sql.DB, keys *encryption.Keyring, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, keys, log, database.DefaultTxConfig()}
+}
+
+func (repo *sqlRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, repo.DB, repo.TxConfig, fn)
+}
+
+// conn returns the transaction in ctx, or the database when there is none.
+func (repo *sqlRepo) conn(ctx context.Context) database.Querier {
+	return database.Conn(ctx, repo.DB)
+}
+
+func (repo *sqlRepo) CreateInvitation(ctx context.Context, invitation entities.Invitation, tokenHash string) error {
+	id := tenant.FromContext(ctx)
+	emailIndex := repo.Keys.BlindIndex(invitation.Email)
+
+	email, err := repo.Keys.Encrypt(invitation.Email)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	if err := repo.releaseExpired(ctx, emailIndex, invitation.CreatedAt); err != nil {
+		return err
+	}
+
+	_, err = repo.conn(ctx).ExecContext(ctx, utils.CreateInvitationQuery,
+		invitation.Id, id, email, emailIndex, strings.Join(invitation.Roles, ","), invitation.GroupId,
+		invitation.Status, invitation.InvitedBy, tokenHash, invitation.CreatedAt, invitation.ExpiresAt)
+	return repo.pendingOr(err)
+}
+
+func (repo *sqlRepo) GetInvitation(ctx context.Context, id string) (entities.Invitation, string, error) {
+	return repo.getInvitation(ctx, utils.GetInvitationQuery, id)
+}
+
+// LockInvitation locks the row of an invitation until the transaction
+// carried by ctx ends.
+func (repo *sqlRepo) LockInvitation(ctx context.Context, id string) (entities.Invitation, string, error) {
+	return repo.getInvitation(ctx, utils.LockInvitationQuery, id)
+}
+
+func (repo *sqlRepo) getInvitation(ctx context.Context, query string, id string) (entities.Invitation, string, error) {
+	row := repo.conn(ctx).QueryRowContext(ctx, query, tenant.FromContext(ctx), id)
+
+	invitation, tokenHash, err := repo.scan(row)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			level.Error(repo.Logger).Log(err)
+		}
+		return entities.Invitation{}, "", err
+	}
+
+	return invitation, tokenHash, nil
+}
+
+// ListInvitations returns up to limit invitations with an id greater than
+// afterId, in id order.
+func (repo *sqlRepo) ListInvitations(ctx context.Context, afterId string, limit int) ([]entities.Invitation, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, utils.ListInvitationsAfterQuery, tenant.FromContext(ctx), afterId, limit)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []entities.Invitation
+	for rows.Next() {
+		invitation, _, err := repo.scan(rows)
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+func (repo *sqlRepo) RenewInvitation(ctx context.Context, invitation entities.Invitation, tokenHash string) error {
+	emailIndex := repo.Keys.BlindIndex(invitation.Email)
+	if err := repo.releaseExpired(ctx, emailIndex, time.Now()); err != nil {
+		return err
+	}
+
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.RenewInvitationQuery,
+		tokenHash, invitation.ExpiresAt, emailIndex, tenant.FromContext(ctx), invitation.Id)
+	return repo.pendingOr(err)
+}
+
+// releaseExpired lets the email of invitations expired by now be invited
+// again.
+func (repo *sqlRepo) releaseExpired(ctx context.Context, emailIndex string, now time.Time) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.ReleaseExpiredInvitationQuery, tenant.FromContext(ctx), emailIndex, now)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) pendingOr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+		return ErrPending
+	}
+	level.Error(repo.Logger).Log(err)
+	return err
+}
+
+func (repo *sqlRepo) CloseInvitation(ctx context.Context, invitation entities.Invitation) error {
+	var acceptedAt sql.NullTime
+	if !invitation.AcceptedAt.IsZero() {
+		acceptedAt = sql.NullTime{Time: invitation.AcceptedAt, Valid: true}
+	}
+
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.CloseInvitationQuery,
+		invitation.Status, acceptedAt, invitation.UserId, tenant.FromContext(ctx), invitation.Id)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) ListUserInvitations(ctx context.Context, userId string) ([]entities.Invitation, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, utils.ListUserInvitationsQuery, tenant.FromContext(ctx), userId)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []entities.Invitation
+	for rows.Next() {
+		invitation, _, err := repo.scan(rows)
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+func (repo *sqlRepo) DeleteUserInvitations(ctx context.Context, userId string) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.DeleteUserInvitationsQuery, tenant.FromContext(ctx), userId)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+// EmailTaken tells whether a user of the tenant has email.
+func (repo *sqlRepo) EmailTaken(ctx context.Context, email string) (bool, error) {
+	var taken bool
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.EmailTakenQuery, tenant.FromContext(ctx), repo.Keys.BlindIndex(email)).Scan(&taken)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return taken, err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (repo *sqlRepo) scan(row scanner) (entities.Invitation, string, error) {
+	var (
+		invitation entities.Invitation
+		roles      string
+		acceptedAt sql.NullTime
+		tokenHash  string
+	)
+	err := row.Scan(&invitation.Id, &invitation.Email, &roles, &invitation.GroupId, &invitation.Status, &invitation.InvitedBy,
+		&invitation.CreatedAt, &invitation.ExpiresAt, &acceptedAt, &invitation.UserId, &tokenHash)
+	if err != nil {
+		return entities.Invitation{}, "", err
+	}
+
+	invitation.Email, err = repo.Keys.Decrypt(invitation.Email)
+	if err != nil {
+		return entities.Invitation{}, "", err
+	}
+	if roles != "" {
+		invitation.Roles = strings.Split(roles, ",")
+	}
+	invitation.AcceptedAt = acceptedAt.Time
+
+	return invitation, tokenHash, nil
+}
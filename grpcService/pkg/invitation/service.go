@@ -0,0 +1,398 @@
+package invitation
+
+import (
+	"context"
+	"database/sql"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	maxRoles        = 20
+)
+
+// Roles are stored comma separated.
+var validRole = regexp.MustCompile(`^[a-zA-Z0-9_.:-]{1,64}$`)
+
+// Users creates the user of an accepted invitation.
+type Users interface {
+	CreateUser(ctx context.Context, rq entities.CreateUserRequest) (entities.CreateUserResponse, error)
+}
+
+// Groups adds the user of an accepted invitation to its group.
+type Groups interface {
+	GetGroup(ctx context.Context, id string) (entities.Group, error)
+	AddMember(ctx context.Context, member entities.GroupMember) error
+}
+
+// Config holds what invitations are sent with.
+type Config struct {
+	// TTL is how long an invitation link can be accepted.
+	TTL time.Duration
+	// LinkURL is where invitees accept their invitation, the token is added
+	// to it as the token query parameter.
+	LinkURL string
+}
+
+type service struct {
+	Repo     Repository
+	Users    Users
+	Groups   Groups
+	Tokens   *Signer
+	Notifier Notifier
+	Config   Config
+	Logger   log.Logger
+}
+
+func NewService(l log.Logger, r Repository, users Users, groups Groups, tokens *Signer, notifier Notifier, config Config) *service {
+	return &service{r, users, groups, tokens, notifier, config, l}
+}
+
+// InviteUser saves a pending invitation and sends its link once saved. The
+// invitation is revoked when the link can't be sent, so the email can be
+// invited again.
+func (s *service) InviteUser(ctx context.Context, rq entities.InviteUserRequest) (entities.InviteUserResponse, error) {
+	s.Logger.Log("request", "invite user", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.InviteUserResponse{}, err
+	}
+
+	address, err := mail.ParseAddress(rq.Email)
+	if err != nil || address.Name != "" {
+		return entities.InviteUserResponse{}, errors.NewInvalidField("email", "must be an email address")
+	}
+	if len(rq.Roles) > maxRoles {
+		return entities.InviteUserResponse{}, errors.NewInvalidField("roles", "at most 20 are allowed")
+	}
+	for _, role := range rq.Roles {
+		if !validRole.MatchString(role) {
+			return entities.InviteUserResponse{}, errors.NewInvalidField("roles", "must be 1 to 64 letters, digits, '_', '.', ':' or '-'")
+		}
+	}
+
+	// The database keeps microseconds.
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	invitation := entities.Invitation{
+		Id:        uuid.NewString(),
+		Email:     address.Address,
+		Roles:     rq.Roles,
+		GroupId:   rq.GroupId,
+		Status:    entities.InvitationPending,
+		InvitedBy: audit.CallerFromContext(ctx).Actor,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.Config.TTL),
+	}
+
+	var token string
+	err = s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		taken, err := s.Repo.EmailTaken(ctx, invitation.Email)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errors.NewUserAlreadyExists()
+		}
+
+		if invitation.GroupId != "" {
+			if _, err := s.Groups.GetGroup(ctx, invitation.GroupId); err != nil {
+				if err == sql.ErrNoRows {
+					return errors.NewResourceNotFound("group")
+				}
+				return err
+			}
+		}
+
+		token, err = s.token(ctx, invitation)
+		if err != nil {
+			return err
+		}
+		return s.Repo.CreateInvitation(ctx, invitation, TokenHash(token))
+	})
+	if err != nil {
+		return entities.InviteUserResponse{}, s.mapError(err)
+	}
+
+	// The link is sent once the invitation is saved, a link sent for an
+	// invitation rolled back afterwards couldn't be accepted.
+	if err := s.notify(ctx, invitation, token); err != nil {
+		revoked := invitation
+		revoked.Status = entities.InvitationRevoked
+		if err := s.Repo.CloseInvitation(ctx, revoked); err != nil {
+			level.Error(s.Logger).Log("msg", "undelivered invitation not revoked", "id", invitation.Id, "error", err)
+		}
+		return entities.InviteUserResponse{}, err
+	}
+
+	return entities.InviteUserResponse{
+		Invitation: invitation,
+		Status:     entities.Status{Message: "invitation sent"},
+	}, nil
+}
+
+// AcceptInvitation creates the user of a pending invitation with the email
+// it was sent to, through the same path as CreateUser, and joins it to the
+// group of the invitation if that still exists. The roles of the invitation
+// are returned for the gateway to grant them.
+func (s *service) AcceptInvitation(ctx context.Context, rq entities.AcceptInvitationRequest) (entities.AcceptInvitationResponse, error) {
+	s.Logger.Log("request", "accept invitation", "received")
+
+	claims, err := s.Tokens.Parse(rq.Token)
+	switch {
+	case err == ErrExpiredToken:
+		return entities.AcceptInvitationResponse{}, errors.NewPreconditionFailed("invitation expired")
+	case err != nil || claims.Tenant != tenant.FromContext(ctx):
+		return entities.AcceptInvitationResponse{}, errors.NewInvalidField("token", "is invalid")
+	}
+	if strings.TrimSpace(rq.Name) == "" || rq.Pass == "" {
+		return entities.AcceptInvitationResponse{}, errors.NewFieldsMissing()
+	}
+
+	var invitation entities.Invitation
+	err = s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		var tokenHash string
+		var err error
+		invitation, tokenHash, err = s.Repo.LockInvitation(ctx, claims.InvitationId)
+		if err != nil {
+			return err
+		}
+		// A resent invitation only takes its last link.
+		if tokenHash != TokenHash(rq.Token) {
+			return errors.NewInvalidField("token", "is invalid")
+		}
+		if err := checkPending(invitation); err != nil {
+			return err
+		}
+
+		user, err := s.Users.CreateUser(ctx, entities.CreateUserRequest{Name: rq.Name, Pass: rq.Pass, Email: invitation.Email})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		if invitation.GroupId != "" {
+			if _, err := s.Groups.GetGroup(ctx, invitation.GroupId); err == nil {
+				member := entities.GroupMember{GroupId: invitation.GroupId, UserId: user.UserId, Role: entities.GroupRoleMember, JoinedAt: now}
+				if err := s.Groups.AddMember(ctx, member); err != nil {
+					return err
+				}
+			} else if err != sql.ErrNoRows {
+				return err
+			}
+		}
+
+		invitation.Status = entities.InvitationAccepted
+		invitation.AcceptedAt = now
+		invitation.UserId = user.UserId
+		return s.Repo.CloseInvitation(ctx, invitation)
+	})
+	if err != nil {
+		return entities.AcceptInvitationResponse{}, s.mapError(err)
+	}
+
+	return entities.AcceptInvitationResponse{UserId: invitation.UserId, Roles: invitation.Roles, Invitation: invitation}, nil
+}
+
+// ListInvitations pages through the invitations of the tenant in id order.
+// The page token is the id of the last invitation of the page before.
+func (s *service) ListInvitations(ctx context.Context, rq entities.ListInvitationsRequest) (entities.ListInvitationsResponse, error) {
+	s.Logger.Log("request", "list invitations", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.ListInvitationsResponse{}, err
+	}
+
+	pageSize := int(rq.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	// One invitation more than asked tells whether there is a next page.
+	invitations, err := s.Repo.ListInvitations(ctx, rq.PageToken, pageSize+1)
+	if err != nil {
+		return entities.ListInvitationsResponse{}, s.mapError(err)
+	}
+
+	now := time.Now()
+	for i := range invitations {
+		invitations[i] = withStatus(invitations[i], now)
+	}
+
+	response := entities.ListInvitationsResponse{Invitations: invitations}
+	if len(invitations) > pageSize {
+		response.Invitations = invitations[:pageSize]
+		response.NextPageToken = invitations[pageSize-1].Id
+	}
+
+	return response, nil
+}
+
+// RevokeInvitation closes a pending invitation. Revoking it again changes
+// nothing.
+func (s *service) RevokeInvitation(ctx context.Context, rq entities.RevokeInvitationRequest) (entities.RevokeInvitationResponse, error) {
+	s.Logger.Log("request", "revoke invitation", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.RevokeInvitationResponse{}, err
+	}
+
+	var invitation entities.Invitation
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		invitation, _, err = s.Repo.LockInvitation(ctx, rq.InvitationId)
+		if err != nil {
+			return err
+		}
+
+		switch invitation.Status {
+		case entities.InvitationRevoked:
+			return nil
+		case entities.InvitationAccepted:
+			return errors.NewPreconditionFailed("invitation already accepted")
+		}
+
+		invitation.Status = entities.InvitationRevoked
+		return s.Repo.CloseInvitation(ctx, invitation)
+	})
+	if err != nil {
+		return entities.RevokeInvitationResponse{}, s.mapError(err)
+	}
+
+	return entities.RevokeInvitationResponse{Invitation: invitation}, nil
+}
+
+// ResendInvitation sends a pending or expired invitation again with a new
+// link and expiry, once saved. The link sent before stops working, when the
+// new one can't be sent the invitation can be resent again.
+func (s *service) ResendInvitation(ctx context.Context, rq entities.ResendInvitationRequest) (entities.ResendInvitationResponse, error) {
+	s.Logger.Log("request", "resend invitation", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.ResendInvitationResponse{}, err
+	}
+
+	var invitation entities.Invitation
+	var token string
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		invitation, _, err = s.Repo.LockInvitation(ctx, rq.InvitationId)
+		if err != nil {
+			return err
+		}
+		if invitation.Status != entities.InvitationPending {
+			return errors.NewPreconditionFailed("invitation already " + invitation.Status)
+		}
+
+		invitation.ExpiresAt = time.Now().UTC().Truncate(time.Microsecond).Add(s.Config.TTL)
+		token, err = s.token(ctx, invitation)
+		if err != nil {
+			return err
+		}
+		return s.Repo.RenewInvitation(ctx, invitation, TokenHash(token))
+	})
+	if err != nil {
+		return entities.ResendInvitationResponse{}, s.mapError(err)
+	}
+
+	if err := s.notify(ctx, invitation, token); err != nil {
+		return entities.ResendInvitationResponse{}, err
+	}
+
+	return entities.ResendInvitationResponse{Invitation: invitation}, nil
+}
+
+func (s *service) token(ctx context.Context, invitation entities.Invitation) (string, error) {
+	return s.Tokens.Sign(Claims{
+		InvitationId: invitation.Id,
+		Tenant:       tenant.FromContext(ctx),
+		ExpiresAt:    invitation.ExpiresAt.Unix(),
+	})
+}
+
+func (s *service) notify(ctx context.Context, invitation entities.Invitation, token string) error {
+	link, err := url.Parse(s.Config.LinkURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	if err := s.Notifier.Notify(ctx, invitation, link.String()); err != nil {
+		level.Error(s.Logger).Log("msg", "invitation not delivered", "id", invitation.Id, "error", err)
+		return status.Error(codes.Unavailable, "the invitation could not be delivered")
+	}
+	return nil
+}
+
+// authorize lets admins through, and callers authenticated with a key,
+// which the key interceptor checked holds the scope of the call.
+func authorize(ctx context.Context) error {
+	if _, ok := apikey.FromContext(ctx); ok {
+		return nil
+	}
+	if audit.CallerFromContext(ctx).IsAdmin() {
+		return nil
+	}
+	return errors.NewForbidden("only admins can manage invitations")
+}
+
+// checkPending fails for an invitation that can no longer be accepted.
+func checkPending(invitation entities.Invitation) error {
+	switch withStatus(invitation, time.Now()).Status {
+	case entities.InvitationPending:
+		return nil
+	case entities.InvitationExpired:
+		return errors.NewPreconditionFailed("invitation expired")
+	default:
+		return errors.NewPreconditionFailed("invitation already " + invitation.Status)
+	}
+}
+
+// withStatus reports a pending invitation past its expiry as expired.
+func withStatus(invitation entities.Invitation, now time.Time) entities.Invitation {
+	if invitation.Status == entities.InvitationPending && !now.Before(invitation.ExpiresAt) {
+		invitation.Status = entities.InvitationExpired
+	}
+	return invitation
+}
+
+// mapError maps the errors of the repository, passing through the ones
+// already made for the client.
+func (s *service) mapError(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return errors.NewResourceNotFound("invitation")
+	case ErrPending:
+		return errors.NewResourceAlreadyExists("invitation")
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	level.Error(s.Logger).Log("error", err)
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
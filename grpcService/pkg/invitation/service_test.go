@@ -0,0 +1,233 @@
+package invitation_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/invitation"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+type dependencies struct {
+	repo     *utils.InvitationRepositoryMock
+	users    *utils.UserCreatorMock
+	groups   *utils.GroupRepositoryMock
+	notifier *utils.NotifierMock
+}
+
+func newService(t *testing.T, signer *invitation.Signer) (invitation.Service, dependencies) {
+	deps := dependencies{
+		repo:     new(utils.InvitationRepositoryMock),
+		users:    new(utils.UserCreatorMock),
+		groups:   new(utils.GroupRepositoryMock),
+		notifier: new(utils.NotifierMock),
+	}
+	t.Cleanup(func() {
+		deps.repo.AssertExpectations(t)
+		deps.users.AssertExpectations(t)
+		deps.groups.AssertExpectations(t)
+		deps.notifier.AssertExpectations(t)
+	})
+
+	srvc := invitation.NewService(log.NewLogfmtLogger(os.Stderr), deps.repo, deps.users, deps.groups, signer, deps.notifier, invitation.Config{
+		TTL:     time.Hour,
+		LinkURL: "https://app.example.com/accept-invitation",
+	})
+	return srvc, deps
+}
+
+// admin is the context of callers allowed to manage invitations.
+var admin = audit.WithCaller(context.Background(), audit.Caller{Actor: "admin@globant.com", Roles: "support, admin"})
+
+func TestServiceInviteUser(t *testing.T) {
+	signer, _ := invitation.GenerateSigner()
+	rq := entities.InviteUserRequest{Email: "timoteo@globant.com", Roles: []string{"admin"}, GroupId: "group-1"}
+
+	t.Run("Link Holds The Saved Token", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+
+		var tokenHash string
+		deps.repo.On("EmailTaken", mock.Anything, "timoteo@globant.com").Return(false, nil)
+		deps.groups.On("GetGroup", mock.Anything, "group-1").Return(entities.Group{Id: "group-1"}, nil)
+		deps.repo.On("CreateInvitation", mock.Anything, mock.MatchedBy(func(i entities.Invitation) bool {
+			return i.Status == entities.InvitationPending && i.Email == rq.Email && i.ExpiresAt.Sub(i.CreatedAt) == time.Hour
+		}), mock.Anything).Run(func(args mock.Arguments) {
+			tokenHash = args.String(2)
+		}).Return(nil)
+		deps.notifier.On("Notify", mock.Anything, mock.Anything, mock.MatchedBy(func(link string) bool {
+			u, err := url.Parse(link)
+			if err != nil || u.Host != "app.example.com" {
+				return false
+			}
+			claims, err := signer.Parse(u.Query().Get("token"))
+			return err == nil && claims.Tenant == tenant.Default && invitation.TokenHash(u.Query().Get("token")) == tokenHash
+		})).Return(nil)
+
+		res, err := srvc.InviteUser(admin, rq)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, res.Invitation.Roles)
+	})
+
+	t.Run("Email Of A User", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+		deps.repo.On("EmailTaken", mock.Anything, "timoteo@globant.com").Return(true, nil)
+
+		_, err := srvc.InviteUser(admin, rq)
+		assert.IsType(t, myErr.UserAlreadyExists{}, err)
+	})
+
+	t.Run("Pending Invitation", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+		deps.repo.On("EmailTaken", mock.Anything, mock.Anything).Return(false, nil)
+		deps.repo.On("CreateInvitation", mock.Anything, mock.Anything, mock.Anything).Return(invitation.ErrPending)
+
+		_, err := srvc.InviteUser(admin, entities.InviteUserRequest{Email: rq.Email})
+		assert.IsType(t, myErr.ResourceAlreadyExists{}, err)
+	})
+
+	t.Run("Undelivered Link", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+		deps.repo.On("EmailTaken", mock.Anything, mock.Anything).Return(false, nil)
+		deps.repo.On("CreateInvitation", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		deps.notifier.On("Notify", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+		// Saved first, then revoked so the email can be invited again.
+		deps.repo.On("CloseInvitation", mock.Anything, mock.MatchedBy(func(i entities.Invitation) bool {
+			return i.Status == entities.InvitationRevoked && i.Email == rq.Email
+		})).Return(nil)
+
+		_, err := srvc.InviteUser(admin, entities.InviteUserRequest{Email: rq.Email})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("Caller Authenticated With A Key", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+		deps.repo.On("EmailTaken", mock.Anything, mock.Anything).Return(false, nil)
+		deps.repo.On("CreateInvitation", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		deps.notifier.On("Notify", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		ctx := apikey.WithKey(context.Background(), entities.ApiKey{Id: "key-1", Scopes: []string{"invitations:write"}})
+		_, err := srvc.InviteUser(ctx, entities.InviteUserRequest{Email: rq.Email})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Caller Not An Admin", func(t *testing.T) {
+		srvc, _ := newService(t, signer)
+
+		ctx := audit.WithCaller(context.Background(), audit.Caller{Actor: "timoteo@globant.com", Roles: "support"})
+		_, err := srvc.InviteUser(ctx, rq)
+		assert.IsType(t, myErr.Forbidden{}, err)
+	})
+
+	t.Run("Invalid Email", func(t *testing.T) {
+		srvc, _ := newService(t, signer)
+
+		_, err := srvc.InviteUser(admin, entities.InviteUserRequest{Email: "Timo <timoteo@globant.com>"})
+		assert.IsType(t, myErr.InvalidField{}, err)
+	})
+}
+
+func TestServiceAcceptInvitation(t *testing.T) {
+	signer, _ := invitation.GenerateSigner()
+	expiresAt := time.Now().Add(time.Hour)
+	token, _ := signer.Sign(invitation.Claims{InvitationId: "invitation-1", Tenant: tenant.Default, ExpiresAt: expiresAt.Unix()})
+	pending := entities.Invitation{
+		Id:        "invitation-1",
+		Email:     "timoteo@globant.com",
+		Roles:     []string{"support", "billing"},
+		GroupId:   "group-1",
+		Status:    entities.InvitationPending,
+		ExpiresAt: expiresAt,
+	}
+	rq := entities.AcceptInvitationRequest{Token: token, Name: "Timo", Pass: "hashed"}
+
+	t.Run("Creates The User", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+		deps.repo.On("LockInvitation", mock.Anything, "invitation-1").Return(pending, invitation.TokenHash(token), nil)
+		deps.users.On("CreateUser", mock.Anything, entities.CreateUserRequest{Name: "Timo", Pass: "hashed", Email: "timoteo@globant.com"}).
+			Return(entities.CreateUserResponse{UserId: "user-1"}, nil)
+		deps.groups.On("GetGroup", mock.Anything, "group-1").Return(entities.Group{Id: "group-1"}, nil)
+		deps.groups.On("AddMember", mock.Anything, mock.MatchedBy(func(m entities.GroupMember) bool {
+			return m.UserId == "user-1" && m.Role == entities.GroupRoleMember
+		})).Return(nil)
+		deps.repo.On("CloseInvitation", mock.Anything, mock.MatchedBy(func(i entities.Invitation) bool {
+			return i.Status == entities.InvitationAccepted && i.UserId == "user-1" && !i.AcceptedAt.IsZero()
+		})).Return(nil)
+
+		res, err := srvc.AcceptInvitation(context.Background(), rq)
+		assert.NoError(t, err)
+		assert.Equal(t, "user-1", res.UserId)
+		assert.Equal(t, []string{"support", "billing"}, res.Roles)
+	})
+
+	t.Run("Link Replaced By A Resend", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+		deps.repo.On("LockInvitation", mock.Anything, "invitation-1").Return(pending, invitation.TokenHash("newer"), nil)
+
+		_, err := srvc.AcceptInvitation(context.Background(), rq)
+		assert.IsType(t, myErr.InvalidField{}, err)
+	})
+
+	t.Run("Revoked Invitation", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+		revoked := pending
+		revoked.Status = entities.InvitationRevoked
+		deps.repo.On("LockInvitation", mock.Anything, "invitation-1").Return(revoked, invitation.TokenHash(token), nil)
+
+		_, err := srvc.AcceptInvitation(context.Background(), rq)
+		assert.IsType(t, myErr.PreconditionFailed{}, err)
+	})
+
+	t.Run("Token Of Another Tenant", func(t *testing.T) {
+		srvc, _ := newService(t, signer)
+
+		_, err := srvc.AcceptInvitation(tenant.WithTenant(context.Background(), "acme"), rq)
+		assert.IsType(t, myErr.InvalidField{}, err)
+	})
+
+	t.Run("Expired Token", func(t *testing.T) {
+		srvc, _ := newService(t, signer)
+		expired, _ := signer.Sign(invitation.Claims{InvitationId: "invitation-1", Tenant: tenant.Default, ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+
+		_, err := srvc.AcceptInvitation(context.Background(), entities.AcceptInvitationRequest{Token: expired, Name: "Timo", Pass: "hashed"})
+		assert.IsType(t, myErr.PreconditionFailed{}, err)
+	})
+}
+
+func TestServiceRevokeInvitation(t *testing.T) {
+	signer, _ := invitation.GenerateSigner()
+
+	t.Run("Revokes A Pending Invitation", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+		deps.repo.On("LockInvitation", mock.Anything, "invitation-1").
+			Return(entities.Invitation{Id: "invitation-1", Status: entities.InvitationPending}, "hash", nil)
+		deps.repo.On("CloseInvitation", mock.Anything, entities.Invitation{Id: "invitation-1", Status: entities.InvitationRevoked}).Return(nil)
+
+		res, err := srvc.RevokeInvitation(admin, entities.RevokeInvitationRequest{InvitationId: "invitation-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, entities.InvitationRevoked, res.Invitation.Status)
+	})
+
+	t.Run("Accepted Invitation", func(t *testing.T) {
+		srvc, deps := newService(t, signer)
+		deps.repo.On("LockInvitation", mock.Anything, "invitation-1").
+			Return(entities.Invitation{Id: "invitation-1", Status: entities.InvitationAccepted}, "hash", nil)
+
+		_, err := srvc.RevokeInvitation(admin, entities.RevokeInvitationRequest{InvitationId: "invitation-1"})
+		assert.IsType(t, myErr.PreconditionFailed{}, err)
+	})
+}
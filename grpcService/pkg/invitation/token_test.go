@@ -0,0 +1,53 @@
+package invitation_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/invitation"
+)
+
+func TestSigner(t *testing.T) {
+	signer, err := invitation.GenerateSigner()
+	assert.NoError(t, err)
+
+	claims := invitation.Claims{InvitationId: "invitation-1", Tenant: "acme", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	token, err := signer.Sign(claims)
+	assert.NoError(t, err)
+
+	t.Run("Valid Token", func(t *testing.T) {
+		parsed, err := signer.Parse(token)
+		assert.NoError(t, err)
+		assert.Equal(t, claims, parsed)
+	})
+
+	t.Run("Tampered Claims", func(t *testing.T) {
+		other, _ := signer.Sign(invitation.Claims{InvitationId: "invitation-2", Tenant: "acme", ExpiresAt: claims.ExpiresAt})
+		forged := strings.Split(other, ".")[0] + "." + strings.Split(token, ".")[1]
+
+		_, err := signer.Parse(forged)
+		assert.ErrorIs(t, err, invitation.ErrInvalidToken)
+	})
+
+	t.Run("Other Key", func(t *testing.T) {
+		other, _ := invitation.GenerateSigner()
+
+		_, err := other.Parse(token)
+		assert.ErrorIs(t, err, invitation.ErrInvalidToken)
+	})
+
+	t.Run("Expired Token", func(t *testing.T) {
+		expired, _ := signer.Sign(invitation.Claims{InvitationId: "invitation-1", Tenant: "acme", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+
+		_, err := signer.Parse(expired)
+		assert.ErrorIs(t, err, invitation.ErrExpiredToken)
+	})
+
+	t.Run("Malformed Token", func(t *testing.T) {
+		_, err := signer.Parse("not-a-token")
+		assert.ErrorIs(t, err, invitation.ErrInvalidToken)
+	})
+}
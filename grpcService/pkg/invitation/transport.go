@@ -0,0 +1,201 @@
+package invitation
+
+import (
+	"context"
+	"time"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	invite gr.Handler
+	accept gr.Handler
+	list   gr.Handler
+	revoke gr.Handler
+	resend gr.Handler
+	proto.UnimplementedInvitationServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.InvitationServiceServer {
+	return &gRPCSv{
+		invite: gr.NewServer(
+			end.InviteUser,
+			decodeInviteUserRequest,
+			encodeInviteUserResponse,
+		),
+
+		accept: gr.NewServer(
+			end.AcceptInvitation,
+			decodeAcceptInvitationRequest,
+			encodeAcceptInvitationResponse,
+		),
+
+		list: gr.NewServer(
+			end.ListInvitations,
+			decodeListInvitationsRequest,
+			encodeListInvitationsResponse,
+		),
+
+		revoke: gr.NewServer(
+			end.RevokeInvitation,
+			decodeRevokeInvitationRequest,
+			encodeRevokeInvitationResponse,
+		),
+
+		resend: gr.NewServer(
+			end.ResendInvitation,
+			decodeResendInvitationRequest,
+			encodeResendInvitationResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) InviteUser(ctx context.Context, rq *proto.InviteUserRequest) (*proto.InviteUserResponse, error) {
+	_, resp, err := g.invite.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.InviteUserResponse), nil
+}
+
+func (g *gRPCSv) AcceptInvitation(ctx context.Context, rq *proto.AcceptInvitationRequest) (*proto.AcceptInvitationResponse, error) {
+	_, resp, err := g.accept.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.AcceptInvitationResponse), nil
+}
+
+func (g *gRPCSv) ListInvitations(ctx context.Context, rq *proto.ListInvitationsRequest) (*proto.ListInvitationsResponse, error) {
+	_, resp, err := g.list.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListInvitationsResponse), nil
+}
+
+func (g *gRPCSv) RevokeInvitation(ctx context.Context, rq *proto.RevokeInvitationRequest) (*proto.RevokeInvitationResponse, error) {
+	_, resp, err := g.revoke.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.RevokeInvitationResponse), nil
+}
+
+func (g *gRPCSv) ResendInvitation(ctx context.Context, rq *proto.ResendInvitationRequest) (*proto.ResendInvitationResponse, error) {
+	_, resp, err := g.resend.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ResendInvitationResponse), nil
+}
+
+func decodeInviteUserRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.InviteUserRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.InviteUserRequest{Email: res.Email, Roles: res.Roles, GroupId: res.Group_Id}, nil
+}
+
+func encodeInviteUserResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.InviteUserResponse)
+	return &proto.InviteUserResponse{
+		Invitation: invitationToProto(res.Invitation),
+		Status:     &proto.Status{Message: res.Status.Message, Code: res.Status.Code},
+	}, nil
+}
+
+func decodeAcceptInvitationRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.AcceptInvitationRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.AcceptInvitationRequest{Token: res.Token, Name: res.Name, Pass: res.Pass}, nil
+}
+
+func encodeAcceptInvitationResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.AcceptInvitationResponse)
+	return &proto.AcceptInvitationResponse{User_Id: res.UserId, Roles: res.Roles, Invitation: invitationToProto(res.Invitation)}, nil
+}
+
+func decodeListInvitationsRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListInvitationsRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListInvitationsRequest{PageSize: res.Page_Size, PageToken: res.Page_Token}, nil
+}
+
+func encodeListInvitationsResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListInvitationsResponse)
+	protoResp := &proto.ListInvitationsResponse{Next_Page_Token: res.NextPageToken}
+	for _, invitation := range res.Invitations {
+		protoResp.Invitations = append(protoResp.Invitations, invitationToProto(invitation))
+	}
+	return protoResp, nil
+}
+
+func decodeRevokeInvitationRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.RevokeInvitationRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.RevokeInvitationRequest{InvitationId: res.Invitation_Id}, nil
+}
+
+func encodeRevokeInvitationResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.RevokeInvitationResponse)
+	return &proto.RevokeInvitationResponse{Invitation: invitationToProto(res.Invitation)}, nil
+}
+
+func decodeResendInvitationRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ResendInvitationRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ResendInvitationRequest{InvitationId: res.Invitation_Id}, nil
+}
+
+func encodeResendInvitationResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ResendInvitationResponse)
+	return &proto.ResendInvitationResponse{Invitation: invitationToProto(res.Invitation)}, nil
+}
+
+func invitationToProto(invitation entities.Invitation) *proto.Invitation {
+	return &proto.Invitation{
+		Id:          invitation.Id,
+		Email:       invitation.Email,
+		Roles:       invitation.Roles,
+		Group_Id:    invitation.GroupId,
+		Status:      invitation.Status,
+		Invited_By:  invitation.InvitedBy,
+		Created_At:  timestamppb.New(invitation.CreatedAt),
+		Expires_At:  timestamppb.New(invitation.ExpiresAt),
+		Accepted_At: timeToProto(invitation.AcceptedAt),
+		User_Id:     invitation.UserId,
+	}
+}
+
+// timeToProto leaves unset times out of the response.
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
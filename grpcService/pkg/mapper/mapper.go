@@ -5,10 +5,11 @@ import "github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
 func CreateUserRequestToUser(userReq entities.CreateUserRequest) entities.User {
 
 	user := entities.User{
-		userReq.Name,
-		userReq.Pass,
-		userReq.Age,
-		userReq.Email,
+		Name:       userReq.Name,
+		Pass:       userReq.Pass,
+		Age:        userReq.Age,
+		Email:      userReq.Email,
+		Attributes: userReq.Attributes,
 	}
 	return user
 }
@@ -0,0 +1,105 @@
+package organization
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	CreateOrganization(ctx context.Context, rq entities.CreateOrganizationRequest) (entities.CreateOrganizationResponse, error)
+	GetOrganization(ctx context.Context, rq entities.GetOrganizationRequest) (entities.GetOrganizationResponse, error)
+	ListOrganizations(ctx context.Context, rq entities.ListOrganizationsRequest) (entities.ListOrganizationsResponse, error)
+	UpdateOrganization(ctx context.Context, rq entities.UpdateOrganizationRequest) (entities.UpdateOrganizationResponse, error)
+	DeleteOrganization(ctx context.Context, rq entities.DeleteOrganizationRequest) (entities.DeleteOrganizationResponse, error)
+}
+
+type Endpoints struct {
+	CreateOrganization endpoint.Endpoint
+	GetOrganization    endpoint.Endpoint
+	ListOrganizations  endpoint.Endpoint
+	UpdateOrganization endpoint.Endpoint
+	DeleteOrganization endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		CreateOrganization: MakeCreateOrganizationEndpoint(s),
+		GetOrganization:    MakeGetOrganizationEndpoint(s),
+		ListOrganizations:  MakeListOrganizationsEndpoint(s),
+		UpdateOrganization: MakeUpdateOrganizationEndpoint(s),
+		DeleteOrganization: MakeDeleteOrganizationEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		CreateOrganization: mw(e.CreateOrganization),
+		GetOrganization:    mw(e.GetOrganization),
+		ListOrganizations:  mw(e.ListOrganizations),
+		UpdateOrganization: mw(e.UpdateOrganization),
+		DeleteOrganization: mw(e.DeleteOrganization),
+	}
+}
+
+func MakeCreateOrganizationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.CreateOrganizationRequest)
+		c, err := s.CreateOrganization(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeGetOrganizationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.GetOrganizationRequest)
+		c, err := s.GetOrganization(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListOrganizationsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListOrganizationsRequest)
+		c, err := s.ListOrganizations(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeUpdateOrganizationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.UpdateOrganizationRequest)
+		c, err := s.UpdateOrganization(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeDeleteOrganizationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.DeleteOrganizationRequest)
+		c, err := s.DeleteOrganization(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
@@ -0,0 +1,131 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+var (
+	ErrExists = errors.New("organization: already exists")
+	ErrInUse  = errors.New("organization: still has users")
+)
+
+type Repository interface {
+	CreateOrganization(ctx context.Context, org entities.Organization) error
+	// GetOrganization returns sql.ErrNoRows when there is no organization
+	// id.
+	GetOrganization(ctx context.Context, id string) (entities.Organization, error)
+	ListOrganizations(ctx context.Context, afterId string, limit int) ([]entities.Organization, error)
+	UpdateOrganization(ctx context.Context, id string, name string) error
+	DeleteOrganization(ctx context.Context, id string) error
+}
+
+type sqlRepo struct {
+	DB     *sql.DB
+	Logger log.Logger
+}
+
+func NewSQL(db *sql.DB, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, log}
+}
+
+// CreateOrganization returns ErrExists when the id is taken.
+func (repo *sqlRepo) CreateOrganization(ctx context.Context, org entities.Organization) error {
+	if _, err := repo.DB.ExecContext(ctx, utils.CreateOrganizationQuery, org.Id, org.Name, org.CreatedAt); err != nil {
+		if isMySQLError(err, 1062) {
+			return ErrExists
+		}
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *sqlRepo) GetOrganization(ctx context.Context, id string) (entities.Organization, error) {
+	var org entities.Organization
+	err := repo.DB.QueryRowContext(ctx, utils.GetOrganizationQuery, id).Scan(&org.Id, &org.Name, &org.CreatedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			level.Error(repo.Logger).Log(err)
+		}
+		return entities.Organization{}, err
+	}
+
+	return org, nil
+}
+
+// ListOrganizations returns up to limit organizations with an id greater
+// than afterId, in id order.
+func (repo *sqlRepo) ListOrganizations(ctx context.Context, afterId string, limit int) ([]entities.Organization, error) {
+	rows, err := repo.DB.QueryContext(ctx, utils.ListOrganizationsAfterQuery, afterId, limit)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []entities.Organization
+	for rows.Next() {
+		var org entities.Organization
+		if err := rows.Scan(&org.Id, &org.Name, &org.CreatedAt); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+
+	return orgs, rows.Err()
+}
+
+// UpdateOrganization returns sql.ErrNoRows when there is no organization
+// id.
+func (repo *sqlRepo) UpdateOrganization(ctx context.Context, id string, name string) error {
+	res, err := repo.DB.ExecContext(ctx, utils.UpdateOrganizationQuery, name, id)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	// MySQL counts the rows changed, not the ones matched, an update to the
+	// same name affects none.
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := repo.GetOrganization(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteOrganization returns sql.ErrNoRows when there was no organization
+// id, and ErrInUse while users still belong to it.
+func (repo *sqlRepo) DeleteOrganization(ctx context.Context, id string) error {
+	res, err := repo.DB.ExecContext(ctx, utils.DeleteOrganizationQuery, id)
+	if err != nil {
+		if isMySQLError(err, 1451) {
+			return ErrInUse
+		}
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func isMySQLError(err error, number uint16) bool {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	return ok && mysqlErr.Number == number
+}
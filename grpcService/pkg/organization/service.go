@@ -0,0 +1,208 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	maxNameLength   = 255
+)
+
+type service struct {
+	Repo   Repository
+	Logger log.Logger
+}
+
+func NewService(l log.Logger, r Repository) *service {
+	return &service{r, l}
+}
+
+func (s *service) CreateOrganization(ctx context.Context, rq entities.CreateOrganizationRequest) (entities.CreateOrganizationResponse, error) {
+	s.Logger.Log("request", "create organization", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.CreateOrganizationResponse{}, err
+	}
+
+	if !tenant.Valid(rq.Id) {
+		return entities.CreateOrganizationResponse{}, errors.NewInvalidField("id", "must be 1 to 63 lowercase letters, digits or hyphens, starting and ending with a letter or digit")
+	}
+	name, err := validName(rq.Name)
+	if err != nil {
+		return entities.CreateOrganizationResponse{}, err
+	}
+
+	org := entities.Organization{
+		Id:   rq.Id,
+		Name: name,
+		// The database keeps microseconds.
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	if err := s.Repo.CreateOrganization(ctx, org); err != nil {
+		if err == ErrExists {
+			return entities.CreateOrganizationResponse{}, errors.NewResourceAlreadyExists("organization")
+		}
+		level.Error(s.Logger).Log("error", err)
+		return entities.CreateOrganizationResponse{}, dataBaseError(err)
+	}
+
+	return entities.CreateOrganizationResponse{
+		Organization: org,
+		Status:       entities.Status{Message: "created successfully"},
+	}, nil
+}
+
+func (s *service) GetOrganization(ctx context.Context, rq entities.GetOrganizationRequest) (entities.GetOrganizationResponse, error) {
+	s.Logger.Log("request", "get organization", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.GetOrganizationResponse{}, err
+	}
+
+	org, err := s.Repo.GetOrganization(ctx, rq.OrganizationId)
+	if err != nil {
+		return entities.GetOrganizationResponse{}, s.notFoundOr(err)
+	}
+
+	return entities.GetOrganizationResponse{Organization: org}, nil
+}
+
+// ListOrganizations pages through the organizations in id order. The page
+// token is the id of the last organization of the page before.
+func (s *service) ListOrganizations(ctx context.Context, rq entities.ListOrganizationsRequest) (entities.ListOrganizationsResponse, error) {
+	s.Logger.Log("request", "list organizations", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.ListOrganizationsResponse{}, err
+	}
+
+	if rq.PageToken != "" && !tenant.Valid(rq.PageToken) {
+		return entities.ListOrganizationsResponse{}, errors.NewInvalidField("page_token", "malformed")
+	}
+
+	pageSize := int(rq.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	// One organization more than asked tells whether there is a next page.
+	orgs, err := s.Repo.ListOrganizations(ctx, rq.PageToken, pageSize+1)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListOrganizationsResponse{}, dataBaseError(err)
+	}
+
+	response := entities.ListOrganizationsResponse{Organizations: orgs}
+	if len(orgs) > pageSize {
+		response.Organizations = orgs[:pageSize]
+		response.NextPageToken = orgs[pageSize-1].Id
+	}
+
+	return response, nil
+}
+
+func (s *service) UpdateOrganization(ctx context.Context, rq entities.UpdateOrganizationRequest) (entities.UpdateOrganizationResponse, error) {
+	s.Logger.Log("request", "update organization", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.UpdateOrganizationResponse{}, err
+	}
+
+	name, err := validName(rq.Name)
+	if err != nil {
+		return entities.UpdateOrganizationResponse{}, err
+	}
+
+	if err := s.Repo.UpdateOrganization(ctx, rq.OrganizationId, name); err != nil {
+		return entities.UpdateOrganizationResponse{}, s.notFoundOr(err)
+	}
+
+	org, err := s.Repo.GetOrganization(ctx, rq.OrganizationId)
+	if err != nil {
+		return entities.UpdateOrganizationResponse{}, s.notFoundOr(err)
+	}
+
+	return entities.UpdateOrganizationResponse{Organization: org}, nil
+}
+
+// DeleteOrganization refuses to delete an organization that still has
+// users, and the default one, which requests naming no tenant fall into.
+func (s *service) DeleteOrganization(ctx context.Context, rq entities.DeleteOrganizationRequest) (entities.DeleteOrganizationResponse, error) {
+	s.Logger.Log("request", "delete organization", "received")
+
+	if err := authorize(ctx); err != nil {
+		return entities.DeleteOrganizationResponse{}, err
+	}
+
+	if rq.OrganizationId == tenant.Default {
+		return entities.DeleteOrganizationResponse{}, errors.NewPreconditionFailed("the default organization can't be deleted")
+	}
+
+	if err := s.Repo.DeleteOrganization(ctx, rq.OrganizationId); err != nil {
+		if err == ErrInUse {
+			return entities.DeleteOrganizationResponse{}, errors.NewPreconditionFailed("organization still has users")
+		}
+		return entities.DeleteOrganizationResponse{}, s.notFoundOr(err)
+	}
+
+	return entities.DeleteOrganizationResponse{
+		Status: entities.Status{Message: "organization deleted successfully"},
+	}, nil
+}
+
+// authorize lets admins through, and callers authenticated with a key,
+// which the key interceptor checked holds the scope of the call.
+func authorize(ctx context.Context) error {
+	if _, ok := apikey.FromContext(ctx); ok {
+		return nil
+	}
+	if audit.CallerFromContext(ctx).IsAdmin() {
+		return nil
+	}
+	return errors.NewForbidden("only admins can manage organizations")
+}
+
+func (s *service) notFoundOr(err error) error {
+	if err == sql.ErrNoRows {
+		return errors.NewResourceNotFound("organization")
+	}
+	level.Error(s.Logger).Log("error", err)
+	return dataBaseError(err)
+}
+
+func validName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.NewInvalidField("name", "is required")
+	}
+	if len(name) > maxNameLength {
+		return "", errors.NewInvalidField("name", "must be at most 255 bytes")
+	}
+	return name, nil
+}
+
+func dataBaseError(err error) error {
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
@@ -0,0 +1,182 @@
+package organization_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/organization"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// admin is the context of callers allowed to manage organizations.
+var admin = audit.WithCaller(context.Background(), audit.Caller{Actor: "admin@globant.com", Roles: "admin"})
+
+func TestServiceCreateOrganization(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Request        entities.CreateOrganizationRequest
+		buildMock      func(repo *utils.OrganizationRepositoryMock)
+		assertResponse func(t *testing.T, res entities.CreateOrganizationResponse, err error)
+	}{
+		{
+			Name:    "Create Organization",
+			Request: entities.CreateOrganizationRequest{Id: "acme", Name: " Acme Corp "},
+			buildMock: func(repo *utils.OrganizationRepositoryMock) {
+				repo.On("CreateOrganization", mock.Anything, mock.MatchedBy(func(org entities.Organization) bool {
+					return org.Id == "acme" && org.Name == "Acme Corp" && !org.CreatedAt.IsZero()
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateOrganizationResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "acme", res.Organization.Id)
+			},
+		},
+		{
+			Name:      "Invalid Id",
+			Request:   entities.CreateOrganizationRequest{Id: "Acme Corp", Name: "Acme Corp"},
+			buildMock: func(repo *utils.OrganizationRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.CreateOrganizationResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:      "Missing Name",
+			Request:   entities.CreateOrganizationRequest{Id: "acme"},
+			buildMock: func(repo *utils.OrganizationRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.CreateOrganizationResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:    "Id Taken",
+			Request: entities.CreateOrganizationRequest{Id: "acme", Name: "Acme Corp"},
+			buildMock: func(repo *utils.OrganizationRepositoryMock) {
+				repo.On("CreateOrganization", mock.Anything, mock.Anything).Return(organization.ErrExists)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateOrganizationResponse, err error) {
+				assert.IsType(t, myErr.ResourceAlreadyExists{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.OrganizationRepositoryMock)
+			tc.buildMock(repo)
+
+			srvc := organization.NewService(logger, repo)
+			res, err := srvc.CreateOrganization(admin, tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceListOrganizations(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	repo := new(utils.OrganizationRepositoryMock)
+	repo.On("ListOrganizations", mock.Anything, "acme", 3).
+		Return([]entities.Organization{{Id: "default"}, {Id: "globex"}, {Id: "initech"}}, nil)
+
+	res, err := organization.NewService(logger, repo).ListOrganizations(admin,
+		entities.ListOrganizationsRequest{PageSize: 2, PageToken: "acme"})
+	assert.NoError(t, err)
+	assert.Len(t, res.Organizations, 2)
+	assert.Equal(t, "globex", res.NextPageToken)
+	repo.AssertExpectations(t)
+}
+
+func TestServiceDeleteOrganization(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Id             string
+		buildMock      func(repo *utils.OrganizationRepositoryMock)
+		assertResponse func(t *testing.T, res entities.DeleteOrganizationResponse, err error)
+	}{
+		{
+			Name: "Delete Organization",
+			Id:   "acme",
+			buildMock: func(repo *utils.OrganizationRepositoryMock) {
+				repo.On("DeleteOrganization", mock.Anything, "acme").Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.DeleteOrganizationResponse, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name: "Organization With Users",
+			Id:   "acme",
+			buildMock: func(repo *utils.OrganizationRepositoryMock) {
+				repo.On("DeleteOrganization", mock.Anything, "acme").Return(organization.ErrInUse)
+			},
+			assertResponse: func(t *testing.T, res entities.DeleteOrganizationResponse, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name:      "Default Organization",
+			Id:        "default",
+			buildMock: func(repo *utils.OrganizationRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.DeleteOrganizationResponse, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name: "Unknown Organization",
+			Id:   "initech",
+			buildMock: func(repo *utils.OrganizationRepositoryMock) {
+				repo.On("DeleteOrganization", mock.Anything, "initech").Return(sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.DeleteOrganizationResponse, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.OrganizationRepositoryMock)
+			tc.buildMock(repo)
+
+			srvc := organization.NewService(logger, repo)
+			res, err := srvc.DeleteOrganization(admin, entities.DeleteOrganizationRequest{OrganizationId: tc.Id})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceOrganizationsNeedAnAdmin(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	repo := new(utils.OrganizationRepositoryMock)
+	srvc := organization.NewService(logger, repo)
+
+	for name, ctx := range map[string]context.Context{
+		"Anonymous": context.Background(),
+		"Not Admin": audit.WithCaller(context.Background(), audit.Caller{Actor: "timoteo@globant.com", Roles: "support"}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := srvc.ListOrganizations(ctx, entities.ListOrganizationsRequest{})
+			assert.IsType(t, myErr.Forbidden{}, err)
+			_, err = srvc.UpdateOrganization(ctx, entities.UpdateOrganizationRequest{OrganizationId: "acme", Name: "Acme"})
+			assert.IsType(t, myErr.Forbidden{}, err)
+			_, err = srvc.DeleteOrganization(ctx, entities.DeleteOrganizationRequest{OrganizationId: "acme"})
+			assert.IsType(t, myErr.Forbidden{}, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,187 @@
+package organization
+
+import (
+	"context"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	createOrg gr.Handler
+	getOrg    gr.Handler
+	listOrg   gr.Handler
+	updateOrg gr.Handler
+	deleteOrg gr.Handler
+	proto.UnimplementedOrganizationServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.OrganizationServiceServer {
+	return &gRPCSv{
+		createOrg: gr.NewServer(
+			end.CreateOrganization,
+			decodeCreateOrganizationRequest,
+			encodeCreateOrganizationResponse,
+		),
+
+		getOrg: gr.NewServer(
+			end.GetOrganization,
+			decodeGetOrganizationRequest,
+			encodeGetOrganizationResponse,
+		),
+
+		listOrg: gr.NewServer(
+			end.ListOrganizations,
+			decodeListOrganizationsRequest,
+			encodeListOrganizationsResponse,
+		),
+
+		updateOrg: gr.NewServer(
+			end.UpdateOrganization,
+			decodeUpdateOrganizationRequest,
+			encodeUpdateOrganizationResponse,
+		),
+
+		deleteOrg: gr.NewServer(
+			end.DeleteOrganization,
+			decodeDeleteOrganizationRequest,
+			encodeDeleteOrganizationResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) CreateOrganization(ctx context.Context, rq *proto.CreateOrganizationRequest) (*proto.CreateOrganizationResponse, error) {
+	_, resp, err := g.createOrg.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.CreateOrganizationResponse), nil
+}
+
+func (g *gRPCSv) GetOrganization(ctx context.Context, rq *proto.GetOrganizationRequest) (*proto.GetOrganizationResponse, error) {
+	_, resp, err := g.getOrg.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.GetOrganizationResponse), nil
+}
+
+func (g *gRPCSv) ListOrganizations(ctx context.Context, rq *proto.ListOrganizationsRequest) (*proto.ListOrganizationsResponse, error) {
+	_, resp, err := g.listOrg.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListOrganizationsResponse), nil
+}
+
+func (g *gRPCSv) UpdateOrganization(ctx context.Context, rq *proto.UpdateOrganizationRequest) (*proto.UpdateOrganizationResponse, error) {
+	_, resp, err := g.updateOrg.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.UpdateOrganizationResponse), nil
+}
+
+func (g *gRPCSv) DeleteOrganization(ctx context.Context, rq *proto.DeleteOrganizationRequest) (*proto.DeleteOrganizationResponse, error) {
+	_, resp, err := g.deleteOrg.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.DeleteOrganizationResponse), nil
+}
+
+func decodeCreateOrganizationRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.CreateOrganizationRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.CreateOrganizationRequest{Id: res.Id, Name: res.Name}, nil
+}
+
+func encodeCreateOrganizationResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.CreateOrganizationResponse)
+	return &proto.CreateOrganizationResponse{
+		Organization: organizationToProto(res.Organization),
+		Status:       &proto.Status{Message: res.Status.Message, Code: res.Status.Code},
+	}, nil
+}
+
+func decodeGetOrganizationRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.GetOrganizationRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.GetOrganizationRequest{OrganizationId: res.Organization_Id}, nil
+}
+
+func encodeGetOrganizationResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.GetOrganizationResponse)
+	return &proto.GetOrganizationResponse{Organization: organizationToProto(res.Organization)}, nil
+}
+
+func decodeListOrganizationsRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListOrganizationsRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListOrganizationsRequest{PageSize: res.Page_Size, PageToken: res.Page_Token}, nil
+}
+
+func encodeListOrganizationsResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListOrganizationsResponse)
+	protoResp := &proto.ListOrganizationsResponse{Next_Page_Token: res.NextPageToken}
+	for _, org := range res.Organizations {
+		protoResp.Organizations = append(protoResp.Organizations, organizationToProto(org))
+	}
+	return protoResp, nil
+}
+
+func decodeUpdateOrganizationRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.UpdateOrganizationRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.UpdateOrganizationRequest{OrganizationId: res.Organization_Id, Name: res.Name}, nil
+}
+
+func encodeUpdateOrganizationResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.UpdateOrganizationResponse)
+	return &proto.UpdateOrganizationResponse{Organization: organizationToProto(res.Organization)}, nil
+}
+
+func decodeDeleteOrganizationRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.DeleteOrganizationRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.DeleteOrganizationRequest{OrganizationId: res.Organization_Id}, nil
+}
+
+func encodeDeleteOrganizationResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.DeleteOrganizationResponse)
+	return &proto.DeleteOrganizationResponse{
+		Status: &proto.Status{Message: res.Status.Message, Code: res.Status.Code},
+	}, nil
+}
+
+func organizationToProto(org entities.Organization) *proto.Organization {
+	return &proto.Organization{
+		Id:         org.Id,
+		Name:       org.Name,
+		Created_At: timestamppb.New(org.CreatedAt),
+	}
+}
@@ -0,0 +1,138 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package grpc_service
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AttributeSchemaServiceClient is the client API for AttributeSchemaService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AttributeSchemaServiceClient interface {
+	GetAttributeSchema(ctx context.Context, in *GetAttributeSchemaRequest, opts ...grpc.CallOption) (*GetAttributeSchemaResponse, error)
+	SetAttributeSchema(ctx context.Context, in *SetAttributeSchemaRequest, opts ...grpc.CallOption) (*SetAttributeSchemaResponse, error)
+}
+
+type attributeSchemaServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAttributeSchemaServiceClient(cc grpc.ClientConnInterface) AttributeSchemaServiceClient {
+	return &attributeSchemaServiceClient{cc}
+}
+
+func (c *attributeSchemaServiceClient) GetAttributeSchema(ctx context.Context, in *GetAttributeSchemaRequest, opts ...grpc.CallOption) (*GetAttributeSchemaResponse, error) {
+	out := new(GetAttributeSchemaResponse)
+	err := c.cc.Invoke(ctx, "/proto.AttributeSchemaService/GetAttributeSchema", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *attributeSchemaServiceClient) SetAttributeSchema(ctx context.Context, in *SetAttributeSchemaRequest, opts ...grpc.CallOption) (*SetAttributeSchemaResponse, error) {
+	out := new(SetAttributeSchemaResponse)
+	err := c.cc.Invoke(ctx, "/proto.AttributeSchemaService/SetAttributeSchema", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AttributeSchemaServiceServer is the server API for AttributeSchemaService service.
+// All implementations must embed UnimplementedAttributeSchemaServiceServer
+// for forward compatibility
+type AttributeSchemaServiceServer interface {
+	GetAttributeSchema(context.Context, *GetAttributeSchemaRequest) (*GetAttributeSchemaResponse, error)
+	SetAttributeSchema(context.Context, *SetAttributeSchemaRequest) (*SetAttributeSchemaResponse, error)
+	mustEmbedUnimplementedAttributeSchemaServiceServer()
+}
+
+// UnimplementedAttributeSchemaServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAttributeSchemaServiceServer struct {
+}
+
+func (UnimplementedAttributeSchemaServiceServer) GetAttributeSchema(context.Context, *GetAttributeSchemaRequest) (*GetAttributeSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAttributeSchema not implemented")
+}
+func (UnimplementedAttributeSchemaServiceServer) SetAttributeSchema(context.Context, *SetAttributeSchemaRequest) (*SetAttributeSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAttributeSchema not implemented")
+}
+func (UnimplementedAttributeSchemaServiceServer) mustEmbedUnimplementedAttributeSchemaServiceServer() {
+}
+
+// UnsafeAttributeSchemaServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AttributeSchemaServiceServer will
+// result in compilation errors.
+type UnsafeAttributeSchemaServiceServer interface {
+	mustEmbedUnimplementedAttributeSchemaServiceServer()
+}
+
+func RegisterAttributeSchemaServiceServer(s grpc.ServiceRegistrar, srv AttributeSchemaServiceServer) {
+	s.RegisterService(&AttributeSchemaService_ServiceDesc, srv)
+}
+
+func _AttributeSchemaService_GetAttributeSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAttributeSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AttributeSchemaServiceServer).GetAttributeSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.AttributeSchemaService/GetAttributeSchema",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AttributeSchemaServiceServer).GetAttributeSchema(ctx, req.(*GetAttributeSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AttributeSchemaService_SetAttributeSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetAttributeSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AttributeSchemaServiceServer).SetAttributeSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.AttributeSchemaService/SetAttributeSchema",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AttributeSchemaServiceServer).SetAttributeSchema(ctx, req.(*SetAttributeSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AttributeSchemaService_ServiceDesc is the grpc.ServiceDesc for AttributeSchemaService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AttributeSchemaService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.AttributeSchemaService",
+	HandlerType: (*AttributeSchemaServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAttributeSchema",
+			Handler:    _AttributeSchemaService_GetAttributeSchema_Handler,
+		},
+		{
+			MethodName: "SetAttributeSchema",
+			Handler:    _AttributeSchemaService_SetAttributeSchema_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "attributes.proto",
+}
@@ -0,0 +1,141 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package grpc_service
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ImpersonationServiceClient is the client API for ImpersonationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ImpersonationServiceClient interface {
+	Impersonate(ctx context.Context, in *ImpersonateRequest, opts ...grpc.CallOption) (*ImpersonateResponse, error)
+	// EndImpersonation ends a session before it expires, its token stops
+	// working. Ending it again changes nothing.
+	EndImpersonation(ctx context.Context, in *EndImpersonationRequest, opts ...grpc.CallOption) (*EndImpersonationResponse, error)
+}
+
+type impersonationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewImpersonationServiceClient(cc grpc.ClientConnInterface) ImpersonationServiceClient {
+	return &impersonationServiceClient{cc}
+}
+
+func (c *impersonationServiceClient) Impersonate(ctx context.Context, in *ImpersonateRequest, opts ...grpc.CallOption) (*ImpersonateResponse, error) {
+	out := new(ImpersonateResponse)
+	err := c.cc.Invoke(ctx, "/proto.ImpersonationService/Impersonate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *impersonationServiceClient) EndImpersonation(ctx context.Context, in *EndImpersonationRequest, opts ...grpc.CallOption) (*EndImpersonationResponse, error) {
+	out := new(EndImpersonationResponse)
+	err := c.cc.Invoke(ctx, "/proto.ImpersonationService/EndImpersonation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ImpersonationServiceServer is the server API for ImpersonationService service.
+// All implementations must embed UnimplementedImpersonationServiceServer
+// for forward compatibility
+type ImpersonationServiceServer interface {
+	Impersonate(context.Context, *ImpersonateRequest) (*ImpersonateResponse, error)
+	// EndImpersonation ends a session before it expires, its token stops
+	// working. Ending it again changes nothing.
+	EndImpersonation(context.Context, *EndImpersonationRequest) (*EndImpersonationResponse, error)
+	mustEmbedUnimplementedImpersonationServiceServer()
+}
+
+// UnimplementedImpersonationServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedImpersonationServiceServer struct {
+}
+
+func (UnimplementedImpersonationServiceServer) Impersonate(context.Context, *ImpersonateRequest) (*ImpersonateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Impersonate not implemented")
+}
+func (UnimplementedImpersonationServiceServer) EndImpersonation(context.Context, *EndImpersonationRequest) (*EndImpersonationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EndImpersonation not implemented")
+}
+func (UnimplementedImpersonationServiceServer) mustEmbedUnimplementedImpersonationServiceServer() {}
+
+// UnsafeImpersonationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ImpersonationServiceServer will
+// result in compilation errors.
+type UnsafeImpersonationServiceServer interface {
+	mustEmbedUnimplementedImpersonationServiceServer()
+}
+
+func RegisterImpersonationServiceServer(s grpc.ServiceRegistrar, srv ImpersonationServiceServer) {
+	s.RegisterService(&ImpersonationService_ServiceDesc, srv)
+}
+
+func _ImpersonationService_Impersonate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImpersonateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImpersonationServiceServer).Impersonate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.ImpersonationService/Impersonate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImpersonationServiceServer).Impersonate(ctx, req.(*ImpersonateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImpersonationService_EndImpersonation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EndImpersonationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImpersonationServiceServer).EndImpersonation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.ImpersonationService/EndImpersonation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImpersonationServiceServer).EndImpersonation(ctx, req.(*EndImpersonationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ImpersonationService_ServiceDesc is the grpc.ServiceDesc for ImpersonationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ImpersonationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.ImpersonationService",
+	HandlerType: (*ImpersonationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Impersonate",
+			Handler:    _ImpersonationService_Impersonate_Handler,
+		},
+		{
+			MethodName: "EndImpersonation",
+			Handler:    _ImpersonationService_EndImpersonation_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "impersonation.proto",
+}
@@ -0,0 +1,57 @@
+package preference
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	GetPreferences(ctx context.Context, rq entities.GetPreferencesRequest) (entities.GetPreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, rq entities.UpdatePreferencesRequest) (entities.UpdatePreferencesResponse, error)
+}
+
+type Endpoints struct {
+	GetPreferences    endpoint.Endpoint
+	UpdatePreferences endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		GetPreferences:    MakeGetPreferencesEndpoint(s),
+		UpdatePreferences: MakeUpdatePreferencesEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		GetPreferences:    mw(e.GetPreferences),
+		UpdatePreferences: mw(e.UpdatePreferences),
+	}
+}
+
+func MakeGetPreferencesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.GetPreferencesRequest)
+		c, err := s.GetPreferences(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeUpdatePreferencesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.UpdatePreferencesRequest)
+		c, err := s.UpdatePreferences(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
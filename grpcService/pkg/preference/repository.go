@@ -0,0 +1,107 @@
+package preference
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// Repository keeps the preferences of the users of the tenant carried by
+// the context of each call.
+type Repository interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// GetPreferences returns sql.ErrNoRows when the user never set any.
+	GetPreferences(ctx context.Context, userId string) (entities.Preferences, error)
+	SavePreferences(ctx context.Context, preferences entities.Preferences) error
+	// LockUser locks the row of a user until the transaction carried by
+	// ctx ends, so updates of its preferences don't race. It returns
+	// sql.ErrNoRows when there is no such user.
+	LockUser(ctx context.Context, userId string) error
+	UserExists(ctx context.Context, userId string) (bool, error)
+}
+
+type sqlRepo struct {
+	DB       *sql.DB
+	Logger   log.Logger
+	TxConfig database.TxConfig
+}
+
+func NewSQL(db *sql.DB, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, log, database.DefaultTxConfig()}
+}
+
+func (repo *sqlRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, repo.DB, repo.TxConfig, fn)
+}
+
+// conn returns the transaction in ctx, or the database when there is none.
+func (repo *sqlRepo) conn(ctx context.Context) database.Querier {
+	return database.Conn(ctx, repo.DB)
+}
+
+func (repo *sqlRepo) GetPreferences(ctx context.Context, userId string) (entities.Preferences, error) {
+	preferences := entities.Preferences{UserId: userId}
+	var content string
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.GetPreferencesQuery, tenant.FromContext(ctx), userId).
+		Scan(&content, &preferences.UpdatedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			level.Error(repo.Logger).Log(err)
+		}
+		return entities.Preferences{}, err
+	}
+
+	if err := json.Unmarshal([]byte(content), &preferences.Values); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.Preferences{}, err
+	}
+
+	return preferences, nil
+}
+
+func (repo *sqlRepo) SavePreferences(ctx context.Context, preferences entities.Preferences) error {
+	values := preferences.Values
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	content, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.conn(ctx).ExecContext(ctx, utils.SavePreferencesQuery,
+		tenant.FromContext(ctx), preferences.UserId, string(content), preferences.UpdatedAt)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) LockUser(ctx context.Context, userId string) error {
+	var version uint64
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.LockUserQuery, userId, tenant.FromContext(ctx)).Scan(&version)
+	if err != nil && err != sql.ErrNoRows {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return err
+}
+
+func (repo *sqlRepo) UserExists(ctx context.Context, userId string) (bool, error) {
+	var exists bool
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.UserExistsQuery, tenant.FromContext(ctx), userId).Scan(&exists)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+	}
+
+	return exists, err
+}
@@ -0,0 +1,110 @@
+package preference
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+type service struct {
+	Repo   Repository
+	Logger log.Logger
+}
+
+func NewService(l log.Logger, r Repository) *service {
+	return &service{r, l}
+}
+
+func (s *service) GetPreferences(ctx context.Context, rq entities.GetPreferencesRequest) (entities.GetPreferencesResponse, error) {
+	s.Logger.Log("request", "get preferences", "received")
+
+	exists, err := s.Repo.UserExists(ctx, rq.UserId)
+	if err != nil {
+		return entities.GetPreferencesResponse{}, s.mapError(err)
+	}
+	if !exists {
+		return entities.GetPreferencesResponse{}, errors.NewUserNotFound()
+	}
+
+	stored, err := s.Repo.GetPreferences(ctx, rq.UserId)
+	if err != nil && err != sql.ErrNoRows {
+		return entities.GetPreferencesResponse{}, s.mapError(err)
+	}
+
+	values, customized := Resolve(stored.Values)
+	return entities.GetPreferencesResponse{Preferences: values, Customized: customized, UpdatedAt: stored.UpdatedAt}, nil
+}
+
+// UpdatePreferences checks every setting of the patch before storing any,
+// an invalid one leaves the preferences as they were.
+func (s *service) UpdatePreferences(ctx context.Context, rq entities.UpdatePreferencesRequest) (entities.UpdatePreferencesResponse, error) {
+	s.Logger.Log("request", "update preferences", "received")
+
+	if len(rq.Preferences) == 0 {
+		return entities.UpdatePreferencesResponse{}, errors.NewInvalidField("preferences", "must set at least one preference")
+	}
+
+	var stored entities.Preferences
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.Repo.LockUser(ctx, rq.UserId); err != nil {
+			if err == sql.ErrNoRows {
+				return errors.NewUserNotFound()
+			}
+			return err
+		}
+
+		var err error
+		stored, err = s.Repo.GetPreferences(ctx, rq.UserId)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		// Settings since removed are dropped along.
+		kept := map[string]interface{}{}
+		for name, value := range stored.Values {
+			if _, ok := Settings[name]; ok {
+				kept[name] = value
+			}
+		}
+
+		merged, err := Merge(kept, rq.Preferences)
+		if err != nil {
+			return err
+		}
+
+		stored = entities.Preferences{
+			UserId: rq.UserId,
+			Values: merged,
+			// The database keeps microseconds.
+			UpdatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		}
+		return s.Repo.SavePreferences(ctx, stored)
+	})
+	if err != nil {
+		return entities.UpdatePreferencesResponse{}, s.mapError(err)
+	}
+
+	values, customized := Resolve(stored.Values)
+	return entities.UpdatePreferencesResponse{Preferences: values, Customized: customized, UpdatedAt: stored.UpdatedAt}, nil
+}
+
+// mapError maps the errors of the repository, passing through the ones
+// already made for the client.
+func (s *service) mapError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	level.Error(s.Logger).Log("error", err)
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
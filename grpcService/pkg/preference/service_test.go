@@ -0,0 +1,176 @@
+package preference_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/preference"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const userId = "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"
+
+func TestServiceGetPreferences(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	updatedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name           string
+		buildMock      func(repo *utils.PreferenceRepositoryMock)
+		assertResponse func(t *testing.T, res entities.GetPreferencesResponse, err error)
+	}{
+		{
+			Name: "Stored Preferences",
+			buildMock: func(repo *utils.PreferenceRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(true, nil)
+				repo.On("GetPreferences", mock.Anything, userId).
+					Return(entities.Preferences{UserId: userId, Values: map[string]interface{}{"locale": "es-AR"}, UpdatedAt: updatedAt}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.GetPreferencesResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "es-AR", res.Preferences["locale"])
+				assert.Equal(t, "UTC", res.Preferences["timezone"])
+				assert.Equal(t, []string{"locale"}, res.Customized)
+				assert.Equal(t, updatedAt, res.UpdatedAt)
+			},
+		},
+		{
+			Name: "Never Set",
+			buildMock: func(repo *utils.PreferenceRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(true, nil)
+				repo.On("GetPreferences", mock.Anything, userId).Return(entities.Preferences{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.GetPreferencesResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "en-US", res.Preferences["locale"])
+				assert.Empty(t, res.Customized)
+				assert.True(t, res.UpdatedAt.IsZero())
+			},
+		},
+		{
+			Name: "Unknown User",
+			buildMock: func(repo *utils.PreferenceRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(false, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.GetPreferencesResponse, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+		{
+			Name: "Database Error",
+			buildMock: func(repo *utils.PreferenceRepositoryMock) {
+				repo.On("UserExists", mock.Anything, userId).Return(false, errors.New("connection reset"))
+			},
+			assertResponse: func(t *testing.T, res entities.GetPreferencesResponse, err error) {
+				assert.IsType(t, myErr.DataBaseErr{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.PreferenceRepositoryMock)
+			tc.buildMock(repo)
+
+			res, err := preference.NewService(logger, repo).GetPreferences(context.Background(), entities.GetPreferencesRequest{UserId: userId})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceUpdatePreferences(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Patch          map[string]interface{}
+		buildMock      func(repo *utils.PreferenceRepositoryMock)
+		assertResponse func(t *testing.T, res entities.UpdatePreferencesResponse, err error)
+	}{
+		{
+			Name:  "Partial Update",
+			Patch: map[string]interface{}{"timezone": "Asia/Tokyo", "theme": nil},
+			buildMock: func(repo *utils.PreferenceRepositoryMock) {
+				repo.On("LockUser", mock.Anything, userId).Return(nil)
+				repo.On("GetPreferences", mock.Anything, userId).
+					Return(entities.Preferences{UserId: userId, Values: map[string]interface{}{"theme": "dark", "locale": "fr-FR", "font": "serif"}}, nil)
+				repo.On("SavePreferences", mock.Anything, mock.MatchedBy(func(p entities.Preferences) bool {
+					return assert.ObjectsAreEqual(map[string]interface{}{"timezone": "Asia/Tokyo", "locale": "fr-FR"}, p.Values) && !p.UpdatedAt.IsZero()
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.UpdatePreferencesResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "Asia/Tokyo", res.Preferences["timezone"])
+				assert.Equal(t, "system", res.Preferences["theme"])
+				assert.Equal(t, []string{"locale", "timezone"}, res.Customized)
+			},
+		},
+		{
+			Name:  "First Update",
+			Patch: map[string]interface{}{"notifications.digest": "off"},
+			buildMock: func(repo *utils.PreferenceRepositoryMock) {
+				repo.On("LockUser", mock.Anything, userId).Return(nil)
+				repo.On("GetPreferences", mock.Anything, userId).Return(entities.Preferences{}, sql.ErrNoRows)
+				repo.On("SavePreferences", mock.Anything, mock.MatchedBy(func(p entities.Preferences) bool {
+					return p.UserId == userId && p.Values["notifications.digest"] == "off"
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.UpdatePreferencesResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"notifications.digest"}, res.Customized)
+			},
+		},
+		{
+			Name:  "Invalid Value Saves Nothing",
+			Patch: map[string]interface{}{"locale": "es-AR", "timezone": "Nowhere/City"},
+			buildMock: func(repo *utils.PreferenceRepositoryMock) {
+				repo.On("LockUser", mock.Anything, userId).Return(nil)
+				repo.On("GetPreferences", mock.Anything, userId).Return(entities.Preferences{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.UpdatePreferencesResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+				assert.Contains(t, err.Error(), "timezone")
+			},
+		},
+		{
+			Name:      "Empty Patch",
+			Patch:     map[string]interface{}{},
+			buildMock: func(repo *utils.PreferenceRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.UpdatePreferencesResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:  "Unknown User",
+			Patch: map[string]interface{}{"theme": "light"},
+			buildMock: func(repo *utils.PreferenceRepositoryMock) {
+				repo.On("LockUser", mock.Anything, userId).Return(sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.UpdatePreferencesResponse, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.PreferenceRepositoryMock)
+			tc.buildMock(repo)
+
+			res, err := preference.NewService(logger, repo).UpdatePreferences(context.Background(), entities.UpdatePreferencesRequest{UserId: userId, Preferences: tc.Patch})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
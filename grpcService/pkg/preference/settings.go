@@ -0,0 +1,177 @@
+package preference
+
+import (
+	"math"
+	"sort"
+	"time"
+	// Timezones are checked against the database embedded in the binary,
+	// hosts without one still take them.
+	_ "time/tzdata"
+
+	"golang.org/x/text/language"
+
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+// Setting is a preference users can set, and the value it takes until
+// they do.
+type Setting struct {
+	Default interface{}
+	// Rule tells what values are valid, for the error rejecting others.
+	Rule  string
+	parse func(value interface{}) (interface{}, bool)
+}
+
+// Settings are the preferences users can set, by name.
+var Settings = map[string]Setting{
+	"locale": {
+		Default: "en-US",
+		Rule:    "must be a BCP 47 language tag, like \"en-US\"",
+		parse:   parseLocale,
+	},
+	"timezone": {
+		Default: "UTC",
+		Rule:    "must be an IANA timezone, like \"America/Argentina/Buenos_Aires\"",
+		parse:   parseTimezone,
+	},
+	"theme": {
+		Default: "system",
+		Rule:    "must be \"system\", \"light\" or \"dark\"",
+		parse:   oneOf("system", "light", "dark"),
+	},
+	"page_size": {
+		Default: float64(20),
+		Rule:    "must be a whole number from 1 to 100",
+		parse:   intBetween(1, 100),
+	},
+	"notifications.email": {
+		Default: true,
+		Rule:    "must be true or false",
+		parse:   parseBool,
+	},
+	"notifications.push": {
+		Default: true,
+		Rule:    "must be true or false",
+		parse:   parseBool,
+	},
+	"notifications.digest": {
+		Default: "weekly",
+		Rule:    "must be \"off\", \"daily\" or \"weekly\"",
+		parse:   oneOf("off", "daily", "weekly"),
+	},
+}
+
+// Merge applies patch to the settings a user set: settings set to null are
+// removed, the others are checked and set in their canonical form. values
+// is left as it is.
+func Merge(values map[string]interface{}, patch map[string]interface{}) (map[string]interface{}, error) {
+	merged := make(map[string]interface{}, len(values)+len(patch))
+	for name, value := range values {
+		merged[name] = value
+	}
+
+	for _, name := range names(patch) {
+		setting, ok := Settings[name]
+		if !ok {
+			return nil, errors.NewInvalidField(name, "is not a known preference")
+		}
+		if patch[name] == nil {
+			delete(merged, name)
+			continue
+		}
+
+		value, ok := setting.parse(patch[name])
+		if !ok {
+			return nil, errors.NewInvalidField(name, setting.Rule)
+		}
+		merged[name] = value
+	}
+
+	return merged, nil
+}
+
+// Resolve returns every setting, the ones missing from values at their
+// default, and the names of the ones values sets. Stored values of settings
+// since removed are dropped, and ones no longer valid take the default.
+func Resolve(values map[string]interface{}) (map[string]interface{}, []string) {
+	resolved := make(map[string]interface{}, len(Settings))
+	customized := []string{}
+	for name, setting := range Settings {
+		resolved[name] = setting.Default
+		if value, ok := values[name]; ok {
+			if value, ok := setting.parse(value); ok {
+				resolved[name] = value
+				customized = append(customized, name)
+			}
+		}
+	}
+	sort.Strings(customized)
+
+	return resolved, customized
+}
+
+// names returns the keys of values in order, so the first invalid setting
+// reported is always the same one.
+func names(values map[string]interface{}) []string {
+	res := make([]string, 0, len(values))
+	for name := range values {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func parseLocale(value interface{}) (interface{}, bool) {
+	text, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+	tag, err := language.Parse(text)
+	if err != nil || tag == language.Und {
+		return nil, false
+	}
+	return tag.String(), true
+}
+
+// parseTimezone takes the names of the IANA database only, "Local" is the
+// zone of the host.
+func parseTimezone(value interface{}) (interface{}, bool) {
+	name, ok := value.(string)
+	if !ok || name == "" || name == "Local" {
+		return nil, false
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return nil, false
+	}
+	return name, true
+}
+
+func parseBool(value interface{}) (interface{}, bool) {
+	b, ok := value.(bool)
+	return b, ok
+}
+
+func oneOf(choices ...string) func(value interface{}) (interface{}, bool) {
+	return func(value interface{}) (interface{}, bool) {
+		text, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		for _, choice := range choices {
+			if text == choice {
+				return text, true
+			}
+		}
+		return nil, false
+	}
+}
+
+func intBetween(min, max float64) func(value interface{}) (interface{}, bool) {
+	return func(value interface{}) (interface{}, bool) {
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) || n < min || n > max {
+			return nil, false
+		}
+		return n, true
+	}
+}
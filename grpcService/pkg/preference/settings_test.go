@@ -0,0 +1,78 @@
+package preference_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/preference"
+)
+
+func TestMerge(t *testing.T) {
+	stored := map[string]interface{}{"theme": "dark", "timezone": "Europe/Madrid"}
+
+	testCases := []struct {
+		Name   string
+		Patch  map[string]interface{}
+		Merged map[string]interface{}
+		Field  string
+	}{
+		{
+			Name:   "Set And Reset",
+			Patch:  map[string]interface{}{"locale": "es-AR", "theme": nil, "notifications.email": false},
+			Merged: map[string]interface{}{"locale": "es-AR", "timezone": "Europe/Madrid", "notifications.email": false},
+		},
+		{
+			Name:   "Locale In Canonical Form",
+			Patch:  map[string]interface{}{"locale": "PT-br"},
+			Merged: map[string]interface{}{"locale": "pt-BR", "theme": "dark", "timezone": "Europe/Madrid"},
+		},
+		{
+			Name:   "Whole Page Size",
+			Patch:  map[string]interface{}{"page_size": float64(50)},
+			Merged: map[string]interface{}{"page_size": float64(50), "theme": "dark", "timezone": "Europe/Madrid"},
+		},
+		{Name: "Unknown Setting", Patch: map[string]interface{}{"font": "serif"}, Field: "font"},
+		{Name: "Invalid Locale", Patch: map[string]interface{}{"locale": "english please"}, Field: "locale"},
+		{Name: "Undetermined Locale", Patch: map[string]interface{}{"locale": "und"}, Field: "locale"},
+		{Name: "Unknown Timezone", Patch: map[string]interface{}{"timezone": "Mars/Olympus_Mons"}, Field: "timezone"},
+		{Name: "Host Timezone", Patch: map[string]interface{}{"timezone": "Local"}, Field: "timezone"},
+		{Name: "Not A Choice", Patch: map[string]interface{}{"theme": "blue"}, Field: "theme"},
+		{Name: "Fractional Page Size", Patch: map[string]interface{}{"page_size": 2.5}, Field: "page_size"},
+		{Name: "Page Size Too Large", Patch: map[string]interface{}{"page_size": float64(500)}, Field: "page_size"},
+		{Name: "Bool As String", Patch: map[string]interface{}{"notifications.push": "false"}, Field: "notifications.push"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			merged, err := preference.Merge(stored, tc.Patch)
+			if tc.Field == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Merged, merged)
+				return
+			}
+
+			assert.IsType(t, myErr.InvalidField{}, err)
+			assert.Contains(t, err.Error(), tc.Field)
+		})
+	}
+
+	assert.Equal(t, map[string]interface{}{"theme": "dark", "timezone": "Europe/Madrid"}, stored)
+}
+
+func TestResolve(t *testing.T) {
+	values, customized := preference.Resolve(map[string]interface{}{
+		"timezone": "America/Argentina/Buenos_Aires",
+		// A setting since removed, and a value no longer valid.
+		"font":  "serif",
+		"theme": "blue",
+	})
+
+	assert.Equal(t, []string{"timezone"}, customized)
+	assert.Equal(t, "America/Argentina/Buenos_Aires", values["timezone"])
+	assert.Equal(t, preference.Settings["theme"].Default, values["theme"])
+	assert.Equal(t, preference.Settings["locale"].Default, values["locale"])
+	assert.NotContains(t, values, "font")
+	assert.Len(t, values, len(preference.Settings))
+}
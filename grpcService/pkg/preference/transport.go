@@ -0,0 +1,123 @@
+package preference
+
+import (
+	"context"
+	"time"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	get    gr.Handler
+	update gr.Handler
+	proto.UnimplementedPreferenceServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.PreferenceServiceServer {
+	return &gRPCSv{
+		get: gr.NewServer(
+			end.GetPreferences,
+			decodeGetPreferencesRequest,
+			encodeGetPreferencesResponse,
+		),
+
+		update: gr.NewServer(
+			end.UpdatePreferences,
+			decodeUpdatePreferencesRequest,
+			encodeUpdatePreferencesResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) GetPreferences(ctx context.Context, rq *proto.GetPreferencesRequest) (*proto.GetPreferencesResponse, error) {
+	_, resp, err := g.get.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.GetPreferencesResponse), nil
+}
+
+func (g *gRPCSv) UpdatePreferences(ctx context.Context, rq *proto.UpdatePreferencesRequest) (*proto.UpdatePreferencesResponse, error) {
+	_, resp, err := g.update.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.UpdatePreferencesResponse), nil
+}
+
+func decodeGetPreferencesRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.GetPreferencesRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.GetPreferencesRequest{UserId: id.String()}, nil
+}
+
+func encodeGetPreferencesResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.GetPreferencesResponse)
+	preferences, err := structpb.NewStruct(res.Preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.GetPreferencesResponse{
+		Preferences: preferences,
+		Customized:  res.Customized,
+		Updated_At:  timeToProto(res.UpdatedAt),
+	}, nil
+}
+
+func decodeUpdatePreferencesRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.UpdatePreferencesRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var preferences map[string]interface{}
+	if res.Preferences != nil {
+		preferences = res.Preferences.AsMap()
+	}
+
+	return entities.UpdatePreferencesRequest{UserId: id.String(), Preferences: preferences}, nil
+}
+
+func encodeUpdatePreferencesResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.UpdatePreferencesResponse)
+	preferences, err := structpb.NewStruct(res.Preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.UpdatePreferencesResponse{
+		Preferences: preferences,
+		Customized:  res.Customized,
+		Updated_At:  timeToProto(res.UpdatedAt),
+	}, nil
+}
+
+// timeToProto leaves unset times out of the response.
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,136 @@
+// Package privacy builds the data exports of users and signs the receipts
+// handed out when a user is erased.
+package privacy
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+var ErrInvalidReceipt = errors.New("privacy: invalid receipt signature")
+
+// Signer signs erasure receipts with an Ed25519 key. Receipts are checked
+// with the public key alone, so the user can verify theirs.
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner uses the 32 byte seed of an Ed25519 key.
+func NewSigner(seed []byte) (*Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("privacy: receipt key seed must be 32 bytes")
+	}
+	return &Signer{ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// LoadSigner reads a file holding the base64 seed of the key.
+func LoadSigner(path string) (*Signer, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, errors.New("privacy: receipt key is not base64")
+	}
+	return NewSigner(seed)
+}
+
+// GenerateSigner makes a signer with a new random key.
+func GenerateSigner() (*Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{key}, nil
+}
+
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// KeyId names the public key receipts are verified with.
+func (s *Signer) KeyId() string {
+	return KeyId(s.PublicKey())
+}
+
+func KeyId(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Sign sets the key id and signature of receipt.
+func (s *Signer) Sign(receipt entities.ErasureReceipt) (entities.ErasureReceipt, error) {
+	receipt.KeyId = s.KeyId()
+	payload, err := signedPayload(receipt)
+	if err != nil {
+		return entities.ErasureReceipt{}, err
+	}
+
+	receipt.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payload))
+	return receipt, nil
+}
+
+// Verify checks the signature of receipt against publicKey.
+func Verify(publicKey ed25519.PublicKey, receipt entities.ErasureReceipt) error {
+	signature, err := base64.StdEncoding.DecodeString(receipt.Signature)
+	if err != nil || receipt.KeyId != KeyId(publicKey) {
+		return ErrInvalidReceipt
+	}
+
+	payload, err := signedPayload(receipt)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return ErrInvalidReceipt
+	}
+	return nil
+}
+
+// signedPayload is the receipt as JSON without its signature. Times are
+// written in UTC so the payload is the same wherever it is rebuilt.
+func signedPayload(receipt entities.ErasureReceipt) ([]byte, error) {
+	receipt.Signature = ""
+	receipt.ErasedAt = receipt.ErasedAt.UTC()
+	return json.Marshal(receipt)
+}
+
+// Archive writes JSON files into a zip.
+type Archive struct {
+	zip *zip.Writer
+	now time.Time
+}
+
+func NewArchive(w io.Writer, now time.Time) *Archive {
+	return &Archive{zip.NewWriter(w), now}
+}
+
+// Add writes v as the indented JSON file name.
+func (a *Archive) Add(name string, v interface{}) error {
+	w, err := a.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.now})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (a *Archive) Close() error {
+	return a.zip.Close()
+}
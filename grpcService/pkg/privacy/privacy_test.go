@@ -0,0 +1,85 @@
+package privacy_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/privacy"
+)
+
+func TestSignReceipt(t *testing.T) {
+	signer, err := privacy.NewSigner(bytes.Repeat([]byte{7}, 32))
+	assert.NoError(t, err)
+
+	receipt, err := signer.Sign(entities.ErasureReceipt{
+		Id:       "receipt-1",
+		UserId:   "user-1",
+		ErasedAt: time.Date(2022, 1, 2, 3, 4, 5, 6000, time.FixedZone("ART", -3*60*60)),
+		Erased:   []string{"profile"},
+		Retained: []string{"audit_log"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, signer.KeyId(), receipt.KeyId)
+	assert.NoError(t, privacy.Verify(signer.PublicKey(), receipt))
+
+	// The receipt verifies again once it went through JSON and back.
+	content, err := json.Marshal(receipt)
+	assert.NoError(t, err)
+	var decoded entities.ErasureReceipt
+	assert.NoError(t, json.Unmarshal(content, &decoded))
+	assert.NoError(t, privacy.Verify(signer.PublicKey(), decoded))
+
+	tampered := receipt
+	tampered.UserId = "user-2"
+	assert.ErrorIs(t, privacy.Verify(signer.PublicKey(), tampered), privacy.ErrInvalidReceipt)
+
+	other, err := privacy.GenerateSigner()
+	assert.NoError(t, err)
+	assert.ErrorIs(t, privacy.Verify(other.PublicKey(), receipt), privacy.ErrInvalidReceipt)
+}
+
+func TestLoadSigner(t *testing.T) {
+	dir := t.TempDir()
+	seed := bytes.Repeat([]byte{7}, 32)
+
+	path := filepath.Join(dir, "receipt.key")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(seed)+"\n"), 0600))
+	signer, err := privacy.LoadSigner(path)
+	assert.NoError(t, err)
+	expected, _ := privacy.NewSigner(seed)
+	assert.Equal(t, expected.KeyId(), signer.KeyId())
+
+	short := filepath.Join(dir, "short.key")
+	assert.NoError(t, ioutil.WriteFile(short, []byte(base64.StdEncoding.EncodeToString(seed[:16])), 0600))
+	_, err = privacy.LoadSigner(short)
+	assert.Error(t, err)
+}
+
+func TestArchive(t *testing.T) {
+	var buf bytes.Buffer
+	archive := privacy.NewArchive(&buf, time.Now())
+	assert.NoError(t, archive.Add("profile.json", map[string]string{"name": "Timo"}))
+	assert.NoError(t, archive.Add("events.json", []string{}))
+	assert.NoError(t, archive.Close())
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	if assert.Len(t, reader.File, 2) {
+		assert.Equal(t, "profile.json", reader.File[0].Name)
+
+		file, err := reader.File[0].Open()
+		assert.NoError(t, err)
+		var profile map[string]string
+		assert.NoError(t, json.NewDecoder(file).Decode(&profile))
+		assert.Equal(t, "Timo", profile["name"])
+	}
+}
@@ -0,0 +1,74 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("tenant: invalid token")
+	ErrExpiredToken = errors.New("tenant: token expired")
+	ErrMissingClaim = errors.New("tenant: token has no tenant claim")
+)
+
+// ParseClaim checks the HS256 JSON web token against secret and returns
+// the tenant held in its claim. Tokens past their exp or before their nbf
+// are refused.
+func ParseClaim(token string, secret []byte, claim string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return "", ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", ErrInvalidToken
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", ErrInvalidToken
+	}
+
+	t := time.Now().Unix()
+	if exp, ok := claims["exp"].(float64); ok && t >= int64(exp) {
+		return "", ErrExpiredToken
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && t < int64(nbf) {
+		return "", ErrInvalidToken
+	}
+
+	id, _ := claims[claim].(string)
+	if id == "" {
+		return "", ErrMissingClaim
+	}
+	if !Valid(id) {
+		return "", ErrInvalidToken
+	}
+	return id, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
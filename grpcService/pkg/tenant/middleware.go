@@ -0,0 +1,44 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+// Directory finds the organization a tenant stands for.
+type Directory interface {
+	GetOrganization(ctx context.Context, id string) (entities.Organization, error)
+}
+
+// Middleware lets a request through only when its tenant is an existing
+// organization and, for an authenticated caller, the one the caller was
+// authenticated for.
+func Middleware(orgs Directory) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			id := FromContext(ctx)
+			if claim, ok := ClaimFromContext(ctx); ok && claim != id {
+				return nil, errors.NewCrossTenantAccess()
+			}
+
+			if _, err := orgs.GetOrganization(ctx, id); err != nil {
+				switch {
+				case err == sql.ErrNoRows:
+					return nil, errors.NewResourceNotFound("organization")
+				case database.IsUnavailable(err):
+					return nil, errors.NewDataBaseUnavailable()
+				default:
+					return nil, errors.NewDataBaseError()
+				}
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
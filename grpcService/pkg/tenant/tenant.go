@@ -0,0 +1,168 @@
+// Package tenant scopes requests to the organization they are made for.
+// The tenant of a request is the id of that organization.
+package tenant
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys a gateway forwards the tenant of a request with, and the
+// token of its caller.
+const (
+	Header              = "x-tenant-id"
+	AuthorizationHeader = "authorization"
+)
+
+// Default is the tenant of requests that don't name one. Users created
+// before tenants existed belong to it.
+const Default = "default"
+
+// Tenant ids end up in host names and paths, they follow the rules of a
+// DNS label.
+var validId = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Valid tells whether id can name a tenant.
+func Valid(id string) bool {
+	return validId.MatchString(id)
+}
+
+type tenantKey struct{}
+
+type claimKey struct{}
+
+type tokenKey struct{}
+
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant carried by ctx, Default if there is none.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
+
+// WithClaim records the tenant the caller was authenticated for.
+func WithClaim(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, claimKey{}, id)
+}
+
+// ClaimFromContext returns the tenant the caller of ctx was authenticated
+// for, if it was.
+func ClaimFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(claimKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithToken keeps the bearer token of the caller, for a gateway to forward.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// ToOutgoingContext forwards the tenant and token carried by ctx to the
+// gRPC server it is used to call.
+func ToOutgoingContext(ctx context.Context) context.Context {
+	var kv []string
+	if id, ok := ctx.Value(tenantKey{}).(string); ok && id != "" {
+		kv = append(kv, Header, id)
+	}
+	if token, ok := ctx.Value(tokenKey{}).(string); ok && token != "" {
+		kv = append(kv, AuthorizationHeader, "Bearer "+token)
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// Resolver reads the tenant of gRPC requests from their metadata. A
+// request names its tenant in the Header, and a caller holding a token
+// signed with Secret is authenticated for the tenant in its Claim. The
+// claim stands for the tenant of requests that don't name one.
+type Resolver struct {
+	Secret []byte
+	Claim  string
+}
+
+// NewResolver builds a resolver checking tokens with secret. Without a
+// secret tokens are ignored and only the header counts.
+func NewResolver(secret []byte, claim string) *Resolver {
+	return &Resolver{Secret: secret, Claim: claim}
+}
+
+// FromIncomingContext reads the tenant forwarded in the metadata of a gRPC
+// request into its context.
+func (r *Resolver) FromIncomingContext(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := first(md.Get(Header))
+	if id != "" && !Valid(id) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tenant %q", id)
+	}
+
+	if authorization := first(md.Get(AuthorizationHeader)); authorization != "" && len(r.Secret) > 0 {
+		token := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+		claim, err := ParseClaim(token, r.Secret, r.Claim)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		ctx = WithClaim(ctx, claim)
+		if id == "" {
+			id = claim
+		}
+	}
+
+	if id != "" {
+		ctx = WithTenant(ctx, id)
+	}
+	return ctx, nil
+}
+
+func (r *Resolver) UnaryServerInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := r.FromIncomingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (r *Resolver) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := r.FromIncomingContext(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &tenantStream{ss, ctx})
+}
+
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(ToOutgoingContext(ctx), method, req, reply, cc, opts...)
+}
+
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(ToOutgoingContext(ctx), desc, cc, method, opts...)
+}
+
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,179 @@
+package tenant_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+var secret = []byte("secret")
+
+func sign(t *testing.T, alg string, key []byte, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	assert.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestValid(t *testing.T) {
+	for id, valid := range map[string]bool{
+		"acme":     true,
+		"acme-01":  true,
+		"a":        true,
+		"":         false,
+		"Acme":     false,
+		"-acme":    false,
+		"acme-":    false,
+		"acme.com": false,
+		"acme/x":   false,
+	} {
+		assert.Equal(t, valid, tenant.Valid(id), id)
+	}
+}
+
+func TestParseClaim(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	testCases := []struct {
+		Name  string
+		Token string
+		Id    string
+		Err   error
+	}{
+		{"Valid Token", sign(t, "HS256", secret, map[string]interface{}{"tenant_id": "acme", "exp": future}), "acme", nil},
+		{"Wrong Key", sign(t, "HS256", []byte("other"), map[string]interface{}{"tenant_id": "acme"}), "", tenant.ErrInvalidToken},
+		{"Unsigned", sign(t, "none", secret, map[string]interface{}{"tenant_id": "acme"}), "", tenant.ErrInvalidToken},
+		{"Expired", sign(t, "HS256", secret, map[string]interface{}{"tenant_id": "acme", "exp": past}), "", tenant.ErrExpiredToken},
+		{"Not Yet Valid", sign(t, "HS256", secret, map[string]interface{}{"tenant_id": "acme", "nbf": future}), "", tenant.ErrInvalidToken},
+		{"No Claim", sign(t, "HS256", secret, map[string]interface{}{"sub": "timo"}), "", tenant.ErrMissingClaim},
+		{"Malformed", "not-a-token", "", tenant.ErrInvalidToken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			id, err := tenant.ParseClaim(tc.Token, secret, "tenant_id")
+			assert.Equal(t, tc.Err, err)
+			assert.Equal(t, tc.Id, id)
+		})
+	}
+}
+
+func TestResolver(t *testing.T) {
+	resolver := tenant.NewResolver(secret, "tenant_id")
+	token := sign(t, "HS256", secret, map[string]interface{}{"tenant_id": "acme"})
+
+	testCases := []struct {
+		Name     string
+		Metadata metadata.MD
+		Tenant   string
+		Claim    string
+		Code     codes.Code
+	}{
+		{"Nothing Sent", metadata.Pairs(), tenant.Default, "", codes.OK},
+		{"Header", metadata.Pairs(tenant.Header, "globex"), "globex", "", codes.OK},
+		{"Claim", metadata.Pairs(tenant.AuthorizationHeader, "Bearer "+token), "acme", "acme", codes.OK},
+		{"Header And Claim", metadata.Pairs(tenant.Header, "globex", tenant.AuthorizationHeader, "Bearer "+token), "globex", "acme", codes.OK},
+		{"Invalid Header", metadata.Pairs(tenant.Header, "Globex Inc"), "", "", codes.InvalidArgument},
+		{"Invalid Token", metadata.Pairs(tenant.AuthorizationHeader, "Bearer "+token+"x"), "", "", codes.Unauthenticated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx, err := resolver.FromIncomingContext(metadata.NewIncomingContext(context.Background(), tc.Metadata))
+			assert.Equal(t, tc.Code, status.Code(err))
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, tc.Tenant, tenant.FromContext(ctx))
+			claim, _ := tenant.ClaimFromContext(ctx)
+			assert.Equal(t, tc.Claim, claim)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	next := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	testCases := []struct {
+		Name           string
+		Ctx            context.Context
+		buildMock      func(orgs *utils.OrganizationRepositoryMock)
+		assertResponse func(t *testing.T, res interface{}, err error)
+	}{
+		{
+			Name: "Tenant Of The Caller",
+			Ctx:  tenant.WithClaim(tenant.WithTenant(context.Background(), "acme"), "acme"),
+			buildMock: func(orgs *utils.OrganizationRepositoryMock) {
+				orgs.On("GetOrganization", mock.Anything, "acme").Return(entities.Organization{Id: "acme"}, nil)
+			},
+			assertResponse: func(t *testing.T, res interface{}, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "ok", res)
+			},
+		},
+		{
+			Name:      "Cross Tenant Access",
+			Ctx:       tenant.WithClaim(tenant.WithTenant(context.Background(), "globex"), "acme"),
+			buildMock: func(orgs *utils.OrganizationRepositoryMock) {},
+			assertResponse: func(t *testing.T, res interface{}, err error) {
+				assert.IsType(t, myErr.CrossTenantAccess{}, err)
+			},
+		},
+		{
+			Name: "Unknown Organization",
+			Ctx:  tenant.WithTenant(context.Background(), "initech"),
+			buildMock: func(orgs *utils.OrganizationRepositoryMock) {
+				orgs.On("GetOrganization", mock.Anything, "initech").Return(entities.Organization{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res interface{}, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+		{
+			Name: "No Tenant Is The Default One",
+			Ctx:  context.Background(),
+			buildMock: func(orgs *utils.OrganizationRepositoryMock) {
+				orgs.On("GetOrganization", mock.Anything, tenant.Default).Return(entities.Organization{Id: tenant.Default}, nil)
+			},
+			assertResponse: func(t *testing.T, res interface{}, err error) {
+				assert.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			orgs := new(utils.OrganizationRepositoryMock)
+			tc.buildMock(orgs)
+
+			res, err := tenant.Middleware(orgs)(next)(tc.Ctx, nil)
+			tc.assertResponse(t, res, err)
+			orgs.AssertExpectations(t)
+		})
+	}
+}
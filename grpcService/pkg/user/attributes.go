@@ -0,0 +1,158 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/attributes"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/etag"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// UpdateUserAttributes applies rq.Attributes to the attributes of a user as
+// a JSON merge patch, attributes set to null are removed. The result has to
+// pass the attribute schema of the tenant.
+func (s *service) UpdateUserAttributes(ctx context.Context, rq entities.UpdateUserAttributesRequest) (entities.UpdateUserAttributesResponse, error) {
+	s.Logger.Log(s.Logger, "request", "update user attributes", "received")
+
+	if rq.UserId == "" {
+		return entities.UpdateUserAttributesResponse{}, errors.NewInvalidField("user_id", "is required")
+	}
+	if rq.IfMatch != "" && !etag.Valid(rq.IfMatch) {
+		return entities.UpdateUserAttributesResponse{}, errors.NewInvalidField("if_match", "must be \"*\" or a list of etags")
+	}
+
+	schema, err := s.attributeSchema(ctx)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.UpdateUserAttributesResponse{}, dataBaseError(err)
+	}
+
+	var response entities.UpdateUserAttributesResponse
+	err = s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		version, err := s.Repo.LockUser(ctx, rq.UserId)
+		if err != nil {
+			return err
+		}
+		if rq.IfMatch != "" && !etag.Match(rq.IfMatch, etag.Format(version)) {
+			return errors.NewPreconditionFailed("user was modified since it was read")
+		}
+
+		before, err := s.Repo.GetUser(ctx, rq.UserId)
+		if err != nil {
+			return err
+		}
+
+		after := before
+		after.Attributes = attributes.Merge(before.Attributes, rq.Attributes)
+		if err := schema.Validate(after.Attributes); err != nil {
+			return err
+		}
+
+		if err := s.Repo.UpdateUserAttributes(ctx, rq.UserId, after.Attributes); err != nil {
+			return err
+		}
+		if err := s.Repo.SaveAttributeIndex(ctx, rq.UserId, schema.Index(after.Attributes)); err != nil {
+			return err
+		}
+
+		if err := s.Repo.SaveEvent(ctx, events.NewUserUpdated(rq.UserId)); err != nil {
+			return err
+		}
+		if err := s.record(ctx, audit.NewEntry(ctx, "UpdateUserAttributes", rq.UserId, &before, &after, nil)); err != nil {
+			return err
+		}
+
+		response.Attributes = after.Attributes
+		response.Etag = etag.Format(version + 1)
+		return nil
+	})
+	if err != nil {
+		switch err.(type) {
+		case errors.PreconditionFailed, errors.InvalidField:
+		default:
+			if err == sql.ErrNoRows {
+				err = errors.NewUserNotFound()
+			} else {
+				level.Error(s.Logger).Log("error", err)
+				err = dataBaseError(err)
+			}
+		}
+		s.recordFailure(ctx, audit.NewEntry(ctx, "UpdateUserAttributes", rq.UserId, nil, nil, err))
+		return entities.UpdateUserAttributesResponse{}, err
+	}
+
+	return response, nil
+}
+
+// ListUsers pages through the users of the tenant in id order. Filters
+// keep the users whose indexed attributes hold the given values.
+func (s *service) ListUsers(ctx context.Context, rq entities.ListUsersRequest) (entities.ListUsersResponse, error) {
+	s.Logger.Log(s.Logger, "request", "list users", "received")
+
+	filters := make(map[string]string, len(rq.Filters))
+	if len(rq.Filters) > 0 {
+		if len(rq.Filters) > attributes.MaxIndexed {
+			return entities.ListUsersResponse{}, errors.NewInvalidField("filters", "more filters than indexed attributes")
+		}
+
+		schema, err := s.attributeSchema(ctx)
+		if err != nil {
+			level.Error(s.Logger).Log("error", err)
+			return entities.ListUsersResponse{}, dataBaseError(err)
+		}
+		for name, value := range rq.Filters {
+			if filters[name], err = schema.FilterValue(name, value); err != nil {
+				return entities.ListUsersResponse{}, err
+			}
+		}
+	}
+
+	pageSize := validPageSize(rq.PageSize)
+
+	// One user more than asked tells whether there is a next page.
+	users, err := s.Repo.FilterUsers(ctx, filters, rq.PageToken, pageSize+1)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListUsersResponse{}, dataBaseError(err)
+	}
+
+	var response entities.ListUsersResponse
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		response.NextPageToken = users[pageSize-1].Id
+	}
+
+	response.Users = make([]entities.GetUserResponse, len(users))
+	for i, user := range users {
+		response.Users[i] = entities.GetUserResponse{
+			Id:         user.Id,
+			Name:       user.Name,
+			Age:        user.Age,
+			Etag:       etag.Format(user.Version),
+			Attributes: user.Attributes,
+		}
+	}
+
+	return response, nil
+}
+
+func validPageSize(size uint32) int {
+	if size == 0 {
+		return defaultPageSize
+	}
+	if size > maxPageSize {
+		return maxPageSize
+	}
+	return int(size)
+}
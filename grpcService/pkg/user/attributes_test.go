@@ -0,0 +1,195 @@
+package user_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/attributes"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	service "github.com/timoteoBone/microservice-project/grpcService/pkg/user"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const testAttributeSchema = `{
+	"type": "object",
+	"properties": {
+		"level": {"type": "integer", "minimum": 1, "x-indexed": true},
+		"team": {"type": "string"}
+	},
+	"additionalProperties": false
+}`
+
+type schemasStub struct {
+	schema *attributes.Schema
+}
+
+func (s schemasStub) Schema(ctx context.Context) (*attributes.Schema, error) {
+	return s.schema, nil
+}
+
+func TestServiceUpdateUserAttributes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	schema, err := attributes.Parse([]byte(testAttributeSchema))
+	assert.NoError(t, err)
+
+	current := entities.User{Name: "Timo", Age: 19, Version: 2, Attributes: map[string]interface{}{"level": float64(2), "team": "core"}}
+
+	testCases := []struct {
+		Name           string
+		Schema         *attributes.Schema
+		Request        entities.UpdateUserAttributesRequest
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, res entities.UpdateUserAttributesResponse, err error)
+	}{
+		{
+			Name:    "Patch Merged And Indexed",
+			Schema:  schema,
+			Request: entities.UpdateUserAttributesRequest{UserId: "user-1", Attributes: map[string]interface{}{"level": float64(3), "team": nil}, IfMatch: `"2"`},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				want := map[string]interface{}{"level": float64(3)}
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(2), nil)
+				repo.On("GetUser", mock.Anything, "user-1").Return(current, nil)
+				repo.On("UpdateUserAttributes", mock.Anything, "user-1", want).Return(nil)
+				repo.On("SaveAttributeIndex", mock.Anything, "user-1", map[string]string{"level": "3"}).Return(nil)
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.UpdateUserAttributesResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, map[string]interface{}{"level": float64(3)}, res.Attributes)
+				assert.Equal(t, `"3"`, res.Etag)
+			},
+		},
+		{
+			Name:    "Patch Fails The Schema",
+			Schema:  schema,
+			Request: entities.UpdateUserAttributesRequest{UserId: "user-1", Attributes: map[string]interface{}{"level": float64(0)}},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(2), nil)
+				repo.On("GetUser", mock.Anything, "user-1").Return(current, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.UpdateUserAttributesResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:    "User Changed Since",
+			Schema:  schema,
+			Request: entities.UpdateUserAttributesRequest{UserId: "user-1", Attributes: map[string]interface{}{"level": float64(3)}, IfMatch: `"1"`},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(2), nil)
+			},
+			assertResponse: func(t *testing.T, res entities.UpdateUserAttributesResponse, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name:    "User Not Found",
+			Schema:  schema,
+			Request: entities.UpdateUserAttributesRequest{UserId: "user-1", Attributes: map[string]interface{}{"level": float64(3)}},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(0), sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.UpdateUserAttributesResponse, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+		{
+			Name:    "Tenant Without Schema",
+			Request: entities.UpdateUserAttributesRequest{UserId: "user-1", Attributes: map[string]interface{}{"level": float64(3)}},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(2), nil)
+				repo.On("GetUser", mock.Anything, "user-1").Return(entities.User{Version: 2}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.UpdateUserAttributesResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Attributes = schemasStub{tc.Schema}
+			res, err := srvc.UpdateUserAttributes(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceListUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	schema, err := attributes.Parse([]byte(testAttributeSchema))
+	assert.NoError(t, err)
+
+	users := []entities.User{
+		{Id: "user-1", Name: "Timo", Version: 1, Attributes: map[string]interface{}{"level": float64(3)}},
+		{Id: "user-2", Name: "Ana", Version: 4, Attributes: map[string]interface{}{"level": float64(3)}},
+		{Id: "user-3", Name: "Juan", Version: 1, Attributes: map[string]interface{}{"level": float64(3)}},
+	}
+
+	testCases := []struct {
+		Name           string
+		Request        entities.ListUsersRequest
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, res entities.ListUsersResponse, err error)
+	}{
+		{
+			Name:    "Filtered Page",
+			Request: entities.ListUsersRequest{PageSize: 2, Filters: map[string]string{"level": "3.0"}},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("FilterUsers", mock.Anything, map[string]string{"level": "3"}, "", 3).Return(users, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ListUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, res.Users, 2)
+				assert.Equal(t, "user-2", res.NextPageToken)
+				assert.Equal(t, `"4"`, res.Users[1].Etag)
+				assert.Equal(t, map[string]interface{}{"level": float64(3)}, res.Users[1].Attributes)
+			},
+		},
+		{
+			Name:    "Last Page",
+			Request: entities.ListUsersRequest{PageToken: "user-2"},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("FilterUsers", mock.Anything, map[string]string{}, "user-2", 101).Return(users[2:], nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ListUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, res.Users, 1)
+				assert.Empty(t, res.NextPageToken)
+			},
+		},
+		{
+			Name:      "Filter On Attribute Not Indexed",
+			Request:   entities.ListUsersRequest{Filters: map[string]string{"team": "core"}},
+			buildMock: func(repo *utils.RepoSitoryMock) {},
+			assertResponse: func(t *testing.T, res entities.ListUsersResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Attributes = schemasStub{schema}
+			res, err := srvc.ListUsers(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/consent"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// unknownUserHash is checked against the password given for an email no
+// user has, so the answer takes as long as for a wrong password.
+const unknownUserHash = "$2a$10$PBQwIT46zgwikfm3XuCLNukDR9uilcdMU2NDsmtNq/13sckvdLlLy"
+
+// Authenticate checks the password of the user with the email, then that
+// the user has accepted the current version of every mandatory policy.
+// Unknown emails and wrong passwords get the same error.
+func (s *service) Authenticate(ctx context.Context, rq entities.AuthenticateRequest) (entities.AuthenticateResponse, error) {
+	s.Logger.Log(s.Logger, "request", "authenticate", "received")
+
+	if rq.Email == "" || rq.Pass == "" {
+		return entities.AuthenticateResponse{}, errors.NewFieldsMissing()
+	}
+
+	user, err := s.Repo.GetUserByEmail(ctx, rq.Email)
+	if err != nil && err != sql.ErrNoRows {
+		level.Error(s.Logger).Log("error", err)
+		return entities.AuthenticateResponse{}, dataBaseError(err)
+	}
+	if err == sql.ErrNoRows {
+		user.Pass = unknownUserHash
+	}
+	if utils.CheckPassword(rq.Pass, user.Pass) != nil || err == sql.ErrNoRows {
+		return entities.AuthenticateResponse{}, errors.NewUnauthenticated("email or password is incorrect")
+	}
+
+	if s.Consents != nil {
+		pending, err := s.pendingPolicies(ctx, user.Id)
+		if err != nil {
+			level.Error(s.Logger).Log("error", err)
+			return entities.AuthenticateResponse{}, dataBaseError(err)
+		}
+		if len(pending) > 0 {
+			versions := map[string]string{}
+			for _, policy := range pending {
+				versions[policy.Kind] = policy.Version
+			}
+			return entities.AuthenticateResponse{}, errors.NewPolicyNotAccepted(versions)
+		}
+	}
+
+	return entities.AuthenticateResponse{
+		UserId: user.Id,
+		Status: entities.Status{Message: "authenticated"},
+	}, nil
+}
+
+// pendingPolicies returns the mandatory policies whose current version
+// the user has yet to accept.
+func (s *service) pendingPolicies(ctx context.Context, userId string) ([]entities.Policy, error) {
+	policies, err := s.Consents.CurrentPolicies(ctx)
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+
+	consents, err := s.Consents.ListConsents(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return consent.Pending(policies, consents), nil
+}
@@ -0,0 +1,237 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/avatar"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/etag"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+// AvatarConfig sets where avatars are kept and what uploads are taken.
+type AvatarConfig struct {
+	// Blobs keeps the thumbnails, avatars can't be uploaded nor read
+	// without it.
+	Blobs avatar.BlobStore
+	// MaxBytes caps the size of an uploaded picture.
+	MaxBytes int64
+	// Sizes are the sides, in pixels, of the thumbnails made of a picture.
+	Sizes []int
+}
+
+func DefaultAvatarConfig() AvatarConfig {
+	return AvatarConfig{
+		MaxBytes: 5 << 20,
+		Sizes:    avatar.DefaultSizes,
+	}
+}
+
+var errAvatarsNotConfigured = status.Error(codes.Unimplemented, "avatar storage is not configured")
+
+// UploadAvatar makes the picture read from body the avatar of a user. The
+// thumbnails are stored before the avatar is saved and the ones of the
+// avatar it replaces removed after, a failure in between leaves thumbnails
+// behind rather than an avatar without them.
+func (s *service) UploadAvatar(ctx context.Context, rq entities.UploadAvatarRequest, body io.Reader) (entities.UploadAvatarResponse, error) {
+	s.Logger.Log(s.Logger, "request", "upload avatar", "received")
+
+	if s.Avatars.Blobs == nil {
+		return entities.UploadAvatarResponse{}, errAvatarsNotConfigured
+	}
+	if rq.UserId == "" {
+		return entities.UploadAvatarResponse{}, errors.NewInvalidField("user_id", "is required")
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, s.Avatars.MaxBytes+1))
+	if err != nil {
+		return entities.UploadAvatarResponse{}, err
+	}
+	if int64(len(data)) > s.Avatars.MaxBytes {
+		return entities.UploadAvatarResponse{}, errors.NewPayloadTooLarge("avatar", s.Avatars.MaxBytes)
+	}
+	if len(data) == 0 {
+		return entities.UploadAvatarResponse{}, errors.NewInvalidField("avatar", "is empty")
+	}
+
+	img, err := avatar.Process(data, s.Avatars.Sizes)
+	if err != nil {
+		return entities.UploadAvatarResponse{}, err
+	}
+
+	uploaded := entities.Avatar{
+		UserId:      rq.UserId,
+		Hash:        img.Hash,
+		ContentType: img.ContentType,
+		// The database keeps microseconds.
+		UpdatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	for _, thumbnail := range img.Thumbnails {
+		uploaded.Sizes = append(uploaded.Sizes, thumbnail.Size)
+	}
+
+	tenantId := tenant.FromContext(ctx)
+	for i, thumbnail := range img.Thumbnails {
+		if err := s.Avatars.Blobs.Put(ctx, avatar.Key(tenantId, rq.UserId, img.Hash, thumbnail.Size), thumbnail.Data, img.ContentType); err != nil {
+			level.Error(s.Logger).Log("msg", "storing avatar failed", "error", err)
+			s.removeAvatars(ctx, entities.Avatar{UserId: rq.UserId, Hash: img.Hash, Sizes: uploaded.Sizes[:i]})
+			return entities.UploadAvatarResponse{}, status.Error(codes.Unavailable, "avatar storage is unavailable, try again later")
+		}
+	}
+
+	// unshared tells whether the thumbnails just stored belong to no saved
+	// avatar, the same picture uploaded again shares them with the one
+	// saved already.
+	var (
+		replaced []entities.Avatar
+		unshared bool
+	)
+	err = s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.Repo.LockUser(ctx, rq.UserId); err != nil {
+			return err
+		}
+
+		previous, err := s.Repo.GetAvatar(ctx, rq.UserId)
+		switch {
+		case err == sql.ErrNoRows:
+			unshared = true
+		case err != nil:
+			return err
+		case previous.Hash != uploaded.Hash:
+			unshared = true
+			replaced = append(replaced, previous)
+		}
+
+		if err := s.Repo.SaveAvatar(ctx, uploaded); err != nil {
+			return err
+		}
+
+		if err := s.Repo.SaveEvent(ctx, events.NewUserUpdated(rq.UserId)); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.NewEntry(ctx, "UploadAvatar", rq.UserId, nil, nil, nil))
+	})
+	if err != nil {
+		if unshared || err == sql.ErrNoRows {
+			s.removeAvatars(ctx, uploaded)
+		}
+
+		if err == sql.ErrNoRows {
+			err = errors.NewUserNotFound()
+		} else {
+			level.Error(s.Logger).Log("error", err)
+			err = dataBaseError(err)
+		}
+		s.recordFailure(ctx, audit.NewEntry(ctx, "UploadAvatar", rq.UserId, nil, nil, err))
+		return entities.UploadAvatarResponse{}, err
+	}
+
+	s.removeAvatars(ctx, replaced...)
+
+	response := entities.UploadAvatarResponse{
+		ContentType: uploaded.ContentType,
+		Etag:        `"` + uploaded.Hash + `"`,
+		UpdatedAt:   uploaded.UpdatedAt,
+	}
+	for _, size := range uploaded.Sizes {
+		response.Sizes = append(response.Sizes, uint32(size))
+	}
+	return response, nil
+}
+
+// GetAvatar returns the smallest thumbnail of the avatar of a user at
+// least rq.Size pixels a side, the largest one when there is none.
+func (s *service) GetAvatar(ctx context.Context, rq entities.GetAvatarRequest) (entities.GetAvatarResponse, error) {
+	s.Logger.Log(s.Logger, "request", "get avatar", "received")
+
+	if s.Avatars.Blobs == nil {
+		return entities.GetAvatarResponse{}, errAvatarsNotConfigured
+	}
+	if rq.UserId == "" {
+		return entities.GetAvatarResponse{}, errors.NewInvalidField("user_id", "is required")
+	}
+
+	stored, err := s.Repo.GetAvatar(ctx, rq.UserId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.GetAvatarResponse{}, errors.NewResourceNotFound("avatar")
+		}
+		level.Error(s.Logger).Log("error", err)
+		return entities.GetAvatarResponse{}, dataBaseError(err)
+	}
+
+	size := thumbnailSize(stored.Sizes, int(rq.Size))
+	response := entities.GetAvatarResponse{
+		ContentType: stored.ContentType,
+		Size:        uint32(size),
+		Etag:        `"` + stored.Hash + "-" + strconv.Itoa(size) + `"`,
+		UpdatedAt:   stored.UpdatedAt,
+	}
+	if rq.IfNoneMatch != "" && !etag.NoneMatch(rq.IfNoneMatch, response.Etag) {
+		response.NotModified = true
+		return response, nil
+	}
+
+	response.Image, err = s.Avatars.Blobs.Get(ctx, avatar.Key(tenant.FromContext(ctx), rq.UserId, stored.Hash, size))
+	if err != nil {
+		level.Error(s.Logger).Log("msg", "reading avatar failed", "user_id", rq.UserId, "error", err)
+		if stderrors.Is(err, avatar.ErrNotFound) {
+			return entities.GetAvatarResponse{}, errors.NewResourceNotFound("avatar")
+		}
+		return entities.GetAvatarResponse{}, status.Error(codes.Unavailable, "avatar storage is unavailable, try again later")
+	}
+
+	return response, nil
+}
+
+// avatarsOf returns the avatars among userIds whose thumbnails have to be
+// removed once the users are deleted, none when avatars aren't stored.
+func (s *service) avatarsOf(ctx context.Context, userIds ...string) ([]entities.Avatar, error) {
+	if s.Avatars.Blobs == nil {
+		return nil, nil
+	}
+	return s.Repo.GetAvatars(ctx, userIds)
+}
+
+// removeAvatars deletes the thumbnails of avatars that are no longer
+// saved. The write they followed is done, failures are only logged.
+func (s *service) removeAvatars(ctx context.Context, avatars ...entities.Avatar) {
+	tenantId := tenant.FromContext(ctx)
+	for _, a := range avatars {
+		for _, size := range a.Sizes {
+			if err := s.Avatars.Blobs.Delete(ctx, avatar.Key(tenantId, a.UserId, a.Hash, size)); err != nil {
+				level.Error(s.Logger).Log("msg", "removing avatar failed", "user_id", a.UserId, "error", err)
+			}
+		}
+	}
+}
+
+func thumbnailSize(sizes []int, wanted int) int {
+	largest, fit := 0, 0
+	for _, size := range sizes {
+		if size > largest {
+			largest = size
+		}
+		if wanted > 0 && size >= wanted && (fit == 0 || size < fit) {
+			fit = size
+		}
+	}
+	if fit == 0 {
+		return largest
+	}
+	return fit
+}
@@ -0,0 +1,285 @@
+package user_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"image"
+	"image/png"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/avatar"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	service "github.com/timoteoBone/microservice-project/grpcService/pkg/user"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// blobsStub keeps blobs in memory.
+type blobsStub struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func newBlobsStub(keys ...string) *blobsStub {
+	stub := &blobsStub{blobs: map[string][]byte{}}
+	for _, key := range keys {
+		stub.blobs[key] = []byte(key)
+	}
+	return stub
+}
+
+func (s *blobsStub) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = data
+	return nil
+}
+
+func (s *blobsStub) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, avatar.ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *blobsStub) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *blobsStub) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.blobs))
+	for key := range s.blobs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func testPicture(t *testing.T) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 80, 60))))
+	return buf.Bytes()
+}
+
+func TestServiceUploadAvatar(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	picture := testPicture(t)
+	processed, err := avatar.Process(picture, []int{32, 64})
+	assert.NoError(t, err)
+
+	previous := entities.Avatar{UserId: "user-1", Hash: "old", ContentType: "image/jpeg", Sizes: []int{32, 64}}
+	previousKeys := []string{avatar.Key(tenant.Default, "user-1", "old", 32), avatar.Key(tenant.Default, "user-1", "old", 64)}
+	uploadedKeys := []string{avatar.Key(tenant.Default, "user-1", processed.Hash, 32), avatar.Key(tenant.Default, "user-1", processed.Hash, 64)}
+
+	testCases := []struct {
+		Name           string
+		Picture        []byte
+		Blobs          *blobsStub
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, res entities.UploadAvatarResponse, err error, blobs *blobsStub)
+	}{
+		{
+			Name:    "Replaces The Avatar",
+			Picture: picture,
+			Blobs:   newBlobsStub(previousKeys...),
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(2), nil)
+				repo.On("GetAvatar", mock.Anything, "user-1").Return(previous, nil)
+				repo.On("SaveAvatar", mock.Anything, mock.MatchedBy(func(a entities.Avatar) bool {
+					return a.UserId == "user-1" && a.Hash == processed.Hash && a.ContentType == "image/png" && len(a.Sizes) == 2
+				})).Return(nil)
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.UploadAvatarResponse, err error, blobs *blobsStub) {
+				assert.NoError(t, err)
+				assert.Equal(t, []uint32{32, 64}, res.Sizes)
+				assert.Equal(t, `"`+processed.Hash+`"`, res.Etag)
+				assert.Equal(t, uploadedKeys, blobs.keys())
+			},
+		},
+		{
+			Name:      "Not An Image",
+			Picture:   []byte("%PDF-1.4 a document"),
+			Blobs:     newBlobsStub(),
+			buildMock: func(repo *utils.RepoSitoryMock) {},
+			assertResponse: func(t *testing.T, res entities.UploadAvatarResponse, err error, blobs *blobsStub) {
+				assert.IsType(t, myErr.UnsupportedMediaType{}, err)
+				assert.Empty(t, blobs.keys())
+			},
+		},
+		{
+			Name:      "Too Large",
+			Picture:   append(picture, make([]byte, 4096)...),
+			Blobs:     newBlobsStub(),
+			buildMock: func(repo *utils.RepoSitoryMock) {},
+			assertResponse: func(t *testing.T, res entities.UploadAvatarResponse, err error, blobs *blobsStub) {
+				assert.IsType(t, myErr.PayloadTooLarge{}, err)
+			},
+		},
+		{
+			Name:    "User Not Found",
+			Picture: picture,
+			Blobs:   newBlobsStub(),
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(0), sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.UploadAvatarResponse, err error, blobs *blobsStub) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+				assert.Empty(t, blobs.keys())
+			},
+		},
+		{
+			Name:    "Same Picture Fails To Save",
+			Picture: picture,
+			Blobs:   newBlobsStub(uploadedKeys...),
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(2), nil)
+				repo.On("GetAvatar", mock.Anything, "user-1").Return(entities.Avatar{UserId: "user-1", Hash: processed.Hash, Sizes: []int{32, 64}}, nil)
+				repo.On("SaveAvatar", mock.Anything, mock.Anything).Return(sql.ErrConnDone)
+			},
+			assertResponse: func(t *testing.T, res entities.UploadAvatarResponse, err error, blobs *blobsStub) {
+				assert.IsType(t, myErr.DataBaseUnavailable{}, err)
+				// The thumbnails are the ones of the avatar still saved.
+				assert.Equal(t, uploadedKeys, blobs.keys())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Avatars = service.AvatarConfig{Blobs: tc.Blobs, MaxBytes: int64(len(picture)) + 1024, Sizes: []int{32, 64}}
+			res, err := srvc.UploadAvatar(context.Background(), entities.UploadAvatarRequest{UserId: "user-1"}, bytes.NewReader(tc.Picture))
+			tc.assertResponse(t, res, err, tc.Blobs)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceGetAvatar(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	stored := entities.Avatar{UserId: "user-1", Hash: "abc", ContentType: "image/png", Sizes: []int{32, 64, 128}}
+	blobs := newBlobsStub(avatar.Key(tenant.Default, "user-1", "abc", 64), avatar.Key(tenant.Default, "user-1", "abc", 128))
+
+	testCases := []struct {
+		Name           string
+		Request        entities.GetAvatarRequest
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, res entities.GetAvatarResponse, err error)
+	}{
+		{
+			Name:    "Smallest Size That Fits",
+			Request: entities.GetAvatarRequest{UserId: "user-1", Size: 40},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("GetAvatar", mock.Anything, "user-1").Return(stored, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.GetAvatarResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint32(64), res.Size)
+				assert.Equal(t, `"abc-64"`, res.Etag)
+				assert.Equal(t, []byte(avatar.Key(tenant.Default, "user-1", "abc", 64)), res.Image)
+			},
+		},
+		{
+			Name:    "Largest Size",
+			Request: entities.GetAvatarRequest{UserId: "user-1", Size: 1000},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("GetAvatar", mock.Anything, "user-1").Return(stored, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.GetAvatarResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint32(128), res.Size)
+			},
+		},
+		{
+			Name:    "Not Modified",
+			Request: entities.GetAvatarRequest{UserId: "user-1", IfNoneMatch: `"abc-128"`},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("GetAvatar", mock.Anything, "user-1").Return(stored, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.GetAvatarResponse, err error) {
+				assert.NoError(t, err)
+				assert.True(t, res.NotModified)
+				assert.Empty(t, res.Image)
+			},
+		},
+		{
+			Name:    "Thumbnail Missing From The Store",
+			Request: entities.GetAvatarRequest{UserId: "user-1", Size: 32},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("GetAvatar", mock.Anything, "user-1").Return(stored, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.GetAvatarResponse, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+		{
+			Name:    "No Avatar",
+			Request: entities.GetAvatarRequest{UserId: "user-2"},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("GetAvatar", mock.Anything, "user-2").Return(entities.Avatar{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.GetAvatarResponse, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Avatars.Blobs = blobs
+			res, err := srvc.GetAvatar(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+
+	_, err := service.NewService(logger, new(utils.RepoSitoryMock)).GetAvatar(context.Background(), entities.GetAvatarRequest{UserId: "user-1"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestServiceDeleteUserRemovesAvatar(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	stored := entities.Avatar{UserId: "user-1", Hash: "abc", Sizes: []int{32}}
+	blobs := newBlobsStub(avatar.Key(tenant.Default, "user-1", "abc", 32), avatar.Key(tenant.Default, "user-2", "def", 32))
+
+	repo := new(utils.RepoSitoryMock)
+	repo.On("GetAvatars", mock.Anything, []string{"user-1"}).Return([]entities.Avatar{stored}, nil)
+	repo.On("DeleteUser", mock.Anything, "user-1").Return(nil)
+	repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil)
+
+	srvc := service.NewService(logger, repo)
+	srvc.Avatars.Blobs = blobs
+	_, err := srvc.DeleteUser(context.Background(), entities.DeleteUserRequest{UserId: "user-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{avatar.Key(tenant.Default, "user-2", "def", 32)}, blobs.keys())
+	assert.False(t, strings.Contains(strings.Join(blobs.keys(), ","), "user-1"))
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,327 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/attributes"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/etag"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	mapper "github.com/timoteoBone/microservice-project/grpcService/pkg/mapper"
+)
+
+// MaxBatchSize caps the items of a single batch request.
+const MaxBatchSize = 1000
+
+var (
+	errBatchAborted  = status.New(codes.Aborted, "not applied, another item of the batch failed")
+	errBatchRollback = stderrors.New("batch rolled back")
+)
+
+// BatchCreateUsers creates the users of rq in one go. Without AllOrNothing
+// every user that can be created is, and the failing ones report why.
+func (s *service) BatchCreateUsers(ctx context.Context, rq entities.BatchCreateUsersRequest) (entities.BatchCreateUsersResponse, error) {
+	s.Logger.Log(s.Logger, "request", "batch create users", "received")
+
+	if err := checkBatchSize("users", len(rq.Users)); err != nil {
+		return entities.BatchCreateUsersResponse{}, err
+	}
+
+	schema, err := s.attributeSchema(ctx)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.BatchCreateUsersResponse{}, dataBaseError(err)
+	}
+
+	results := make([]entities.BatchCreateUserResult, len(rq.Users))
+	users := make([]entities.User, 0, len(rq.Users))
+	pending := make([]int, 0, len(rq.Users))
+
+	// The database would reject the second of two items with the same email
+	// along with the whole statement, so it is caught here first.
+	emails := make(map[string]bool, len(rq.Users))
+	for i, userReq := range rq.Users {
+		if err := schema.Validate(userReq.Attributes); err != nil {
+			results[i].Error = itemStatus(err)
+			continue
+		}
+
+		email := strings.ToLower(strings.TrimSpace(userReq.Email))
+		if emails[email] {
+			results[i].Error = itemStatus(errors.NewUserAlreadyExists())
+			continue
+		}
+		emails[email] = true
+
+		user := mapper.CreateUserRequestToUser(userReq)
+		user.Id = s.Ids.NewId()
+		users = append(users, user)
+		pending = append(pending, i)
+	}
+
+	if rq.AllOrNothing && len(pending) < len(rq.Users) {
+		return entities.BatchCreateUsersResponse{Results: abortRemaining(results, pending)}, nil
+	}
+
+	if len(users) == 0 {
+		return entities.BatchCreateUsersResponse{Results: results}, nil
+	}
+
+	rejected, err := s.createUsers(ctx, "BatchCreateUsers", users, schema, rq.AllOrNothing, nil)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.BatchCreateUsersResponse{}, dataBaseError(err)
+	}
+
+	failures := make([]entities.AuditEntry, 0, len(rejected))
+	for j, i := range pending {
+		if err, ok := rejected[j]; ok {
+			results[i].Error = itemStatus(err)
+			failures = append(failures, audit.NewEntry(ctx, "BatchCreateUsers", "", nil, &users[j], err))
+		}
+	}
+	s.recordFailure(ctx, failures...)
+	if rq.AllOrNothing && len(rejected) > 0 {
+		return entities.BatchCreateUsersResponse{Results: abortRemaining(results, pending)}, nil
+	}
+
+	for j, i := range pending {
+		if results[i].Error == nil {
+			results[i].UserId = users[j].Id
+		}
+	}
+
+	return entities.BatchCreateUsersResponse{Results: results}, nil
+}
+
+// createUsers inserts users together with their events, audit entries,
+// recorded as operation, and the attributes schema indexes, in one
+// transaction. A user the database rejects is
+// left out and the rest are tried again, unless stopOnError is set. then,
+// when not nil, runs last in the transaction with the number of users
+// created. The users left out are returned by index with the reason they
+// were rejected.
+func (s *service) createUsers(ctx context.Context, operation string, users []entities.User, schema *attributes.Schema, stopOnError bool, then func(ctx context.Context, created int) error) (map[int]error, error) {
+	rejected := make(map[int]error)
+	remaining := make([]int, len(users))
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	for {
+		batch := make([]entities.User, len(remaining))
+		for j, i := range remaining {
+			batch[j] = users[i]
+		}
+
+		err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+			if len(batch) > 0 {
+				if err := s.Repo.CreateUsers(ctx, batch); err != nil {
+					return err
+				}
+			}
+
+			entries := make([]entities.AuditEntry, len(batch))
+			for j, user := range batch {
+				if index := schema.Index(user.Attributes); len(index) > 0 {
+					if err := s.Repo.SaveAttributeIndex(ctx, user.Id, index); err != nil {
+						return err
+					}
+				}
+				if err := s.Repo.SaveEvent(ctx, events.NewUserCreated(user.Id)); err != nil {
+					return err
+				}
+				entries[j] = audit.NewEntry(ctx, operation, user.Id, nil, &batch[j], nil)
+			}
+			if err := s.record(ctx, entries...); err != nil {
+				return err
+			}
+
+			if then != nil {
+				return then(ctx, len(batch))
+			}
+			return nil
+		})
+
+		var itemErr *BatchItemError
+		if err == nil || !stderrors.As(err, &itemErr) {
+			return rejected, err
+		}
+
+		rejected[remaining[itemErr.Index]] = createError(itemErr.Err)
+		if stopOnError {
+			return rejected, nil
+		}
+
+		// The rest of the batch was rolled back with the rejected user.
+		remaining = append(remaining[:itemErr.Index], remaining[itemErr.Index+1:]...)
+	}
+}
+
+func (s *service) BatchGetUsers(ctx context.Context, rq entities.BatchGetUsersRequest) (entities.BatchGetUsersResponse, error) {
+	s.Logger.Log(s.Logger, "request", "batch get users", "received")
+
+	if err := checkBatchSize("user_ids", len(rq.UserIds)); err != nil {
+		return entities.BatchGetUsersResponse{}, err
+	}
+
+	users, err := s.Repo.GetUsers(ctx, uniqueIds(rq.UserIds))
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.BatchGetUsersResponse{}, dataBaseError(err)
+	}
+
+	results := make([]entities.BatchGetUserResult, len(rq.UserIds))
+	for i, userId := range rq.UserIds {
+		user, ok := users[userId]
+		if !ok {
+			results[i].Error = itemStatus(errors.NewUserNotFound())
+			continue
+		}
+
+		results[i].User = entities.GetUserResponse{
+			Id:         userId,
+			Name:       user.Name,
+			Age:        user.Age,
+			Etag:       etag.Format(user.Version),
+			Attributes: user.Attributes,
+		}
+	}
+
+	return entities.BatchGetUsersResponse{Results: results}, nil
+}
+
+// BatchDeleteUsers deletes the users of rq. Without AllOrNothing missing
+// users are reported as not found and the rest are deleted.
+func (s *service) BatchDeleteUsers(ctx context.Context, rq entities.BatchDeleteUsersRequest) (entities.BatchDeleteUsersResponse, error) {
+	s.Logger.Log(s.Logger, "request", "batch delete users", "received")
+
+	if err := checkBatchSize("user_ids", len(rq.UserIds)); err != nil {
+		return entities.BatchDeleteUsersResponse{}, err
+	}
+
+	userIds := uniqueIds(rq.UserIds)
+	deleted := make(map[string]bool, len(userIds))
+
+	var avatars []entities.Avatar
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		// The audit log keeps what the users looked like before.
+		var before map[string]entities.User
+		if s.Audit != nil {
+			users, err := s.Repo.GetUsers(ctx, userIds)
+			if err != nil {
+				return err
+			}
+			before = users
+		}
+
+		var err error
+		if avatars, err = s.avatarsOf(ctx, userIds...); err != nil {
+			return err
+		}
+
+		ids, err := s.Repo.DeleteUsers(ctx, userIds)
+		if err != nil {
+			return err
+		}
+
+		for _, userId := range ids {
+			deleted[userId] = true
+		}
+		if rq.AllOrNothing && len(ids) < len(userIds) {
+			return errBatchRollback
+		}
+
+		entries := make([]entities.AuditEntry, len(ids))
+		for j, userId := range ids {
+			if err := s.Repo.SaveEvent(ctx, events.NewUserDeleted(userId)); err != nil {
+				return err
+			}
+			var user *entities.User
+			if u, ok := before[userId]; ok {
+				user = &u
+			}
+			entries[j] = audit.NewEntry(ctx, "BatchDeleteUsers", userId, user, nil, nil)
+		}
+		return s.record(ctx, entries...)
+	})
+	if err != nil && err != errBatchRollback {
+		level.Error(s.Logger).Log("error", err)
+		return entities.BatchDeleteUsersResponse{}, dataBaseError(err)
+	}
+	if err == nil {
+		s.removeAvatars(ctx, avatars...)
+	}
+
+	results := make([]entities.BatchDeleteUserResult, len(rq.UserIds))
+	failures := make([]entities.AuditEntry, 0)
+	for i, userId := range rq.UserIds {
+		results[i].UserId = userId
+		switch {
+		case !deleted[userId]:
+			results[i].Error = itemStatus(errors.NewUserNotFound())
+			failures = append(failures, audit.NewEntry(ctx, "BatchDeleteUsers", userId, nil, nil, errors.NewUserNotFound()))
+		case err == errBatchRollback:
+			results[i].Error = &entities.Status{Code: int32(errBatchAborted.Code()), Message: errBatchAborted.Message()}
+			failures = append(failures, audit.NewEntry(ctx, "BatchDeleteUsers", userId, nil, nil, errBatchAborted.Err()))
+		}
+	}
+	s.recordFailure(ctx, failures...)
+
+	return entities.BatchDeleteUsersResponse{Results: results}, nil
+}
+
+func checkBatchSize(field string, n int) error {
+	if n == 0 {
+		return errors.NewInvalidField(field, "must not be empty")
+	}
+	if n > MaxBatchSize {
+		return errors.NewInvalidField(field, fmt.Sprintf("more than %d items", MaxBatchSize))
+	}
+	return nil
+}
+
+// abortRemaining marks the pending items that didn't fail themselves as
+// aborted.
+func abortRemaining(results []entities.BatchCreateUserResult, pending []int) []entities.BatchCreateUserResult {
+	for _, i := range pending {
+		if results[i].Error == nil {
+			results[i].Error = &entities.Status{Code: int32(errBatchAborted.Code()), Message: errBatchAborted.Message()}
+		}
+	}
+	return results
+}
+
+func createError(err error) error {
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+		return errors.NewUserAlreadyExists()
+	}
+	return dataBaseError(err)
+}
+
+func itemStatus(err error) *entities.Status {
+	st := status.Convert(err)
+	return &entities.Status{Code: int32(st.Code()), Message: st.Message()}
+}
+
+func uniqueIds(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
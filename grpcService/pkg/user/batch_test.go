@@ -0,0 +1,143 @@
+package user_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	service "github.com/timoteoBone/microservice-project/grpcService/pkg/user"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+func TestServiceBatchCreateUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	requests := []entities.CreateUserRequest{
+		{Name: "Timo", Age: 19, Pass: "123", Email: "timoteo@globant.com"},
+		{Name: "Ana", Age: 21, Pass: "123", Email: "ana@globant.com"},
+		{Name: "Timo", Age: 19, Pass: "123", Email: " Timoteo@globant.com"},
+	}
+	duplicate := &service.BatchItemError{Index: 1, Err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}}
+
+	testCases := []struct {
+		Name           string
+		AllOrNothing   bool
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, res entities.BatchCreateUsersResponse, err error)
+	}{
+		{
+			Name: "Partial Success",
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("CreateUsers", mock.Anything, mock.MatchedBy(func(users []entities.User) bool { return len(users) == 2 })).Return(duplicate).Once()
+				repo.On("CreateUsers", mock.Anything, mock.MatchedBy(func(users []entities.User) bool { return len(users) == 1 })).Return(nil).Once()
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			assertResponse: func(t *testing.T, res entities.BatchCreateUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, res.Results, 3)
+				assert.NotEmpty(t, res.Results[0].UserId)
+				assert.Nil(t, res.Results[0].Error)
+				assert.Equal(t, int32(codes.AlreadyExists), res.Results[1].Error.Code)
+				assert.Equal(t, int32(codes.AlreadyExists), res.Results[2].Error.Code)
+			},
+		},
+		{
+			Name:         "All Or Nothing Aborts The Rest",
+			AllOrNothing: true,
+			buildMock:    func(repo *utils.RepoSitoryMock) {},
+			assertResponse: func(t *testing.T, res entities.BatchCreateUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, int32(codes.Aborted), res.Results[0].Error.Code)
+				assert.Equal(t, int32(codes.Aborted), res.Results[1].Error.Code)
+				assert.Equal(t, int32(codes.AlreadyExists), res.Results[2].Error.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			res, err := srvc.BatchCreateUsers(context.Background(), entities.BatchCreateUsersRequest{Users: requests, AllOrNothing: tc.AllOrNothing})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceBatchGetUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	repo := new(utils.RepoSitoryMock)
+	repo.On("GetUsers", mock.Anything, []string{"user-1", "user-2"}).
+		Return(map[string]entities.User{"user-1": {Id: "user-1", Name: "Timo", Age: 19}}, nil)
+
+	srvc := service.NewService(logger, repo)
+	res, err := srvc.BatchGetUsers(context.Background(), entities.BatchGetUsersRequest{UserIds: []string{"user-1", "user-2", "user-1"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Timo", res.Results[0].User.Name)
+	assert.Equal(t, int32(codes.NotFound), res.Results[1].Error.Code)
+	assert.Equal(t, "user-1", res.Results[2].User.Id)
+
+	_, err = srvc.BatchGetUsers(context.Background(), entities.BatchGetUsersRequest{UserIds: make([]string, service.MaxBatchSize+1)})
+	assert.IsType(t, myErr.InvalidField{}, err)
+}
+
+func TestServiceBatchDeleteUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		AllOrNothing   bool
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, res entities.BatchDeleteUsersResponse, err error)
+	}{
+		{
+			Name: "Partial Success",
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("DeleteUsers", mock.Anything, []string{"user-1", "user-2"}).Return([]string{"user-1"}, nil)
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			assertResponse: func(t *testing.T, res entities.BatchDeleteUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Nil(t, res.Results[0].Error)
+				assert.Equal(t, int32(codes.NotFound), res.Results[1].Error.Code)
+			},
+		},
+		{
+			Name:         "All Or Nothing Rolls Back",
+			AllOrNothing: true,
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("DeleteUsers", mock.Anything, []string{"user-1", "user-2"}).Return([]string{"user-1"}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.BatchDeleteUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, int32(codes.Aborted), res.Results[0].Error.Code)
+				assert.Equal(t, int32(codes.NotFound), res.Results[1].Error.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			res, err := srvc.BatchDeleteUsers(context.Background(), entities.BatchDeleteUsersRequest{UserIds: []string{"user-1", "user-2"}, AllOrNothing: tc.AllOrNothing})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,429 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc/codes"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/bulk"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const maxImportIdLength = 64
+
+var (
+	errDryRun      = stderrors.New("dry run")
+	errImportMoved = stderrors.New("import moved by another upload")
+)
+
+// BulkConfig tunes imports and exports.
+type BulkConfig struct {
+	// ChunkSize is how many rows of an import are committed together.
+	ChunkSize int
+	// ExportPageSize is how many users an export reads, and sends, at once.
+	ExportPageSize int
+	// MaxRowErrors caps the row errors an import reports, the ones past it
+	// are only counted.
+	MaxRowErrors int
+}
+
+func DefaultBulkConfig() BulkConfig {
+	return BulkConfig{
+		ChunkSize:      500,
+		ExportPageSize: 1000,
+		MaxRowErrors:   1000,
+	}
+}
+
+// importRow is a row read from an import waiting for its chunk to be
+// committed. Rows that failed already carry their error.
+type importRow struct {
+	row      uint64
+	user     entities.User
+	password string
+	field    string
+	err      *entities.Status
+}
+
+type importer struct {
+	*service
+	opts       entities.ImportOptions
+	source     *sourceReader
+	checkpoint entities.ImportCheckpoint
+	chunk      []importRow
+	// seen holds the emails and ids of a dry run, which rolls back every
+	// chunk and so can't count on the database to catch duplicates across
+	// chunks.
+	seen map[string]bool
+	res  entities.ImportUsersResponse
+}
+
+// sourceReader remembers the error the file was cut short with. Decoders
+// may still hand out the partial row they were reading when it happened,
+// which must not be committed.
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *sourceReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// ImportUsers creates the users read from body, committing them a chunk at
+// a time. Rows that can't be imported are reported and don't stop the
+// rest. Under an ImportId already seen the rows committed by the earlier
+// uploads are skipped, so a cut short upload is resumed by sending the same
+// file again. A dry run goes through every row and rolls each chunk back.
+func (s *service) ImportUsers(ctx context.Context, opts entities.ImportOptions, body io.Reader) (entities.ImportUsersResponse, error) {
+	s.Logger.Log(s.Logger, "request", "import users", "received")
+
+	if !bulk.Supported(opts.Format) {
+		return entities.ImportUsersResponse{}, errors.NewInvalidField("format", "must be csv or ndjson")
+	}
+	if len(opts.ImportId) > maxImportIdLength {
+		return entities.ImportUsersResponse{}, errors.NewInvalidField("import_id", fmt.Sprintf("longer than %d characters", maxImportIdLength))
+	}
+
+	// Imported users hold no attributes, a schema requiring some would turn
+	// every row away.
+	schema, err := s.attributeSchema(ctx)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ImportUsersResponse{}, dataBaseError(err)
+	}
+	if schema.Validate(nil) != nil {
+		return entities.ImportUsersResponse{}, errors.NewPreconditionFailed("the attribute schema of the tenant requires attributes an import can't set")
+	}
+
+	imp := &importer{
+		service: s,
+		opts:    opts,
+		source:  &sourceReader{r: body},
+		res:     entities.ImportUsersResponse{DryRun: opts.DryRun},
+	}
+
+	if opts.DryRun {
+		imp.seen = make(map[string]bool)
+	} else {
+		imp.res.ImportId = opts.ImportId
+		if imp.res.ImportId == "" {
+			imp.res.ImportId = s.Ids.NewId()
+		}
+
+		checkpoint, err := s.Repo.StartImport(ctx, imp.res.ImportId, opts.Format)
+		if err != nil {
+			level.Error(s.Logger).Log("error", err)
+			return entities.ImportUsersResponse{}, dataBaseError(err)
+		}
+		if checkpoint.Format != opts.Format {
+			return entities.ImportUsersResponse{}, errors.NewInvalidField("format", fmt.Sprintf("import %s is %s", checkpoint.ImportId, checkpoint.Format))
+		}
+		imp.checkpoint = checkpoint
+	}
+
+	decoder, err := bulk.NewDecoder(opts.Format, imp.source)
+	if err != nil {
+		return entities.ImportUsersResponse{}, importReadError(err)
+	}
+
+	for {
+		row, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var rowErr *bulk.RowError
+		if err != nil && !stderrors.As(err, &rowErr) {
+			var syntaxErr *bulk.SyntaxError
+			if stderrors.As(err, &syntaxErr) {
+				// Keep the rows before the broken one, a corrected file sent
+				// again carries on from there.
+				if err := imp.flush(ctx); err != nil {
+					return entities.ImportUsersResponse{}, err
+				}
+			}
+			return entities.ImportUsersResponse{}, importReadError(err)
+		}
+
+		imp.res.Rows++
+		if imp.res.Rows <= imp.checkpoint.Rows {
+			imp.res.Skipped++
+			continue
+		}
+
+		item := importRow{row: imp.res.Rows}
+		if rowErr != nil {
+			item.field, item.err = rowErr.Field, invalidRow(rowErr.Err.Error())
+		} else {
+			item.user, item.password, item.field, item.err = validateImportRow(row)
+			if item.err == nil && item.user.Id == "" {
+				item.user.Id = s.Ids.NewId()
+			}
+		}
+		imp.chunk = append(imp.chunk, item)
+
+		if len(imp.chunk) == s.Bulk.ChunkSize {
+			if err := imp.flush(ctx); err != nil {
+				return entities.ImportUsersResponse{}, err
+			}
+		}
+	}
+
+	if err := imp.flush(ctx); err != nil {
+		return entities.ImportUsersResponse{}, err
+	}
+
+	return imp.res, nil
+}
+
+// flush commits the rows of the chunk that are fine along with the import
+// checkpoint, and reports the rest.
+func (imp *importer) flush(ctx context.Context) error {
+	if len(imp.chunk) == 0 {
+		return nil
+	}
+	if imp.source.err != nil {
+		return imp.source.err
+	}
+
+	if err := hashImportPasswords(imp.chunk); err != nil {
+		level.Error(imp.Logger).Log("error", err)
+		return errors.NewDataBaseError()
+	}
+
+	// A duplicate inside a single statement is blamed by the database on
+	// the first of the two rows, so they are caught here first.
+	seen := imp.seen
+	if seen == nil {
+		seen = make(map[string]bool, 2*len(imp.chunk))
+	}
+
+	var (
+		users   []entities.User
+		indexes []int
+	)
+	for i := range imp.chunk {
+		item := &imp.chunk[i]
+		if item.err != nil {
+			continue
+		}
+
+		email := "email:" + strings.ToLower(item.user.Email)
+		id := "id:" + item.user.Id
+		if seen[email] || seen[id] {
+			item.field = "email"
+			if seen[id] {
+				item.field = "id"
+			}
+			item.err = itemStatus(errors.NewUserAlreadyExists())
+			continue
+		}
+		seen[email], seen[id] = true, true
+
+		users = append(users, item.user)
+		indexes = append(indexes, i)
+	}
+
+	last := imp.chunk[len(imp.chunk)-1].row
+	next := imp.checkpoint
+
+	rejected, err := imp.createUsers(ctx, "ImportUsers", users, nil, false, func(ctx context.Context, created int) error {
+		if imp.opts.DryRun {
+			return errDryRun
+		}
+
+		next = imp.checkpoint
+		next.Rows = last
+		next.Imported += uint64(created)
+		next.Failed += uint64(len(imp.chunk) - created)
+
+		moved, err := imp.Repo.AdvanceImport(ctx, next, imp.checkpoint.Rows)
+		if err != nil {
+			return err
+		}
+		if !moved {
+			return errImportMoved
+		}
+		return nil
+	})
+	switch {
+	case err == errImportMoved:
+		return errors.NewPreconditionFailed(fmt.Sprintf("import %s is being uploaded by another request", imp.res.ImportId))
+	case err != nil && err != errDryRun:
+		level.Error(imp.Logger).Log("error", err)
+		return dataBaseError(err)
+	}
+
+	for j, i := range indexes {
+		if err, ok := rejected[j]; ok {
+			imp.chunk[i].err = itemStatus(err)
+		}
+	}
+
+	for _, item := range imp.chunk {
+		if item.err == nil {
+			imp.res.Imported++
+			continue
+		}
+
+		imp.res.Failed++
+		if len(imp.res.Errors) == imp.Bulk.MaxRowErrors {
+			imp.res.ErrorsTruncated = true
+			continue
+		}
+		imp.res.Errors = append(imp.res.Errors, entities.ImportRowError{Row: item.row, Field: item.field, Error: *item.err})
+	}
+
+	imp.checkpoint = next
+	imp.chunk = imp.chunk[:0]
+	return nil
+}
+
+// ExportUsers streams every user, ordered by id and starting after
+// rq.AfterId, through send a page at a time. The pages are read as the
+// export goes, users created or deleted meanwhile may or may not be in it.
+func (s *service) ExportUsers(ctx context.Context, rq entities.ExportUsersRequest, send func(entities.ExportChunk) error) error {
+	s.Logger.Log(s.Logger, "request", "export users", "received")
+
+	if !bulk.Supported(rq.Format) {
+		return errors.NewInvalidField("format", "must be csv or ndjson")
+	}
+
+	var buf bytes.Buffer
+	encoder, err := bulk.NewEncoder(rq.Format, &buf, rq.AfterId == "")
+	if err != nil {
+		return err
+	}
+
+	after := rq.AfterId
+	for {
+		users, err := s.Repo.ListUsers(ctx, after, s.Bulk.ExportPageSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			level.Error(s.Logger).Log("error", err)
+			return dataBaseError(err)
+		}
+
+		for _, user := range users {
+			row := bulk.Row{Id: user.Id, Name: user.Name, Age: user.Age, Email: user.Email}
+			if err := encoder.Encode(row); err != nil {
+				return err
+			}
+			after = user.Id
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+
+		if buf.Len() > 0 {
+			if err := send(entities.ExportChunk{Data: append([]byte(nil), buf.Bytes()...), LastId: after}); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+
+		if len(users) < s.Bulk.ExportPageSize {
+			return nil
+		}
+	}
+}
+
+func validateImportRow(row bulk.Row) (entities.User, string, string, *entities.Status) {
+	switch {
+	case row.Id != "" && !ids.Valid(row.Id):
+		return entities.User{}, "", "id", invalidRow("is not a UUID, ULID or Snowflake id")
+	case row.Name == "":
+		return entities.User{}, "", "name", invalidRow("is required")
+	case !strings.Contains(row.Email, "@"):
+		return entities.User{}, "", "email", invalidRow("is not an email address")
+	case row.Age < 1:
+		return entities.User{}, "", "age", invalidRow("must be at least 1")
+	case row.Password == "" && row.PasswordHash == "":
+		return entities.User{}, "", "password", invalidRow("password or password_hash is required")
+	case row.Password != "" && row.PasswordHash != "":
+		return entities.User{}, "", "password_hash", invalidRow("can't be set together with password")
+	}
+
+	if row.PasswordHash != "" {
+		if err := utils.ValidatePasswordHash(row.PasswordHash); err != nil {
+			return entities.User{}, "", "password_hash", invalidRow(err.Error())
+		}
+	}
+
+	user := entities.User{
+		Id:    row.Id,
+		Name:  row.Name,
+		Age:   row.Age,
+		Email: row.Email,
+		Pass:  row.PasswordHash,
+	}
+	return user, row.Password, "", nil
+}
+
+// hashImportPasswords hashes the plaintext passwords of the chunk, spread
+// over the available CPUs.
+func hashImportPasswords(chunk []importRow) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i := range chunk {
+		if chunk[i].err != nil || chunk[i].password == "" {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(item *importRow) {
+			defer func() { <-sem; wg.Done() }()
+
+			hash, err := utils.HashPassword(item.password)
+			if err != nil {
+				mu.Lock()
+				firstErr = err
+				mu.Unlock()
+				return
+			}
+			item.user.Pass, item.password = hash, ""
+		}(&chunk[i])
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+func importReadError(err error) error {
+	var syntaxErr *bulk.SyntaxError
+	if stderrors.As(err, &syntaxErr) {
+		return errors.NewInvalidField("file", syntaxErr.Error())
+	}
+	if err == bulk.ErrUnsupportedFormat {
+		return errors.NewInvalidField("format", "must be csv or ndjson")
+	}
+	return err
+}
+
+func invalidRow(message string) *entities.Status {
+	return &entities.Status{Code: int32(codes.InvalidArgument), Message: message}
+}
@@ -0,0 +1,243 @@
+package user_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
+	service "github.com/timoteoBone/microservice-project/grpcService/pkg/user"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const bcryptHash = "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+
+func TestServiceImportUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	file := strings.Join([]string{
+		"email,name,age,password_hash,password",
+		"timoteo@globant.com,Timo,19," + bcryptHash + ",",
+		"ana@globant.com,Ana,0,,secret",
+		"luz@globant.com,Luz,30,,secret",
+		"LUZ@globant.com,Luz,30,,secret",
+		"sol@globant.com,Sol,25,$1$salt$hash,",
+	}, "\n")
+
+	checkpoint := func(rows, imported, failed uint64) entities.ImportCheckpoint {
+		return entities.ImportCheckpoint{ImportId: "import-1", Format: "csv", Rows: rows, Imported: imported, Failed: failed}
+	}
+
+	testCases := []struct {
+		Name           string
+		Options        entities.ImportOptions
+		File           string
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, res entities.ImportUsersResponse, err error)
+	}{
+		{
+			Name:    "Rows Are Committed A Chunk At A Time",
+			Options: entities.ImportOptions{Format: "csv", ImportId: "import-1"},
+			File:    file,
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("StartImport", mock.Anything, "import-1", "csv").Return(checkpoint(0, 0, 0), nil)
+				repo.On("CreateUsers", mock.Anything, mock.MatchedBy(func(users []entities.User) bool {
+					return len(users) == 1 && users[0].Pass == bcryptHash
+				})).Return(nil).Once()
+				repo.On("CreateUsers", mock.Anything, mock.MatchedBy(func(users []entities.User) bool {
+					return len(users) == 1 && strings.HasPrefix(users[0].Pass, "$2a$") && users[0].Pass != bcryptHash
+				})).Return(nil).Once()
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil).Twice()
+				repo.On("AdvanceImport", mock.Anything, checkpoint(2, 1, 1), uint64(0)).Return(true, nil).Once()
+				repo.On("AdvanceImport", mock.Anything, checkpoint(4, 2, 2), uint64(2)).Return(true, nil).Once()
+				repo.On("AdvanceImport", mock.Anything, checkpoint(5, 2, 3), uint64(4)).Return(true, nil).Once()
+			},
+			assertResponse: func(t *testing.T, res entities.ImportUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "import-1", res.ImportId)
+				assert.Equal(t, uint64(5), res.Rows)
+				assert.Equal(t, uint64(2), res.Imported)
+				assert.Equal(t, uint64(3), res.Failed)
+				assert.Equal(t, []entities.ImportRowError{
+					{Row: 2, Field: "age", Error: entities.Status{Code: int32(codes.InvalidArgument), Message: "must be at least 1"}},
+					{Row: 4, Field: "email", Error: entities.Status{Code: int32(codes.AlreadyExists), Message: myErr.NewUserAlreadyExists().Error()}},
+					{Row: 5, Field: "password_hash", Error: entities.Status{Code: int32(codes.InvalidArgument), Message: utils.ErrUnsupportedHash.Error()}},
+				}, res.Errors)
+			},
+		},
+		{
+			Name:    "Resumed Import Skips Committed Rows",
+			Options: entities.ImportOptions{Format: "csv", ImportId: "import-1"},
+			File:    file,
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("StartImport", mock.Anything, "import-1", "csv").Return(checkpoint(4, 2, 2), nil)
+				repo.On("AdvanceImport", mock.Anything, checkpoint(5, 2, 3), uint64(4)).Return(true, nil).Once()
+			},
+			assertResponse: func(t *testing.T, res entities.ImportUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint64(5), res.Rows)
+				assert.Equal(t, uint64(4), res.Skipped)
+				assert.Equal(t, uint64(1), res.Failed)
+				assert.Len(t, res.Errors, 1)
+			},
+		},
+		{
+			Name:    "Dry Run Keeps Nothing",
+			Options: entities.ImportOptions{Format: "csv", ImportId: "import-1", DryRun: true},
+			File:    file,
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("CreateUsers", mock.Anything, mock.Anything).Return(nil).Twice()
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil).Twice()
+			},
+			assertResponse: func(t *testing.T, res entities.ImportUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.True(t, res.DryRun)
+				assert.Empty(t, res.ImportId)
+				assert.Equal(t, uint64(2), res.Imported)
+				assert.Equal(t, uint64(3), res.Failed)
+			},
+		},
+		{
+			Name:    "Import Moved By Another Upload",
+			Options: entities.ImportOptions{Format: "csv", ImportId: "import-1"},
+			File:    file,
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("StartImport", mock.Anything, "import-1", "csv").Return(checkpoint(0, 0, 0), nil)
+				repo.On("CreateUsers", mock.Anything, mock.Anything).Return(nil).Once()
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil).Once()
+				repo.On("AdvanceImport", mock.Anything, checkpoint(2, 1, 1), uint64(0)).Return(false, nil).Once()
+			},
+			assertResponse: func(t *testing.T, res entities.ImportUsersResponse, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name:    "Ids Are Kept Or Generated",
+			Options: entities.ImportOptions{Format: "csv", DryRun: true},
+			File: strings.Join([]string{
+				"id,email,name,age,password_hash",
+				"01ARZ3NDEKTSV4RRFFQ69G5FAV,timoteo@globant.com,Timo,19," + bcryptHash,
+				",ana@globant.com,Ana,21," + bcryptHash,
+				"user-1,luz@globant.com,Luz,30," + bcryptHash,
+			}, "\n"),
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("CreateUsers", mock.Anything, mock.MatchedBy(func(users []entities.User) bool {
+					return len(users) == 2 && users[0].Id == "01ARZ3NDEKTSV4RRFFQ69G5FAV" && ids.Valid(users[1].Id)
+				})).Return(nil).Once()
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil).Twice()
+			},
+			assertResponse: func(t *testing.T, res entities.ImportUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint64(2), res.Imported)
+				assert.Equal(t, []entities.ImportRowError{
+					{Row: 3, Field: "id", Error: entities.Status{Code: int32(codes.InvalidArgument), Message: "is not a UUID, ULID or Snowflake id"}},
+				}, res.Errors)
+			},
+		},
+		{
+			Name:    "Unknown Column",
+			Options: entities.ImportOptions{Format: "csv", DryRun: true},
+			File:    "email,name,age,password,nickname\n",
+			buildMock: func(repo *utils.RepoSitoryMock) {
+			},
+			assertResponse: func(t *testing.T, res entities.ImportUsersResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:      "Unsupported Format",
+			Options:   entities.ImportOptions{Format: "xlsx"},
+			File:      file,
+			buildMock: func(repo *utils.RepoSitoryMock) {},
+			assertResponse: func(t *testing.T, res entities.ImportUsersResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Bulk.ChunkSize = 2
+			res, err := srvc.ImportUsers(context.Background(), tc.Options, strings.NewReader(tc.File))
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceExportUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Request        entities.ExportUsersRequest
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, chunks []entities.ExportChunk, err error)
+	}{
+		{
+			Name:    "Export Pages Through Users",
+			Request: entities.ExportUsersRequest{Format: "csv"},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("ListUsers", mock.Anything, "", 2).Return([]entities.User{
+					{Id: "a", Name: "Timo", Age: 19, Email: "timoteo@globant.com", Pass: bcryptHash},
+					{Id: "b", Name: "Ana, Maria", Age: 21, Email: "ana@globant.com"},
+				}, nil)
+				repo.On("ListUsers", mock.Anything, "b", 2).Return([]entities.User{
+					{Id: "c", Name: "Luz", Age: 30, Email: "luz@globant.com"},
+				}, nil)
+			},
+			assertResponse: func(t *testing.T, chunks []entities.ExportChunk, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, chunks, 2)
+				assert.Equal(t, "id,name,age,email\na,Timo,19,timoteo@globant.com\nb,\"Ana, Maria\",21,ana@globant.com\n", string(chunks[0].Data))
+				assert.Equal(t, "b", chunks[0].LastId)
+				assert.Equal(t, "c,Luz,30,luz@globant.com\n", string(chunks[1].Data))
+				assert.Equal(t, "c", chunks[1].LastId)
+			},
+		},
+		{
+			Name:    "Resumed Export Has No Header",
+			Request: entities.ExportUsersRequest{Format: "ndjson", AfterId: "b"},
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("ListUsers", mock.Anything, "b", 2).Return([]entities.User{
+					{Id: "c", Name: "Luz", Age: 30, Email: "luz@globant.com", Pass: bcryptHash},
+				}, nil)
+			},
+			assertResponse: func(t *testing.T, chunks []entities.ExportChunk, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, chunks, 1)
+				assert.Equal(t, `{"id":"c","name":"Luz","age":30,"email":"luz@globant.com"}`+"\n", string(chunks[0].Data))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Bulk.ExportPageSize = 2
+
+			var chunks []entities.ExportChunk
+			err := srvc.ExportUsers(context.Background(), tc.Request, func(chunk entities.ExportChunk) error {
+				chunks = append(chunks, chunk)
+				return nil
+			})
+			tc.assertResponse(t, chunks, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/cache"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/encryption"
+	entities "github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+// CacheConfig sets how long found and not found users stay cached.
+type CacheConfig struct {
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+type cachedUser struct {
+	User     entities.User
+	NotFound bool
+}
+
+type cachingRepo struct {
+	Repository
+	store  cache.Store
+	keys   *encryption.Keyring
+	config CacheConfig
+	group  singleflight.Group
+	hits   metrics.Counter
+	misses metrics.Counter
+	logger log.Logger
+}
+
+type invalidationsKey struct{}
+
+type invalidations struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+// NewCachingRepository decorates next with a read-through cache for
+// GetUser. Concurrent misses for the same user share one repository call,
+// and writes invalidate the entry they touch. Entries hold PII, they are
+// sealed with keys before they leave the process.
+func NewCachingRepository(next Repository, store cache.Store, keys *encryption.Keyring, config CacheConfig, hits, misses metrics.Counter, logger log.Logger) Repository {
+	return &cachingRepo{
+		Repository: next,
+		store:      store,
+		keys:       keys,
+		config:     config,
+		hits:       hits,
+		misses:     misses,
+		logger:     logger,
+	}
+}
+
+func (c *cachingRepo) GetUser(ctx context.Context, userId string) (entities.User, error) {
+	// Reads inside a transaction may see uncommitted rows, keep them out.
+	if _, ok := database.TxFromContext(ctx); ok {
+		return c.Repository.GetUser(ctx, userId)
+	}
+
+	key := userCacheKey(ctx, userId)
+
+	if entry, ok := c.lookup(ctx, key); ok {
+		c.hits.Add(1)
+		if entry.NotFound {
+			return entities.User{}, sql.ErrNoRows
+		}
+		return entry.User, nil
+	}
+
+	c.misses.Add(1)
+
+	res, err, _ := c.group.Do(key, func() (interface{}, error) {
+		user, err := c.Repository.GetUser(ctx, userId)
+		switch err {
+		case nil:
+			c.save(ctx, key, cachedUser{User: user}, c.config.TTL)
+		case sql.ErrNoRows:
+			c.save(ctx, key, cachedUser{NotFound: true}, c.config.NegativeTTL)
+		}
+		return user, err
+	})
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	return res.(entities.User), nil
+}
+
+func (c *cachingRepo) CreateUser(ctx context.Context, user entities.User, newId string) (string, error) {
+	id, err := c.Repository.CreateUser(ctx, user, newId)
+	if err != nil {
+		return id, err
+	}
+
+	// A lookup for the id before it existed may have left a not found entry.
+	c.invalidate(ctx, userCacheKey(ctx, newId))
+	return id, nil
+}
+
+func (c *cachingRepo) DeleteUser(ctx context.Context, userId string) error {
+	if err := c.Repository.DeleteUser(ctx, userId); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, userCacheKey(ctx, userId))
+	return nil
+}
+
+func (c *cachingRepo) UpdateUserAttributes(ctx context.Context, userId string, attrs map[string]interface{}) error {
+	if err := c.Repository.UpdateUserAttributes(ctx, userId, attrs); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, userCacheKey(ctx, userId))
+	return nil
+}
+
+func (c *cachingRepo) CreateUsers(ctx context.Context, users []entities.User) error {
+	if err := c.Repository.CreateUsers(ctx, users); err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		c.invalidate(ctx, userCacheKey(ctx, user.Id))
+	}
+	return nil
+}
+
+func (c *cachingRepo) DeleteUsers(ctx context.Context, userIds []string) ([]string, error) {
+	deleted, err := c.Repository.DeleteUsers(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userId := range deleted {
+		c.invalidate(ctx, userCacheKey(ctx, userId))
+	}
+	return deleted, nil
+}
+
+// WithTx invalidates the entries written inside the transaction a second
+// time once it commits, so a read racing the commit can't leave a stale
+// entry behind.
+func (c *cachingRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(invalidationsKey{}).(*invalidations); ok {
+		return c.Repository.WithTx(ctx, fn)
+	}
+
+	pending := &invalidations{}
+	err := c.Repository.WithTx(context.WithValue(ctx, invalidationsKey{}, pending), fn)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range pending.keys {
+		c.invalidate(ctx, key)
+	}
+	return nil
+}
+
+func (c *cachingRepo) lookup(ctx context.Context, key string) (cachedUser, bool) {
+	value, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "cache lookup failed", "key", key, "error", err)
+		return cachedUser{}, false
+	}
+	if !ok {
+		return cachedUser{}, false
+	}
+
+	// Entries sealed with a master key retired since can't be read anymore
+	// and are dropped like any other.
+	var entry cachedUser
+	plain, err := c.keys.Decrypt(string(value))
+	if err == nil {
+		err = json.Unmarshal([]byte(plain), &entry)
+	}
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "dropping unreadable cache entry", "key", key, "error", err)
+		c.store.Delete(ctx, key)
+		return cachedUser{}, false
+	}
+
+	return entry, true
+}
+
+func (c *cachingRepo) save(ctx context.Context, key string, entry cachedUser, ttl time.Duration) {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	sealed, err := c.keys.Encrypt(string(value))
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "cache entry can't be sealed", "key", key, "error", err)
+		return
+	}
+
+	if err := c.store.Set(ctx, key, []byte(sealed), ttl); err != nil {
+		level.Warn(c.logger).Log("msg", "cache store failed", "key", key, "error", err)
+	}
+}
+
+func (c *cachingRepo) invalidate(ctx context.Context, key string) {
+	if pending, ok := ctx.Value(invalidationsKey{}).(*invalidations); ok {
+		pending.mu.Lock()
+		pending.keys = append(pending.keys, key)
+		pending.mu.Unlock()
+	}
+
+	if err := c.store.Delete(ctx, key); err != nil {
+		level.Error(c.logger).Log("msg", "cache invalidation failed", "key", key, "error", err)
+	}
+}
+
+// userCacheKey keeps the users of each tenant apart, a user is only found
+// in the cache by the tenant it belongs to.
+func userCacheKey(ctx context.Context, userId string) string {
+	return "user:" + tenant.FromContext(ctx) + ":" + userId
+}
@@ -0,0 +1,162 @@
+package user_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/stretchr/testify/assert"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/cache"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/user"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+func TestCachingRepository(t *testing.T) {
+	var logger log.Logger
+	{
+		logger = log.NewLogfmtLogger(os.Stderr)
+		logger = log.NewSyncLogger(logger)
+		logger = log.With(logger,
+			"service", "grpcUserService",
+			"time:", log.DefaultTimestampUTC,
+			"caller", log.DefaultCaller,
+		)
+	}
+
+	userMock := entities.User{
+		Name:  "Timo",
+		Age:   19,
+		Email: "timoteo@globant.com",
+	}
+
+	config := user.CacheConfig{TTL: time.Minute, NegativeTTL: time.Minute}
+	keys := utils.NewKeyringMock()
+	ctx := context.Background()
+
+	t.Run("Second Read Is A Hit", func(t *testing.T) {
+		userId := utils.GenerateId()
+		next := new(utils.RepoSitoryMock)
+		hits, misses := generic.NewCounter("hits"), generic.NewCounter("misses")
+		repo := user.NewCachingRepository(next, cache.NewLRU(10), keys, config, hits, misses, logger)
+
+		next.On("GetUser", ctx, userId).Return(userMock, nil).Once()
+
+		for i := 0; i < 2; i++ {
+			res, err := repo.GetUser(ctx, userId)
+			assert.NoError(t, err)
+			assert.Equal(t, userMock, res)
+		}
+
+		next.AssertNumberOfCalls(t, "GetUser", 1)
+		assert.Equal(t, float64(1), hits.Value())
+		assert.Equal(t, float64(1), misses.Value())
+	})
+
+	t.Run("Tenants Are Cached Apart", func(t *testing.T) {
+		userId := utils.GenerateId()
+		next := new(utils.RepoSitoryMock)
+		repo := user.NewCachingRepository(next, cache.NewLRU(10), keys, config, generic.NewCounter("hits"), generic.NewCounter("misses"), logger)
+
+		acme := tenant.WithTenant(ctx, "acme")
+		globex := tenant.WithTenant(ctx, "globex")
+		next.On("GetUser", acme, userId).Return(userMock, nil).Once()
+		next.On("GetUser", globex, userId).Return(entities.User{}, sql.ErrNoRows).Once()
+
+		res, err := repo.GetUser(acme, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, userMock, res)
+
+		_, err = repo.GetUser(globex, userId)
+		assert.Equal(t, sql.ErrNoRows, err)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("Entries Are Sealed", func(t *testing.T) {
+		userId := utils.GenerateId()
+		next := new(utils.RepoSitoryMock)
+		store := cache.NewLRU(10)
+		repo := user.NewCachingRepository(next, store, keys, config, generic.NewCounter("hits"), generic.NewCounter("misses"), logger)
+
+		next.On("GetUser", ctx, userId).Return(userMock, nil).Once()
+
+		_, err := repo.GetUser(ctx, userId)
+		assert.NoError(t, err)
+
+		value, ok, err := store.Get(ctx, "user:"+tenant.Default+":"+userId)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.NotContains(t, string(value), userMock.Email)
+		assert.NotContains(t, string(value), userMock.Name)
+
+		res, err := repo.GetUser(ctx, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, userMock, res)
+		next.AssertNumberOfCalls(t, "GetUser", 1)
+	})
+
+	t.Run("Missing User Is Cached", func(t *testing.T) {
+		userId := utils.GenerateId()
+		next := new(utils.RepoSitoryMock)
+		hits, misses := generic.NewCounter("hits"), generic.NewCounter("misses")
+		repo := user.NewCachingRepository(next, cache.NewLRU(10), keys, config, hits, misses, logger)
+
+		next.On("GetUser", ctx, userId).Return(entities.User{}, sql.ErrNoRows).Once()
+
+		for i := 0; i < 2; i++ {
+			_, err := repo.GetUser(ctx, userId)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		}
+
+		next.AssertNumberOfCalls(t, "GetUser", 1)
+	})
+
+	t.Run("Delete Invalidates Entry", func(t *testing.T) {
+		userId := utils.GenerateId()
+		next := new(utils.RepoSitoryMock)
+		hits, misses := generic.NewCounter("hits"), generic.NewCounter("misses")
+		repo := user.NewCachingRepository(next, cache.NewLRU(10), keys, config, hits, misses, logger)
+
+		next.On("GetUser", ctx, userId).Return(userMock, nil).Once()
+		next.On("DeleteUser", ctx, userId).Return(nil)
+		next.On("GetUser", ctx, userId).Return(entities.User{}, sql.ErrNoRows).Once()
+
+		_, err := repo.GetUser(ctx, userId)
+		assert.NoError(t, err)
+
+		assert.NoError(t, repo.DeleteUser(ctx, userId))
+
+		_, err = repo.GetUser(ctx, userId)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Equal(t, float64(2), misses.Value())
+	})
+
+	t.Run("Concurrent Misses Are Coalesced", func(t *testing.T) {
+		userId := utils.GenerateId()
+		next := new(utils.RepoSitoryMock)
+		hits, misses := generic.NewCounter("hits"), generic.NewCounter("misses")
+		repo := user.NewCachingRepository(next, cache.NewLRU(10), keys, config, hits, misses, logger)
+
+		next.On("GetUser", ctx, userId).Return(userMock, nil).After(50 * time.Millisecond).Once()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := repo.GetUser(ctx, userId)
+				assert.NoError(t, err)
+				assert.Equal(t, userMock, res)
+			}()
+		}
+		wg.Wait()
+
+		next.AssertNumberOfCalls(t, "GetUser", 1)
+	})
+}
@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"io"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
@@ -11,19 +12,107 @@ type Service interface {
 	GetUser(ctx context.Context, userReq entities.GetUserRequest) (entities.GetUserResponse, error)
 	CreateUser(ctx context.Context, userReq entities.CreateUserRequest) (entities.CreateUserResponse, error)
 	DeleteUser(ctx context.Context, userReq entities.DeleteUserRequest) (entities.DeleteUserResponse, error)
+	BatchCreateUsers(ctx context.Context, userReq entities.BatchCreateUsersRequest) (entities.BatchCreateUsersResponse, error)
+	BatchGetUsers(ctx context.Context, userReq entities.BatchGetUsersRequest) (entities.BatchGetUsersResponse, error)
+	BatchDeleteUsers(ctx context.Context, userReq entities.BatchDeleteUsersRequest) (entities.BatchDeleteUsersResponse, error)
+	WatchUsers(ctx context.Context, userReq entities.WatchRequest, send func(entities.WatchEvent) error) error
+	ImportUsers(ctx context.Context, opts entities.ImportOptions, body io.Reader) (entities.ImportUsersResponse, error)
+	ExportUsers(ctx context.Context, userReq entities.ExportUsersRequest, send func(entities.ExportChunk) error) error
+	ExportUserData(ctx context.Context, userReq entities.ExportUserDataRequest) (entities.ExportUserDataResponse, error)
+	EraseUser(ctx context.Context, userReq entities.EraseUserRequest) (entities.EraseUserResponse, error)
+	UpdateUserAttributes(ctx context.Context, userReq entities.UpdateUserAttributesRequest) (entities.UpdateUserAttributesResponse, error)
+	ListUsers(ctx context.Context, userReq entities.ListUsersRequest) (entities.ListUsersResponse, error)
+	UploadAvatar(ctx context.Context, userReq entities.UploadAvatarRequest, body io.Reader) (entities.UploadAvatarResponse, error)
+	GetAvatar(ctx context.Context, userReq entities.GetAvatarRequest) (entities.GetAvatarResponse, error)
+	Authenticate(ctx context.Context, userReq entities.AuthenticateRequest) (entities.AuthenticateResponse, error)
+}
+
+// WatchUsersRequest carries the callback a streaming transport hands
+// events to, since an endpoint returns a single response.
+type WatchUsersRequest struct {
+	entities.WatchRequest
+	Send func(entities.WatchEvent) error
+}
+
+// ImportUsersRequest carries the file of an import as it is streamed in.
+type ImportUsersRequest struct {
+	entities.ImportOptions
+	Body io.Reader
+}
+
+// UploadAvatarRequest carries the picture of an avatar as it is streamed
+// in.
+type UploadAvatarRequest struct {
+	entities.UploadAvatarRequest
+	Body io.Reader
+}
+
+// ExportUsersRequest carries the callback a streaming transport hands the
+// exported file to.
+type ExportUsersRequest struct {
+	entities.ExportUsersRequest
+	Send func(entities.ExportChunk) error
 }
 
 type Endpoints struct {
-	CreateUser endpoint.Endpoint
-	GetUser    endpoint.Endpoint
-	DeleteUser endpoint.Endpoint
+	CreateUser           endpoint.Endpoint
+	GetUser              endpoint.Endpoint
+	DeleteUser           endpoint.Endpoint
+	BatchCreateUsers     endpoint.Endpoint
+	BatchGetUsers        endpoint.Endpoint
+	BatchDeleteUsers     endpoint.Endpoint
+	WatchUsers           endpoint.Endpoint
+	ImportUsers          endpoint.Endpoint
+	ExportUsers          endpoint.Endpoint
+	ExportUserData       endpoint.Endpoint
+	EraseUser            endpoint.Endpoint
+	UpdateUserAttributes endpoint.Endpoint
+	ListUsers            endpoint.Endpoint
+	UploadAvatar         endpoint.Endpoint
+	GetAvatar            endpoint.Endpoint
+	Authenticate         endpoint.Endpoint
 }
 
 func MakeEndpoint(s Service) Endpoints {
 	return Endpoints{
-		CreateUser: MakeCreateUserEndpoint(s),
-		GetUser:    MakeGetUserEndpoint(s),
-		DeleteUser: MakeDeleteUserEndpoint(s),
+		CreateUser:           MakeCreateUserEndpoint(s),
+		GetUser:              MakeGetUserEndpoint(s),
+		DeleteUser:           MakeDeleteUserEndpoint(s),
+		BatchCreateUsers:     MakeBatchCreateUsersEndpoint(s),
+		BatchGetUsers:        MakeBatchGetUsersEndpoint(s),
+		BatchDeleteUsers:     MakeBatchDeleteUsersEndpoint(s),
+		WatchUsers:           MakeWatchUsersEndpoint(s),
+		ImportUsers:          MakeImportUsersEndpoint(s),
+		ExportUsers:          MakeExportUsersEndpoint(s),
+		ExportUserData:       MakeExportUserDataEndpoint(s),
+		EraseUser:            MakeEraseUserEndpoint(s),
+		UpdateUserAttributes: MakeUpdateUserAttributesEndpoint(s),
+		ListUsers:            MakeListUsersEndpoint(s),
+		UploadAvatar:         MakeUploadAvatarEndpoint(s),
+		GetAvatar:            MakeGetAvatarEndpoint(s),
+		Authenticate:         MakeAuthenticateEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		CreateUser:           mw(e.CreateUser),
+		GetUser:              mw(e.GetUser),
+		DeleteUser:           mw(e.DeleteUser),
+		BatchCreateUsers:     mw(e.BatchCreateUsers),
+		BatchGetUsers:        mw(e.BatchGetUsers),
+		BatchDeleteUsers:     mw(e.BatchDeleteUsers),
+		WatchUsers:           mw(e.WatchUsers),
+		ImportUsers:          mw(e.ImportUsers),
+		ExportUsers:          mw(e.ExportUsers),
+		ExportUserData:       mw(e.ExportUserData),
+		EraseUser:            mw(e.EraseUser),
+		UpdateUserAttributes: mw(e.UpdateUserAttributes),
+		ListUsers:            mw(e.ListUsers),
+		UploadAvatar:         mw(e.UploadAvatar),
+		GetAvatar:            mw(e.GetAvatar),
+		Authenticate:         mw(e.Authenticate),
 	}
 }
 
@@ -69,3 +158,157 @@ func MakeDeleteUserEndpoint(s Service) endpoint.Endpoint {
 
 	}
 }
+
+func MakeBatchCreateUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.BatchCreateUsersRequest)
+		c, err := s.BatchCreateUsers(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeBatchGetUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.BatchGetUsersRequest)
+		c, err := s.BatchGetUsers(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeBatchDeleteUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.BatchDeleteUsersRequest)
+		c, err := s.BatchDeleteUsers(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeWatchUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(WatchUsersRequest)
+		if err := s.WatchUsers(ctx, req.WatchRequest, req.Send); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func MakeImportUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ImportUsersRequest)
+		c, err := s.ImportUsers(ctx, req.ImportOptions, req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeExportUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ExportUsersRequest)
+		if err := s.ExportUsers(ctx, req.ExportUsersRequest, req.Send); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func MakeExportUserDataEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ExportUserDataRequest)
+		c, err := s.ExportUserData(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeEraseUserEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.EraseUserRequest)
+		c, err := s.EraseUser(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeUpdateUserAttributesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.UpdateUserAttributesRequest)
+		c, err := s.UpdateUserAttributes(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListUsersRequest)
+		c, err := s.ListUsers(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeUploadAvatarEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(UploadAvatarRequest)
+		c, err := s.UploadAvatar(ctx, req.UploadAvatarRequest, req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeGetAvatarEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.GetAvatarRequest)
+		c, err := s.GetAvatar(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeAuthenticateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.AuthenticateRequest)
+		c, err := s.Authenticate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
@@ -0,0 +1,182 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// KeyRotation is what a batch of RotateKeys did.
+type KeyRotation struct {
+	// Updated counts the users moved to the active master key.
+	Updated int
+	// Failed holds the users whose keys couldn't be rewrapped, they are
+	// left on their old key.
+	Failed []string
+	// Next is the id the following batch starts after, empty once the
+	// last user was read.
+	Next string
+}
+
+type staleUser struct {
+	id         string
+	name       string
+	email      string
+	keyVersion uint32
+}
+
+// RunKeyRotation rewraps the PII of users whose data keys are wrapped by an
+// old master key, batchSize rows at a time, until ctx is cancelled. Every
+// interval it goes once through the users, the ones that failed are tried
+// again on the next pass.
+func (repo *sqlRepo) RunKeyRotation(ctx context.Context, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var after string
+		failed := 0
+		for {
+			batch, err := repo.RotateKeys(ctx, after, batchSize)
+			if err != nil {
+				level.Error(repo.Logger).Log("msg", "key rotation failed", "error", err)
+				break
+			}
+			failed += len(batch.Failed)
+			if batch.Updated > 0 {
+				level.Info(repo.Logger).Log("msg", "rotated user keys", "rows", batch.Updated)
+			}
+			if batch.Next == "" {
+				break
+			}
+			after = batch.Next
+		}
+		if failed > 0 {
+			level.Error(repo.Logger).Log("msg", "users left on an old master key", "users", failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RotateKeys moves up to batchSize users with an id greater than afterId
+// to the active master key, in id order. Rows left over from before
+// encryption (key version 0) are encrypted on the way. A user that can't be
+// rewrapped is reported and skipped, the batches after it go on.
+func (repo *sqlRepo) RotateKeys(ctx context.Context, afterId string, batchSize int) (KeyRotation, error) {
+	active := repo.Keys.ActiveVersion()
+	return repo.rewrapBatch(ctx, active, batchSize, utils.ListStaleKeyUsersQuery, active, afterId, batchSize)
+}
+
+// EncryptLegacyUsers encrypts the users left over from before encryption
+// (key version 0), batchSize rows at a time. Their emails have no blind
+// index until then, so they can't be found by email: they can't sign in
+// and their emails don't count as taken. It is run before serving, and
+// fails when any of them is left unencrypted.
+func (repo *sqlRepo) EncryptLegacyUsers(ctx context.Context, batchSize int) error {
+	var after string
+	failed := 0
+	for {
+		batch, err := repo.rewrapBatch(ctx, repo.Keys.ActiveVersion(), batchSize, utils.ListLegacyUsersQuery, after, batchSize)
+		if err != nil {
+			return err
+		}
+		failed += len(batch.Failed)
+		if batch.Updated > 0 {
+			level.Info(repo.Logger).Log("msg", "encrypted legacy users", "rows", batch.Updated)
+		}
+		if batch.Next == "" {
+			break
+		}
+		after = batch.Next
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("user: %d legacy users left unencrypted", failed)
+	}
+	return nil
+}
+
+// rewrapBatch moves the users query returns to the active master key.
+func (repo *sqlRepo) rewrapBatch(ctx context.Context, active uint32, batchSize int, query string, args ...interface{}) (KeyRotation, error) {
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return KeyRotation{}, err
+	}
+
+	var stale []staleUser
+	for rows.Next() {
+		var u staleUser
+		if err := rows.Scan(&u.id, &u.name, &u.email, &u.keyVersion); err != nil {
+			rows.Close()
+			return KeyRotation{}, err
+		}
+		stale = append(stale, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return KeyRotation{}, err
+	}
+
+	var batch KeyRotation
+	if len(stale) == batchSize {
+		batch.Next = stale[len(stale)-1].id
+	}
+	for _, u := range stale {
+		name, email, emailIndex, err := repo.rewrap(u)
+		if err != nil {
+			level.Error(repo.Logger).Log("msg", "can't rewrap user keys", "user", u.id, "error", err)
+			batch.Failed = append(batch.Failed, u.id)
+			continue
+		}
+
+		// The key version guard skips rows changed since they were read.
+		res, err := repo.DB.ExecContext(ctx, utils.RewrapUserQuery, name, email, emailIndex, active, u.id, u.keyVersion)
+		if err != nil {
+			return batch, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			batch.Updated++
+		}
+	}
+
+	return batch, nil
+}
+
+func (repo *sqlRepo) rewrap(u staleUser) (string, string, string, error) {
+	if u.keyVersion == 0 {
+		name, err := repo.Keys.Encrypt(u.name)
+		if err != nil {
+			return "", "", "", err
+		}
+		email, err := repo.Keys.Encrypt(u.email)
+		if err != nil {
+			return "", "", "", err
+		}
+		return name, email, repo.Keys.BlindIndex(u.email), nil
+	}
+
+	name, _, err := repo.Keys.Rewrap(u.name)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	email, _, err := repo.Keys.Rewrap(u.email)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	plainEmail, err := repo.Keys.Decrypt(email)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return name, email, repo.Keys.BlindIndex(plainEmail), nil
+}
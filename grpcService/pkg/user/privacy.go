@@ -0,0 +1,369 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/privacy"
+)
+
+// auditExportPageSize is how many audit entries a data export reads at a
+// time.
+const auditExportPageSize = 1000
+
+// What an erasure removes, and what it keeps and why, as listed on the
+// receipt.
+var (
+	erasedData = []string{
+		"profile: name, age, email, password hash and custom attributes",
+		"avatar: every thumbnail of the uploaded picture",
+		"invitations: the invitations the user accepted, holding its email",
+	}
+	retainedData = []string{
+		"audit_log: entries about the user, holding its id and masked values only, kept as a tamper-evident record",
+		"outbox: events about the user, holding its id only",
+		"consents: the policies the user accepted or withdrew, and when and from where, kept as proof of its consent",
+		"user_erasures: this receipt, to prove the erasure and answer later requests about the user",
+	}
+)
+
+type exportManifest struct {
+	UserId     string    `json:"user_id"`
+	ExportedAt time.Time `json:"exported_at"`
+	Files      []string  `json:"files"`
+	Notes      []string  `json:"notes"`
+}
+
+type exportProfile struct {
+	Id         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Age        uint32                 `json:"age"`
+	Email      string                 `json:"email"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Version    uint64                 `json:"version"`
+}
+
+type exportEvent struct {
+	Seq        int64     `json:"seq"`
+	Id         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type exportConsent struct {
+	Kind          string     `json:"kind"`
+	Version       string     `json:"version"`
+	Source        string     `json:"source"`
+	AcceptedAt    time.Time  `json:"accepted_at"`
+	WithdrawnAt   *time.Time `json:"withdrawn_at,omitempty"`
+	WithdrawnFrom string     `json:"withdrawn_from,omitempty"`
+}
+
+type exportInvitation struct {
+	Id         string    `json:"id"`
+	Email      string    `json:"email"`
+	Roles      []string  `json:"roles,omitempty"`
+	GroupId    string    `json:"group_id,omitempty"`
+	InvitedBy  string    `json:"invited_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	AcceptedAt time.Time `json:"accepted_at"`
+}
+
+type exportAuditEntry struct {
+	Seq       int64     `json:"seq"`
+	Id        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor"`
+	SourceIp  string    `json:"source_ip"`
+	RequestId string    `json:"request_id"`
+	Operation string    `json:"operation"`
+	Diff      string    `json:"diff"`
+	Outcome   string    `json:"outcome"`
+	// Impersonation is the session an admin acted through as the user.
+	Impersonation string `json:"impersonation,omitempty"`
+}
+
+// ExportUserData collects everything kept on a user into a zip of JSON
+// files. Handing out the export is itself audited.
+func (s *service) ExportUserData(ctx context.Context, rq entities.ExportUserDataRequest) (entities.ExportUserDataResponse, error) {
+	s.Logger.Log(s.Logger, "request", "export user data", "received")
+
+	if rq.UserId == "" {
+		return entities.ExportUserDataResponse{}, errors.NewInvalidField("user_id", "is required")
+	}
+
+	user, err := s.Repo.GetUser(ctx, rq.UserId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.ExportUserDataResponse{}, errors.NewUserNotFound()
+		}
+		level.Error(s.Logger).Log("error", err)
+		return entities.ExportUserDataResponse{}, dataBaseError(err)
+	}
+
+	userEvents, err := s.Repo.ListUserEvents(ctx, rq.UserId)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ExportUserDataResponse{}, dataBaseError(err)
+	}
+
+	entries, err := s.auditEntriesOf(ctx, rq.UserId)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ExportUserDataResponse{}, dataBaseError(err)
+	}
+
+	var consents []entities.Consent
+	if s.Consents != nil {
+		consents, err = s.Consents.ListConsents(ctx, rq.UserId)
+		if err != nil {
+			level.Error(s.Logger).Log("error", err)
+			return entities.ExportUserDataResponse{}, dataBaseError(err)
+		}
+	}
+
+	var invitations []entities.Invitation
+	if s.Invitations != nil {
+		invitations, err = s.Invitations.ListUserInvitations(ctx, rq.UserId)
+		if err != nil {
+			level.Error(s.Logger).Log("error", err)
+			return entities.ExportUserDataResponse{}, dataBaseError(err)
+		}
+	}
+
+	now := time.Now().UTC()
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", exportProfile{Id: rq.UserId, Name: user.Name, Age: user.Age, Email: user.Email, Attributes: user.Attributes, Version: user.Version}},
+		{"events.json", exportEvents(userEvents)},
+		{"audit_log.json", exportAuditEntries(entries)},
+		{"consents.json", exportConsents(consents)},
+		{"invitations.json", exportInvitations(invitations)},
+	}
+
+	manifest := exportManifest{
+		UserId:     rq.UserId,
+		ExportedAt: now,
+		Notes:      []string{"The password is only kept as a one-way hash and is left out."},
+	}
+	for _, file := range files {
+		manifest.Files = append(manifest.Files, file.name)
+	}
+
+	var buf bytes.Buffer
+	archive := privacy.NewArchive(&buf, now)
+	if err := archive.Add("manifest.json", manifest); err != nil {
+		return entities.ExportUserDataResponse{}, err
+	}
+	for _, file := range files {
+		if err := archive.Add(file.name, file.content); err != nil {
+			return entities.ExportUserDataResponse{}, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return entities.ExportUserDataResponse{}, err
+	}
+
+	if err := s.record(ctx, audit.NewEntry(ctx, "ExportUserData", rq.UserId, nil, nil, nil)); err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ExportUserDataResponse{}, dataBaseError(err)
+	}
+
+	return entities.ExportUserDataResponse{
+		Archive:  buf.Bytes(),
+		FileName: fmt.Sprintf("user-%s-data.zip", rq.UserId),
+	}, nil
+}
+
+// auditEntriesOf returns the audit entries about userId, oldest first.
+func (s *service) auditEntriesOf(ctx context.Context, userId string) ([]entities.AuditEntry, error) {
+	if s.Audit == nil {
+		return nil, nil
+	}
+
+	var entries []entities.AuditEntry
+	var beforeSeq int64
+	for {
+		page, err := s.Audit.ListEntries(ctx, entities.AuditFilter{TargetUserId: userId}, beforeSeq, auditExportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page...)
+		if len(page) < auditExportPageSize {
+			break
+		}
+		beforeSeq = page[len(page)-1].Seq
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+func exportEvents(entries []entities.EventLogEntry) []exportEvent {
+	exported := make([]exportEvent, 0, len(entries))
+	for _, entry := range entries {
+		exported = append(exported, exportEvent{
+			Seq:        entry.Seq,
+			Id:         entry.Event.Id,
+			Type:       entry.Event.Type,
+			OccurredAt: entry.Event.Occurred_At.AsTime(),
+		})
+	}
+	return exported
+}
+
+func exportConsents(consents []entities.Consent) []exportConsent {
+	exported := make([]exportConsent, 0, len(consents))
+	for _, consent := range consents {
+		entry := exportConsent{
+			Kind:          consent.Kind,
+			Version:       consent.Version,
+			Source:        consent.Source,
+			AcceptedAt:    consent.AcceptedAt,
+			WithdrawnFrom: consent.WithdrawnFrom,
+		}
+		if !consent.WithdrawnAt.IsZero() {
+			withdrawnAt := consent.WithdrawnAt
+			entry.WithdrawnAt = &withdrawnAt
+		}
+		exported = append(exported, entry)
+	}
+	return exported
+}
+
+func exportInvitations(invitations []entities.Invitation) []exportInvitation {
+	exported := make([]exportInvitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		exported = append(exported, exportInvitation{
+			Id:         invitation.Id,
+			Email:      invitation.Email,
+			Roles:      invitation.Roles,
+			GroupId:    invitation.GroupId,
+			InvitedBy:  invitation.InvitedBy,
+			CreatedAt:  invitation.CreatedAt,
+			AcceptedAt: invitation.AcceptedAt,
+		})
+	}
+	return exported
+}
+
+func exportAuditEntries(entries []entities.AuditEntry) []exportAuditEntry {
+	exported := make([]exportAuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		exported = append(exported, exportAuditEntry{
+			Seq:           entry.Seq,
+			Id:            entry.Id,
+			Time:          entry.Time,
+			Actor:         entry.Actor,
+			SourceIp:      entry.SourceIp,
+			RequestId:     entry.RequestId,
+			Operation:     entry.Operation,
+			Diff:          entry.Diff,
+			Outcome:       entry.Outcome,
+			Impersonation: entry.ImpersonationId,
+		})
+	}
+	return exported
+}
+
+// EraseUser deletes the personal data of a user for good and returns a
+// signed receipt of what was erased and what was kept. The user id stays
+// behind in a tombstone holding the receipt, erasing the user again
+// returns the same receipt.
+func (s *service) EraseUser(ctx context.Context, rq entities.EraseUserRequest) (entities.EraseUserResponse, error) {
+	s.Logger.Log(s.Logger, "request", "erase user", "received")
+
+	if s.Receipts == nil {
+		return entities.EraseUserResponse{}, status.Error(codes.Unimplemented, "erasure receipts are not configured")
+	}
+	if rq.UserId == "" {
+		return entities.EraseUserResponse{}, errors.NewInvalidField("user_id", "is required")
+	}
+
+	var (
+		receipt entities.ErasureReceipt
+		avatars []entities.Avatar
+	)
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.Repo.LockUser(ctx, rq.UserId); err != nil {
+			if err != sql.ErrNoRows {
+				return err
+			}
+
+			previous, err := s.Repo.GetErasure(ctx, rq.UserId)
+			if err != nil {
+				return err
+			}
+			receipt = previous
+			return nil
+		}
+
+		var err error
+		if avatars, err = s.avatarsOf(ctx, rq.UserId); err != nil {
+			return err
+		}
+
+		if err := s.Repo.DeleteUser(ctx, rq.UserId); err != nil {
+			return err
+		}
+
+		if s.Invitations != nil {
+			if err := s.Invitations.DeleteUserInvitations(ctx, rq.UserId); err != nil {
+				return err
+			}
+		}
+
+		signed, err := s.Receipts.Sign(entities.ErasureReceipt{
+			Id:     s.Ids.NewId(),
+			UserId: rq.UserId,
+			// The database keeps microseconds, the signature has to
+			// survive the trip.
+			ErasedAt: time.Now().UTC().Truncate(time.Microsecond),
+			Erased:   erasedData,
+			Retained: retainedData,
+		})
+		if err != nil {
+			return err
+		}
+		receipt = signed
+
+		if err := s.Repo.SaveErasure(ctx, receipt); err != nil {
+			return err
+		}
+
+		if err := s.Repo.SaveEvent(ctx, events.NewUserDeleted(rq.UserId)); err != nil {
+			return err
+		}
+
+		// The entry names the user but, unlike a delete, keeps no diff.
+		return s.record(ctx, audit.NewEntry(ctx, "EraseUser", rq.UserId, nil, nil, nil))
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errors.NewUserNotFound()
+		} else {
+			level.Error(s.Logger).Log("error", err)
+			err = dataBaseError(err)
+		}
+		s.recordFailure(ctx, audit.NewEntry(ctx, "EraseUser", rq.UserId, nil, nil, err))
+		return entities.EraseUserResponse{}, err
+	}
+	s.removeAvatars(ctx, avatars...)
+
+	return entities.EraseUserResponse{Receipt: receipt}, nil
+}
@@ -0,0 +1,216 @@
+package user_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/privacy"
+	service "github.com/timoteoBone/microservice-project/grpcService/pkg/user"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+func TestServiceExportUserData(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		buildMock      func(repo *utils.RepoSitoryMock, auditRepo *utils.AuditRepositoryMock, consents *utils.ConsentRepositoryMock, invitations *utils.InvitationRepositoryMock)
+		assertResponse func(t *testing.T, res entities.ExportUserDataResponse, err error)
+	}{
+		{
+			Name: "Archive Holds Everything Kept On The User",
+			buildMock: func(repo *utils.RepoSitoryMock, auditRepo *utils.AuditRepositoryMock, consents *utils.ConsentRepositoryMock, invitations *utils.InvitationRepositoryMock) {
+				repo.On("GetUser", mock.Anything, "user-1").Return(entities.User{Name: "Timo", Age: 19, Email: "timoteo@globant.com", Pass: bcryptHash, Version: 2}, nil)
+				repo.On("ListUserEvents", mock.Anything, "user-1").Return([]entities.EventLogEntry{{Seq: 4, Event: events.NewUserCreated("user-1")}}, nil)
+				auditRepo.On("ListEntries", mock.Anything, entities.AuditFilter{TargetUserId: "user-1"}, int64(0), 1000).
+					Return([]entities.AuditEntry{{Seq: 9, Operation: "DeleteUser"}, {Seq: 3, Operation: "CreateUser"}}, nil)
+				consents.On("ListConsents", mock.Anything, "user-1").Return([]entities.Consent{
+					{UserId: "user-1", Kind: "marketing_email", Version: "1", Source: "signup", WithdrawnAt: time.Now(), WithdrawnFrom: "unsubscribe_link"},
+					{UserId: "user-1", Kind: "terms", Version: "2026-10", Source: "signup"},
+				}, nil)
+				invitations.On("ListUserInvitations", mock.Anything, "user-1").Return([]entities.Invitation{
+					{Id: "invitation-1", Email: "timoteo@globant.com", Roles: []string{"editor"}, Status: entities.InvitationAccepted, InvitedBy: "admin", UserId: "user-1"},
+				}, nil)
+				auditRepo.On("Record", mock.Anything, mock.MatchedBy(func(entries []entities.AuditEntry) bool {
+					return len(entries) == 1 && entries[0].Operation == "ExportUserData" && entries[0].TargetUserId == "user-1"
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.ExportUserDataResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-user-1-data.zip", res.FileName)
+
+				files := unzip(t, res.Archive)
+				assert.Contains(t, files, "manifest.json")
+				assert.Contains(t, files["profile.json"], `"email": "timoteo@globant.com"`)
+				assert.NotContains(t, files["profile.json"], bcryptHash)
+				assert.Contains(t, files["events.json"], `"type": "user.created"`)
+
+				var entries []map[string]interface{}
+				assert.NoError(t, json.Unmarshal([]byte(files["audit_log.json"]), &entries))
+				if assert.Len(t, entries, 2) {
+					assert.Equal(t, "CreateUser", entries[0]["operation"])
+				}
+
+				var consents []map[string]interface{}
+				assert.NoError(t, json.Unmarshal([]byte(files["consents.json"]), &consents))
+				if assert.Len(t, consents, 2) {
+					assert.Equal(t, "unsubscribe_link", consents[0]["withdrawn_from"])
+					assert.NotContains(t, consents[1], "withdrawn_at")
+				}
+
+				var invitations []map[string]interface{}
+				assert.NoError(t, json.Unmarshal([]byte(files["invitations.json"]), &invitations))
+				if assert.Len(t, invitations, 1) {
+					assert.Equal(t, "timoteo@globant.com", invitations[0]["email"])
+					assert.Equal(t, "admin", invitations[0]["invited_by"])
+				}
+			},
+		},
+		{
+			Name: "Missing User",
+			buildMock: func(repo *utils.RepoSitoryMock, auditRepo *utils.AuditRepositoryMock, consents *utils.ConsentRepositoryMock, invitations *utils.InvitationRepositoryMock) {
+				repo.On("GetUser", mock.Anything, "user-1").Return(entities.User{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.ExportUserDataResponse, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			auditRepo := new(utils.AuditRepositoryMock)
+			consents := new(utils.ConsentRepositoryMock)
+			invitations := new(utils.InvitationRepositoryMock)
+			tc.buildMock(repo, auditRepo, consents, invitations)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Audit = auditRepo
+			srvc.Consents = consents
+			srvc.Invitations = invitations
+			res, err := srvc.ExportUserData(context.Background(), entities.ExportUserDataRequest{UserId: "user-1"})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+			auditRepo.AssertExpectations(t)
+			consents.AssertExpectations(t)
+			invitations.AssertExpectations(t)
+		})
+	}
+}
+
+func unzip(t *testing.T, archive []byte) map[string]string {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		r, err := file.Open()
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func TestServiceEraseUser(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	signer, err := privacy.NewSigner(bytes.Repeat([]byte{7}, 32))
+	assert.NoError(t, err)
+
+	previous, err := signer.Sign(entities.ErasureReceipt{Id: "receipt-1", UserId: "user-1"})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		Name           string
+		Receipts       *privacy.Signer
+		buildMock      func(repo *utils.RepoSitoryMock, invitations *utils.InvitationRepositoryMock)
+		assertResponse func(t *testing.T, res entities.EraseUserResponse, err error)
+	}{
+		{
+			Name:     "Erased User Gets A Signed Receipt",
+			Receipts: signer,
+			buildMock: func(repo *utils.RepoSitoryMock, invitations *utils.InvitationRepositoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(2), nil)
+				repo.On("DeleteUser", mock.Anything, "user-1").Return(nil)
+				invitations.On("DeleteUserInvitations", mock.Anything, "user-1").Return(nil)
+				repo.On("SaveErasure", mock.Anything, mock.MatchedBy(func(receipt entities.ErasureReceipt) bool {
+					return receipt.UserId == "user-1" && privacy.Verify(signer.PublicKey(), receipt) == nil
+				})).Return(nil)
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.EraseUserResponse, err error) {
+				assert.NoError(t, err)
+				assert.NoError(t, privacy.Verify(signer.PublicKey(), res.Receipt))
+				assert.Contains(t, res.Receipt.Erased, "invitations: the invitations the user accepted, holding its email")
+				assert.NotEmpty(t, res.Receipt.Retained)
+			},
+		},
+		{
+			Name:     "Erasing Again Returns The First Receipt",
+			Receipts: signer,
+			buildMock: func(repo *utils.RepoSitoryMock, invitations *utils.InvitationRepositoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(0), sql.ErrNoRows)
+				repo.On("GetErasure", mock.Anything, "user-1").Return(previous, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.EraseUserResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, previous, res.Receipt)
+			},
+		},
+		{
+			Name:     "Missing User",
+			Receipts: signer,
+			buildMock: func(repo *utils.RepoSitoryMock, invitations *utils.InvitationRepositoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(0), sql.ErrNoRows)
+				repo.On("GetErasure", mock.Anything, "user-1").Return(entities.ErasureReceipt{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.EraseUserResponse, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+		{
+			Name:      "No Receipt Key",
+			buildMock: func(repo *utils.RepoSitoryMock, invitations *utils.InvitationRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.EraseUserResponse, err error) {
+				assert.Equal(t, codes.Unimplemented, status.Code(err))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			invitations := new(utils.InvitationRepositoryMock)
+			tc.buildMock(repo, invitations)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Receipts = tc.Receipts
+			srvc.Invitations = invitations
+			res, err := srvc.EraseUser(context.Background(), entities.EraseUserRequest{UserId: "user-1"})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+			invitations.AssertExpectations(t)
+		})
+	}
+}
@@ -3,34 +3,138 @@ package user
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/attributes"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/encryption"
 	entities "github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
 )
 
+// batchChunkSize caps the rows of a single batch statement, well below the
+// placeholder limit of MySQL prepared statements.
+const batchChunkSize = 500
+
+var duplicateEntry = regexp.MustCompile(`Duplicate entry '([^']*)'`)
+
+// BatchItemError points at the item of a batch a failure came from.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// sqlRepo scopes every query to the tenant of the context it is called
+// with, a user of another tenant is as good as missing.
 type sqlRepo struct {
-	DB     *sql.DB
-	Logger log.Logger
+	DB       *sql.DB
+	Replicas *database.ReplicaSet
+	TxConfig database.TxConfig
+	Keys     *encryption.Keyring
+	Logger   log.Logger
 }
 
-func NewSQL(db *sql.DB, log log.Logger) *sqlRepo {
-	return &sqlRepo{db, log}
+func NewSQL(db *sql.DB, keys *encryption.Keyring, log log.Logger) *sqlRepo {
+	return NewReplicatedSQL(db, database.NewReplicaSet(nil, 0, log), keys, log)
+}
+
+// NewReplicatedSQL builds a repository that writes to primary and spreads
+// reads over replicas, falling back to primary when none of them is healthy.
+// Names and emails are encrypted with keys before they are stored.
+func NewReplicatedSQL(primary *sql.DB, replicas *database.ReplicaSet, keys *encryption.Keyring, log log.Logger) *sqlRepo {
+	return &sqlRepo{primary, replicas, database.DefaultTxConfig(), keys, log}
+}
+
+// WithTx runs fn as a single unit of work. Every repository call made with
+// the context handed to fn, including the ones made by nested service
+// methods, joins the same transaction.
+func (repo *sqlRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, repo.DB, repo.TxConfig, fn)
+}
+
+// conn returns the transaction in ctx, or the primary when there is none.
+func (repo *sqlRepo) conn(ctx context.Context) database.Querier {
+	return database.Conn(ctx, repo.DB)
+}
+
+// replica picks a healthy replica for a read. Reads that are part of a
+// transaction or asked to see their own writes stay on the primary.
+func (repo *sqlRepo) replica(ctx context.Context) (*sql.DB, bool) {
+	if _, ok := database.TxFromContext(ctx); ok || database.ReadYourWrites(ctx) {
+		return nil, false
+	}
+
+	return repo.Replicas.Pick()
+}
+
+// read runs query against a healthy replica when there is one, and against
+// the primary otherwise or when the replica turns out to be unreachable.
+func (repo *sqlRepo) read(ctx context.Context, query func(db database.Querier) error) error {
+	if replica, ok := repo.replica(ctx); ok {
+		err := query(replica)
+		if err == nil || !database.IsUnavailable(err) {
+			return err
+		}
+
+		level.Warn(repo.Logger).Log("msg", "replica unavailable, reading from primary", "error", err)
+		repo.Replicas.Eject(replica, err)
+	}
+
+	return query(repo.conn(ctx))
 }
 
 func (repo *sqlRepo) CreateUser(ctx context.Context, user entities.User, newId string) (string, error) {
 
 	repo.Logger.Log(repo.Logger, "Repository method", "Create user")
 
-	stmt, err := repo.DB.PrepareContext(ctx, utils.CreateUserQuery)
+	stmt, err := repo.conn(ctx).PrepareContext(ctx, utils.CreateUserQuery)
 	if err != nil {
 		level.Error(repo.Logger).Log(err)
 		return "", err
 	}
 
 	defer stmt.Close()
-	res, err := stmt.ExecContext(ctx, user.Name, newId, user.Pass, user.Age, user.Email)
+
+	name, err := repo.Keys.Encrypt(user.Name)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return "", err
+	}
+
+	email, err := repo.Keys.Encrypt(user.Email)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return "", err
+	}
+
+	attrs, err := attributes.Encode(user.Attributes)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return "", err
+	}
+
+	res, err := stmt.ExecContext(ctx, name, newId, user.Pass, user.Age, email, repo.Keys.BlindIndex(user.Email), repo.Keys.ActiveVersion(), tenant.FromContext(ctx), attrs)
 	if err != nil {
 		level.Error(repo.Logger).Log(err)
 		return "", err
@@ -44,28 +148,109 @@ func (repo *sqlRepo) CreateUser(ctx context.Context, user entities.User, newId s
 func (repo *sqlRepo) GetUser(ctx context.Context, userId string) (entities.User, error) {
 	repo.Logger.Log(repo.Logger, "Repository method", "Get user")
 
-	user := entities.User{}
-	stmt, err := repo.DB.PrepareContext(ctx, utils.GetUserQuery)
+	var (
+		user       entities.User
+		keyVersion uint32
+		attrs      sql.NullString
+	)
+
+	err := repo.read(ctx, func(db database.Querier) error {
+		stmt, err := db.PrepareContext(ctx, utils.GetUserQuery)
+		if err != nil {
+			return err
+		}
+
+		defer stmt.Close()
+
+		return stmt.QueryRowContext(ctx, userId, tenant.FromContext(ctx)).Scan(&user.Name, &user.Age, &user.Email, &keyVersion, &user.Version, &attrs)
+	})
 	if err != nil {
 		level.Error(repo.Logger).Log(err)
 		return entities.User{}, err
 	}
 
-	defer stmt.Close()
+	if user.Attributes, err = attributes.Decode(attrs); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.User{}, err
+	}
+
+	if err := repo.decrypt(&user, keyVersion); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.User{}, err
+	}
+
+	return user, nil
+}
+
+// GetUserByEmail finds a user through the blind index of its email, since
+// the email column itself is encrypted.
+func (repo *sqlRepo) GetUserByEmail(ctx context.Context, email string) (entities.User, error) {
+	repo.Logger.Log(repo.Logger, "Repository method", "Get user by email")
 
-	err = stmt.QueryRowContext(ctx, userId).Scan(&user.Name, &user.Age, &user.Email)
+	var (
+		user       entities.User
+		keyVersion uint32
+	)
+
+	err := repo.read(ctx, func(db database.Querier) error {
+		stmt, err := db.PrepareContext(ctx, utils.GetUserByEmailQuery)
+		if err != nil {
+			return err
+		}
+
+		defer stmt.Close()
+
+		return stmt.QueryRowContext(ctx, repo.Keys.BlindIndex(email), tenant.FromContext(ctx)).Scan(&user.Id, &user.Name, &user.Pass, &user.Age, &user.Email, &keyVersion)
+	})
 	if err != nil {
 		level.Error(repo.Logger).Log(err)
 		return entities.User{}, err
 	}
 
+	if err := repo.decrypt(&user, keyVersion); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.User{}, err
+	}
+
 	return user, nil
 }
 
+// decrypt replaces the encrypted columns of user with their plaintext. Rows
+// written before encryption was introduced have key version 0 and are
+// returned as they are until EncryptLegacyUsers encrypts them.
+func (repo *sqlRepo) decrypt(user *entities.User, keyVersion uint32) error {
+	if keyVersion == 0 {
+		return nil
+	}
+
+	name, err := repo.Keys.Decrypt(user.Name)
+	if err != nil {
+		return err
+	}
+
+	email, err := repo.Keys.Decrypt(user.Email)
+	if err != nil {
+		return err
+	}
+
+	user.Name = name
+	user.Email = email
+	return nil
+}
+
 func (repo *sqlRepo) DeleteUser(ctx context.Context, userId string) error {
 	repo.Logger.Log(repo.Logger, "Repository method", "delete user")
 
-	stmt, err := repo.DB.PrepareContext(ctx, utils.DeleteUserQuery)
+	// Group memberships, indexed attributes, the avatar and preferences go
+	// with the user, callers run the deletes in a transaction.
+	for _, query := range []string{utils.DeleteUserMembershipsQuery, utils.DeleteUserAttributeIndexQuery, utils.DeleteUserAvatarQuery, utils.DeleteUserPreferencesQuery} {
+		if _, err := repo.conn(ctx).ExecContext(ctx, query, tenant.FromContext(ctx), userId); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return err
+		}
+	}
+
+	stmt, err := repo.conn(ctx).PrepareContext(ctx, utils.DeleteUserQuery)
 	if err != nil {
 		level.Error(repo.Logger).Log(err)
 		return err
@@ -73,12 +258,580 @@ func (repo *sqlRepo) DeleteUser(ctx context.Context, userId string) error {
 
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, userId)
+	res, err := stmt.ExecContext(ctx, userId, tenant.FromContext(ctx))
 	if err != nil {
 		level.Error(repo.Logger).Log(err)
 		return err
 	}
 
+	// Deleting a user that isn't there is reported like reading it.
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
 	return nil
 
 }
+
+// LockUser returns the version of a user and locks its row until the
+// transaction carried by ctx ends, so a write conditioned on the version
+// can't race another one.
+func (repo *sqlRepo) LockUser(ctx context.Context, userId string) (uint64, error) {
+	repo.Logger.Log(repo.Logger, "Repository method", "lock user")
+
+	var version uint64
+	err := repo.conn(ctx).QueryRowContext(ctx, utils.LockUserQuery, userId, tenant.FromContext(ctx)).Scan(&version)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// CreateUsers inserts users with multi-row statements of up to
+// batchChunkSize rows. Callers run it in a transaction to make the chunks
+// atomic. A duplicate key is reported as a BatchItemError for the offending
+// user.
+func (repo *sqlRepo) CreateUsers(ctx context.Context, users []entities.User) error {
+	repo.Logger.Log(repo.Logger, "Repository method", "Create users")
+
+	tenantId := tenant.FromContext(ctx)
+	for start := 0; start < len(users); start += batchChunkSize {
+		chunk := users[start:min(start+batchChunkSize, len(users))]
+
+		args := make([]interface{}, 0, len(chunk)*9)
+		emailIndexes := make([]string, len(chunk))
+		for i, user := range chunk {
+			name, err := repo.Keys.Encrypt(user.Name)
+			if err != nil {
+				level.Error(repo.Logger).Log(err)
+				return err
+			}
+
+			email, err := repo.Keys.Encrypt(user.Email)
+			if err != nil {
+				level.Error(repo.Logger).Log(err)
+				return err
+			}
+
+			attrs, err := attributes.Encode(user.Attributes)
+			if err != nil {
+				level.Error(repo.Logger).Log(err)
+				return err
+			}
+
+			emailIndexes[i] = repo.Keys.BlindIndex(user.Email)
+			args = append(args, name, user.Id, user.Pass, user.Age, email, emailIndexes[i], repo.Keys.ActiveVersion(), tenantId, attrs)
+		}
+
+		query := utils.Placeholders(utils.CreateUsersQuery, len(chunk), 9)
+		if _, err := repo.conn(ctx).ExecContext(ctx, query, args...); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return duplicateItem(err, chunk, tenantId, emailIndexes, start)
+		}
+	}
+
+	return nil
+}
+
+// duplicateItem finds the user a duplicate key error was raised for from
+// the key value MySQL puts in the message, the id or the tenant and email
+// index joined by a hyphen.
+func duplicateItem(err error, chunk []entities.User, tenantId string, emailIndexes []string, offset int) error {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	if !ok || mysqlErr.Number != 1062 {
+		return err
+	}
+
+	match := duplicateEntry.FindStringSubmatch(mysqlErr.Message)
+	if match == nil {
+		return err
+	}
+
+	for i, user := range chunk {
+		if tenantId+"-"+emailIndexes[i] == match[1] || user.Id == match[1] {
+			return &BatchItemError{Index: offset + i, Err: err}
+		}
+	}
+	return err
+}
+
+// GetUsers returns the users found among userIds, keyed by id.
+func (repo *sqlRepo) GetUsers(ctx context.Context, userIds []string) (map[string]entities.User, error) {
+	repo.Logger.Log(repo.Logger, "Repository method", "Get users")
+
+	users := make(map[string]entities.User, len(userIds))
+	for start := 0; start < len(userIds); start += batchChunkSize {
+		chunk := userIds[start:min(start+batchChunkSize, len(userIds))]
+
+		err := repo.read(ctx, func(db database.Querier) error {
+			rows, err := db.QueryContext(ctx, utils.Placeholders(utils.GetUsersQuery, len(chunk), 1), tenantArgs(ctx, chunk)...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				user, err := repo.scanUser(rows)
+				if err != nil {
+					return err
+				}
+				users[user.Id] = user
+			}
+
+			return rows.Err()
+		})
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+	}
+
+	return users, nil
+}
+
+// DeleteUsers deletes the users found among userIds and returns their ids.
+// The rows are locked before they are deleted, so in a transaction the ids
+// returned are exactly the ones removed.
+func (repo *sqlRepo) DeleteUsers(ctx context.Context, userIds []string) ([]string, error) {
+	repo.Logger.Log(repo.Logger, "Repository method", "delete users")
+
+	var deleted []string
+	for start := 0; start < len(userIds); start += batchChunkSize {
+		chunk := userIds[start:min(start+batchChunkSize, len(userIds))]
+		db := repo.conn(ctx)
+
+		rows, err := db.QueryContext(ctx, utils.Placeholders(utils.LockUsersQuery, len(chunk), 1), tenantArgs(ctx, chunk)...)
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+
+		var existing []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				level.Error(repo.Logger).Log(err)
+				return nil, err
+			}
+			existing = append(existing, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+
+		if len(existing) == 0 {
+			continue
+		}
+
+		for _, query := range []string{utils.DeleteUsersMembershipsQuery, utils.DeleteUsersAttributeIndexQuery, utils.DeleteUsersAvatarQuery, utils.DeleteUsersPreferencesQuery, utils.DeleteUsersQuery} {
+			if _, err := db.ExecContext(ctx, utils.Placeholders(query, len(existing), 1), tenantArgs(ctx, existing)...); err != nil {
+				level.Error(repo.Logger).Log(err)
+				return nil, err
+			}
+		}
+		deleted = append(deleted, existing...)
+	}
+
+	return deleted, nil
+}
+
+// ListUsers returns up to limit users with an id greater than afterId, in
+// id order, for exports to page through.
+func (repo *sqlRepo) ListUsers(ctx context.Context, afterId string, limit int) ([]entities.User, error) {
+	repo.Logger.Log(repo.Logger, "Repository method", "List users")
+
+	var users []entities.User
+	err := repo.read(ctx, func(db database.Querier) error {
+		users = users[:0]
+
+		rows, err := db.QueryContext(ctx, utils.ListUsersAfterQuery, tenant.FromContext(ctx), afterId, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				user       entities.User
+				keyVersion uint32
+			)
+			if err := rows.Scan(&user.Id, &user.Name, &user.Age, &user.Email, &keyVersion); err != nil {
+				return err
+			}
+
+			if err := repo.decrypt(&user, keyVersion); err != nil {
+				return err
+			}
+			users = append(users, user)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// FilterUsers returns up to limit users with an id greater than afterId,
+// in id order, whose indexed attributes hold the values of filters.
+func (repo *sqlRepo) FilterUsers(ctx context.Context, filters map[string]string, afterId string, limit int) ([]entities.User, error) {
+	repo.Logger.Log(repo.Logger, "Repository method", "Filter users")
+
+	tenantId := tenant.FromContext(ctx)
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := []interface{}{tenantId, afterId}
+	for _, name := range names {
+		args = append(args, tenantId, name, filters[name])
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(utils.FilterUsersQuery, strings.Repeat(utils.AttributeFilterClause, len(names)))
+
+	var users []entities.User
+	err := repo.read(ctx, func(db database.Querier) error {
+		users = users[:0]
+
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			user, err := repo.scanUser(rows)
+			if err != nil {
+				return err
+			}
+			users = append(users, user)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// scanUser reads a row of id, first_name, age, email, key_version, version
+// and attributes.
+func (repo *sqlRepo) scanUser(rows *sql.Rows) (entities.User, error) {
+	var (
+		user       entities.User
+		keyVersion uint32
+		attrs      sql.NullString
+	)
+	if err := rows.Scan(&user.Id, &user.Name, &user.Age, &user.Email, &keyVersion, &user.Version, &attrs); err != nil {
+		return entities.User{}, err
+	}
+
+	if err := repo.decrypt(&user, keyVersion); err != nil {
+		return entities.User{}, err
+	}
+
+	var err error
+	if user.Attributes, err = attributes.Decode(attrs); err != nil {
+		return entities.User{}, err
+	}
+	return user, nil
+}
+
+// UpdateUserAttributes replaces the attributes of a user and moves it to
+// its next version.
+func (repo *sqlRepo) UpdateUserAttributes(ctx context.Context, userId string, attrs map[string]interface{}) error {
+	repo.Logger.Log(repo.Logger, "Repository method", "Update user attributes")
+
+	content, err := attributes.Encode(attrs)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	res, err := repo.conn(ctx).ExecContext(ctx, utils.UpdateUserAttributesQuery, content, time.Now().UTC(), userId, tenant.FromContext(ctx))
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SaveAttributeIndex replaces the indexed attributes of a user with
+// entries.
+func (repo *sqlRepo) SaveAttributeIndex(ctx context.Context, userId string, entries map[string]string) error {
+	db := repo.conn(ctx)
+	if _, err := db.ExecContext(ctx, utils.DeleteUserAttributeIndexQuery, tenant.FromContext(ctx), userId); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	if err := attributes.WriteIndex(ctx, db, userId, entries); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+// StartImport returns the checkpoint of the import importId, creating it
+// when the import is new.
+func (repo *sqlRepo) StartImport(ctx context.Context, importId string, format string) (entities.ImportCheckpoint, error) {
+	repo.Logger.Log(repo.Logger, "Repository method", "Start import")
+
+	db := repo.conn(ctx)
+	tenantId := tenant.FromContext(ctx)
+	if _, err := db.ExecContext(ctx, utils.StartImportQuery, tenantId, importId, format); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.ImportCheckpoint{}, err
+	}
+
+	var checkpoint entities.ImportCheckpoint
+	err := db.QueryRowContext(ctx, utils.GetImportQuery, tenantId, importId).
+		Scan(&checkpoint.ImportId, &checkpoint.Format, &checkpoint.Rows, &checkpoint.Imported, &checkpoint.Failed)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.ImportCheckpoint{}, err
+	}
+
+	return checkpoint, nil
+}
+
+// AdvanceImport moves the checkpoint of an import from the row from to
+// checkpoint. It reports false when the import is no longer at from because
+// another upload of it moved it first.
+func (repo *sqlRepo) AdvanceImport(ctx context.Context, checkpoint entities.ImportCheckpoint, from uint64) (bool, error) {
+	repo.Logger.Log(repo.Logger, "Repository method", "Advance import")
+
+	res, err := repo.conn(ctx).ExecContext(ctx, utils.AdvanceImportQuery,
+		checkpoint.Rows, checkpoint.Imported, checkpoint.Failed, tenant.FromContext(ctx), checkpoint.ImportId, from)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// tenantArgs returns the tenant of ctx followed by values, the arguments
+// of the batch queries.
+func tenantArgs(ctx context.Context, values []string) []interface{} {
+	args := make([]interface{}, 0, len(values)+1)
+	args = append(args, tenant.FromContext(ctx))
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return args
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// SaveEvent writes event to the outbox, in the transaction carried by ctx
+// when there is one.
+func (repo *sqlRepo) SaveEvent(ctx context.Context, event *pb.Event) error {
+	if err := events.Append(ctx, repo.conn(ctx), event); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListEvents reads the user event log after the position after. Replicas
+// serve it too, a lagging one only delays the events, it can't skip any.
+func (repo *sqlRepo) ListEvents(ctx context.Context, after int64, limit int, settle time.Duration) ([]entities.EventLogEntry, error) {
+	var entries []entities.EventLogEntry
+
+	err := repo.read(ctx, func(db database.Querier) error {
+		var err error
+		entries, err = events.ReadLog(ctx, db, after, limit, settle)
+		return err
+	})
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (repo *sqlRepo) LastEventSeq(ctx context.Context) (int64, error) {
+	seq, err := events.LastSeq(ctx, repo.conn(ctx))
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return 0, err
+	}
+
+	return seq, nil
+}
+
+// ListUserEvents returns every event written about userId.
+func (repo *sqlRepo) ListUserEvents(ctx context.Context, userId string) ([]entities.EventLogEntry, error) {
+	entries, err := events.ReadAggregate(ctx, repo.conn(ctx), userId)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// SaveErasure keeps the receipt of an erased user as its tombstone.
+func (repo *sqlRepo) SaveErasure(ctx context.Context, receipt entities.ErasureReceipt) error {
+	content, err := json.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := repo.conn(ctx).ExecContext(ctx, utils.SaveErasureQuery, receipt.UserId, receipt.Id, receipt.ErasedAt, string(content), tenant.FromContext(ctx)); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetErasure returns the receipt of an erased user, or sql.ErrNoRows when
+// userId was never erased.
+func (repo *sqlRepo) GetErasure(ctx context.Context, userId string) (entities.ErasureReceipt, error) {
+	var content string
+	if err := repo.conn(ctx).QueryRowContext(ctx, utils.GetErasureQuery, userId, tenant.FromContext(ctx)).Scan(&content); err != nil {
+		if err != sql.ErrNoRows {
+			level.Error(repo.Logger).Log(err)
+		}
+		return entities.ErasureReceipt{}, err
+	}
+
+	var receipt entities.ErasureReceipt
+	if err := json.Unmarshal([]byte(content), &receipt); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.ErasureReceipt{}, err
+	}
+
+	return receipt, nil
+}
+
+// GetAvatar returns the avatar of a user, sql.ErrNoRows when it has none.
+func (repo *sqlRepo) GetAvatar(ctx context.Context, userId string) (entities.Avatar, error) {
+	avatar := entities.Avatar{UserId: userId}
+	var sizes string
+
+	err := repo.read(ctx, func(db database.Querier) error {
+		return db.QueryRowContext(ctx, utils.GetAvatarQuery, tenant.FromContext(ctx), userId).
+			Scan(&avatar.Hash, &avatar.ContentType, &sizes, &avatar.UpdatedAt)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			level.Error(repo.Logger).Log(err)
+		}
+		return entities.Avatar{}, err
+	}
+
+	if avatar.Sizes, err = parseSizes(sizes); err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.Avatar{}, err
+	}
+	return avatar, nil
+}
+
+// GetAvatars returns the avatars of the users among userIds that have one.
+func (repo *sqlRepo) GetAvatars(ctx context.Context, userIds []string) ([]entities.Avatar, error) {
+	var avatars []entities.Avatar
+	for start := 0; start < len(userIds); start += batchChunkSize {
+		chunk := userIds[start:min(start+batchChunkSize, len(userIds))]
+
+		err := repo.read(ctx, func(db database.Querier) error {
+			rows, err := db.QueryContext(ctx, utils.Placeholders(utils.GetAvatarsQuery, len(chunk), 1), tenantArgs(ctx, chunk)...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var (
+					avatar entities.Avatar
+					sizes  string
+				)
+				if err := rows.Scan(&avatar.UserId, &avatar.Hash, &avatar.ContentType, &sizes, &avatar.UpdatedAt); err != nil {
+					return err
+				}
+				if avatar.Sizes, err = parseSizes(sizes); err != nil {
+					return err
+				}
+				avatars = append(avatars, avatar)
+			}
+
+			return rows.Err()
+		})
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+	}
+
+	return avatars, nil
+}
+
+// SaveAvatar sets the avatar of a user, replacing the one it had.
+func (repo *sqlRepo) SaveAvatar(ctx context.Context, avatar entities.Avatar) error {
+	sizes := make([]string, len(avatar.Sizes))
+	for i, size := range avatar.Sizes {
+		sizes[i] = strconv.Itoa(size)
+	}
+
+	_, err := repo.conn(ctx).ExecContext(ctx, utils.SaveAvatarQuery,
+		tenant.FromContext(ctx), avatar.UserId, avatar.Hash, avatar.ContentType, strings.Join(sizes, ","), avatar.UpdatedAt)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+func parseSizes(column string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(column, ",") {
+		size, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid avatar sizes %q", column)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
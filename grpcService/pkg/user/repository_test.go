@@ -1,19 +1,33 @@
 package user_test
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-kit/log"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/encryption"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/user"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
 )
 
+func encryptedUserRows(keys *encryption.Keyring, u entities.User) *sqlmock.Rows {
+	name, _ := keys.Encrypt(u.Name)
+	email, _ := keys.Encrypt(u.Email)
+	return sqlmock.NewRows([]string{"first_name", "age", "email", "key_version", "version", "attributes"}).AddRow(name, u.Age, email, keys.ActiveVersion(), u.Version, nil)
+}
+
 func TestNewRepo(t *testing.T) {
 	var logger log.Logger
 	{
@@ -27,8 +41,9 @@ func TestNewRepo(t *testing.T) {
 	}
 
 	db, _ := utils.NewMock(logger)
+	keys := utils.NewKeyringMock()
 
-	repo := user.NewSQL(db, logger)
+	repo := user.NewSQL(db, keys, logger)
 
 	assert.NotNil(t, repo)
 }
@@ -55,10 +70,11 @@ func TestCreateUser(t *testing.T) {
 
 	db, mock := utils.NewMock(logger)
 	defer db.Close()
+	keys := utils.NewKeyringMock()
 
 	userId := utils.GenerateId()
 
-	repo := user.NewSQL(db, logger)
+	repo := user.NewSQL(db, keys, logger)
 
 	testCases := []struct {
 		Name           string
@@ -74,7 +90,7 @@ func TestCreateUser(t *testing.T) {
 			UserID: userId,
 			buildMock: func(mock sqlmock.Sqlmock, user entities.User) {
 				mock.ExpectPrepare(utils.CreateUserQuery)
-				mock.ExpectExec(utils.CreateUserQuery).WithArgs(user.Name, userId, user.Pass, user.Age, user.Email).WillReturnResult(sqlmock.NewResult(int64(1), int64(1)))
+				mock.ExpectExec(utils.CreateUserQuery).WithArgs(sqlmock.AnyArg(), userId, user.Pass, user.Age, sqlmock.AnyArg(), keys.BlindIndex(user.Email), keys.ActiveVersion(), tenant.Default, nil).WillReturnResult(sqlmock.NewResult(int64(1), int64(1)))
 			},
 			assertResponse: func(t *testing.T, id string, err error) {
 				assert.Equal(t, userId, id)
@@ -86,7 +102,7 @@ func TestCreateUser(t *testing.T) {
 			User: userMock,
 			buildMock: func(mock sqlmock.Sqlmock, user entities.User) {
 				mock.ExpectPrepare(utils.CreateUserQuery)
-				mock.ExpectExec(utils.CreateUserQuery).WithArgs(user.Name, userId, user.Pass, user.Age, user.Email).WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectExec(utils.CreateUserQuery).WithArgs(sqlmock.AnyArg(), userId, user.Pass, user.Age, sqlmock.AnyArg(), keys.BlindIndex(user.Email), keys.ActiveVersion(), tenant.Default, nil).WillReturnError(sqlmock.ErrCancelled)
 			},
 			assertResponse: func(t *testing.T, id string, err error) {
 				assert.Equal(t, "", id)
@@ -128,10 +144,11 @@ func TestGetUser(t *testing.T) {
 
 	db, mock := utils.NewMock(logger)
 	defer db.Close()
+	keys := utils.NewKeyringMock()
 
 	userId := utils.GenerateId()
 
-	repo := user.NewSQL(db, logger)
+	repo := user.NewSQL(db, keys, logger)
 
 	testCases := []struct {
 		Name           string
@@ -143,9 +160,9 @@ func TestGetUser(t *testing.T) {
 			Name:   "Get Existing User",
 			UserID: userId,
 			buildMock: func(mock sqlmock.Sqlmock, userId string) {
-				res := sqlmock.NewRows([]string{"first_name", "age", "email"}).AddRow(userMock.Name, userMock.Age, userMock.Email)
+				res := encryptedUserRows(keys, userMock)
 				mock.ExpectPrepare(utils.GetUserQuery)
-				mock.ExpectQuery(utils.GetUserQuery).WithArgs(userId).WillReturnRows(res)
+				mock.ExpectQuery(utils.GetUserQuery).WithArgs(userId, tenant.Default).WillReturnRows(res)
 			},
 			assertResponse: func(t *testing.T, resp entities.User, err error) {
 				assert.Nil(t, err)
@@ -157,9 +174,9 @@ func TestGetUser(t *testing.T) {
 			Name:   "Get non existing user",
 			UserID: userId,
 			buildMock: func(mock sqlmock.Sqlmock, userId string) {
-				res := sqlmock.NewRows([]string{"first_name", "age", "email"})
+				res := sqlmock.NewRows([]string{"first_name", "age", "email", "key_version", "version", "attributes"})
 				mock.ExpectPrepare(utils.GetUserQuery)
-				mock.ExpectQuery(utils.GetUserQuery).WithArgs(userId).WillReturnRows(res)
+				mock.ExpectQuery(utils.GetUserQuery).WithArgs(userId, tenant.Default).WillReturnRows(res)
 			},
 			assertResponse: func(t *testing.T, resp entities.User, err error) {
 				assert.Error(t, err)
@@ -195,10 +212,11 @@ func TestDeleteUser(t *testing.T) {
 
 	db, mock := utils.NewMock(logger)
 	defer db.Close()
+	keys := utils.NewKeyringMock()
 
 	userId := utils.GenerateId()
 
-	repo := user.NewSQL(db, logger)
+	repo := user.NewSQL(db, keys, logger)
 
 	testCases := []struct {
 		Name           string
@@ -210,8 +228,12 @@ func TestDeleteUser(t *testing.T) {
 			Name:   "Delete Existing User",
 			UserID: userId,
 			buildMock: func(mock sqlmock.Sqlmock, userId string) {
+				mock.ExpectExec(utils.DeleteUserMembershipsQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAttributeIndexQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAvatarQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserPreferencesQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
 				mock.ExpectPrepare(utils.DeleteUserQuery)
-				mock.ExpectExec(utils.DeleteUserQuery).WithArgs(userId).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(utils.DeleteUserQuery).WithArgs(userId, tenant.Default).WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			assertResponse: func(t *testing.T, err error) {
 				assert.Nil(t, err)
@@ -222,13 +244,33 @@ func TestDeleteUser(t *testing.T) {
 			Name:   "Delete non existing user",
 			UserID: userId,
 			buildMock: func(mock sqlmock.Sqlmock, userId string) {
+				mock.ExpectExec(utils.DeleteUserMembershipsQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAttributeIndexQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAvatarQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserPreferencesQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
 				mock.ExpectPrepare(utils.DeleteUserQuery)
-				mock.ExpectExec(utils.DeleteUserQuery).WithArgs(userId).WillReturnError(sql.ErrNoRows)
+				mock.ExpectExec(utils.DeleteUserQuery).WithArgs(userId, tenant.Default).WillReturnError(sql.ErrNoRows)
 			},
 			assertResponse: func(t *testing.T, err error) {
 				assert.ErrorIs(t, sql.ErrNoRows, err)
 			},
 		},
+
+		{
+			Name:   "No Row Deleted",
+			UserID: userId,
+			buildMock: func(mock sqlmock.Sqlmock, userId string) {
+				mock.ExpectExec(utils.DeleteUserMembershipsQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAttributeIndexQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAvatarQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserPreferencesQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectPrepare(utils.DeleteUserQuery)
+				mock.ExpectExec(utils.DeleteUserQuery).WithArgs(userId, tenant.Default).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -243,3 +285,484 @@ func TestDeleteUser(t *testing.T) {
 	}
 
 }
+
+func TestGetUserFromReplica(t *testing.T) {
+	var logger log.Logger
+	{
+		logger = log.NewLogfmtLogger(os.Stderr)
+		logger = log.NewSyncLogger(logger)
+		logger = log.With(logger,
+			"service", "grpcUserService",
+			"time:", log.DefaultTimestampUTC,
+			"caller", log.DefaultCaller,
+		)
+	}
+
+	userMock := entities.User{
+		Name:    "Timoteo",
+		Age:     19,
+		Email:   "timoteo@globant.com",
+		Version: 3,
+	}
+
+	userId := utils.GenerateId()
+	keys := utils.NewKeyringMock()
+
+	testCases := []struct {
+		Name           string
+		Context        context.Context
+		buildMock      func(primary sqlmock.Sqlmock, replica sqlmock.Sqlmock)
+		assertResponse func(t *testing.T, response entities.User, err error)
+	}{
+		{
+			Name:    "Read Goes To Replica",
+			Context: context.Background(),
+			buildMock: func(primary sqlmock.Sqlmock, replica sqlmock.Sqlmock) {
+				res := encryptedUserRows(keys, userMock)
+				replica.ExpectPrepare(utils.GetUserQuery)
+				replica.ExpectQuery(utils.GetUserQuery).WithArgs(userId, tenant.Default).WillReturnRows(res)
+			},
+			assertResponse: func(t *testing.T, resp entities.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, userMock, resp)
+			},
+		},
+		{
+			Name:    "Read Your Writes Goes To Primary",
+			Context: database.WithReadYourWrites(context.Background()),
+			buildMock: func(primary sqlmock.Sqlmock, replica sqlmock.Sqlmock) {
+				res := encryptedUserRows(keys, userMock)
+				primary.ExpectPrepare(utils.GetUserQuery)
+				primary.ExpectQuery(utils.GetUserQuery).WithArgs(userId, tenant.Default).WillReturnRows(res)
+			},
+			assertResponse: func(t *testing.T, resp entities.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, userMock, resp)
+			},
+		},
+		{
+			Name:    "Unavailable Replica Falls Back To Primary",
+			Context: context.Background(),
+			buildMock: func(primary sqlmock.Sqlmock, replica sqlmock.Sqlmock) {
+				replica.ExpectPrepare(utils.GetUserQuery).WillReturnError(mysql.ErrInvalidConn)
+				res := encryptedUserRows(keys, userMock)
+				primary.ExpectPrepare(utils.GetUserQuery)
+				primary.ExpectQuery(utils.GetUserQuery).WithArgs(userId, tenant.Default).WillReturnRows(res)
+			},
+			assertResponse: func(t *testing.T, resp entities.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, userMock, resp)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			primaryDB, primaryMock := utils.NewMock(logger)
+			defer primaryDB.Close()
+			replicaDB, replicaMock := utils.NewMock(logger)
+			defer replicaDB.Close()
+
+			replicas := database.NewReplicaSet([]*sql.DB{replicaDB}, time.Second, logger)
+			repo := user.NewReplicatedSQL(primaryDB, replicas, keys, logger)
+
+			tc.buildMock(primaryMock, replicaMock)
+
+			res, err := repo.GetUser(tc.Context, userId)
+			tc.assertResponse(t, res, err)
+			assert.NoError(t, primaryMock.ExpectationsWereMet())
+			assert.NoError(t, replicaMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	var logger log.Logger
+	{
+		logger = log.NewLogfmtLogger(os.Stderr)
+		logger = log.NewSyncLogger(logger)
+		logger = log.With(logger,
+			"service", "grpcUserService",
+			"time:", log.DefaultTimestampUTC,
+			"caller", log.DefaultCaller,
+		)
+	}
+
+	userMock := entities.User{
+		Name:  "Timo",
+		Age:   19,
+		Pass:  "1234",
+		Email: "timoteo@globant.com",
+	}
+
+	oldId := utils.GenerateId()
+	keys := utils.NewKeyringMock()
+	newId := utils.GenerateId()
+
+	testCases := []struct {
+		Name           string
+		buildMock      func(mock sqlmock.Sqlmock)
+		assertResponse func(t *testing.T, err error)
+	}{
+		{
+			Name: "Both Statements Commit Together",
+			buildMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(utils.DeleteUserMembershipsQuery).WithArgs(tenant.Default, oldId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAttributeIndexQuery).WithArgs(tenant.Default, oldId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAvatarQuery).WithArgs(tenant.Default, oldId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserPreferencesQuery).WithArgs(tenant.Default, oldId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectPrepare(utils.DeleteUserQuery)
+				mock.ExpectExec(utils.DeleteUserQuery).WithArgs(oldId, tenant.Default).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(utils.CreateUserQuery)
+				mock.ExpectExec(utils.CreateUserQuery).WithArgs(sqlmock.AnyArg(), newId, userMock.Pass, userMock.Age, sqlmock.AnyArg(), keys.BlindIndex(userMock.Email), keys.ActiveVersion(), tenant.Default, nil).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name: "Failed Statement Rolls Back Both",
+			buildMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(utils.DeleteUserMembershipsQuery).WithArgs(tenant.Default, oldId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAttributeIndexQuery).WithArgs(tenant.Default, oldId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserAvatarQuery).WithArgs(tenant.Default, oldId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(utils.DeleteUserPreferencesQuery).WithArgs(tenant.Default, oldId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectPrepare(utils.DeleteUserQuery)
+				mock.ExpectExec(utils.DeleteUserQuery).WithArgs(oldId, tenant.Default).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(utils.CreateUserQuery)
+				mock.ExpectExec(utils.CreateUserQuery).WithArgs(sqlmock.AnyArg(), newId, userMock.Pass, userMock.Age, sqlmock.AnyArg(), keys.BlindIndex(userMock.Email), keys.ActiveVersion(), tenant.Default, nil).WillReturnError(&mysql.MySQLError{Number: 1062})
+				mock.ExpectRollback()
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.Error(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, mock := utils.NewMock(logger)
+			defer db.Close()
+
+			repo := user.NewSQL(db, keys, logger)
+			tc.buildMock(mock)
+
+			err := repo.WithTx(context.Background(), func(ctx context.Context) error {
+				if err := repo.DeleteUser(ctx, oldId); err != nil {
+					return err
+				}
+				_, err := repo.CreateUser(ctx, userMock, newId)
+				return err
+			})
+			tc.assertResponse(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	var logger log.Logger
+	{
+		logger = log.NewLogfmtLogger(os.Stderr)
+		logger = log.NewSyncLogger(logger)
+		logger = log.With(logger,
+			"service", "grpcUserService",
+			"time:", log.DefaultTimestampUTC,
+			"caller", log.DefaultCaller,
+		)
+	}
+
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+	keys := utils.NewKeyringMock()
+
+	userMock := entities.User{
+		Id:    utils.GenerateId(),
+		Name:  "Timo",
+		Pass:  "hashed",
+		Age:   19,
+		Email: "timoteo@globant.com",
+	}
+
+	name, _ := keys.Encrypt(userMock.Name)
+	email, _ := keys.Encrypt(userMock.Email)
+
+	repo := user.NewSQL(db, keys, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "first_name", "pass", "age", "email", "key_version"}).
+		AddRow(userMock.Id, name, userMock.Pass, userMock.Age, email, keys.ActiveVersion())
+	mock.ExpectPrepare(utils.GetUserByEmailQuery)
+	mock.ExpectQuery(utils.GetUserByEmailQuery).WithArgs(keys.BlindIndex("Timoteo@globant.com"), tenant.Default).WillReturnRows(rows)
+
+	res, err := repo.GetUserByEmail(context.Background(), "Timoteo@globant.com")
+	assert.NoError(t, err)
+	assert.Equal(t, userMock, res)
+}
+
+func TestUserOfAnotherTenant(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+	keys := utils.NewKeyringMock()
+	userId := utils.GenerateId()
+
+	mock.ExpectPrepare(utils.GetUserQuery)
+	mock.ExpectQuery(utils.GetUserQuery).WithArgs(userId, "globex").WillReturnRows(sqlmock.NewRows([]string{"first_name", "age", "email", "key_version", "version", "attributes"}))
+
+	_, err := user.NewSQL(db, keys, logger).GetUser(tenant.WithTenant(context.Background(), "globex"), userId)
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRotateKeys(t *testing.T) {
+	var logger log.Logger
+	{
+		logger = log.NewLogfmtLogger(os.Stderr)
+		logger = log.NewSyncLogger(logger)
+		logger = log.With(logger,
+			"service", "grpcUserService",
+			"time:", log.DefaultTimestampUTC,
+			"caller", log.DefaultCaller,
+		)
+	}
+
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+	keys := utils.NewKeyringMock()
+
+	oldKeys, _ := encryption.NewKeyring(1, map[uint32][]byte{1: bytes.Repeat([]byte{1}, 32)}, bytes.Repeat([]byte{3}, 32))
+	oldName, _ := oldKeys.Encrypt("Timo")
+	oldEmail, _ := oldKeys.Encrypt("timoteo@globant.com")
+	// Wrapped by a master key the keyring doesn't hold.
+	lostKeys, _ := encryption.NewKeyring(9, map[uint32][]byte{9: bytes.Repeat([]byte{9}, 32)}, bytes.Repeat([]byte{3}, 32))
+	lostName, _ := lostKeys.Encrypt("Lost")
+	lostEmail, _ := lostKeys.Encrypt("lost@globant.com")
+
+	repo := user.NewSQL(db, keys, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "first_name", "email", "key_version"}).
+		AddRow("user-1", lostName, lostEmail, 9).
+		AddRow("user-2", oldName, oldEmail, 1).
+		AddRow("user-3", "Legacy", "legacy@globant.com", 0)
+	mock.ExpectQuery(utils.ListStaleKeyUsersQuery).WithArgs(keys.ActiveVersion(), "", 3).WillReturnRows(rows)
+	mock.ExpectExec(utils.RewrapUserQuery).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), keys.BlindIndex("timoteo@globant.com"), keys.ActiveVersion(), "user-2", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(utils.RewrapUserQuery).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), keys.BlindIndex("legacy@globant.com"), keys.ActiveVersion(), "user-3", 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	batch, err := repo.RotateKeys(context.Background(), "", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, user.KeyRotation{Updated: 2, Failed: []string{"user-1"}, Next: "user-3"}, batch)
+
+	// The next batch starts after the last user read, the failed one isn't
+	// read again.
+	mock.ExpectQuery(utils.ListStaleKeyUsersQuery).WithArgs(keys.ActiveVersion(), "user-3", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "email", "key_version"}))
+
+	batch, err = repo.RotateKeys(context.Background(), batch.Next, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, user.KeyRotation{}, batch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEncryptLegacyUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	keys := utils.NewKeyringMock()
+	legacyRows := func(ids ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "first_name", "email", "key_version"})
+		for _, id := range ids {
+			rows.AddRow(id, "Legacy", id+"@globant.com", 0)
+		}
+		return rows
+	}
+
+	t.Run("Encrypts Every Batch", func(t *testing.T) {
+		db, mock := utils.NewMock(logger)
+		defer db.Close()
+
+		mock.ExpectQuery(utils.ListLegacyUsersQuery).WithArgs("", 2).WillReturnRows(legacyRows("user-1", "user-2"))
+		for _, id := range []string{"user-1", "user-2"} {
+			mock.ExpectExec(utils.RewrapUserQuery).
+				WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), keys.BlindIndex(id+"@globant.com"), keys.ActiveVersion(), id, 0).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectQuery(utils.ListLegacyUsersQuery).WithArgs("user-2", 2).WillReturnRows(legacyRows("user-3"))
+		mock.ExpectExec(utils.RewrapUserQuery).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), keys.BlindIndex("user-3@globant.com"), keys.ActiveVersion(), "user-3", 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, user.NewSQL(db, keys, logger).EncryptLegacyUsers(context.Background(), 2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Fails When A User Can't Be Saved", func(t *testing.T) {
+		db, mock := utils.NewMock(logger)
+		defer db.Close()
+
+		// Another user holds the same email.
+		mock.ExpectQuery(utils.ListLegacyUsersQuery).WithArgs("", 2).WillReturnRows(legacyRows("user-1"))
+		mock.ExpectExec(utils.RewrapUserQuery).WillReturnError(&mysql.MySQLError{Number: 1062})
+
+		assert.Error(t, user.NewSQL(db, keys, logger).EncryptLegacyUsers(context.Background(), 2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreateUsersDuplicate(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+	keys := utils.NewKeyringMock()
+
+	users := []entities.User{
+		{Id: "user-1", Name: "Timo", Age: 19, Pass: "1234", Email: "timoteo@globant.com"},
+		{Id: "user-2", Name: "Ana", Age: 21, Pass: "1234", Email: "ana@globant.com"},
+	}
+
+	args := make([]driver.Value, 0, 18)
+	for _, u := range users {
+		args = append(args, sqlmock.AnyArg(), u.Id, u.Pass, u.Age, sqlmock.AnyArg(), keys.BlindIndex(u.Email), keys.ActiveVersion(), tenant.Default, nil)
+	}
+
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '" + tenant.Default + "-" + keys.BlindIndex("ana@globant.com") + "' for key 'user_tenant_email_index'"}
+	mock.ExpectExec(utils.Placeholders(utils.CreateUsersQuery, 2, 9)).WithArgs(args...).WillReturnError(duplicate)
+
+	err := user.NewSQL(db, keys, logger).CreateUsers(context.Background(), users)
+
+	var itemErr *user.BatchItemError
+	assert.ErrorAs(t, err, &itemErr)
+	assert.Equal(t, 1, itemErr.Index)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAndDeleteUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+	keys := utils.NewKeyringMock()
+	repo := user.NewSQL(db, keys, logger)
+
+	name, _ := keys.Encrypt("Timo")
+	email, _ := keys.Encrypt("timoteo@globant.com")
+	mock.ExpectQuery(utils.Placeholders(utils.GetUsersQuery, 2, 1)).
+		WithArgs(tenant.Default, "user-1", "user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age", "email", "key_version", "version", "attributes"}).AddRow("user-1", name, 19, email, keys.ActiveVersion(), 2, `{"team":"core"}`))
+
+	users, err := repo.GetUsers(context.Background(), []string{"user-1", "user-2"})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "Timo", users["user-1"].Name)
+	assert.Equal(t, uint64(2), users["user-1"].Version)
+	assert.Equal(t, map[string]interface{}{"team": "core"}, users["user-1"].Attributes)
+
+	mock.ExpectQuery(utils.Placeholders(utils.LockUsersQuery, 2, 1)).
+		WithArgs(tenant.Default, "user-1", "user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("user-1"))
+	mock.ExpectExec(utils.Placeholders(utils.DeleteUsersMembershipsQuery, 1, 1)).
+		WithArgs(tenant.Default, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(utils.Placeholders(utils.DeleteUsersAttributeIndexQuery, 1, 1)).
+		WithArgs(tenant.Default, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(utils.Placeholders(utils.DeleteUsersAvatarQuery, 1, 1)).
+		WithArgs(tenant.Default, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(utils.Placeholders(utils.DeleteUsersPreferencesQuery, 1, 1)).
+		WithArgs(tenant.Default, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(utils.Placeholders(utils.DeleteUsersQuery, 1, 1)).
+		WithArgs(tenant.Default, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deleted, err := repo.DeleteUsers(context.Background(), []string{"user-1", "user-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user-1"}, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFilterUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+	keys := utils.NewKeyringMock()
+
+	name, _ := keys.Encrypt("Timo")
+	email, _ := keys.Encrypt("timoteo@globant.com")
+	query := fmt.Sprintf(utils.FilterUsersQuery, utils.AttributeFilterClause+utils.AttributeFilterClause)
+	mock.ExpectQuery(query).
+		WithArgs(tenant.Default, "user-0", tenant.Default, "level", "3", tenant.Default, "team", "core", 11).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age", "email", "key_version", "version", "attributes"}).
+			AddRow("user-1", name, 19, email, keys.ActiveVersion(), 1, `{"level":3,"team":"core"}`))
+
+	users, err := user.NewSQL(db, keys, logger).FilterUsers(context.Background(), map[string]string{"team": "core", "level": "3"}, "user-0", 11)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "Timo", users[0].Name)
+	assert.Equal(t, map[string]interface{}{"level": float64(3), "team": "core"}, users[0].Attributes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateUserAttributes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+	repo := user.NewSQL(db, utils.NewKeyringMock(), logger)
+	userId := utils.GenerateId()
+
+	mock.ExpectExec(utils.UpdateUserAttributesQuery).
+		WithArgs(`{"team":"core"}`, sqlmock.AnyArg(), userId, tenant.Default).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(utils.DeleteUserAttributeIndexQuery).WithArgs(tenant.Default, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(utils.Placeholders(utils.InsertAttributeIndexQuery, 1, 4)).
+		WithArgs(tenant.Default, userId, "team", "core").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := context.Background()
+	assert.NoError(t, repo.UpdateUserAttributes(ctx, userId, map[string]interface{}{"team": "core"}))
+	assert.NoError(t, repo.SaveAttributeIndex(ctx, userId, map[string]string{"team": "core"}))
+
+	mock.ExpectExec(utils.UpdateUserAttributesQuery).
+		WithArgs(nil, sqlmock.AnyArg(), userId, tenant.Default).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Equal(t, sql.ErrNoRows, repo.UpdateUserAttributes(ctx, userId, nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportCheckpoint(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	db, mock := utils.NewMock(logger)
+	defer db.Close()
+	repo := user.NewSQL(db, utils.NewKeyringMock(), logger)
+
+	mock.ExpectExec(utils.StartImportQuery).
+		WithArgs(tenant.Default, "import-1", "csv").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(utils.GetImportQuery).
+		WithArgs(tenant.Default, "import-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "format", "rows_committed", "imported", "failed"}).AddRow("import-1", "csv", 500, 480, 20))
+
+	checkpoint, err := repo.StartImport(context.Background(), "import-1", "csv")
+	assert.NoError(t, err)
+	assert.Equal(t, entities.ImportCheckpoint{ImportId: "import-1", Format: "csv", Rows: 500, Imported: 480, Failed: 20}, checkpoint)
+
+	checkpoint.Rows, checkpoint.Imported = 1000, 980
+	mock.ExpectExec(utils.AdvanceImportQuery).
+		WithArgs(uint64(1000), uint64(980), uint64(20), tenant.Default, "import-1", uint64(500)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	moved, err := repo.AdvanceImport(context.Background(), checkpoint, 500)
+	assert.NoError(t, err)
+	assert.False(t, moved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -4,58 +4,190 @@ import (
 	"context"
 
 	"database/sql"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
-	"github.com/google/uuid"
+	"google.golang.org/grpc/status"
 
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/attributes"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/consent"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
 	entities "github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
 	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/etag"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/idempotency"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/invitation"
 	mapper "github.com/timoteoBone/microservice-project/grpcService/pkg/mapper"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/privacy"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
 )
 
 type Repository interface {
 	GetUser(ctx context.Context, userId string) (entities.User, error)
+	GetUserByEmail(ctx context.Context, email string) (entities.User, error)
 	CreateUser(ctx context.Context, user entities.User, newId string) (string, error)
 	DeleteUser(ctx context.Context, userId string) error
+	LockUser(ctx context.Context, userId string) (uint64, error)
+	CreateUsers(ctx context.Context, users []entities.User) error
+	GetUsers(ctx context.Context, userIds []string) (map[string]entities.User, error)
+	DeleteUsers(ctx context.Context, userIds []string) ([]string, error)
+	ListUsers(ctx context.Context, afterId string, limit int) ([]entities.User, error)
+	StartImport(ctx context.Context, importId string, format string) (entities.ImportCheckpoint, error)
+	AdvanceImport(ctx context.Context, checkpoint entities.ImportCheckpoint, from uint64) (bool, error)
+	SaveEvent(ctx context.Context, event *pb.Event) error
+	ListEvents(ctx context.Context, after int64, limit int, settle time.Duration) ([]entities.EventLogEntry, error)
+	LastEventSeq(ctx context.Context) (int64, error)
+	ListUserEvents(ctx context.Context, userId string) ([]entities.EventLogEntry, error)
+	SaveErasure(ctx context.Context, receipt entities.ErasureReceipt) error
+	GetErasure(ctx context.Context, userId string) (entities.ErasureReceipt, error)
+	UpdateUserAttributes(ctx context.Context, userId string, attrs map[string]interface{}) error
+	SaveAttributeIndex(ctx context.Context, userId string, entries map[string]string) error
+	FilterUsers(ctx context.Context, filters map[string]string, afterId string, limit int) ([]entities.User, error)
+	GetAvatar(ctx context.Context, userId string) (entities.Avatar, error)
+	GetAvatars(ctx context.Context, userIds []string) ([]entities.Avatar, error)
+	SaveAvatar(ctx context.Context, avatar entities.Avatar) error
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Schemas finds the attribute schema of the tenant of a request, nil when
+// the tenant has none.
+type Schemas interface {
+	Schema(ctx context.Context) (*attributes.Schema, error)
 }
 
 type service struct {
 	Repo   Repository
 	Logger log.Logger
+	Watch  WatchConfig
+	Bulk   BulkConfig
+	// Idempotency, when set, makes CreateUser requests carrying an
+	// idempotency key run once.
+	Idempotency *idempotency.Guard
+	// Audit, when set, records every write to a user and every attempt at
+	// one that failed, and adds the entries about a user to its data export.
+	Audit audit.Repository
+	// Receipts signs the receipts of erased users, erasing users fails
+	// without it.
+	Receipts *privacy.Signer
+	// Attributes finds the schema custom attributes are checked against.
+	// Without it users can't hold attributes.
+	Attributes Schemas
+	// Avatars sets where the thumbnails of avatars are kept and what
+	// uploads are taken.
+	Avatars AvatarConfig
+	// Ids makes the ids of new users, imports and erasure receipts.
+	Ids ids.IDGenerator
+	// Consents, when set, keeps users who have yet to accept the current
+	// version of a mandatory policy from authenticating, and adds their
+	// consents to their data export.
+	Consents consent.Repository
+	// Invitations, when set, are deleted with the user they were accepted
+	// by on erasure, and added to its data export.
+	Invitations invitation.Repository
 }
 
 func NewService(l log.Logger, r Repository) *service {
-	return &service{r, l}
+	return &service{r, l, DefaultWatchConfig(), DefaultBulkConfig(), nil, nil, nil, nil, DefaultAvatarConfig(), ids.NewUUIDv7Generator(), nil, nil}
 }
 
 func (s *service) CreateUser(ctx context.Context, userReq entities.CreateUserRequest) (entities.CreateUserResponse, error) {
 	s.Logger.Log(s.Logger, "request", "create user", "received")
 
+	key, fingerprint, ok := idempotency.FromContext(ctx)
+	if !ok || s.Idempotency == nil {
+		return s.createUser(ctx, userReq, nil)
+	}
+	if fingerprint == "" {
+		fingerprint = idempotency.CreateUserFingerprint(userReq)
+	}
+
+	// The same key sent to two tenants names two requests.
+	var response entities.CreateUserResponse
+	err := s.Idempotency.Do(ctx, tenant.FromContext(ctx)+"/CreateUser", key, fingerprint, &response, func(ctx context.Context, commit idempotency.Commit) error {
+		var err error
+		response, err = s.createUser(ctx, userReq, commit)
+		return err
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); !ok {
+			level.Error(s.Logger).Log("error", err)
+			err = dataBaseError(err)
+		}
+		return entities.CreateUserResponse{}, err
+	}
+
+	return response, nil
+}
+
+// createUser stores the response with commit, when given, in the
+// transaction creating the user.
+func (s *service) createUser(ctx context.Context, userReq entities.CreateUserRequest, commit idempotency.Commit) (entities.CreateUserResponse, error) {
 	response := entities.CreateUserResponse{}
 	status := entities.Status{}
 
 	user := mapper.CreateUserRequestToUser(userReq)
-	newId := generateId()
-	genId, err := s.Repo.CreateUser(ctx, user, newId)
+	newId := s.Ids.NewId()
+
+	schema, err := s.attributeSchema(ctx)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.CreateUserResponse{}, dataBaseError(err)
+	}
+	if err := schema.Validate(user.Attributes); err != nil {
+		return entities.CreateUserResponse{}, err
+	}
+
+	err = s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		genId, err := s.Repo.CreateUser(ctx, user, newId)
+		if err != nil {
+			return err
+		}
+
+		if entries := schema.Index(user.Attributes); len(entries) > 0 {
+			if err := s.Repo.SaveAttributeIndex(ctx, genId, entries); err != nil {
+				return err
+			}
+		}
+
+		if err := s.Repo.SaveEvent(ctx, events.NewUserCreated(genId)); err != nil {
+			return err
+		}
+
+		if err := s.record(ctx, audit.NewEntry(ctx, "CreateUser", genId, nil, &user, nil)); err != nil {
+			return err
+		}
+
+		status.Message = "created successfully"
+		response.Status = status
+		response.UserId = genId
+		if commit != nil {
+			return commit(ctx, response)
+		}
+		return nil
+	})
 
 	if err != nil {
 		if mysqlErr, ok := err.(*mysql.MySQLError); ok {
 			if mysqlErr.Number == 1062 {
 				level.Error(s.Logger).Log(err)
-				return entities.CreateUserResponse{}, errors.NewUserAlreadyExists()
+				err = errors.NewUserAlreadyExists()
+				s.recordFailure(ctx, audit.NewEntry(ctx, "CreateUser", "", nil, &user, err))
+				return entities.CreateUserResponse{}, err
 			}
 		}
 		level.Error(s.Logger).Log("error", err)
-		return entities.CreateUserResponse{}, errors.NewDataBaseError()
+		err = dataBaseError(err)
+		s.recordFailure(ctx, audit.NewEntry(ctx, "CreateUser", "", nil, &user, err))
+		return entities.CreateUserResponse{}, err
 	}
 
-	status.Message = "created successfully"
-	response.Status = status
-	response.UserId = genId
-
 	return response, nil
 }
 
@@ -69,13 +201,15 @@ func (s *service) GetUser(ctx context.Context, user entities.GetUserRequest) (en
 			return entities.GetUserResponse{}, errors.NewUserNotFound()
 		}
 		level.Error(s.Logger).Log("error", err)
-		return entities.GetUserResponse{}, errors.NewDataBaseError()
+		return entities.GetUserResponse{}, dataBaseError(err)
 	}
 
 	response := entities.GetUserResponse{
-		Name: res.Name,
-		Id:   user.UserID,
-		Age:  res.Age,
+		Name:       res.Name,
+		Id:         user.UserID,
+		Age:        res.Age,
+		Etag:       etag.Format(res.Version),
+		Attributes: res.Attributes,
 	}
 
 	return response, nil
@@ -85,16 +219,57 @@ func (s *service) DeleteUser(ctx context.Context, rq entities.DeleteUserRequest)
 	s.Logger.Log(s.Logger, "delete user", "recevied")
 
 	userId := rq.UserId
+	if rq.IfMatch != "" && !etag.Valid(rq.IfMatch) {
+		return entities.DeleteUserResponse{}, errors.NewInvalidField("if_match", "must be \"*\" or a list of etags")
+	}
+
+	var avatars []entities.Avatar
+	err := s.Repo.WithTx(ctx, func(ctx context.Context) error {
+		if rq.IfMatch != "" {
+			if err := s.checkVersion(ctx, userId, rq.IfMatch); err != nil {
+				return err
+			}
+		}
+
+		// The audit log keeps what the user looked like before.
+		var before *entities.User
+		if s.Audit != nil {
+			user, err := s.Repo.GetUser(ctx, userId)
+			if err != nil {
+				return err
+			}
+			before = &user
+		}
+
+		var err error
+		if avatars, err = s.avatarsOf(ctx, userId); err != nil {
+			return err
+		}
+
+		if err := s.Repo.DeleteUser(ctx, userId); err != nil {
+			return err
+		}
+
+		if err := s.Repo.SaveEvent(ctx, events.NewUserDeleted(userId)); err != nil {
+			return err
+		}
 
-	err := s.Repo.DeleteUser(ctx, userId)
+		return s.record(ctx, audit.NewEntry(ctx, "DeleteUser", userId, before, nil, nil))
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		switch {
+		case err == sql.ErrNoRows:
 			level.Error(s.Logger).Log("error", err)
-			return entities.DeleteUserResponse{}, errors.NewUserNotFound()
+			err = errors.NewUserNotFound()
+		case isPreconditionFailed(err):
+		default:
+			level.Error(s.Logger).Log("error", err)
+			err = dataBaseError(err)
 		}
-		level.Error(s.Logger).Log("error", err)
-		return entities.DeleteUserResponse{}, errors.NewDataBaseError()
+		s.recordFailure(ctx, audit.NewEntry(ctx, "DeleteUser", userId, nil, nil, err))
+		return entities.DeleteUserResponse{}, err
 	}
+	s.removeAvatars(ctx, avatars...)
 
 	return entities.DeleteUserResponse{
 		Status: entities.Status{
@@ -104,6 +279,55 @@ func (s *service) DeleteUser(ctx context.Context, rq entities.DeleteUserRequest)
 	}, nil
 }
 
-func generateId() string {
-	return uuid.NewString()
+// checkVersion fails with PreconditionFailed unless the current etag of the
+// user matches ifMatch. It locks the user for the rest of the transaction
+// carried by ctx so the write that follows sees the version it checked.
+func (s *service) checkVersion(ctx context.Context, userId string, ifMatch string) error {
+	version, err := s.Repo.LockUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if !etag.Match(ifMatch, etag.Format(version)) {
+		return errors.NewPreconditionFailed("user was modified since it was read")
+	}
+	return nil
+}
+
+// attributeSchema returns the attribute schema of the tenant of ctx, nil
+// when users of the tenant can't hold attributes.
+func (s *service) attributeSchema(ctx context.Context) (*attributes.Schema, error) {
+	if s.Attributes == nil {
+		return nil, nil
+	}
+	return s.Attributes.Schema(ctx)
+}
+
+// record appends entries to the audit log in the transaction carried by
+// ctx.
+func (s *service) record(ctx context.Context, entries ...entities.AuditEntry) error {
+	if s.Audit == nil || len(entries) == 0 {
+		return nil
+	}
+	return s.Audit.Record(ctx, entries...)
+}
+
+// recordFailure audits writes that didn't happen. The request failed
+// already, an error recording them is only logged.
+func (s *service) recordFailure(ctx context.Context, entries ...entities.AuditEntry) {
+	if err := s.record(ctx, entries...); err != nil {
+		level.Error(s.Logger).Log("msg", "recording audit entries failed", "error", err)
+	}
+}
+
+func isPreconditionFailed(err error) bool {
+	_, ok := err.(errors.PreconditionFailed)
+	return ok
+}
+
+func dataBaseError(err error) error {
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
 }
@@ -3,16 +3,23 @@ package user_test
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/go-kit/kit/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
 	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/idempotency"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
 	service "github.com/timoteoBone/microservice-project/grpcService/pkg/user"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewService(t *testing.T) {
@@ -73,6 +80,9 @@ func TestServiceCreateUser(t *testing.T) {
 	t.Run("Create User Valid case", func(t *testing.T) {
 		ctx := context.Background()
 		repo.On("CreateUser", ctx, user).Return(userId, nil)
+		repo.On("SaveEvent", ctx, mock.MatchedBy(func(event *pb.Event) bool {
+			return event.GetUser_Created().GetUser_Id() == userId
+		})).Return(nil)
 
 		res, err := srvc.CreateUser(ctx, correctCreateUserRequest)
 		assert.ErrorIs(t, err, nil)
@@ -123,6 +133,46 @@ func TestServiceCreateExistingUser(t *testing.T) {
 
 }
 
+func TestServiceCreateUserEventFails(t *testing.T) {
+	var logger log.Logger
+	{
+		logger = log.NewLogfmtLogger(os.Stderr)
+		logger = log.NewSyncLogger(logger)
+		logger = log.With(logger,
+			"service", "grpcUserService",
+			"time:", log.DefaultTimestampUTC,
+			"caller", log.DefaultCaller,
+		)
+	}
+
+	user := entities.User{
+		Name:  "Timo",
+		Pass:  "123",
+		Age:   19,
+		Email: "timoteo@globant.com",
+	}
+
+	userId := utils.GenerateId()
+
+	correctCreateUserRequest := entities.CreateUserRequest{
+		Name:  user.Name,
+		Pass:  user.Pass,
+		Age:   user.Age,
+		Email: user.Email,
+	}
+
+	repo := new(utils.RepoSitoryMock)
+	srvc := service.NewService(logger, repo)
+
+	ctx := context.Background()
+	repo.On("CreateUser", ctx, user).Return(userId, nil)
+	repo.On("SaveEvent", ctx, mock.Anything).Return(sql.ErrTxDone)
+
+	res, err := srvc.CreateUser(ctx, correctCreateUserRequest)
+	assert.Equal(t, myErr.NewDataBaseError(), err)
+	assert.Empty(t, res)
+}
+
 func TestServiceGetExistingUser(t *testing.T) {
 
 	var logger log.Logger
@@ -137,9 +187,10 @@ func TestServiceGetExistingUser(t *testing.T) {
 	}
 
 	user := entities.User{
-		Name: "Timo",
-		Pass: "123",
-		Age:  19,
+		Name:    "Timo",
+		Pass:    "123",
+		Age:     19,
+		Version: 2,
 	}
 
 	userId := utils.GenerateId()
@@ -152,6 +203,7 @@ func TestServiceGetExistingUser(t *testing.T) {
 		Name: user.Name,
 		Id:   userId,
 		Age:  user.Age,
+		Etag: `"2"`,
 	}
 
 	repo := new(utils.RepoSitoryMock)
@@ -200,6 +252,39 @@ func TestServiceGetNonExistingUser(t *testing.T) {
 
 }
 
+func TestServiceGetUserDatabaseUnavailable(t *testing.T) {
+
+	var logger log.Logger
+	{
+		logger = log.NewLogfmtLogger(os.Stderr)
+		logger = log.NewSyncLogger(logger)
+		logger = log.With(logger,
+			"service", "grpcUserService",
+			"time:", log.DefaultTimestampUTC,
+			"caller", log.DefaultCaller,
+		)
+	}
+
+	userId := utils.GenerateId()
+
+	correctGetUserRequest := entities.GetUserRequest{
+		UserID: userId,
+	}
+
+	expectedErr := myErr.NewDataBaseUnavailable()
+
+	repo := new(utils.RepoSitoryMock)
+	srvc := service.NewService(logger, repo)
+	ctx := context.Background()
+
+	repo.Mock.On("GetUser", ctx, userId).Return(entities.User{}, driver.ErrBadConn)
+
+	res, err := srvc.GetUser(ctx, correctGetUserRequest)
+	assert.Equal(t, entities.GetUserResponse{}, res)
+	assert.Equal(t, expectedErr, err)
+
+}
+
 func TestDeleteExistingUser(t *testing.T) {
 	var logger log.Logger
 	{
@@ -230,6 +315,9 @@ func TestDeleteExistingUser(t *testing.T) {
 
 	ctx := context.Background()
 	repo.Mock.On("DeleteUser", ctx, userId).Return(nil)
+	repo.Mock.On("SaveEvent", ctx, mock.MatchedBy(func(event *pb.Event) bool {
+		return event.GetUser_Deleted().GetUser_Id() == userId
+	})).Return(nil)
 
 	res, err := srvc.DeleteUser(ctx, correctDeleteUserRequest)
 	assert.Equal(t, succesfullDeleteUserRequest, res)
@@ -255,8 +343,11 @@ func TestDeleteNonExistingUser(t *testing.T) {
 		UserId: userId,
 	}
 
+	// Without an audit log the user isn't read first, the delete finds
+	// out it's missing.
 	repo := new(utils.RepoSitoryMock)
 	srvc := service.NewService(logger, repo)
+	assert.Nil(t, srvc.Audit)
 
 	ctx := context.Background()
 	repo.Mock.On("DeleteUser", ctx, userId).Return(sql.ErrNoRows)
@@ -264,9 +355,328 @@ func TestDeleteNonExistingUser(t *testing.T) {
 	res, err := srvc.DeleteUser(ctx, correctDeleteUserRequest)
 	assert.Empty(t, res)
 	assert.Equal(t, err.Error(), myErr.NewUserNotFound().Error())
+	repo.AssertNotCalled(t, "GetUser", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "SaveEvent", mock.Anything, mock.Anything)
 
 }
 
 func TestAuthenticateUser(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	user := entities.User{Id: "user-1", Name: "Timo", Email: "timoteo@globant.com", Pass: string(hash)}
+	terms := entities.Policy{Kind: "terms", Version: "2026-10", Mandatory: true}
+
+	testCases := []struct {
+		Name           string
+		Request        entities.AuthenticateRequest
+		buildMock      func(repo *utils.RepoSitoryMock, consents *utils.ConsentRepositoryMock)
+		assertResponse func(t *testing.T, res entities.AuthenticateResponse, err error)
+	}{
+		{
+			Name:    "Authenticate User",
+			Request: entities.AuthenticateRequest{Email: "timoteo@globant.com", Pass: "secret"},
+			buildMock: func(repo *utils.RepoSitoryMock, consents *utils.ConsentRepositoryMock) {
+				repo.On("GetUserByEmail", mock.Anything, "timoteo@globant.com").Return(user, nil)
+				consents.On("CurrentPolicies", mock.Anything).Return([]entities.Policy{terms}, nil)
+				consents.On("ListConsents", mock.Anything, "user-1").Return([]entities.Consent{{Kind: "terms", Version: "2026-10"}}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AuthenticateResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-1", res.UserId)
+			},
+		},
+		{
+			Name:    "New Terms Must Be Accepted",
+			Request: entities.AuthenticateRequest{Email: "timoteo@globant.com", Pass: "secret"},
+			buildMock: func(repo *utils.RepoSitoryMock, consents *utils.ConsentRepositoryMock) {
+				repo.On("GetUserByEmail", mock.Anything, "timoteo@globant.com").Return(user, nil)
+				consents.On("CurrentPolicies", mock.Anything).Return([]entities.Policy{terms}, nil)
+				consents.On("ListConsents", mock.Anything, "user-1").Return([]entities.Consent{{Kind: "terms", Version: "2025-01"}}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AuthenticateResponse, err error) {
+				assert.IsType(t, myErr.PolicyNotAccepted{}, err)
+				assert.Equal(t, map[string]string{"terms": "2026-10"}, myErr.PendingPolicies(status.Convert(err).Err()))
+				assert.Empty(t, res.UserId)
+			},
+		},
+		{
+			Name:    "Wrong Password",
+			Request: entities.AuthenticateRequest{Email: "timoteo@globant.com", Pass: "secreto"},
+			buildMock: func(repo *utils.RepoSitoryMock, consents *utils.ConsentRepositoryMock) {
+				repo.On("GetUserByEmail", mock.Anything, "timoteo@globant.com").Return(user, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.AuthenticateResponse, err error) {
+				assert.IsType(t, myErr.Unauthenticated{}, err)
+			},
+		},
+		{
+			Name:    "Unknown Email",
+			Request: entities.AuthenticateRequest{Email: "ana@globant.com", Pass: "secret"},
+			buildMock: func(repo *utils.RepoSitoryMock, consents *utils.ConsentRepositoryMock) {
+				repo.On("GetUserByEmail", mock.Anything, "ana@globant.com").Return(entities.User{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.AuthenticateResponse, err error) {
+				assert.IsType(t, myErr.Unauthenticated{}, err)
+			},
+		},
+		{
+			Name:      "Missing Password",
+			Request:   entities.AuthenticateRequest{Email: "timoteo@globant.com"},
+			buildMock: func(repo *utils.RepoSitoryMock, consents *utils.ConsentRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.AuthenticateResponse, err error) {
+				assert.IsType(t, myErr.FieldsMissingErr{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			consents := new(utils.ConsentRepositoryMock)
+			tc.buildMock(repo, consents)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Consents = consents
+			res, err := srvc.Authenticate(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+			consents.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceCreateUserIdempotency(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	keys := utils.NewKeyringMock()
+
+	request := entities.CreateUserRequest{Name: "Timo", Pass: "123", Age: 19, Email: "timoteo@globant.com"}
+	fingerprint := keys.BlindIndex(idempotency.Fingerprint("Timo", "19", "timoteo@globant.com", "123"))
+
+	testCases := []struct {
+		Name           string
+		Ctx            context.Context
+		buildMock      func(repo *utils.RepoSitoryMock, store *utils.IdempotencyStoreMock)
+		assertResponse func(t *testing.T, res entities.CreateUserResponse, err error)
+	}{
+		{
+			Name: "Response Is Stored With The User",
+			Ctx:  idempotency.WithKey(context.Background(), "key-1"),
+			buildMock: func(repo *utils.RepoSitoryMock, store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.MatchedBy(func(record entities.IdempotencyRecord) bool {
+					return record.Fingerprint == fingerprint
+				}), mock.Anything).Return(entities.IdempotencyRecord{}, true, nil)
+				repo.On("CreateUser", mock.Anything, mock.Anything).Return("user-1", nil)
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil)
+				store.On("Complete", mock.Anything, mock.MatchedBy(func(record entities.IdempotencyRecord) bool {
+					return strings.Contains(string(record.Response), "user-1")
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateUserResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-1", res.UserId)
+			},
+		},
+		{
+			Name: "Replay Does Not Create The User Again",
+			Ctx:  idempotency.WithKey(context.Background(), "key-1"),
+			buildMock: func(repo *utils.RepoSitoryMock, store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(entities.IdempotencyRecord{
+					Fingerprint: fingerprint,
+					Status:      idempotency.StatusCompleted,
+					Response:    []byte(`{"UserId":"user-1","Status":{"Message":"created successfully"}}`),
+				}, false, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateUserResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-1", res.UserId)
+				assert.Equal(t, "created successfully", res.Status.Message)
+			},
+		},
+		{
+			Name: "Fingerprint Sent By The Gateway",
+			Ctx:  idempotency.WithFingerprint(idempotency.WithKey(context.Background(), "key-1"), "from-gateway"),
+			buildMock: func(repo *utils.RepoSitoryMock, store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(entities.IdempotencyRecord{
+					Fingerprint: keys.BlindIndex("from-gateway"),
+					Status:      idempotency.StatusCompleted,
+					Response:    []byte(`{"UserId":"user-1"}`),
+				}, false, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateUserResponse, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name: "Claim Fails",
+			Ctx:  idempotency.WithKey(context.Background(), "key-1"),
+			buildMock: func(repo *utils.RepoSitoryMock, store *utils.IdempotencyStoreMock) {
+				store.On("Claim", mock.Anything, mock.Anything, mock.Anything).Return(entities.IdempotencyRecord{}, false, sql.ErrTxDone)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateUserResponse, err error) {
+				assert.IsType(t, myErr.DataBaseErr{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			store := new(utils.IdempotencyStoreMock)
+			tc.buildMock(repo, store)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Idempotency = idempotency.NewGuard(store, keys, logger)
+			res, err := srvc.CreateUser(tc.Ctx, request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceDeleteUserIfMatch(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		IfMatch        string
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, err error)
+	}{
+		{
+			Name:    "Current Etag",
+			IfMatch: `"1", "2"`,
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(2), nil)
+				repo.On("DeleteUser", mock.Anything, "user-1").Return(nil)
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name:    "User Changed Since",
+			IfMatch: `"1"`,
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(2), nil)
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name:    "User Gone",
+			IfMatch: "*",
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LockUser", mock.Anything, "user-1").Return(uint64(0), sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+		{
+			Name:      "Unquoted Etag",
+			IfMatch:   "2",
+			buildMock: func(repo *utils.RepoSitoryMock) {},
+			assertResponse: func(t *testing.T, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
 
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			_, err := srvc.DeleteUser(context.Background(), entities.DeleteUserRequest{UserId: "user-1", IfMatch: tc.IfMatch})
+			tc.assertResponse(t, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceAudit(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	ctx := audit.WithCaller(context.Background(), audit.Caller{Actor: "admin@globant.com", SourceIp: "10.0.0.1", RequestId: "request-1"})
+
+	entry := func(operation, targetUserId, outcome string) interface{} {
+		return mock.MatchedBy(func(entries []entities.AuditEntry) bool {
+			return len(entries) == 1 && entries[0].Operation == operation && entries[0].TargetUserId == targetUserId &&
+				entries[0].Outcome == outcome && entries[0].Actor == "admin@globant.com" && entries[0].RequestId == "request-1"
+		})
+	}
+
+	testCases := []struct {
+		Name      string
+		run       func(srvc service.Service) error
+		buildMock func(repo *utils.RepoSitoryMock, auditRepo *utils.AuditRepositoryMock)
+		assertErr func(t *testing.T, err error)
+	}{
+		{
+			Name: "Create Records The New User",
+			run: func(srvc service.Service) error {
+				_, err := srvc.CreateUser(ctx, entities.CreateUserRequest{Name: "Timo", Age: 19, Email: "timoteo@globant.com", Pass: "123"})
+				return err
+			},
+			buildMock: func(repo *utils.RepoSitoryMock, auditRepo *utils.AuditRepositoryMock) {
+				repo.On("CreateUser", mock.Anything, mock.Anything).Return("user-1", nil)
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil)
+				auditRepo.On("Record", mock.Anything, mock.MatchedBy(func(entries []entities.AuditEntry) bool {
+					return len(entries) == 1 && entries[0].TargetUserId == "user-1" && entries[0].Outcome == "OK" &&
+						strings.Contains(entries[0].Diff, `"password":{"after":"[redacted]"}`)
+				})).Return(nil)
+			},
+			assertErr: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name: "Failed Audit Fails The Delete",
+			run: func(srvc service.Service) error {
+				_, err := srvc.DeleteUser(ctx, entities.DeleteUserRequest{UserId: "user-1"})
+				return err
+			},
+			buildMock: func(repo *utils.RepoSitoryMock, auditRepo *utils.AuditRepositoryMock) {
+				repo.On("GetUser", mock.Anything, "user-1").Return(entities.User{Id: "user-1", Name: "Timo"}, nil)
+				repo.On("DeleteUser", mock.Anything, "user-1").Return(nil)
+				repo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil)
+				auditRepo.On("Record", mock.Anything, entry("DeleteUser", "user-1", "OK")).Return(sql.ErrConnDone)
+				auditRepo.On("Record", mock.Anything, entry("DeleteUser", "user-1", "Unavailable")).Return(nil)
+			},
+			assertErr: func(t *testing.T, err error) {
+				assert.Error(t, err)
+			},
+		},
+		{
+			Name: "Delete Of Missing User Is Recorded",
+			run: func(srvc service.Service) error {
+				_, err := srvc.DeleteUser(ctx, entities.DeleteUserRequest{UserId: "user-1"})
+				return err
+			},
+			buildMock: func(repo *utils.RepoSitoryMock, auditRepo *utils.AuditRepositoryMock) {
+				repo.On("GetUser", mock.Anything, "user-1").Return(entities.User{}, sql.ErrNoRows)
+				auditRepo.On("Record", mock.Anything, entry("DeleteUser", "user-1", "NotFound")).Return(nil)
+			},
+			assertErr: func(t *testing.T, err error) {
+				assert.IsType(t, myErr.UserNotFoundErr{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			auditRepo := new(utils.AuditRepositoryMock)
+			tc.buildMock(repo, auditRepo)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Audit = auditRepo
+			tc.assertErr(t, tc.run(srvc))
+			repo.AssertExpectations(t)
+			auditRepo.AssertExpectations(t)
+		})
+	}
 }
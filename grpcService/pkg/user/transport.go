@@ -3,17 +3,38 @@ package user
 import (
 	"context"
 
+	"github.com/go-kit/kit/endpoint"
 	gr "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
 	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/idempotency"
 	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
 )
 
+const ReadYourWritesHeader = "read-your-writes"
+
 type gRPCSv struct {
 	createUs gr.Handler
 	getUs    gr.Handler
 	deleteUs gr.Handler
+	batchCr  gr.Handler
+	batchGet gr.Handler
+	batchDel gr.Handler
+	watchUs  endpoint.Endpoint
+	importUs endpoint.Endpoint
+	exportUs endpoint.Endpoint
+	exportDt gr.Handler
+	eraseUs  gr.Handler
+	attrsUp  gr.Handler
+	listUs   gr.Handler
+	avatarUp endpoint.Endpoint
+	avatarGt gr.Handler
+	authn    gr.Handler
 	proto.UnimplementedUserServiceServer
 }
 
@@ -24,12 +45,14 @@ func NewGrpcServer(end Endpoints) proto.UserServiceServer {
 			end.CreateUser,
 			decodeCreateUserRequest,
 			encodeCreateUserResponse,
+			gr.ServerBefore(idempotency.FromMetadata),
 		),
 
 		getUs: gr.NewServer(
 			end.GetUser,
 			decodeGetUserRequest,
 			encodeGetUserResponse,
+			gr.ServerBefore(readYourWritesFromMetadata),
 		),
 
 		deleteUs: gr.NewServer(
@@ -37,6 +60,67 @@ func NewGrpcServer(end Endpoints) proto.UserServiceServer {
 			decodeDeleteUserRequest,
 			encodeDeleteUserRequest,
 		),
+
+		batchCr: gr.NewServer(
+			end.BatchCreateUsers,
+			decodeBatchCreateUsersRequest,
+			encodeBatchCreateUsersResponse,
+		),
+
+		batchGet: gr.NewServer(
+			end.BatchGetUsers,
+			decodeBatchGetUsersRequest,
+			encodeBatchGetUsersResponse,
+			gr.ServerBefore(readYourWritesFromMetadata),
+		),
+
+		batchDel: gr.NewServer(
+			end.BatchDeleteUsers,
+			decodeBatchDeleteUsersRequest,
+			encodeBatchDeleteUsersResponse,
+		),
+
+		exportDt: gr.NewServer(
+			end.ExportUserData,
+			decodeExportUserDataRequest,
+			encodeExportUserDataResponse,
+		),
+
+		eraseUs: gr.NewServer(
+			end.EraseUser,
+			decodeEraseUserRequest,
+			encodeEraseUserResponse,
+		),
+
+		attrsUp: gr.NewServer(
+			end.UpdateUserAttributes,
+			decodeUpdateUserAttributesRequest,
+			encodeUpdateUserAttributesResponse,
+		),
+
+		listUs: gr.NewServer(
+			end.ListUsers,
+			decodeListUsersRequest,
+			encodeListUsersResponse,
+			gr.ServerBefore(readYourWritesFromMetadata),
+		),
+
+		avatarGt: gr.NewServer(
+			end.GetAvatar,
+			decodeGetAvatarRequest,
+			encodeGetAvatarResponse,
+		),
+
+		authn: gr.NewServer(
+			end.Authenticate,
+			decodeAuthenticateRequest,
+			encodeAuthenticateResponse,
+		),
+
+		watchUs:  end.WatchUsers,
+		importUs: end.ImportUsers,
+		exportUs: end.ExportUsers,
+		avatarUp: end.UploadAvatar,
 	}
 }
 
@@ -70,6 +154,223 @@ func (g *gRPCSv) DeleteUser(ctx context.Context, rq *proto.DeleteUserRequest) (*
 	return resp.(*proto.DeleteUserResponse), nil
 }
 
+func (g *gRPCSv) BatchCreateUsers(ctx context.Context, rq *proto.BatchCreateUsersRequest) (*proto.BatchCreateUsersResponse, error) {
+	_, resp, err := g.batchCr.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.BatchCreateUsersResponse), nil
+}
+
+func (g *gRPCSv) BatchGetUsers(ctx context.Context, rq *proto.BatchGetUsersRequest) (*proto.BatchGetUsersResponse, error) {
+	_, resp, err := g.batchGet.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.BatchGetUsersResponse), nil
+}
+
+func (g *gRPCSv) BatchDeleteUsers(ctx context.Context, rq *proto.BatchDeleteUsersRequest) (*proto.BatchDeleteUsersResponse, error) {
+	_, resp, err := g.batchDel.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.BatchDeleteUsersResponse), nil
+}
+
+func (g *gRPCSv) ExportUserData(ctx context.Context, rq *proto.ExportUserDataRequest) (*proto.ExportUserDataResponse, error) {
+	_, resp, err := g.exportDt.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ExportUserDataResponse), nil
+}
+
+func (g *gRPCSv) EraseUser(ctx context.Context, rq *proto.EraseUserRequest) (*proto.EraseUserResponse, error) {
+	_, resp, err := g.eraseUs.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.EraseUserResponse), nil
+}
+
+func (g *gRPCSv) UpdateUserAttributes(ctx context.Context, rq *proto.UpdateUserAttributesRequest) (*proto.UpdateUserAttributesResponse, error) {
+	_, resp, err := g.attrsUp.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.UpdateUserAttributesResponse), nil
+}
+
+func (g *gRPCSv) ListUsers(ctx context.Context, rq *proto.ListUsersRequest) (*proto.ListUsersResponse, error) {
+	_, resp, err := g.listUs.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListUsersResponse), nil
+}
+
+// WatchUsers calls the endpoint directly, go-kit's gRPC transport only
+// handles unary calls. Send blocks while the client's flow control window
+// is full, which is what holds the feed back for slow clients.
+func (g *gRPCSv) WatchUsers(rq *proto.WatchRequest, stream proto.UserService_WatchUsersServer) error {
+	req := WatchUsersRequest{
+		WatchRequest: decodeWatchRequest(rq),
+		Send: func(event entities.WatchEvent) error {
+			return stream.Send(&proto.WatchResponse{
+				Event:        event.Event,
+				Resume_Token: event.ResumeToken,
+				Heartbeat:    event.Heartbeat,
+			})
+		},
+	}
+
+	_, err := g.watchUs(stream.Context(), req)
+	return err
+}
+
+// ImportUsers reads the options from the first message of the stream and
+// hands the chunks that follow to the endpoint as a single file.
+func (g *gRPCSv) ImportUsers(stream proto.UserService_ImportUsersServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if first.Options == nil {
+		return customErr.NewInvalidField("options", "must come in the first message")
+	}
+
+	req := ImportUsersRequest{
+		ImportOptions: entities.ImportOptions{
+			Format:   first.Options.Format,
+			DryRun:   first.Options.Dry_Run,
+			ImportId: first.Options.Import_Id,
+		},
+		Body: &chunkReader{buf: first.Chunk, next: func() ([]byte, error) {
+			msg, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			if msg.Options != nil {
+				return nil, customErr.NewInvalidField("options", "must come in the first message only")
+			}
+			return msg.Chunk, nil
+		}},
+	}
+
+	resp, err := g.importUs(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(encodeImportUsersResponse(resp.(entities.ImportUsersResponse)))
+}
+
+func (g *gRPCSv) ExportUsers(rq *proto.ExportUsersRequest, stream proto.UserService_ExportUsersServer) error {
+	req := ExportUsersRequest{
+		ExportUsersRequest: entities.ExportUsersRequest{Format: rq.Format, AfterId: rq.After_Id},
+		Send: func(chunk entities.ExportChunk) error {
+			return stream.Send(&proto.ExportUsersResponse{Chunk: chunk.Data, Last_Id: chunk.LastId})
+		},
+	}
+
+	_, err := g.exportUs(stream.Context(), req)
+	return err
+}
+
+// UploadAvatar reads the user from the first message of the stream and
+// hands the chunks that follow to the endpoint as a single file.
+func (g *gRPCSv) UploadAvatar(stream proto.UserService_UploadAvatarServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if first.User_Id == "" {
+		return customErr.NewInvalidField("user_id", "must come in the first message")
+	}
+	id, err := entities.ParseID("user_id", first.User_Id)
+	if err != nil {
+		return err
+	}
+
+	req := UploadAvatarRequest{
+		UploadAvatarRequest: entities.UploadAvatarRequest{UserId: id.String()},
+		Body: &chunkReader{buf: first.Chunk, next: func() ([]byte, error) {
+			msg, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			if msg.User_Id != "" {
+				return nil, customErr.NewInvalidField("user_id", "must come in the first message only")
+			}
+			return msg.Chunk, nil
+		}},
+	}
+
+	resp, err := g.avatarUp(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(encodeUploadAvatarResponse(resp.(entities.UploadAvatarResponse)))
+}
+
+func (g *gRPCSv) GetAvatar(ctx context.Context, rq *proto.GetAvatarRequest) (*proto.GetAvatarResponse, error) {
+	_, resp, err := g.avatarGt.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.GetAvatarResponse), nil
+}
+
+func (g *gRPCSv) Authenticate(ctx context.Context, rq *proto.AuthenticateRequest) (*proto.AuthenticateResponse, error) {
+	_, resp, err := g.authn.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.AuthenticateResponse), nil
+}
+
+// chunkReader reads the chunks of a client stream as one file, next
+// returning the error of the client closing its side of the stream is the
+// end of it.
+type chunkReader struct {
+	next func() ([]byte, error)
+	buf  []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.next()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// readYourWritesFromMetadata lets a client that has just written ask for
+// its next reads to be served by the primary database.
+func readYourWritesFromMetadata(ctx context.Context, md metadata.MD) context.Context {
+	if values := md.Get(ReadYourWritesHeader); len(values) > 0 && values[0] == "true" {
+		return database.WithReadYourWrites(ctx)
+	}
+	return ctx
+}
+
 func decodeCreateUserRequest(ctx context.Context, request interface{}) (interface{}, error) {
 	res, err := request.(*proto.CreateUserRequest)
 
@@ -78,10 +379,11 @@ func decodeCreateUserRequest(ctx context.Context, request interface{}) (interfac
 	}
 
 	return entities.CreateUserRequest{
-		Name:  res.Name,
-		Age:   res.Age,
-		Pass:  res.Pass,
-		Email: res.Email,
+		Name:       res.Name,
+		Age:        res.Age,
+		Pass:       res.Pass,
+		Email:      res.Email,
+		Attributes: attributesFromProto(res.Attributes),
 	}, nil
 
 }
@@ -94,14 +396,19 @@ func encodeCreateUserResponse(ctx context.Context, response interface{}) (interf
 }
 
 func decodeGetUserRequest(ctx context.Context, request interface{}) (interface{}, error) {
-	res, err := request.(*proto.GetUserRequest)
+	res, valid := request.(*proto.GetUserRequest)
 
-	if !err {
+	if !valid {
 		return nil, customErr.NewGrpcError()
 	}
 
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
 	return entities.GetUserRequest{
-		UserID: res.User_Id,
+		UserID: id.String(),
 	}, nil
 
 }
@@ -111,8 +418,7 @@ func encodeGetUserResponse(ctx context.Context, response interface{}) (interface
 	if !valid {
 		return nil, customErr.NewGrpcError()
 	}
-	protoResp := &proto.GetUserResponse{Id: res.Id, Name: res.Name, Age: res.Age}
-	return protoResp, nil
+	return getUserResponseToProto(res)
 }
 
 func decodeDeleteUserRequest(ctx context.Context, request interface{}) (interface{}, error) {
@@ -121,8 +427,14 @@ func decodeDeleteUserRequest(ctx context.Context, request interface{}) (interfac
 		return nil, customErr.NewGrpcError()
 	}
 
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
 	return entities.DeleteUserRequest{
-		UserId: res.User_Id,
+		UserId:  id.String(),
+		IfMatch: res.If_Match,
 	}, nil
 }
 
@@ -131,3 +443,285 @@ func encodeDeleteUserRequest(ctx context.Context, response interface{}) (interfa
 	protoResp := &proto.DeleteUserResponse{Status: &proto.Status{Message: resp.Status.Message, Code: resp.Status.Code}}
 	return protoResp, nil
 }
+
+func decodeWatchRequest(rq *proto.WatchRequest) entities.WatchRequest {
+	req := entities.WatchRequest{ResumeToken: rq.Resume_Token}
+	if rq.Filter != nil {
+		req.Filter = entities.WatchFilter{EventTypes: rq.Filter.Event_Types, UserIds: rq.Filter.User_Ids}
+	}
+	return req
+}
+
+func decodeBatchCreateUsersRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.BatchCreateUsersRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	req := entities.BatchCreateUsersRequest{AllOrNothing: res.All_Or_Nothing}
+	for _, user := range res.Users {
+		req.Users = append(req.Users, entities.CreateUserRequest{
+			Name:       user.Name,
+			Age:        user.Age,
+			Pass:       user.Pass,
+			Email:      user.Email,
+			Attributes: attributesFromProto(user.Attributes),
+		})
+	}
+	return req, nil
+}
+
+func encodeBatchCreateUsersResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.BatchCreateUsersResponse)
+	protoResp := &proto.BatchCreateUsersResponse{}
+	for _, result := range res.Results {
+		protoResp.Results = append(protoResp.Results, &proto.BatchCreateUserResult{
+			User_Id: result.UserId,
+			Error:   statusToProto(result.Error),
+		})
+	}
+	return protoResp, nil
+}
+
+func decodeBatchGetUsersRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.BatchGetUsersRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.BatchGetUsersRequest{UserIds: res.User_Ids}, nil
+}
+
+func encodeBatchGetUsersResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.BatchGetUsersResponse)
+	protoResp := &proto.BatchGetUsersResponse{}
+	for _, result := range res.Results {
+		protoResult := &proto.BatchGetUserResult{Error: statusToProto(result.Error)}
+		if result.Error == nil {
+			user, err := getUserResponseToProto(result.User)
+			if err != nil {
+				return nil, err
+			}
+			protoResult.User = user
+		}
+		protoResp.Results = append(protoResp.Results, protoResult)
+	}
+	return protoResp, nil
+}
+
+func decodeBatchDeleteUsersRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.BatchDeleteUsersRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.BatchDeleteUsersRequest{UserIds: res.User_Ids, AllOrNothing: res.All_Or_Nothing}, nil
+}
+
+func encodeBatchDeleteUsersResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.BatchDeleteUsersResponse)
+	protoResp := &proto.BatchDeleteUsersResponse{}
+	for _, result := range res.Results {
+		protoResp.Results = append(protoResp.Results, &proto.BatchDeleteUserResult{
+			User_Id: result.UserId,
+			Error:   statusToProto(result.Error),
+		})
+	}
+	return protoResp, nil
+}
+
+func encodeImportUsersResponse(res entities.ImportUsersResponse) *proto.ImportUsersResponse {
+	protoResp := &proto.ImportUsersResponse{
+		Import_Id:        res.ImportId,
+		Rows:             res.Rows,
+		Imported:         res.Imported,
+		Skipped:          res.Skipped,
+		Failed:           res.Failed,
+		Errors_Truncated: res.ErrorsTruncated,
+		Dry_Run:          res.DryRun,
+	}
+	for _, rowErr := range res.Errors {
+		protoResp.Errors = append(protoResp.Errors, &proto.ImportRowError{
+			Row:   rowErr.Row,
+			Field: rowErr.Field,
+			Error: statusToProto(&rowErr.Error),
+		})
+	}
+	return protoResp
+}
+
+func statusToProto(status *entities.Status) *proto.Status {
+	if status == nil {
+		return nil
+	}
+	return &proto.Status{Code: status.Code, Message: status.Message}
+}
+
+func decodeExportUserDataRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ExportUserDataRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ExportUserDataRequest{UserId: id.String()}, nil
+}
+
+func encodeExportUserDataResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ExportUserDataResponse)
+	return &proto.ExportUserDataResponse{Archive: res.Archive, File_Name: res.FileName}, nil
+}
+
+func decodeEraseUserRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.EraseUserRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.EraseUserRequest{UserId: id.String()}, nil
+}
+
+func encodeEraseUserResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	receipt := response.(entities.EraseUserResponse).Receipt
+	return &proto.EraseUserResponse{Receipt: &proto.ErasureReceipt{
+		Id:        receipt.Id,
+		User_Id:   receipt.UserId,
+		Erased_At: timestamppb.New(receipt.ErasedAt),
+		Erased:    receipt.Erased,
+		Retained:  receipt.Retained,
+		Key_Id:    receipt.KeyId,
+		Signature: receipt.Signature,
+	}}, nil
+}
+
+func decodeUpdateUserAttributesRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.UpdateUserAttributesRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.UpdateUserAttributesRequest{
+		UserId:     id.String(),
+		Attributes: attributesFromProto(res.Attributes),
+		IfMatch:    res.If_Match,
+	}, nil
+}
+
+func encodeUpdateUserAttributesResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.UpdateUserAttributesResponse)
+	attrs, err := attributesToProto(res.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.UpdateUserAttributesResponse{Attributes: attrs, Etag: res.Etag}, nil
+}
+
+func decodeListUsersRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListUsersRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListUsersRequest{PageSize: res.Page_Size, PageToken: res.Page_Token, Filters: res.Filters}, nil
+}
+
+func encodeListUsersResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListUsersResponse)
+	protoResp := &proto.ListUsersResponse{Next_Page_Token: res.NextPageToken}
+	for _, user := range res.Users {
+		protoUser, err := getUserResponseToProto(user)
+		if err != nil {
+			return nil, err
+		}
+		protoResp.Users = append(protoResp.Users, protoUser)
+	}
+	return protoResp, nil
+}
+
+func encodeUploadAvatarResponse(res entities.UploadAvatarResponse) *proto.UploadAvatarResponse {
+	return &proto.UploadAvatarResponse{
+		Content_Type: res.ContentType,
+		Sizes:        res.Sizes,
+		Etag:         res.Etag,
+		Updated_At:   timestamppb.New(res.UpdatedAt),
+	}
+}
+
+func decodeGetAvatarRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.GetAvatarRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	id, err := entities.ParseID("user_id", res.User_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.GetAvatarRequest{UserId: id.String(), Size: res.Size, IfNoneMatch: res.If_None_Match}, nil
+}
+
+func encodeGetAvatarResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.GetAvatarResponse)
+	return &proto.GetAvatarResponse{
+		Image:        res.Image,
+		Content_Type: res.ContentType,
+		Size:         res.Size,
+		Etag:         res.Etag,
+		Updated_At:   timestamppb.New(res.UpdatedAt),
+		Not_Modified: res.NotModified,
+	}, nil
+}
+
+func decodeAuthenticateRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.AuthenticateRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.AuthenticateRequest{Email: res.Email, Pass: res.Pass}, nil
+}
+
+func encodeAuthenticateResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.AuthenticateResponse)
+	return &proto.AuthenticateResponse{
+		User_Id: res.UserId,
+		Status:  &proto.Status{Message: res.Status.Message, Code: res.Status.Code},
+	}, nil
+}
+
+func getUserResponseToProto(res entities.GetUserResponse) (*proto.GetUserResponse, error) {
+	attrs, err := attributesToProto(res.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.GetUserResponse{Id: res.Id, Name: res.Name, Age: res.Age, Etag: res.Etag, Attributes: attrs}, nil
+}
+
+func attributesFromProto(attrs *structpb.Struct) map[string]interface{} {
+	if attrs == nil {
+		return nil
+	}
+	return attrs.AsMap()
+}
+
+func attributesToProto(attrs map[string]interface{}) (*structpb.Struct, error) {
+	if len(attrs) == 0 {
+		return nil, nil
+	}
+	return structpb.NewStruct(attrs)
+}
@@ -0,0 +1,88 @@
+package user_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+	service "github.com/timoteoBone/microservice-project/grpcService/pkg/user"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+// userIdSeeds are ids of every form, well formed or not.
+var userIdSeeds = []string{
+	"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+	"01ARZ3NDEKTSV4RRFFQ69G5FAV",
+	"1234567890123456789",
+	"",
+	"user-1",
+	"0190F3B2-7C1E-7D3A-9B4F-1C2D3E4F5A6B",
+	"9223372036854775808",
+	"../../etc/passwd",
+	"1' OR '1'='1",
+	"01ARZ3NDEKTSV4RRFFQ69G5FAé",
+}
+
+// fuzzUserId checks a request for the user id goes through to the
+// repository when it is well formed and is turned down with
+// InvalidArgument before reaching it otherwise. The repository answers
+// method with returns.
+func fuzzUserId(f *testing.F, method string, returns []interface{}, call func(sv pb.UserServiceServer, id string) error) {
+	for _, seed := range userIdSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, id string) {
+		repo := new(utils.RepoSitoryMock)
+		repo.On(method, mock.Anything, id).Return(returns...).Maybe()
+		sv := service.NewGrpcServer(service.MakeEndpoint(service.NewService(log.NewNopLogger(), repo)))
+
+		err := call(sv, id)
+		if ids.Valid(id) {
+			assert.Equal(t, codes.NotFound, status.Code(err), id)
+			repo.AssertNumberOfCalls(t, method, 1)
+		} else {
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), id)
+			repo.AssertNumberOfCalls(t, method, 0)
+		}
+	})
+}
+
+func FuzzGetUserRequest(f *testing.F) {
+	fuzzUserId(f, "GetUser", []interface{}{entities.User{}, sql.ErrNoRows}, func(sv pb.UserServiceServer, id string) error {
+		_, err := sv.GetUser(context.Background(), &pb.GetUserRequest{User_Id: id})
+		return err
+	})
+}
+
+func FuzzDeleteUserRequest(f *testing.F) {
+	fuzzUserId(f, "DeleteUser", []interface{}{sql.ErrNoRows}, func(sv pb.UserServiceServer, id string) error {
+		_, err := sv.DeleteUser(context.Background(), &pb.DeleteUserRequest{User_Id: id})
+		return err
+	})
+}
+
+func FuzzParseID(f *testing.F) {
+	for _, seed := range userIdSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		id, err := entities.ParseID("user_id", s)
+		if err != nil {
+			assert.False(t, ids.Valid(s))
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			return
+		}
+		assert.Equal(t, s, id.String())
+	})
+}
@@ -0,0 +1,112 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+// WatchConfig tunes the change feed served by WatchUsers.
+type WatchConfig struct {
+	PollInterval      time.Duration
+	HeartbeatInterval time.Duration
+	// BatchSize caps how many events a stream reads ahead of its client.
+	BatchSize int
+	// Settle is how old an event has to be before it is streamed, see
+	// events.ReadLog.
+	Settle time.Duration
+}
+
+func DefaultWatchConfig() WatchConfig {
+	return WatchConfig{
+		PollInterval:      500 * time.Millisecond,
+		HeartbeatInterval: 15 * time.Second,
+		BatchSize:         100,
+		Settle:            time.Second,
+	}
+}
+
+// WatchUsers streams user events through send, in the order they were
+// written, until ctx is cancelled or send fails. The log is only read again
+// once send has taken every event of the previous batch, so a slow client
+// holds its own stream back instead of piling events up in memory.
+func (s *service) WatchUsers(ctx context.Context, rq entities.WatchRequest, send func(entities.WatchEvent) error) error {
+	s.Logger.Log(s.Logger, "request", "watch users", "received")
+
+	var (
+		after int64
+		err   error
+	)
+	if rq.ResumeToken != "" {
+		if after, err = events.DecodeResumeToken(rq.ResumeToken); err != nil {
+			return errors.NewInvalidField("resume_token", "not issued by this service")
+		}
+	} else if after, err = s.Repo.LastEventSeq(ctx); err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return dataBaseError(err)
+	}
+
+	poll := time.NewTicker(s.Watch.PollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(s.Watch.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		entries, err := s.Repo.ListEvents(ctx, after, s.Watch.BatchSize, s.Watch.Settle)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			level.Error(s.Logger).Log("error", err)
+			return dataBaseError(err)
+		}
+
+		for _, entry := range entries {
+			after = entry.Seq
+			if !watched(rq.Filter, entry.Event) {
+				continue
+			}
+
+			if err := send(entities.WatchEvent{Event: entry.Event, ResumeToken: events.EncodeResumeToken(after)}); err != nil {
+				return err
+			}
+		}
+
+		if len(entries) == s.Watch.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if err := send(entities.WatchEvent{ResumeToken: events.EncodeResumeToken(after), Heartbeat: true}); err != nil {
+				return err
+			}
+		case <-poll.C:
+		}
+	}
+}
+
+func watched(filter entities.WatchFilter, event *pb.Event) bool {
+	return matches(filter.EventTypes, event.Type) && matches(filter.UserIds, events.UserId(event))
+}
+
+func matches(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
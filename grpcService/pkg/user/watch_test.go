@@ -0,0 +1,100 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	service "github.com/timoteoBone/microservice-project/grpcService/pkg/user"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+var errStreamClosed = errors.New("stream closed")
+
+func TestServiceWatchUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	created := events.NewUserCreated("user-1")
+	deleted := events.NewUserDeleted("user-2")
+	entries := []entities.EventLogEntry{{Seq: 8, Event: created}, {Seq: 9, Event: deleted}}
+
+	testCases := []struct {
+		Name           string
+		Request        entities.WatchRequest
+		Limit          int
+		buildMock      func(repo *utils.RepoSitoryMock)
+		assertResponse func(t *testing.T, sent []entities.WatchEvent, err error)
+	}{
+		{
+			Name:    "Resume After Token",
+			Request: entities.WatchRequest{ResumeToken: events.EncodeResumeToken(7)},
+			Limit:   2,
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("ListEvents", mock.Anything, int64(7), 100).Return(entries, nil)
+			},
+			assertResponse: func(t *testing.T, sent []entities.WatchEvent, err error) {
+				assert.ErrorIs(t, err, errStreamClosed)
+				assert.Equal(t, created.Id, sent[0].Event.Id)
+				assert.Equal(t, deleted.Id, sent[1].Event.Id)
+
+				seq, _ := events.DecodeResumeToken(sent[1].ResumeToken)
+				assert.Equal(t, int64(9), seq)
+			},
+		},
+		{
+			Name:    "Filter Events And Send Heartbeat",
+			Request: entities.WatchRequest{Filter: entities.WatchFilter{UserIds: []string{"user-2"}}},
+			Limit:   2,
+			buildMock: func(repo *utils.RepoSitoryMock) {
+				repo.On("LastEventSeq", mock.Anything).Return(int64(7), nil)
+				repo.On("ListEvents", mock.Anything, int64(7), 100).Return(entries, nil)
+				repo.On("ListEvents", mock.Anything, int64(9), 100).Return([]entities.EventLogEntry{}, nil)
+			},
+			assertResponse: func(t *testing.T, sent []entities.WatchEvent, err error) {
+				assert.ErrorIs(t, err, errStreamClosed)
+				assert.Equal(t, deleted.Id, sent[0].Event.Id)
+				assert.True(t, sent[1].Heartbeat)
+				assert.Equal(t, events.EncodeResumeToken(9), sent[1].ResumeToken)
+			},
+		},
+		{
+			Name:      "Invalid Resume Token",
+			Request:   entities.WatchRequest{ResumeToken: "garbage"},
+			buildMock: func(repo *utils.RepoSitoryMock) {},
+			assertResponse: func(t *testing.T, sent []entities.WatchEvent, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+				assert.Empty(t, sent)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := new(utils.RepoSitoryMock)
+			tc.buildMock(repo)
+
+			srvc := service.NewService(logger, repo)
+			srvc.Watch.PollInterval = time.Hour
+			srvc.Watch.HeartbeatInterval = 10 * time.Millisecond
+
+			var sent []entities.WatchEvent
+			err := srvc.WatchUsers(context.Background(), tc.Request, func(event entities.WatchEvent) error {
+				sent = append(sent, event)
+				if len(sent) == tc.Limit {
+					return errStreamClosed
+				}
+				return nil
+			})
+			tc.assertResponse(t, sent, err)
+		})
+	}
+}
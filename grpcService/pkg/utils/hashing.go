@@ -1,9 +1,126 @@
 package utils
 
 import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrUnsupportedHash is returned for password hashes that are neither
+// bcrypt nor argon2 in PHC string format.
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+// Imported hashes set the work of every check of their password, the
+// parameters are capped so one of them can't exhaust the memory or the CPU
+// of the service.
+const (
+	maxArgon2Memory  = 256 << 10 // KiB, 256 MiB
+	maxArgon2Time    = 10
+	maxArgon2Threads = 16
+	minArgon2Salt    = 8
+	maxArgon2Salt    = 64
+	maxArgon2Key     = 64
+	maxBcryptCost    = 14
+)
+
+// argon2Hash is a parsed argon2 PHC string,
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+type argon2Hash struct {
+	variant string
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func HashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+// CheckPassword compares password with a bcrypt hash or an argon2 PHC
+// string, so users imported with either keep their passwords.
 func CheckPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if !strings.HasPrefix(hashedPassword, "$argon2") {
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	}
+
+	hash, err := parseArgon2(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	if hash.variant == "argon2id" {
+		key = argon2.IDKey([]byte(password), hash.salt, hash.time, hash.memory, hash.threads, uint32(len(hash.key)))
+	} else {
+		key = argon2.Key([]byte(password), hash.salt, hash.time, hash.memory, hash.threads, uint32(len(hash.key)))
+	}
+
+	if subtle.ConstantTimeCompare(key, hash.key) != 1 {
+		return bcrypt.ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+// ValidatePasswordHash checks that hash is a bcrypt hash or an argon2i or
+// argon2id PHC string CheckPassword can verify.
+func ValidatePasswordHash(hash string) error {
+	if strings.HasPrefix(hash, "$argon2") {
+		_, err := parseArgon2(hash)
+		return err
+	}
+
+	if !strings.HasPrefix(hash, "$2") {
+		return ErrUnsupportedHash
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsupportedHash, err)
+	}
+	if cost > maxBcryptCost {
+		return fmt.Errorf("%w: bcrypt cost %d above %d", ErrUnsupportedHash, cost, maxBcryptCost)
+	}
+	return nil
+}
+
+func parseArgon2(phc string) (argon2Hash, error) {
+	parts := strings.Split(phc, "$")
+	if len(parts) != 6 || (parts[1] != "argon2id" && parts[1] != "argon2i") {
+		return argon2Hash{}, ErrUnsupportedHash
+	}
+
+	hash := argon2Hash{variant: parts[1]}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2Hash{}, fmt.Errorf("%w: argon2 version %q", ErrUnsupportedHash, parts[2])
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hash.memory, &hash.time, &hash.threads); err != nil {
+		return argon2Hash{}, fmt.Errorf("%w: argon2 parameters %q", ErrUnsupportedHash, parts[3])
+	}
+	if hash.memory == 0 || hash.time == 0 || hash.threads == 0 ||
+		hash.memory > maxArgon2Memory || hash.time > maxArgon2Time || hash.threads > maxArgon2Threads {
+		return argon2Hash{}, fmt.Errorf("%w: argon2 parameters %q", ErrUnsupportedHash, parts[3])
+	}
+
+	var err error
+	if hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(hash.salt) < minArgon2Salt || len(hash.salt) > maxArgon2Salt {
+		return argon2Hash{}, fmt.Errorf("%w: argon2 salt", ErrUnsupportedHash)
+	}
+	if hash.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(hash.key) == 0 || len(hash.key) > maxArgon2Key {
+		return argon2Hash{}, fmt.Errorf("%w: argon2 hash", ErrUnsupportedHash)
+	}
+
+	return hash, nil
 }
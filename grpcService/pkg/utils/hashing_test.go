@@ -0,0 +1,85 @@
+package utils_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+func TestCheckPassword(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	argon2id := fmt.Sprintf("$argon2id$v=19$m=1024,t=1,p=1$%s$%s",
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("secret"), salt, 1, 1024, 1, 32)))
+	argon2i := fmt.Sprintf("$argon2i$v=19$m=1024,t=1,p=1$%s$%s",
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(argon2.Key([]byte("secret"), salt, 1, 1024, 1, 32)))
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	for _, hash := range []string{argon2id, argon2i, string(bcryptHash)} {
+		assert.NoError(t, utils.ValidatePasswordHash(hash), hash)
+		assert.NoError(t, utils.CheckPassword("secret", hash), hash)
+		assert.Error(t, utils.CheckPassword("other", hash), hash)
+	}
+}
+
+func TestValidatePasswordHash(t *testing.T) {
+	for _, hash := range []string{
+		"secret",
+		"$1$salt$hash",
+		"$2a$10$tooshort",
+		"$argon2d$v=19$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$",
+	} {
+		assert.ErrorIs(t, utils.ValidatePasswordHash(hash), utils.ErrUnsupportedHash, hash)
+	}
+}
+
+func TestValidatePasswordHashLimits(t *testing.T) {
+	encode := func(b []byte) string { return base64.RawStdEncoding.EncodeToString(b) }
+	salt := encode([]byte("0123456789abcdef"))
+	key := encode(make([]byte, 32))
+	tooExpensive, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	// The cost is read from the hash, forging it is enough.
+	tooExpensive = append([]byte("$2a$15"), tooExpensive[6:]...)
+
+	testCases := []struct {
+		Name  string
+		Hash  string
+		Valid bool
+	}{
+		{"Largest Parameters", "$argon2id$v=19$m=262144,t=10,p=16$" + salt + "$" + key, true},
+		{"Memory Too Large", "$argon2id$v=19$m=4194304,t=1,p=1$" + salt + "$" + key, false},
+		{"Too Many Passes", "$argon2id$v=19$m=1024,t=1000,p=1$" + salt + "$" + key, false},
+		{"Too Many Threads", "$argon2id$v=19$m=1024,t=1,p=64$" + salt + "$" + key, false},
+		{"Salt Too Short", "$argon2id$v=19$m=1024,t=1,p=1$" + encode([]byte("salt")) + "$" + key, false},
+		{"Salt Too Long", "$argon2id$v=19$m=1024,t=1,p=1$" + encode(make([]byte, 65)) + "$" + key, false},
+		{"Key Too Long", "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$" + encode(make([]byte, 65)), false},
+		{"Bcrypt Cost Too High", string(tooExpensive), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := utils.ValidatePasswordHash(tc.Hash)
+			if tc.Valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, utils.ErrUnsupportedHash)
+			// Checking a password against it fails before any work is done.
+			if tc.Hash[:7] == "$argon2" {
+				assert.ErrorIs(t, utils.CheckPassword("secret", tc.Hash), utils.ErrUnsupportedHash)
+			}
+		})
+	}
+}
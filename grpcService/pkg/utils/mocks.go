@@ -1,14 +1,18 @@
 package utils
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/stretchr/testify/mock"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/encryption"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
 )
 
 func NewMock(logger log.Logger) (*sql.DB, sqlmock.Sqlmock) {
@@ -21,6 +25,17 @@ func NewMock(logger log.Logger) (*sql.DB, sqlmock.Sqlmock) {
 	return db, mock
 }
 
+// NewKeyringMock returns a keyring with fixed keys, with master key 2 active
+// and master key 1 still available to decrypt older values.
+func NewKeyringMock() *encryption.Keyring {
+	keys, _ := encryption.NewKeyring(2, map[uint32][]byte{
+		1: bytes.Repeat([]byte{1}, 32),
+		2: bytes.Repeat([]byte{2}, 32),
+	}, bytes.Repeat([]byte{3}, 32))
+
+	return keys
+}
+
 type RepoSitoryMock struct {
 	mock.Mock
 	logger log.Logger
@@ -43,12 +58,641 @@ func (repo *RepoSitoryMock) GetUser(ctx context.Context, userId string) (entitie
 
 }
 
+func (repo *RepoSitoryMock) GetUserByEmail(ctx context.Context, email string) (entities.User, error) {
+	args := repo.Called(ctx, email)
+
+	return args.Get(0).(entities.User), args.Error(1)
+}
+
 func (repo *RepoSitoryMock) DeleteUser(ctx context.Context, userId string) error {
 	args := repo.Called(ctx, userId)
 
 	return args.Error(0)
 }
 
+func (repo *RepoSitoryMock) CreateUsers(ctx context.Context, users []entities.User) error {
+	args := repo.Called(ctx, users)
+
+	return args.Error(0)
+}
+
+func (repo *RepoSitoryMock) GetUsers(ctx context.Context, userIds []string) (map[string]entities.User, error) {
+	args := repo.Called(ctx, userIds)
+
+	return args.Get(0).(map[string]entities.User), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) DeleteUsers(ctx context.Context, userIds []string) ([]string, error) {
+	args := repo.Called(ctx, userIds)
+
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) ListUsers(ctx context.Context, afterId string, limit int) ([]entities.User, error) {
+	args := repo.Called(ctx, afterId, limit)
+
+	return args.Get(0).([]entities.User), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) StartImport(ctx context.Context, importId string, format string) (entities.ImportCheckpoint, error) {
+	args := repo.Called(ctx, importId, format)
+
+	return args.Get(0).(entities.ImportCheckpoint), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) AdvanceImport(ctx context.Context, checkpoint entities.ImportCheckpoint, from uint64) (bool, error) {
+	args := repo.Called(ctx, checkpoint, from)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) SaveEvent(ctx context.Context, event *pb.Event) error {
+	args := repo.Called(ctx, event)
+
+	return args.Error(0)
+}
+
+func (repo *RepoSitoryMock) ListEvents(ctx context.Context, after int64, limit int, settle time.Duration) ([]entities.EventLogEntry, error) {
+	args := repo.Called(ctx, after, limit)
+
+	return args.Get(0).([]entities.EventLogEntry), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) LastEventSeq(ctx context.Context) (int64, error) {
+	args := repo.Called(ctx)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) LockUser(ctx context.Context, userId string) (uint64, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) ListUserEvents(ctx context.Context, userId string) ([]entities.EventLogEntry, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Get(0).([]entities.EventLogEntry), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) SaveErasure(ctx context.Context, receipt entities.ErasureReceipt) error {
+	args := repo.Called(ctx, receipt)
+
+	return args.Error(0)
+}
+
+func (repo *RepoSitoryMock) GetErasure(ctx context.Context, userId string) (entities.ErasureReceipt, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Get(0).(entities.ErasureReceipt), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) UpdateUserAttributes(ctx context.Context, userId string, attrs map[string]interface{}) error {
+	args := repo.Called(ctx, userId, attrs)
+
+	return args.Error(0)
+}
+
+func (repo *RepoSitoryMock) SaveAttributeIndex(ctx context.Context, userId string, entries map[string]string) error {
+	args := repo.Called(ctx, userId, entries)
+
+	return args.Error(0)
+}
+
+func (repo *RepoSitoryMock) FilterUsers(ctx context.Context, filters map[string]string, afterId string, limit int) ([]entities.User, error) {
+	args := repo.Called(ctx, filters, afterId, limit)
+
+	return args.Get(0).([]entities.User), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) GetAvatar(ctx context.Context, userId string) (entities.Avatar, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Get(0).(entities.Avatar), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) GetAvatars(ctx context.Context, userIds []string) ([]entities.Avatar, error) {
+	args := repo.Called(ctx, userIds)
+
+	return args.Get(0).([]entities.Avatar), args.Error(1)
+}
+
+func (repo *RepoSitoryMock) SaveAvatar(ctx context.Context, avatar entities.Avatar) error {
+	args := repo.Called(ctx, avatar)
+
+	return args.Error(0)
+}
+
+func (repo *RepoSitoryMock) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func (repo *RepoSitoryMock) AuthenticateUser(ctx context.Context, email string) (string, error) {
 	return "nil", nil
 }
+
+type WebhookRepositoryMock struct {
+	mock.Mock
+}
+
+func (repo *WebhookRepositoryMock) CreateWebhook(ctx context.Context, webhook entities.Webhook) error {
+	args := repo.Called(ctx, webhook)
+
+	return args.Error(0)
+}
+
+func (repo *WebhookRepositoryMock) ListWebhooks(ctx context.Context, activeOnly bool) ([]entities.Webhook, error) {
+	args := repo.Called(ctx, activeOnly)
+
+	return args.Get(0).([]entities.Webhook), args.Error(1)
+}
+
+func (repo *WebhookRepositoryMock) GetWebhook(ctx context.Context, webhookId string) (entities.Webhook, error) {
+	args := repo.Called(ctx, webhookId)
+
+	return args.Get(0).(entities.Webhook), args.Error(1)
+}
+
+func (repo *WebhookRepositoryMock) DeleteWebhook(ctx context.Context, webhookId string) error {
+	args := repo.Called(ctx, webhookId)
+
+	return args.Error(0)
+}
+
+func (repo *WebhookRepositoryMock) RecordResult(ctx context.Context, webhookId string, success bool, disableAfter uint32) error {
+	args := repo.Called(ctx, webhookId, success, disableAfter)
+
+	return args.Error(0)
+}
+
+func (repo *WebhookRepositoryMock) CreateDelivery(ctx context.Context, delivery entities.WebhookDelivery, nextAttemptAt time.Time) error {
+	args := repo.Called(ctx, delivery, nextAttemptAt)
+
+	return args.Error(0)
+}
+
+func (repo *WebhookRepositoryMock) NextAttempt(ctx context.Context, webhookId string, eventId string) (uint32, error) {
+	args := repo.Called(ctx, webhookId, eventId)
+
+	return args.Get(0).(uint32), args.Error(1)
+}
+
+func (repo *WebhookRepositoryMock) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entities.WebhookDelivery, error) {
+	args := repo.Called(ctx, now, lease, limit)
+
+	return args.Get(0).([]entities.WebhookDelivery), args.Error(1)
+}
+
+func (repo *WebhookRepositoryMock) CompleteDelivery(ctx context.Context, delivery entities.WebhookDelivery) error {
+	args := repo.Called(ctx, delivery)
+
+	return args.Error(0)
+}
+
+func (repo *WebhookRepositoryMock) ListDeliveries(ctx context.Context, webhookId string, limit uint32) ([]entities.WebhookDelivery, error) {
+	args := repo.Called(ctx, webhookId, limit)
+
+	return args.Get(0).([]entities.WebhookDelivery), args.Error(1)
+}
+
+func (repo *WebhookRepositoryMock) GetDelivery(ctx context.Context, deliveryId string) (entities.WebhookDelivery, error) {
+	args := repo.Called(ctx, deliveryId)
+
+	return args.Get(0).(entities.WebhookDelivery), args.Error(1)
+}
+
+type IdempotencyStoreMock struct {
+	mock.Mock
+}
+
+func (store *IdempotencyStoreMock) Claim(ctx context.Context, record entities.IdempotencyRecord, now time.Time) (entities.IdempotencyRecord, bool, error) {
+	args := store.Called(ctx, record, now)
+
+	return args.Get(0).(entities.IdempotencyRecord), args.Bool(1), args.Error(2)
+}
+
+func (store *IdempotencyStoreMock) Complete(ctx context.Context, record entities.IdempotencyRecord) error {
+	args := store.Called(ctx, record)
+
+	return args.Error(0)
+}
+
+func (store *IdempotencyStoreMock) Release(ctx context.Context, record entities.IdempotencyRecord) error {
+	args := store.Called(ctx, record)
+
+	return args.Error(0)
+}
+
+func (store *IdempotencyStoreMock) Purge(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := store.Called(ctx, before, limit)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type AuditRepositoryMock struct {
+	mock.Mock
+}
+
+func (repo *AuditRepositoryMock) Record(ctx context.Context, entries ...entities.AuditEntry) error {
+	args := repo.Called(ctx, entries)
+
+	return args.Error(0)
+}
+
+func (repo *AuditRepositoryMock) ListEntries(ctx context.Context, filter entities.AuditFilter, beforeSeq int64, limit int) ([]entities.AuditEntry, error) {
+	args := repo.Called(ctx, filter, beforeSeq, limit)
+
+	return args.Get(0).([]entities.AuditEntry), args.Error(1)
+}
+
+func (repo *AuditRepositoryMock) ListChain(ctx context.Context, legacy bool, afterSeq int64, limit int) ([]entities.AuditEntry, error) {
+	args := repo.Called(ctx, legacy, afterSeq, limit)
+
+	return args.Get(0).([]entities.AuditEntry), args.Error(1)
+}
+
+func (repo *AuditRepositoryMock) ChainHead(ctx context.Context, legacy bool) (string, error) {
+	args := repo.Called(ctx, legacy)
+
+	return args.String(0), args.Error(1)
+}
+
+type OrganizationRepositoryMock struct {
+	mock.Mock
+}
+
+func (repo *OrganizationRepositoryMock) CreateOrganization(ctx context.Context, org entities.Organization) error {
+	args := repo.Called(ctx, org)
+
+	return args.Error(0)
+}
+
+func (repo *OrganizationRepositoryMock) GetOrganization(ctx context.Context, id string) (entities.Organization, error) {
+	args := repo.Called(ctx, id)
+
+	return args.Get(0).(entities.Organization), args.Error(1)
+}
+
+func (repo *OrganizationRepositoryMock) ListOrganizations(ctx context.Context, afterId string, limit int) ([]entities.Organization, error) {
+	args := repo.Called(ctx, afterId, limit)
+
+	return args.Get(0).([]entities.Organization), args.Error(1)
+}
+
+func (repo *OrganizationRepositoryMock) UpdateOrganization(ctx context.Context, id string, name string) error {
+	args := repo.Called(ctx, id, name)
+
+	return args.Error(0)
+}
+
+func (repo *OrganizationRepositoryMock) DeleteOrganization(ctx context.Context, id string) error {
+	args := repo.Called(ctx, id)
+
+	return args.Error(0)
+}
+
+type GroupRepositoryMock struct {
+	mock.Mock
+}
+
+func (repo *GroupRepositoryMock) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (repo *GroupRepositoryMock) CreateGroup(ctx context.Context, group entities.Group) error {
+	args := repo.Called(ctx, group)
+
+	return args.Error(0)
+}
+
+func (repo *GroupRepositoryMock) GetGroup(ctx context.Context, id string) (entities.Group, error) {
+	args := repo.Called(ctx, id)
+
+	return args.Get(0).(entities.Group), args.Error(1)
+}
+
+func (repo *GroupRepositoryMock) LockGroup(ctx context.Context, id string) error {
+	args := repo.Called(ctx, id)
+
+	return args.Error(0)
+}
+
+func (repo *GroupRepositoryMock) ListGroups(ctx context.Context, afterId string, limit int) ([]entities.Group, error) {
+	args := repo.Called(ctx, afterId, limit)
+
+	return args.Get(0).([]entities.Group), args.Error(1)
+}
+
+func (repo *GroupRepositoryMock) RenameGroup(ctx context.Context, id string, name string) error {
+	args := repo.Called(ctx, id, name)
+
+	return args.Error(0)
+}
+
+func (repo *GroupRepositoryMock) DeleteGroup(ctx context.Context, id string) error {
+	args := repo.Called(ctx, id)
+
+	return args.Error(0)
+}
+
+func (repo *GroupRepositoryMock) AddMember(ctx context.Context, member entities.GroupMember) error {
+	args := repo.Called(ctx, member)
+
+	return args.Error(0)
+}
+
+func (repo *GroupRepositoryMock) GetMember(ctx context.Context, groupId string, userId string) (entities.GroupMember, error) {
+	args := repo.Called(ctx, groupId, userId)
+
+	return args.Get(0).(entities.GroupMember), args.Error(1)
+}
+
+func (repo *GroupRepositoryMock) UpdateMember(ctx context.Context, member entities.GroupMember) error {
+	args := repo.Called(ctx, member)
+
+	return args.Error(0)
+}
+
+func (repo *GroupRepositoryMock) RemoveMember(ctx context.Context, groupId string, userId string) error {
+	args := repo.Called(ctx, groupId, userId)
+
+	return args.Error(0)
+}
+
+func (repo *GroupRepositoryMock) CountOwners(ctx context.Context, groupId string) (int, error) {
+	args := repo.Called(ctx, groupId)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (repo *GroupRepositoryMock) ListMembers(ctx context.Context, groupId string, afterUserId string, limit int) ([]entities.GroupMember, error) {
+	args := repo.Called(ctx, groupId, afterUserId, limit)
+
+	return args.Get(0).([]entities.GroupMember), args.Error(1)
+}
+
+func (repo *GroupRepositoryMock) ListUserGroups(ctx context.Context, userId string) ([]entities.UserGroup, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Get(0).([]entities.UserGroup), args.Error(1)
+}
+
+func (repo *GroupRepositoryMock) UserExists(ctx context.Context, userId string) (bool, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Bool(0), args.Error(1)
+}
+
+type InvitationRepositoryMock struct {
+	mock.Mock
+}
+
+func (repo *InvitationRepositoryMock) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (repo *InvitationRepositoryMock) CreateInvitation(ctx context.Context, invitation entities.Invitation, tokenHash string) error {
+	args := repo.Called(ctx, invitation, tokenHash)
+
+	return args.Error(0)
+}
+
+func (repo *InvitationRepositoryMock) GetInvitation(ctx context.Context, id string) (entities.Invitation, string, error) {
+	args := repo.Called(ctx, id)
+
+	return args.Get(0).(entities.Invitation), args.String(1), args.Error(2)
+}
+
+func (repo *InvitationRepositoryMock) LockInvitation(ctx context.Context, id string) (entities.Invitation, string, error) {
+	args := repo.Called(ctx, id)
+
+	return args.Get(0).(entities.Invitation), args.String(1), args.Error(2)
+}
+
+func (repo *InvitationRepositoryMock) ListInvitations(ctx context.Context, afterId string, limit int) ([]entities.Invitation, error) {
+	args := repo.Called(ctx, afterId, limit)
+
+	return args.Get(0).([]entities.Invitation), args.Error(1)
+}
+
+func (repo *InvitationRepositoryMock) RenewInvitation(ctx context.Context, invitation entities.Invitation, tokenHash string) error {
+	args := repo.Called(ctx, invitation, tokenHash)
+
+	return args.Error(0)
+}
+
+func (repo *InvitationRepositoryMock) CloseInvitation(ctx context.Context, invitation entities.Invitation) error {
+	args := repo.Called(ctx, invitation)
+
+	return args.Error(0)
+}
+
+func (repo *InvitationRepositoryMock) ListUserInvitations(ctx context.Context, userId string) ([]entities.Invitation, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Get(0).([]entities.Invitation), args.Error(1)
+}
+
+func (repo *InvitationRepositoryMock) DeleteUserInvitations(ctx context.Context, userId string) error {
+	args := repo.Called(ctx, userId)
+
+	return args.Error(0)
+}
+
+func (repo *InvitationRepositoryMock) EmailTaken(ctx context.Context, email string) (bool, error) {
+	args := repo.Called(ctx, email)
+
+	return args.Bool(0), args.Error(1)
+}
+
+// NotifierMock records the links it is asked to deliver.
+type NotifierMock struct {
+	mock.Mock
+}
+
+func (n *NotifierMock) Notify(ctx context.Context, invitation entities.Invitation, link string) error {
+	args := n.Called(ctx, invitation, link)
+
+	return args.Error(0)
+}
+
+type UserCreatorMock struct {
+	mock.Mock
+}
+
+func (u *UserCreatorMock) CreateUser(ctx context.Context, rq entities.CreateUserRequest) (entities.CreateUserResponse, error) {
+	args := u.Called(ctx, rq)
+
+	return args.Get(0).(entities.CreateUserResponse), args.Error(1)
+}
+
+type ConsentRepositoryMock struct {
+	mock.Mock
+}
+
+func (repo *ConsentRepositoryMock) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (repo *ConsentRepositoryMock) CreatePolicy(ctx context.Context, policy entities.Policy) error {
+	args := repo.Called(ctx, policy)
+
+	return args.Error(0)
+}
+
+func (repo *ConsentRepositoryMock) CurrentPolicies(ctx context.Context) ([]entities.Policy, error) {
+	args := repo.Called(ctx)
+
+	return args.Get(0).([]entities.Policy), args.Error(1)
+}
+
+func (repo *ConsentRepositoryMock) ListPolicyVersions(ctx context.Context, kind string) ([]entities.Policy, error) {
+	args := repo.Called(ctx, kind)
+
+	return args.Get(0).([]entities.Policy), args.Error(1)
+}
+
+func (repo *ConsentRepositoryMock) CreateConsent(ctx context.Context, consent entities.Consent) error {
+	args := repo.Called(ctx, consent)
+
+	return args.Error(0)
+}
+
+func (repo *ConsentRepositoryMock) ListConsents(ctx context.Context, userId string) ([]entities.Consent, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Get(0).([]entities.Consent), args.Error(1)
+}
+
+func (repo *ConsentRepositoryMock) LockConsent(ctx context.Context, userId string, kind string) (entities.Consent, error) {
+	args := repo.Called(ctx, userId, kind)
+
+	return args.Get(0).(entities.Consent), args.Error(1)
+}
+
+func (repo *ConsentRepositoryMock) WithdrawConsent(ctx context.Context, consent entities.Consent) error {
+	args := repo.Called(ctx, consent)
+
+	return args.Error(0)
+}
+
+func (repo *ConsentRepositoryMock) UserExists(ctx context.Context, userId string) (bool, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Bool(0), args.Error(1)
+}
+
+type PreferenceRepositoryMock struct {
+	mock.Mock
+}
+
+func (repo *PreferenceRepositoryMock) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (repo *PreferenceRepositoryMock) GetPreferences(ctx context.Context, userId string) (entities.Preferences, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Get(0).(entities.Preferences), args.Error(1)
+}
+
+func (repo *PreferenceRepositoryMock) SavePreferences(ctx context.Context, preferences entities.Preferences) error {
+	args := repo.Called(ctx, preferences)
+
+	return args.Error(0)
+}
+
+func (repo *PreferenceRepositoryMock) LockUser(ctx context.Context, userId string) error {
+	args := repo.Called(ctx, userId)
+
+	return args.Error(0)
+}
+
+func (repo *PreferenceRepositoryMock) UserExists(ctx context.Context, userId string) (bool, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Bool(0), args.Error(1)
+}
+
+type ImpersonationRepositoryMock struct {
+	mock.Mock
+}
+
+func (repo *ImpersonationRepositoryMock) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (repo *ImpersonationRepositoryMock) CreateSession(ctx context.Context, session entities.ImpersonationSession) error {
+	args := repo.Called(ctx, session)
+
+	return args.Error(0)
+}
+
+func (repo *ImpersonationRepositoryMock) GetSession(ctx context.Context, id string) (entities.ImpersonationSession, error) {
+	args := repo.Called(ctx, id)
+
+	return args.Get(0).(entities.ImpersonationSession), args.Error(1)
+}
+
+func (repo *ImpersonationRepositoryMock) EndSession(ctx context.Context, session entities.ImpersonationSession) error {
+	args := repo.Called(ctx, session)
+
+	return args.Error(0)
+}
+
+func (repo *ImpersonationRepositoryMock) UserExists(ctx context.Context, userId string) (bool, error) {
+	args := repo.Called(ctx, userId)
+
+	return args.Bool(0), args.Error(1)
+}
+
+type ApiKeyRepositoryMock struct {
+	mock.Mock
+}
+
+func (repo *ApiKeyRepositoryMock) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (repo *ApiKeyRepositoryMock) CreateKey(ctx context.Context, key entities.ApiKey) error {
+	args := repo.Called(ctx, key)
+
+	return args.Error(0)
+}
+
+func (repo *ApiKeyRepositoryMock) GetKeyByPrefix(ctx context.Context, prefix string) (entities.ApiKey, error) {
+	args := repo.Called(ctx, prefix)
+
+	return args.Get(0).(entities.ApiKey), args.Error(1)
+}
+
+func (repo *ApiKeyRepositoryMock) LockKey(ctx context.Context, id string) (entities.ApiKey, error) {
+	args := repo.Called(ctx, id)
+
+	return args.Get(0).(entities.ApiKey), args.Error(1)
+}
+
+func (repo *ApiKeyRepositoryMock) ListKeys(ctx context.Context) ([]entities.ApiKey, error) {
+	args := repo.Called(ctx)
+
+	return args.Get(0).([]entities.ApiKey), args.Error(1)
+}
+
+func (repo *ApiKeyRepositoryMock) RevokeKey(ctx context.Context, id string, at time.Time) error {
+	args := repo.Called(ctx, id, at)
+
+	return args.Error(0)
+}
+
+func (repo *ApiKeyRepositoryMock) RotateKey(ctx context.Context, id string, expiresAt time.Time, replacedBy string) error {
+	args := repo.Called(ctx, id, expiresAt, replacedBy)
+
+	return args.Error(0)
+}
+
+func (repo *ApiKeyRepositoryMock) TouchKey(ctx context.Context, id string, at time.Time, since time.Time) error {
+	args := repo.Called(ctx, id, at, since)
+
+	return args.Error(0)
+}
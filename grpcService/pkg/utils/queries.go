@@ -1,8 +1,177 @@
 package utils
 
+import (
+	"fmt"
+	"strings"
+)
+
 var (
-	CreateUserQuery  string = "INSERT INTO USER (first_name, id, pass, age, email) VALUES (?,?,?,?,?)"
-	GetUserQuery     string = "SELECT first_name, age, email FROM USER WHERE id=?"
-	GetPasswordQuery string = "SELECT pass FROM USER WHERE id = ?"
-	DeleteUserQuery  string = "DELETE FROM USER WHERE id = ?"
+	CreateUserQuery     string = "INSERT INTO USER (first_name, id, pass, age, email, email_index, key_version, tenant_id, attributes) VALUES (?,?,?,?,?,?,?,?,?)"
+	GetUserQuery        string = "SELECT first_name, age, email, key_version, version, attributes FROM USER WHERE id=? AND tenant_id=?"
+	GetUserByEmailQuery string = "SELECT id, first_name, pass, age, email, key_version FROM USER WHERE email_index=? AND tenant_id=?"
+	GetPasswordQuery    string = "SELECT pass FROM USER WHERE id = ? AND tenant_id = ?"
+	DeleteUserQuery     string = "DELETE FROM USER WHERE id = ? AND tenant_id = ?"
+	LockUserQuery       string = "SELECT version FROM USER WHERE id = ? AND tenant_id = ? FOR UPDATE"
+
+	// Batch queries are completed with Placeholders for the number of rows,
+	// the tenant comes first in the arguments of the ones that select.
+	CreateUsersQuery string = "INSERT INTO USER (first_name, id, pass, age, email, email_index, key_version, tenant_id, attributes) VALUES %s"
+	GetUsersQuery    string = "SELECT id, first_name, age, email, key_version, version, attributes FROM USER WHERE tenant_id = ? AND id IN (%s)"
+	LockUsersQuery   string = "SELECT id FROM USER WHERE tenant_id = ? AND id IN (%s) FOR UPDATE"
+	DeleteUsersQuery string = "DELETE FROM USER WHERE tenant_id = ? AND id IN (%s)"
+
+	ListUsersAfterQuery string = "SELECT id, first_name, age, email, key_version FROM USER WHERE tenant_id = ? AND id > ? ORDER BY id LIMIT ?"
+	// FilterUsersQuery is completed with an AttributeFilterClause for every
+	// filter.
+	FilterUsersQuery      string = "SELECT id, first_name, age, email, key_version, version, attributes FROM USER WHERE tenant_id = ? AND id > ?%s ORDER BY id LIMIT ?"
+	AttributeFilterClause string = " AND id IN (SELECT user_id FROM user_attribute_index WHERE tenant_id = ? AND name = ? AND value = ?)"
+
+	UpdateUserAttributesQuery string = "UPDATE USER SET attributes = ?, version = version + 1, updated_at = ? WHERE id = ? AND tenant_id = ?"
+	ListUserAttributesQuery   string = "SELECT id, attributes FROM USER WHERE tenant_id = ? AND id > ? AND attributes IS NOT NULL ORDER BY id LIMIT ?"
+
+	GetAttributeSchemaQuery  string = "SELECT schema_json, updated_at FROM attribute_schemas WHERE tenant_id = ?"
+	SaveAttributeSchemaQuery string = "INSERT INTO attribute_schemas (tenant_id, schema_json, updated_at) VALUES (?,?,?) ON DUPLICATE KEY UPDATE schema_json = VALUES(schema_json), updated_at = VALUES(updated_at)"
+	// InsertAttributeIndexQuery is completed with Placeholders for the number of rows.
+	InsertAttributeIndexQuery       string = "INSERT INTO user_attribute_index (tenant_id, user_id, name, value) VALUES %s"
+	DeleteUserAttributeIndexQuery   string = "DELETE FROM user_attribute_index WHERE tenant_id = ? AND user_id = ?"
+	DeleteUsersAttributeIndexQuery  string = "DELETE FROM user_attribute_index WHERE tenant_id = ? AND user_id IN (%s)"
+	DeleteTenantAttributeIndexQuery string = "DELETE FROM user_attribute_index WHERE tenant_id = ?"
+
+	GetAvatarQuery         string = "SELECT hash, content_type, sizes, updated_at FROM user_avatars WHERE tenant_id = ? AND user_id = ?"
+	GetAvatarsQuery        string = "SELECT user_id, hash, content_type, sizes, updated_at FROM user_avatars WHERE tenant_id = ? AND user_id IN (%s)"
+	SaveAvatarQuery        string = "INSERT INTO user_avatars (tenant_id, user_id, hash, content_type, sizes, updated_at) VALUES (?,?,?,?,?,?) ON DUPLICATE KEY UPDATE hash = VALUES(hash), content_type = VALUES(content_type), sizes = VALUES(sizes), updated_at = VALUES(updated_at)"
+	DeleteUserAvatarQuery  string = "DELETE FROM user_avatars WHERE tenant_id = ? AND user_id = ?"
+	DeleteUsersAvatarQuery string = "DELETE FROM user_avatars WHERE tenant_id = ? AND user_id IN (%s)"
+
+	StartImportQuery   string = "INSERT IGNORE INTO user_imports (tenant_id, id, format) VALUES (?,?,?)"
+	GetImportQuery     string = "SELECT id, format, rows_committed, imported, failed FROM user_imports WHERE tenant_id = ? AND id = ?"
+	AdvanceImportQuery string = "UPDATE user_imports SET rows_committed = ?, imported = ?, failed = ? WHERE tenant_id = ? AND id = ? AND rows_committed = ?"
+
+	ClaimIdempotencyKeyQuery    string = "INSERT IGNORE INTO idempotency_keys (scope, idem_key, fingerprint, status, expires_at) VALUES (?,?,?,'in_flight',?)"
+	TakeOverIdempotencyKeyQuery string = "UPDATE idempotency_keys SET fingerprint = ?, status = 'in_flight', response = NULL, code = 0, message = '', expires_at = ? WHERE scope = ? AND idem_key = ? AND expires_at < ?"
+	GetIdempotencyKeyQuery      string = "SELECT fingerprint, status, response, code, message, expires_at FROM idempotency_keys WHERE scope = ? AND idem_key = ?"
+	CompleteIdempotencyKeyQuery string = "UPDATE idempotency_keys SET status = 'completed', response = ?, code = ?, message = ?, expires_at = ? WHERE scope = ? AND idem_key = ? AND fingerprint = ? AND status = 'in_flight'"
+	ReleaseIdempotencyKeyQuery  string = "DELETE FROM idempotency_keys WHERE scope = ? AND idem_key = ? AND fingerprint = ? AND status = 'in_flight'"
+	PurgeIdempotencyKeysQuery   string = "DELETE FROM idempotency_keys WHERE expires_at < ? LIMIT ?"
+
+	// Key rotation goes through the users of every tenant.
+	ListStaleKeyUsersQuery string = "SELECT id, first_name, email, key_version FROM USER WHERE key_version <> ? AND id > ? ORDER BY id LIMIT ?"
+	ListLegacyUsersQuery   string = "SELECT id, first_name, email, key_version FROM USER WHERE key_version = 0 AND id > ? ORDER BY id LIMIT ?"
+	RewrapUserQuery        string = "UPDATE USER SET first_name=?, email=?, email_index=?, key_version=? WHERE id=? AND key_version=?"
+
+	InsertOutboxEventQuery        string = "INSERT INTO outbox (id, event_type, aggregate_id, payload, tenant_id) VALUES (?,?,?,?,?)"
+	ListPendingOutboxEventsQuery  string = "SELECT seq, tenant_id, payload FROM outbox WHERE published_at IS NULL ORDER BY seq LIMIT ? FOR UPDATE SKIP LOCKED"
+	MarkOutboxEventPublishedQuery string = "UPDATE outbox SET published_at = CURRENT_TIMESTAMP(6) WHERE seq = ?"
+	ListEventsAfterQuery          string = "SELECT seq, payload FROM outbox WHERE tenant_id = ? AND seq > ? AND created_at <= CURRENT_TIMESTAMP(6) - INTERVAL ? MICROSECOND ORDER BY seq LIMIT ?"
+	LastEventSeqQuery             string = "SELECT COALESCE(MAX(seq), 0) FROM outbox WHERE tenant_id = ?"
+	ListAggregateEventsQuery      string = "SELECT seq, payload FROM outbox WHERE tenant_id = ? AND aggregate_id = ? ORDER BY seq"
+
+	SaveErasureQuery string = "INSERT INTO user_erasures (user_id, receipt_id, erased_at, receipt, tenant_id) VALUES (?,?,?,?,?)"
+	GetErasureQuery  string = "SELECT receipt FROM user_erasures WHERE user_id = ? AND tenant_id = ?"
+
+	// Each tenant has a chain of its own, the head of a chain is created by
+	// its first entry.
+	LockAuditChainQuery    string = "SELECT hash FROM audit_chain_head WHERE tenant_id = ? FOR UPDATE"
+	StartAuditChainQuery   string = "INSERT INTO audit_chain_head (tenant_id, hash) VALUES (?, ?) ON DUPLICATE KEY UPDATE tenant_id = tenant_id"
+	AdvanceAuditChainQuery string = "UPDATE audit_chain_head SET hash = ? WHERE tenant_id = ?"
+	GetAuditChainHeadQuery string = "SELECT hash FROM audit_chain_head WHERE tenant_id = ?"
+	// InsertAuditEntriesQuery is completed with Placeholders for the number of entries.
+	InsertAuditEntriesQuery string = "INSERT INTO audit_log (id, occurred_at, actor, source_ip, request_id, operation, target_user_id, diff, outcome, prev_hash, hash, tenant_id, impersonation_id, chain_id) VALUES %s"
+	ListAuditChainQuery     string = "SELECT seq, id, occurred_at, actor, source_ip, request_id, operation, target_user_id, diff, outcome, prev_hash, hash, impersonation_id, tenant_id FROM audit_log WHERE chain_id = ? AND seq > ? ORDER BY seq LIMIT ?"
+	// ListAuditEntriesQuery is completed with the optional filters.
+	ListAuditEntriesQuery string = "SELECT seq, id, occurred_at, actor, source_ip, request_id, operation, target_user_id, diff, outcome, prev_hash, hash, impersonation_id FROM audit_log WHERE tenant_id = ? AND seq < ? AND occurred_at >= ? AND occurred_at < ?%s ORDER BY seq DESC LIMIT ?"
+
+	CreateOrganizationQuery     string = "INSERT INTO organizations (id, name, created_at) VALUES (?,?,?)"
+	GetOrganizationQuery        string = "SELECT id, name, created_at FROM organizations WHERE id = ?"
+	ListOrganizationsAfterQuery string = "SELECT id, name, created_at FROM organizations WHERE id > ? ORDER BY id LIMIT ?"
+	UpdateOrganizationQuery     string = "UPDATE organizations SET name = ? WHERE id = ?"
+	DeleteOrganizationQuery     string = "DELETE FROM organizations WHERE id = ?"
+
+	CreateGroupQuery     string = "INSERT INTO user_groups (id, tenant_id, name, created_at) VALUES (?,?,?,?)"
+	GetGroupQuery        string = "SELECT id, name, created_at FROM user_groups WHERE tenant_id = ? AND id = ?"
+	LockGroupQuery       string = "SELECT id FROM user_groups WHERE tenant_id = ? AND id = ? FOR UPDATE"
+	ListGroupsAfterQuery string = "SELECT id, name, created_at FROM user_groups WHERE tenant_id = ? AND id > ? ORDER BY id LIMIT ?"
+	RenameGroupQuery     string = "UPDATE user_groups SET name = ? WHERE tenant_id = ? AND id = ?"
+	DeleteGroupQuery     string = "DELETE FROM user_groups WHERE tenant_id = ? AND id = ?"
+	// AddGroupMemberQuery inserts nothing when the user isn't one of the tenant.
+	AddGroupMemberQuery    string = "INSERT INTO group_members (tenant_id, group_id, user_id, role, joined_at) SELECT tenant_id, ?, id, ?, ? FROM USER WHERE tenant_id = ? AND id = ?"
+	GetGroupMemberQuery    string = "SELECT group_id, user_id, role, joined_at FROM group_members WHERE tenant_id = ? AND group_id = ? AND user_id = ?"
+	UpdateGroupMemberQuery string = "UPDATE group_members SET role = ? WHERE tenant_id = ? AND group_id = ? AND user_id = ?"
+	RemoveGroupMemberQuery string = "DELETE FROM group_members WHERE tenant_id = ? AND group_id = ? AND user_id = ?"
+	CountGroupOwnersQuery  string = "SELECT COUNT(*) FROM group_members WHERE tenant_id = ? AND group_id = ? AND role = 'owner'"
+	ListGroupMembersQuery  string = "SELECT m.group_id, m.user_id, m.role, m.joined_at FROM group_members m JOIN USER u ON u.tenant_id = m.tenant_id AND u.id = m.user_id WHERE m.tenant_id = ? AND m.group_id = ? AND m.user_id > ? ORDER BY m.user_id LIMIT ?"
+	ListUserGroupsQuery    string = "SELECT g.id, g.name, g.created_at, m.role FROM group_members m JOIN user_groups g ON g.id = m.group_id WHERE m.tenant_id = ? AND m.user_id = ? ORDER BY g.name"
+	UserExistsQuery        string = "SELECT EXISTS (SELECT 1 FROM USER WHERE tenant_id = ? AND id = ?)"
+	// Deleting users deletes their memberships first.
+	DeleteUserMembershipsQuery  string = "DELETE FROM group_members WHERE tenant_id = ? AND user_id = ?"
+	DeleteUsersMembershipsQuery string = "DELETE FROM group_members WHERE tenant_id = ? AND user_id IN (%s)"
+
+	CreateInvitationQuery     string = "INSERT INTO invitations (id, tenant_id, email, pending_email_index, roles, group_id, status, invited_by, token_hash, created_at, expires_at) VALUES (?,?,?,?,?,?,?,?,?,?,?)"
+	GetInvitationQuery        string = "SELECT id, email, roles, group_id, status, invited_by, created_at, expires_at, accepted_at, user_id, token_hash FROM invitations WHERE tenant_id = ? AND id = ?"
+	LockInvitationQuery       string = "SELECT id, email, roles, group_id, status, invited_by, created_at, expires_at, accepted_at, user_id, token_hash FROM invitations WHERE tenant_id = ? AND id = ? FOR UPDATE"
+	ListInvitationsAfterQuery string = "SELECT id, email, roles, group_id, status, invited_by, created_at, expires_at, accepted_at, user_id, token_hash FROM invitations WHERE tenant_id = ? AND id > ? ORDER BY id LIMIT ?"
+	// ReleaseExpiredInvitationQuery lets an expired invitation be sent again.
+	ReleaseExpiredInvitationQuery string = "UPDATE invitations SET pending_email_index = NULL WHERE tenant_id = ? AND pending_email_index = ? AND expires_at <= ?"
+	RenewInvitationQuery          string = "UPDATE invitations SET token_hash = ?, expires_at = ?, pending_email_index = ? WHERE tenant_id = ? AND id = ?"
+	CloseInvitationQuery          string = "UPDATE invitations SET status = ?, pending_email_index = NULL, accepted_at = ?, user_id = ? WHERE tenant_id = ? AND id = ?"
+	ListUserInvitationsQuery      string = "SELECT id, email, roles, group_id, status, invited_by, created_at, expires_at, accepted_at, user_id, token_hash FROM invitations WHERE tenant_id = ? AND user_id = ? ORDER BY id"
+	DeleteUserInvitationsQuery    string = "DELETE FROM invitations WHERE tenant_id = ? AND user_id = ?"
+	EmailTakenQuery               string = "SELECT EXISTS (SELECT 1 FROM USER WHERE tenant_id = ? AND email_index = ?)"
+
+	CreatePolicyQuery       string = "INSERT INTO policies (tenant_id, kind, version, url, mandatory, published_at) VALUES (?,?,?,?,?,?)"
+	ListPolicyVersionsQuery string = "SELECT kind, version, url, mandatory, published_at FROM policies WHERE tenant_id = ? AND kind = ? ORDER BY published_at DESC"
+	// CurrentPoliciesQuery picks the last version published of every kind.
+	CurrentPoliciesQuery string = "SELECT p.kind, p.version, p.url, p.mandatory, p.published_at FROM policies p WHERE p.tenant_id = ? AND p.published_at = (SELECT MAX(published_at) FROM policies WHERE tenant_id = p.tenant_id AND kind = p.kind) ORDER BY p.kind"
+	CreateConsentQuery   string = "INSERT INTO consents (tenant_id, user_id, kind, version, source, accepted_at) VALUES (?,?,?,?,?,?)"
+	ListConsentsQuery    string = "SELECT user_id, kind, version, source, accepted_at, withdrawn_at, withdrawn_from FROM consents WHERE tenant_id = ? AND user_id = ? ORDER BY seq DESC"
+	LockConsentQuery     string = "SELECT user_id, kind, version, source, accepted_at, withdrawn_at, withdrawn_from FROM consents WHERE tenant_id = ? AND user_id = ? AND kind = ? ORDER BY seq DESC LIMIT 1 FOR UPDATE"
+	WithdrawConsentQuery string = "UPDATE consents SET withdrawn_at = ?, withdrawn_from = ? WHERE tenant_id = ? AND user_id = ? AND kind = ? ORDER BY seq DESC LIMIT 1"
+
+	GetPreferencesQuery         string = "SELECT preferences, updated_at FROM user_preferences WHERE tenant_id = ? AND user_id = ?"
+	SavePreferencesQuery        string = "INSERT INTO user_preferences (tenant_id, user_id, preferences, updated_at) VALUES (?,?,?,?) ON DUPLICATE KEY UPDATE preferences = VALUES(preferences), updated_at = VALUES(updated_at)"
+	DeleteUserPreferencesQuery  string = "DELETE FROM user_preferences WHERE tenant_id = ? AND user_id = ?"
+	DeleteUsersPreferencesQuery string = "DELETE FROM user_preferences WHERE tenant_id = ? AND user_id IN (%s)"
+
+	CreateImpersonationQuery string = "INSERT INTO impersonation_sessions (id, tenant_id, actor, user_id, reason, created_at, expires_at) VALUES (?,?,?,?,?,?,?)"
+	GetImpersonationQuery    string = "SELECT id, actor, user_id, reason, created_at, expires_at, ended_at FROM impersonation_sessions WHERE tenant_id = ? AND id = ?"
+	// EndImpersonationQuery leaves sessions already ended as they were.
+	EndImpersonationQuery string = "UPDATE impersonation_sessions SET ended_at = ? WHERE tenant_id = ? AND id = ? AND ended_at IS NULL"
+
+	CreateApiKeyQuery      string = "INSERT INTO api_keys (id, tenant_id, name, prefix, hash, scopes, created_by, created_at, expires_at) VALUES (?,?,?,?,?,?,?,?,?)"
+	GetApiKeyByPrefixQuery string = "SELECT id, tenant_id, name, prefix, hash, scopes, created_by, created_at, expires_at, revoked_at, last_used_at, replaced_by FROM api_keys WHERE prefix = ?"
+	LockApiKeyQuery        string = "SELECT id, tenant_id, name, prefix, hash, scopes, created_by, created_at, expires_at, revoked_at, last_used_at, replaced_by FROM api_keys WHERE tenant_id = ? AND id = ? FOR UPDATE"
+	ListApiKeysQuery       string = "SELECT id, tenant_id, name, prefix, hash, scopes, created_by, created_at, expires_at, revoked_at, last_used_at, replaced_by FROM api_keys WHERE tenant_id = ? ORDER BY created_at DESC"
+	RevokeApiKeyQuery      string = "UPDATE api_keys SET revoked_at = ? WHERE tenant_id = ? AND id = ? AND revoked_at IS NULL"
+	RotateApiKeyQuery      string = "UPDATE api_keys SET expires_at = ?, replaced_by = ? WHERE tenant_id = ? AND id = ?"
+	// TouchApiKeyQuery only writes when the last use recorded is older
+	// than its last argument, keys used often aren't written every call.
+	TouchApiKeyQuery string = "UPDATE api_keys SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)"
+
+	CreateWebhookQuery        string = "INSERT INTO webhooks (id, tenant_id, url, secret, event_types, active, created_at) VALUES (?,?,?,?,?,?,?)"
+	ListWebhooksQuery         string = "SELECT id, url, secret, event_types, active, consecutive_failures, created_at FROM webhooks WHERE tenant_id = ? ORDER BY created_at"
+	ListActiveWebhooksQuery   string = "SELECT id, url, secret, event_types, active, consecutive_failures, created_at FROM webhooks WHERE tenant_id = ? AND active = TRUE ORDER BY created_at"
+	GetWebhookQuery           string = "SELECT id, url, secret, event_types, active, consecutive_failures, created_at FROM webhooks WHERE tenant_id = ? AND id = ?"
+	DeleteWebhookQuery        string = "DELETE FROM webhooks WHERE tenant_id = ? AND id = ?"
+	RecordWebhookSuccessQuery string = "UPDATE webhooks SET consecutive_failures = 0 WHERE tenant_id = ? AND id = ?"
+	// MySQL applies the assignments in order, active sees the incremented count.
+	RecordWebhookFailureQuery string = "UPDATE webhooks SET consecutive_failures = consecutive_failures + 1, active = consecutive_failures < ? WHERE tenant_id = ? AND id = ?"
+
+	CreateWebhookDeliveryQuery    string = "INSERT IGNORE INTO webhook_deliveries (id, tenant_id, webhook_id, event_id, event_type, payload, attempt, status, next_attempt_at, created_at) VALUES (?,?,?,?,?,?,?,?,?,?)"
+	NextWebhookAttemptQuery       string = "SELECT COALESCE(MAX(attempt), 0) + 1 FROM webhook_deliveries WHERE tenant_id = ? AND webhook_id = ? AND event_id = ?"
+	ListDueWebhookDeliveriesQuery string = "SELECT id, tenant_id, webhook_id, event_id, event_type, payload, attempt, status, response_code, error, created_at, completed_at FROM webhook_deliveries WHERE status IN ('pending', 'sending') AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ? FOR UPDATE SKIP LOCKED"
+	LeaseWebhookDeliveryQuery     string = "UPDATE webhook_deliveries SET status = 'sending', next_attempt_at = ? WHERE id = ?"
+	CompleteWebhookDeliveryQuery  string = "UPDATE webhook_deliveries SET status = ?, response_code = ?, error = ?, completed_at = ? WHERE tenant_id = ? AND id = ?"
+	ListWebhookDeliveriesQuery    string = "SELECT id, tenant_id, webhook_id, event_id, event_type, payload, attempt, status, response_code, error, created_at, completed_at FROM webhook_deliveries WHERE tenant_id = ? AND webhook_id = ? ORDER BY created_at DESC, attempt DESC LIMIT ?"
+	GetWebhookDeliveryQuery       string = "SELECT id, tenant_id, webhook_id, event_id, event_type, payload, attempt, status, response_code, error, created_at, completed_at FROM webhook_deliveries WHERE tenant_id = ? AND id = ?"
 )
+
+// Placeholders fills query with n groups of columns placeholders, "?,?"
+// for a single column or "(?,?),(?,?)" for rows of several.
+func Placeholders(query string, n int, columns int) string {
+	group := strings.TrimSuffix(strings.Repeat("?,", columns), ",")
+	if columns > 1 {
+		group = "(" + group + ")"
+	}
+
+	return fmt.Sprintf(query, strings.TrimSuffix(strings.Repeat(group+",", n), ","))
+}
@@ -1,9 +1,12 @@
 package utils
 
 import (
-	"github.com/google/uuid"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
 )
 
+// generator makes ids the way the service does by default.
+var generator = ids.NewUUIDv7Generator()
+
 func GenerateId() string {
-	return uuid.NewString()
+	return generator.NewId()
 }
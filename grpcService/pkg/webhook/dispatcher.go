@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	pb "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+// RetryPolicy decides when a failed delivery is attempted again.
+type RetryPolicy struct {
+	MaxAttempts uint32
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 8,
+		BaseDelay:   10 * time.Second,
+		MaxDelay:    time.Hour,
+	}
+}
+
+// Backoff returns how long to wait after the given failed attempt. The
+// delay doubles with every attempt up to MaxDelay, and a random half of it
+// is dropped so endpoints that came back up aren't hit all at once.
+func (p RetryPolicy) Backoff(attempt uint32) time.Duration {
+	delay := p.MaxDelay
+	if attempt < 32 {
+		if d := p.BaseDelay << (attempt - 1); d > 0 && d < p.MaxDelay {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// Dispatcher turns user events into webhook deliveries and sends them.
+// Publish only queues the deliveries, so it can sit behind the outbox relay
+// without slow endpoints holding the relay back.
+type Dispatcher struct {
+	Repo   Repository
+	Client *http.Client
+	Retry  RetryPolicy
+	// DisableAfter consecutive failed attempts deactivate a webhook.
+	DisableAfter uint32
+	Lease        time.Duration
+	BatchSize    int
+	Logger       log.Logger
+
+	now func() time.Time
+}
+
+func NewDispatcher(repo Repository, client *http.Client, logger log.Logger) *Dispatcher {
+	return &Dispatcher{
+		Repo:         repo,
+		Client:       client,
+		Retry:        DefaultRetryPolicy(),
+		DisableAfter: 20,
+		Lease:        time.Minute,
+		BatchSize:    50,
+		Logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// Publish queues a delivery of event for every active webhook of the
+// tenant of ctx subscribed to its type. The outbox can hand the same event
+// over again, the queue ignores the repeated first attempts.
+func (d *Dispatcher) Publish(ctx context.Context, event *pb.Event) error {
+	webhooks, err := d.Repo.ListWebhooks(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	now := d.now()
+	for _, webhook := range webhooks {
+		if !subscribed(webhook, event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = protojson.Marshal(event); err != nil {
+				return err
+			}
+		}
+
+		delivery := entities.WebhookDelivery{
+			Id:        uuid.NewString(),
+			TenantId:  tenant.FromContext(ctx),
+			WebhookId: webhook.Id,
+			EventId:   event.Id,
+			EventType: event.Type,
+			Payload:   payload,
+			Attempt:   1,
+			Status:    StatusPending,
+			CreatedAt: now,
+		}
+		if err := d.Repo.CreateDelivery(ctx, delivery, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run sends due deliveries every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := d.DispatchDue(ctx)
+			if err != nil {
+				level.Error(d.Logger).Log("msg", "webhook dispatch failed", "error", err)
+				break
+			}
+			if n < d.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue sends up to BatchSize due deliveries concurrently and returns
+// how many were attempted.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := d.Repo.ClaimDeliveries(ctx, d.now(), d.Lease, d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery entities.WebhookDelivery) {
+			defer wg.Done()
+			if err := d.deliver(ctx, delivery); err != nil {
+				level.Error(d.Logger).Log("msg", "can't record webhook delivery", "delivery", delivery.Id, "error", err)
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery entities.WebhookDelivery) error {
+	ctx = tenant.WithTenant(ctx, delivery.TenantId)
+
+	webhook, err := d.Repo.GetWebhook(ctx, delivery.WebhookId)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if err == sql.ErrNoRows || !webhook.Active {
+		delivery.Status = StatusFailed
+		delivery.Error = "webhook disabled"
+		return d.complete(ctx, delivery)
+	}
+
+	delivery.ResponseCode, err = d.send(ctx, webhook, delivery)
+	success := err == nil
+	if success {
+		delivery.Status = StatusSucceeded
+	} else {
+		delivery.Status = StatusFailed
+		delivery.Error = err.Error()
+		level.Warn(d.Logger).Log("msg", "webhook delivery failed", "webhook", webhook.Id, "delivery", delivery.Id, "attempt", delivery.Attempt, "error", err)
+	}
+
+	if err := d.complete(ctx, delivery); err != nil {
+		return err
+	}
+
+	if err := d.Repo.RecordResult(ctx, webhook.Id, success, d.DisableAfter); err != nil {
+		return err
+	}
+
+	if success || delivery.Attempt >= d.Retry.MaxAttempts {
+		return nil
+	}
+
+	now := d.now()
+	retry := delivery
+	retry.Id = uuid.NewString()
+	retry.Attempt++
+	retry.Status = StatusPending
+	retry.ResponseCode = 0
+	retry.Error = ""
+	retry.CreatedAt = now
+	retry.CompletedAt = nil
+	return d.Repo.CreateDelivery(ctx, retry, now.Add(d.Retry.Backoff(delivery.Attempt)))
+}
+
+func (d *Dispatcher) complete(ctx context.Context, delivery entities.WebhookDelivery) error {
+	completedAt := d.now()
+	delivery.CompletedAt = &completedAt
+	return d.Repo.CompleteDelivery(ctx, delivery)
+}
+
+// send posts the payload and returns the response code. Anything but a 2xx
+// answer is a failure.
+func (d *Dispatcher) send(ctx context.Context, webhook entities.Webhook, delivery entities.WebhookDelivery) (int32, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdHeader, webhook.Id)
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.EventId)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return int32(resp.StatusCode), fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return int32(resp.StatusCode), nil
+}
+
+func subscribed(webhook entities.Webhook, eventType string) bool {
+	if len(webhook.EventTypes) == 0 {
+		return true
+	}
+
+	for _, t := range webhook.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
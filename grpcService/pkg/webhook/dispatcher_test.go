@@ -0,0 +1,216 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/webhook"
+)
+
+func TestDispatcherPublish(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	event := events.NewUserCreated("user-1")
+	inTenant := mock.MatchedBy(func(ctx context.Context) bool { return tenant.FromContext(ctx) == "acme" })
+	repo := &utils.WebhookRepositoryMock{}
+	repo.On("ListWebhooks", inTenant, true).Return([]entities.Webhook{
+		{Id: "all", Active: true},
+		{Id: "created", Active: true, EventTypes: []string{events.UserCreatedType}},
+		{Id: "deleted", Active: true, EventTypes: []string{events.UserDeletedType}},
+	}, nil)
+
+	var queued []entities.WebhookDelivery
+	repo.On("CreateDelivery", inTenant, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { queued = append(queued, args.Get(1).(entities.WebhookDelivery)) }).
+		Return(nil)
+
+	dispatcher := webhook.NewDispatcher(repo, http.DefaultClient, logger)
+	assert.NoError(t, dispatcher.Publish(tenant.WithTenant(context.Background(), "acme"), event))
+
+	assert.Len(t, queued, 2)
+	assert.Equal(t, "all", queued[0].WebhookId)
+	assert.Equal(t, "created", queued[1].WebhookId)
+	for _, delivery := range queued {
+		assert.Equal(t, event.Id, delivery.EventId)
+		assert.Equal(t, uint32(1), delivery.Attempt)
+		assert.Equal(t, webhook.StatusPending, delivery.Status)
+		assert.Equal(t, "acme", delivery.TenantId)
+	}
+}
+
+func TestDispatchDue(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	event := events.NewUserDeleted("user-1")
+	payload, _ := protojson.Marshal(event)
+	delivery := entities.WebhookDelivery{
+		Id:        "delivery-1",
+		TenantId:  "acme",
+		WebhookId: "webhook-1",
+		EventId:   event.Id,
+		EventType: event.Type,
+		Payload:   payload,
+		Attempt:   1,
+		Status:    webhook.StatusSending,
+	}
+
+	testCases := []struct {
+		Name           string
+		StatusCode     int
+		Active         bool
+		buildMock      func(repo *utils.WebhookRepositoryMock)
+		assertResponse func(t *testing.T, repo *utils.WebhookRepositoryMock, requests []*http.Request)
+	}{
+		{
+			Name:       "Signed Delivery Succeeds",
+			StatusCode: http.StatusNoContent,
+			Active:     true,
+			buildMock: func(repo *utils.WebhookRepositoryMock) {
+				repo.On("CompleteDelivery", mock.Anything, mock.MatchedBy(func(d entities.WebhookDelivery) bool {
+					return d.Status == webhook.StatusSucceeded && d.ResponseCode == http.StatusNoContent && d.CompletedAt != nil
+				})).Return(nil)
+				repo.On("RecordResult", mock.Anything, "webhook-1", true, uint32(20)).Return(nil)
+			},
+			assertResponse: func(t *testing.T, repo *utils.WebhookRepositoryMock, requests []*http.Request) {
+				assert.Len(t, requests, 1)
+				assert.Equal(t, event.Id, requests[0].Header.Get(webhook.DeliveryHeader))
+				assert.Equal(t, events.UserDeletedType, requests[0].Header.Get(webhook.EventHeader))
+				repo.AssertNotCalled(t, "CreateDelivery", mock.Anything, mock.Anything, mock.Anything)
+			},
+		},
+		{
+			Name:       "Failed Delivery Is Retried Later",
+			StatusCode: http.StatusInternalServerError,
+			Active:     true,
+			buildMock: func(repo *utils.WebhookRepositoryMock) {
+				repo.On("CompleteDelivery", mock.Anything, mock.MatchedBy(func(d entities.WebhookDelivery) bool {
+					return d.Status == webhook.StatusFailed && d.ResponseCode == http.StatusInternalServerError
+				})).Return(nil)
+				repo.On("RecordResult", mock.Anything, "webhook-1", false, uint32(20)).Return(nil)
+				repo.On("CreateDelivery", mock.Anything, mock.MatchedBy(func(d entities.WebhookDelivery) bool {
+					return d.Attempt == 2 && d.Status == webhook.StatusPending && d.Id != delivery.Id
+				}), mock.MatchedBy(func(next time.Time) bool {
+					return next.After(time.Now())
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, repo *utils.WebhookRepositoryMock, requests []*http.Request) {
+				assert.Len(t, requests, 1)
+			},
+		},
+		{
+			Name:   "Disabled Webhook Is Skipped",
+			Active: false,
+			buildMock: func(repo *utils.WebhookRepositoryMock) {
+				repo.On("CompleteDelivery", mock.Anything, mock.MatchedBy(func(d entities.WebhookDelivery) bool {
+					return d.Status == webhook.StatusFailed && d.Error == "webhook disabled"
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, repo *utils.WebhookRepositoryMock, requests []*http.Request) {
+				assert.Empty(t, requests)
+				repo.AssertNotCalled(t, "RecordResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var requests []*http.Request
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.NoError(t, webhook.Verify("secret", r.Header.Get(webhook.TimestampHeader), r.Header.Get(webhook.SignatureHeader), body, time.Minute, time.Now()))
+				requests = append(requests, r)
+				w.WriteHeader(tc.StatusCode)
+			}))
+			defer server.Close()
+
+			repo := &utils.WebhookRepositoryMock{}
+			repo.On("ClaimDeliveries", mock.Anything, mock.Anything, time.Minute, 50).Return([]entities.WebhookDelivery{delivery}, nil)
+			repo.On("GetWebhook", mock.MatchedBy(func(ctx context.Context) bool {
+				return tenant.FromContext(ctx) == "acme"
+			}), "webhook-1").Return(entities.Webhook{
+				Id: "webhook-1", Url: server.URL, Secret: "secret", Active: tc.Active,
+			}, nil)
+			tc.buildMock(repo)
+
+			dispatcher := webhook.NewDispatcher(repo, server.Client(), logger)
+			n, err := dispatcher.DispatchDue(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+
+			tc.assertResponse(t, repo, requests)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := webhook.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	for attempt, max := range map[uint32]time.Duration{1: time.Second, 3: 4 * time.Second, 10: 10 * time.Second, 64: 10 * time.Second} {
+		delay := policy.Backoff(attempt)
+		assert.GreaterOrEqual(t, delay, max/2)
+		assert.LessOrEqual(t, delay, max)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"id":"1"}`)
+	signature := webhook.Sign("secret", now.Unix(), body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	assert.NoError(t, webhook.Verify("secret", timestamp, signature, body, time.Minute, now))
+	assert.ErrorIs(t, webhook.Verify("other", timestamp, signature, body, time.Minute, now), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify("secret", timestamp, signature, []byte(`{"id":"2"}`), time.Minute, now), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify("secret", timestamp, signature, body, time.Minute, now.Add(time.Hour)), webhook.ErrStaleTimestamp)
+}
+
+func TestPublic(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"93.184.216.34":      true,
+		"2606:2800:220:1::1": true,
+		"127.0.0.1":          false,
+		"10.1.2.3":           false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"169.254.169.254":    false,
+		"100.64.0.1":         false,
+		"0.0.0.0":            false,
+		"::1":                false,
+		"fd00::1":            false,
+		"fe80::1":            false,
+		"::ffff:127.0.0.1":   false,
+		"64:ff9b::a9fe:a9fe": false,
+		"2002:a9fe:a9fe::1":  false,
+		"ff02::1":            false,
+		"255.255.255.255":    false,
+	} {
+		assert.Equal(t, public, webhook.Public(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The server listens on loopback, a host resolving to it later on is
+	// refused when dialing.
+	_, err := webhook.NewClient(time.Second).Get(server.URL)
+	assert.ErrorIs(t, err, webhook.ErrPrivateAddress)
+}
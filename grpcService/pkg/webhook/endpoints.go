@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+type Service interface {
+	CreateWebhook(ctx context.Context, rq entities.CreateWebhookRequest) (entities.CreateWebhookResponse, error)
+	ListWebhooks(ctx context.Context, rq entities.ListWebhooksRequest) (entities.ListWebhooksResponse, error)
+	DeleteWebhook(ctx context.Context, rq entities.DeleteWebhookRequest) (entities.DeleteWebhookResponse, error)
+	ListWebhookDeliveries(ctx context.Context, rq entities.ListWebhookDeliveriesRequest) (entities.ListWebhookDeliveriesResponse, error)
+	RedeliverWebhook(ctx context.Context, rq entities.RedeliverWebhookRequest) (entities.RedeliverWebhookResponse, error)
+}
+
+type Endpoints struct {
+	CreateWebhook         endpoint.Endpoint
+	ListWebhooks          endpoint.Endpoint
+	DeleteWebhook         endpoint.Endpoint
+	ListWebhookDeliveries endpoint.Endpoint
+	RedeliverWebhook      endpoint.Endpoint
+}
+
+func MakeEndpoint(s Service) Endpoints {
+	return Endpoints{
+		CreateWebhook:         MakeCreateWebhookEndpoint(s),
+		ListWebhooks:          MakeListWebhooksEndpoint(s),
+		DeleteWebhook:         MakeDeleteWebhookEndpoint(s),
+		ListWebhookDeliveries: MakeListWebhookDeliveriesEndpoint(s),
+		RedeliverWebhook:      MakeRedeliverWebhookEndpoint(s),
+	}
+}
+
+// Wrap applies mw to every endpoint.
+func (e Endpoints) Wrap(mw endpoint.Middleware) Endpoints {
+	return Endpoints{
+		CreateWebhook:         mw(e.CreateWebhook),
+		ListWebhooks:          mw(e.ListWebhooks),
+		DeleteWebhook:         mw(e.DeleteWebhook),
+		ListWebhookDeliveries: mw(e.ListWebhookDeliveries),
+		RedeliverWebhook:      mw(e.RedeliverWebhook),
+	}
+}
+
+func MakeCreateWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.CreateWebhookRequest)
+		c, err := s.CreateWebhook(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListWebhooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListWebhooksRequest)
+		c, err := s.ListWebhooks(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeDeleteWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.DeleteWebhookRequest)
+		c, err := s.DeleteWebhook(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeListWebhookDeliveriesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.ListWebhookDeliveriesRequest)
+		c, err := s.ListWebhookDeliveries(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+func MakeRedeliverWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(entities.RedeliverWebhookRequest)
+		c, err := s.RedeliverWebhook(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
@@ -0,0 +1,305 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/encryption"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusSending   = "sending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Repository keeps the webhooks of the tenant carried by the context of
+// each call. Only ClaimDeliveries reaches every tenant, for the dispatcher.
+type Repository interface {
+	CreateWebhook(ctx context.Context, webhook entities.Webhook) error
+	ListWebhooks(ctx context.Context, activeOnly bool) ([]entities.Webhook, error)
+	GetWebhook(ctx context.Context, webhookId string) (entities.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookId string) error
+	RecordResult(ctx context.Context, webhookId string, success bool, disableAfter uint32) error
+	CreateDelivery(ctx context.Context, delivery entities.WebhookDelivery, nextAttemptAt time.Time) error
+	NextAttempt(ctx context.Context, webhookId string, eventId string) (uint32, error)
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entities.WebhookDelivery, error)
+	CompleteDelivery(ctx context.Context, delivery entities.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookId string, limit uint32) ([]entities.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, deliveryId string) (entities.WebhookDelivery, error)
+}
+
+type sqlRepo struct {
+	DB       *sql.DB
+	TxConfig database.TxConfig
+	Keys     *encryption.Keyring
+	Logger   log.Logger
+}
+
+// NewSQL builds a webhook repository. Webhook secrets are encrypted with
+// keys before they are stored, like user PII.
+func NewSQL(db *sql.DB, keys *encryption.Keyring, log log.Logger) *sqlRepo {
+	return &sqlRepo{db, database.DefaultTxConfig(), keys, log}
+}
+
+func (repo *sqlRepo) CreateWebhook(ctx context.Context, webhook entities.Webhook) error {
+	secret, err := repo.Keys.Encrypt(webhook.Secret)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	_, err = repo.DB.ExecContext(ctx, utils.CreateWebhookQuery,
+		webhook.Id, tenant.FromContext(ctx), webhook.Url, secret, strings.Join(webhook.EventTypes, ","), webhook.Active, webhook.CreatedAt)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *sqlRepo) ListWebhooks(ctx context.Context, activeOnly bool) ([]entities.Webhook, error) {
+	query := utils.ListWebhooksQuery
+	if activeOnly {
+		query = utils.ListActiveWebhooksQuery
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, query, tenant.FromContext(ctx))
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []entities.Webhook
+	for rows.Next() {
+		webhook, err := repo.scanWebhook(rows)
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (repo *sqlRepo) GetWebhook(ctx context.Context, webhookId string) (entities.Webhook, error) {
+	webhook, err := repo.scanWebhook(repo.DB.QueryRowContext(ctx, utils.GetWebhookQuery, tenant.FromContext(ctx), webhookId))
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook removes the webhook and returns sql.ErrNoRows when there
+// was none. Its delivery log goes with it through the foreign key.
+func (repo *sqlRepo) DeleteWebhook(ctx context.Context, webhookId string) error {
+	res, err := repo.DB.ExecContext(ctx, utils.DeleteWebhookQuery, tenant.FromContext(ctx), webhookId)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RecordResult keeps count of the consecutive failed attempts of a webhook
+// and disables it once disableAfter of them fail in a row. A success resets
+// the count.
+func (repo *sqlRepo) RecordResult(ctx context.Context, webhookId string, success bool, disableAfter uint32) error {
+	var err error
+	if success {
+		_, err = repo.DB.ExecContext(ctx, utils.RecordWebhookSuccessQuery, tenant.FromContext(ctx), webhookId)
+	} else {
+		_, err = repo.DB.ExecContext(ctx, utils.RecordWebhookFailureQuery, disableAfter, tenant.FromContext(ctx), webhookId)
+	}
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+// CreateDelivery queues a delivery attempt. Queueing the same attempt of an
+// event twice, as happens when the outbox relays it again, is a no-op.
+func (repo *sqlRepo) CreateDelivery(ctx context.Context, delivery entities.WebhookDelivery, nextAttemptAt time.Time) error {
+	_, err := repo.DB.ExecContext(ctx, utils.CreateWebhookDeliveryQuery,
+		delivery.Id, tenant.FromContext(ctx), delivery.WebhookId, delivery.EventId, delivery.EventType, delivery.Payload,
+		delivery.Attempt, delivery.Status, nextAttemptAt, delivery.CreatedAt)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *sqlRepo) NextAttempt(ctx context.Context, webhookId string, eventId string) (uint32, error) {
+	var attempt uint32
+	err := repo.DB.QueryRowContext(ctx, utils.NextWebhookAttemptQuery, tenant.FromContext(ctx), webhookId, eventId).Scan(&attempt)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return 0, err
+	}
+
+	return attempt, nil
+}
+
+// ClaimDeliveries leases up to limit deliveries that are due at now, of
+// every tenant. A claimed delivery is not due again until the lease runs
+// out, so a dispatcher that dies mid-send has its deliveries picked up by
+// another.
+func (repo *sqlRepo) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entities.WebhookDelivery, error) {
+	var deliveries []entities.WebhookDelivery
+
+	err := database.RunInTx(ctx, repo.DB, repo.TxConfig, func(ctx context.Context) error {
+		tx := database.Conn(ctx, repo.DB)
+		deliveries = nil
+
+		rows, err := tx.QueryContext(ctx, utils.ListDueWebhookDeliveriesQuery, now, limit)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			delivery, err := scanDelivery(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i := range deliveries {
+			if _, err := tx.ExecContext(ctx, utils.LeaseWebhookDeliveryQuery, now.Add(lease), deliveries[i].Id); err != nil {
+				return err
+			}
+			deliveries[i].Status = StatusSending
+		}
+
+		return nil
+	})
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (repo *sqlRepo) CompleteDelivery(ctx context.Context, delivery entities.WebhookDelivery) error {
+	_, err := repo.DB.ExecContext(ctx, utils.CompleteWebhookDeliveryQuery,
+		delivery.Status, delivery.ResponseCode, delivery.Error, delivery.CompletedAt, tenant.FromContext(ctx), delivery.Id)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *sqlRepo) ListDeliveries(ctx context.Context, webhookId string, limit uint32) ([]entities.WebhookDelivery, error) {
+	rows, err := repo.DB.QueryContext(ctx, utils.ListWebhookDeliveriesQuery, tenant.FromContext(ctx), webhookId, limit)
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []entities.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			level.Error(repo.Logger).Log(err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (repo *sqlRepo) GetDelivery(ctx context.Context, deliveryId string) (entities.WebhookDelivery, error) {
+	delivery, err := scanDelivery(repo.DB.QueryRowContext(ctx, utils.GetWebhookDeliveryQuery, tenant.FromContext(ctx), deliveryId))
+	if err != nil {
+		level.Error(repo.Logger).Log(err)
+		return entities.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (repo *sqlRepo) scanWebhook(row scanner) (entities.Webhook, error) {
+	var (
+		webhook    entities.Webhook
+		eventTypes string
+	)
+
+	err := row.Scan(&webhook.Id, &webhook.Url, &webhook.Secret, &eventTypes,
+		&webhook.Active, &webhook.ConsecutiveFailures, &webhook.CreatedAt)
+	if err != nil {
+		return entities.Webhook{}, err
+	}
+
+	if eventTypes != "" {
+		webhook.EventTypes = strings.Split(eventTypes, ",")
+	}
+
+	webhook.Secret, err = repo.Keys.Decrypt(webhook.Secret)
+	if err != nil {
+		return entities.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func scanDelivery(row scanner) (entities.WebhookDelivery, error) {
+	var (
+		delivery     entities.WebhookDelivery
+		responseCode sql.NullInt32
+		deliveryErr  sql.NullString
+		completedAt  sql.NullTime
+	)
+
+	err := row.Scan(&delivery.Id, &delivery.TenantId, &delivery.WebhookId, &delivery.EventId, &delivery.EventType,
+		&delivery.Payload, &delivery.Attempt, &delivery.Status, &responseCode, &deliveryErr,
+		&delivery.CreatedAt, &completedAt)
+	if err != nil {
+		return entities.WebhookDelivery{}, err
+	}
+
+	delivery.ResponseCode = responseCode.Int32
+	delivery.Error = deliveryErr.String
+	if completedAt.Valid {
+		delivery.CompletedAt = &completedAt.Time
+	}
+
+	return delivery, nil
+}
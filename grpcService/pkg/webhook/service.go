@@ -0,0 +1,221 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/uuid"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/database"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+var eventTypes = map[string]bool{
+	events.UserCreatedType: true,
+	events.UserUpdatedType: true,
+	events.UserDeletedType: true,
+}
+
+type service struct {
+	Repo   Repository
+	Logger log.Logger
+	// Resolver finds the addresses a webhook url points at, only urls
+	// pointing at public addresses are accepted.
+	Resolver Resolver
+}
+
+func NewService(l log.Logger, r Repository) *service {
+	return &service{r, l, net.DefaultResolver}
+}
+
+func (s *service) CreateWebhook(ctx context.Context, rq entities.CreateWebhookRequest) (entities.CreateWebhookResponse, error) {
+	s.Logger.Log("request", "create webhook", "received")
+
+	endpoint, err := url.Parse(rq.Url)
+	if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		return entities.CreateWebhookResponse{}, errors.NewInvalidField("url", "must be an absolute http or https url")
+	}
+	if err := CheckHost(ctx, s.Resolver, endpoint.Hostname()); err != nil {
+		level.Warn(s.Logger).Log("url", rq.Url, "error", err)
+		return entities.CreateWebhookResponse{}, errors.NewInvalidField("url", "must resolve to public addresses only")
+	}
+
+	for _, t := range rq.EventTypes {
+		if !eventTypes[t] {
+			return entities.CreateWebhookResponse{}, errors.NewInvalidField("event_types", "unknown event type "+t)
+		}
+	}
+
+	secret := rq.Secret
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			level.Error(s.Logger).Log("error", err)
+			return entities.CreateWebhookResponse{}, errors.NewGrpcError()
+		}
+	}
+
+	webhook := entities.Webhook{
+		Id:         uuid.NewString(),
+		Url:        rq.Url,
+		Secret:     secret,
+		EventTypes: rq.EventTypes,
+		Active:     true,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := s.Repo.CreateWebhook(ctx, webhook); err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.CreateWebhookResponse{}, dataBaseError(err)
+	}
+
+	webhook.Secret = ""
+	return entities.CreateWebhookResponse{
+		Webhook: webhook,
+		Secret:  secret,
+		Status:  entities.Status{Message: "created successfully"},
+	}, nil
+}
+
+func (s *service) ListWebhooks(ctx context.Context, rq entities.ListWebhooksRequest) (entities.ListWebhooksResponse, error) {
+	s.Logger.Log("request", "list webhooks", "received")
+
+	webhooks, err := s.Repo.ListWebhooks(ctx, false)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListWebhooksResponse{}, dataBaseError(err)
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return entities.ListWebhooksResponse{Webhooks: webhooks}, nil
+}
+
+func (s *service) DeleteWebhook(ctx context.Context, rq entities.DeleteWebhookRequest) (entities.DeleteWebhookResponse, error) {
+	s.Logger.Log("request", "delete webhook", "received")
+
+	if err := s.Repo.DeleteWebhook(ctx, rq.WebhookId); err != nil {
+		if err == sql.ErrNoRows {
+			return entities.DeleteWebhookResponse{}, errors.NewResourceNotFound("webhook")
+		}
+		level.Error(s.Logger).Log("error", err)
+		return entities.DeleteWebhookResponse{}, dataBaseError(err)
+	}
+
+	return entities.DeleteWebhookResponse{
+		Status: entities.Status{Message: "webhook deleted successfully"},
+	}, nil
+}
+
+func (s *service) ListWebhookDeliveries(ctx context.Context, rq entities.ListWebhookDeliveriesRequest) (entities.ListWebhookDeliveriesResponse, error) {
+	s.Logger.Log("request", "list webhook deliveries", "received")
+
+	limit := rq.Limit
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+	if limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+
+	if _, err := s.Repo.GetWebhook(ctx, rq.WebhookId); err != nil {
+		if err == sql.ErrNoRows {
+			return entities.ListWebhookDeliveriesResponse{}, errors.NewResourceNotFound("webhook")
+		}
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListWebhookDeliveriesResponse{}, dataBaseError(err)
+	}
+
+	deliveries, err := s.Repo.ListDeliveries(ctx, rq.WebhookId, limit)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.ListWebhookDeliveriesResponse{}, dataBaseError(err)
+	}
+
+	return entities.ListWebhookDeliveriesResponse{Deliveries: deliveries}, nil
+}
+
+// RedeliverWebhook queues the event of a past delivery again as a new
+// attempt. Disabled webhooks have to be recreated before anything is sent
+// to them again.
+func (s *service) RedeliverWebhook(ctx context.Context, rq entities.RedeliverWebhookRequest) (entities.RedeliverWebhookResponse, error) {
+	s.Logger.Log("request", "redeliver webhook", "received")
+
+	delivery, err := s.Repo.GetDelivery(ctx, rq.DeliveryId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.RedeliverWebhookResponse{}, errors.NewResourceNotFound("webhook delivery")
+		}
+		level.Error(s.Logger).Log("error", err)
+		return entities.RedeliverWebhookResponse{}, dataBaseError(err)
+	}
+
+	webhook, err := s.Repo.GetWebhook(ctx, delivery.WebhookId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.RedeliverWebhookResponse{}, errors.NewResourceNotFound("webhook")
+		}
+		level.Error(s.Logger).Log("error", err)
+		return entities.RedeliverWebhookResponse{}, dataBaseError(err)
+	}
+
+	if !webhook.Active {
+		return entities.RedeliverWebhookResponse{}, errors.NewPreconditionFailed("webhook is disabled")
+	}
+
+	attempt, err := s.Repo.NextAttempt(ctx, delivery.WebhookId, delivery.EventId)
+	if err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.RedeliverWebhookResponse{}, dataBaseError(err)
+	}
+
+	now := time.Now().UTC()
+	redelivery := entities.WebhookDelivery{
+		Id:        uuid.NewString(),
+		TenantId:  delivery.TenantId,
+		WebhookId: delivery.WebhookId,
+		EventId:   delivery.EventId,
+		EventType: delivery.EventType,
+		Payload:   delivery.Payload,
+		Attempt:   attempt,
+		Status:    StatusPending,
+		CreatedAt: now,
+	}
+
+	if err := s.Repo.CreateDelivery(ctx, redelivery, now); err != nil {
+		level.Error(s.Logger).Log("error", err)
+		return entities.RedeliverWebhookResponse{}, dataBaseError(err)
+	}
+
+	return entities.RedeliverWebhookResponse{Delivery: redelivery}, nil
+}
+
+func dataBaseError(err error) error {
+	if database.IsUnavailable(err) {
+		return errors.NewDataBaseUnavailable()
+	}
+	return errors.NewDataBaseError()
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
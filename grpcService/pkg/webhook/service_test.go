@@ -0,0 +1,201 @@
+package webhook_test
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/utils"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/webhook"
+)
+
+// hosts resolves the names it holds, and no other.
+type hosts map[string][]string
+
+func (h hosts) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if _, ok := h[host]; !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	var addrs []net.IPAddr
+	for _, ip := range h[host] {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestServiceCreateWebhook(t *testing.T) {
+	var logger log.Logger
+	{
+		logger = log.NewLogfmtLogger(os.Stderr)
+		logger = log.NewSyncLogger(logger)
+		logger = log.With(logger,
+			"service", "grpcUserService",
+			"time:", log.DefaultTimestampUTC,
+			"caller", log.DefaultCaller,
+		)
+	}
+
+	testCases := []struct {
+		Name           string
+		Request        entities.CreateWebhookRequest
+		buildMock      func(repo *utils.WebhookRepositoryMock)
+		assertResponse func(t *testing.T, res entities.CreateWebhookResponse, err error)
+	}{
+		{
+			Name:    "Create Webhook Generates Secret",
+			Request: entities.CreateWebhookRequest{Url: "https://example.com/hooks", EventTypes: []string{events.UserCreatedType}},
+			buildMock: func(repo *utils.WebhookRepositoryMock) {
+				repo.On("CreateWebhook", mock.Anything, mock.MatchedBy(func(w entities.Webhook) bool {
+					return w.Active && w.Secret != "" && w.Url == "https://example.com/hooks"
+				})).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.CreateWebhookResponse, err error) {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, res.Secret)
+				assert.Empty(t, res.Webhook.Secret)
+				assert.NotEmpty(t, res.Webhook.Id)
+			},
+		},
+		{
+			Name:      "Invalid Url",
+			Request:   entities.CreateWebhookRequest{Url: "example.com/hooks"},
+			buildMock: func(repo *utils.WebhookRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.CreateWebhookResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:      "Loopback Address",
+			Request:   entities.CreateWebhookRequest{Url: "http://127.0.0.1:8080/hooks"},
+			buildMock: func(repo *utils.WebhookRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.CreateWebhookResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:      "Metadata Endpoint",
+			Request:   entities.CreateWebhookRequest{Url: "http://169.254.169.254/latest/meta-data"},
+			buildMock: func(repo *utils.WebhookRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.CreateWebhookResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:      "Host Resolving To A Private Address",
+			Request:   entities.CreateWebhookRequest{Url: "https://internal.example.com/hooks"},
+			buildMock: func(repo *utils.WebhookRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.CreateWebhookResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:      "Unknown Host",
+			Request:   entities.CreateWebhookRequest{Url: "https://nowhere.example.com/hooks"},
+			buildMock: func(repo *utils.WebhookRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.CreateWebhookResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+		{
+			Name:      "Unknown Event Type",
+			Request:   entities.CreateWebhookRequest{Url: "https://example.com/hooks", EventTypes: []string{"user.renamed"}},
+			buildMock: func(repo *utils.WebhookRepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.CreateWebhookResponse, err error) {
+				assert.IsType(t, myErr.InvalidField{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := &utils.WebhookRepositoryMock{}
+			tc.buildMock(repo)
+
+			srvc := webhook.NewService(logger, repo)
+			srvc.Resolver = hosts{
+				"example.com":          {"93.184.216.34"},
+				"internal.example.com": {"93.184.216.34", "10.0.0.7"},
+			}
+			res, err := srvc.CreateWebhook(context.Background(), tc.Request)
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceRedeliverWebhook(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	delivery := entities.WebhookDelivery{
+		Id:        "delivery-1",
+		WebhookId: "webhook-1",
+		EventId:   "event-1",
+		EventType: events.UserCreatedType,
+		Payload:   []byte(`{}`),
+		Attempt:   3,
+		Status:    webhook.StatusFailed,
+	}
+
+	testCases := []struct {
+		Name           string
+		buildMock      func(repo *utils.WebhookRepositoryMock)
+		assertResponse func(t *testing.T, res entities.RedeliverWebhookResponse, err error)
+	}{
+		{
+			Name: "Redeliver Queues Next Attempt",
+			buildMock: func(repo *utils.WebhookRepositoryMock) {
+				repo.On("GetDelivery", mock.Anything, "delivery-1").Return(delivery, nil)
+				repo.On("GetWebhook", mock.Anything, "webhook-1").Return(entities.Webhook{Id: "webhook-1", Active: true}, nil)
+				repo.On("NextAttempt", mock.Anything, "webhook-1", "event-1").Return(uint32(4), nil)
+				repo.On("CreateDelivery", mock.Anything, mock.MatchedBy(func(d entities.WebhookDelivery) bool {
+					return d.Attempt == 4 && d.Status == webhook.StatusPending && d.EventId == "event-1"
+				}), mock.Anything).Return(nil)
+			},
+			assertResponse: func(t *testing.T, res entities.RedeliverWebhookResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint32(4), res.Delivery.Attempt)
+				assert.NotEqual(t, "delivery-1", res.Delivery.Id)
+			},
+		},
+		{
+			Name: "Disabled Webhook",
+			buildMock: func(repo *utils.WebhookRepositoryMock) {
+				repo.On("GetDelivery", mock.Anything, "delivery-1").Return(delivery, nil)
+				repo.On("GetWebhook", mock.Anything, "webhook-1").Return(entities.Webhook{Id: "webhook-1"}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.RedeliverWebhookResponse, err error) {
+				assert.IsType(t, myErr.PreconditionFailed{}, err)
+			},
+		},
+		{
+			Name: "Delivery Not Found",
+			buildMock: func(repo *utils.WebhookRepositoryMock) {
+				repo.On("GetDelivery", mock.Anything, "delivery-1").Return(entities.WebhookDelivery{}, sql.ErrNoRows)
+			},
+			assertResponse: func(t *testing.T, res entities.RedeliverWebhookResponse, err error) {
+				assert.IsType(t, myErr.ResourceNotFound{}, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := &utils.WebhookRepositoryMock{}
+			tc.buildMock(repo)
+
+			srvc := webhook.NewService(logger, repo)
+			res, err := srvc.RedeliverWebhook(context.Background(), entities.RedeliverWebhookRequest{DeliveryId: "delivery-1"})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"context"
+
+	gr "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	customErr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
+)
+
+type gRPCSv struct {
+	createWh    gr.Handler
+	listWh      gr.Handler
+	deleteWh    gr.Handler
+	listDeliv   gr.Handler
+	redeliverWh gr.Handler
+	proto.UnimplementedWebhookServiceServer
+}
+
+func NewGrpcServer(end Endpoints) proto.WebhookServiceServer {
+	return &gRPCSv{
+		createWh: gr.NewServer(
+			end.CreateWebhook,
+			decodeCreateWebhookRequest,
+			encodeCreateWebhookResponse,
+		),
+
+		listWh: gr.NewServer(
+			end.ListWebhooks,
+			decodeListWebhooksRequest,
+			encodeListWebhooksResponse,
+		),
+
+		deleteWh: gr.NewServer(
+			end.DeleteWebhook,
+			decodeDeleteWebhookRequest,
+			encodeDeleteWebhookResponse,
+		),
+
+		listDeliv: gr.NewServer(
+			end.ListWebhookDeliveries,
+			decodeListWebhookDeliveriesRequest,
+			encodeListWebhookDeliveriesResponse,
+		),
+
+		redeliverWh: gr.NewServer(
+			end.RedeliverWebhook,
+			decodeRedeliverWebhookRequest,
+			encodeRedeliverWebhookResponse,
+		),
+	}
+}
+
+func (g *gRPCSv) CreateWebhook(ctx context.Context, rq *proto.CreateWebhookRequest) (*proto.CreateWebhookResponse, error) {
+	_, resp, err := g.createWh.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.CreateWebhookResponse), nil
+}
+
+func (g *gRPCSv) ListWebhooks(ctx context.Context, rq *proto.ListWebhooksRequest) (*proto.ListWebhooksResponse, error) {
+	_, resp, err := g.listWh.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListWebhooksResponse), nil
+}
+
+func (g *gRPCSv) DeleteWebhook(ctx context.Context, rq *proto.DeleteWebhookRequest) (*proto.DeleteWebhookResponse, error) {
+	_, resp, err := g.deleteWh.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.DeleteWebhookResponse), nil
+}
+
+func (g *gRPCSv) ListWebhookDeliveries(ctx context.Context, rq *proto.ListWebhookDeliveriesRequest) (*proto.ListWebhookDeliveriesResponse, error) {
+	_, resp, err := g.listDeliv.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.ListWebhookDeliveriesResponse), nil
+}
+
+func (g *gRPCSv) RedeliverWebhook(ctx context.Context, rq *proto.RedeliverWebhookRequest) (*proto.RedeliverWebhookResponse, error) {
+	_, resp, err := g.redeliverWh.ServeGRPC(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*proto.RedeliverWebhookResponse), nil
+}
+
+func decodeCreateWebhookRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.CreateWebhookRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.CreateWebhookRequest{
+		Url:        res.Url,
+		EventTypes: res.Event_Types,
+		Secret:     res.Secret,
+	}, nil
+}
+
+func encodeCreateWebhookResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.CreateWebhookResponse)
+	return &proto.CreateWebhookResponse{
+		Webhook: webhookToProto(res.Webhook),
+		Secret:  res.Secret,
+		Status:  &proto.Status{Message: res.Status.Message, Code: res.Status.Code},
+	}, nil
+}
+
+func decodeListWebhooksRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	if _, valid := request.(*proto.ListWebhooksRequest); !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListWebhooksRequest{}, nil
+}
+
+func encodeListWebhooksResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListWebhooksResponse)
+	protoResp := &proto.ListWebhooksResponse{}
+	for _, webhook := range res.Webhooks {
+		protoResp.Webhooks = append(protoResp.Webhooks, webhookToProto(webhook))
+	}
+	return protoResp, nil
+}
+
+func decodeDeleteWebhookRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.DeleteWebhookRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.DeleteWebhookRequest{WebhookId: res.Webhook_Id}, nil
+}
+
+func encodeDeleteWebhookResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.DeleteWebhookResponse)
+	return &proto.DeleteWebhookResponse{Status: &proto.Status{Message: res.Status.Message, Code: res.Status.Code}}, nil
+}
+
+func decodeListWebhookDeliveriesRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.ListWebhookDeliveriesRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.ListWebhookDeliveriesRequest{WebhookId: res.Webhook_Id, Limit: res.Limit}, nil
+}
+
+func encodeListWebhookDeliveriesResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.ListWebhookDeliveriesResponse)
+	protoResp := &proto.ListWebhookDeliveriesResponse{}
+	for _, delivery := range res.Deliveries {
+		protoResp.Deliveries = append(protoResp.Deliveries, deliveryToProto(delivery))
+	}
+	return protoResp, nil
+}
+
+func decodeRedeliverWebhookRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	res, valid := request.(*proto.RedeliverWebhookRequest)
+	if !valid {
+		return nil, customErr.NewGrpcError()
+	}
+
+	return entities.RedeliverWebhookRequest{DeliveryId: res.Delivery_Id}, nil
+}
+
+func encodeRedeliverWebhookResponse(ctx context.Context, response interface{}) (interface{}, error) {
+	res := response.(entities.RedeliverWebhookResponse)
+	return &proto.RedeliverWebhookResponse{Delivery: deliveryToProto(res.Delivery)}, nil
+}
+
+func webhookToProto(webhook entities.Webhook) *proto.Webhook {
+	return &proto.Webhook{
+		Id:          webhook.Id,
+		Url:         webhook.Url,
+		Event_Types: webhook.EventTypes,
+		Active:      webhook.Active,
+		Created_At:  timestamppb.New(webhook.CreatedAt),
+	}
+}
+
+func deliveryToProto(delivery entities.WebhookDelivery) *proto.WebhookDelivery {
+	protoDelivery := &proto.WebhookDelivery{
+		Id:            delivery.Id,
+		Webhook_Id:    delivery.WebhookId,
+		Event_Id:      delivery.EventId,
+		Event_Type:    delivery.EventType,
+		Attempt:       delivery.Attempt,
+		Status:        delivery.Status,
+		Response_Code: delivery.ResponseCode,
+		Error:         delivery.Error,
+		Created_At:    timestamppb.New(delivery.CreatedAt),
+	}
+	if delivery.CompletedAt != nil {
+		protoDelivery.Completed_At = timestamppb.New(*delivery.CompletedAt)
+	}
+	return protoDelivery
+}
@@ -10,6 +10,9 @@ import (
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
 	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
 
 	"google.golang.org/grpc"
@@ -34,6 +37,9 @@ func main() {
 	var (
 		grpcServerAddress = flag.String("addr", "localhost:50000", "grpcSvAddres")
 	)
+	var (
+		tenantBaseDomain = flag.String("tenant.base-domain", "", "domain tenants are subdomains of, acme.<domain> serves the tenant acme, hosts are ignored when empty")
+	)
 
 	flag.Parse()
 
@@ -42,6 +48,10 @@ func main() {
 	{
 		var opts []grpc.DialOption
 		opts = append(opts, grpc.WithInsecure())
+		// Every call carries who made the request, for the audit log, the
+		// tenant it was made for and the API key it was sent with.
+		opts = append(opts, grpc.WithChainUnaryInterceptor(audit.UnaryClientInterceptor, tenant.UnaryClientInterceptor, apikey.UnaryClientInterceptor))
+		opts = append(opts, grpc.WithChainStreamInterceptor(audit.StreamClientInterceptor, tenant.StreamClientInterceptor, apikey.StreamClientInterceptor))
 		grpcServerConnection, err = grpc.Dial(*grpcServerAddress, opts...)
 		if err != nil {
 			level.Error(logger).Log("exit", err)
@@ -68,7 +78,7 @@ func main() {
 	}()
 
 	go func() {
-		httpHandler := user.NewHTTPSrv(*endpoint, logger)
+		httpHandler := user.WithTenant(user.NewHTTPSrv(*endpoint, logger), user.TenantConfig{BaseDomain: *tenantBaseDomain})
 		level.Info(logger).Log("Listening to", httpAddr)
 		errs <- http.ListenAndServe(*httpAddr, httpHandler)
 	}()
@@ -0,0 +1,61 @@
+package user
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+// API keys are managed by admins, or with a key holding the manage scope,
+// which the gRPC service checks.
+
+func (s *service) CreateApiKey(ctx context.Context, rq entities.CreateApiKeyRequest) (entities.CreateApiKeyResponse, error) {
+	logger := log.With(s.Logger, "create api key request", "recevied")
+
+	res, err := s.Repo.CreateApiKey(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.CreateApiKeyResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ListApiKeys(ctx context.Context, rq entities.ListApiKeysRequest) (entities.ListApiKeysResponse, error) {
+	logger := log.With(s.Logger, "list api keys request", "recevied")
+
+	res, err := s.Repo.ListApiKeys(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListApiKeysResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) RevokeApiKey(ctx context.Context, rq entities.RevokeApiKeyRequest) (entities.RevokeApiKeyResponse, error) {
+	logger := log.With(s.Logger, "revoke api key request", "recevied")
+
+	res, err := s.Repo.RevokeApiKey(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RevokeApiKeyResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) RotateApiKey(ctx context.Context, rq entities.RotateApiKeyRequest) (entities.RotateApiKeyResponse, error) {
+	logger := log.With(s.Logger, "rotate api key request", "recevied")
+
+	res, err := s.Repo.RotateApiKey(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RotateApiKeyResponse{}, err
+	}
+
+	return res, nil
+}
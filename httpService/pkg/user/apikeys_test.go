@@ -0,0 +1,173 @@
+package user_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestApiKeyRoutes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	adminId := "0190f3b2-7c1e-7d3a-9b4f-000000000001"
+	keyId := "7d1f2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"
+	userId := "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"
+
+	secret, prefix, hash, _ := apikey.Generate()
+	key := entities.ApiKey{Id: keyId, Name: "nightly export", Prefix: prefix, Hash: hash, Scopes: []string{"users:read"}}
+
+	forwarded := func(ctx context.Context) bool {
+		md, _ := metadata.FromOutgoingContext(apikey.ToOutgoingContext(ctx))
+		values := md.Get(apikey.Header)
+		return len(values) == 1 && values[0] == secret
+	}
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Header         map[string]string
+		Body           string
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Create",
+			Method: http.MethodPost,
+			Target: "/api-keys",
+			Header: map[string]string{user.ActorHeader: adminId, user.RolesHeader: "admin"},
+			Body:   `{"Name":"nightly export","Scopes":["users:read"],"TtlSeconds":3600}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("CreateApiKey", mock.Anything, entities.CreateApiKeyRequest{Name: "nightly export", Scopes: []string{"users:read"}, TtlSeconds: 3600}).
+					Return(entities.CreateApiKeyResponse{Key: key, Secret: secret}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Secret":"`+secret+`"`)
+				assert.NotContains(t, rec.Body.String(), hash)
+			},
+		},
+		{
+			Name:   "List",
+			Method: http.MethodGet,
+			Target: "/api-keys",
+			Header: map[string]string{user.ActorHeader: adminId, user.RolesHeader: "admin"},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListApiKeys", mock.Anything, entities.ListApiKeysRequest{}).
+					Return(entities.ListApiKeysResponse{Keys: []entities.ApiKey{key}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Prefix":"`+prefix+`"`)
+				assert.NotContains(t, rec.Body.String(), hash)
+			},
+		},
+		{
+			Name:   "Revoke",
+			Method: http.MethodPost,
+			Target: "/api-keys/" + keyId + ":revoke",
+			Header: map[string]string{user.ActorHeader: adminId, user.RolesHeader: "admin"},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("RevokeApiKey", mock.Anything, entities.RevokeApiKeyRequest{KeyId: keyId}).
+					Return(entities.RevokeApiKeyResponse{Key: key}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Rotate With Overlap",
+			Method: http.MethodPost,
+			Target: "/api-keys/" + keyId + ":rotate",
+			Header: map[string]string{user.ActorHeader: adminId, user.RolesHeader: "admin"},
+			Body:   `{"OverlapSeconds":600}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("RotateApiKey", mock.Anything, entities.RotateApiKeyRequest{KeyId: keyId, OverlapSeconds: 600}).
+					Return(entities.RotateApiKeyResponse{Key: key, Secret: secret}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Rotate Without Body",
+			Method: http.MethodPost,
+			Target: "/api-keys/" + keyId + ":rotate",
+			Header: map[string]string{user.ActorHeader: adminId, user.RolesHeader: "admin"},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("RotateApiKey", mock.Anything, entities.RotateApiKeyRequest{KeyId: keyId}).
+					Return(entities.RotateApiKeyResponse{Key: key, Secret: secret}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Key Forwarded",
+			Method: http.MethodGet,
+			Target: "/user/" + userId,
+			Header: map[string]string{user.ApiKeyHeader: secret},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetUser", mock.MatchedBy(forwarded), mock.Anything).
+					Return(entities.GetUserResponse{}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Key Lacking Scope",
+			Method: http.MethodDelete,
+			Target: "/user/" + userId,
+			Header: map[string]string{user.ApiKeyHeader: secret},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("DeleteUser", mock.MatchedBy(forwarded), mock.Anything).
+					Return(entities.DeleteUserResponse{}, myerr.NewForbidden("api key lacks the users:write scope").GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			Name:      "Malformed Key",
+			Method:    http.MethodGet,
+			Target:    "/user/" + userId,
+			Header:    map[string]string{user.ApiKeyHeader: "not-a-key"},
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, tc.Target, strings.NewReader(tc.Body))
+			for key, value := range tc.Header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package user
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errs "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+// Attributes are checked against the schema of the tenant by the gRPC
+// service, the gateway only relays them.
+
+func (s *service) UpdateUserAttributes(ctx context.Context, rq entities.UpdateUserAttributesRequest) (entities.UpdateUserAttributesResponse, error) {
+	logger := log.With(s.Logger, "update user attributes request", "recevied")
+
+	if rq.UserId == "" || rq.Attributes == nil {
+		return entities.UpdateUserAttributesResponse{}, errs.NewFieldsMissing()
+	}
+
+	res, err := s.Repo.UpdateUserAttributes(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.UpdateUserAttributesResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ListUsers(ctx context.Context, rq entities.ListUsersRequest) (entities.ListUsersResponse, error) {
+	logger := log.With(s.Logger, "list users request", "recevied")
+
+	res, err := s.Repo.ListUsers(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListUsersResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) GetAttributeSchema(ctx context.Context, rq entities.GetAttributeSchemaRequest) (entities.GetAttributeSchemaResponse, error) {
+	logger := log.With(s.Logger, "get attribute schema request", "recevied")
+
+	res, err := s.Repo.GetAttributeSchema(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.GetAttributeSchemaResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) SetAttributeSchema(ctx context.Context, rq entities.SetAttributeSchemaRequest) (entities.SetAttributeSchemaResponse, error) {
+	logger := log.With(s.Logger, "set attribute schema request", "recevied")
+
+	res, err := s.Repo.SetAttributeSchema(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.SetAttributeSchemaResponse{}, err
+	}
+
+	return res, nil
+}
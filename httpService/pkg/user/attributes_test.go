@@ -0,0 +1,169 @@
+package user_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestAttributeRoutes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	updatedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Header         http.Header
+		Body           string
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Patch Attributes",
+			Method: http.MethodPatch,
+			Target: "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b/attributes",
+			Header: http.Header{"If-Match": {`"2"`}},
+			Body:   `{"team":"core","level":null}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("UpdateUserAttributes", mock.Anything, entities.UpdateUserAttributesRequest{
+					UserId:     "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+					Attributes: map[string]interface{}{"team": "core", "level": nil},
+					IfMatch:    `"2"`,
+				}).Return(entities.UpdateUserAttributesResponse{Attributes: map[string]interface{}{"team": "core"}, Etag: `"3"`}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+				assert.Contains(t, rec.Body.String(), `"Attributes":{"team":"core"}`)
+			},
+		},
+		{
+			Name:   "Patch Own Attributes",
+			Method: http.MethodPatch,
+			Target: "/me/attributes",
+			Header: http.Header{user.UserIdHeader: {"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}},
+			Body:   `{"team":"core"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("UpdateUserAttributes", mock.Anything, mock.MatchedBy(func(rq entities.UpdateUserAttributesRequest) bool {
+					return rq.UserId == "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"
+				})).Return(entities.UpdateUserAttributesResponse{}, status.Error(codes.InvalidArgument, "attributes.team is not in the attribute schema"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:      "Patch With A Body That Is Not An Object",
+			Method:    http.MethodPatch,
+			Target:    "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b/attributes",
+			Body:      `["team"]`,
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:   "List Users By Attribute",
+			Method: http.MethodGet,
+			Target: "/users?attr.team=core&page_size=10&page_token=0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListUsers", mock.Anything, entities.ListUsersRequest{PageSize: 10, PageToken: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b", Filters: map[string]string{"team": "core"}}).
+					Return(entities.ListUsersResponse{Users: []entities.GetUserResponse{{Id: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c", Attributes: map[string]interface{}{"team": "core"}}}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Id":"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c"`)
+			},
+		},
+		{
+			Name:      "List Users With A Repeated Filter",
+			Method:    http.MethodGet,
+			Target:    "/users?attr.team=core&attr.team=growth",
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:   "Get Schema",
+			Method: http.MethodGet,
+			Target: "/attribute-schema",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetAttributeSchema", mock.Anything, entities.GetAttributeSchemaRequest{}).
+					Return(entities.GetAttributeSchemaResponse{Schema: `{"type":"object"}`, UpdatedAt: updatedAt}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.JSONEq(t, `{"Schema":{"type":"object"},"UpdatedAt":"2026-10-01T12:00:00Z"}`, rec.Body.String())
+			},
+		},
+		{
+			Name:   "Get Missing Schema",
+			Method: http.MethodGet,
+			Target: "/attribute-schema",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetAttributeSchema", mock.Anything, mock.Anything).
+					Return(entities.GetAttributeSchemaResponse{}, status.Error(codes.NotFound, "attribute schema not found"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			Name:   "Set Schema",
+			Method: http.MethodPut,
+			Target: "/attribute-schema",
+			Body:   `{"type":"object"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("SetAttributeSchema", mock.Anything, entities.SetAttributeSchemaRequest{Schema: `{"type":"object"}`}).
+					Return(entities.SetAttributeSchemaResponse{Schema: `{"type":"object"}`, UpdatedAt: updatedAt}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.JSONEq(t, `{"Schema":{"type":"object"},"UpdatedAt":"2026-10-01T12:00:00Z","Reindexed":0}`, rec.Body.String())
+			},
+		},
+		{
+			Name:      "Set Schema That Is Not JSON",
+			Method:    http.MethodPut,
+			Target:    "/attribute-schema",
+			Body:      `type: object`,
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, tc.Target, strings.NewReader(tc.Body))
+			for key, values := range tc.Header {
+				req.Header[key] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
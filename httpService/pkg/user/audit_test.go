@@ -0,0 +1,139 @@
+package user_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestListAuditEvents(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name           string
+		Target         string
+		Header         http.Header
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Filters Are Read From The Query",
+			Target: "/audit?start=2022-01-01T00:00:00Z&actor=admin&target_user_id=user-1&page_size=10&page_token=7",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListAuditEvents", mock.Anything, entities.ListAuditEventsRequest{
+					AuditFilter: entities.AuditFilter{Start: start, Actor: "admin", TargetUserId: "user-1"},
+					PageSize:    10,
+					PageToken:   "7",
+				}).Return(entities.ListAuditEventsResponse{
+					Events:        []entities.AuditEntry{{Seq: 6, Operation: "DeleteUser", TargetUserId: "user-1"}},
+					NextPageToken: "6",
+				}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"NextPageToken":"6"`)
+				assert.Contains(t, rec.Body.String(), `"Operation":"DeleteUser"`)
+			},
+		},
+		{
+			Name:   "Caller Is Forwarded",
+			Target: "/audit",
+			Header: http.Header{user.ActorHeader: {"admin@globant.com"}, user.RequestIdHeader: {"request-1"}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListAuditEvents", mock.MatchedBy(func(ctx context.Context) bool {
+					caller := audit.CallerFromContext(ctx)
+					return caller == audit.Caller{Actor: "admin@globant.com", SourceIp: "192.0.2.1", RequestId: "request-1"}
+				}), entities.ListAuditEventsRequest{}).Return(entities.ListAuditEventsResponse{}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "request-1", rec.Header().Get(user.RequestIdHeader))
+			},
+		},
+		{
+			Name:   "Request Id Is Generated",
+			Target: "/audit?end=2022-01-02T00:00:00Z",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListAuditEvents", mock.Anything, mock.Anything).Return(entities.ListAuditEventsResponse{}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.NotEmpty(t, rec.Header().Get(user.RequestIdHeader))
+			},
+		},
+		{
+			Name:      "Malformed Start",
+			Target:    "/audit?start=yesterday",
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.Contains(t, rec.Body.String(), "start")
+			},
+		},
+		{
+			Name:   "Verify The Chain",
+			Target: "/audit/verify",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("VerifyAuditLog", mock.Anything, entities.VerifyAuditLogRequest{}).
+					Return(entities.VerifyAuditLogResponse{Entries: 4, BrokenAtSeq: 3}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Intact":false`)
+				assert.Contains(t, rec.Body.String(), `"BrokenAtSeq":3`)
+			},
+		},
+		{
+			Name:   "Verify The Legacy Chain",
+			Target: "/audit/verify?legacy=true",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("VerifyAuditLog", mock.Anything, entities.VerifyAuditLogRequest{Legacy: true}).
+					Return(entities.VerifyAuditLogResponse{Intact: true, Entries: 4}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Intact":true`)
+			},
+		},
+		{
+			Name:      "Malformed Legacy Flag",
+			Target:    "/audit/verify?legacy=maybe",
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(http.MethodGet, tc.Target, nil)
+			for name, values := range tc.Header {
+				req.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
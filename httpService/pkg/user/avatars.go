@@ -0,0 +1,47 @@
+package user
+
+import (
+	"context"
+	"io"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errs "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+// Avatars are checked, resized and stored by the gRPC service, the gateway
+// streams the uploaded picture on as it arrives.
+
+func (s *service) UploadAvatar(ctx context.Context, rq entities.UploadAvatarRequest, body io.Reader) (entities.UploadAvatarResponse, error) {
+	logger := log.With(s.Logger, "upload avatar request", "recevied")
+
+	if rq.UserId == "" || body == nil {
+		return entities.UploadAvatarResponse{}, errs.NewFieldsMissing()
+	}
+
+	res, err := s.Repo.UploadAvatar(ctx, rq, body)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.UploadAvatarResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) GetAvatar(ctx context.Context, rq entities.GetAvatarRequest) (entities.GetAvatarResponse, error) {
+	logger := log.With(s.Logger, "get avatar request", "recevied")
+
+	if rq.UserId == "" {
+		return entities.GetAvatarResponse{}, errs.NewFieldsMissing()
+	}
+
+	res, err := s.Repo.GetAvatar(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.GetAvatarResponse{}, err
+	}
+
+	return res, nil
+}
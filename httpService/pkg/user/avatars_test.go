@@ -0,0 +1,163 @@
+package user_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func avatarForm(t *testing.T, field, picture string) (string, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, "me.png")
+	assert.NoError(t, err)
+	part.Write([]byte(picture))
+	assert.NoError(t, writer.Close())
+	return body.String(), writer.FormDataContentType()
+}
+
+func TestAvatarRoutes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	updatedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	form, formType := avatarForm(t, "avatar", "picture")
+	wrongForm, wrongFormType := avatarForm(t, "file", "picture")
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Header         http.Header
+		Body           string
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Upload",
+			Method: http.MethodPut,
+			Target: "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b/avatar",
+			Header: http.Header{"Content-Type": {formType}},
+			Body:   form,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("UploadAvatar", mock.Anything, entities.UploadAvatarRequest{UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}, "picture").
+					Return(entities.UploadAvatarResponse{ContentType: "image/png", Sizes: []uint32{32, 64}, Etag: `"abc"`, UpdatedAt: updatedAt}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, `"abc"`, rec.Header().Get("ETag"))
+				assert.Contains(t, rec.Body.String(), `"Sizes":[32,64]`)
+			},
+		},
+		{
+			Name:   "Upload Own Avatar Too Large",
+			Method: http.MethodPut,
+			Target: "/me/avatar",
+			Header: http.Header{"Content-Type": {formType}, user.UserIdHeader: {"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}},
+			Body:   form,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("UploadAvatar", mock.Anything, entities.UploadAvatarRequest{UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}, "picture").
+					Return(entities.UploadAvatarResponse{}, myerr.NewPayloadTooLarge("avatar", 5<<20).GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			},
+		},
+		{
+			Name:      "Upload Without A Form",
+			Method:    http.MethodPut,
+			Target:    "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b/avatar",
+			Header:    http.Header{"Content-Type": {"image/png"}},
+			Body:      "picture",
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+			},
+		},
+		{
+			Name:      "Upload Without The Avatar Part",
+			Method:    http.MethodPut,
+			Target:    "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b/avatar",
+			Header:    http.Header{"Content-Type": {wrongFormType}},
+			Body:      wrongForm,
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:   "Get",
+			Method: http.MethodGet,
+			Target: "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b/avatar?size=64",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetAvatar", mock.Anything, entities.GetAvatarRequest{UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b", Size: 64}).
+					Return(entities.GetAvatarResponse{Image: []byte("thumbnail"), ContentType: "image/png", Size: 64, Etag: `"abc-64"`, UpdatedAt: updatedAt}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "thumbnail", rec.Body.String())
+				assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+				assert.Equal(t, "9", rec.Header().Get("Content-Length"))
+				assert.Equal(t, `"abc-64"`, rec.Header().Get("ETag"))
+				assert.Equal(t, "Thu, 01 Oct 2026 12:00:00 GMT", rec.Header().Get("Last-Modified"))
+				assert.Equal(t, "private, max-age=300", rec.Header().Get("Cache-Control"))
+				assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+			},
+		},
+		{
+			Name:   "Get Not Modified",
+			Method: http.MethodGet,
+			Target: "/me/avatar",
+			Header: http.Header{"If-None-Match": {`"abc-512"`}, user.UserIdHeader: {"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetAvatar", mock.Anything, entities.GetAvatarRequest{UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b", IfNoneMatch: `"abc-512"`}).
+					Return(entities.GetAvatarResponse{ContentType: "image/png", Size: 512, Etag: `"abc-512"`, UpdatedAt: updatedAt, NotModified: true}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotModified, rec.Code)
+				assert.Empty(t, rec.Body.String())
+				assert.Equal(t, `"abc-512"`, rec.Header().Get("ETag"))
+			},
+		},
+		{
+			Name:      "Get With A Bad Size",
+			Method:    http.MethodGet,
+			Target:    "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b/avatar?size=big",
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, tc.Target, strings.NewReader(tc.Body))
+			for key, values := range tc.Header {
+				req.Header[key] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errs "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+// MaxBatchItems caps the items of a batch request. It is lower than what
+// the gRPC service accepts since every created user costs a bcrypt hash
+// here.
+const MaxBatchItems = 100
+
+// BatchCreateUsers validates and hashes every user before handing the valid
+// ones to the gRPC service, the invalid ones get their error in place.
+func (s *service) BatchCreateUsers(ctx context.Context, rq entities.BatchCreateUsersRequest) (entities.BatchCreateUsersResponse, error) {
+	logger := log.With(s.Logger, "batch create users request", "recevied")
+
+	if err := checkBatchItems("users", len(rq.Users)); err != nil {
+		return entities.BatchCreateUsersResponse{}, err
+	}
+
+	results := make([]entities.BatchCreateUserResult, len(rq.Users))
+	var valid []int
+	for i, user := range rq.Users {
+		if err := util.ValidateCreateUserRequest(user); err != nil {
+			results[i].Error = itemStatus(err)
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	if len(valid) == 0 || (rq.AllOrNothing && len(valid) < len(rq.Users)) {
+		for _, i := range valid {
+			results[i].Error = &entities.Status{Code: int32(codes.Aborted), Message: "not applied, another item of the batch failed"}
+		}
+		return entities.BatchCreateUsersResponse{Results: results}, nil
+	}
+
+	users, err := hashPasswords(rq.Users, valid)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.BatchCreateUsersResponse{}, err
+	}
+
+	res, err := s.Repo.BatchCreateUsers(ctx, entities.BatchCreateUsersRequest{Users: users, AllOrNothing: rq.AllOrNothing})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.BatchCreateUsersResponse{}, err
+	}
+
+	for j, result := range res.Results {
+		results[valid[j]] = result
+	}
+
+	return entities.BatchCreateUsersResponse{Results: results}, nil
+}
+
+func (s *service) BatchGetUsers(ctx context.Context, rq entities.BatchGetUsersRequest) (entities.BatchGetUsersResponse, error) {
+	logger := log.With(s.Logger, "batch get users request", "recevied")
+
+	if err := checkBatchItems("user_ids", len(rq.UserIds)); err != nil {
+		return entities.BatchGetUsersResponse{}, err
+	}
+
+	res, err := s.Repo.BatchGetUsers(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.BatchGetUsersResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) BatchDeleteUsers(ctx context.Context, rq entities.BatchDeleteUsersRequest) (entities.BatchDeleteUsersResponse, error) {
+	logger := log.With(s.Logger, "batch delete users request", "recevied")
+
+	if err := checkBatchItems("user_ids", len(rq.UserIds)); err != nil {
+		return entities.BatchDeleteUsersResponse{}, err
+	}
+
+	res, err := s.Repo.BatchDeleteUsers(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.BatchDeleteUsersResponse{}, err
+	}
+
+	return res, nil
+}
+
+// hashPasswords returns the users at indexes with their password hashed,
+// spreading the hashing over the available CPUs.
+func hashPasswords(users []entities.CreateUserRequest, indexes []int) ([]entities.CreateUserRequest, error) {
+	hashed := make([]entities.CreateUserRequest, len(indexes))
+	errors := make([]error, len(indexes))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for j, i := range indexes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(j int, user entities.CreateUserRequest) {
+			defer func() { <-sem; wg.Done() }()
+			user.Pass, errors[j] = util.HashPassword(user.Pass)
+			hashed[j] = user
+		}(j, users[i])
+	}
+	wg.Wait()
+
+	for _, err := range errors {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashed, nil
+}
+
+func checkBatchItems(field string, n int) error {
+	if n == 0 {
+		return errs.NewInvalidField(field, "must not be empty")
+	}
+	if n > MaxBatchItems {
+		return errs.NewInvalidField(field, fmt.Sprintf("more than %d items", MaxBatchItems))
+	}
+	return nil
+}
+
+func itemStatus(err error) *entities.Status {
+	st := status.Convert(err)
+	return &entities.Status{Code: int32(st.Code()), Message: st.Message()}
+}
@@ -0,0 +1,99 @@
+package user_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestBatchCreateUsers(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	users := []entities.CreateUserRequest{
+		{Name: "Timo", Age: 19, Pass: "123", Email: "timoteo@globant.com"},
+		{Name: "Ana"},
+		{Name: "Luz", Age: 30, Pass: "456", Email: "luz@globant.com"},
+	}
+
+	testCases := []struct {
+		Name           string
+		AllOrNothing   bool
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, res entities.BatchCreateUsersResponse, err error)
+	}{
+		{
+			Name: "Invalid Items Are Reported In Place",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("BatchCreateUsers", mock.Anything, mock.MatchedBy(func(rq entities.BatchCreateUsersRequest) bool {
+					return len(rq.Users) == 2 && rq.Users[0].Pass != "123" && rq.Users[1].Email == "luz@globant.com"
+				})).Return(entities.BatchCreateUsersResponse{Results: []entities.BatchCreateUserResult{
+					{UserId: "user-1"},
+					{Error: &entities.Status{Code: int32(codes.AlreadyExists), Message: "user already exists"}},
+				}}, nil)
+			},
+			assertResponse: func(t *testing.T, res entities.BatchCreateUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-1", res.Results[0].UserId)
+				assert.Equal(t, int32(codes.InvalidArgument), res.Results[1].Error.Code)
+				assert.Equal(t, int32(codes.AlreadyExists), res.Results[2].Error.Code)
+			},
+		},
+		{
+			Name:         "All Or Nothing Stops Before The Service",
+			AllOrNothing: true,
+			buildMock:    func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, res entities.BatchCreateUsersResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, int32(codes.Aborted), res.Results[0].Error.Code)
+				assert.Equal(t, int32(codes.InvalidArgument), res.Results[1].Error.Code)
+				assert.Equal(t, int32(codes.Aborted), res.Results[2].Error.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			srvc := user.NewService(&repo, logger)
+			res, err := srvc.BatchCreateUsers(context.Background(), entities.BatchCreateUsersRequest{Users: users, AllOrNothing: tc.AllOrNothing})
+			tc.assertResponse(t, res, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBatchRequestCaps(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	repo := util.NewRepositoryMock()
+	handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+
+	body := `{"UserIds":["` + string(bytes.Repeat([]byte("a"), 2<<20)) + `"]}`
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users:batchGet", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ids := bytes.Repeat([]byte(`"a",`), user.MaxBatchItems+1)
+	body = `{"UserIds":[` + string(ids[:len(ids)-1]) + `]}`
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users:batchDelete", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "more than")
+
+	repo.AssertNotCalled(t, "BatchGetUsers", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "BatchDeleteUsers", mock.Anything, mock.Anything)
+}
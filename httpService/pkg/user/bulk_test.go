@@ -0,0 +1,113 @@
+package user_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestImportUsersUpload(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	file := "name,email,age,password\nTimo,timoteo@globant.com,19,123\n"
+
+	upload := func(fields map[string]string, fileName string) *http.Request {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for name, value := range fields {
+			writer.WriteField(name, value)
+		}
+		if fileName != "" {
+			part, _ := writer.CreateFormFile("file", fileName)
+			part.Write([]byte(file))
+		}
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/users:import", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req
+	}
+
+	testCases := []struct {
+		Name           string
+		Request        *http.Request
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:    "Format From File Name",
+			Request: upload(map[string]string{"import_id": "import-1", "dry_run": "true"}, "users.CSV"),
+			buildMock: func(repo *util.RepositoryMock) {
+				opts := entities.ImportOptions{Format: "csv", DryRun: true, ImportId: "import-1"}
+				repo.On("ImportUsers", mock.Anything, opts, file).Return(entities.ImportUsersResponse{Rows: 1, Imported: 1, DryRun: true}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Imported":1`)
+			},
+		},
+		{
+			Name:      "Unknown Format",
+			Request:   upload(nil, "users.xlsx"),
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.Contains(t, rec.Body.String(), "format")
+			},
+		},
+		{
+			Name:      "Missing File",
+			Request:   upload(map[string]string{"format": "ndjson"}, ""),
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.Contains(t, rec.Body.String(), "file")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tc.Request)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportUsersDownload(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	repo := util.NewRepositoryMock()
+	repo.On("ExportUsers", mock.Anything, entities.ExportUsersRequest{Format: "ndjson", AfterId: "user-1"}).Return([]entities.ExportChunk{
+		{Data: []byte(`{"id":"user-2"}` + "\n"), LastId: "user-2"},
+		{Data: []byte(`{"id":"user-3"}` + "\n"), LastId: "user-3"},
+	}, nil)
+
+	handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users:export?format=ndjson&after_id=user-1", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="users.ndjson"`, rec.Header().Get("Content-Disposition"))
+	assert.Equal(t, `{"id":"user-2"}`+"\n"+`{"id":"user-3"}`+"\n", rec.Body.String())
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,92 @@
+package user
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errs "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+)
+
+// Policies and consents are kept by the gRPC service, which validates the
+// requests.
+
+func (s *service) PublishPolicy(ctx context.Context, rq entities.PublishPolicyRequest) (entities.PublishPolicyResponse, error) {
+	logger := log.With(s.Logger, "publish policy request", "recevied")
+
+	res, err := s.Repo.PublishPolicy(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.PublishPolicyResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ListPolicies(ctx context.Context, rq entities.ListPoliciesRequest) (entities.ListPoliciesResponse, error) {
+	logger := log.With(s.Logger, "list policies request", "recevied")
+
+	res, err := s.Repo.ListPolicies(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListPoliciesResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) AcceptPolicy(ctx context.Context, rq entities.AcceptPolicyRequest) (entities.AcceptPolicyResponse, error) {
+	logger := log.With(s.Logger, "accept policy request", "recevied")
+
+	res, err := s.Repo.AcceptPolicy(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.AcceptPolicyResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ListConsents(ctx context.Context, rq entities.ListConsentsRequest) (entities.ListConsentsResponse, error) {
+	logger := log.With(s.Logger, "list consents request", "recevied")
+
+	res, err := s.Repo.ListConsents(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListConsentsResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) WithdrawConsent(ctx context.Context, rq entities.WithdrawConsentRequest) (entities.WithdrawConsentResponse, error) {
+	logger := log.With(s.Logger, "withdraw consent request", "recevied")
+
+	res, err := s.Repo.WithdrawConsent(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.WithdrawConsentResponse{}, err
+	}
+
+	return res, nil
+}
+
+// Authenticate sends the password as given, the gRPC service checks it
+// against the stored hash.
+func (s *service) Authenticate(ctx context.Context, rq entities.AuthenticateRequest) (entities.AuthenticateResponse, error) {
+	logger := log.With(s.Logger, "authenticate request", "recevied")
+
+	if rq.Email == "" || rq.Pass == "" {
+		return entities.AuthenticateResponse{}, errs.NewFieldsMissing()
+	}
+
+	res, err := s.Repo.Authenticate(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.AuthenticateResponse{}, err
+	}
+
+	return res, nil
+}
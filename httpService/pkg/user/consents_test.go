@@ -0,0 +1,183 @@
+package user_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestConsentRoutes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	userId := "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Header         map[string]string
+		Body           string
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Publish Policy",
+			Method: http.MethodPost,
+			Target: "/policies",
+			Body:   `{"Kind":"terms","Version":"2024-06","Url":"https://example.com/terms","Mandatory":true}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("PublishPolicy", mock.Anything, entities.PublishPolicyRequest{Kind: "terms", Version: "2024-06", Url: "https://example.com/terms", Mandatory: true}).
+					Return(entities.PublishPolicyResponse{Policy: entities.Policy{Kind: "terms", Version: "2024-06"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Version":"2024-06"`)
+			},
+		},
+		{
+			Name:   "List Policy Versions",
+			Method: http.MethodGet,
+			Target: "/policies?kind=terms",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListPolicies", mock.Anything, entities.ListPoliciesRequest{Kind: "terms"}).
+					Return(entities.ListPoliciesResponse{Policies: []entities.Policy{{Kind: "terms", Version: "2024-06"}}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Accept Policy Of Caller",
+			Method: http.MethodPost,
+			Target: "/me/consents",
+			Header: map[string]string{user.UserIdHeader: userId},
+			Body:   `{"Kind":"marketing_email","Source":"settings"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("AcceptPolicy", mock.Anything, entities.AcceptPolicyRequest{UserId: userId, Kind: "marketing_email", Source: "settings"}).
+					Return(entities.AcceptPolicyResponse{Consent: entities.Consent{UserId: userId, Kind: "marketing_email", Version: "1"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Kind":"marketing_email"`)
+			},
+		},
+		{
+			Name:   "Accept Outdated Version",
+			Method: http.MethodPost,
+			Target: "/user/" + userId + "/consents",
+			Body:   `{"Kind":"terms","Version":"2023-01","Source":"signup"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("AcceptPolicy", mock.Anything, mock.Anything).
+					Return(entities.AcceptPolicyResponse{}, status.Error(codes.FailedPrecondition, "version 2023-01 of terms is not the current one"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+			},
+		},
+		{
+			Name:   "List Consents",
+			Method: http.MethodGet,
+			Target: "/user/" + userId + "/consents",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListConsents", mock.Anything, entities.ListConsentsRequest{UserId: userId}).
+					Return(entities.ListConsentsResponse{Pending: []entities.Policy{{Kind: "terms", Version: "2024-06", Mandatory: true}}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Pending":[{"Kind":"terms"`)
+			},
+		},
+		{
+			Name:      "List Consents Of Unknown Caller",
+			Method:    http.MethodGet,
+			Target:    "/me/consents",
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			Name:   "Withdraw Consent",
+			Method: http.MethodDelete,
+			Target: "/me/consents/marketing_email?source=unsubscribe_link",
+			Header: map[string]string{user.UserIdHeader: userId},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("WithdrawConsent", mock.Anything, entities.WithdrawConsentRequest{UserId: userId, Kind: "marketing_email", Source: "unsubscribe_link"}).
+					Return(entities.WithdrawConsentResponse{Consent: entities.Consent{Kind: "marketing_email", WithdrawnFrom: "unsubscribe_link"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"WithdrawnFrom":"unsubscribe_link"`)
+			},
+		},
+		{
+			Name:   "Authenticate",
+			Method: http.MethodPost,
+			Target: "/authenticate",
+			Body:   `{"Email":"timoteo@globant.com","Pass":"secret"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("Authenticate", mock.Anything, entities.AuthenticateRequest{Email: "timoteo@globant.com", Pass: "secret"}).
+					Return(entities.AuthenticateResponse{UserId: userId}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), userId)
+			},
+		},
+		{
+			Name:   "Authenticate With New Terms",
+			Method: http.MethodPost,
+			Target: "/authenticate",
+			Body:   `{"Email":"timoteo@globant.com","Pass":"secret"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				err := myerr.NewPolicyNotAccepted(map[string]string{"terms": "2024-06"})
+				repo.On("Authenticate", mock.Anything, mock.Anything).
+					Return(entities.AuthenticateResponse{}, err.GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"pending_policies":{"terms":"2024-06"}`)
+			},
+		},
+		{
+			Name:      "Authenticate Without Password",
+			Method:    http.MethodPost,
+			Target:    "/authenticate",
+			Body:      `{"Email":"timoteo@globant.com"}`,
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, tc.Target, strings.NewReader(tc.Body))
+			for key, value := range tc.Header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
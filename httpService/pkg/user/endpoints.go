@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"io"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
@@ -12,20 +13,175 @@ type Service interface {
 	CreateUser(ctx context.Context, rq entities.CreateUserRequest) (entities.CreateUserResponse, error)
 	GetUser(ctx context.Context, rq entities.GetUserRequest) (entities.GetUserResponse, error)
 	DeleteUser(ctx context.Context, rq entities.DeleteUserRequest) (entities.DeleteUserResponse, error)
+	BatchCreateUsers(ctx context.Context, rq entities.BatchCreateUsersRequest) (entities.BatchCreateUsersResponse, error)
+	BatchGetUsers(ctx context.Context, rq entities.BatchGetUsersRequest) (entities.BatchGetUsersResponse, error)
+	BatchDeleteUsers(ctx context.Context, rq entities.BatchDeleteUsersRequest) (entities.BatchDeleteUsersResponse, error)
+	WatchUsers(ctx context.Context, rq entities.WatchRequest, send func(entities.WatchEvent) error) error
+	ImportUsers(ctx context.Context, opts entities.ImportOptions, body io.Reader) (entities.ImportUsersResponse, error)
+	ExportUsers(ctx context.Context, rq entities.ExportUsersRequest, send func(entities.ExportChunk) error) error
+	ListAuditEvents(ctx context.Context, rq entities.ListAuditEventsRequest) (entities.ListAuditEventsResponse, error)
+	VerifyAuditLog(ctx context.Context, rq entities.VerifyAuditLogRequest) (entities.VerifyAuditLogResponse, error)
+	ExportUserData(ctx context.Context, rq entities.ExportUserDataRequest) (entities.ExportUserDataResponse, error)
+	EraseUser(ctx context.Context, rq entities.EraseUserRequest) (entities.EraseUserResponse, error)
+	CreateGroup(ctx context.Context, rq entities.CreateGroupRequest) (entities.CreateGroupResponse, error)
+	RenameGroup(ctx context.Context, rq entities.RenameGroupRequest) (entities.RenameGroupResponse, error)
+	DeleteGroup(ctx context.Context, rq entities.DeleteGroupRequest) (entities.DeleteGroupResponse, error)
+	ListGroups(ctx context.Context, rq entities.ListGroupsRequest) (entities.ListGroupsResponse, error)
+	AddMember(ctx context.Context, rq entities.AddMemberRequest) (entities.AddMemberResponse, error)
+	RemoveMember(ctx context.Context, rq entities.RemoveMemberRequest) (entities.RemoveMemberResponse, error)
+	ListMembers(ctx context.Context, rq entities.ListMembersRequest) (entities.ListMembersResponse, error)
+	ListUserGroups(ctx context.Context, rq entities.ListUserGroupsRequest) (entities.ListUserGroupsResponse, error)
+	InviteUser(ctx context.Context, rq entities.InviteUserRequest) (entities.InviteUserResponse, error)
+	AcceptInvitation(ctx context.Context, rq entities.AcceptInvitationRequest) (entities.AcceptInvitationResponse, error)
+	ListInvitations(ctx context.Context, rq entities.ListInvitationsRequest) (entities.ListInvitationsResponse, error)
+	RevokeInvitation(ctx context.Context, rq entities.RevokeInvitationRequest) (entities.RevokeInvitationResponse, error)
+	ResendInvitation(ctx context.Context, rq entities.ResendInvitationRequest) (entities.ResendInvitationResponse, error)
+	UpdateUserAttributes(ctx context.Context, rq entities.UpdateUserAttributesRequest) (entities.UpdateUserAttributesResponse, error)
+	ListUsers(ctx context.Context, rq entities.ListUsersRequest) (entities.ListUsersResponse, error)
+	GetAttributeSchema(ctx context.Context, rq entities.GetAttributeSchemaRequest) (entities.GetAttributeSchemaResponse, error)
+	SetAttributeSchema(ctx context.Context, rq entities.SetAttributeSchemaRequest) (entities.SetAttributeSchemaResponse, error)
+	UploadAvatar(ctx context.Context, rq entities.UploadAvatarRequest, body io.Reader) (entities.UploadAvatarResponse, error)
+	GetAvatar(ctx context.Context, rq entities.GetAvatarRequest) (entities.GetAvatarResponse, error)
+	PublishPolicy(ctx context.Context, rq entities.PublishPolicyRequest) (entities.PublishPolicyResponse, error)
+	ListPolicies(ctx context.Context, rq entities.ListPoliciesRequest) (entities.ListPoliciesResponse, error)
+	AcceptPolicy(ctx context.Context, rq entities.AcceptPolicyRequest) (entities.AcceptPolicyResponse, error)
+	ListConsents(ctx context.Context, rq entities.ListConsentsRequest) (entities.ListConsentsResponse, error)
+	WithdrawConsent(ctx context.Context, rq entities.WithdrawConsentRequest) (entities.WithdrawConsentResponse, error)
+	Authenticate(ctx context.Context, rq entities.AuthenticateRequest) (entities.AuthenticateResponse, error)
+	GetPreferences(ctx context.Context, rq entities.GetPreferencesRequest) (entities.GetPreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, rq entities.UpdatePreferencesRequest) (entities.UpdatePreferencesResponse, error)
+	Impersonate(ctx context.Context, rq entities.ImpersonateRequest) (entities.ImpersonateResponse, error)
+	EndImpersonation(ctx context.Context, rq entities.EndImpersonationRequest) (entities.EndImpersonationResponse, error)
+	CreateApiKey(ctx context.Context, rq entities.CreateApiKeyRequest) (entities.CreateApiKeyResponse, error)
+	ListApiKeys(ctx context.Context, rq entities.ListApiKeysRequest) (entities.ListApiKeysResponse, error)
+	RevokeApiKey(ctx context.Context, rq entities.RevokeApiKeyRequest) (entities.RevokeApiKeyResponse, error)
+	RotateApiKey(ctx context.Context, rq entities.RotateApiKeyRequest) (entities.RotateApiKeyResponse, error)
+}
+
+// WatchUsersRequest carries the callback the event stream is written to,
+// since an endpoint returns a single response.
+type WatchUsersRequest struct {
+	entities.WatchRequest
+	Send func(entities.WatchEvent) error
+}
+
+// ImportUsersRequest carries the uploaded file, read as it is streamed on.
+type ImportUsersRequest struct {
+	entities.ImportOptions
+	Body io.Reader
+}
+
+// UploadAvatarRequest carries the uploaded picture, read as it is streamed
+// on.
+type UploadAvatarRequest struct {
+	entities.UploadAvatarRequest
+	Body io.Reader
+}
+
+// ExportUsersRequest carries the callback the exported file is written to.
+type ExportUsersRequest struct {
+	entities.ExportUsersRequest
+	Send func(entities.ExportChunk) error
 }
 
 type Endpoints struct {
-	CreateUs endpoint.Endpoint
-	GetUs    endpoint.Endpoint
-	DeleteUs endpoint.Endpoint
+	CreateUs             endpoint.Endpoint
+	GetUs                endpoint.Endpoint
+	DeleteUs             endpoint.Endpoint
+	BatchCreateUs        endpoint.Endpoint
+	BatchGetUs           endpoint.Endpoint
+	BatchDeleteUs        endpoint.Endpoint
+	WatchUs              endpoint.Endpoint
+	ImportUs             endpoint.Endpoint
+	ExportUs             endpoint.Endpoint
+	ListAuditEvs         endpoint.Endpoint
+	VerifyAudit          endpoint.Endpoint
+	ExportData           endpoint.Endpoint
+	EraseUs              endpoint.Endpoint
+	CreateGroup          endpoint.Endpoint
+	RenameGroup          endpoint.Endpoint
+	DeleteGroup          endpoint.Endpoint
+	ListGroups           endpoint.Endpoint
+	AddMember            endpoint.Endpoint
+	RemoveMember         endpoint.Endpoint
+	ListMembers          endpoint.Endpoint
+	ListUserGroups       endpoint.Endpoint
+	InviteUser           endpoint.Endpoint
+	AcceptInvitation     endpoint.Endpoint
+	ListInvitations      endpoint.Endpoint
+	RevokeInvitation     endpoint.Endpoint
+	ResendInvitation     endpoint.Endpoint
+	UpdateUserAttributes endpoint.Endpoint
+	ListUsers            endpoint.Endpoint
+	GetAttributeSchema   endpoint.Endpoint
+	SetAttributeSchema   endpoint.Endpoint
+	UploadAvatar         endpoint.Endpoint
+	GetAvatar            endpoint.Endpoint
+	PublishPolicy        endpoint.Endpoint
+	ListPolicies         endpoint.Endpoint
+	AcceptPolicy         endpoint.Endpoint
+	ListConsents         endpoint.Endpoint
+	WithdrawConsent      endpoint.Endpoint
+	Authenticate         endpoint.Endpoint
+	GetPreferences       endpoint.Endpoint
+	UpdatePreferences    endpoint.Endpoint
+	Impersonate          endpoint.Endpoint
+	EndImpersonation     endpoint.Endpoint
+	CreateApiKey         endpoint.Endpoint
+	ListApiKeys          endpoint.Endpoint
+	RevokeApiKey         endpoint.Endpoint
+	RotateApiKey         endpoint.Endpoint
 }
 
 func MakeEndpoints(s Service) *Endpoints {
 
 	return &Endpoints{
-		CreateUs: MakeCreateUserEndpoint(s),
-		GetUs:    MakeGetUserEndpoint(s),
-		DeleteUs: MakeDeleteUserEndpoint(s),
+		CreateUs:             MakeCreateUserEndpoint(s),
+		GetUs:                MakeGetUserEndpoint(s),
+		DeleteUs:             MakeDeleteUserEndpoint(s),
+		BatchCreateUs:        MakeBatchCreateUsersEndpoint(s),
+		BatchGetUs:           MakeBatchGetUsersEndpoint(s),
+		BatchDeleteUs:        MakeBatchDeleteUsersEndpoint(s),
+		WatchUs:              MakeWatchUsersEndpoint(s),
+		ImportUs:             MakeImportUsersEndpoint(s),
+		ExportUs:             MakeExportUsersEndpoint(s),
+		ListAuditEvs:         MakeListAuditEventsEndpoint(s),
+		VerifyAudit:          MakeVerifyAuditLogEndpoint(s),
+		ExportData:           MakeExportUserDataEndpoint(s),
+		EraseUs:              MakeEraseUserEndpoint(s),
+		CreateGroup:          MakeCreateGroupEndpoint(s),
+		RenameGroup:          MakeRenameGroupEndpoint(s),
+		DeleteGroup:          MakeDeleteGroupEndpoint(s),
+		ListGroups:           MakeListGroupsEndpoint(s),
+		AddMember:            MakeAddMemberEndpoint(s),
+		RemoveMember:         MakeRemoveMemberEndpoint(s),
+		ListMembers:          MakeListMembersEndpoint(s),
+		ListUserGroups:       MakeListUserGroupsEndpoint(s),
+		InviteUser:           MakeInviteUserEndpoint(s),
+		AcceptInvitation:     MakeAcceptInvitationEndpoint(s),
+		ListInvitations:      MakeListInvitationsEndpoint(s),
+		RevokeInvitation:     MakeRevokeInvitationEndpoint(s),
+		ResendInvitation:     MakeResendInvitationEndpoint(s),
+		UpdateUserAttributes: MakeUpdateUserAttributesEndpoint(s),
+		ListUsers:            MakeListUsersEndpoint(s),
+		GetAttributeSchema:   MakeGetAttributeSchemaEndpoint(s),
+		SetAttributeSchema:   MakeSetAttributeSchemaEndpoint(s),
+		UploadAvatar:         MakeUploadAvatarEndpoint(s),
+		GetAvatar:            MakeGetAvatarEndpoint(s),
+		PublishPolicy:        MakePublishPolicyEndpoint(s),
+		ListPolicies:         MakeListPoliciesEndpoint(s),
+		AcceptPolicy:         MakeAcceptPolicyEndpoint(s),
+		ListConsents:         MakeListConsentsEndpoint(s),
+		WithdrawConsent:      MakeWithdrawConsentEndpoint(s),
+		Authenticate:         MakeAuthenticateEndpoint(s),
+		GetPreferences:       MakeGetPreferencesEndpoint(s),
+		UpdatePreferences:    MakeUpdatePreferencesEndpoint(s),
+		Impersonate:          MakeImpersonateEndpoint(s),
+		EndImpersonation:     MakeEndImpersonationEndpoint(s),
+		CreateApiKey:         MakeCreateApiKeyEndpoint(s),
+		ListApiKeys:          MakeListApiKeysEndpoint(s),
+		RevokeApiKey:         MakeRevokeApiKeyEndpoint(s),
+		RotateApiKey:         MakeRotateApiKeyEndpoint(s),
 	}
 }
 
@@ -78,3 +234,689 @@ func MakeDeleteUserEndpoint(s Service) endpoint.Endpoint {
 		return res, nil
 	}
 }
+
+func MakeBatchCreateUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.BatchCreateUsersRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.BatchCreateUsers(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeBatchGetUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.BatchGetUsersRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.BatchGetUsers(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeBatchDeleteUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.BatchDeleteUsersRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.BatchDeleteUsers(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeWatchUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(WatchUsersRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		if err := s.WatchUsers(ctx, request.WatchRequest, request.Send); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func MakeImportUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(ImportUsersRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ImportUsers(ctx, request.ImportOptions, request.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeExportUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(ExportUsersRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		if err := s.ExportUsers(ctx, request.ExportUsersRequest, request.Send); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func MakeListAuditEventsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ListAuditEventsRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ListAuditEvents(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeVerifyAuditLogEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.VerifyAuditLogRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.VerifyAuditLog(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeExportUserDataEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ExportUserDataRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ExportUserData(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeEraseUserEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.EraseUserRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.EraseUser(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeCreateGroupEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.CreateGroupRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.CreateGroup(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeRenameGroupEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.RenameGroupRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.RenameGroup(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeDeleteGroupEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.DeleteGroupRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.DeleteGroup(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeListGroupsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ListGroupsRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ListGroups(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeAddMemberEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.AddMemberRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.AddMember(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeRemoveMemberEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.RemoveMemberRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.RemoveMember(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeListMembersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ListMembersRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ListMembers(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeListUserGroupsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ListUserGroupsRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ListUserGroups(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeInviteUserEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.InviteUserRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.InviteUser(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeAcceptInvitationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.AcceptInvitationRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.AcceptInvitation(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeListInvitationsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ListInvitationsRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ListInvitations(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeRevokeInvitationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.RevokeInvitationRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.RevokeInvitation(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeResendInvitationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ResendInvitationRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ResendInvitation(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeUpdateUserAttributesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.UpdateUserAttributesRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.UpdateUserAttributes(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeListUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ListUsersRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ListUsers(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeGetAttributeSchemaEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.GetAttributeSchemaRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.GetAttributeSchema(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeSetAttributeSchemaEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.SetAttributeSchemaRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.SetAttributeSchema(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeUploadAvatarEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(UploadAvatarRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.UploadAvatar(ctx, request.UploadAvatarRequest, request.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeGetAvatarEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.GetAvatarRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.GetAvatar(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakePublishPolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.PublishPolicyRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.PublishPolicy(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeListPoliciesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ListPoliciesRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ListPolicies(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeAcceptPolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.AcceptPolicyRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.AcceptPolicy(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeListConsentsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ListConsentsRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ListConsents(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeWithdrawConsentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.WithdrawConsentRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.WithdrawConsent(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeAuthenticateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.AuthenticateRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.Authenticate(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeGetPreferencesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.GetPreferencesRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.GetPreferences(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeUpdatePreferencesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.UpdatePreferencesRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.UpdatePreferences(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeImpersonateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ImpersonateRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.Impersonate(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeEndImpersonationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.EndImpersonationRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.EndImpersonation(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeCreateApiKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.CreateApiKeyRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.CreateApiKey(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeListApiKeysEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.ListApiKeysRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.ListApiKeys(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeRevokeApiKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.RevokeApiKeyRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.RevokeApiKey(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+func MakeRotateApiKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		request, valid := rq.(entities.RotateApiKeyRequest)
+		if !valid {
+			return nil, errs.NewFieldsMissing()
+		}
+
+		res, err := s.RotateApiKey(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
@@ -0,0 +1,109 @@
+package user_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestUserETags(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Header         http.Header
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Get Returns The ETag",
+			Method: http.MethodGet,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetUser", mock.Anything, entities.GetUserRequest{UserID: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}).
+					Return(entities.GetUserResponse{Id: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b", Name: "Timo", Etag: `"2"`}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+				assert.Contains(t, rec.Body.String(), "Timo")
+			},
+		},
+		{
+			Name:   "Get Not Modified",
+			Method: http.MethodGet,
+			Header: http.Header{"If-None-Match": {`"1", W/"2"`}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetUser", mock.Anything, mock.Anything).Return(entities.GetUserResponse{Id: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b", Etag: `"2"`}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotModified, rec.Code)
+				assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+				assert.Empty(t, rec.Body.String())
+			},
+		},
+		{
+			Name:   "Get Modified",
+			Method: http.MethodGet,
+			Header: http.Header{"If-None-Match": {`"1"`}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetUser", mock.Anything, mock.Anything).Return(entities.GetUserResponse{Id: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b", Etag: `"2"`}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Delete Sends If-Match",
+			Method: http.MethodDelete,
+			Header: http.Header{"If-Match": {`"2"`}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("DeleteUser", mock.Anything, entities.DeleteUserRequest{UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b", IfMatch: `"2"`}).
+					Return(entities.DeleteUserResponse{}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Delete Of A Changed User",
+			Method: http.MethodDelete,
+			Header: http.Header{"If-Match": {`"1"`}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("DeleteUser", mock.Anything, mock.Anything).
+					Return(entities.DeleteUserResponse{}, myerr.NewPreconditionFailed("user was modified since it was read").GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b", nil)
+			for name, values := range tc.Header {
+				req.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package user
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+// Groups are kept by the gRPC service, which validates the requests.
+
+func (s *service) CreateGroup(ctx context.Context, rq entities.CreateGroupRequest) (entities.CreateGroupResponse, error) {
+	logger := log.With(s.Logger, "create group request", "recevied")
+
+	res, err := s.Repo.CreateGroup(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.CreateGroupResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) RenameGroup(ctx context.Context, rq entities.RenameGroupRequest) (entities.RenameGroupResponse, error) {
+	logger := log.With(s.Logger, "rename group request", "recevied")
+
+	res, err := s.Repo.RenameGroup(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RenameGroupResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) DeleteGroup(ctx context.Context, rq entities.DeleteGroupRequest) (entities.DeleteGroupResponse, error) {
+	logger := log.With(s.Logger, "delete group request", "recevied")
+
+	res, err := s.Repo.DeleteGroup(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.DeleteGroupResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ListGroups(ctx context.Context, rq entities.ListGroupsRequest) (entities.ListGroupsResponse, error) {
+	logger := log.With(s.Logger, "list groups request", "recevied")
+
+	res, err := s.Repo.ListGroups(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListGroupsResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) AddMember(ctx context.Context, rq entities.AddMemberRequest) (entities.AddMemberResponse, error) {
+	logger := log.With(s.Logger, "add member request", "recevied")
+
+	res, err := s.Repo.AddMember(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.AddMemberResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) RemoveMember(ctx context.Context, rq entities.RemoveMemberRequest) (entities.RemoveMemberResponse, error) {
+	logger := log.With(s.Logger, "remove member request", "recevied")
+
+	res, err := s.Repo.RemoveMember(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RemoveMemberResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ListMembers(ctx context.Context, rq entities.ListMembersRequest) (entities.ListMembersResponse, error) {
+	logger := log.With(s.Logger, "list members request", "recevied")
+
+	res, err := s.Repo.ListMembers(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListMembersResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ListUserGroups(ctx context.Context, rq entities.ListUserGroupsRequest) (entities.ListUserGroupsResponse, error) {
+	logger := log.With(s.Logger, "list user groups request", "recevied")
+
+	res, err := s.Repo.ListUserGroups(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListUserGroupsResponse{}, err
+	}
+
+	return res, nil
+}
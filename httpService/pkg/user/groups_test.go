@@ -0,0 +1,143 @@
+package user_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestGroupRoutes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Body           string
+		Header         http.Header
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Create Group",
+			Method: http.MethodPost,
+			Target: "/groups",
+			Body:   `{"Name":"Platform","OwnerId":"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("CreateGroup", mock.Anything, entities.CreateGroupRequest{Name: "Platform", OwnerId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}).
+					Return(entities.CreateGroupResponse{Group: entities.Group{Id: "group-1", Name: "Platform"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Id":"group-1"`)
+			},
+		},
+		{
+			Name:   "List Groups",
+			Method: http.MethodGet,
+			Target: "/groups?page_size=2&page_token=group-1",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListGroups", mock.Anything, entities.ListGroupsRequest{PageSize: 2, PageToken: "group-1"}).
+					Return(entities.ListGroupsResponse{NextPageToken: "group-3"}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"NextPageToken":"group-3"`)
+			},
+		},
+		{
+			Name:   "Rename Group",
+			Method: http.MethodPatch,
+			Target: "/groups/group-1",
+			Body:   `{"Name":"Infrastructure"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("RenameGroup", mock.Anything, entities.RenameGroupRequest{GroupId: "group-1", Name: "Infrastructure"}).
+					Return(entities.RenameGroupResponse{}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Add Member",
+			Method: http.MethodPost,
+			Target: "/groups/group-1/members",
+			Body:   `{"UserId":"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c","Role":"owner"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("AddMember", mock.Anything, entities.AddMemberRequest{GroupId: "group-1", UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c", Role: "owner"}).
+					Return(entities.AddMemberResponse{Member: entities.GroupMember{GroupId: "group-1", UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c", Role: "owner"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Role":"owner"`)
+			},
+		},
+		{
+			Name:   "Remove Last Owner",
+			Method: http.MethodDelete,
+			Target: "/groups/group-1/members/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("RemoveMember", mock.Anything, entities.RemoveMemberRequest{GroupId: "group-1", UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}).
+					Return(entities.RemoveMemberResponse{}, status.Error(codes.FailedPrecondition, "a group keeps at least one owner"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+				assert.Contains(t, rec.Body.String(), "at least one owner")
+			},
+		},
+		{
+			Name:   "Groups Of The Caller",
+			Method: http.MethodGet,
+			Target: "/me/groups",
+			Header: http.Header{user.UserIdHeader: {"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListUserGroups", mock.Anything, entities.ListUserGroupsRequest{UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}).
+					Return(entities.ListUserGroupsResponse{Groups: []entities.UserGroup{{Group: entities.Group{Id: "group-1"}, Role: "member"}}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Role":"member"`)
+			},
+		},
+		{
+			Name:      "Malformed Page Size",
+			Method:    http.MethodGet,
+			Target:    "/groups/group-1/members?page_size=all",
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.Contains(t, rec.Body.String(), "page_size")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, tc.Target, strings.NewReader(tc.Body))
+			for name, values := range tc.Header {
+				req.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package user_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/idempotency"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestCreateUserIdempotencyKey(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	body := `{"Name":"Timo","Pass":"123","Age":19,"Email":"timoteo@globant.com"}`
+	fingerprint := idempotency.Fingerprint("Timo", "19", "timoteo@globant.com", "123")
+
+	testCases := []struct {
+		Name           string
+		Key            string
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "Key Is Sent With The Plain Request Fingerprint",
+			Key:  "key-1",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("CreateUser", mock.MatchedBy(func(ctx context.Context) bool {
+					key, sent, _ := idempotency.FromContext(ctx)
+					return key == "key-1" && sent == fingerprint
+				}), mock.MatchedBy(func(rq entities.CreateUserRequest) bool {
+					return rq.Pass != "123"
+				})).Return(entities.CreateUserResponse{UserId: "user-1"}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), "user-1")
+			},
+		},
+		{
+			Name: "Key Reused With Another Body",
+			Key:  "key-1",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("CreateUser", mock.Anything, mock.Anything).
+					Return(entities.CreateUserResponse{}, myerr.NewIdempotencyKeyReused().GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+				assert.Contains(t, rec.Body.String(), myerr.NewIdempotencyKeyReused().Error())
+				assert.NotContains(t, rec.Body.String(), "rpc error")
+			},
+		},
+		{
+			Name: "First Request Still In Progress",
+			Key:  "key-1",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("CreateUser", mock.Anything, mock.Anything).
+					Return(entities.CreateUserResponse{}, myerr.NewRequestInProgress().GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			Name: "Replayed Error",
+			Key:  "key-1",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("CreateUser", mock.Anything, mock.Anything).
+					Return(entities.CreateUserResponse{}, status.Error(codes.AlreadyExists, "user already exists in database"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			Name:      "Key Too Long",
+			Key:       strings.Repeat("k", idempotency.MaxKeyLength+1),
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(http.MethodPost, "/user", bytes.NewBufferString(body))
+			req.Header.Set(user.IdempotencyKeyHeader, tc.Key)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package user
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+// Impersonation sessions are started by admins, the roles of the caller
+// are checked by the gRPC service.
+
+func (s *service) Impersonate(ctx context.Context, rq entities.ImpersonateRequest) (entities.ImpersonateResponse, error) {
+	logger := log.With(s.Logger, "impersonate request", "recevied")
+
+	res, err := s.Repo.Impersonate(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ImpersonateResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) EndImpersonation(ctx context.Context, rq entities.EndImpersonationRequest) (entities.EndImpersonationResponse, error) {
+	logger := log.With(s.Logger, "end impersonation request", "recevied")
+
+	res, err := s.Repo.EndImpersonation(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.EndImpersonationResponse{}, err
+	}
+
+	return res, nil
+}
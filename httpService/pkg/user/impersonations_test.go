@@ -0,0 +1,159 @@
+package user_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/impersonation"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestImpersonationRoutes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	adminId := "0190f3b2-7c1e-7d3a-9b4f-000000000001"
+	userId := "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"
+	sessionId := "7d1f2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"
+
+	signer, _ := impersonation.GenerateSigner()
+	token, _ := signer.Sign(impersonation.Claims{SessionId: sessionId, Actor: adminId, Subject: userId, Tenant: "default", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	session := entities.ImpersonationSession{Id: sessionId, Actor: adminId, UserId: userId, Reason: "ticket 4521"}
+
+	impersonating := func(ctx context.Context) bool {
+		caller := audit.CallerFromContext(ctx)
+		return caller.Actor == adminId && caller.ImpersonationToken == token
+	}
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Header         map[string]string
+		Body           string
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Impersonate",
+			Method: http.MethodPost,
+			Target: "/impersonations",
+			Header: map[string]string{user.ActorHeader: adminId, user.RolesHeader: "admin"},
+			Body:   `{"UserId":"` + userId + `","Reason":"ticket 4521","TtlSeconds":600}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("Impersonate", mock.MatchedBy(func(ctx context.Context) bool {
+					return audit.CallerFromContext(ctx).HasRole("admin")
+				}), entities.ImpersonateRequest{UserId: userId, Reason: "ticket 4521", TtlSeconds: 600}).
+					Return(entities.ImpersonateResponse{Token: token, Session: session}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Token":"`+token+`"`)
+			},
+		},
+		{
+			Name:   "Impersonate Without Admin Role",
+			Method: http.MethodPost,
+			Target: "/impersonations",
+			Header: map[string]string{user.ActorHeader: adminId},
+			Body:   `{"UserId":"` + userId + `","Reason":"ticket 4521"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("Impersonate", mock.Anything, mock.Anything).
+					Return(entities.ImpersonateResponse{}, myerr.NewForbidden("only admins can impersonate users").GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			Name:      "Negative TTL",
+			Method:    http.MethodPost,
+			Target:    "/impersonations",
+			Header:    map[string]string{user.ActorHeader: adminId, user.RolesHeader: "admin"},
+			Body:      `{"UserId":"` + userId + `","Reason":"ticket 4521","TtlSeconds":-600}`,
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.Contains(t, rec.Body.String(), "ttl_seconds")
+			},
+		},
+		{
+			Name:   "End Impersonation",
+			Method: http.MethodPost,
+			Target: "/impersonations/" + sessionId + ":end",
+			Header: map[string]string{user.ActorHeader: adminId, user.ImpersonationTokenHeader: token},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("EndImpersonation", mock.MatchedBy(impersonating), entities.EndImpersonationRequest{SessionId: sessionId}).
+					Return(entities.EndImpersonationResponse{Session: session}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Me As The Impersonated User",
+			Method: http.MethodGet,
+			Target: "/me/preferences",
+			Header: map[string]string{user.ActorHeader: adminId, user.UserIdHeader: adminId, user.ImpersonationTokenHeader: token},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetPreferences", mock.MatchedBy(impersonating), entities.GetPreferencesRequest{UserId: userId}).
+					Return(entities.GetPreferencesResponse{}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:      "Malformed Token",
+			Method:    http.MethodGet,
+			Target:    "/me/preferences",
+			Header:    map[string]string{user.ActorHeader: adminId, user.ImpersonationTokenHeader: "not-a-token"},
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			Name:   "Deleting While Impersonating",
+			Method: http.MethodDelete,
+			Target: "/user/" + userId,
+			Header: map[string]string{user.ActorHeader: adminId, user.ImpersonationTokenHeader: token},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("DeleteUser", mock.MatchedBy(impersonating), mock.Anything).
+					Return(entities.DeleteUserResponse{}, myerr.NewForbidden("DeleteUser is not allowed while impersonating a user").GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, tc.Target, strings.NewReader(tc.Body))
+			for key, value := range tc.Header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
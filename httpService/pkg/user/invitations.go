@@ -0,0 +1,87 @@
+package user
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errs "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+// Invitations are kept by the gRPC service, which validates the requests.
+// Only the password of an invitee is handled here, it is hashed before it
+// leaves the gateway, as on CreateUser.
+
+func (s *service) InviteUser(ctx context.Context, rq entities.InviteUserRequest) (entities.InviteUserResponse, error) {
+	logger := log.With(s.Logger, "invite user request", "recevied")
+
+	res, err := s.Repo.InviteUser(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.InviteUserResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) AcceptInvitation(ctx context.Context, rq entities.AcceptInvitationRequest) (entities.AcceptInvitationResponse, error) {
+	logger := log.With(s.Logger, "accept invitation request", "recevied")
+
+	if rq.Token == "" || rq.Name == "" || rq.Pass == "" {
+		return entities.AcceptInvitationResponse{}, errs.NewFieldsMissing()
+	}
+
+	var err error
+	rq.Pass, err = util.HashPassword(rq.Pass)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.AcceptInvitationResponse{}, err
+	}
+
+	res, err := s.Repo.AcceptInvitation(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.AcceptInvitationResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ListInvitations(ctx context.Context, rq entities.ListInvitationsRequest) (entities.ListInvitationsResponse, error) {
+	logger := log.With(s.Logger, "list invitations request", "recevied")
+
+	res, err := s.Repo.ListInvitations(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListInvitationsResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) RevokeInvitation(ctx context.Context, rq entities.RevokeInvitationRequest) (entities.RevokeInvitationResponse, error) {
+	logger := log.With(s.Logger, "revoke invitation request", "recevied")
+
+	res, err := s.Repo.RevokeInvitation(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RevokeInvitationResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ResendInvitation(ctx context.Context, rq entities.ResendInvitationRequest) (entities.ResendInvitationResponse, error) {
+	logger := log.With(s.Logger, "resend invitation request", "recevied")
+
+	res, err := s.Repo.ResendInvitation(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ResendInvitationResponse{}, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,139 @@
+package user_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestInvitationRoutes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Body           string
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Invite User",
+			Method: http.MethodPost,
+			Target: "/invitations",
+			Body:   `{"Email":"timoteo@globant.com","Roles":["admin"],"GroupId":"group-1"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("InviteUser", mock.Anything, entities.InviteUserRequest{Email: "timoteo@globant.com", Roles: []string{"admin"}, GroupId: "group-1"}).
+					Return(entities.InviteUserResponse{Invitation: entities.Invitation{Id: "invitation-1", Status: entities.InvitationPending}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Status":"pending"`)
+			},
+		},
+		{
+			Name:   "Invite Existing User",
+			Method: http.MethodPost,
+			Target: "/invitations",
+			Body:   `{"Email":"timoteo@globant.com"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("InviteUser", mock.Anything, mock.Anything).
+					Return(entities.InviteUserResponse{}, status.Error(codes.AlreadyExists, "user already exists"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			Name:   "List Invitations",
+			Method: http.MethodGet,
+			Target: "/invitations?page_size=10",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ListInvitations", mock.Anything, entities.ListInvitationsRequest{PageSize: 10}).
+					Return(entities.ListInvitationsResponse{Invitations: []entities.Invitation{{Id: "invitation-1", Status: entities.InvitationExpired}}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"Status":"expired"`)
+			},
+		},
+		{
+			Name:   "Accept Invitation",
+			Method: http.MethodPost,
+			Target: "/invitations:accept",
+			Body:   `{"Token":"token","Name":"Timo","Pass":"secret"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("AcceptInvitation", mock.Anything, mock.MatchedBy(func(rq entities.AcceptInvitationRequest) bool {
+					return rq.Token == "token" && rq.Name == "Timo" && bcrypt.CompareHashAndPassword([]byte(rq.Pass), []byte("secret")) == nil
+				})).Return(entities.AcceptInvitationResponse{UserId: "user-1", Roles: []string{"support"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"UserId":"user-1"`)
+				assert.Contains(t, rec.Body.String(), `"Roles":["support"]`)
+			},
+		},
+		{
+			Name:      "Accept Without Password",
+			Method:    http.MethodPost,
+			Target:    "/invitations:accept",
+			Body:      `{"Token":"token","Name":"Timo"}`,
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:   "Revoke Accepted Invitation",
+			Method: http.MethodPost,
+			Target: "/invitations/invitation-1:revoke",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("RevokeInvitation", mock.Anything, entities.RevokeInvitationRequest{InvitationId: "invitation-1"}).
+					Return(entities.RevokeInvitationResponse{}, status.Error(codes.FailedPrecondition, "the invitation was accepted"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+			},
+		},
+		{
+			Name:   "Resend Invitation",
+			Method: http.MethodPost,
+			Target: "/invitations/invitation-1:resend",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ResendInvitation", mock.Anything, entities.ResendInvitationRequest{InvitationId: "invitation-1"}).
+					Return(entities.ResendInvitationResponse{Invitation: entities.Invitation{Id: "invitation-1"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, tc.Target, strings.NewReader(tc.Body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
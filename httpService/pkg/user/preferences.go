@@ -0,0 +1,36 @@
+package user
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+)
+
+// Preferences are checked against the settings the gRPC service knows.
+
+func (s *service) GetPreferences(ctx context.Context, rq entities.GetPreferencesRequest) (entities.GetPreferencesResponse, error) {
+	logger := log.With(s.Logger, "get preferences request", "recevied")
+
+	res, err := s.Repo.GetPreferences(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.GetPreferencesResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) UpdatePreferences(ctx context.Context, rq entities.UpdatePreferencesRequest) (entities.UpdatePreferencesResponse, error) {
+	logger := log.With(s.Logger, "update preferences request", "recevied")
+
+	res, err := s.Repo.UpdatePreferences(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.UpdatePreferencesResponse{}, err
+	}
+
+	return res, nil
+}
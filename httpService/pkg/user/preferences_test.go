@@ -0,0 +1,127 @@
+package user_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestPreferenceRoutes(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	userId := "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Header         map[string]string
+		Body           string
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Get Preferences",
+			Method: http.MethodGet,
+			Target: "/user/" + userId + "/preferences",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetPreferences", mock.Anything, entities.GetPreferencesRequest{UserId: userId}).
+					Return(entities.GetPreferencesResponse{Preferences: map[string]interface{}{"locale": "es-AR", "theme": "system"}, Customized: []string{"locale"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"locale":"es-AR"`)
+				assert.Contains(t, rec.Body.String(), `"Customized":["locale"]`)
+			},
+		},
+		{
+			Name:   "Update Preferences Of Caller",
+			Method: http.MethodPatch,
+			Target: "/me/preferences",
+			Header: map[string]string{user.UserIdHeader: userId},
+			Body:   `{"timezone":"Asia/Tokyo","theme":null}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("UpdatePreferences", mock.Anything, entities.UpdatePreferencesRequest{UserId: userId, Preferences: map[string]interface{}{"timezone": "Asia/Tokyo", "theme": nil}}).
+					Return(entities.UpdatePreferencesResponse{Preferences: map[string]interface{}{"timezone": "Asia/Tokyo", "theme": "system"}, Customized: []string{"timezone"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"timezone":"Asia/Tokyo"`)
+			},
+		},
+		{
+			Name:   "Invalid Preference",
+			Method: http.MethodPatch,
+			Target: "/user/" + userId + "/preferences",
+			Body:   `{"timezone":"Nowhere/City"}`,
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("UpdatePreferences", mock.Anything, mock.Anything).
+					Return(entities.UpdatePreferencesResponse{}, status.Error(codes.InvalidArgument, "timezone must be an IANA timezone"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:      "Body Is Not An Object",
+			Method:    http.MethodPatch,
+			Target:    "/user/" + userId + "/preferences",
+			Body:      `["locale"]`,
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:      "Body Too Large",
+			Method:    http.MethodPatch,
+			Target:    "/user/" + userId + "/preferences",
+			Body:      `{"locale":"` + strings.Repeat("a", 17<<10) + `"}`,
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:   "Unknown User",
+			Method: http.MethodGet,
+			Target: "/user/" + userId + "/preferences",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetPreferences", mock.Anything, mock.Anything).
+					Return(entities.GetPreferencesResponse{}, status.Error(codes.NotFound, "user not found"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, tc.Target, strings.NewReader(tc.Body))
+			for key, value := range tc.Header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
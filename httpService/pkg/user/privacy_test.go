@@ -0,0 +1,113 @@
+package user_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestUserPrivacy(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Header         http.Header
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Admin Downloads A User's Data",
+			Method: http.MethodGet,
+			Target: "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b/data",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ExportUserData", mock.Anything, entities.ExportUserDataRequest{UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}).
+					Return(entities.ExportUserDataResponse{Archive: []byte("PK"), FileName: "user-0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b-data.zip"}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+				assert.Equal(t, `attachment; filename="user-0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b-data.zip"`, rec.Header().Get("Content-Disposition"))
+				assert.Equal(t, "PK", rec.Body.String())
+			},
+		},
+		{
+			Name:   "User Downloads Their Own Data",
+			Method: http.MethodGet,
+			Target: "/me/data",
+			Header: http.Header{user.UserIdHeader: {"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c"}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("ExportUserData", mock.Anything, entities.ExportUserDataRequest{UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c"}).
+					Return(entities.ExportUserDataResponse{Archive: []byte("PK"), FileName: "user-0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c-data.zip"}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:      "Unknown Caller",
+			Method:    http.MethodPost,
+			Target:    "/me/erasure",
+			buildMock: func(repo *util.RepositoryMock) {},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			Name:   "Erasure Returns The Receipt",
+			Method: http.MethodPost,
+			Target: "/me/erasure",
+			Header: http.Header{user.UserIdHeader: {"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c"}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("EraseUser", mock.Anything, entities.EraseUserRequest{UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c"}).
+					Return(entities.EraseUserResponse{Receipt: entities.ErasureReceipt{Id: "receipt-1", UserId: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c", Signature: "c2ln"}}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"user_id":"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6c"`)
+				assert.Contains(t, rec.Body.String(), `"signature":"c2ln"`)
+			},
+		},
+		{
+			Name:   "Erasure Of A Missing User",
+			Method: http.MethodPost,
+			Target: "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b/erasure",
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("EraseUser", mock.Anything, mock.Anything).
+					Return(entities.EraseUserResponse{}, myerr.NewUserNotFound().GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+			req := httptest.NewRequest(tc.Method, tc.Target, nil)
+			for name, values := range tc.Header {
+				req.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
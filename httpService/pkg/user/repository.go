@@ -2,10 +2,12 @@ package user
 
 import (
 	"context"
+	"io"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/idempotency"
 	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
 
 	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
@@ -28,7 +30,7 @@ func (repo *grpcClient) CreateUser(ctx context.Context, rq entities.CreateUserRe
 
 	protoReq := util.CreateToProto(rq)
 
-	resp, err := client.CreateUser(ctx, protoReq)
+	resp, err := client.CreateUser(idempotency.ToOutgoingContext(ctx), protoReq)
 	if err != nil {
 		level.Error(logger).Log("error", err.Error())
 		return entities.CreateUserResponse{}, err
@@ -76,3 +78,740 @@ func (repo *grpcClient) DeleteUser(ctx context.Context, rq entities.DeleteUserRe
 	return ret, nil
 
 }
+
+// WatchUsers relays the WatchUsers stream of the gRPC service to send
+// until the stream ends, ctx is cancelled or send fails.
+func (repo *grpcClient) WatchUsers(ctx context.Context, rq entities.WatchRequest, send func(entities.WatchEvent) error) error {
+	logger := log.With(repo.logger, "watch users request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.WatchUsers(ctx, util.WatchToProto(rq))
+	if err != nil {
+		level.Error(logger).Log(err)
+		return err
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			level.Error(logger).Log(err)
+			return err
+		}
+
+		if err := send(util.WatchFromProto(resp)); err != nil {
+			return err
+		}
+	}
+}
+
+// importChunkBytes is how much of an import file goes in one message.
+const importChunkBytes = 64 << 10
+
+// ImportUsers sends body to the ImportUsers stream of the gRPC service a
+// chunk at a time and returns its report. When body can't be read to the
+// end the stream is cancelled rather than closed, so the service doesn't
+// take the part it got for the whole file.
+func (repo *grpcClient) ImportUsers(ctx context.Context, opts entities.ImportOptions, body io.Reader) (entities.ImportUsersResponse, error) {
+	logger := log.With(repo.logger, "import users request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.ImportUsers(ctx)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ImportUsersResponse{}, err
+	}
+
+	// Send returns io.EOF once the service has ended the stream, the reason
+	// comes with CloseAndRecv.
+	err = stream.Send(&proto.ImportUsersRequest{Options: util.ImportOptionsToProto(opts)})
+	for err == nil {
+		chunk := make([]byte, importChunkBytes)
+
+		n, readErr := io.ReadFull(body, chunk)
+		if n > 0 {
+			err = stream.Send(&proto.ImportUsersRequest{Chunk: chunk[:n]})
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			level.Error(logger).Log(readErr)
+			return entities.ImportUsersResponse{}, readErr
+		}
+	}
+	if err != nil && err != io.EOF {
+		level.Error(logger).Log(err)
+		return entities.ImportUsersResponse{}, err
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ImportUsersResponse{}, err
+	}
+
+	return util.ImportFromProto(resp), nil
+}
+
+// ExportUsers relays the ExportUsers stream of the gRPC service to send
+// until it ends, ctx is cancelled or send fails.
+func (repo *grpcClient) ExportUsers(ctx context.Context, rq entities.ExportUsersRequest, send func(entities.ExportChunk) error) error {
+	logger := log.With(repo.logger, "export users request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.ExportUsers(ctx, &proto.ExportUsersRequest{Format: rq.Format, After_Id: rq.AfterId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return err
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			level.Error(logger).Log(err)
+			return err
+		}
+
+		if err := send(entities.ExportChunk{Data: resp.Chunk, LastId: resp.Last_Id}); err != nil {
+			return err
+		}
+	}
+}
+
+func (repo *grpcClient) BatchCreateUsers(ctx context.Context, rq entities.BatchCreateUsersRequest) (entities.BatchCreateUsersResponse, error) {
+	logger := log.With(repo.logger, "batch create users request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	resp, err := client.BatchCreateUsers(ctx, util.BatchCreateToProto(rq))
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.BatchCreateUsersResponse{}, err
+	}
+
+	return util.BatchCreateFromProto(resp), nil
+}
+
+func (repo *grpcClient) BatchGetUsers(ctx context.Context, rq entities.BatchGetUsersRequest) (entities.BatchGetUsersResponse, error) {
+	logger := log.With(repo.logger, "batch get users request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	resp, err := client.BatchGetUsers(ctx, &proto.BatchGetUsersRequest{User_Ids: rq.UserIds})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.BatchGetUsersResponse{}, err
+	}
+
+	return util.BatchGetFromProto(resp), nil
+}
+
+func (repo *grpcClient) BatchDeleteUsers(ctx context.Context, rq entities.BatchDeleteUsersRequest) (entities.BatchDeleteUsersResponse, error) {
+	logger := log.With(repo.logger, "batch delete users request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	resp, err := client.BatchDeleteUsers(ctx, &proto.BatchDeleteUsersRequest{User_Ids: rq.UserIds, All_Or_Nothing: rq.AllOrNothing})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.BatchDeleteUsersResponse{}, err
+	}
+
+	return util.BatchDeleteFromProto(resp), nil
+}
+
+func (repo *grpcClient) ListAuditEvents(ctx context.Context, rq entities.ListAuditEventsRequest) (entities.ListAuditEventsResponse, error) {
+	logger := log.With(repo.logger, "list audit events request", "received")
+
+	client := proto.NewAuditServiceClient(repo.server)
+
+	resp, err := client.ListAuditEvents(ctx, util.ListAuditEventsToProto(rq))
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListAuditEventsResponse{}, err
+	}
+
+	return util.ListAuditEventsFromProto(resp), nil
+}
+
+func (repo *grpcClient) VerifyAuditLog(ctx context.Context, rq entities.VerifyAuditLogRequest) (entities.VerifyAuditLogResponse, error) {
+	logger := log.With(repo.logger, "verify audit log request", "received")
+
+	client := proto.NewAuditServiceClient(repo.server)
+
+	resp, err := client.VerifyAuditLog(ctx, &proto.VerifyAuditLogRequest{Legacy: rq.Legacy})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.VerifyAuditLogResponse{}, err
+	}
+
+	return util.VerifyAuditLogFromProto(resp), nil
+}
+
+func (repo *grpcClient) ExportUserData(ctx context.Context, rq entities.ExportUserDataRequest) (entities.ExportUserDataResponse, error) {
+	logger := log.With(repo.logger, "export user data request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	resp, err := client.ExportUserData(ctx, &proto.ExportUserDataRequest{User_Id: rq.UserId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ExportUserDataResponse{}, err
+	}
+
+	return entities.ExportUserDataResponse{Archive: resp.Archive, FileName: resp.File_Name}, nil
+}
+
+func (repo *grpcClient) EraseUser(ctx context.Context, rq entities.EraseUserRequest) (entities.EraseUserResponse, error) {
+	logger := log.With(repo.logger, "erase user request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	resp, err := client.EraseUser(ctx, &proto.EraseUserRequest{User_Id: rq.UserId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.EraseUserResponse{}, err
+	}
+
+	return entities.EraseUserResponse{Receipt: util.ErasureReceiptFromProto(resp.Receipt)}, nil
+}
+
+func (repo *grpcClient) CreateGroup(ctx context.Context, rq entities.CreateGroupRequest) (entities.CreateGroupResponse, error) {
+	logger := log.With(repo.logger, "create group request", "received")
+
+	client := proto.NewGroupServiceClient(repo.server)
+
+	resp, err := client.CreateGroup(ctx, &proto.CreateGroupRequest{Name: rq.Name, Owner_Id: rq.OwnerId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.CreateGroupResponse{}, err
+	}
+
+	return util.CreateGroupFromProto(resp), nil
+}
+
+func (repo *grpcClient) RenameGroup(ctx context.Context, rq entities.RenameGroupRequest) (entities.RenameGroupResponse, error) {
+	logger := log.With(repo.logger, "rename group request", "received")
+
+	client := proto.NewGroupServiceClient(repo.server)
+
+	resp, err := client.RenameGroup(ctx, &proto.RenameGroupRequest{Group_Id: rq.GroupId, Name: rq.Name})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RenameGroupResponse{}, err
+	}
+
+	return util.RenameGroupFromProto(resp), nil
+}
+
+func (repo *grpcClient) DeleteGroup(ctx context.Context, rq entities.DeleteGroupRequest) (entities.DeleteGroupResponse, error) {
+	logger := log.With(repo.logger, "delete group request", "received")
+
+	client := proto.NewGroupServiceClient(repo.server)
+
+	resp, err := client.DeleteGroup(ctx, &proto.DeleteGroupRequest{Group_Id: rq.GroupId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.DeleteGroupResponse{}, err
+	}
+
+	return util.DeleteGroupFromProto(resp), nil
+}
+
+func (repo *grpcClient) ListGroups(ctx context.Context, rq entities.ListGroupsRequest) (entities.ListGroupsResponse, error) {
+	logger := log.With(repo.logger, "list groups request", "received")
+
+	client := proto.NewGroupServiceClient(repo.server)
+
+	resp, err := client.ListGroups(ctx, &proto.ListGroupsRequest{Page_Size: rq.PageSize, Page_Token: rq.PageToken})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListGroupsResponse{}, err
+	}
+
+	return util.ListGroupsFromProto(resp), nil
+}
+
+func (repo *grpcClient) AddMember(ctx context.Context, rq entities.AddMemberRequest) (entities.AddMemberResponse, error) {
+	logger := log.With(repo.logger, "add member request", "received")
+
+	client := proto.NewGroupServiceClient(repo.server)
+
+	resp, err := client.AddMember(ctx, &proto.AddMemberRequest{Group_Id: rq.GroupId, User_Id: rq.UserId, Role: rq.Role})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.AddMemberResponse{}, err
+	}
+
+	return util.AddMemberFromProto(resp), nil
+}
+
+func (repo *grpcClient) RemoveMember(ctx context.Context, rq entities.RemoveMemberRequest) (entities.RemoveMemberResponse, error) {
+	logger := log.With(repo.logger, "remove member request", "received")
+
+	client := proto.NewGroupServiceClient(repo.server)
+
+	resp, err := client.RemoveMember(ctx, &proto.RemoveMemberRequest{Group_Id: rq.GroupId, User_Id: rq.UserId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RemoveMemberResponse{}, err
+	}
+
+	return util.RemoveMemberFromProto(resp), nil
+}
+
+func (repo *grpcClient) ListMembers(ctx context.Context, rq entities.ListMembersRequest) (entities.ListMembersResponse, error) {
+	logger := log.With(repo.logger, "list members request", "received")
+
+	client := proto.NewGroupServiceClient(repo.server)
+
+	resp, err := client.ListMembers(ctx, &proto.ListMembersRequest{Group_Id: rq.GroupId, Page_Size: rq.PageSize, Page_Token: rq.PageToken})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListMembersResponse{}, err
+	}
+
+	return util.ListMembersFromProto(resp), nil
+}
+
+func (repo *grpcClient) ListUserGroups(ctx context.Context, rq entities.ListUserGroupsRequest) (entities.ListUserGroupsResponse, error) {
+	logger := log.With(repo.logger, "list user groups request", "received")
+
+	client := proto.NewGroupServiceClient(repo.server)
+
+	resp, err := client.ListUserGroups(ctx, &proto.ListUserGroupsRequest{User_Id: rq.UserId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListUserGroupsResponse{}, err
+	}
+
+	return util.ListUserGroupsFromProto(resp), nil
+}
+
+func (repo *grpcClient) InviteUser(ctx context.Context, rq entities.InviteUserRequest) (entities.InviteUserResponse, error) {
+	logger := log.With(repo.logger, "invite user request", "received")
+
+	client := proto.NewInvitationServiceClient(repo.server)
+
+	resp, err := client.InviteUser(ctx, &proto.InviteUserRequest{Email: rq.Email, Roles: rq.Roles, Group_Id: rq.GroupId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.InviteUserResponse{}, err
+	}
+
+	return util.InviteUserFromProto(resp), nil
+}
+
+func (repo *grpcClient) AcceptInvitation(ctx context.Context, rq entities.AcceptInvitationRequest) (entities.AcceptInvitationResponse, error) {
+	logger := log.With(repo.logger, "accept invitation request", "received")
+
+	client := proto.NewInvitationServiceClient(repo.server)
+
+	resp, err := client.AcceptInvitation(ctx, &proto.AcceptInvitationRequest{Token: rq.Token, Name: rq.Name, Pass: rq.Pass})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.AcceptInvitationResponse{}, err
+	}
+
+	return util.AcceptInvitationFromProto(resp), nil
+}
+
+func (repo *grpcClient) ListInvitations(ctx context.Context, rq entities.ListInvitationsRequest) (entities.ListInvitationsResponse, error) {
+	logger := log.With(repo.logger, "list invitations request", "received")
+
+	client := proto.NewInvitationServiceClient(repo.server)
+
+	resp, err := client.ListInvitations(ctx, &proto.ListInvitationsRequest{Page_Size: rq.PageSize, Page_Token: rq.PageToken})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListInvitationsResponse{}, err
+	}
+
+	return util.ListInvitationsFromProto(resp), nil
+}
+
+func (repo *grpcClient) RevokeInvitation(ctx context.Context, rq entities.RevokeInvitationRequest) (entities.RevokeInvitationResponse, error) {
+	logger := log.With(repo.logger, "revoke invitation request", "received")
+
+	client := proto.NewInvitationServiceClient(repo.server)
+
+	resp, err := client.RevokeInvitation(ctx, &proto.RevokeInvitationRequest{Invitation_Id: rq.InvitationId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RevokeInvitationResponse{}, err
+	}
+
+	return util.RevokeInvitationFromProto(resp), nil
+}
+
+func (repo *grpcClient) ResendInvitation(ctx context.Context, rq entities.ResendInvitationRequest) (entities.ResendInvitationResponse, error) {
+	logger := log.With(repo.logger, "resend invitation request", "received")
+
+	client := proto.NewInvitationServiceClient(repo.server)
+
+	resp, err := client.ResendInvitation(ctx, &proto.ResendInvitationRequest{Invitation_Id: rq.InvitationId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ResendInvitationResponse{}, err
+	}
+
+	return util.ResendInvitationFromProto(resp), nil
+}
+
+func (repo *grpcClient) UpdateUserAttributes(ctx context.Context, rq entities.UpdateUserAttributesRequest) (entities.UpdateUserAttributesResponse, error) {
+	logger := log.With(repo.logger, "update user attributes request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	resp, err := client.UpdateUserAttributes(ctx, &proto.UpdateUserAttributesRequest{
+		User_Id:    rq.UserId,
+		Attributes: util.AttributesToProto(rq.Attributes),
+		If_Match:   rq.IfMatch,
+	})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.UpdateUserAttributesResponse{}, err
+	}
+
+	return util.UpdateUserAttributesFromProto(resp), nil
+}
+
+func (repo *grpcClient) ListUsers(ctx context.Context, rq entities.ListUsersRequest) (entities.ListUsersResponse, error) {
+	logger := log.With(repo.logger, "list users request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	resp, err := client.ListUsers(ctx, &proto.ListUsersRequest{Page_Size: rq.PageSize, Page_Token: rq.PageToken, Filters: rq.Filters})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListUsersResponse{}, err
+	}
+
+	return util.ListUsersFromProto(resp), nil
+}
+
+func (repo *grpcClient) GetAttributeSchema(ctx context.Context, rq entities.GetAttributeSchemaRequest) (entities.GetAttributeSchemaResponse, error) {
+	logger := log.With(repo.logger, "get attribute schema request", "received")
+
+	client := proto.NewAttributeSchemaServiceClient(repo.server)
+
+	resp, err := client.GetAttributeSchema(ctx, &proto.GetAttributeSchemaRequest{})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.GetAttributeSchemaResponse{}, err
+	}
+
+	return util.GetAttributeSchemaFromProto(resp), nil
+}
+
+func (repo *grpcClient) SetAttributeSchema(ctx context.Context, rq entities.SetAttributeSchemaRequest) (entities.SetAttributeSchemaResponse, error) {
+	logger := log.With(repo.logger, "set attribute schema request", "received")
+
+	client := proto.NewAttributeSchemaServiceClient(repo.server)
+
+	resp, err := client.SetAttributeSchema(ctx, &proto.SetAttributeSchemaRequest{Schema: rq.Schema})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.SetAttributeSchemaResponse{}, err
+	}
+
+	return util.SetAttributeSchemaFromProto(resp), nil
+}
+
+// UploadAvatar streams body to the UploadAvatar RPC, the first message
+// names the user and every one after carries a chunk of the picture.
+func (repo *grpcClient) UploadAvatar(ctx context.Context, rq entities.UploadAvatarRequest, body io.Reader) (entities.UploadAvatarResponse, error) {
+	logger := log.With(repo.logger, "upload avatar request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.UploadAvatar(ctx)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.UploadAvatarResponse{}, err
+	}
+
+	// Send returns io.EOF once the service has ended the stream, the reason
+	// comes with CloseAndRecv.
+	err = stream.Send(&proto.UploadAvatarRequest{User_Id: rq.UserId})
+	for err == nil {
+		chunk := make([]byte, importChunkBytes)
+
+		n, readErr := io.ReadFull(body, chunk)
+		if n > 0 {
+			err = stream.Send(&proto.UploadAvatarRequest{Chunk: chunk[:n]})
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			level.Error(logger).Log(readErr)
+			return entities.UploadAvatarResponse{}, readErr
+		}
+	}
+	if err != nil && err != io.EOF {
+		level.Error(logger).Log(err)
+		return entities.UploadAvatarResponse{}, err
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.UploadAvatarResponse{}, err
+	}
+
+	return util.UploadAvatarFromProto(resp), nil
+}
+
+func (repo *grpcClient) GetAvatar(ctx context.Context, rq entities.GetAvatarRequest) (entities.GetAvatarResponse, error) {
+	logger := log.With(repo.logger, "get avatar request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	resp, err := client.GetAvatar(ctx, &proto.GetAvatarRequest{
+		User_Id:       rq.UserId,
+		Size:          rq.Size,
+		If_None_Match: rq.IfNoneMatch,
+	})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.GetAvatarResponse{}, err
+	}
+
+	return util.GetAvatarFromProto(resp), nil
+}
+
+func (repo *grpcClient) PublishPolicy(ctx context.Context, rq entities.PublishPolicyRequest) (entities.PublishPolicyResponse, error) {
+	logger := log.With(repo.logger, "publish policy request", "received")
+
+	client := proto.NewConsentServiceClient(repo.server)
+
+	resp, err := client.PublishPolicy(ctx, &proto.PublishPolicyRequest{Kind: rq.Kind, Version: rq.Version, Url: rq.Url, Mandatory: rq.Mandatory})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.PublishPolicyResponse{}, err
+	}
+
+	return util.PublishPolicyFromProto(resp), nil
+}
+
+func (repo *grpcClient) ListPolicies(ctx context.Context, rq entities.ListPoliciesRequest) (entities.ListPoliciesResponse, error) {
+	logger := log.With(repo.logger, "list policies request", "received")
+
+	client := proto.NewConsentServiceClient(repo.server)
+
+	resp, err := client.ListPolicies(ctx, &proto.ListPoliciesRequest{Kind: rq.Kind})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListPoliciesResponse{}, err
+	}
+
+	return entities.ListPoliciesResponse{Policies: util.PoliciesFromProto(resp.Policies)}, nil
+}
+
+func (repo *grpcClient) AcceptPolicy(ctx context.Context, rq entities.AcceptPolicyRequest) (entities.AcceptPolicyResponse, error) {
+	logger := log.With(repo.logger, "accept policy request", "received")
+
+	client := proto.NewConsentServiceClient(repo.server)
+
+	resp, err := client.AcceptPolicy(ctx, &proto.AcceptPolicyRequest{User_Id: rq.UserId, Kind: rq.Kind, Version: rq.Version, Source: rq.Source})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.AcceptPolicyResponse{}, err
+	}
+
+	return entities.AcceptPolicyResponse{Consent: util.ConsentFromProto(resp.Consent)}, nil
+}
+
+func (repo *grpcClient) ListConsents(ctx context.Context, rq entities.ListConsentsRequest) (entities.ListConsentsResponse, error) {
+	logger := log.With(repo.logger, "list consents request", "received")
+
+	client := proto.NewConsentServiceClient(repo.server)
+
+	resp, err := client.ListConsents(ctx, &proto.ListConsentsRequest{User_Id: rq.UserId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListConsentsResponse{}, err
+	}
+
+	return util.ListConsentsFromProto(resp), nil
+}
+
+func (repo *grpcClient) WithdrawConsent(ctx context.Context, rq entities.WithdrawConsentRequest) (entities.WithdrawConsentResponse, error) {
+	logger := log.With(repo.logger, "withdraw consent request", "received")
+
+	client := proto.NewConsentServiceClient(repo.server)
+
+	resp, err := client.WithdrawConsent(ctx, &proto.WithdrawConsentRequest{User_Id: rq.UserId, Kind: rq.Kind, Source: rq.Source})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.WithdrawConsentResponse{}, err
+	}
+
+	return entities.WithdrawConsentResponse{Consent: util.ConsentFromProto(resp.Consent)}, nil
+}
+
+func (repo *grpcClient) Authenticate(ctx context.Context, rq entities.AuthenticateRequest) (entities.AuthenticateResponse, error) {
+	logger := log.With(repo.logger, "authenticate request", "received")
+
+	client := proto.NewUserServiceClient(repo.server)
+
+	resp, err := client.Authenticate(ctx, &proto.AuthenticateRequest{Email: rq.Email, Pass: rq.Pass})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.AuthenticateResponse{}, err
+	}
+
+	return util.AuthenticateFromProto(resp), nil
+}
+
+func (repo *grpcClient) GetPreferences(ctx context.Context, rq entities.GetPreferencesRequest) (entities.GetPreferencesResponse, error) {
+	logger := log.With(repo.logger, "get preferences request", "received")
+
+	client := proto.NewPreferenceServiceClient(repo.server)
+
+	resp, err := client.GetPreferences(ctx, &proto.GetPreferencesRequest{User_Id: rq.UserId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.GetPreferencesResponse{}, err
+	}
+
+	return util.GetPreferencesFromProto(resp), nil
+}
+
+func (repo *grpcClient) UpdatePreferences(ctx context.Context, rq entities.UpdatePreferencesRequest) (entities.UpdatePreferencesResponse, error) {
+	logger := log.With(repo.logger, "update preferences request", "received")
+
+	client := proto.NewPreferenceServiceClient(repo.server)
+
+	resp, err := client.UpdatePreferences(ctx, &proto.UpdatePreferencesRequest{
+		User_Id:     rq.UserId,
+		Preferences: util.PreferencesToProto(rq.Preferences),
+	})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.UpdatePreferencesResponse{}, err
+	}
+
+	return util.UpdatePreferencesFromProto(resp), nil
+}
+
+func (repo *grpcClient) Impersonate(ctx context.Context, rq entities.ImpersonateRequest) (entities.ImpersonateResponse, error) {
+	logger := log.With(repo.logger, "impersonate request", "received")
+
+	client := proto.NewImpersonationServiceClient(repo.server)
+
+	resp, err := client.Impersonate(ctx, &proto.ImpersonateRequest{
+		Target_User_Id: rq.UserId,
+		Reason:         rq.Reason,
+		Ttl_Seconds:    rq.TtlSeconds,
+	})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ImpersonateResponse{}, err
+	}
+
+	return util.ImpersonateFromProto(resp), nil
+}
+
+func (repo *grpcClient) EndImpersonation(ctx context.Context, rq entities.EndImpersonationRequest) (entities.EndImpersonationResponse, error) {
+	logger := log.With(repo.logger, "end impersonation request", "received")
+
+	client := proto.NewImpersonationServiceClient(repo.server)
+
+	resp, err := client.EndImpersonation(ctx, &proto.EndImpersonationRequest{Session_Id: rq.SessionId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.EndImpersonationResponse{}, err
+	}
+
+	return entities.EndImpersonationResponse{Session: util.ImpersonationSessionFromProto(resp.Session)}, nil
+}
+
+func (repo *grpcClient) CreateApiKey(ctx context.Context, rq entities.CreateApiKeyRequest) (entities.CreateApiKeyResponse, error) {
+	logger := log.With(repo.logger, "create api key request", "received")
+
+	client := proto.NewApiKeyServiceClient(repo.server)
+
+	resp, err := client.CreateApiKey(ctx, &proto.CreateApiKeyRequest{
+		Name:        rq.Name,
+		Scopes:      rq.Scopes,
+		Ttl_Seconds: rq.TtlSeconds,
+	})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.CreateApiKeyResponse{}, err
+	}
+
+	return entities.CreateApiKeyResponse{Key: util.ApiKeyFromProto(resp.Key), Secret: resp.Secret}, nil
+}
+
+func (repo *grpcClient) ListApiKeys(ctx context.Context, rq entities.ListApiKeysRequest) (entities.ListApiKeysResponse, error) {
+	logger := log.With(repo.logger, "list api keys request", "received")
+
+	client := proto.NewApiKeyServiceClient(repo.server)
+
+	resp, err := client.ListApiKeys(ctx, &proto.ListApiKeysRequest{})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListApiKeysResponse{}, err
+	}
+
+	return util.ListApiKeysFromProto(resp), nil
+}
+
+func (repo *grpcClient) RevokeApiKey(ctx context.Context, rq entities.RevokeApiKeyRequest) (entities.RevokeApiKeyResponse, error) {
+	logger := log.With(repo.logger, "revoke api key request", "received")
+
+	client := proto.NewApiKeyServiceClient(repo.server)
+
+	resp, err := client.RevokeApiKey(ctx, &proto.RevokeApiKeyRequest{Key_Id: rq.KeyId})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RevokeApiKeyResponse{}, err
+	}
+
+	return entities.RevokeApiKeyResponse{Key: util.ApiKeyFromProto(resp.Key)}, nil
+}
+
+func (repo *grpcClient) RotateApiKey(ctx context.Context, rq entities.RotateApiKeyRequest) (entities.RotateApiKeyResponse, error) {
+	logger := log.With(repo.logger, "rotate api key request", "received")
+
+	client := proto.NewApiKeyServiceClient(repo.server)
+
+	resp, err := client.RotateApiKey(ctx, &proto.RotateApiKeyRequest{
+		Key_Id:          rq.KeyId,
+		Overlap_Seconds: rq.OverlapSeconds,
+	})
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.RotateApiKeyResponse{}, err
+	}
+
+	return entities.RotateApiKeyResponse{
+		Key:      util.ApiKeyFromProto(resp.Key),
+		Secret:   resp.Secret,
+		Previous: util.ApiKeyFromProto(resp.Previous),
+	}, nil
+}
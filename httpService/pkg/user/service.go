@@ -2,10 +2,14 @@ package user
 
 import (
 	"context"
+	"io"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/bulk"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errs "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/idempotency"
 	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
 )
 
@@ -13,6 +17,49 @@ type Repository interface {
 	CreateUser(ctx context.Context, rq entities.CreateUserRequest) (entities.CreateUserResponse, error)
 	GetUser(ctx context.Context, rq entities.GetUserRequest) (entities.GetUserResponse, error)
 	DeleteUser(ctx context.Context, rq entities.DeleteUserRequest) (entities.DeleteUserResponse, error)
+	BatchCreateUsers(ctx context.Context, rq entities.BatchCreateUsersRequest) (entities.BatchCreateUsersResponse, error)
+	BatchGetUsers(ctx context.Context, rq entities.BatchGetUsersRequest) (entities.BatchGetUsersResponse, error)
+	BatchDeleteUsers(ctx context.Context, rq entities.BatchDeleteUsersRequest) (entities.BatchDeleteUsersResponse, error)
+	WatchUsers(ctx context.Context, rq entities.WatchRequest, send func(entities.WatchEvent) error) error
+	ImportUsers(ctx context.Context, opts entities.ImportOptions, body io.Reader) (entities.ImportUsersResponse, error)
+	ExportUsers(ctx context.Context, rq entities.ExportUsersRequest, send func(entities.ExportChunk) error) error
+	ListAuditEvents(ctx context.Context, rq entities.ListAuditEventsRequest) (entities.ListAuditEventsResponse, error)
+	VerifyAuditLog(ctx context.Context, rq entities.VerifyAuditLogRequest) (entities.VerifyAuditLogResponse, error)
+	ExportUserData(ctx context.Context, rq entities.ExportUserDataRequest) (entities.ExportUserDataResponse, error)
+	EraseUser(ctx context.Context, rq entities.EraseUserRequest) (entities.EraseUserResponse, error)
+	CreateGroup(ctx context.Context, rq entities.CreateGroupRequest) (entities.CreateGroupResponse, error)
+	RenameGroup(ctx context.Context, rq entities.RenameGroupRequest) (entities.RenameGroupResponse, error)
+	DeleteGroup(ctx context.Context, rq entities.DeleteGroupRequest) (entities.DeleteGroupResponse, error)
+	ListGroups(ctx context.Context, rq entities.ListGroupsRequest) (entities.ListGroupsResponse, error)
+	AddMember(ctx context.Context, rq entities.AddMemberRequest) (entities.AddMemberResponse, error)
+	RemoveMember(ctx context.Context, rq entities.RemoveMemberRequest) (entities.RemoveMemberResponse, error)
+	ListMembers(ctx context.Context, rq entities.ListMembersRequest) (entities.ListMembersResponse, error)
+	ListUserGroups(ctx context.Context, rq entities.ListUserGroupsRequest) (entities.ListUserGroupsResponse, error)
+	InviteUser(ctx context.Context, rq entities.InviteUserRequest) (entities.InviteUserResponse, error)
+	AcceptInvitation(ctx context.Context, rq entities.AcceptInvitationRequest) (entities.AcceptInvitationResponse, error)
+	ListInvitations(ctx context.Context, rq entities.ListInvitationsRequest) (entities.ListInvitationsResponse, error)
+	RevokeInvitation(ctx context.Context, rq entities.RevokeInvitationRequest) (entities.RevokeInvitationResponse, error)
+	ResendInvitation(ctx context.Context, rq entities.ResendInvitationRequest) (entities.ResendInvitationResponse, error)
+	UpdateUserAttributes(ctx context.Context, rq entities.UpdateUserAttributesRequest) (entities.UpdateUserAttributesResponse, error)
+	ListUsers(ctx context.Context, rq entities.ListUsersRequest) (entities.ListUsersResponse, error)
+	GetAttributeSchema(ctx context.Context, rq entities.GetAttributeSchemaRequest) (entities.GetAttributeSchemaResponse, error)
+	SetAttributeSchema(ctx context.Context, rq entities.SetAttributeSchemaRequest) (entities.SetAttributeSchemaResponse, error)
+	UploadAvatar(ctx context.Context, rq entities.UploadAvatarRequest, body io.Reader) (entities.UploadAvatarResponse, error)
+	GetAvatar(ctx context.Context, rq entities.GetAvatarRequest) (entities.GetAvatarResponse, error)
+	PublishPolicy(ctx context.Context, rq entities.PublishPolicyRequest) (entities.PublishPolicyResponse, error)
+	ListPolicies(ctx context.Context, rq entities.ListPoliciesRequest) (entities.ListPoliciesResponse, error)
+	AcceptPolicy(ctx context.Context, rq entities.AcceptPolicyRequest) (entities.AcceptPolicyResponse, error)
+	ListConsents(ctx context.Context, rq entities.ListConsentsRequest) (entities.ListConsentsResponse, error)
+	WithdrawConsent(ctx context.Context, rq entities.WithdrawConsentRequest) (entities.WithdrawConsentResponse, error)
+	Authenticate(ctx context.Context, rq entities.AuthenticateRequest) (entities.AuthenticateResponse, error)
+	GetPreferences(ctx context.Context, rq entities.GetPreferencesRequest) (entities.GetPreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, rq entities.UpdatePreferencesRequest) (entities.UpdatePreferencesResponse, error)
+	Impersonate(ctx context.Context, rq entities.ImpersonateRequest) (entities.ImpersonateResponse, error)
+	EndImpersonation(ctx context.Context, rq entities.EndImpersonationRequest) (entities.EndImpersonationResponse, error)
+	CreateApiKey(ctx context.Context, rq entities.CreateApiKeyRequest) (entities.CreateApiKeyResponse, error)
+	ListApiKeys(ctx context.Context, rq entities.ListApiKeysRequest) (entities.ListApiKeysResponse, error)
+	RevokeApiKey(ctx context.Context, rq entities.RevokeApiKeyRequest) (entities.RevokeApiKeyResponse, error)
+	RotateApiKey(ctx context.Context, rq entities.RotateApiKeyRequest) (entities.RotateApiKeyResponse, error)
 }
 
 type service struct {
@@ -33,6 +80,16 @@ func (s *service) CreateUser(ctx context.Context, rq entities.CreateUserRequest)
 		return entities.CreateUserResponse{}, err
 	}
 
+	// The gRPC service only sees the hashed password, which changes on
+	// every retry, so the request is fingerprinted as the client sent it.
+	if key, _, ok := idempotency.FromContext(ctx); ok {
+		if err := idempotency.ValidateKey(key); err != nil {
+			level.Error(logger).Log(err)
+			return entities.CreateUserResponse{}, err
+		}
+		ctx = idempotency.WithFingerprint(ctx, idempotency.CreateUserFingerprint(rq))
+	}
+
 	rq.Pass, err = util.HashPassword(rq.Pass)
 	if err != nil {
 		level.Error(logger).Log(err)
@@ -74,9 +131,102 @@ func (s *service) DeleteUser(ctx context.Context, rq entities.DeleteUserRequest)
 	res, err := s.Repo.DeleteUser(ctx, rq)
 	if err != nil {
 		level.Error(logger).Log(err)
-		return entities.DeleteUserResponse{}, nil
+		return entities.DeleteUserResponse{}, err
+	}
+
+	return res, nil
+
+}
+
+func (s *service) WatchUsers(ctx context.Context, rq entities.WatchRequest, send func(entities.WatchEvent) error) error {
+	logger := log.With(s.Logger, "watch users request", "recevied")
+
+	if err := s.Repo.WatchUsers(ctx, rq, send); err != nil {
+		level.Error(logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+// ImportUsers hands body to the gRPC service, which validates and hashes
+// the rows itself. Only the options are checked here, before the upload is
+// streamed on.
+func (s *service) ImportUsers(ctx context.Context, opts entities.ImportOptions, body io.Reader) (entities.ImportUsersResponse, error) {
+	logger := log.With(s.Logger, "import users request", "recevied")
+
+	if !bulk.Supported(opts.Format) {
+		return entities.ImportUsersResponse{}, errs.NewInvalidField("format", "must be csv or ndjson")
+	}
+
+	res, err := s.Repo.ImportUsers(ctx, opts, body)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ImportUsersResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) ExportUsers(ctx context.Context, rq entities.ExportUsersRequest, send func(entities.ExportChunk) error) error {
+	logger := log.With(s.Logger, "export users request", "recevied")
+
+	if !bulk.Supported(rq.Format) {
+		return errs.NewInvalidField("format", "must be csv or ndjson")
+	}
+
+	if err := s.Repo.ExportUsers(ctx, rq, send); err != nil {
+		level.Error(logger).Log(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) ListAuditEvents(ctx context.Context, rq entities.ListAuditEventsRequest) (entities.ListAuditEventsResponse, error) {
+	logger := log.With(s.Logger, "list audit events request", "recevied")
+
+	res, err := s.Repo.ListAuditEvents(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ListAuditEventsResponse{}, err
 	}
 
 	return res, nil
+}
+
+func (s *service) VerifyAuditLog(ctx context.Context, rq entities.VerifyAuditLogRequest) (entities.VerifyAuditLogResponse, error) {
+	logger := log.With(s.Logger, "verify audit log request", "recevied")
+
+	res, err := s.Repo.VerifyAuditLog(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.VerifyAuditLogResponse{}, err
+	}
+
+	return res, nil
+}
 
+func (s *service) ExportUserData(ctx context.Context, rq entities.ExportUserDataRequest) (entities.ExportUserDataResponse, error) {
+	logger := log.With(s.Logger, "export user data request", "recevied")
+
+	res, err := s.Repo.ExportUserData(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.ExportUserDataResponse{}, err
+	}
+
+	return res, nil
+}
+
+func (s *service) EraseUser(ctx context.Context, rq entities.EraseUserRequest) (entities.EraseUserResponse, error) {
+	logger := log.With(s.Logger, "erase user request", "recevied")
+
+	res, err := s.Repo.EraseUser(ctx, rq)
+	if err != nil {
+		level.Error(logger).Log(err)
+		return entities.EraseUserResponse{}, err
+	}
+
+	return res, nil
 }
@@ -124,12 +124,12 @@ func TestGetExistingUser(t *testing.T) {
 
 	var (
 		correctGetUserRequest entities.GetUserRequest = entities.GetUserRequest{
-			UserID: "2abc-323kol",
+			UserID: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
 		}
 
 		correctGetUserResponse entities.GetUserResponse = entities.GetUserResponse{
 			Name:  "Timo",
-			Id:    "2abc-323kol",
+			Id:    "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
 			Age:   19,
 			Email: "timoteo@globant.com",
 		}
@@ -165,7 +165,7 @@ func TestGetNonExistingUser(t *testing.T) {
 
 	var (
 		correctGetUserRequest entities.GetUserRequest = entities.GetUserRequest{
-			UserID: "2abc-323kol",
+			UserID: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
 		}
 	)
 
@@ -183,6 +183,24 @@ func TestGetNonExistingUser(t *testing.T) {
 
 }
 
+func TestGetUserMalformedId(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	repo := util.NewRepositoryMock()
+
+	srvc := user.NewService(&repo, logger)
+
+	for _, id := range []string{"2abc-323kol", "0190F3B2-7C1E-7D3A-9B4F-1C2D3E4F5A6B", "1' OR '1'='1"} {
+		t.Run(id, func(t *testing.T) {
+			res, err := srvc.GetUser(context.Background(), entities.GetUserRequest{UserID: id})
+			assert.Empty(t, res)
+			assert.IsType(t, errors.InvalidField{}, err)
+		})
+	}
+
+	repo.AssertExpectations(t)
+}
+
 func TestDeleteExistingUser(t *testing.T) {
 	var logger log.Logger
 	{
@@ -0,0 +1,102 @@
+package user
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+)
+
+// TenantHeader names the tenant of a request when neither its host nor its
+// path does.
+const TenantHeader = "X-Tenant-Id"
+
+// tenantPathPrefix starts the paths naming their tenant, /t/acme/user/1 is
+// /user/1 of the tenant acme.
+const tenantPathPrefix = "/t/"
+
+// TenantConfig sets where the tenant of a request is read from besides its
+// path and the TenantHeader.
+type TenantConfig struct {
+	// BaseDomain is the domain tenants are subdomains of. With
+	// users.example.com, acme.users.example.com serves the tenant acme.
+	// Hosts are ignored when it is empty.
+	BaseDomain string
+}
+
+// WithTenant puts the tenant a request is made for into its context, along
+// with the bearer token of the caller, both forwarded to the gRPC service.
+// The service checks the token and refuses requests made for another
+// tenant than the one the caller holds a token for. Requests naming no
+// tenant are served by the default one.
+func WithTenant(next http.Handler, config TenantConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.Clone(r.Context())
+
+		id, err := resolveTenant(r, config)
+		if err != nil {
+			encodeErrorResponse(r.Context(), err, w)
+			return
+		}
+
+		ctx := r.Context()
+		if id != "" {
+			ctx = tenant.WithTenant(ctx, id)
+		}
+		if authorization := r.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+			ctx = tenant.WithToken(ctx, strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer ")))
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolveTenant reads the tenant from the path, the host and the header of
+// r, which have to agree when several of them name one. A tenant path
+// prefix is removed from r.
+func resolveTenant(r *http.Request, config TenantConfig) (string, error) {
+	var named []string
+
+	if strings.HasPrefix(r.URL.Path, tenantPathPrefix) {
+		rest := strings.TrimPrefix(r.URL.Path, tenantPathPrefix)
+		id := rest
+		if slash := strings.Index(rest, "/"); slash >= 0 {
+			id = rest[:slash]
+		}
+		r.URL.Path = strings.TrimPrefix(rest, id)
+		r.URL.RawPath = ""
+		if r.URL.Path == "" {
+			r.URL.Path = "/"
+		}
+		named = append(named, id)
+	}
+
+	if config.BaseDomain != "" {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if suffix := "." + strings.ToLower(config.BaseDomain); strings.HasSuffix(host, suffix) {
+			named = append(named, strings.TrimSuffix(host, suffix))
+		}
+	}
+
+	if id := r.Header.Get(TenantHeader); id != "" {
+		named = append(named, id)
+	}
+
+	if len(named) == 0 {
+		return "", nil
+	}
+	for _, id := range named {
+		if !tenant.Valid(id) {
+			return "", myerr.NewInvalidField("tenant", "invalid tenant "+id)
+		}
+		if id != named[0] {
+			return "", myerr.NewInvalidField("tenant", "the path, host and "+TenantHeader+" header name different tenants")
+		}
+	}
+	return named[0], nil
+}
@@ -0,0 +1,140 @@
+package user_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/tenant"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+// forwarded returns the metadata a call made with ctx sends to the gRPC
+// service.
+func forwarded(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(tenant.ToOutgoingContext(ctx))
+	return md
+}
+
+func TestWithTenant(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+	config := user.TenantConfig{BaseDomain: "users.example.com"}
+
+	getUser := func(repo *util.RepositoryMock, tenantId string, token string) {
+		repo.On("GetUser", mock.MatchedBy(func(ctx context.Context) bool {
+			md := forwarded(ctx)
+			wantToken := []string(nil)
+			if token != "" {
+				wantToken = []string{"Bearer " + token}
+			}
+			return assert.ObjectsAreEqual([]string{tenantId}, md.Get(tenant.Header)) &&
+				assert.ObjectsAreEqual(wantToken, md.Get(tenant.AuthorizationHeader))
+		}), entities.GetUserRequest{UserID: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}).Return(entities.GetUserResponse{Id: "0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b"}, nil)
+	}
+
+	testCases := []struct {
+		Name           string
+		Host           string
+		Target         string
+		Header         http.Header
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name:   "Tenant From The Header",
+			Target: "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+			Header: http.Header{user.TenantHeader: {"acme"}},
+			buildMock: func(repo *util.RepositoryMock) {
+				getUser(repo, "acme", "")
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Tenant From The Host",
+			Host:   "acme.users.example.com:8000",
+			Target: "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+			buildMock: func(repo *util.RepositoryMock) {
+				getUser(repo, "acme", "")
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Tenant From The Path With The Token Of The Caller",
+			Target: "/t/acme/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+			Header: http.Header{"Authorization": {"Bearer token-1"}},
+			buildMock: func(repo *util.RepositoryMock) {
+				getUser(repo, "acme", "token-1")
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			Name:   "Conflicting Tenants",
+			Host:   "globex.users.example.com",
+			Target: "/t/acme/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+			buildMock: func(repo *util.RepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:   "Invalid Tenant",
+			Target: "/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+			Header: http.Header{user.TenantHeader: {"Acme Corp"}},
+			buildMock: func(repo *util.RepositoryMock) {
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			Name:   "Cross Tenant Access Is Forbidden",
+			Target: "/t/globex/user/0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+			Header: http.Header{"Authorization": {"Bearer token-1"}},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("GetUser", mock.Anything, mock.Anything).
+					Return(entities.GetUserResponse{}, myerr.NewCrossTenantAccess().GRPCStatus().Err())
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.WithTenant(user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger), config)
+			req := httptest.NewRequest(http.MethodGet, tc.Target, nil)
+			if tc.Host != "" {
+				req.Host = tc.Host
+			}
+			for name, values := range tc.Header {
+				req.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			tc.assertResponse(t, rec)
+			repo.AssertExpectations(t)
+		})
+	}
+}
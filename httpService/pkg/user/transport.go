@@ -3,17 +3,72 @@ package user
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/apikey"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/attributes"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/audit"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/bulk"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
 	myerr "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/etag"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/idempotency"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/impersonation"
 
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	httptransport "github.com/go-kit/kit/transport/http"
 )
 
+// IdempotencyKeyHeader makes a create request safe to retry, the user is
+// created once and retries sent with the same key get the first response.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const (
+	// ActorHeader names the caller as authenticated by the proxy in front of
+	// the service. Requests without it are audited as anonymous.
+	ActorHeader = "X-Forwarded-User"
+	// RequestIdHeader is echoed back, a request id is generated for
+	// requests without one.
+	RequestIdHeader = "X-Request-Id"
+	// UserIdHeader is the id of the user calling, set by the proxy in front
+	// of the service. The /me routes act on that user.
+	UserIdHeader = "X-Forwarded-User-Id"
+	// RolesHeader holds the roles the proxy authenticated the caller with,
+	// comma separated.
+	RolesHeader = "X-Forwarded-Roles"
+	// ImpersonationTokenHeader makes the request as the user an admin is
+	// impersonating, the /me routes act on that user instead.
+	ImpersonationTokenHeader = "X-Impersonation-Token"
+	// ApiKeyHeader authenticates batch jobs and other services, forwarded
+	// to the gRPC service, which checks the scopes of the key for every
+	// call the request makes.
+	ApiKeyHeader = "X-API-Key"
+)
+
+// maxRequestIdLength caps request ids taken from the client.
+const maxRequestIdLength = 128
+
+// userPath only takes well formed user ids, requests for others are
+// answered by notFound without reaching the gRPC service.
+const userPath = "/user/{id:" + ids.Pattern + "}"
+
 func NewHTTPSrv(endpoint Endpoints, logger log.Logger) http.Handler {
 	rt := mux.NewRouter()
 
@@ -26,23 +81,495 @@ func NewHTTPSrv(endpoint Endpoints, logger log.Logger) http.Handler {
 		endpoint.CreateUs,
 		decodeCreateUserReq,
 		encodeCreateUserResp,
-		options...,
+		append(options, httptransport.ServerBefore(idempotencyKeyFromHeader))...,
 	))
 
-	rt.Methods("GET").Path("/user/{id}").Handler(httptransport.NewServer(
+	rt.Methods("GET").Path(userPath).Handler(httptransport.NewServer(
 		endpoint.GetUs,
 		decodeGetUserReq,
 		encodeGetUserResp,
-		options...,
+		append(options, httptransport.ServerBefore(ifNoneMatchFromHeader))...,
 	))
 
-	rt.Methods("DELETE").Path("/user/{id}").Handler(httptransport.NewServer(
+	rt.Methods("DELETE").Path(userPath).Handler(httptransport.NewServer(
 		endpoint.DeleteUs,
 		decodeDeleteRequest,
 		encodeDeleteUserResponse,
 		options...,
 	))
-	return rt
+
+	rt.Methods("POST").Path("/users:batchCreate").Handler(httptransport.NewServer(
+		endpoint.BatchCreateUs,
+		decodeBatchCreateUsersReq,
+		encodeBatchResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/users:batchGet").Handler(httptransport.NewServer(
+		endpoint.BatchGetUs,
+		decodeBatchGetUsersReq,
+		encodeBatchResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/users:batchDelete").Handler(httptransport.NewServer(
+		endpoint.BatchDeleteUs,
+		decodeBatchDeleteUsersReq,
+		encodeBatchResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/users:import").Handler(httptransport.NewServer(
+		endpoint.ImportUs,
+		decodeImportUsersReq,
+		encodeImportUsersResp,
+		options...,
+	))
+
+	rt.Methods("GET").Path("/audit").Handler(httptransport.NewServer(
+		endpoint.ListAuditEvs,
+		decodeListAuditEventsReq,
+		encodeListAuditEventsResp,
+		options...,
+	))
+
+	rt.Methods("GET").Path("/audit/verify").Handler(httptransport.NewServer(
+		endpoint.VerifyAudit,
+		decodeVerifyAuditLogReq,
+		encodeVerifyAuditLogResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/groups").Handler(httptransport.NewServer(
+		endpoint.CreateGroup,
+		decodeCreateGroupReq,
+		encodeGroupResp,
+		options...,
+	))
+
+	rt.Methods("GET").Path("/groups").Handler(httptransport.NewServer(
+		endpoint.ListGroups,
+		decodeListGroupsReq,
+		encodeGroupResp,
+		options...,
+	))
+
+	rt.Methods("PATCH").Path("/groups/{group_id}").Handler(httptransport.NewServer(
+		endpoint.RenameGroup,
+		decodeRenameGroupReq,
+		encodeGroupResp,
+		options...,
+	))
+
+	rt.Methods("DELETE").Path("/groups/{group_id}").Handler(httptransport.NewServer(
+		endpoint.DeleteGroup,
+		decodeDeleteGroupReq,
+		encodeGroupResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/groups/{group_id}/members").Handler(httptransport.NewServer(
+		endpoint.AddMember,
+		decodeAddMemberReq,
+		encodeGroupResp,
+		options...,
+	))
+
+	rt.Methods("GET").Path("/groups/{group_id}/members").Handler(httptransport.NewServer(
+		endpoint.ListMembers,
+		decodeListMembersReq,
+		encodeGroupResp,
+		options...,
+	))
+
+	rt.Methods("DELETE").Path("/groups/{group_id}/members/{user_id}").Handler(httptransport.NewServer(
+		endpoint.RemoveMember,
+		decodeRemoveMemberReq,
+		encodeGroupResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/invitations").Handler(httptransport.NewServer(
+		endpoint.InviteUser,
+		decodeInviteUserReq,
+		encodeInvitationResp,
+		options...,
+	))
+
+	rt.Methods("GET").Path("/invitations").Handler(httptransport.NewServer(
+		endpoint.ListInvitations,
+		decodeListInvitationsReq,
+		encodeInvitationResp,
+		options...,
+	))
+
+	// The invitee has no user yet, the token in the link stands for them.
+	rt.Methods("POST").Path("/invitations:accept").Handler(httptransport.NewServer(
+		endpoint.AcceptInvitation,
+		decodeAcceptInvitationReq,
+		encodeInvitationResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/invitations/{invitation_id:[^/:]+}:revoke").Handler(httptransport.NewServer(
+		endpoint.RevokeInvitation,
+		decodeRevokeInvitationReq,
+		encodeInvitationResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/invitations/{invitation_id:[^/:]+}:resend").Handler(httptransport.NewServer(
+		endpoint.ResendInvitation,
+		decodeResendInvitationReq,
+		encodeInvitationResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/policies").Handler(httptransport.NewServer(
+		endpoint.PublishPolicy,
+		decodePublishPolicyReq,
+		encodeConsentResp,
+		options...,
+	))
+
+	rt.Methods("GET").Path("/policies").Handler(httptransport.NewServer(
+		endpoint.ListPolicies,
+		decodeListPoliciesReq,
+		encodeConsentResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/authenticate").Handler(httptransport.NewServer(
+		endpoint.Authenticate,
+		decodeAuthenticateReq,
+		encodeConsentResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/impersonations").Handler(httptransport.NewServer(
+		endpoint.Impersonate,
+		decodeImpersonateReq,
+		encodeImpersonationResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/impersonations/{session_id:[^/:]+}:end").Handler(httptransport.NewServer(
+		endpoint.EndImpersonation,
+		decodeEndImpersonationReq,
+		encodeImpersonationResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/api-keys").Handler(httptransport.NewServer(
+		endpoint.CreateApiKey,
+		decodeCreateApiKeyReq,
+		encodeApiKeyResp,
+		options...,
+	))
+
+	rt.Methods("GET").Path("/api-keys").Handler(httptransport.NewServer(
+		endpoint.ListApiKeys,
+		decodeListApiKeysReq,
+		encodeApiKeyResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/api-keys/{key_id:[^/:]+}:revoke").Handler(httptransport.NewServer(
+		endpoint.RevokeApiKey,
+		decodeRevokeApiKeyReq,
+		encodeApiKeyResp,
+		options...,
+	))
+
+	rt.Methods("POST").Path("/api-keys/{key_id:[^/:]+}:rotate").Handler(httptransport.NewServer(
+		endpoint.RotateApiKey,
+		decodeRotateApiKeyReq,
+		encodeApiKeyResp,
+		options...,
+	))
+
+	// Users reach their own data under /me, admins reach anyone's under
+	// /user/{id}.
+	for _, path := range []string{userPath, "/me"} {
+		rt.Methods("GET").Path(path + "/groups").Handler(httptransport.NewServer(
+			endpoint.ListUserGroups,
+			decodeListUserGroupsReq,
+			encodeGroupResp,
+			options...,
+		))
+
+		rt.Methods("GET").Path(path + "/data").Handler(httptransport.NewServer(
+			endpoint.ExportData,
+			decodeExportUserDataReq,
+			encodeExportUserDataResp,
+			options...,
+		))
+
+		rt.Methods("POST").Path(path + "/erasure").Handler(httptransport.NewServer(
+			endpoint.EraseUs,
+			decodeEraseUserReq,
+			encodeEraseUserResp,
+			options...,
+		))
+
+		rt.Methods("PATCH").Path(path + "/attributes").Handler(httptransport.NewServer(
+			endpoint.UpdateUserAttributes,
+			decodeUpdateUserAttributesReq,
+			encodeUpdateUserAttributesResp,
+			options...,
+		))
+
+		rt.Methods("PUT").Path(path + "/avatar").Handler(httptransport.NewServer(
+			endpoint.UploadAvatar,
+			decodeUploadAvatarReq,
+			encodeUploadAvatarResp,
+			options...,
+		))
+
+		rt.Methods("GET").Path(path + "/avatar").Handler(httptransport.NewServer(
+			endpoint.GetAvatar,
+			decodeGetAvatarReq,
+			encodeGetAvatarResp,
+			options...,
+		))
+
+		rt.Methods("GET").Path(path + "/preferences").Handler(httptransport.NewServer(
+			endpoint.GetPreferences,
+			decodeGetPreferencesReq,
+			encodePreferencesResp,
+			options...,
+		))
+
+		rt.Methods("PATCH").Path(path + "/preferences").Handler(httptransport.NewServer(
+			endpoint.UpdatePreferences,
+			decodeUpdatePreferencesReq,
+			encodePreferencesResp,
+			options...,
+		))
+
+		rt.Methods("GET").Path(path + "/consents").Handler(httptransport.NewServer(
+			endpoint.ListConsents,
+			decodeListConsentsReq,
+			encodeConsentResp,
+			options...,
+		))
+
+		rt.Methods("POST").Path(path + "/consents").Handler(httptransport.NewServer(
+			endpoint.AcceptPolicy,
+			decodeAcceptPolicyReq,
+			encodeConsentResp,
+			options...,
+		))
+
+		rt.Methods("DELETE").Path(path + "/consents/{kind}").Handler(httptransport.NewServer(
+			endpoint.WithdrawConsent,
+			decodeWithdrawConsentReq,
+			encodeConsentResp,
+			options...,
+		))
+	}
+
+	rt.Methods("GET").Path("/users").Handler(httptransport.NewServer(
+		endpoint.ListUsers,
+		decodeListUsersReq,
+		encodeListUsersResp,
+		options...,
+	))
+
+	rt.Methods("GET").Path("/attribute-schema").Handler(httptransport.NewServer(
+		endpoint.GetAttributeSchema,
+		decodeGetAttributeSchemaReq,
+		encodeAttributeSchemaResp,
+		options...,
+	))
+
+	rt.Methods("PUT").Path("/attribute-schema").Handler(httptransport.NewServer(
+		endpoint.SetAttributeSchema,
+		decodeSetAttributeSchemaReq,
+		encodeAttributeSchemaResp,
+		options...,
+	))
+
+	rt.Methods("GET").Path("/users:export").Handler(newExportUsersHandler(endpoint.ExportUs, logger))
+	rt.Methods("GET").Path("/users/events").Handler(newWatchUsersHandler(endpoint.WatchUs, logger))
+	rt.NotFoundHandler = http.HandlerFunc(notFound)
+	return withCaller(withApiKey(rt))
+}
+
+// notFound answers 400 rather than 404 to paths under /user whose id is
+// malformed, which no route takes.
+func notFound(w http.ResponseWriter, r *http.Request) {
+	if rest := strings.TrimPrefix(r.URL.Path, "/user/"); rest != r.URL.Path {
+		if _, err := entities.ParseID("id", strings.SplitN(rest, "/", 2)[0]); err != nil {
+			encodeErrorResponse(r.Context(), err, w)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// withCaller puts who is calling into the context of every request, to be
+// forwarded to the gRPC service for its audit log.
+func withCaller(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(RequestIdHeader)
+		if requestId == "" || len(requestId) > maxRequestIdLength {
+			requestId = uuid.NewString()
+		}
+		w.Header().Set(RequestIdHeader, requestId)
+
+		sourceIp, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			sourceIp = r.RemoteAddr
+		}
+
+		ctx := audit.WithCaller(r.Context(), audit.Caller{
+			Actor:              r.Header.Get(ActorHeader),
+			SourceIp:           sourceIp,
+			RequestId:          requestId,
+			Roles:              r.Header.Get(RolesHeader),
+			ImpersonationToken: r.Header.Get(ImpersonationTokenHeader),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// withApiKey forwards the key of requests sent with one to the gRPC
+// service, which authenticates the request as the key. Malformed keys are
+// refused here.
+func withApiKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(ApiKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, ok := apikey.Parse(key); !ok {
+			encodeErrorResponse(r.Context(), myerr.NewUnauthenticated("api key is malformed"), w)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(apikey.WithSecret(r.Context(), key)))
+	})
+}
+
+// newWatchUsersHandler serves the user change feed as Server-Sent Events.
+// The id of every event is its resume token, so browsers resume on their
+// own through Last-Event-ID after a reconnect.
+func newWatchUsersHandler(watch endpoint.Endpoint, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			encodeErrorResponse(r.Context(), errors.New("streaming unsupported"), w)
+			return
+		}
+
+		query := r.URL.Query()
+		request := entities.WatchRequest{
+			ResumeToken: query.Get("resume_token"),
+			Filter: entities.WatchFilter{
+				EventTypes: query["event_type"],
+				UserIds:    query["user_id"],
+			},
+		}
+		if lastEventId := r.Header.Get("Last-Event-ID"); lastEventId != "" {
+			request.ResumeToken = lastEventId
+		}
+
+		started := false
+		send := func(event entities.WatchEvent) error {
+			if !started {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Cache-Control", "no-cache")
+				w.Header().Set("X-Accel-Buffering", "no")
+				w.WriteHeader(http.StatusOK)
+				started = true
+			}
+
+			if err := writeServerSentEvent(w, event); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		}
+
+		_, err := watch(r.Context(), WatchUsersRequest{WatchRequest: request, Send: send})
+		if err != nil && r.Context().Err() == nil {
+			if !started {
+				encodeErrorResponse(r.Context(), err, w)
+				return
+			}
+			logger.Log("msg", "user event stream ended", "error", err)
+		}
+	})
+}
+
+// newExportUsersHandler serves an export as a download flushed a page at a
+// time. Once the first page is out an error can only cut the response
+// short, the download is then resumed with after_id set to the id of the
+// last complete row.
+func newExportUsersHandler(export endpoint.Endpoint, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		request := entities.ExportUsersRequest{
+			Format:  strings.ToLower(query.Get("format")),
+			AfterId: query.Get("after_id"),
+		}
+		if request.Format == "" {
+			request.Format = bulk.CSV
+		}
+
+		flusher, _ := w.(http.Flusher)
+		started := false
+		start := func() {
+			w.Header().Set("Content-Type", bulk.ContentType(request.Format))
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, request.Format))
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+
+		send := func(chunk entities.ExportChunk) error {
+			if !started {
+				start()
+			}
+
+			if _, err := w.Write(chunk.Data); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		}
+
+		_, err := export(r.Context(), ExportUsersRequest{ExportUsersRequest: request, Send: send})
+		switch {
+		case err == nil && !started:
+			start()
+		case err != nil && !started:
+			encodeErrorResponse(r.Context(), err, w)
+		case err != nil:
+			logger.Log("msg", "user export cut short", "error", err)
+			// Break the connection so the client can't take what it got for
+			// the whole export.
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
+
+func writeServerSentEvent(w io.Writer, event entities.WatchEvent) error {
+	if event.Heartbeat {
+		_, err := fmt.Fprintf(w, "id: %s\nevent: heartbeat\ndata: {}\n\n", event.ResumeToken)
+		return err
+	}
+
+	data, err := protojson.Marshal(event.Event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ResumeToken, event.Event.Type, data)
+	return err
 }
 
 func decodeCreateUserReq(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -68,14 +595,37 @@ func decodeGetUserReq(ctx context.Context, r *http.Request) (interface{}, error)
 		return nil, myerr.NewFieldsMissing()
 	}
 
-	request.UserID = id
+	userId, err := entities.ParseID("id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserID = userId.String()
 	return request, nil
 }
 
 func encodeGetUserResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	if res, ok := response.(entities.GetUserResponse); ok && res.Etag != "" {
+		wr.Header().Set("ETag", res.Etag)
+		if ifNoneMatch, ok := ctx.Value(ifNoneMatchKey{}).(string); ok && !etag.NoneMatch(ifNoneMatch, res.Etag) {
+			wr.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
 	return json.NewEncoder(wr).Encode(response)
 }
 
+type ifNoneMatchKey struct{}
+
+// ifNoneMatchFromHeader keeps If-None-Match for the response encoder, the
+// etag it is compared with is only known once the user was read.
+func ifNoneMatchFromHeader(ctx context.Context, r *http.Request) context.Context {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return context.WithValue(ctx, ifNoneMatchKey{}, ifNoneMatch)
+	}
+	return ctx
+}
+
 func decodeDeleteRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	var request entities.DeleteUserRequest
 	vars := mux.Vars(r)
@@ -83,7 +633,14 @@ func decodeDeleteRequest(ctx context.Context, r *http.Request) (interface{}, err
 	if !ok {
 		return nil, myerr.NewFieldsMissing()
 	}
-	request.UserId = id
+
+	userId, err := entities.ParseID("id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = userId.String()
+	request.IfMatch = r.Header.Get("If-Match")
 	return request, nil
 }
 
@@ -91,11 +648,731 @@ func encodeDeleteUserResponse(ctx context.Context, r http.ResponseWriter, respon
 	return json.NewEncoder(r).Encode(response)
 }
 
+// maxBatchBodyBytes caps the body of batch requests.
+const maxBatchBodyBytes = 1 << 20
+
+func decodeBatchBody(r *http.Request, request interface{}) error {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBatchBodyBytes+1))
+	if err != nil {
+		return myerr.NewFieldsMissing()
+	}
+	if len(body) > maxBatchBodyBytes {
+		return myerr.NewInvalidField("body", "larger than 1 MiB")
+	}
+
+	if err := json.Unmarshal(body, request); err != nil {
+		return myerr.NewFieldsMissing()
+	}
+	return nil
+}
+
+func decodeBatchCreateUsersReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.BatchCreateUsersRequest
+	if err := decodeBatchBody(r, &request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func decodeBatchGetUsersReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.BatchGetUsersRequest
+	if err := decodeBatchBody(r, &request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func decodeBatchDeleteUsersReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.BatchDeleteUsersRequest
+	if err := decodeBatchBody(r, &request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func encodeBatchResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+// maxImportFieldBytes caps the form fields sent along an import file.
+const maxImportFieldBytes = 1 << 10
+
+// decodeImportUsersReq reads the options of an import from the form fields
+// before the file part and leaves the file to be streamed on as it is
+// uploaded. Without a format field it follows the file name extension.
+func decodeImportUsersReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, myerr.NewInvalidField("body", "must be multipart/form-data")
+	}
+
+	var request ImportUsersRequest
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, myerr.NewInvalidField("file", "is required")
+		}
+		if err != nil {
+			return nil, myerr.NewInvalidField("body", err.Error())
+		}
+
+		if part.FormName() == "file" {
+			if request.Format == "" {
+				request.Format = formatFromFileName(part.FileName())
+			}
+			request.Body = part
+			return request, nil
+		}
+
+		value, err := ioutil.ReadAll(io.LimitReader(part, maxImportFieldBytes+1))
+		if err != nil {
+			return nil, myerr.NewInvalidField("body", err.Error())
+		}
+		if len(value) > maxImportFieldBytes {
+			return nil, myerr.NewInvalidField(part.FormName(), "too long")
+		}
+
+		switch part.FormName() {
+		case "format":
+			request.Format = strings.ToLower(string(value))
+		case "import_id":
+			request.ImportId = string(value)
+		case "dry_run":
+			if request.DryRun, err = strconv.ParseBool(string(value)); err != nil {
+				return nil, myerr.NewInvalidField("dry_run", "must be true or false")
+			}
+		}
+	}
+}
+
+func formatFromFileName(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return bulk.CSV
+	case ".ndjson", ".jsonl":
+		return bulk.NDJSON
+	}
+	return ""
+}
+
+func encodeImportUsersResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+// decodeListAuditEventsReq reads the filters from the query, times are
+// RFC 3339.
+func decodeListAuditEventsReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	request := entities.ListAuditEventsRequest{
+		AuditFilter: entities.AuditFilter{
+			Actor:        query.Get("actor"),
+			TargetUserId: query.Get("target_user_id"),
+		},
+		PageToken: query.Get("page_token"),
+	}
+
+	for field, t := range map[string]*time.Time{"start": &request.Start, "end": &request.End} {
+		if value := query.Get(field); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, myerr.NewInvalidField(field, "must be an RFC 3339 time")
+			}
+			*t = parsed
+		}
+	}
+
+	pageSize, err := pageSizeFromQuery(r)
+	if err != nil {
+		return nil, err
+	}
+	request.PageSize = pageSize
+
+	return request, nil
+}
+
+func encodeListAuditEventsResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+func decodeVerifyAuditLogReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.VerifyAuditLogRequest
+	if value := r.URL.Query().Get("legacy"); value != "" {
+		var err error
+		if request.Legacy, err = strconv.ParseBool(value); err != nil {
+			return nil, myerr.NewInvalidField("legacy", "must be true or false")
+		}
+	}
+
+	return request, nil
+}
+
+func encodeVerifyAuditLogResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+// subjectId is the user a request is about: the one in the path,
+// or the caller on the /me routes.
+func subjectId(r *http.Request) (string, error) {
+	if id, ok := mux.Vars(r)["id"]; ok {
+		userId, err := entities.ParseID("id", id)
+		return userId.String(), err
+	}
+
+	// The token is checked by the gRPC service, which denies requests for
+	// anyone but its subject.
+	if token := r.Header.Get(ImpersonationTokenHeader); token != "" {
+		claims, err := impersonation.Subject(token)
+		if err != nil {
+			return "", myerr.NewUnauthenticated("impersonation token is invalid")
+		}
+		userId, err := entities.ParseID(ImpersonationTokenHeader, claims.Subject)
+		return userId.String(), err
+	}
+
+	id := r.Header.Get(UserIdHeader)
+	if id == "" {
+		return "", myerr.NewUnauthenticated("the calling user is unknown")
+	}
+	userId, err := entities.ParseID(UserIdHeader, id)
+	return userId.String(), err
+}
+
+func decodeExportUserDataReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ExportUserDataRequest{UserId: id}, nil
+}
+
+func encodeExportUserDataResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	res := response.(entities.ExportUserDataResponse)
+	wr.Header().Set("Content-Type", "application/zip")
+	wr.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, res.FileName))
+	wr.Header().Set("Cache-Control", "no-store")
+	_, err := wr.Write(res.Archive)
+	return err
+}
+
+// decodeUploadAvatarReq leaves the avatar part of the form to be streamed
+// on as it is uploaded, the gRPC service tells whether it is an image.
+func decodeUploadAvatarReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, myerr.NewUnsupportedMediaType("body", r.Header.Get("Content-Type"))
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, myerr.NewInvalidField("avatar", "is required")
+		}
+		if err != nil {
+			return nil, myerr.NewInvalidField("body", err.Error())
+		}
+
+		if part.FormName() == "avatar" {
+			return UploadAvatarRequest{UploadAvatarRequest: entities.UploadAvatarRequest{UserId: id}, Body: part}, nil
+		}
+	}
+}
+
+func encodeUploadAvatarResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	wr.Header().Set("ETag", response.(entities.UploadAvatarResponse).Etag)
+	return json.NewEncoder(wr).Encode(response)
+}
+
+// decodeGetAvatarReq reads the wanted side in pixels from the size query
+// parameter, the largest thumbnail is served without it.
+func decodeGetAvatarReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	request := entities.GetAvatarRequest{UserId: id, IfNoneMatch: r.Header.Get("If-None-Match")}
+	if value := r.URL.Query().Get("size"); value != "" {
+		size, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || size == 0 {
+			return nil, myerr.NewInvalidField("size", "must be a positive integer")
+		}
+		request.Size = uint32(size)
+	}
+
+	return request, nil
+}
+
+// avatarMaxAge is how long browsers reuse an avatar before revalidating
+// it, a new upload shows up once it has passed.
+const avatarMaxAge = 5 * time.Minute
+
+func encodeGetAvatarResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	res := response.(entities.GetAvatarResponse)
+	wr.Header().Set("ETag", res.Etag)
+	wr.Header().Set("Last-Modified", res.UpdatedAt.UTC().Format(http.TimeFormat))
+	wr.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(avatarMaxAge.Seconds())))
+	if res.NotModified {
+		wr.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	wr.Header().Set("Content-Type", res.ContentType)
+	wr.Header().Set("Content-Length", strconv.Itoa(len(res.Image)))
+	wr.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err := wr.Write(res.Image)
+	return err
+}
+
+func decodeEraseUserReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.EraseUserRequest{UserId: id}, nil
+}
+
+func encodeEraseUserResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response.(entities.EraseUserResponse).Receipt)
+}
+
+func idempotencyKeyFromHeader(ctx context.Context, r *http.Request) context.Context {
+	if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+		return idempotency.WithKey(ctx, key)
+	}
+	return ctx
+}
+
+func decodeCreateGroupReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+
+	return request, nil
+}
+
+func decodeListGroupsReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	pageSize, err := pageSizeFromQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ListGroupsRequest{PageSize: pageSize, PageToken: r.URL.Query().Get("page_token")}, nil
+}
+
+func decodeRenameGroupReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.RenameGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+
+	request.GroupId = mux.Vars(r)["group_id"]
+	return request, nil
+}
+
+func decodeDeleteGroupReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	return entities.DeleteGroupRequest{GroupId: mux.Vars(r)["group_id"]}, nil
+}
+
+func decodeAddMemberReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.AddMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+
+	request.GroupId = mux.Vars(r)["group_id"]
+	return request, nil
+}
+
+func decodeListMembersReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	pageSize, err := pageSizeFromQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ListMembersRequest{
+		GroupId:   mux.Vars(r)["group_id"],
+		PageSize:  pageSize,
+		PageToken: r.URL.Query().Get("page_token"),
+	}, nil
+}
+
+func decodeRemoveMemberReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	return entities.RemoveMemberRequest{GroupId: vars["group_id"], UserId: vars["user_id"]}, nil
+}
+
+func decodeListUserGroupsReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ListUserGroupsRequest{UserId: id}, nil
+}
+
+func encodeGroupResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+func decodeInviteUserReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.InviteUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+
+	return request, nil
+}
+
+func decodeAcceptInvitationReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+
+	return request, nil
+}
+
+func decodeListInvitationsReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	pageSize, err := pageSizeFromQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ListInvitationsRequest{PageSize: pageSize, PageToken: r.URL.Query().Get("page_token")}, nil
+}
+
+func decodeRevokeInvitationReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	return entities.RevokeInvitationRequest{InvitationId: mux.Vars(r)["invitation_id"]}, nil
+}
+
+func decodeResendInvitationReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	return entities.ResendInvitationRequest{InvitationId: mux.Vars(r)["invitation_id"]}, nil
+}
+
+func encodeInvitationResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+func decodePublishPolicyReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.PublishPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+
+	return request, nil
+}
+
+// decodeListPoliciesReq lists every version of the kind in the kind query
+// parameter, the current version of each kind without it.
+func decodeListPoliciesReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	return entities.ListPoliciesRequest{Kind: r.URL.Query().Get("kind")}, nil
+}
+
+func decodeAuthenticateReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.AuthenticateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+
+	return request, nil
+}
+
+func decodeListConsentsReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ListConsentsRequest{UserId: id}, nil
+}
+
+// decodeAcceptPolicyReq accepts the current version of the kind when the
+// body names no version.
+func decodeAcceptPolicyReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var request entities.AcceptPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+	request.UserId = id
+
+	return request, nil
+}
+
+// decodeWithdrawConsentReq reads where the consent was withdrawn from the
+// source query parameter.
+func decodeWithdrawConsentReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.WithdrawConsentRequest{
+		UserId: id,
+		Kind:   mux.Vars(r)["kind"],
+		Source: r.URL.Query().Get("source"),
+	}, nil
+}
+
+func encodeConsentResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+// maxAttributesBodyBytes caps the body of an attributes patch, twice what
+// the attributes of a user can hold leaves room for the attributes it
+// removes.
+const maxAttributesBodyBytes = 2 * attributes.MaxBytes
+
+// AttributeFilterPrefix marks the query parameters of GET /users filtering
+// on an indexed attribute, as in attr.team=core.
+const AttributeFilterPrefix = "attr."
+
+// decodeUpdateUserAttributesReq reads the body as a JSON merge patch of the
+// attributes, attributes set to null are removed.
+func decodeUpdateUserAttributesReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAttributesBodyBytes+1))
+	if err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+	if len(body) > maxAttributesBodyBytes {
+		return nil, myerr.NewInvalidField("body", fmt.Sprintf("larger than %d KiB", maxAttributesBodyBytes>>10))
+	}
+
+	var attrs map[string]interface{}
+	if err := json.Unmarshal(body, &attrs); err != nil || attrs == nil {
+		return nil, myerr.NewInvalidField("body", "must be a JSON object")
+	}
+
+	return entities.UpdateUserAttributesRequest{UserId: id, Attributes: attrs, IfMatch: r.Header.Get("If-Match")}, nil
+}
+
+func encodeUpdateUserAttributesResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	if res, ok := response.(entities.UpdateUserAttributesResponse); ok && res.Etag != "" {
+		wr.Header().Set("ETag", res.Etag)
+	}
+	return json.NewEncoder(wr).Encode(response)
+}
+
+// maxPreferencesBodyBytes caps the body of a preferences patch, settings
+// are a handful of short values.
+const maxPreferencesBodyBytes = 16 << 10
+
+func decodeGetPreferencesReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.GetPreferencesRequest{UserId: id}, nil
+}
+
+// decodeUpdatePreferencesReq reads the body as a JSON merge patch of the
+// preferences, settings set to null go back to their default.
+func decodeUpdatePreferencesReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := subjectId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxPreferencesBodyBytes+1))
+	if err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+	if len(body) > maxPreferencesBodyBytes {
+		return nil, myerr.NewInvalidField("body", fmt.Sprintf("larger than %d KiB", maxPreferencesBodyBytes>>10))
+	}
+
+	var preferences map[string]interface{}
+	if err := json.Unmarshal(body, &preferences); err != nil || preferences == nil {
+		return nil, myerr.NewInvalidField("body", "must be a JSON object")
+	}
+
+	return entities.UpdatePreferencesRequest{UserId: id, Preferences: preferences}, nil
+}
+
+func encodePreferencesResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+func decodeImpersonateReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	// The TTL is decoded signed, so a negative one is told apart from a
+	// malformed body.
+	var request struct {
+		UserId     string
+		Reason     string
+		TtlSeconds int64
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+	if request.TtlSeconds < 0 || request.TtlSeconds > math.MaxUint32 {
+		return nil, myerr.NewInvalidField("ttl_seconds", "must be positive")
+	}
+
+	return entities.ImpersonateRequest{UserId: request.UserId, Reason: request.Reason, TtlSeconds: uint32(request.TtlSeconds)}, nil
+}
+
+func decodeEndImpersonationReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	return entities.EndImpersonationRequest{SessionId: mux.Vars(r)["session_id"]}, nil
+}
+
+func encodeImpersonationResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+func decodeCreateApiKeyReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.CreateApiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+
+	return request, nil
+}
+
+func decodeListApiKeysReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	return entities.ListApiKeysRequest{}, nil
+}
+
+func decodeRevokeApiKeyReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	return entities.RevokeApiKeyRequest{KeyId: mux.Vars(r)["key_id"]}, nil
+}
+
+// decodeRotateApiKeyReq takes an optional body setting the overlap window.
+func decodeRotateApiKeyReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request entities.RotateApiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		return nil, myerr.NewFieldsMissing()
+	}
+	request.KeyId = mux.Vars(r)["key_id"]
+
+	return request, nil
+}
+
+func encodeApiKeyResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+func decodeListUsersReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	pageSize, err := pageSizeFromQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	request := entities.ListUsersRequest{PageSize: pageSize, PageToken: r.URL.Query().Get("page_token")}
+	for key, values := range r.URL.Query() {
+		if !strings.HasPrefix(key, AttributeFilterPrefix) {
+			continue
+		}
+		if len(values) > 1 {
+			return nil, myerr.NewInvalidField(key, "given more than once")
+		}
+		if request.Filters == nil {
+			request.Filters = make(map[string]string)
+		}
+		request.Filters[strings.TrimPrefix(key, AttributeFilterPrefix)] = values[0]
+	}
+
+	return request, nil
+}
+
+func encodeListUsersResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(wr).Encode(response)
+}
+
+func decodeGetAttributeSchemaReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	return entities.GetAttributeSchemaRequest{}, nil
+}
+
+// decodeSetAttributeSchemaReq takes the body as the schema, it is parsed by
+// the gRPC service.
+func decodeSetAttributeSchemaReq(ctx context.Context, r *http.Request) (interface{}, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, attributes.MaxSchemaBytes+1))
+	if err != nil {
+		return nil, myerr.NewFieldsMissing()
+	}
+	if len(body) > attributes.MaxSchemaBytes {
+		return nil, myerr.NewInvalidField("body", fmt.Sprintf("larger than %d KiB", attributes.MaxSchemaBytes>>10))
+	}
+	if !json.Valid(body) {
+		return nil, myerr.NewInvalidField("schema", "must be JSON")
+	}
+
+	return entities.SetAttributeSchemaRequest{Schema: string(body)}, nil
+}
+
+// attributeSchemaResponse embeds the schema as JSON rather than as the
+// string it is kept in.
+type attributeSchemaResponse struct {
+	Schema    json.RawMessage
+	UpdatedAt time.Time
+	Reindexed *uint64 `json:",omitempty"`
+}
+
+func encodeAttributeSchemaResp(ctx context.Context, wr http.ResponseWriter, response interface{}) error {
+	var res attributeSchemaResponse
+	switch schema := response.(type) {
+	case entities.GetAttributeSchemaResponse:
+		res = attributeSchemaResponse{Schema: json.RawMessage(schema.Schema), UpdatedAt: schema.UpdatedAt}
+	case entities.SetAttributeSchemaResponse:
+		res = attributeSchemaResponse{Schema: json.RawMessage(schema.Schema), UpdatedAt: schema.UpdatedAt, Reindexed: &schema.Reindexed}
+	default:
+		return json.NewEncoder(wr).Encode(response)
+	}
+	return json.NewEncoder(wr).Encode(res)
+}
+
+// pageSizeFromQuery reads the optional page_size of a list request.
+func pageSizeFromQuery(r *http.Request) (uint32, error) {
+	value := r.URL.Query().Get("page_size")
+	if value == "" {
+		return 0, nil
+	}
+
+	pageSize, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, myerr.NewInvalidField("page_size", "must be a whole number")
+	}
+	return uint32(pageSize), nil
+}
+
 func encodeErrorResponse(_ context.Context, err error, w http.ResponseWriter) {
 	if err != nil {
+		// Errors relayed from the gRPC service read like the ones made here,
+		// without the code gRPC prefixes them with.
+		message := err.Error()
+		if st, ok := status.FromError(err); ok {
+			message = st.Message()
+		}
+
+		body := map[string]interface{}{
+			"error": message,
+		}
+		// The client shows the versions to accept before authenticating again.
+		if pending := myerr.PendingPolicies(err); pending != nil {
+			body["pending_policies"] = pending
+		}
+
 		w.WriteHeader(myerr.CustomToHttp(err))
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"error": err.Error(),
-		})
+		json.NewEncoder(w).Encode(body)
 	}
 }
@@ -0,0 +1,147 @@
+package user_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
+	errors "github.com/timoteoBone/microservice-project/grpcService/pkg/errors"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/events"
+	"github.com/timoteoBone/microservice-project/grpcService/pkg/ids"
+	"github.com/timoteoBone/microservice-project/httpService/pkg/user"
+	util "github.com/timoteoBone/microservice-project/httpService/pkg/utils"
+)
+
+func TestWatchUsersEvents(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	created := events.NewUserCreated("user-1")
+
+	testCases := []struct {
+		Name           string
+		Request        func() *http.Request
+		buildMock      func(repo *util.RepositoryMock)
+		assertResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "Stream Events And Heartbeats",
+			Request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/users/events?event_type=user.created&user_id=user-1", nil)
+				req.Header.Set("Last-Event-ID", "token-1")
+				return req
+			},
+			buildMock: func(repo *util.RepositoryMock) {
+				request := entities.WatchRequest{
+					ResumeToken: "token-1",
+					Filter:      entities.WatchFilter{EventTypes: []string{"user.created"}, UserIds: []string{"user-1"}},
+				}
+				repo.On("WatchUsers", mock.Anything, request).Return([]entities.WatchEvent{
+					{Event: created, ResumeToken: "token-2"},
+					{ResumeToken: "token-2", Heartbeat: true},
+				}, nil)
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+
+				frames := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+				assert.Len(t, frames, 2)
+				assert.True(t, strings.HasPrefix(frames[0], "id: token-2\nevent: user.created\ndata: "))
+				assert.Contains(t, frames[0], created.Id)
+				assert.Equal(t, "id: token-2\nevent: heartbeat\ndata: {}", frames[1])
+			},
+		},
+		{
+			Name: "Error Before First Event",
+			Request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/users/events", nil)
+			},
+			buildMock: func(repo *util.RepositoryMock) {
+				repo.On("WatchUsers", mock.Anything, entities.WatchRequest{}).
+					Return([]entities.WatchEvent{}, errors.NewInvalidField("resume_token", "not issued by this service"))
+			},
+			assertResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.Contains(t, rec.Body.String(), "resume_token")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := util.NewRepositoryMock()
+			tc.buildMock(&repo)
+
+			handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tc.Request())
+			tc.assertResponse(t, rec)
+		})
+	}
+}
+
+// fuzzUserRoute checks a request for the user id in its path reaches the
+// repository when the id is well formed and is answered 400 otherwise.
+// The repository answers method with a NotFound error.
+func fuzzUserRoute(f *testing.F, httpMethod, method string, request func(id string) interface{}, returned interface{}) {
+	for _, seed := range []string{
+		"0190f3b2-7c1e-7d3a-9b4f-1c2d3e4f5a6b",
+		"01ARZ3NDEKTSV4RRFFQ69G5FAV",
+		"1234567890123456789",
+		"user-1",
+		"0190F3B2-7C1E-7D3A-9B4F-1C2D3E4F5A6B",
+		"9223372036854775808",
+		"1' OR '1'='1",
+		"%00",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, id string) {
+		// Paths that clean to another one are redirected by the router
+		// before any route is looked at.
+		if path.Clean("/user/"+id) != "/user/"+id {
+			t.Skip()
+		}
+
+		repo := util.NewRepositoryMock()
+		repo.On(method, mock.Anything, request(id)).Return(returned, status.Error(codes.NotFound, "user not found")).Maybe()
+		logger := log.NewNopLogger()
+		handler := user.NewHTTPSrv(*user.MakeEndpoints(user.NewService(&repo, logger)), logger)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(httpMethod, "/user/"+url.PathEscape(id), nil))
+
+		if ids.Valid(id) {
+			assert.Equal(t, http.StatusNotFound, rec.Code, id)
+			repo.AssertNumberOfCalls(t, method, 1)
+		} else {
+			assert.Equal(t, http.StatusBadRequest, rec.Code, id)
+			repo.AssertNumberOfCalls(t, method, 0)
+		}
+	})
+}
+
+func FuzzGetUserRoute(f *testing.F) {
+	fuzzUserRoute(f, http.MethodGet, "GetUser", func(id string) interface{} {
+		return entities.GetUserRequest{UserID: id}
+	}, entities.GetUserResponse{})
+}
+
+func FuzzDeleteUserRoute(f *testing.F) {
+	fuzzUserRoute(f, http.MethodDelete, "DeleteUser", func(id string) interface{} {
+		return entities.DeleteUserRequest{UserId: id}
+	}, entities.DeleteUserResponse{})
+}
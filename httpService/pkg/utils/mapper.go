@@ -1,16 +1,22 @@
 package util
 
 import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
 	proto "github.com/timoteoBone/microservice-project/grpcService/pkg/pb"
 )
 
 func CreateToProto(req entities.CreateUserRequest) *proto.CreateUserRequest {
 	return &proto.CreateUserRequest{
-		Name:  req.Name,
-		Pass:  req.Pass,
-		Age:   req.Age,
-		Email: req.Email,
+		Name:       req.Name,
+		Pass:       req.Pass,
+		Age:        req.Age,
+		Email:      req.Email,
+		Attributes: AttributesToProto(req.Attributes),
 	}
 }
 
@@ -31,16 +37,19 @@ func CreateFromProto(resp *proto.CreateUserResponse) entities.CreateUserResponse
 
 func GetFromProto(resp *proto.GetUserResponse) entities.GetUserResponse {
 	return entities.GetUserResponse{
-		Name:  resp.Name,
-		Id:    resp.Id,
-		Age:   resp.Age,
-		Email: resp.Email,
+		Name:       resp.Name,
+		Id:         resp.Id,
+		Age:        resp.Age,
+		Email:      resp.Email,
+		Etag:       resp.Etag,
+		Attributes: AttributesFromProto(resp.Attributes),
 	}
 }
 
 func DeleteToProto(req entities.DeleteUserRequest) *proto.DeleteUserRequest {
 	return &proto.DeleteUserRequest{
-		User_Id: req.UserId,
+		User_Id:  req.UserId,
+		If_Match: req.IfMatch,
 	}
 }
 
@@ -52,3 +61,466 @@ func DeleteFromProto(resp *proto.DeleteUserResponse) entities.DeleteUserResponse
 		},
 	}
 }
+
+func WatchToProto(req entities.WatchRequest) *proto.WatchRequest {
+	return &proto.WatchRequest{
+		Resume_Token: req.ResumeToken,
+		Filter: &proto.WatchFilter{
+			Event_Types: req.Filter.EventTypes,
+			User_Ids:    req.Filter.UserIds,
+		},
+	}
+}
+
+func WatchFromProto(resp *proto.WatchResponse) entities.WatchEvent {
+	return entities.WatchEvent{
+		Event:       resp.Event,
+		ResumeToken: resp.Resume_Token,
+		Heartbeat:   resp.Heartbeat,
+	}
+}
+
+func BatchCreateToProto(req entities.BatchCreateUsersRequest) *proto.BatchCreateUsersRequest {
+	protoReq := &proto.BatchCreateUsersRequest{All_Or_Nothing: req.AllOrNothing}
+	for _, user := range req.Users {
+		protoReq.Users = append(protoReq.Users, CreateToProto(user))
+	}
+	return protoReq
+}
+
+func BatchCreateFromProto(resp *proto.BatchCreateUsersResponse) entities.BatchCreateUsersResponse {
+	res := entities.BatchCreateUsersResponse{}
+	for _, result := range resp.Results {
+		res.Results = append(res.Results, entities.BatchCreateUserResult{
+			UserId: result.User_Id,
+			Error:  statusFromProto(result.Error),
+		})
+	}
+	return res
+}
+
+func BatchGetFromProto(resp *proto.BatchGetUsersResponse) entities.BatchGetUsersResponse {
+	res := entities.BatchGetUsersResponse{}
+	for _, result := range resp.Results {
+		item := entities.BatchGetUserResult{Error: statusFromProto(result.Error)}
+		if result.User != nil {
+			item.User = GetFromProto(result.User)
+		}
+		res.Results = append(res.Results, item)
+	}
+	return res
+}
+
+func BatchDeleteFromProto(resp *proto.BatchDeleteUsersResponse) entities.BatchDeleteUsersResponse {
+	res := entities.BatchDeleteUsersResponse{}
+	for _, result := range resp.Results {
+		res.Results = append(res.Results, entities.BatchDeleteUserResult{
+			UserId: result.User_Id,
+			Error:  statusFromProto(result.Error),
+		})
+	}
+	return res
+}
+
+func ImportOptionsToProto(opts entities.ImportOptions) *proto.ImportOptions {
+	return &proto.ImportOptions{
+		Format:    opts.Format,
+		Dry_Run:   opts.DryRun,
+		Import_Id: opts.ImportId,
+	}
+}
+
+func ImportFromProto(resp *proto.ImportUsersResponse) entities.ImportUsersResponse {
+	res := entities.ImportUsersResponse{
+		ImportId:        resp.Import_Id,
+		Rows:            resp.Rows,
+		Imported:        resp.Imported,
+		Skipped:         resp.Skipped,
+		Failed:          resp.Failed,
+		ErrorsTruncated: resp.Errors_Truncated,
+		DryRun:          resp.Dry_Run,
+	}
+	for _, rowErr := range resp.Errors {
+		importErr := entities.ImportRowError{Row: rowErr.Row, Field: rowErr.Field}
+		if status := statusFromProto(rowErr.Error); status != nil {
+			importErr.Error = *status
+		}
+		res.Errors = append(res.Errors, importErr)
+	}
+	return res
+}
+
+func statusFromProto(status *proto.Status) *entities.Status {
+	if status == nil {
+		return nil
+	}
+	return &entities.Status{Code: status.Code, Message: status.Message}
+}
+
+func ListAuditEventsToProto(req entities.ListAuditEventsRequest) *proto.ListAuditEventsRequest {
+	return &proto.ListAuditEventsRequest{
+		Start:          timeToProto(req.Start),
+		End:            timeToProto(req.End),
+		Actor:          req.Actor,
+		Target_User_Id: req.TargetUserId,
+		Page_Size:      req.PageSize,
+		Page_Token:     req.PageToken,
+	}
+}
+
+func ListAuditEventsFromProto(resp *proto.ListAuditEventsResponse) entities.ListAuditEventsResponse {
+	res := entities.ListAuditEventsResponse{NextPageToken: resp.Next_Page_Token}
+	for _, event := range resp.Events {
+		res.Events = append(res.Events, entities.AuditEntry{
+			Seq:          event.Seq,
+			Id:           event.Id,
+			Time:         event.Time.AsTime(),
+			Actor:        event.Actor,
+			SourceIp:     event.Source_Ip,
+			RequestId:    event.Request_Id,
+			Operation:    event.Operation,
+			TargetUserId: event.Target_User_Id,
+			Diff:         event.Diff,
+			Outcome:      event.Outcome,
+			// Set for what an admin did while impersonating the target.
+			ImpersonationId: event.Impersonation_Id,
+			PrevHash:        event.Prev_Hash,
+			Hash:            event.Hash,
+		})
+	}
+	return res
+}
+
+func VerifyAuditLogFromProto(resp *proto.VerifyAuditLogResponse) entities.VerifyAuditLogResponse {
+	return entities.VerifyAuditLogResponse{Intact: resp.Intact, Entries: resp.Entries, BrokenAtSeq: resp.Broken_At_Seq}
+}
+
+// AttributesToProto leaves empty attributes out of the request. Attributes
+// decoded from JSON always convert, the error is for types JSON has none of.
+func AttributesToProto(attrs map[string]interface{}) *structpb.Struct {
+	if len(attrs) == 0 {
+		return nil
+	}
+	protoAttrs, _ := structpb.NewStruct(attrs)
+	return protoAttrs
+}
+
+func AttributesFromProto(attrs *structpb.Struct) map[string]interface{} {
+	if attrs == nil {
+		return nil
+	}
+	return attrs.AsMap()
+}
+
+func UpdateUserAttributesFromProto(resp *proto.UpdateUserAttributesResponse) entities.UpdateUserAttributesResponse {
+	return entities.UpdateUserAttributesResponse{
+		Attributes: AttributesFromProto(resp.Attributes),
+		Etag:       resp.Etag,
+	}
+}
+
+func ListUsersFromProto(resp *proto.ListUsersResponse) entities.ListUsersResponse {
+	res := entities.ListUsersResponse{NextPageToken: resp.Next_Page_Token}
+	for _, user := range resp.Users {
+		res.Users = append(res.Users, GetFromProto(user))
+	}
+	return res
+}
+
+func GetAttributeSchemaFromProto(resp *proto.GetAttributeSchemaResponse) entities.GetAttributeSchemaResponse {
+	return entities.GetAttributeSchemaResponse{Schema: resp.Schema, UpdatedAt: resp.Updated_At.AsTime()}
+}
+
+func SetAttributeSchemaFromProto(resp *proto.SetAttributeSchemaResponse) entities.SetAttributeSchemaResponse {
+	return entities.SetAttributeSchemaResponse{Schema: resp.Schema, UpdatedAt: resp.Updated_At.AsTime(), Reindexed: resp.Reindexed}
+}
+
+// timeToProto leaves unset times out of the request.
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func ErasureReceiptFromProto(receipt *proto.ErasureReceipt) entities.ErasureReceipt {
+	return entities.ErasureReceipt{
+		Id:        receipt.Id,
+		UserId:    receipt.User_Id,
+		ErasedAt:  receipt.Erased_At.AsTime(),
+		Erased:    receipt.Erased,
+		Retained:  receipt.Retained,
+		KeyId:     receipt.Key_Id,
+		Signature: receipt.Signature,
+	}
+}
+
+func GroupFromProto(group *proto.Group) entities.Group {
+	return entities.Group{
+		Id:        group.Id,
+		Name:      group.Name,
+		CreatedAt: group.Created_At.AsTime(),
+	}
+}
+
+func GroupMemberFromProto(member *proto.GroupMember) entities.GroupMember {
+	return entities.GroupMember{
+		GroupId:  member.Group_Id,
+		UserId:   member.User_Id,
+		Role:     member.Role,
+		JoinedAt: member.Joined_At.AsTime(),
+	}
+}
+
+func CreateGroupFromProto(resp *proto.CreateGroupResponse) entities.CreateGroupResponse {
+	return entities.CreateGroupResponse{
+		Group:  GroupFromProto(resp.Group),
+		Status: entities.Status{Code: resp.Status.GetCode(), Message: resp.Status.GetMessage()},
+	}
+}
+
+func RenameGroupFromProto(resp *proto.RenameGroupResponse) entities.RenameGroupResponse {
+	return entities.RenameGroupResponse{Group: GroupFromProto(resp.Group)}
+}
+
+func DeleteGroupFromProto(resp *proto.DeleteGroupResponse) entities.DeleteGroupResponse {
+	return entities.DeleteGroupResponse{
+		Status: entities.Status{Code: resp.Status.GetCode(), Message: resp.Status.GetMessage()},
+	}
+}
+
+func ListGroupsFromProto(resp *proto.ListGroupsResponse) entities.ListGroupsResponse {
+	res := entities.ListGroupsResponse{NextPageToken: resp.Next_Page_Token}
+	for _, group := range resp.Groups {
+		res.Groups = append(res.Groups, GroupFromProto(group))
+	}
+	return res
+}
+
+func AddMemberFromProto(resp *proto.AddMemberResponse) entities.AddMemberResponse {
+	return entities.AddMemberResponse{Member: GroupMemberFromProto(resp.Member)}
+}
+
+func RemoveMemberFromProto(resp *proto.RemoveMemberResponse) entities.RemoveMemberResponse {
+	return entities.RemoveMemberResponse{
+		Status: entities.Status{Code: resp.Status.GetCode(), Message: resp.Status.GetMessage()},
+	}
+}
+
+func ListMembersFromProto(resp *proto.ListMembersResponse) entities.ListMembersResponse {
+	res := entities.ListMembersResponse{NextPageToken: resp.Next_Page_Token}
+	for _, member := range resp.Members {
+		res.Members = append(res.Members, GroupMemberFromProto(member))
+	}
+	return res
+}
+
+func ListUserGroupsFromProto(resp *proto.ListUserGroupsResponse) entities.ListUserGroupsResponse {
+	var res entities.ListUserGroupsResponse
+	for _, group := range resp.Groups {
+		res.Groups = append(res.Groups, entities.UserGroup{Group: GroupFromProto(group.Group), Role: group.Role})
+	}
+	return res
+}
+
+// InvitationFromProto leaves AcceptedAt zero on invitations not accepted.
+func InvitationFromProto(invitation *proto.Invitation) entities.Invitation {
+	res := entities.Invitation{
+		Id:        invitation.Id,
+		Email:     invitation.Email,
+		Roles:     invitation.Roles,
+		GroupId:   invitation.Group_Id,
+		Status:    invitation.Status,
+		InvitedBy: invitation.Invited_By,
+		CreatedAt: invitation.Created_At.AsTime(),
+		ExpiresAt: invitation.Expires_At.AsTime(),
+		UserId:    invitation.User_Id,
+	}
+	if invitation.Accepted_At != nil {
+		res.AcceptedAt = invitation.Accepted_At.AsTime()
+	}
+	return res
+}
+
+func InviteUserFromProto(resp *proto.InviteUserResponse) entities.InviteUserResponse {
+	return entities.InviteUserResponse{
+		Invitation: InvitationFromProto(resp.Invitation),
+		Status:     entities.Status{Code: resp.Status.GetCode(), Message: resp.Status.GetMessage()},
+	}
+}
+
+func AcceptInvitationFromProto(resp *proto.AcceptInvitationResponse) entities.AcceptInvitationResponse {
+	return entities.AcceptInvitationResponse{
+		UserId:     resp.User_Id,
+		Roles:      resp.Roles,
+		Invitation: InvitationFromProto(resp.Invitation),
+	}
+}
+
+func ListInvitationsFromProto(resp *proto.ListInvitationsResponse) entities.ListInvitationsResponse {
+	res := entities.ListInvitationsResponse{NextPageToken: resp.Next_Page_Token}
+	for _, invitation := range resp.Invitations {
+		res.Invitations = append(res.Invitations, InvitationFromProto(invitation))
+	}
+	return res
+}
+
+func RevokeInvitationFromProto(resp *proto.RevokeInvitationResponse) entities.RevokeInvitationResponse {
+	return entities.RevokeInvitationResponse{Invitation: InvitationFromProto(resp.Invitation)}
+}
+
+func ResendInvitationFromProto(resp *proto.ResendInvitationResponse) entities.ResendInvitationResponse {
+	return entities.ResendInvitationResponse{Invitation: InvitationFromProto(resp.Invitation)}
+}
+
+func UploadAvatarFromProto(resp *proto.UploadAvatarResponse) entities.UploadAvatarResponse {
+	return entities.UploadAvatarResponse{
+		ContentType: resp.Content_Type,
+		Sizes:       resp.Sizes,
+		Etag:        resp.Etag,
+		UpdatedAt:   resp.Updated_At.AsTime(),
+	}
+}
+
+func GetAvatarFromProto(resp *proto.GetAvatarResponse) entities.GetAvatarResponse {
+	return entities.GetAvatarResponse{
+		Image:       resp.Image,
+		ContentType: resp.Content_Type,
+		Size:        resp.Size,
+		Etag:        resp.Etag,
+		UpdatedAt:   resp.Updated_At.AsTime(),
+		NotModified: resp.Not_Modified,
+	}
+}
+
+func PolicyFromProto(policy *proto.Policy) entities.Policy {
+	return entities.Policy{
+		Kind:        policy.Kind,
+		Version:     policy.Version,
+		Url:         policy.Url,
+		Mandatory:   policy.Mandatory,
+		PublishedAt: policy.Published_At.AsTime(),
+	}
+}
+
+func PoliciesFromProto(policies []*proto.Policy) []entities.Policy {
+	var res []entities.Policy
+	for _, policy := range policies {
+		res = append(res, PolicyFromProto(policy))
+	}
+	return res
+}
+
+// ConsentFromProto leaves WithdrawnAt zero on consents in force.
+func ConsentFromProto(consent *proto.Consent) entities.Consent {
+	res := entities.Consent{
+		UserId:        consent.User_Id,
+		Kind:          consent.Kind,
+		Version:       consent.Version,
+		Source:        consent.Source,
+		AcceptedAt:    consent.Accepted_At.AsTime(),
+		WithdrawnFrom: consent.Withdrawn_From,
+	}
+	if consent.Withdrawn_At != nil {
+		res.WithdrawnAt = consent.Withdrawn_At.AsTime()
+	}
+	return res
+}
+
+func PublishPolicyFromProto(resp *proto.PublishPolicyResponse) entities.PublishPolicyResponse {
+	return entities.PublishPolicyResponse{
+		Policy: PolicyFromProto(resp.Policy),
+		Status: entities.Status{Code: resp.Status.GetCode(), Message: resp.Status.GetMessage()},
+	}
+}
+
+func ListConsentsFromProto(resp *proto.ListConsentsResponse) entities.ListConsentsResponse {
+	res := entities.ListConsentsResponse{Pending: PoliciesFromProto(resp.Pending)}
+	for _, consent := range resp.Consents {
+		res.Consents = append(res.Consents, ConsentFromProto(consent))
+	}
+	return res
+}
+
+func AuthenticateFromProto(resp *proto.AuthenticateResponse) entities.AuthenticateResponse {
+	return entities.AuthenticateResponse{
+		UserId: resp.User_Id,
+		Status: entities.Status{Code: resp.Status.GetCode(), Message: resp.Status.GetMessage()},
+	}
+}
+
+// PreferencesToProto converts a patch decoded from JSON, which always
+// converts.
+func PreferencesToProto(preferences map[string]interface{}) *structpb.Struct {
+	protoPreferences, _ := structpb.NewStruct(preferences)
+	return protoPreferences
+}
+
+func GetPreferencesFromProto(resp *proto.GetPreferencesResponse) entities.GetPreferencesResponse {
+	return entities.GetPreferencesResponse{
+		Preferences: AttributesFromProto(resp.Preferences),
+		Customized:  resp.Customized,
+		UpdatedAt:   timeFromProto(resp.Updated_At),
+	}
+}
+
+func UpdatePreferencesFromProto(resp *proto.UpdatePreferencesResponse) entities.UpdatePreferencesResponse {
+	return entities.UpdatePreferencesResponse{
+		Preferences: AttributesFromProto(resp.Preferences),
+		Customized:  resp.Customized,
+		UpdatedAt:   timeFromProto(resp.Updated_At),
+	}
+}
+
+// timeFromProto leaves times the gRPC service left unset zero.
+func timeFromProto(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+func ImpersonationSessionFromProto(session *proto.ImpersonationSession) entities.ImpersonationSession {
+	if session == nil {
+		return entities.ImpersonationSession{}
+	}
+	return entities.ImpersonationSession{
+		Id:        session.Id,
+		Actor:     session.Actor,
+		UserId:    session.User_Id,
+		Reason:    session.Reason,
+		CreatedAt: timeFromProto(session.Created_At),
+		ExpiresAt: timeFromProto(session.Expires_At),
+		EndedAt:   timeFromProto(session.Ended_At),
+	}
+}
+
+func ImpersonateFromProto(resp *proto.ImpersonateResponse) entities.ImpersonateResponse {
+	return entities.ImpersonateResponse{Token: resp.Token, Session: ImpersonationSessionFromProto(resp.Session)}
+}
+
+func ApiKeyFromProto(key *proto.ApiKey) entities.ApiKey {
+	if key == nil {
+		return entities.ApiKey{}
+	}
+	return entities.ApiKey{
+		Id:         key.Id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedBy:  key.Created_By,
+		CreatedAt:  timeFromProto(key.Created_At),
+		ExpiresAt:  timeFromProto(key.Expires_At),
+		RevokedAt:  timeFromProto(key.Revoked_At),
+		LastUsedAt: timeFromProto(key.Last_Used_At),
+		ReplacedBy: key.Replaced_By,
+	}
+}
+
+func ListApiKeysFromProto(resp *proto.ListApiKeysResponse) entities.ListApiKeysResponse {
+	keys := make([]entities.ApiKey, 0, len(resp.Keys))
+	for _, key := range resp.Keys {
+		keys = append(keys, ApiKeyFromProto(key))
+	}
+	return entities.ListApiKeysResponse{Keys: keys}
+}
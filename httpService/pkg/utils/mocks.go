@@ -2,6 +2,8 @@ package util
 
 import (
 	"context"
+	"io"
+	"io/ioutil"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/timoteoBone/microservice-project/grpcService/pkg/entities"
@@ -37,3 +39,281 @@ func (repo *RepositoryMock) DeleteUser(ctx context.Context, rq entities.DeleteUs
 
 	return response.(entities.DeleteUserResponse), args.Error(1)
 }
+
+func (repo *RepositoryMock) WatchUsers(ctx context.Context, rq entities.WatchRequest, send func(entities.WatchEvent) error) error {
+	args := repo.Mock.Called(ctx, rq)
+
+	for _, event := range args.Get(0).([]entities.WatchEvent) {
+		if err := send(event); err != nil {
+			return err
+		}
+	}
+
+	return args.Error(1)
+}
+
+func (repo *RepositoryMock) BatchCreateUsers(ctx context.Context, rq entities.BatchCreateUsersRequest) (entities.BatchCreateUsersResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.BatchCreateUsersResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) BatchGetUsers(ctx context.Context, rq entities.BatchGetUsersRequest) (entities.BatchGetUsersResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.BatchGetUsersResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) BatchDeleteUsers(ctx context.Context, rq entities.BatchDeleteUsersRequest) (entities.BatchDeleteUsersResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.BatchDeleteUsersResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ImportUsers(ctx context.Context, opts entities.ImportOptions, body io.Reader) (entities.ImportUsersResponse, error) {
+	file, err := ioutil.ReadAll(body)
+	if err != nil {
+		return entities.ImportUsersResponse{}, err
+	}
+	args := repo.Mock.Called(ctx, opts, string(file))
+
+	return args.Get(0).(entities.ImportUsersResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ExportUsers(ctx context.Context, rq entities.ExportUsersRequest, send func(entities.ExportChunk) error) error {
+	args := repo.Mock.Called(ctx, rq)
+
+	for _, chunk := range args.Get(0).([]entities.ExportChunk) {
+		if err := send(chunk); err != nil {
+			return err
+		}
+	}
+
+	return args.Error(1)
+}
+
+func (repo *RepositoryMock) ListAuditEvents(ctx context.Context, rq entities.ListAuditEventsRequest) (entities.ListAuditEventsResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ListAuditEventsResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) VerifyAuditLog(ctx context.Context, rq entities.VerifyAuditLogRequest) (entities.VerifyAuditLogResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.VerifyAuditLogResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ExportUserData(ctx context.Context, rq entities.ExportUserDataRequest) (entities.ExportUserDataResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ExportUserDataResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) EraseUser(ctx context.Context, rq entities.EraseUserRequest) (entities.EraseUserResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.EraseUserResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) CreateGroup(ctx context.Context, rq entities.CreateGroupRequest) (entities.CreateGroupResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.CreateGroupResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) RenameGroup(ctx context.Context, rq entities.RenameGroupRequest) (entities.RenameGroupResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.RenameGroupResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) DeleteGroup(ctx context.Context, rq entities.DeleteGroupRequest) (entities.DeleteGroupResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.DeleteGroupResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ListGroups(ctx context.Context, rq entities.ListGroupsRequest) (entities.ListGroupsResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ListGroupsResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) AddMember(ctx context.Context, rq entities.AddMemberRequest) (entities.AddMemberResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.AddMemberResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) RemoveMember(ctx context.Context, rq entities.RemoveMemberRequest) (entities.RemoveMemberResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.RemoveMemberResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ListMembers(ctx context.Context, rq entities.ListMembersRequest) (entities.ListMembersResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ListMembersResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ListUserGroups(ctx context.Context, rq entities.ListUserGroupsRequest) (entities.ListUserGroupsResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ListUserGroupsResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) InviteUser(ctx context.Context, rq entities.InviteUserRequest) (entities.InviteUserResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.InviteUserResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) AcceptInvitation(ctx context.Context, rq entities.AcceptInvitationRequest) (entities.AcceptInvitationResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.AcceptInvitationResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ListInvitations(ctx context.Context, rq entities.ListInvitationsRequest) (entities.ListInvitationsResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ListInvitationsResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) RevokeInvitation(ctx context.Context, rq entities.RevokeInvitationRequest) (entities.RevokeInvitationResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.RevokeInvitationResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ResendInvitation(ctx context.Context, rq entities.ResendInvitationRequest) (entities.ResendInvitationResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ResendInvitationResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) UpdateUserAttributes(ctx context.Context, rq entities.UpdateUserAttributesRequest) (entities.UpdateUserAttributesResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.UpdateUserAttributesResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ListUsers(ctx context.Context, rq entities.ListUsersRequest) (entities.ListUsersResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ListUsersResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) GetAttributeSchema(ctx context.Context, rq entities.GetAttributeSchemaRequest) (entities.GetAttributeSchemaResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.GetAttributeSchemaResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) SetAttributeSchema(ctx context.Context, rq entities.SetAttributeSchemaRequest) (entities.SetAttributeSchemaResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.SetAttributeSchemaResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) UploadAvatar(ctx context.Context, rq entities.UploadAvatarRequest, body io.Reader) (entities.UploadAvatarResponse, error) {
+	picture, err := ioutil.ReadAll(body)
+	if err != nil {
+		return entities.UploadAvatarResponse{}, err
+	}
+	args := repo.Mock.Called(ctx, rq, string(picture))
+
+	return args.Get(0).(entities.UploadAvatarResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) GetAvatar(ctx context.Context, rq entities.GetAvatarRequest) (entities.GetAvatarResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.GetAvatarResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) PublishPolicy(ctx context.Context, rq entities.PublishPolicyRequest) (entities.PublishPolicyResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.PublishPolicyResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ListPolicies(ctx context.Context, rq entities.ListPoliciesRequest) (entities.ListPoliciesResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ListPoliciesResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) AcceptPolicy(ctx context.Context, rq entities.AcceptPolicyRequest) (entities.AcceptPolicyResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.AcceptPolicyResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ListConsents(ctx context.Context, rq entities.ListConsentsRequest) (entities.ListConsentsResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ListConsentsResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) WithdrawConsent(ctx context.Context, rq entities.WithdrawConsentRequest) (entities.WithdrawConsentResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.WithdrawConsentResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) Authenticate(ctx context.Context, rq entities.AuthenticateRequest) (entities.AuthenticateResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.AuthenticateResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) GetPreferences(ctx context.Context, rq entities.GetPreferencesRequest) (entities.GetPreferencesResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.GetPreferencesResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) UpdatePreferences(ctx context.Context, rq entities.UpdatePreferencesRequest) (entities.UpdatePreferencesResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.UpdatePreferencesResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) Impersonate(ctx context.Context, rq entities.ImpersonateRequest) (entities.ImpersonateResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ImpersonateResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) EndImpersonation(ctx context.Context, rq entities.EndImpersonationRequest) (entities.EndImpersonationResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.EndImpersonationResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) CreateApiKey(ctx context.Context, rq entities.CreateApiKeyRequest) (entities.CreateApiKeyResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.CreateApiKeyResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) ListApiKeys(ctx context.Context, rq entities.ListApiKeysRequest) (entities.ListApiKeysResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.ListApiKeysResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) RevokeApiKey(ctx context.Context, rq entities.RevokeApiKeyRequest) (entities.RevokeApiKeyResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.RevokeApiKeyResponse), args.Error(1)
+}
+
+func (repo *RepositoryMock) RotateApiKey(ctx context.Context, rq entities.RotateApiKeyRequest) (entities.RotateApiKeyResponse, error) {
+	args := repo.Mock.Called(ctx, rq)
+
+	return args.Get(0).(entities.RotateApiKeyResponse), args.Error(1)
+}
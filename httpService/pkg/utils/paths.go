@@ -1,7 +0,0 @@
-package util
-
-const (
-	GetUserPath    string = "/user/{id}"
-	DeleteUserPath string = "/user/{id}"
-	CreateUserPath string = "/user"
-)
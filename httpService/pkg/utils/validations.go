@@ -14,9 +14,12 @@ func ValidateCreateUserRequest(user entities.CreateUserRequest) error {
 	return nil
 }
 
+// ValidateGetUserRequest rejects ids no generator makes before they reach
+// the database.
 func ValidateGetUserRequest(id entities.GetUserRequest) error {
 	if len(id.UserID) < 1 {
 		return errors.NewFieldsMissing()
 	}
-	return nil
+	_, err := entities.ParseID("id", id.UserID)
+	return err
 }